@@ -0,0 +1,63 @@
+// Command anonymize scrubs personal data from a database restored from production so it can be
+// used as a staging dataset. It connects with the same environment variables as the server.
+//
+//	go run ./cmd/anonymize -confirm <database name> [-password <staging password>]
+//
+// The -confirm value must match the name of the connected database, so the command cannot be
+// pointed at the wrong environment by accident. Never run it against production.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"yourapp/internal/app"
+	"yourapp/internal/config"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+)
+
+func main() {
+	confirm := flag.String("confirm", "", "name of the database to anonymize (required)")
+	password := flag.String("password", "staging123", "password every user can log in with afterwards")
+	flag.Parse()
+
+	if *confirm == "" {
+		log.Fatal("Refusing to run without -confirm <database name>")
+	}
+	if len(*password) < 8 {
+		log.Fatal("-password must be at least 8 characters")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+	db, err := app.InitDB(cfg)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	var database string
+	if err := db.Raw("SELECT current_database()").Scan(&database).Error; err != nil {
+		log.Fatal("Failed to read database name:", err)
+	}
+	if database != *confirm {
+		log.Fatalf("Connected to database %q but -confirm is %q; refusing to run", database, *confirm)
+	}
+
+	passwordHash, err := util.HashPassword(*password)
+	if err != nil {
+		log.Fatal("Failed to hash password:", err)
+	}
+
+	log.Printf("🧹 Anonymizing database %s...", database)
+	steps, err := repository.AnonymizePII(context.Background(), db, passwordHash)
+	if err != nil {
+		log.Fatal("Anonymization failed, nothing was changed: ", err)
+	}
+	for _, step := range steps {
+		log.Printf("   %-24s %d rows", step.Table, step.Rows)
+	}
+	log.Printf("✅ Database %s anonymized; every user can now log in with the -password value", database)
+}
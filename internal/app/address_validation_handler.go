@@ -0,0 +1,205 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AddressValidationHandler struct {
+	addressValidationService service.AddressValidationService
+}
+
+func NewAddressValidationHandler(addressValidationService service.AddressValidationService) *AddressValidationHandler {
+	return &AddressValidationHandler{
+		addressValidationService: addressValidationService,
+	}
+}
+
+type ImportRegionsRequest struct {
+	Regions []repository.ReferenceRegion `json:"regions" binding:"required,min=1"`
+}
+
+// ImportRegions handles creating or updating region reference data (admin only)
+// POST /api/v1/admin/regions
+func (h *AddressValidationHandler) ImportRegions(c *gin.Context) {
+	var req ImportRegionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	regions := make([]model.Region, 0, len(req.Regions))
+	for _, item := range req.Regions {
+		regions = append(regions, model.Region{
+			Province:       item.Province,
+			City:           item.City,
+			PostalCodeFrom: item.PostalCodeFrom,
+			PostalCodeTo:   item.PostalCodeTo,
+		})
+	}
+
+	result, err := h.addressValidationService.ImportRegions(c.Request.Context(), regions)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidRegion) {
+			util.BadRequest(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusInternalServerError, "Failed to import regions", gin.H{"error": err.Error()})
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Regions imported successfully", result)
+}
+
+// GetRegions handles listing region reference data (admin only)
+// GET /api/v1/admin/regions
+func (h *AddressValidationHandler) GetRegions(c *gin.Context) {
+	regions, err := h.addressValidationService.GetRegions(c.Request.Context())
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Regions retrieved successfully", regions)
+}
+
+// StartRun handles starting a background validation of every saved address (admin only)
+// POST /api/v1/admin/address-validations/runs
+func (h *AddressValidationHandler) StartRun(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	report, err := h.addressValidationService.StartRun(c.Request.Context(), userID.(string))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAddressValidationRunning):
+			util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		case errors.Is(err, service.ErrNoRegions):
+			util.BadRequest(c, err.Error())
+		default:
+			util.ErrorResponse(c, http.StatusInternalServerError, "Failed to start address validation", gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusAccepted, "Address validation started", report)
+}
+
+// GetRun handles reporting the progress and outcome of an address validation run (admin only)
+// GET /api/v1/admin/address-validations/runs/:id
+func (h *AddressValidationHandler) GetRun(c *gin.Context) {
+	report, err := h.addressValidationService.GetRun(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			util.NotFound(c, "Address validation run not found")
+			return
+		}
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Address validation run retrieved successfully", report)
+}
+
+// GetFlagged handles listing flagged addresses (admin only)
+// GET /api/v1/admin/address-validations?status=pending&issue=unknown_city&page=1&limit=20
+func (h *AddressValidationHandler) GetFlagged(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	validations, total, err := h.addressValidationService.ListFlagged(c.Request.Context(), c.Query("status"), c.Query("issue"), page, limit)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Address validations retrieved successfully", gin.H{
+		"validations": validations,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+	})
+}
+
+// GetSuggestions handles listing the user's flagged addresses with their suggested corrections
+// GET /api/v1/users/me/address-suggestions
+func (h *AddressValidationHandler) GetSuggestions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	suggestions, err := h.addressValidationService.GetSuggestions(c.Request.Context(), userID.(string))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Address suggestions retrieved successfully", suggestions)
+}
+
+// ConfirmSuggestion handles applying a suggested correction to the user's address
+// POST /api/v1/users/me/address-suggestions/:id/confirm
+func (h *AddressValidationHandler) ConfirmSuggestion(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	address, err := h.addressValidationService.ConfirmSuggestion(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		writeAddressSuggestionError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Address updated successfully", address)
+}
+
+// DismissSuggestion handles keeping a flagged address as it is
+// POST /api/v1/users/me/address-suggestions/:id/dismiss
+func (h *AddressValidationHandler) DismissSuggestion(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	validation, err := h.addressValidationService.DismissSuggestion(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		writeAddressSuggestionError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Address suggestion dismissed", validation)
+}
+
+func writeAddressSuggestionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		util.NotFound(c, "Address suggestion not found")
+	case errors.Is(err, service.ErrAddressSuggestionStale), strings.HasPrefix(err.Error(), "suggestion was already"):
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, service.ErrNoAddressSuggestion):
+		util.BadRequest(c, err.Error())
+	default:
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+	}
+}
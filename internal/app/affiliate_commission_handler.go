@@ -0,0 +1,255 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"yourapp/internal/repository"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// affiliateCookieName holds the affiliate code of the last short link the visitor opened; checkout
+// falls back to it when the request carries no affiliate_code
+const affiliateCookieName = "aff"
+
+type AffiliateCommissionHandler struct {
+	commissionService service.AffiliateCommissionService
+	cookieMaxAge      int
+}
+
+func NewAffiliateCommissionHandler(commissionService service.AffiliateCommissionService, cookieDays int) *AffiliateCommissionHandler {
+	return &AffiliateCommissionHandler{
+		commissionService: commissionService,
+		cookieMaxAge:      cookieDays * 24 * 60 * 60,
+	}
+}
+
+// CreateLink handles creating an affiliate short link
+// POST /api/v1/users/me/affiliate-links
+func (h *AffiliateCommissionHandler) CreateLink(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.CreateAffiliateLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	link, err := h.commissionService.CreateLink(c.Request.Context(), userID.(string), req)
+	if err != nil {
+		if err.Error() == "API key not found" || err.Error() == "product not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Affiliate link created successfully", link)
+}
+
+// GetLinks handles listing the current user's affiliate short links with their clicks
+// GET /api/v1/users/me/affiliate-links
+func (h *AffiliateCommissionHandler) GetLinks(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	links, err := h.commissionService.GetLinks(c.Request.Context(), userID.(string))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Affiliate links retrieved successfully", links)
+}
+
+// FollowLink handles an affiliate short link: remembers the affiliate code in a cookie and
+// redirects to the storefront
+// GET /r/:slug
+func (h *AffiliateCommissionHandler) FollowLink(c *gin.Context) {
+	link, err := h.commissionService.ResolveLink(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(affiliateCookieName, link.AffiliateCode, h.cookieMaxAge, "/", "", false, true)
+	c.Redirect(http.StatusFound, link.TargetURL)
+}
+
+// GetEarnings handles the affiliate portal overview: earnings totals and commissions
+// GET /api/v1/users/me/affiliate/earnings?page=1&limit=10&status=pending
+func (h *AffiliateCommissionHandler) GetEarnings(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	status := c.Query("status") // Optional: pending, paid, void
+
+	earnings, err := h.commissionService.GetEarnings(c.Request.Context(), userID.(string), status, page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Affiliate earnings retrieved successfully", earnings)
+}
+
+// GetMyPayouts handles listing the payouts made to the current user as an affiliate
+// GET /api/v1/users/me/affiliate/payouts?page=1&limit=10
+func (h *AffiliateCommissionHandler) GetMyPayouts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	payouts, total, err := h.commissionService.GetPayouts(c.Request.Context(), userID.(string), page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Payouts retrieved successfully", gin.H{
+		"payouts": payouts,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// ListPayouts handles listing affiliate payouts for admins
+// GET /api/v1/admin/affiliate-payouts?page=1&limit=10&status=pending&user_id=...
+func (h *AffiliateCommissionHandler) ListPayouts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	payouts, total, err := h.commissionService.ListPayouts(c.Request.Context(), c.Query("user_id"), c.Query("status"), page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Payouts retrieved successfully", gin.H{
+		"payouts": payouts,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// CreatePayout handles bundling an affiliate's pending commissions into a payout
+// POST /api/v1/admin/affiliate-payouts
+func (h *AffiliateCommissionHandler) CreatePayout(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req struct {
+		UserID string `json:"user_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	payout, err := h.commissionService.CreatePayout(c.Request.Context(), adminID.(string), req.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoPayableCommissions) || errors.Is(err, repository.ErrPayoutBelowMinimum) {
+			util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Payout created successfully", payout)
+}
+
+// MarkPayoutPaid handles recording that a payout was transferred
+// PUT /api/v1/admin/affiliate-payouts/:id/paid
+func (h *AffiliateCommissionHandler) MarkPayoutPaid(c *gin.Context) {
+	var req struct {
+		Reference string `json:"reference" binding:"required,max=100"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	payout, err := h.commissionService.MarkPayoutPaid(c.Request.Context(), c.Param("id"), req.Reference)
+	if err != nil {
+		h.payoutError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Payout marked as paid", payout)
+}
+
+// CancelPayout handles cancelling a pending payout, releasing its commissions
+// PUT /api/v1/admin/affiliate-payouts/:id/cancel
+func (h *AffiliateCommissionHandler) CancelPayout(c *gin.Context) {
+	payout, err := h.commissionService.CancelPayout(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.payoutError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Payout cancelled successfully", payout)
+}
+
+// VoidCommission handles voiding a commission, e.g. for a returned order
+// PUT /api/v1/admin/affiliate-commissions/:id/void
+func (h *AffiliateCommissionHandler) VoidCommission(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	commission, err := h.commissionService.VoidCommission(c.Request.Context(), c.Param("id"), req.Reason)
+	if err != nil {
+		if err.Error() == "commission not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Commission voided successfully", commission)
+}
+
+func (h *AffiliateCommissionHandler) payoutError(c *gin.Context, err error) {
+	if err.Error() == "payout not found" {
+		util.NotFound(c, err.Error())
+		return
+	}
+	if errors.Is(err, repository.ErrPayoutNotPending) {
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		return
+	}
+	util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+}
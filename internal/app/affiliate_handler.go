@@ -0,0 +1,225 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"yourapp/internal/middleware"
+	"yourapp/internal/model"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AffiliateHandler struct {
+	affiliateService service.AffiliateService
+	rateLimiter      *middleware.RateLimiter
+}
+
+func NewAffiliateHandler(affiliateService service.AffiliateService, rateLimiter *middleware.RateLimiter) *AffiliateHandler {
+	return &AffiliateHandler{
+		affiliateService: affiliateService,
+		rateLimiter:      rateLimiter,
+	}
+}
+
+// CreateAPIKey handles creating an affiliate API key for the current user
+// POST /api/v1/users/me/affiliate-keys
+func (h *AffiliateHandler) CreateAPIKey(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req struct {
+		Name string `json:"name" binding:"required,max=100"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	resp, err := h.affiliateService.CreateKey(c.Request.Context(), userID.(string), req.Name)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "API key created. Store it now, it will not be shown again", resp)
+}
+
+// GetAPIKeys handles listing the current user's affiliate API keys
+// GET /api/v1/users/me/affiliate-keys
+func (h *AffiliateHandler) GetAPIKeys(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	keys, err := h.affiliateService.GetKeys(c.Request.Context(), userID.(string))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "API keys retrieved successfully", keys)
+}
+
+// RevokeAPIKey handles revoking an affiliate API key
+// DELETE /api/v1/users/me/affiliate-keys/:id
+func (h *AffiliateHandler) RevokeAPIKey(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.affiliateService.RevokeKey(c.Request.Context(), userID.(string), c.Param("id")); err != nil {
+		util.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "API key revoked successfully", nil)
+}
+
+// GetUsage handles reporting an affiliate API key's daily usage
+// GET /api/v1/users/me/affiliate-keys/:id/usage?from=2024-01-01&to=2024-01-31
+func (h *AffiliateHandler) GetUsage(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	report, err := h.affiliateService.GetUsage(c.Request.Context(), userID.(string), c.Param("id"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		if err.Error() == "API key not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "API usage retrieved successfully", report)
+}
+
+// SetQuota handles changing an affiliate API key's daily quota
+// PUT /api/v1/admin/affiliate-keys/:id/quota
+func (h *AffiliateHandler) SetQuota(c *gin.Context) {
+	var req struct {
+		DailyQuota *int `json:"daily_quota" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	apiKey, err := h.affiliateService.SetQuota(c.Request.Context(), c.Param("id"), *req.DailyQuota)
+	if err != nil {
+		if err.Error() == "API key not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "API quota updated successfully", apiKey)
+}
+
+// GetProducts handles listing active products for affiliates
+// GET /api/v1/affiliate/products?page=1&limit=20&category_id=...&keyword=...
+func (h *AffiliateHandler) GetProducts(c *gin.Context) {
+	apiKey := c.MustGet("affiliateAPIKey").(*model.AffiliateAPIKey)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	resp, err := h.affiliateService.GetProducts(c.Request.Context(), apiKey, service.AffiliateProductQuery{
+		Page:       page,
+		Limit:      limit,
+		CategoryID: c.Query("category_id"),
+		Keyword:    c.Query("keyword"),
+	})
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Products retrieved successfully", resp)
+}
+
+// GetProduct handles getting one active product for affiliates
+// GET /api/v1/affiliate/products/:id
+func (h *AffiliateHandler) GetProduct(c *gin.Context) {
+	apiKey := c.MustGet("affiliateAPIKey").(*model.AffiliateAPIKey)
+
+	product, err := h.affiliateService.GetProduct(c.Request.Context(), apiKey, c.Param("id"))
+	if err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Product retrieved successfully", product)
+}
+
+// GetCategories handles listing active categories for affiliates
+// GET /api/v1/affiliate/categories
+func (h *AffiliateHandler) GetCategories(c *gin.Context) {
+	categories, err := h.affiliateService.GetCategories(c.Request.Context())
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Categories retrieved successfully", categories)
+}
+
+// APIKeyMiddleware authenticates affiliate requests with the X-API-Key header, then applies the
+// per-key burst limit and daily quota
+func (h *AffiliateHandler) APIKeyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader("X-API-Key")
+		if rawKey == "" {
+			util.Unauthorized(c, "X-API-Key header required")
+			c.Abort()
+			return
+		}
+
+		apiKey, err := h.affiliateService.Authenticate(c.Request.Context(), rawKey)
+		if err != nil {
+			util.Unauthorized(c, err.Error())
+			c.Abort()
+			return
+		}
+
+		if !h.rateLimiter.Allow(apiKey.ID) {
+			util.ErrorResponse(c, http.StatusTooManyRequests, "Too many requests, please slow down", nil)
+			c.Abort()
+			return
+		}
+
+		quota, err := h.affiliateService.ConsumeQuota(c.Request.Context(), apiKey)
+		if quota != nil {
+			c.Header("X-RateLimit-Limit", strconv.Itoa(quota.Limit))
+			c.Header("X-RateLimit-Remaining", strconv.FormatInt(quota.Remaining, 10))
+			c.Header("X-RateLimit-Reset", strconv.FormatInt(quota.ResetAt.Unix(), 10))
+		}
+		if err != nil {
+			if errors.Is(err, service.ErrAffiliateQuotaExceeded) {
+				util.ErrorResponse(c, http.StatusTooManyRequests, err.Error(), nil)
+			} else {
+				util.ErrorResponse(c, http.StatusInternalServerError, "Failed to check API quota", nil)
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set("affiliateAPIKey", apiKey)
+		c.Next()
+	}
+}
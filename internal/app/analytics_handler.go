@@ -0,0 +1,37 @@
+package app
+
+import (
+	"net/http"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnalyticsHandler struct {
+	analyticsService service.AnalyticsService
+}
+
+func NewAnalyticsHandler(analyticsService service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: analyticsService,
+	}
+}
+
+// GetProductFunnel handles the seller's per-product funnel report (views → cart → checkout → purchase)
+// GET /api/v1/sellers/me/analytics/products?from=2025-01-01&to=2025-01-31&product_id=...
+func (h *AnalyticsHandler) GetProductFunnel(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	report, err := h.analyticsService.GetProductFunnel(c.Request.Context(), userID.(string), c.Query("from"), c.Query("to"), c.Query("product_id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Product funnel retrieved successfully", report)
+}
@@ -317,3 +317,16 @@ func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// AdminMiddleware restricts access to admin users. Must be chained after AuthMiddleware.
+func (h *AuthHandler) AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userType, _ := c.Get("userType")
+		if userType != "admin" {
+			util.Forbidden(c, "Admin access required")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
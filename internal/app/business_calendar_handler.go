@@ -0,0 +1,80 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BusinessCalendarHandler struct {
+	calendarService service.BusinessCalendarService
+}
+
+func NewBusinessCalendarHandler(calendarService service.BusinessCalendarService) *BusinessCalendarHandler {
+	return &BusinessCalendarHandler{
+		calendarService: calendarService,
+	}
+}
+
+// GetShippingEstimate handles getting the estimated ship date for an order placed now
+// GET /api/v1/checkout/estimate
+func (h *BusinessCalendarHandler) GetShippingEstimate(c *gin.Context) {
+	estimate, err := h.calendarService.GetShippingEstimate(c.Request.Context(), time.Now())
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Shipping estimate retrieved successfully", estimate)
+}
+
+// GetHolidays handles listing the holidays calendar for a year
+// GET /api/v1/holidays?year=2025
+func (h *BusinessCalendarHandler) GetHolidays(c *gin.Context) {
+	year, err := strconv.Atoi(c.DefaultQuery("year", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		util.BadRequest(c, "Invalid year")
+		return
+	}
+
+	holidays, err := h.calendarService.GetHolidays(c.Request.Context(), year)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Holidays retrieved successfully", holidays)
+}
+
+// CreateHoliday handles adding a date to the holidays calendar (admin only)
+// POST /api/v1/admin/holidays
+func (h *BusinessCalendarHandler) CreateHoliday(c *gin.Context) {
+	var req service.CreateHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	holiday, err := h.calendarService.CreateHoliday(c.Request.Context(), req)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Holiday created successfully", holiday)
+}
+
+// DeleteHoliday handles removing a date from the holidays calendar (admin only)
+// DELETE /api/v1/admin/holidays/:id
+func (h *BusinessCalendarHandler) DeleteHoliday(c *gin.Context) {
+	if err := h.calendarService.DeleteHoliday(c.Request.Context(), c.Param("id")); err != nil {
+		util.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Holiday deleted successfully", nil)
+}
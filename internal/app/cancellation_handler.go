@@ -0,0 +1,162 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"yourapp/internal/repository"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CancellationHandler struct {
+	cancellationService service.CancellationRequestService
+}
+
+func NewCancellationHandler(cancellationService service.CancellationRequestService) *CancellationHandler {
+	return &CancellationHandler{
+		cancellationService: cancellationService,
+	}
+}
+
+// RequestCancellation handles a buyer asking to cancel a paid sub-order before it ships
+// POST /api/v1/orders/:id/cancellation-requests
+func (h *CancellationHandler) RequestCancellation(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.CancellationRequestInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	request, err := h.cancellationService.RequestCancellation(c.Request.Context(), userID.(string), c.Param("id"), req)
+	if err != nil {
+		if err.Error() == "order not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Cancellation requested successfully", request)
+}
+
+// GetMyRequests handles listing the current user's cancellation requests
+// GET /api/v1/cancellation-requests?page=1&limit=10&status=requested
+func (h *CancellationHandler) GetMyRequests(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	requests, total, err := h.cancellationService.GetMyRequests(c.Request.Context(), userID.(string), c.Query("status"), page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Cancellation requests retrieved successfully", gin.H{
+		"cancellation_requests": requests,
+		"total":                 total,
+		"page":                  page,
+		"limit":                 limit,
+	})
+}
+
+// GetSellerRequests handles listing cancellation requests for the current seller's sub-orders
+// GET /api/v1/sellers/me/cancellation-requests?page=1&limit=10&status=requested
+func (h *CancellationHandler) GetSellerRequests(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	requests, total, err := h.cancellationService.GetSellerRequests(c.Request.Context(), userID.(string), c.Query("status"), page, limit)
+	if err != nil {
+		if err.Error() == "seller not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Cancellation requests retrieved successfully", gin.H{
+		"cancellation_requests": requests,
+		"total":                 total,
+		"page":                  page,
+		"limit":                 limit,
+	})
+}
+
+// Approve handles the seller accepting a cancellation; the buyer is refunded automatically
+// PUT /api/v1/sellers/me/cancellation-requests/:id/approve
+func (h *CancellationHandler) Approve(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	request, err := h.cancellationService.Approve(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Cancellation approved successfully", request)
+}
+
+// Reject handles the seller declining a cancellation
+// PUT /api/v1/sellers/me/cancellation-requests/:id/reject
+func (h *CancellationHandler) Reject(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req struct {
+		Note string `json:"note" binding:"required,max=1000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	request, err := h.cancellationService.Reject(c.Request.Context(), userID.(string), c.Param("id"), req.Note)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Cancellation rejected", request)
+}
+
+func (h *CancellationHandler) handleError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrInvalidCancellationTransition) || errors.Is(err, repository.ErrSubOrderAlreadyShipped) {
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		return
+	}
+	if err.Error() == "cancellation request not found" {
+		util.NotFound(c, err.Error())
+		return
+	}
+	util.BadRequest(c, err.Error())
+}
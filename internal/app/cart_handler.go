@@ -1,24 +1,36 @@
 package app
 
 import (
+	"context"
+	"io"
 	"net/http"
+	"time"
+	"yourapp/internal/config"
 	"yourapp/internal/service"
 	"yourapp/internal/util"
 
 	"github.com/gin-gonic/gin"
 )
 
+// defaultCartStockHeartbeat is the keep-alive interval used when none is configured
+const defaultCartStockHeartbeat = 20 * time.Second
+
 type CartHandler struct {
 	cartService service.CartService
+
+	streamHeartbeat time.Duration
+	streamMaxAge    time.Duration
 }
 
-func NewCartHandler(cartService service.CartService) *CartHandler {
+func NewCartHandler(cartService service.CartService, cfg *config.Config) *CartHandler {
 	return &CartHandler{
-		cartService: cartService,
+		cartService:     cartService,
+		streamHeartbeat: time.Duration(cfg.CartStockHeartbeatSeconds) * time.Second,
+		streamMaxAge:    time.Duration(cfg.CartStockStreamMaxSeconds) * time.Second,
 	}
 }
 
-// GetCart handles getting user's cart
+// GetCart handles getting user's cart, grouped per seller
 // GET /api/v1/carts
 func (h *CartHandler) GetCart(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
@@ -28,7 +40,7 @@ func (h *CartHandler) GetCart(c *gin.Context) {
 		return
 	}
 
-	cart, err := h.cartService.GetCart(userID.(string))
+	cart, err := h.cartService.GetCart(c.Request.Context(), userID.(string))
 	if err != nil {
 		util.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
 		return
@@ -55,6 +67,9 @@ func (h *CartHandler) AddItemToCart(c *gin.Context) {
 
 	cartItem, err := h.cartService.AddItemToCart(userID.(string), &req)
 	if err != nil {
+		if writeOrderConstraintError(c, err) {
+			return
+		}
 		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
@@ -62,6 +77,30 @@ func (h *CartHandler) AddItemToCart(c *gin.Context) {
 	util.SuccessResponse(c, http.StatusCreated, "Item added to cart successfully", cartItem)
 }
 
+// AddItemsToCart handles adding several items to the cart at once (reorder, add all from wishlist)
+// POST /api/v1/carts/items/bulk
+func (h *CartHandler) AddItemsToCart(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.BulkAddCartItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	resp, err := h.cartService.AddItemsToCart(userID.(string), &req)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "failed to add items to cart", nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Cart items processed", resp)
+}
+
 // UpdateCartItem handles updating cart item quantity
 // PUT /api/v1/carts/items/:id
 func (h *CartHandler) UpdateCartItem(c *gin.Context) {
@@ -86,6 +125,9 @@ func (h *CartHandler) UpdateCartItem(c *gin.Context) {
 
 	cartItem, err := h.cartService.UpdateCartItem(userID.(string), cartItemID, &req)
 	if err != nil {
+		if writeOrderConstraintError(c, err) {
+			return
+		}
 		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
@@ -137,6 +179,242 @@ func (h *CartHandler) ClearCart(c *gin.Context) {
 	util.SuccessResponse(c, http.StatusOK, "Cart cleared successfully", nil)
 }
 
+// SelectCartItem handles including a cart item in checkout or leaving it out
+// PUT /api/v1/carts/items/:id/select
+func (h *CartHandler) SelectCartItem(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.SelectCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	cartItem, err := h.cartService.SelectCartItem(userID.(string), c.Param("id"), &req)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Cart item selection updated successfully", cartItem)
+}
+
+// SelectCartItems handles selecting or deselecting several cart items, or the whole cart
+// PUT /api/v1/carts/items/select
+func (h *CartHandler) SelectCartItems(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.SelectCartItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	cartItems, err := h.cartService.SelectCartItems(userID.(string), &req)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Cart item selection updated successfully", cartItems)
+}
+
+// ValidateCart handles re-checking the cart's prices, stock and availability before checkout
+// POST /api/v1/carts/validate
+func (h *CartHandler) ValidateCart(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	validation, err := h.cartService.ValidateCart(c.Request.Context(), userID.(string))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "failed to validate cart", nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Cart validated successfully", validation)
+}
+
+// SaveForLater handles moving a cart item to the saved-for-later list
+// POST /api/v1/carts/items/:id/save-for-later
+func (h *CartHandler) SaveForLater(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	saved, err := h.cartService.SaveForLater(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Item saved for later successfully", saved)
+}
+
+// GetSavedForLater handles listing the user's saved-for-later items
+// GET /api/v1/carts/saved
+func (h *CartHandler) GetSavedForLater(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	items, err := h.cartService.GetSavedForLater(c.Request.Context(), userID.(string))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "failed to get saved items", nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Saved items retrieved successfully", items)
+}
+
+// MoveToCart handles moving a saved-for-later item back into the cart
+// POST /api/v1/carts/saved/:id/move-to-cart
+func (h *CartHandler) MoveToCart(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	cartItem, err := h.cartService.MoveToCart(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		if writeOrderConstraintError(c, err) {
+			return
+		}
+		if err.Error() == "saved item not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Item moved to cart successfully", cartItem)
+}
+
+// RemoveSavedForLater handles deleting an item from the saved-for-later list
+// DELETE /api/v1/carts/saved/:id
+func (h *CartHandler) RemoveSavedForLater(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.cartService.RemoveSavedForLater(c.Request.Context(), userID.(string), c.Param("id")); err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Saved item removed successfully", nil)
+}
+
+// MoveToWishlist handles moving a cart item to the wishlist; the updated cart is returned so the
+// client can refresh in one round trip
+// POST /api/v1/carts/items/:id/move-to-wishlist
+func (h *CartHandler) MoveToWishlist(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	cart, err := h.cartService.MoveToWishlist(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		switch {
+		case err.Error() == "cart not found" || err.Error() == "cart item not found":
+			util.NotFound(c, err.Error())
+		case err.Error() == "unauthorized":
+			util.Forbidden(c, "You don't have permission to move this item")
+		default:
+			util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		}
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Item moved to wishlist successfully", cart)
+}
+
+// GetWishlist handles listing the user's wishlist
+// GET /api/v1/wishlist
+func (h *CartHandler) GetWishlist(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	items, err := h.cartService.GetWishlist(c.Request.Context(), userID.(string))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "failed to get wishlist", nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Wishlist retrieved successfully", items)
+}
+
+type AddToWishlistRequest struct {
+	ProductID string `json:"product_id" binding:"required"`
+}
+
+// AddToWishlist handles adding a product to the wishlist
+// POST /api/v1/wishlist
+func (h *CartHandler) AddToWishlist(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req AddToWishlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.cartService.AddToWishlist(c.Request.Context(), userID.(string), req.ProductID); err != nil {
+		if err.Error() == "product not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Product added to wishlist successfully", nil)
+}
+
+// RemoveFromWishlist handles deleting an item from the wishlist
+// DELETE /api/v1/wishlist/:id
+func (h *CartHandler) RemoveFromWishlist(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.cartService.RemoveFromWishlist(c.Request.Context(), userID.(string), c.Param("id")); err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Wishlist item removed successfully", nil)
+}
+
 // GetCartItems handles getting all cart items
 // GET /api/v1/carts/items
 func (h *CartHandler) GetCartItems(c *gin.Context) {
@@ -155,3 +433,65 @@ func (h *CartHandler) GetCartItems(c *gin.Context) {
 
 	util.SuccessResponse(c, http.StatusOK, "Cart items retrieved successfully", cartItems)
 }
+
+// GetCartCount handles getting the number of items in the cart, for the cart badge
+// GET /api/v1/carts/count
+func (h *CartHandler) GetCartCount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	count, err := h.cartService.CountCartItems(userID.(string))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Cart count retrieved successfully", count)
+}
+
+// StreamCartStock handles streaming the live stock and price of the cart's items as Server-Sent
+// Events, so the cart screen can warn "only 2 left" without polling. Every "stock" event carries a
+// service.CartStockUpdate; the first one is full. The stream ends after a while and the client
+// reconnects.
+// GET /api/v1/carts/stock/stream
+func (h *CartHandler) StreamCartStock(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	ctx := c.Request.Context()
+	if h.streamMaxAge > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.streamMaxAge)
+		defer cancel()
+	}
+	updates := h.cartService.WatchCartStock(ctx, userID.(string))
+
+	interval := h.streamHeartbeat
+	if interval <= 0 {
+		interval = defaultCartStockHeartbeat
+	}
+	heartbeat := time.NewTicker(interval)
+	defer heartbeat.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Keep nginx from buffering the stream
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return false
+			}
+			c.SSEvent("stock", update)
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+		}
+		return true
+	})
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"net/http"
+	"strconv"
 
 	"yourapp/internal/service"
 	"yourapp/internal/util"
@@ -127,3 +128,39 @@ func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 
 	util.SuccessResponse(c, http.StatusOK, "Category deleted successfully", nil)
 }
+
+// GetDeletedCategories handles listing soft-deleted categories for admins
+// GET /api/v1/admin/categories/trash?page=1&limit=10
+func (h *CategoryHandler) GetDeletedCategories(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	categories, total, err := h.categoryService.GetDeletedCategories(page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Deleted categories retrieved successfully", gin.H{
+		"categories": categories,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+	})
+}
+
+// RestoreCategory handles restoring a soft-deleted category
+// PUT /api/v1/admin/categories/:id/restore
+func (h *CategoryHandler) RestoreCategory(c *gin.Context) {
+	category, err := h.categoryService.RestoreCategory(c.Param("id"))
+	if err != nil {
+		if err.Error() == "category not found in trash" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Category restored successfully", category)
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxConfigBundleSize caps uploaded bundles; reference data is small
+const maxConfigBundleSize = 10 << 20
+
+type ConfigBundleHandler struct {
+	bundleService service.ConfigBundleService
+}
+
+func NewConfigBundleHandler(bundleService service.ConfigBundleService) *ConfigBundleHandler {
+	return &ConfigBundleHandler{
+		bundleService: bundleService,
+	}
+}
+
+// ExportBundle handles downloading the encrypted reference data bundle (admin only)
+// GET /api/v1/admin/config-bundle
+func (h *ConfigBundleHandler) ExportBundle(c *gin.Context) {
+	bundle, err := h.bundleService.Export(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("config-bundle-%s.json", time.Now().Format("20060102-150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/json", bundle)
+}
+
+// ImportBundle handles importing an exported bundle sent as the request body (admin only)
+// POST /api/v1/admin/config-bundle/import?dry_run=true
+func (h *ConfigBundleHandler) ImportBundle(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxConfigBundleSize))
+	if err != nil {
+		util.BadRequest(c, "Bundle is too large or could not be read")
+		return
+	}
+	if len(body) == 0 {
+		util.BadRequest(c, "Bundle is required")
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+	result, err := h.bundleService.Import(c.Request.Context(), body, dryRun)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	message := "Config bundle imported successfully"
+	if dryRun {
+		message = "Config bundle checked successfully (dry run, nothing was changed)"
+	}
+	util.SuccessResponse(c, http.StatusOK, message, result)
+}
+
+func (h *ConfigBundleHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrConfigBundleDisabled):
+		util.ErrorResponse(c, http.StatusServiceUnavailable, err.Error(), nil)
+	case errors.Is(err, service.ErrConfigBundleInvalid):
+		util.BadRequest(c, err.Error())
+	default:
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+	}
+}
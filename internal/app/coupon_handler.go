@@ -0,0 +1,226 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CouponHandler struct {
+	couponService service.CouponService
+}
+
+func NewCouponHandler(couponService service.CouponService) *CouponHandler {
+	return &CouponHandler{
+		couponService: couponService,
+	}
+}
+
+type ApplyVoucherRequest struct {
+	Code string `json:"code" binding:"required,max=50"`
+}
+
+// ApplyVoucher handles applying a voucher code to the cart and previewing its discount
+// POST /api/v1/carts/apply-voucher
+func (h *CouponHandler) ApplyVoucher(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req ApplyVoucherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	preview, err := h.couponService.ApplyToCart(c.Request.Context(), userID.(string), req.Code)
+	if err != nil {
+		if writeCouponError(c, err) {
+			return
+		}
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Voucher applied successfully", preview)
+}
+
+// RemoveVoucher handles removing the voucher from the cart
+// DELETE /api/v1/carts/apply-voucher
+func (h *CouponHandler) RemoveVoucher(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.couponService.RemoveFromCart(c.Request.Context(), userID.(string)); err != nil {
+		var internalErr *service.InternalError
+		if errors.As(err, &internalErr) {
+			util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Voucher removed successfully", nil)
+}
+
+// ListCoupons handles listing vouchers (admin only)
+// GET /api/v1/admin/coupons?page=1&limit=20
+func (h *CouponHandler) ListCoupons(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	coupons, total, err := h.couponService.ListCoupons(c.Request.Context(), page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Coupons retrieved successfully", gin.H{
+		"coupons": coupons,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// CreateCoupon handles adding a voucher (admin only)
+// POST /api/v1/admin/coupons
+func (h *CouponHandler) CreateCoupon(c *gin.Context) {
+	var req service.CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	coupon, err := h.couponService.CreateCoupon(c.Request.Context(), req)
+	if err != nil {
+		writeCouponAdminError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Coupon created successfully", coupon)
+}
+
+// UpdateCoupon handles replacing a voucher, e.g. to extend or deactivate it (admin only)
+// PUT /api/v1/admin/coupons/:id
+func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
+	var req service.CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	coupon, err := h.couponService.UpdateCoupon(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeCouponAdminError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Coupon updated successfully", coupon)
+}
+
+// ListCouponBatches handles listing generated code batches with their redemption rates (admin only)
+// GET /api/v1/admin/coupon-batches?page=1&limit=20
+func (h *CouponHandler) ListCouponBatches(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	batches, total, err := h.couponService.ListBatches(c.Request.Context(), page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Coupon batches retrieved successfully", gin.H{
+		"batches": batches,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// GenerateCouponBatch handles generating unique single-use codes for a campaign (admin only)
+// POST /api/v1/admin/coupon-batches
+func (h *CouponHandler) GenerateCouponBatch(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.CouponBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	batch, err := h.couponService.GenerateBatch(c.Request.Context(), userID.(string), req)
+	if err != nil {
+		writeCouponAdminError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Coupon batch created successfully", batch)
+}
+
+// GetCouponBatch handles getting a code batch with its redemption rate (admin only)
+// GET /api/v1/admin/coupon-batches/:id
+func (h *CouponHandler) GetCouponBatch(c *gin.Context) {
+	batch, err := h.couponService.GetBatch(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeCouponAdminError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Coupon batch retrieved successfully", batch)
+}
+
+// ExportCouponBatch handles downloading a batch's codes as CSV for offline distribution (admin only)
+// GET /api/v1/admin/coupon-batches/:id/export
+func (h *CouponHandler) ExportCouponBatch(c *gin.Context) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "coupon-batch-"+c.Param("id")+".csv"))
+
+	err := h.couponService.ExportBatch(c.Request.Context(), c.Param("id"), c.Writer)
+	if err != nil && !c.Writer.Written() {
+		c.Header("Content-Type", "")
+		c.Header("Content-Disposition", "")
+		writeCouponAdminError(c, err)
+	}
+}
+
+// writeCouponError responds with a rejected voucher and its code, and reports whether err was
+// such an error
+func writeCouponError(c *gin.Context, err error) bool {
+	var couponErr *service.CouponError
+	if !errors.As(err, &couponErr) {
+		return false
+	}
+	util.ErrorResponse(c, http.StatusUnprocessableEntity, couponErr.Message, gin.H{"code": couponErr.Code})
+	return true
+}
+
+func writeCouponAdminError(c *gin.Context, err error) {
+	var internalErr *service.InternalError
+	switch {
+	case err.Error() == "coupon not found", err.Error() == "coupon batch not found":
+		util.NotFound(c, err.Error())
+	case err.Error() == "coupon code already exists":
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+	case errors.As(err, &internalErr):
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+	default:
+		util.BadRequest(c, err.Error())
+	}
+}
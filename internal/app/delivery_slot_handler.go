@@ -0,0 +1,122 @@
+package app
+
+import (
+	"net/http"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeliverySlotHandler struct {
+	deliverySlotService service.DeliverySlotService
+}
+
+func NewDeliverySlotHandler(deliverySlotService service.DeliverySlotService) *DeliverySlotHandler {
+	return &DeliverySlotHandler{
+		deliverySlotService: deliverySlotService,
+	}
+}
+
+// CreateSlot handles adding a weekly delivery window to the current user's shop
+// POST /api/v1/sellers/me/delivery-slots
+func (h *DeliverySlotHandler) CreateSlot(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.DeliverySlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	slot, err := h.deliverySlotService.CreateSlot(c.Request.Context(), userID.(string), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Delivery slot created successfully", slot)
+}
+
+// GetMySlots handles listing the current user's delivery slots, including inactive ones
+// GET /api/v1/sellers/me/delivery-slots
+func (h *DeliverySlotHandler) GetMySlots(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	slots, err := h.deliverySlotService.GetMySlots(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Delivery slots retrieved successfully", slots)
+}
+
+// UpdateSlot handles replacing one of the current user's delivery slots
+// PUT /api/v1/sellers/me/delivery-slots/:id
+func (h *DeliverySlotHandler) UpdateSlot(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.DeliverySlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	slot, err := h.deliverySlotService.UpdateSlot(c.Request.Context(), userID.(string), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Delivery slot updated successfully", slot)
+}
+
+// DeleteSlot handles removing one of the current user's delivery slots
+// DELETE /api/v1/sellers/me/delivery-slots/:id
+func (h *DeliverySlotHandler) DeleteSlot(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.deliverySlotService.DeleteSlot(c.Request.Context(), userID.(string), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Delivery slot deleted successfully", nil)
+}
+
+// GetAvailableSlots handles listing a seller's bookable delivery slots for the next two weeks
+// GET /api/v1/sellers/:id/delivery-slots?from=2024-01-15
+func (h *DeliverySlotHandler) GetAvailableSlots(c *gin.Context) {
+	slots, err := h.deliverySlotService.GetAvailableSlots(c.Request.Context(), c.Param("id"), c.Query("from"))
+	if err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Delivery slots retrieved successfully", slots)
+}
+
+func (h *DeliverySlotHandler) handleError(c *gin.Context, err error) {
+	if err.Error() == "delivery slot not found" || err.Error() == "seller not found" {
+		util.NotFound(c, err.Error())
+		return
+	}
+	util.BadRequest(c, err.Error())
+}
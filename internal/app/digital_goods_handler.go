@@ -0,0 +1,103 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DigitalGoodsHandler struct {
+	digitalGoodsService service.DigitalGoodsService
+}
+
+func NewDigitalGoodsHandler(digitalGoodsService service.DigitalGoodsService) *DigitalGoodsHandler {
+	return &DigitalGoodsHandler{
+		digitalGoodsService: digitalGoodsService,
+	}
+}
+
+// GetOrderDownloads handles listing the download links and license keys of a paid order
+// GET /api/v1/orders/:id/downloads
+func (h *DigitalGoodsHandler) GetOrderDownloads(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	downloads, err := h.digitalGoodsService.GetDownloads(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		var internalErr *service.InternalError
+		switch {
+		case errors.Is(err, service.ErrDownloadOrderNotFound):
+			util.NotFound(c, err.Error())
+		case errors.As(err, &internalErr):
+			util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		default:
+			util.BadRequest(c, err.Error())
+		}
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Downloads retrieved successfully", downloads)
+}
+
+// Download handles a signed download link by streaming the file
+// GET /api/v1/downloads/:token
+func (h *DigitalGoodsHandler) Download(c *gin.Context) {
+	file, err := h.digitalGoodsService.OpenDownload(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		if errors.Is(err, service.ErrDownloadNotFound) {
+			util.NotFound(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrDownloadFetchFailed) {
+			util.ErrorResponse(c, http.StatusBadGateway, err.Error(), nil)
+			return
+		}
+		util.ErrorResponse(c, http.StatusForbidden, err.Error(), nil)
+		return
+	}
+	defer file.Body.Close()
+
+	c.Header("Cache-Control", "private, no-store")
+	c.DataFromReader(http.StatusOK, file.ContentLength, file.ContentType, file.Body, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", file.FileName),
+	})
+}
+
+// AddLicenseKeys handles adding keys to the license key pool of one of the current user's products
+// POST /api/v1/products/:id/license-keys
+func (h *DigitalGoodsHandler) AddLicenseKeys(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.AddLicenseKeysRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	pool, err := h.digitalGoodsService.AddLicenseKeys(c.Request.Context(), userID.(string), c.Param("id"), req)
+	if err != nil {
+		var internalErr *service.InternalError
+		switch {
+		case errors.Is(err, service.ErrLicenseProductNotFound), errors.Is(err, service.ErrLicenseSellerNotFound):
+			util.NotFound(c, err.Error())
+		case errors.As(err, &internalErr):
+			util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		default:
+			util.BadRequest(c, err.Error())
+		}
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "License keys added successfully", pool)
+}
@@ -0,0 +1,389 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"yourapp/internal/config"
+	"yourapp/internal/repository"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DisputeHandler struct {
+	disputeService   service.DisputeService
+	cloudinaryUpload *util.CloudinaryUploader
+}
+
+func NewDisputeHandler(disputeService service.DisputeService, cfg *config.Config) *DisputeHandler {
+	var uploader *util.CloudinaryUploader
+	if cfg.CloudinaryCloudName != "" && cfg.CloudinaryAPIKey != "" && cfg.CloudinaryAPISecret != "" {
+		uploader = util.NewCloudinaryUploader(cfg.CloudinaryCloudName, cfg.CloudinaryAPIKey, cfg.CloudinaryAPISecret)
+	}
+
+	return &DisputeHandler{
+		disputeService:   disputeService,
+		cloudinaryUpload: uploader,
+	}
+}
+
+// OpenDispute handles a buyer opening a dispute about a sub-order
+// POST /api/v1/orders/:id/disputes (multipart form: seller_order_id, reason, photos[])
+func (h *DisputeHandler) OpenDispute(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.OpenDisputeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	var photoURLs []string
+	form, err := c.MultipartForm()
+	if err == nil && len(form.File["photos"]) > 0 {
+		if h.cloudinaryUpload == nil {
+			util.ErrorResponse(c, http.StatusInternalServerError, "Cloudinary is not configured", nil)
+			return
+		}
+
+		// Validate MIME type
+		allowedMIMETypes := map[string]bool{
+			"image/jpeg": true,
+			"image/jpg":  true,
+			"image/png":  true,
+			"image/webp": true,
+		}
+		mimeMap := map[string]string{
+			".jpg":  "image/jpeg",
+			".jpeg": "image/jpeg",
+			".png":  "image/png",
+			".webp": "image/webp",
+		}
+
+		files := form.File["photos"]
+		if len(files) > 5 {
+			util.BadRequest(c, "At most 5 photos can be attached")
+			return
+		}
+		for _, fileHeader := range files {
+			contentType := fileHeader.Header.Get("Content-Type")
+			if contentType == "" {
+				contentType = mimeMap[strings.ToLower(filepath.Ext(fileHeader.Filename))]
+			}
+			if !allowedMIMETypes[contentType] {
+				util.BadRequest(c, "Invalid image format. Allowed: JPEG, PNG, WEBP")
+				return
+			}
+			if fileHeader.Size > 5<<20 {
+				util.BadRequest(c, "Photo exceeds 5MB limit")
+				return
+			}
+
+			file, err := fileHeader.Open()
+			if err != nil {
+				util.BadRequest(c, "Failed to open file: "+err.Error())
+				return
+			}
+			fileData, err := io.ReadAll(file)
+			file.Close()
+			if err != nil {
+				util.BadRequest(c, "Failed to read file: "+err.Error())
+				return
+			}
+
+			url, err := h.cloudinaryUpload.UploadImage(fileData, fileHeader.Filename, fmt.Sprintf("disputes/%s", req.SellerOrderID))
+			if err != nil {
+				util.ErrorResponse(c, http.StatusInternalServerError, "Failed to upload photo: "+err.Error(), nil)
+				return
+			}
+			photoURLs = append(photoURLs, url)
+		}
+	}
+
+	dispute, err := h.disputeService.OpenDispute(c.Request.Context(), userID.(string), c.Param("id"), req, photoURLs)
+	if err != nil {
+		if err.Error() == "order not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrDisputeWindowClosed) {
+			util.ErrorResponse(c, http.StatusUnprocessableEntity, err.Error(), nil)
+			return
+		}
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Dispute opened successfully", dispute)
+}
+
+// GetMyDisputes handles listing the current user's disputes as a buyer
+// GET /api/v1/disputes?page=1&limit=10&status=open
+func (h *DisputeHandler) GetMyDisputes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	disputes, total, err := h.disputeService.GetMyDisputes(c.Request.Context(), userID.(string), c.Query("status"), page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Disputes retrieved successfully", gin.H{
+		"disputes": disputes,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+// GetSellerDisputes handles listing disputes against the current user's shop
+// GET /api/v1/sellers/me/disputes?page=1&limit=10&status=open
+func (h *DisputeHandler) GetSellerDisputes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	disputes, total, err := h.disputeService.GetSellerDisputes(c.Request.Context(), userID.(string), c.Query("status"), page, limit)
+	if err != nil {
+		if err.Error() == "seller not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Disputes retrieved successfully", gin.H{
+		"disputes": disputes,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+// GetDispute handles getting a dispute with its message thread, for its buyer or seller
+// GET /api/v1/disputes/:id
+func (h *DisputeHandler) GetDispute(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	dispute, err := h.disputeService.GetDispute(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Dispute retrieved successfully", dispute)
+}
+
+// AddMessage handles the buyer or seller posting to a dispute's thread
+// POST /api/v1/disputes/:id/messages
+func (h *DisputeHandler) AddMessage(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req struct {
+		Message string `json:"message" binding:"required,max=2000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	message, err := h.disputeService.AddMessage(c.Request.Context(), userID.(string), c.Param("id"), req.Message)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Message added successfully", message)
+}
+
+// Respond handles the seller answering a dispute
+// PUT /api/v1/sellers/me/disputes/:id/respond
+func (h *DisputeHandler) Respond(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req struct {
+		Message string `json:"message" binding:"required,max=2000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	dispute, err := h.disputeService.Respond(c.Request.Context(), userID.(string), c.Param("id"), req.Message)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Response sent successfully", dispute)
+}
+
+// Escalate handles the buyer or seller asking an admin to arbitrate
+// PUT /api/v1/disputes/:id/escalate
+func (h *DisputeHandler) Escalate(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	dispute, err := h.disputeService.Escalate(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Dispute escalated to the marketplace team", dispute)
+}
+
+// Withdraw handles the buyer dropping a dispute
+// PUT /api/v1/disputes/:id/withdraw
+func (h *DisputeHandler) Withdraw(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	dispute, err := h.disputeService.Withdraw(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Dispute withdrawn successfully", dispute)
+}
+
+// AdminListDisputes handles listing every dispute for arbitration
+// GET /api/v1/admin/disputes?page=1&limit=10&status=under_review
+func (h *DisputeHandler) AdminListDisputes(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	disputes, total, err := h.disputeService.ListDisputes(c.Request.Context(), c.Query("status"), page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Disputes retrieved successfully", gin.H{
+		"disputes": disputes,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+// AdminGetDispute handles getting any dispute with its message thread
+// GET /api/v1/admin/disputes/:id
+func (h *DisputeHandler) AdminGetDispute(c *gin.Context) {
+	dispute, err := h.disputeService.GetDisputeForAdmin(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Dispute retrieved successfully", dispute)
+}
+
+// AdminAddMessage handles an admin posting to a dispute's thread
+// POST /api/v1/admin/disputes/:id/messages
+func (h *DisputeHandler) AdminAddMessage(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req struct {
+		Message string `json:"message" binding:"required,max=2000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	message, err := h.disputeService.AddAdminMessage(c.Request.Context(), adminID.(string), c.Param("id"), req.Message)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Message added successfully", message)
+}
+
+// AdminResolveDispute handles an admin deciding a dispute, optionally refunding the buyer.
+// Calling it again retries a refund that failed.
+// PUT /api/v1/admin/disputes/:id/resolve
+func (h *DisputeHandler) AdminResolveDispute(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.ResolveDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	dispute, err := h.disputeService.Resolve(c.Request.Context(), adminID.(string), c.Param("id"), req)
+	if err != nil {
+		if errors.Is(err, service.ErrRefundFailed) {
+			util.ErrorResponse(c, http.StatusBadGateway, err.Error(), nil)
+			return
+		}
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Dispute resolved successfully", dispute)
+}
+
+func (h *DisputeHandler) handleError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrInvalidDisputeTransition) {
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		return
+	}
+	if err.Error() == "dispute not found" {
+		util.NotFound(c, err.Error())
+		return
+	}
+	util.BadRequest(c, err.Error())
+}
@@ -0,0 +1,178 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"yourapp/internal/repository"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DonationHandler struct {
+	donationService service.DonationService
+}
+
+func NewDonationHandler(donationService service.DonationService) *DonationHandler {
+	return &DonationHandler{
+		donationService: donationService,
+	}
+}
+
+// GetActiveCause handles getting the cause checkout offers donations to
+// GET /api/v1/donations/cause
+func (h *DonationHandler) GetActiveCause(c *gin.Context) {
+	cause, err := h.donationService.GetActiveCause(c.Request.Context())
+	if err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Donation cause retrieved successfully", cause)
+}
+
+// ListCauses handles listing donation causes for admins, newest first
+// GET /api/v1/admin/donation-causes?page=1&limit=10
+func (h *DonationHandler) ListCauses(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	causes, total, err := h.donationService.GetCauses(c.Request.Context(), page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Donation causes retrieved successfully", gin.H{
+		"causes": causes,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// CreateCause handles creating a donation cause
+// POST /api/v1/admin/donation-causes
+func (h *DonationHandler) CreateCause(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.DonationCauseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	cause, err := h.donationService.CreateCause(c.Request.Context(), adminID.(string), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Donation cause created successfully", cause)
+}
+
+// GetCause handles getting a donation cause
+// GET /api/v1/admin/donation-causes/:id
+func (h *DonationHandler) GetCause(c *gin.Context) {
+	cause, err := h.donationService.GetCause(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Donation cause retrieved successfully", cause)
+}
+
+// UpdateCause handles updating a donation cause, including opening or closing it for donations
+// PUT /api/v1/admin/donation-causes/:id
+func (h *DonationHandler) UpdateCause(c *gin.Context) {
+	var req service.DonationCauseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	cause, err := h.donationService.UpdateCause(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Donation cause updated successfully", cause)
+}
+
+// GetLedger handles listing a donation cause's donations and disbursements, newest first
+// GET /api/v1/admin/donation-causes/:id/ledger?page=1&limit=10
+func (h *DonationHandler) GetLedger(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	entries, total, err := h.donationService.GetLedger(c.Request.Context(), c.Param("id"), page, limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Donation ledger retrieved successfully", gin.H{
+		"entries": entries,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// RecordDisbursement handles recording money handed over to a donation cause
+// POST /api/v1/admin/donation-causes/:id/disbursements
+func (h *DonationHandler) RecordDisbursement(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.DonationDisbursementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	entry, err := h.donationService.RecordDisbursement(c.Request.Context(), adminID.(string), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Disbursement recorded successfully", entry)
+}
+
+// GetSummary handles the donation totals per cause for admin analytics
+// GET /api/v1/admin/analytics/donations?from=2024-01-01&to=2024-01-31
+func (h *DonationHandler) GetSummary(c *gin.Context) {
+	summary, err := h.donationService.GetSummary(c.Request.Context(), c.Query("from"), c.Query("to"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Donation summary retrieved successfully", summary)
+}
+
+func (h *DonationHandler) handleError(c *gin.Context, err error) {
+	var internalErr *service.InternalError
+	switch {
+	case errors.Is(err, repository.ErrDisbursementExceedsBalance):
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+	case err.Error() == "donation cause not found":
+		util.NotFound(c, err.Error())
+	case errors.As(err, &internalErr):
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+	default:
+		util.BadRequest(c, err.Error())
+	}
+}
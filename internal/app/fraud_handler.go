@@ -0,0 +1,124 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FraudHandler struct {
+	fraudService service.FraudService
+}
+
+func NewFraudHandler(fraudService service.FraudService) *FraudHandler {
+	return &FraudHandler{
+		fraudService: fraudService,
+	}
+}
+
+// GetRules handles listing all fraud rules, active or not (admin only)
+// GET /api/v1/admin/fraud-rules
+func (h *FraudHandler) GetRules(c *gin.Context) {
+	rules, err := h.fraudService.GetRules(c.Request.Context())
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Fraud rules retrieved successfully", rules)
+}
+
+// CreateRule handles adding a checkout fraud rule (admin only)
+// POST /api/v1/admin/fraud-rules
+func (h *FraudHandler) CreateRule(c *gin.Context) {
+	var req service.FraudRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	rule, err := h.fraudService.CreateRule(c.Request.Context(), req)
+	if err != nil {
+		writeFraudRuleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Fraud rule created successfully", rule)
+}
+
+// UpdateRule handles replacing a fraud rule, e.g. to change its limits or disable it (admin only)
+// PUT /api/v1/admin/fraud-rules/:id
+func (h *FraudHandler) UpdateRule(c *gin.Context) {
+	var req service.FraudRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	rule, err := h.fraudService.UpdateRule(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeFraudRuleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Fraud rule updated successfully", rule)
+}
+
+// DeleteRule handles removing a fraud rule (admin only)
+// DELETE /api/v1/admin/fraud-rules/:id
+func (h *FraudHandler) DeleteRule(c *gin.Context) {
+	if err := h.fraudService.DeleteRule(c.Request.Context(), c.Param("id")); err != nil {
+		writeFraudRuleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Fraud rule deleted successfully", nil)
+}
+
+// GetBlockedAttempts handles the audit of checkouts and payments blocked by fraud rules (admin only)
+// GET /api/v1/admin/fraud-blocks?page=1&limit=20&stage=order&user_id=...&rule_id=...&from=2024-01-01&to=2024-01-31
+func (h *FraudHandler) GetBlockedAttempts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	query := service.FraudBlockQuery{
+		Stage:  c.Query("stage"),   // Optional: order, payment
+		UserID: c.Query("user_id"), // Optional
+		RuleID: c.Query("rule_id"), // Optional
+		From:   c.Query("from"),    // Optional: YYYY-MM-DD
+		To:     c.Query("to"),      // Optional: YYYY-MM-DD
+	}
+
+	attempts, total, err := h.fraudService.GetBlockedAttempts(c.Request.Context(), query, page, limit)
+	if err != nil {
+		var dateRangeErr *service.DateRangeError
+		if errors.As(err, &dateRangeErr) {
+			util.BadRequest(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Blocked attempts retrieved successfully", gin.H{
+		"attempts": attempts,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+func writeFraudRuleError(c *gin.Context, err error) {
+	var internalErr *service.InternalError
+	switch {
+	case err.Error() == "fraud rule not found":
+		util.NotFound(c, err.Error())
+	case errors.As(err, &internalErr):
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+	default:
+		util.BadRequest(c, err.Error())
+	}
+}
@@ -0,0 +1,164 @@
+package app
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fulfillmentCallbackMaxBody caps the size of a fulfillment callback body
+const fulfillmentCallbackMaxBody = 64 << 10
+
+type FulfillmentHandler struct {
+	fulfillmentService service.FulfillmentService
+}
+
+func NewFulfillmentHandler(fulfillmentService service.FulfillmentService) *FulfillmentHandler {
+	return &FulfillmentHandler{
+		fulfillmentService: fulfillmentService,
+	}
+}
+
+// GetReadyOrders handles pulling paid sub-orders the warehouse has not acknowledged yet
+// GET /api/v1/partner/fulfillment/orders?limit=50
+func (h *FulfillmentHandler) GetReadyOrders(c *gin.Context) {
+	apiKey := c.MustGet("partnerAPIKey").(*model.PartnerAPIKey)
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	orders, err := h.fulfillmentService.GetReadyOrders(c.Request.Context(), apiKey, limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Orders retrieved successfully", orders)
+}
+
+// Acknowledge handles the warehouse taking a sub-order over
+// POST /api/v1/partner/fulfillment/orders/:id/acknowledge
+func (h *FulfillmentHandler) Acknowledge(c *gin.Context) {
+	apiKey := c.MustGet("partnerAPIKey").(*model.PartnerAPIKey)
+
+	var req struct {
+		Reference string `json:"reference" binding:"required,max=100"` // The warehouse's own ID for the sub-order
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	order, err := h.fulfillmentService.Acknowledge(c.Request.Context(), apiKey, c.Param("id"), req.Reference)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Order acknowledged successfully", order)
+}
+
+// PushShipment handles the warehouse handing a sub-order to the courier
+// POST /api/v1/partner/fulfillment/orders/:id/shipment
+func (h *FulfillmentHandler) PushShipment(c *gin.Context) {
+	apiKey := c.MustGet("partnerAPIKey").(*model.PartnerAPIKey)
+
+	var req service.FulfillmentShipmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	order, err := h.fulfillmentService.PushShipment(c.Request.Context(), apiKey, c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Shipment recorded successfully", order)
+}
+
+// Reconcile handles comparing the warehouse's sub-order states with the marketplace's
+// POST /api/v1/partner/fulfillment/reconcile
+func (h *FulfillmentHandler) Reconcile(c *gin.Context) {
+	apiKey := c.MustGet("partnerAPIKey").(*model.PartnerAPIKey)
+
+	var req service.FulfillmentReconcileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	resp, err := h.fulfillmentService.Reconcile(c.Request.Context(), apiKey, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Orders reconciled", resp)
+}
+
+// Callback handles a signed acknowledgement or shipment pushed by the warehouse
+// POST /api/v1/partner/fulfillment/callbacks/:keyId
+func (h *FulfillmentHandler) Callback(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, fulfillmentCallbackMaxBody))
+	if err != nil {
+		util.BadRequest(c, "failed to read callback body")
+		return
+	}
+
+	order, err := h.fulfillmentService.HandleCallback(c.Request.Context(), c.Param("keyId"),
+		c.GetHeader("X-Callback-Timestamp"), c.GetHeader("X-Callback-Signature"), body)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCallbackSignature) {
+			util.Unauthorized(c, err.Error())
+			return
+		}
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Callback processed", order)
+}
+
+// RotateCallbackSecret handles issuing a new fulfillment callback secret for an API key
+// POST /api/v1/sellers/me/api-keys/:id/callback-secret
+func (h *FulfillmentHandler) RotateCallbackSecret(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	secret, err := h.fulfillmentService.RotateCallbackSecret(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Callback secret issued. Store it now, it will not be shown again", gin.H{
+		"callback_secret": secret,
+	})
+}
+
+func (h *FulfillmentHandler) handleError(c *gin.Context, err error) {
+	var internalErr *service.InternalError
+	switch {
+	case errors.Is(err, service.ErrFulfillmentLiveOnly):
+		util.Forbidden(c, err.Error())
+	case errors.Is(err, repository.ErrFulfillmentAcknowledged), errors.Is(err, repository.ErrInvalidSellerOrderTransition):
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+	case err.Error() == "order not found", err.Error() == "API key not found", err.Error() == "seller not found":
+		util.NotFound(c, err.Error())
+	case errors.As(err, &internalErr):
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+	default:
+		util.BadRequest(c, err.Error())
+	}
+}
@@ -0,0 +1,287 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"yourapp/internal/config"
+	"yourapp/internal/repository"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InsuranceClaimHandler struct {
+	claimService     service.InsuranceClaimService
+	cloudinaryUpload *util.CloudinaryUploader
+}
+
+func NewInsuranceClaimHandler(claimService service.InsuranceClaimService, cfg *config.Config) *InsuranceClaimHandler {
+	var uploader *util.CloudinaryUploader
+	if cfg.CloudinaryCloudName != "" && cfg.CloudinaryAPIKey != "" && cfg.CloudinaryAPISecret != "" {
+		uploader = util.NewCloudinaryUploader(cfg.CloudinaryCloudName, cfg.CloudinaryAPIKey, cfg.CloudinaryAPISecret)
+	}
+
+	return &InsuranceClaimHandler{
+		claimService:     claimService,
+		cloudinaryUpload: uploader,
+	}
+}
+
+// FileClaim handles the buyer or seller of an insured order claiming a lost or damaged shipment
+// POST /api/v1/insurance-claims (multipart form: seller_order_id, type, description, claim_amount, photos[])
+func (h *InsuranceClaimHandler) FileClaim(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.FileInsuranceClaimRequest
+	if err := c.ShouldBind(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	photoURLs, ok := h.uploadPhotos(c, fmt.Sprintf("insurance-claims/%s", req.SellerOrderID))
+	if !ok {
+		return
+	}
+
+	claim, err := h.claimService.FileClaim(c.Request.Context(), userID.(string), req, photoURLs)
+	if err != nil {
+		if errors.Is(err, service.ErrInsuranceClaimsDisabled) {
+			util.ErrorResponse(c, http.StatusForbidden, err.Error(), nil)
+			return
+		}
+		if err.Error() == "order not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Insurance claim filed successfully", claim)
+}
+
+// GetMyClaims handles listing insurance claims on the current user's orders as a buyer
+// GET /api/v1/insurance-claims?page=1&limit=10&status=submitted
+func (h *InsuranceClaimHandler) GetMyClaims(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	claims, total, err := h.claimService.GetMyClaims(c.Request.Context(), userID.(string), c.Query("status"), page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Insurance claims retrieved successfully", gin.H{
+		"claims": claims,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// GetSellerClaims handles listing insurance claims on the current user's shop's orders
+// GET /api/v1/sellers/me/insurance-claims?page=1&limit=10&status=submitted
+func (h *InsuranceClaimHandler) GetSellerClaims(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	claims, total, err := h.claimService.GetSellerClaims(c.Request.Context(), userID.(string), c.Query("status"), page, limit)
+	if err != nil {
+		if err.Error() == "seller not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Insurance claims retrieved successfully", gin.H{
+		"claims": claims,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// GetClaim handles getting an insurance claim, for the buyer or seller of its order
+// GET /api/v1/insurance-claims/:id
+func (h *InsuranceClaimHandler) GetClaim(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	claim, err := h.claimService.GetClaim(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Insurance claim retrieved successfully", claim)
+}
+
+// AdminListClaims handles listing every insurance claim for adjudication
+// GET /api/v1/admin/insurance-claims?page=1&limit=10&status=submitted
+func (h *InsuranceClaimHandler) AdminListClaims(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	claims, total, err := h.claimService.ListClaims(c.Request.Context(), c.Query("status"), page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Insurance claims retrieved successfully", gin.H{
+		"claims": claims,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// AdminGetClaim handles getting any insurance claim with its evidence
+// GET /api/v1/admin/insurance-claims/:id
+func (h *InsuranceClaimHandler) AdminGetClaim(c *gin.Context) {
+	claim, err := h.claimService.GetClaimForAdmin(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Insurance claim retrieved successfully", claim)
+}
+
+// AdminDecideClaim handles an admin approving (with a payout) or rejecting an insurance claim.
+// Calling it again retries a refund that failed.
+// PUT /api/v1/admin/insurance-claims/:id/decide
+func (h *InsuranceClaimHandler) AdminDecideClaim(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.DecideInsuranceClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	claim, err := h.claimService.Decide(c.Request.Context(), adminID.(string), c.Param("id"), req)
+	if err != nil {
+		if errors.Is(err, service.ErrRefundFailed) {
+			util.ErrorResponse(c, http.StatusBadGateway, err.Error(), nil)
+			return
+		}
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Insurance claim decided successfully", claim)
+}
+
+// uploadPhotos uploads the evidence photos of a claim form. It writes the error response itself
+// and returns false when the request should stop.
+func (h *InsuranceClaimHandler) uploadPhotos(c *gin.Context, folder string) ([]string, bool) {
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["photos"]) == 0 {
+		return nil, true
+	}
+	if h.cloudinaryUpload == nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "Cloudinary is not configured", nil)
+		return nil, false
+	}
+
+	// Validate MIME type
+	allowedMIMETypes := map[string]bool{
+		"image/jpeg": true,
+		"image/jpg":  true,
+		"image/png":  true,
+		"image/webp": true,
+	}
+	mimeMap := map[string]string{
+		".jpg":  "image/jpeg",
+		".jpeg": "image/jpeg",
+		".png":  "image/png",
+		".webp": "image/webp",
+	}
+
+	files := form.File["photos"]
+	if len(files) > 5 {
+		util.BadRequest(c, "At most 5 photos can be attached")
+		return nil, false
+	}
+	var photoURLs []string
+	for _, fileHeader := range files {
+		contentType := fileHeader.Header.Get("Content-Type")
+		if contentType == "" {
+			contentType = mimeMap[strings.ToLower(filepath.Ext(fileHeader.Filename))]
+		}
+		if !allowedMIMETypes[contentType] {
+			util.BadRequest(c, "Invalid image format. Allowed: JPEG, PNG, WEBP")
+			return nil, false
+		}
+		if fileHeader.Size > 5<<20 {
+			util.BadRequest(c, "Photo exceeds 5MB limit")
+			return nil, false
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			util.BadRequest(c, "Failed to open file: "+err.Error())
+			return nil, false
+		}
+		fileData, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			util.BadRequest(c, "Failed to read file: "+err.Error())
+			return nil, false
+		}
+
+		url, err := h.cloudinaryUpload.UploadImage(fileData, fileHeader.Filename, folder)
+		if err != nil {
+			util.ErrorResponse(c, http.StatusInternalServerError, "Failed to upload photo: "+err.Error(), nil)
+			return nil, false
+		}
+		photoURLs = append(photoURLs, url)
+	}
+	return photoURLs, true
+}
+
+func (h *InsuranceClaimHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrInvalidClaimTransition):
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+	case err.Error() == "insurance claim not found":
+		util.NotFound(c, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+	default:
+		util.BadRequest(c, err.Error())
+	}
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MetricsHandler struct {
+	timeoutMetrics   *util.TimeoutMetrics
+	retentionService service.RetentionService
+}
+
+func NewMetricsHandler(timeoutMetrics *util.TimeoutMetrics, retentionService service.RetentionService) *MetricsHandler {
+	return &MetricsHandler{
+		timeoutMetrics:   timeoutMetrics,
+		retentionService: retentionService,
+	}
+}
+
+// GetTimeoutMetrics handles reporting request and Midtrans call timeouts since startup
+// GET /api/v1/admin/metrics/timeouts
+func (h *MetricsHandler) GetTimeoutMetrics(c *gin.Context) {
+	util.SuccessResponse(c, http.StatusOK, "Timeout metrics retrieved successfully", h.timeoutMetrics.Snapshot())
+}
+
+// GetRetentionMetrics handles reporting retention policies and the volumes they purged
+// GET /api/v1/admin/metrics/retention
+func (h *MetricsHandler) GetRetentionMetrics(c *gin.Context) {
+	util.SuccessResponse(c, http.StatusOK, "Retention metrics retrieved successfully", h.retentionService.Report())
+}
+
+// RunRetention handles purging expired data now instead of waiting for the next scheduled run
+// POST /api/v1/admin/retention/run
+func (h *MetricsHandler) RunRetention(c *gin.Context) {
+	report, err := h.retentionService.Run(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, service.ErrRetentionRunning) {
+			util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
+		util.ErrorResponse(c, http.StatusInternalServerError, "Retention run failed", gin.H{"error": err.Error(), "report": report})
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, "Retention run completed", report)
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrderChatHandler struct {
+	chatService service.OrderChatService
+}
+
+func NewOrderChatHandler(chatService service.OrderChatService) *OrderChatHandler {
+	return &OrderChatHandler{
+		chatService: chatService,
+	}
+}
+
+// GetBuyerThreads handles the buyer opening the chats of their order, one per shop
+// GET /api/v1/orders/:id/chat
+func (h *OrderChatHandler) GetBuyerThreads(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	threads, err := h.chatService.GetBuyerThreads(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Order chat retrieved successfully", threads)
+}
+
+// BuyerSend handles the buyer messaging one shop of their order
+// POST /api/v1/orders/:id/chat/:seller_order_id/messages
+func (h *OrderChatHandler) BuyerSend(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.OrderChatMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	message, err := h.chatService.BuyerSend(c.Request.Context(), userID.(string), c.Param("id"), c.Param("seller_order_id"), req.Message)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Message sent successfully", message)
+}
+
+// GetSellerThread handles the seller opening the chat of their sub-order
+// GET /api/v1/sellers/me/orders/:id/chat
+func (h *OrderChatHandler) GetSellerThread(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	thread, err := h.chatService.GetSellerThread(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Order chat retrieved successfully", thread)
+}
+
+// SellerSend handles the seller messaging the buyer of their sub-order
+// POST /api/v1/sellers/me/orders/:id/chat/messages
+func (h *OrderChatHandler) SellerSend(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.OrderChatMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	message, err := h.chatService.SellerSend(c.Request.Context(), userID.(string), c.Param("id"), req.Message)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Message sent successfully", message)
+}
+
+func (h *OrderChatHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "order not found" || err.Error() == "chat not found" || err.Error() == "seller not found":
+		util.NotFound(c, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+	default:
+		util.BadRequest(c, err.Error())
+	}
+}
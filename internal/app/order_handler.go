@@ -1,8 +1,11 @@
 package app
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"yourapp/internal/repository"
 	"yourapp/internal/service"
 	"yourapp/internal/util"
 
@@ -35,8 +38,24 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	order, err := h.orderService.CreateOrder(userID.(string), &req)
+	order, err := h.orderService.CreateOrder(c.Request.Context(), userID.(string), &req)
 	if err != nil {
+		var mismatch *service.PriceMismatchError
+		if errors.As(err, &mismatch) {
+			util.ErrorResponse(c, http.StatusUnprocessableEntity, "Order amounts do not match the server calculation", mismatch.Mismatches)
+			return
+		}
+		if writeOrderConstraintError(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrInsufficientStock) || errors.Is(err, service.ErrDeliverySlotUnavailable) {
+			util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
+		if errors.Is(err, service.ErrFraudBlocked) {
+			util.Forbidden(c, err.Error())
+			return
+		}
 		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
@@ -44,6 +63,93 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	util.SuccessResponse(c, http.StatusCreated, "Order created successfully", order)
 }
 
+// Checkout handles creating an order from the user's cart
+// POST /api/v1/checkout
+func (h *OrderHandler) Checkout(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+	if req.AffiliateCode == nil {
+		if code, err := c.Cookie(affiliateCookieName); err == nil && code != "" {
+			req.AffiliateCode = &code
+		}
+	}
+
+	order, err := h.orderService.Checkout(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		var mismatch *service.PriceMismatchError
+		if errors.As(err, &mismatch) {
+			util.ErrorResponse(c, http.StatusUnprocessableEntity, "Order amounts do not match the server calculation", mismatch.Mismatches)
+			return
+		}
+		if writeOrderConstraintError(c, err) || writeCouponError(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrInsufficientStock) || errors.Is(err, service.ErrDeliverySlotUnavailable) {
+			util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
+		if errors.Is(err, service.ErrFraudBlocked) {
+			util.Forbidden(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Order created successfully", order)
+}
+
+// GetCartSummary handles getting the cart's item count and totals computed by the server
+// GET /api/v1/carts/summary
+func (h *OrderHandler) GetCartSummary(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	summary, err := h.orderService.GetCartSummary(c.Request.Context(), userID.(string))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Cart summary retrieved successfully", summary)
+}
+
+// PreviewCheckout handles pricing the cart and estimating delivery without creating an order
+// POST /api/v1/checkout/preview
+func (h *OrderHandler) PreviewCheckout(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	preview, err := h.orderService.PreviewCheckout(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Checkout preview retrieved successfully", preview)
+}
+
 // GetOrder handles getting order by ID
 // GET /api/v1/orders/:id
 func (h *OrderHandler) GetOrder(c *gin.Context) {
@@ -60,7 +166,7 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 		return
 	}
 
-	order, err := h.orderService.GetOrderByID(id, userID.(string))
+	order, err := h.orderService.GetOrderByID(c.Request.Context(), id, userID.(string))
 	if err != nil {
 		util.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
 		return
@@ -69,8 +175,49 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 	util.SuccessResponse(c, http.StatusOK, "Order retrieved successfully", order)
 }
 
+// GetOrderTimeline handles getting the status progress tracker of an order
+// GET /api/v1/orders/:id/timeline
+func (h *OrderHandler) GetOrderTimeline(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	timeline, err := h.orderService.GetOrderTimeline(c.Request.Context(), c.Param("id"), userID.(string))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Order timeline retrieved successfully", timeline)
+}
+
+// GetInvoice handles getting the invoice of a paid order, with each seller's PPN and NPWP
+// GET /api/v1/orders/:id/invoice
+func (h *OrderHandler) GetInvoice(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	invoice, err := h.orderService.GetInvoice(c.Request.Context(), c.Param("id"), userID.(string))
+	if err != nil {
+		switch err.Error() {
+		case "order not found", "order does not belong to user":
+			util.NotFound(c, err.Error())
+		default:
+			util.BadRequest(c, err.Error())
+		}
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Invoice retrieved successfully", invoice)
+}
+
 // GetOrders handles getting list of orders for authenticated user
-// GET /api/v1/orders?page=1&limit=10&status=pending&payment_status=success
+// GET /api/v1/orders?page=1&limit=10&status=pending&payment_status=success&archived=true&from=2024-01-01&to=2024-01-31
 func (h *OrderHandler) GetOrders(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("userID")
@@ -81,11 +228,21 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	status := c.Query("status")                // Optional: filter by order status (pending, processing, shipped, delivered, cancelled)
-	paymentStatus := c.Query("payment_status") // Optional: filter by payment status (pending, success, failed, cancelled, expired)
+	query := service.OrderListQuery{
+		Status:        c.Query("status"),         // Optional: pending, processing, shipped, delivered, cancelled
+		PaymentStatus: c.Query("payment_status"), // Optional: pending, success, failed, cancelled, expired
+		Archived:      c.Query("archived"),       // Optional: true lists archived orders only, all lists both
+		From:          c.Query("from"),           // Optional: YYYY-MM-DD
+		To:            c.Query("to"),             // Optional: YYYY-MM-DD
+	}
 
-	orders, total, err := h.orderService.GetOrdersByUserID(userID.(string), page, limit, status, paymentStatus)
+	orders, total, err := h.orderService.GetOrdersByUserID(c.Request.Context(), userID.(string), query, page, limit)
 	if err != nil {
+		var dateRangeErr *service.DateRangeError
+		if errors.As(err, &dateRangeErr) {
+			util.BadRequest(c, err.Error())
+			return
+		}
 		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
@@ -97,3 +254,323 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 		"limit":  limit,
 	})
 }
+
+// SearchOrders handles searching the authenticated user's orders
+// GET /api/v1/orders/search?q=keyword&page=1&limit=10 (order number, product name or shop name)
+func (h *OrderHandler) SearchOrders(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	keyword := strings.TrimSpace(c.Query("q"))
+	if keyword == "" {
+		util.BadRequest(c, "Search keyword is required")
+		return
+	}
+
+	orders, total, err := h.orderService.SearchOrders(c.Request.Context(), userID.(string), keyword, page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Orders found successfully", gin.H{
+		"orders": orders,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// CancelOrder handles buyer cancellation of an unpaid order
+// POST /api/v1/orders/:id/cancel
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		util.BadRequest(c, "Order ID is required")
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	order, err := h.orderService.CancelOrder(c.Request.Context(), id, userID.(string), req.Reason)
+	if err != nil {
+		switch err.Error() {
+		case "order not found", "order does not belong to user":
+			util.NotFound(c, "Order not found")
+		default:
+			util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		}
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Order cancelled successfully", order)
+}
+
+// ConfirmDelivery handles the buyer confirming a shipped order has arrived
+// POST /api/v1/orders/:id/confirm-delivery
+func (h *OrderHandler) ConfirmDelivery(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	order, err := h.orderService.ConfirmDelivery(c.Request.Context(), c.Param("id"), userID.(string))
+	if err != nil {
+		switch {
+		case err.Error() == "order not found", err.Error() == "order does not belong to user":
+			util.NotFound(c, "Order not found")
+		case errors.Is(err, repository.ErrOrderNotShipped):
+			util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		default:
+			util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		}
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Order delivery confirmed successfully", order)
+}
+
+// ArchiveOrder handles hiding a delivered or cancelled order from the buyer's order list
+// PUT /api/v1/orders/:id/archive
+func (h *OrderHandler) ArchiveOrder(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	order, err := h.orderService.ArchiveOrder(c.Request.Context(), c.Param("id"), userID.(string))
+	if err != nil {
+		h.archiveError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Order archived successfully", order)
+}
+
+// UnarchiveOrder handles moving an archived order back to the buyer's order list
+// PUT /api/v1/orders/:id/unarchive
+func (h *OrderHandler) UnarchiveOrder(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	order, err := h.orderService.UnarchiveOrder(c.Request.Context(), c.Param("id"), userID.(string))
+	if err != nil {
+		h.archiveError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Order unarchived successfully", order)
+}
+
+func (h *OrderHandler) archiveError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "order not found", err.Error() == "order does not belong to user":
+		util.NotFound(c, "Order not found")
+	case errors.Is(err, repository.ErrOrderNotCompleted):
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+	default:
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+	}
+}
+
+// Reorder handles copying the items of a past order back into the cart at current prices
+// POST /api/v1/orders/:id/reorder
+func (h *OrderHandler) Reorder(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.orderService.Reorder(c.Request.Context(), c.Param("id"), userID.(string))
+	if err != nil {
+		switch err.Error() {
+		case "order not found", "order does not belong to user":
+			util.NotFound(c, "Order not found")
+		default:
+			util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		}
+		return
+	}
+
+	message := "Items added to cart successfully"
+	if len(result.Added) == 0 {
+		message = "None of the items are available anymore"
+	}
+	util.SuccessResponse(c, http.StatusOK, message, result)
+}
+
+// SetOrder3DSOverride handles setting the per-order credit card 3DS override (admin only)
+// PUT /api/v1/admin/orders/:id/3ds
+// Body: {"require_3ds": true|false|null} - null clears the override and falls back to the configured policy
+func (h *OrderHandler) SetOrder3DSOverride(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		util.BadRequest(c, "Order ID is required")
+		return
+	}
+
+	var req struct {
+		Require3DS *bool `json:"require_3ds"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	order, err := h.orderService.SetRequire3DS(c.Request.Context(), id, req.Require3DS)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Order 3DS override updated successfully", order)
+}
+
+// AdminListOrders handles listing all orders (admin only)
+// GET /api/v1/admin/orders?page=1&limit=10&status=processing&payment_status=success&user_id=&seller_id=&from=2024-01-01&to=2024-01-31&q=keyword
+func (h *OrderHandler) AdminListOrders(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	query := service.AdminOrderQuery{
+		Status:        c.Query("status"),
+		PaymentStatus: c.Query("payment_status"),
+		UserID:        c.Query("user_id"),
+		SellerID:      c.Query("seller_id"),
+		From:          c.Query("from"), // Optional: YYYY-MM-DD
+		To:            c.Query("to"),   // Optional: YYYY-MM-DD
+		Search:        c.Query("q"),    // Optional: order number or buyer email
+	}
+
+	orders, total, err := h.orderService.ListAllOrders(c.Request.Context(), query, page, limit)
+	if err != nil {
+		var dateRangeErr *service.DateRangeError
+		if errors.As(err, &dateRangeErr) {
+			util.BadRequest(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Orders retrieved successfully", gin.H{
+		"orders": orders,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// AdminGetOrder handles getting any order with its history and internal notes (admin only)
+// GET /api/v1/admin/orders/:id
+func (h *OrderHandler) AdminGetOrder(c *gin.Context) {
+	detail, err := h.orderService.GetOrderForAdmin(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err.Error() == "order not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Order retrieved successfully", detail)
+}
+
+// AdminUpdateOrderStatus handles forcing an order into any status (admin only)
+// PUT /api/v1/admin/orders/:id/status
+func (h *OrderHandler) AdminUpdateOrderStatus(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req struct {
+		Status string `json:"status" binding:"required,oneof=pending processing shipped delivered cancelled"`
+		Reason string `json:"reason" binding:"required,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	order, err := h.orderService.ForceOrderStatus(c.Request.Context(), c.Param("id"), adminID.(string), req.Status, req.Reason)
+	if err != nil {
+		if err.Error() == "order not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		if errors.Is(err, repository.ErrOrderNotPending) {
+			util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Order status updated successfully", order)
+}
+
+// AdminAddOrderNote handles annotating an order with an internal note (admin only)
+// POST /api/v1/admin/orders/:id/notes
+func (h *OrderHandler) AdminAddOrderNote(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req struct {
+		Note string `json:"note" binding:"required,max=2000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	note, err := h.orderService.AddOrderNote(c.Request.Context(), c.Param("id"), adminID.(string), req.Note)
+	if err != nil {
+		if err.Error() == "order not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Order note added successfully", note)
+}
+
+// writeOrderConstraintError responds with the violated order rules, each with its code, and
+// reports whether err was such an error
+func writeOrderConstraintError(c *gin.Context, err error) bool {
+	var constraints *service.OrderConstraintError
+	if !errors.As(err, &constraints) {
+		return false
+	}
+	util.ErrorResponse(c, http.StatusUnprocessableEntity, err.Error(), constraints.Violations)
+	return true
+}
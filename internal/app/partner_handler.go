@@ -0,0 +1,358 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"yourapp/internal/model"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PartnerHandler struct {
+	partnerService service.PartnerService
+	sandboxService service.SandboxService
+	usageService   service.PartnerUsageService
+}
+
+func NewPartnerHandler(partnerService service.PartnerService, sandboxService service.SandboxService, usageService service.PartnerUsageService) *PartnerHandler {
+	return &PartnerHandler{
+		partnerService: partnerService,
+		sandboxService: sandboxService,
+		usageService:   usageService,
+	}
+}
+
+// CreateAPIKey handles creating a partner API key for the current user's shop
+// POST /api/v1/sellers/me/api-keys
+func (h *PartnerHandler) CreateAPIKey(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req struct {
+		Name    string `json:"name" binding:"required,max=100"`
+		Sandbox bool   `json:"sandbox"` // Key only works against sandbox data
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	resp, err := h.partnerService.CreateAPIKey(c.Request.Context(), userID.(string), req.Name, req.Sandbox)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "API key created. Store it now, it will not be shown again", resp)
+}
+
+// GetAPIKeys handles listing the current user's partner API keys
+// GET /api/v1/sellers/me/api-keys
+func (h *PartnerHandler) GetAPIKeys(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	keys, err := h.partnerService.GetAPIKeys(c.Request.Context(), userID.(string))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "API keys retrieved successfully", keys)
+}
+
+// RevokeAPIKey handles revoking a partner API key
+// DELETE /api/v1/sellers/me/api-keys/:id
+func (h *PartnerHandler) RevokeAPIKey(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.partnerService.RevokeAPIKey(c.Request.Context(), userID.(string), c.Param("id")); err != nil {
+		util.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "API key revoked successfully", nil)
+}
+
+// GetAPIKeyUsage handles reporting a partner API key's daily usage and its quota this month
+// GET /api/v1/sellers/me/api-keys/:id/usage?from=2024-01-01&to=2024-01-31
+func (h *PartnerHandler) GetAPIKeyUsage(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	report, err := h.usageService.GetUsage(c.Request.Context(), userID.(string), c.Param("id"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		if err.Error() == "API key not found" || err.Error() == "seller not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "API usage retrieved successfully", report)
+}
+
+// GetUsageBilling handles listing every partner API key's usage and overage in a month
+// GET /api/v1/admin/partner-usage?month=2024-01
+func (h *PartnerHandler) GetUsageBilling(c *gin.Context) {
+	report, err := h.usageService.GetBillingReport(c.Request.Context(), c.Query("month"))
+	if err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "API usage billing retrieved successfully", report)
+}
+
+// SetAPIKeyPlan handles moving a partner API key to another usage plan
+// PUT /api/v1/admin/partner-keys/:id/plan
+func (h *PartnerHandler) SetAPIKeyPlan(c *gin.Context) {
+	var req struct {
+		Plan string `json:"plan" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	apiKey, err := h.usageService.SetPlan(c.Request.Context(), c.Param("id"), req.Plan)
+	if err != nil {
+		if err.Error() == "API key not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "API key plan updated successfully", apiKey)
+}
+
+// GetInventoryChanges handles pulling product/stock changes since a cursor
+// GET /api/v1/partner/inventory?cursor=...&limit=100
+func (h *PartnerHandler) GetInventoryChanges(c *gin.Context) {
+	apiKey := c.MustGet("partnerAPIKey").(*model.PartnerAPIKey)
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	var resp *service.InventoryChangesResponse
+	var err error
+	if apiKey.Sandbox {
+		resp, err = h.sandboxService.GetInventoryChanges(c.Request.Context(), apiKey, c.Query("cursor"), limit)
+	} else {
+		resp, err = h.partnerService.GetInventoryChanges(c.Request.Context(), apiKey.SellerID, c.Query("cursor"), limit)
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Inventory changes retrieved successfully", resp)
+}
+
+// AdjustStock handles pushing stock adjustments in bulk
+// POST /api/v1/partner/inventory/adjustments
+func (h *PartnerHandler) AdjustStock(c *gin.Context) {
+	apiKey := c.MustGet("partnerAPIKey").(*model.PartnerAPIKey)
+
+	var req service.BulkStockAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	var resp *service.BulkStockAdjustmentResponse
+	var err error
+	if apiKey.Sandbox {
+		resp, err = h.sandboxService.AdjustStock(c.Request.Context(), apiKey, req)
+	} else {
+		resp, err = h.partnerService.AdjustStock(c.Request.Context(), apiKey, req)
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Stock adjustments processed", resp)
+}
+
+// CreateSandboxOrder handles placing a fake order against the sandbox catalog
+// POST /api/v1/partner/sandbox/orders
+func (h *PartnerHandler) CreateSandboxOrder(c *gin.Context) {
+	apiKey := c.MustGet("partnerAPIKey").(*model.PartnerAPIKey)
+
+	var req service.SandboxOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	order, err := h.sandboxService.CreateOrder(c.Request.Context(), apiKey, req)
+	if err != nil {
+		writeSandboxError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Sandbox order created successfully", order)
+}
+
+// GetSandboxOrders handles listing sandbox orders
+// GET /api/v1/partner/sandbox/orders?page=1&limit=20
+func (h *PartnerHandler) GetSandboxOrders(c *gin.Context) {
+	apiKey := c.MustGet("partnerAPIKey").(*model.PartnerAPIKey)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	orders, total, err := h.sandboxService.GetOrders(c.Request.Context(), apiKey, page, limit)
+	if err != nil {
+		writeSandboxError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Sandbox orders retrieved successfully", gin.H{
+		"orders": orders,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// GetSandboxOrder handles getting one sandbox order
+// GET /api/v1/partner/sandbox/orders/:id
+func (h *PartnerHandler) GetSandboxOrder(c *gin.Context) {
+	apiKey := c.MustGet("partnerAPIKey").(*model.PartnerAPIKey)
+
+	order, err := h.sandboxService.GetOrder(c.Request.Context(), apiKey, c.Param("id"))
+	if err != nil {
+		writeSandboxError(c, err, http.StatusNotFound)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Sandbox order retrieved successfully", order)
+}
+
+// SimulateSandboxPayment handles settling a sandbox order's payment with a chosen outcome
+// POST /api/v1/partner/sandbox/orders/:id/pay
+func (h *PartnerHandler) SimulateSandboxPayment(c *gin.Context) {
+	apiKey := c.MustGet("partnerAPIKey").(*model.PartnerAPIKey)
+
+	var req struct {
+		Outcome string `json:"outcome" binding:"required"` // success, failed, expired
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	order, err := h.sandboxService.SimulatePayment(c.Request.Context(), apiKey, c.Param("id"), req.Outcome)
+	if err != nil {
+		if err.Error() == "order not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		writeSandboxError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Sandbox payment simulated successfully", order)
+}
+
+// GetSandboxNotifications handles listing the notifications sandbox events would have sent
+// GET /api/v1/partner/sandbox/notifications?limit=100
+func (h *PartnerHandler) GetSandboxNotifications(c *gin.Context) {
+	apiKey := c.MustGet("partnerAPIKey").(*model.PartnerAPIKey)
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	notifications, err := h.sandboxService.GetNotifications(c.Request.Context(), apiKey, limit)
+	if err != nil {
+		writeSandboxError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Sandbox notifications retrieved successfully", notifications)
+}
+
+// ResetSandbox handles wiping the key's sandbox data and re-seeding the catalog
+// POST /api/v1/partner/sandbox/reset
+func (h *PartnerHandler) ResetSandbox(c *gin.Context) {
+	apiKey := c.MustGet("partnerAPIKey").(*model.PartnerAPIKey)
+
+	if err := h.sandboxService.Reset(c.Request.Context(), apiKey); err != nil {
+		writeSandboxError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Sandbox reset successfully", nil)
+}
+
+// writeSandboxError answers live keys on sandbox endpoints with 403 and other errors with status
+func writeSandboxError(c *gin.Context, err error, status int) {
+	if errors.Is(err, service.ErrSandboxOnly) {
+		util.Forbidden(c, err.Error())
+		return
+	}
+	util.ErrorResponse(c, status, err.Error(), nil)
+}
+
+// APIKeyMiddleware authenticates partner requests with the X-API-Key header and meters them
+// against the key's monthly quota
+func (h *PartnerHandler) APIKeyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader("X-API-Key")
+		if rawKey == "" {
+			util.Unauthorized(c, "X-API-Key header required")
+			c.Abort()
+			return
+		}
+
+		apiKey, err := h.partnerService.AuthenticateAPIKey(c.Request.Context(), rawKey)
+		if err != nil {
+			util.Unauthorized(c, err.Error())
+			c.Abort()
+			return
+		}
+
+		quota, err := h.usageService.CheckQuota(c.Request.Context(), apiKey)
+		if quota != nil {
+			c.Header("X-Quota-Plan", quota.Plan)
+			c.Header("X-Quota-Requests-Remaining", strconv.FormatInt(quota.RequestsLeft, 10))
+			c.Header("X-Quota-Transfer-Remaining", strconv.FormatInt(quota.TransferLeft, 10))
+			c.Header("X-Quota-Reset", strconv.FormatInt(quota.ResetAt.Unix(), 10))
+		}
+		if err != nil {
+			if errors.Is(err, service.ErrPartnerQuotaExceeded) {
+				util.ErrorResponse(c, http.StatusTooManyRequests, err.Error(), quota)
+			} else {
+				util.ErrorResponse(c, http.StatusInternalServerError, "Failed to check API quota", nil)
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set("partnerAPIKey", apiKey)
+		c.Next()
+
+		// Meter after the response so its size is known; the client may already be gone
+		h.usageService.Record(context.WithoutCancel(c.Request.Context()), apiKey, c.Request.ContentLength, int64(c.Writer.Size()))
+	}
+}
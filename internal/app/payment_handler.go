@@ -1,8 +1,17 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"yourapp/internal/config"
 	"yourapp/internal/model"
 	"yourapp/internal/service"
 	"yourapp/internal/util"
@@ -10,13 +19,29 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// callbackProcessingTimeout bounds background processing of a single Midtrans callback
+const callbackProcessingTimeout = 30 * time.Second
+
+// maxCallbackBodySize caps the size of a Midtrans notification body
+const maxCallbackBodySize = 1 << 20
+
+// maxPaymentStatusWait caps how long a long-poll status request may be held open
+const maxPaymentStatusWait = 30 * time.Second
+
 type PaymentHandler struct {
-	paymentService service.PaymentService
+	paymentService   service.PaymentService
+	cloudinaryUpload *util.CloudinaryUploader
 }
 
-func NewPaymentHandler(paymentService service.PaymentService) *PaymentHandler {
+func NewPaymentHandler(paymentService service.PaymentService, cfg *config.Config) *PaymentHandler {
+	var uploader *util.CloudinaryUploader
+	if cfg.CloudinaryCloudName != "" && cfg.CloudinaryAPIKey != "" && cfg.CloudinaryAPISecret != "" {
+		uploader = util.NewCloudinaryUploader(cfg.CloudinaryCloudName, cfg.CloudinaryAPIKey, cfg.CloudinaryAPISecret)
+	}
+
 	return &PaymentHandler{
-		paymentService: paymentService,
+		paymentService:   paymentService,
+		cloudinaryUpload: uploader,
 	}
 }
 
@@ -27,6 +52,7 @@ func (h *PaymentHandler) CreatePayment(c *gin.Context) {
 		OrderID       string  `json:"order_id" binding:"required"`
 		PaymentMethod string  `json:"payment_method" binding:"required"`
 		Bank          *string `json:"bank,omitempty"` // bca, bni, mandiri, etc (for bank_transfer)
+		service.CardChargeOptions
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -37,19 +63,37 @@ func (h *PaymentHandler) CreatePayment(c *gin.Context) {
 	// Validate payment method
 	paymentMethod := model.PaymentMethod(req.PaymentMethod)
 	validMethods := map[model.PaymentMethod]bool{
-		model.PaymentMethodBankTransfer: true,
-		model.PaymentMethodGopay:        true,
-		model.PaymentMethodCreditCard:   true,
-		model.PaymentMethodQRIS:         true,
-		model.PaymentMethodAlfamart:     true,
+		model.PaymentMethodBankTransfer:   true,
+		model.PaymentMethodGopay:          true,
+		model.PaymentMethodCreditCard:     true,
+		model.PaymentMethodQRIS:           true,
+		model.PaymentMethodAlfamart:       true,
+		model.PaymentMethodManualTransfer: true,
 	}
 	if !validMethods[paymentMethod] {
 		util.BadRequest(c, "Invalid payment method")
 		return
 	}
 
-	payment, err := h.paymentService.CreatePayment(req.OrderID, paymentMethod, req.Bank)
+	payment, err := h.paymentService.CreatePayment(c.Request.Context(), req.OrderID, paymentMethod, req.Bank, &req.CardChargeOptions)
 	if err != nil {
+		if errors.Is(err, service.ErrFraudBlocked) {
+			util.Forbidden(c, err.Error())
+			return
+		}
+		var unavailable *service.MidtransUnavailableError
+		if errors.As(err, &unavailable) {
+			// The order stays pending; the app retries the payment after the hint
+			seconds := unavailable.RetryAfterSeconds()
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			util.ErrorResponse(c, http.StatusServiceUnavailable, "Payment temporarily unavailable, your order has been saved", gin.H{
+				"code":                "payment_unavailable",
+				"order_id":            req.OrderID,
+				"order_saved":         true,
+				"retry_after_seconds": seconds,
+			})
+			return
+		}
 		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
@@ -66,7 +110,7 @@ func (h *PaymentHandler) GetPayment(c *gin.Context) {
 		return
 	}
 
-	payment, err := h.paymentService.GetPaymentByID(id)
+	payment, err := h.paymentService.GetPaymentByID(c.Request.Context(), id)
 	if err != nil {
 		util.ErrorResponse(c, http.StatusNotFound, "Payment not found", nil)
 		return
@@ -84,7 +128,7 @@ func (h *PaymentHandler) GetPaymentByOrder(c *gin.Context) {
 		return
 	}
 
-	payment, err := h.paymentService.GetPaymentByOrderID(orderID)
+	payment, err := h.paymentService.GetPaymentByOrderID(c.Request.Context(), orderID)
 	if err != nil {
 		util.ErrorResponse(c, http.StatusNotFound, "Payment not found", nil)
 		return
@@ -94,7 +138,7 @@ func (h *PaymentHandler) GetPaymentByOrder(c *gin.Context) {
 }
 
 // CheckPaymentStatus handles checking payment status
-// GET /api/v1/payments/:id/status
+// GET /api/v1/payments/:id/status?wait=30s
 // This endpoint always checks latest status from Midtrans API if payment is still pending
 func (h *PaymentHandler) CheckPaymentStatus(c *gin.Context) {
 	id := c.Param("id")
@@ -103,8 +147,30 @@ func (h *PaymentHandler) CheckPaymentStatus(c *gin.Context) {
 		return
 	}
 
+	// Long-poll: ?wait=30s holds the request until the status changes or the wait elapses.
+	// Clients may pass ?status=<last seen status> so a change between polls is not missed.
+	if waitParam := c.Query("wait"); waitParam != "" {
+		wait, err := time.ParseDuration(waitParam)
+		if err != nil || wait <= 0 {
+			util.BadRequest(c, "wait must be a positive duration such as 30s")
+			return
+		}
+		if wait > maxPaymentStatusWait {
+			wait = maxPaymentStatusWait
+		}
+
+		payment, err := h.paymentService.WaitForPaymentStatusChange(c.Request.Context(), id, model.PaymentStatus(c.Query("status")), wait)
+		if err != nil {
+			util.ErrorResponse(c, http.StatusNotFound, "Payment not found", nil)
+			return
+		}
+
+		util.SuccessResponse(c, http.StatusOK, "Payment status retrieved successfully", payment)
+		return
+	}
+
 	// Force check from Midtrans API if payment is pending
-	payment, err := h.paymentService.CheckPaymentStatus(id)
+	payment, err := h.paymentService.CheckPaymentStatus(c.Request.Context(), id)
 	if err != nil {
 		util.ErrorResponse(c, http.StatusNotFound, "Payment not found", nil)
 		return
@@ -118,20 +184,37 @@ func (h *PaymentHandler) CheckPaymentStatus(c *gin.Context) {
 // This is a PUBLIC endpoint - Midtrans will POST webhook notifications here
 // Note: In production, you should verify the signature for security
 func (h *PaymentHandler) MidtransCallback(c *gin.Context) {
-	var notification map[string]interface{}
-	if err := c.ShouldBindJSON(&notification); err != nil {
+	if c.ContentType() != "application/json" {
+		util.ErrorResponse(c, http.StatusUnsupportedMediaType, "Content-Type must be application/json", nil)
+		return
+	}
+
+	// Keep the body exactly as sent; it is stored with the payment and the signature covers it
+	rawBody, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxCallbackBodySize))
+	if err != nil {
+		log.Printf("❌ Failed to read Midtrans callback body: %v", err)
+		util.BadRequest(c, "Invalid notification body")
+		return
+	}
+
+	notification, err := service.DecodeMidtransNotification(rawBody)
+	if err != nil {
 		log.Printf("❌ Invalid Midtrans callback JSON: %v", err)
 		util.BadRequest(c, "Invalid notification format")
 		return
 	}
 
 	// Log raw notification for debugging
-	log.Printf("📥 Received Midtrans callback: %+v", notification)
+	log.Printf("📥 Received Midtrans callback: %s", rawBody)
 
 	// Process callback asynchronously to respond quickly to Midtrans
 	// Midtrans expects fast response (< 10 seconds)
+	// Detach from the request context: the response is sent before processing finishes
+	ctx := context.WithoutCancel(c.Request.Context())
 	go func() {
-		if err := h.paymentService.HandleMidtransCallback(notification); err != nil {
+		ctx, cancel := context.WithTimeout(ctx, callbackProcessingTimeout)
+		defer cancel()
+		if err := h.paymentService.HandleMidtransCallback(ctx, notification, rawBody); err != nil {
 			log.Printf("❌ Failed to process Midtrans callback: %v", err)
 			// Note: We still return 200 OK to Midtrans even if processing fails
 			// This prevents Midtrans from retrying immediately
@@ -146,3 +229,239 @@ func (h *PaymentHandler) MidtransCallback(c *gin.Context) {
 		"message": "Callback received",
 	})
 }
+
+// UploadTransferProof handles uploading a transfer receipt for a manual transfer payment
+// POST /api/v1/payments/:id/proof (multipart form, field: image)
+func (h *PaymentHandler) UploadTransferProof(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		util.BadRequest(c, "Payment ID is required")
+		return
+	}
+
+	if h.cloudinaryUpload == nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "Cloudinary is not configured", nil)
+		return
+	}
+
+	// Authorize before touching Cloudinary so rejected requests do not leave orphaned uploads
+	if err := h.paymentService.CheckTransferProofUpload(c.Request.Context(), id, userID.(string)); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		util.BadRequest(c, "Transfer proof image is required")
+		return
+	}
+
+	// Validate MIME type
+	allowedMIMETypes := map[string]bool{
+		"image/jpeg": true,
+		"image/jpg":  true,
+		"image/png":  true,
+		"image/webp": true,
+	}
+	contentType := fileHeader.Header.Get("Content-Type")
+	if contentType == "" {
+		// Try to detect from filename
+		mimeMap := map[string]string{
+			".jpg":  "image/jpeg",
+			".jpeg": "image/jpeg",
+			".png":  "image/png",
+			".webp": "image/webp",
+		}
+		contentType = mimeMap[strings.ToLower(filepath.Ext(fileHeader.Filename))]
+	}
+	if !allowedMIMETypes[contentType] {
+		util.BadRequest(c, "Invalid image format. Allowed: JPEG, PNG, WEBP")
+		return
+	}
+
+	// Validate file size (max 5MB)
+	if fileHeader.Size > 5<<20 {
+		util.BadRequest(c, "Transfer proof exceeds 5MB limit")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		util.BadRequest(c, "Failed to open file: "+err.Error())
+		return
+	}
+	fileData, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		util.BadRequest(c, "Failed to read file: "+err.Error())
+		return
+	}
+
+	url, err := h.cloudinaryUpload.UploadImage(fileData, fileHeader.Filename, fmt.Sprintf("payments/%s", id))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "Failed to upload transfer proof: "+err.Error(), nil)
+		return
+	}
+
+	payment, err := h.paymentService.SubmitTransferProof(c.Request.Context(), id, userID.(string), url)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Transfer proof uploaded successfully", payment)
+}
+
+// VerifyManualTransfer handles admin review of a manual transfer proof. Review is admin-only:
+// an order can span several sellers, so no single seller can confirm the whole transfer.
+// PUT /api/v1/admin/payments/:id/verify
+func (h *PaymentHandler) VerifyManualTransfer(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		util.BadRequest(c, "Payment ID is required")
+		return
+	}
+
+	var req struct {
+		Approved *bool   `json:"approved" binding:"required"`
+		Note     *string `json:"note,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	payment, err := h.paymentService.VerifyManualTransfer(c.Request.Context(), id, adminID.(string), *req.Approved, req.Note)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Payment verified successfully", payment)
+}
+
+// GetMidtransBudgetStats handles reporting background Midtrans API budget consumption
+// GET /api/v1/admin/payments/midtrans-budget
+func (h *PaymentHandler) GetMidtransBudgetStats(c *gin.Context) {
+	util.SuccessResponse(c, http.StatusOK, "Midtrans budget stats retrieved successfully", h.paymentService.GetMidtransBudgetStats())
+}
+
+// GetMidtransBreakerStats handles reporting the Midtrans circuit breaker state
+// GET /api/v1/admin/payments/midtrans-breaker
+func (h *PaymentHandler) GetMidtransBreakerStats(c *gin.Context) {
+	util.SuccessResponse(c, http.StatusOK, "Midtrans breaker stats retrieved successfully", h.paymentService.GetMidtransBreakerStats())
+}
+
+// ResendPaymentInstructions handles re-sending payment instructions (VA / QR / payment code) to the buyer
+// POST /api/v1/payments/:id/resend-instructions
+func (h *PaymentHandler) ResendPaymentInstructions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		util.BadRequest(c, "Payment ID is required")
+		return
+	}
+
+	if err := h.paymentService.ResendPaymentInstructions(c.Request.Context(), id, userID.(string)); err != nil {
+		if errors.Is(err, service.ErrResendTooSoon) {
+			util.ErrorResponse(c, http.StatusTooManyRequests, err.Error(), nil)
+			return
+		}
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Payment instructions sent successfully", nil)
+}
+
+// GetPaymentQRCode handles serving the QR code of a QRIS/GoPay payment as a PNG image
+// GET /api/v1/payments/:id/qr.png
+func (h *PaymentHandler) GetPaymentQRCode(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		util.BadRequest(c, "Payment ID is required")
+		return
+	}
+
+	png, expiresAt, err := h.paymentService.GetPaymentQRCode(c.Request.Context(), id, userID.(string))
+	if err != nil {
+		if errors.Is(err, service.ErrQRCodeUnavailable) || err.Error() == "payment not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// Let the client cache the image until the payment expires
+	if expiresAt != nil {
+		if maxAge := int(time.Until(*expiresAt).Seconds()); maxAge > 0 {
+			c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+		}
+	}
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// GetSavedCards handles listing the current user's saved cards
+// GET /api/v1/users/me/cards
+func (h *PaymentHandler) GetSavedCards(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	cards, err := h.paymentService.GetSavedCards(c.Request.Context(), userID.(string))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Saved cards retrieved successfully", cards)
+}
+
+// DeleteSavedCard handles removing a saved card
+// DELETE /api/v1/users/me/cards/:id
+func (h *PaymentHandler) DeleteSavedCard(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		util.BadRequest(c, "Card ID is required")
+		return
+	}
+
+	if err := h.paymentService.DeleteSavedCard(c.Request.Context(), userID.(string), id); err != nil {
+		util.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Saved card deleted successfully", nil)
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -49,7 +50,7 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 
 	product, err := h.productService.CreateProduct(userID.(string), req)
 	if err != nil {
-		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		util.ErrorResponse(c, publishErrorStatus(err), err.Error(), nil)
 		return
 	}
 
@@ -65,7 +66,7 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.productService.GetProductByID(id)
+	product, err := h.productService.ViewProduct(c.Request.Context(), id)
 	if err != nil {
 		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
 		return
@@ -74,28 +75,13 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 	util.SuccessResponse(c, http.StatusOK, "Product retrieved successfully", product)
 }
 
-// GetProducts handles getting list of products
-// GET /api/v1/products
+// GetProducts handles getting list of products, optionally filtered by price range and sorted
+// GET /api/v1/products?category_id=uuid&min_price=10000&max_price=50000&sort=price_asc&page=1&limit=10
 func (h *ProductHandler) GetProducts(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 
-	categoryID := c.Query("category_id")
-	featured := c.Query("featured")
-	activeOnly := c.Query("active_only")
-
-	var categoryIDPtr, featuredPtr, activeOnlyPtr *string
-	if categoryID != "" {
-		categoryIDPtr = &categoryID
-	}
-	if featured != "" {
-		featuredPtr = &featured
-	}
-	if activeOnly != "" {
-		activeOnlyPtr = &activeOnly
-	}
-
-	response, err := h.productService.GetProducts(page, limit, categoryIDPtr, featuredPtr, activeOnlyPtr)
+	response, err := h.productService.GetProducts(page, limit, productListQuery(c, c.Query("active_only")))
 	if err != nil {
 		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
 		return
@@ -104,8 +90,8 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 	util.SuccessResponse(c, http.StatusOK, "Products retrieved successfully", response)
 }
 
-// SearchProducts handles product search by keyword
-// GET /api/v1/products/search?q=keyword
+// SearchProducts handles full-text product search by keyword, best match first unless sorted otherwise
+// GET /api/v1/products/search?q=keyword&category_id=uuid&featured=true&min_price=10000&sort=best_selling&page=1&limit=20
 func (h *ProductHandler) SearchProducts(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
@@ -116,10 +102,8 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 		return
 	}
 
-	activeOnlyStr := c.DefaultQuery("active_only", "true")
-	activeOnly := activeOnlyStr == "true"
-
-	response, err := h.productService.SearchProducts(page, limit, keyword, activeOnly)
+	query := productListQuery(c, c.DefaultQuery("active_only", "true"))
+	response, err := h.productService.SearchProducts(page, limit, keyword, query)
 	if err != nil {
 		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
 		return
@@ -128,6 +112,43 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 	util.SuccessResponse(c, http.StatusOK, "Products found successfully", response)
 }
 
+// productListQuery reads the filter and sort query parameters shared by the product listings
+func productListQuery(c *gin.Context, activeOnly string) service.ProductListQuery {
+	return service.ProductListQuery{
+		CategoryID: c.Query("category_id"),
+		Featured:   c.Query("featured"),
+		ActiveOnly: activeOnly,
+		MinPrice:   c.Query("min_price"),
+		MaxPrice:   c.Query("max_price"),
+		Sort:       c.Query("sort"),
+	}
+}
+
+// GetSellerProducts handles listing and searching a shop's products
+// GET /api/v1/sellers/:id/products?q=keyword&category_id=uuid&page=1&limit=20
+func (h *ProductHandler) GetSellerProducts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	keyword := strings.TrimSpace(c.Query("q")) // Optional
+
+	var categoryIDPtr *string
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		categoryIDPtr = &categoryID
+	}
+
+	response, err := h.productService.GetSellerProducts(c.Param("id"), page, limit, keyword, categoryIDPtr)
+	if err != nil {
+		if err.Error() == "seller not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Products retrieved successfully", response)
+}
+
 // UpdateProduct handles product update
 // PUT /api/v1/products/:id
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
@@ -145,7 +166,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 
 	product, err := h.productService.UpdateProduct(id, req)
 	if err != nil {
-		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		util.ErrorResponse(c, publishErrorStatus(err), err.Error(), nil)
 		return
 	}
 
@@ -210,6 +231,30 @@ func (h *ProductHandler) DeleteProductImage(c *gin.Context) {
 	util.SuccessResponse(c, http.StatusOK, "Image deleted successfully", nil)
 }
 
+// ScheduleProducts handles scheduling publish/unpublish times for several products
+// PUT /api/v1/products/schedule
+func (h *ProductHandler) ScheduleProducts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.ScheduleProductVisibilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	products, err := h.productService.ScheduleProductVisibility(userID.(string), req)
+	if err != nil {
+		util.ErrorResponse(c, publishErrorStatus(err), err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Product visibility scheduled successfully", products)
+}
+
 // UploadMultipleProductImages handles uploading multiple images to Cloudinary and saving to database
 // POST /api/v1/products/:id/images/upload
 func (h *ProductHandler) UploadMultipleProductImages(c *gin.Context) {
@@ -337,3 +382,12 @@ func (h *ProductHandler) UploadMultipleProductImages(c *gin.Context) {
 		"count":  len(urls),
 	})
 }
+
+// publishErrorStatus maps errors from creating or publishing products; a full listing quota is
+// reported as forbidden so clients can show the upgrade path
+func publishErrorStatus(err error) int {
+	if errors.Is(err, service.ErrProductLimitReached) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
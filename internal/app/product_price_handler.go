@@ -0,0 +1,111 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"yourapp/internal/repository"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProductPriceHandler struct {
+	priceService service.ProductPriceService
+}
+
+func NewProductPriceHandler(priceService service.ProductPriceService) *ProductPriceHandler {
+	return &ProductPriceHandler{
+		priceService: priceService,
+	}
+}
+
+// SchedulePrice handles scheduling a future price for a product in the current user's shop
+// POST /api/v1/products/:id/price-schedules
+func (h *ProductPriceHandler) SchedulePrice(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.SchedulePriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	schedule, err := h.priceService.SchedulePrice(c.Request.Context(), userID.(string), c.Param("id"), req)
+	if err != nil {
+		h.handlePriceError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Price change scheduled successfully", schedule)
+}
+
+// GetPriceSchedules handles listing a product's price schedules, soonest first
+// GET /api/v1/products/:id/price-schedules?status=scheduled
+func (h *ProductPriceHandler) GetPriceSchedules(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	schedules, err := h.priceService.GetSchedules(c.Request.Context(), userID.(string), c.Param("id"), c.Query("status"))
+	if err != nil {
+		h.handlePriceError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Price schedules retrieved successfully", schedules)
+}
+
+// CancelPriceSchedule handles cancelling a price change that has not been applied yet
+// DELETE /api/v1/products/:id/price-schedules/:scheduleId
+func (h *ProductPriceHandler) CancelPriceSchedule(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	schedule, err := h.priceService.CancelSchedule(c.Request.Context(), userID.(string), c.Param("id"), c.Param("scheduleId"))
+	if err != nil {
+		h.handlePriceError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Price schedule cancelled successfully", schedule)
+}
+
+// GetPriceHistory handles listing a product's past price changes, newest first
+// GET /api/v1/products/:id/price-history?limit=30
+func (h *ProductPriceHandler) GetPriceHistory(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "30"))
+
+	history, err := h.priceService.GetPriceHistory(c.Request.Context(), c.Param("id"), limit)
+	if err != nil {
+		h.handlePriceError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Price history retrieved successfully", history)
+}
+
+func (h *ProductPriceHandler) handlePriceError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrPriceScheduleNotPending) {
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		return
+	}
+	switch err.Error() {
+	case "product not found", "price schedule not found":
+		util.NotFound(c, err.Error())
+	case "seller not found. Please create a shop first":
+		util.Forbidden(c, err.Error())
+	default:
+		util.BadRequest(c, err.Error())
+	}
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"net/http"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProductQuotaHandler struct {
+	quotaService service.ProductQuotaService
+}
+
+func NewProductQuotaHandler(quotaService service.ProductQuotaService) *ProductQuotaHandler {
+	return &ProductQuotaHandler{
+		quotaService: quotaService,
+	}
+}
+
+// GetMyQuota handles showing the current seller's product listing limit and usage
+// GET /api/v1/sellers/me/product-quota
+func (h *ProductQuotaHandler) GetMyQuota(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	quota, err := h.quotaService.GetQuota(userID.(string))
+	if err != nil {
+		if err.Error() == "seller not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Product quota retrieved successfully", quota)
+}
+
+// SetSellerLimit handles an admin overriding a seller's product listing limit
+// PUT /api/v1/admin/sellers/:id/product-limit (limit: null restores the tier default, 0 is unlimited)
+func (h *ProductQuotaHandler) SetSellerLimit(c *gin.Context) {
+	var req struct {
+		Limit *int `json:"limit"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	quota, err := h.quotaService.SetLimitOverride(c.Param("id"), req.Limit)
+	if err != nil {
+		if err.Error() == "seller not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Product limit updated successfully", quota)
+}
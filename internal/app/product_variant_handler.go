@@ -0,0 +1,110 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProductVariantHandler struct {
+	variantService service.ProductVariantService
+}
+
+func NewProductVariantHandler(variantService service.ProductVariantService) *ProductVariantHandler {
+	return &ProductVariantHandler{
+		variantService: variantService,
+	}
+}
+
+// GetVariants handles listing the variants a product can be bought in
+// GET /api/v1/products/:id/variants
+func (h *ProductVariantHandler) GetVariants(c *gin.Context) {
+	variants, err := h.variantService.GetVariants(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleVariantError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Product variants retrieved successfully", variants)
+}
+
+// CreateVariant handles adding a variant to a product in the current user's shop
+// POST /api/v1/products/:id/variants
+func (h *ProductVariantHandler) CreateVariant(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.CreateVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	variant, err := h.variantService.CreateVariant(c.Request.Context(), userID.(string), c.Param("id"), req)
+	if err != nil {
+		h.handleVariantError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Product variant created successfully", variant)
+}
+
+// UpdateVariant handles changing a variant's SKU, attributes, price, stock or availability
+// PUT /api/v1/products/:id/variants/:variantId
+func (h *ProductVariantHandler) UpdateVariant(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.UpdateVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	variant, err := h.variantService.UpdateVariant(c.Request.Context(), userID.(string), c.Param("id"), c.Param("variantId"), req)
+	if err != nil {
+		h.handleVariantError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Product variant updated successfully", variant)
+}
+
+// DeleteVariant handles removing a variant; its stock is taken off the product's
+// DELETE /api/v1/products/:id/variants/:variantId
+func (h *ProductVariantHandler) DeleteVariant(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.variantService.DeleteVariant(c.Request.Context(), userID.(string), c.Param("id"), c.Param("variantId")); err != nil {
+		h.handleVariantError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Product variant deleted successfully", nil)
+}
+
+func (h *ProductVariantHandler) handleVariantError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "product not found" || err.Error() == "variant not found":
+		util.NotFound(c, err.Error())
+	case err.Error() == "seller not found. Please create a shop first":
+		util.Forbidden(c, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+	default:
+		util.BadRequest(c, err.Error())
+	}
+}
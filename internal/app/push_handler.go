@@ -0,0 +1,68 @@
+package app
+
+import (
+	"net/http"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PushHandler struct {
+	pushService service.PushService
+}
+
+func NewPushHandler(pushService service.PushService) *PushHandler {
+	return &PushHandler{
+		pushService: pushService,
+	}
+}
+
+// RegisterDevice handles registering the FCM token of the current user's device for pushes
+// POST /api/v1/users/me/devices
+func (h *PushHandler) RegisterDevice(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.RegisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	device, err := h.pushService.RegisterDevice(c.Request.Context(), userID.(string), req)
+	if err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Device registered successfully", device)
+}
+
+// UnregisterDevice handles stopping pushes to a device, e.g. on logout
+// DELETE /api/v1/users/me/devices
+func (h *PushHandler) UnregisterDevice(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.pushService.UnregisterDevice(c.Request.Context(), userID.(string), req.Token); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "Failed to unregister device", nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Device unregistered successfully", nil)
+}
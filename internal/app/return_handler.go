@@ -0,0 +1,289 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"yourapp/internal/config"
+	"yourapp/internal/repository"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReturnHandler struct {
+	returnService    service.ReturnService
+	cloudinaryUpload *util.CloudinaryUploader
+}
+
+func NewReturnHandler(returnService service.ReturnService, cfg *config.Config) *ReturnHandler {
+	var uploader *util.CloudinaryUploader
+	if cfg.CloudinaryCloudName != "" && cfg.CloudinaryAPIKey != "" && cfg.CloudinaryAPISecret != "" {
+		uploader = util.NewCloudinaryUploader(cfg.CloudinaryCloudName, cfg.CloudinaryAPIKey, cfg.CloudinaryAPISecret)
+	}
+
+	return &ReturnHandler{
+		returnService:    returnService,
+		cloudinaryUpload: uploader,
+	}
+}
+
+// OpenReturn handles a buyer asking to return a delivered sub-order
+// POST /api/v1/orders/:id/returns (multipart form: seller_order_id, reason, photos[])
+func (h *ReturnHandler) OpenReturn(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.OpenReturnRequest
+	if err := c.ShouldBind(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	var photoURLs []string
+	form, err := c.MultipartForm()
+	if err == nil && len(form.File["photos"]) > 0 {
+		if h.cloudinaryUpload == nil {
+			util.ErrorResponse(c, http.StatusInternalServerError, "Cloudinary is not configured", nil)
+			return
+		}
+
+		// Validate MIME type
+		allowedMIMETypes := map[string]bool{
+			"image/jpeg": true,
+			"image/jpg":  true,
+			"image/png":  true,
+			"image/webp": true,
+		}
+		mimeMap := map[string]string{
+			".jpg":  "image/jpeg",
+			".jpeg": "image/jpeg",
+			".png":  "image/png",
+			".webp": "image/webp",
+		}
+
+		files := form.File["photos"]
+		if len(files) > 5 {
+			util.BadRequest(c, "At most 5 photos can be attached")
+			return
+		}
+		for _, fileHeader := range files {
+			contentType := fileHeader.Header.Get("Content-Type")
+			if contentType == "" {
+				contentType = mimeMap[strings.ToLower(filepath.Ext(fileHeader.Filename))]
+			}
+			if !allowedMIMETypes[contentType] {
+				util.BadRequest(c, "Invalid image format. Allowed: JPEG, PNG, WEBP")
+				return
+			}
+			if fileHeader.Size > 5<<20 {
+				util.BadRequest(c, "Photo exceeds 5MB limit")
+				return
+			}
+
+			file, err := fileHeader.Open()
+			if err != nil {
+				util.BadRequest(c, "Failed to open file: "+err.Error())
+				return
+			}
+			fileData, err := io.ReadAll(file)
+			file.Close()
+			if err != nil {
+				util.BadRequest(c, "Failed to read file: "+err.Error())
+				return
+			}
+
+			url, err := h.cloudinaryUpload.UploadImage(fileData, fileHeader.Filename, fmt.Sprintf("returns/%s", req.SellerOrderID))
+			if err != nil {
+				util.ErrorResponse(c, http.StatusInternalServerError, "Failed to upload photo: "+err.Error(), nil)
+				return
+			}
+			photoURLs = append(photoURLs, url)
+		}
+	}
+
+	returnRequest, err := h.returnService.OpenReturn(c.Request.Context(), userID.(string), c.Param("id"), req, photoURLs)
+	if err != nil {
+		if err.Error() == "order not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrReturnWindowClosed) {
+			util.ErrorResponse(c, http.StatusUnprocessableEntity, err.Error(), nil)
+			return
+		}
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Return requested successfully", returnRequest)
+}
+
+// GetMyReturns handles listing the current user's return requests
+// GET /api/v1/returns?page=1&limit=10&status=requested
+func (h *ReturnHandler) GetMyReturns(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	returns, total, err := h.returnService.GetMyReturns(c.Request.Context(), userID.(string), c.Query("status"), page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Returns retrieved successfully", gin.H{
+		"returns": returns,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// GetMyReturn handles getting one of the current user's return requests
+// GET /api/v1/returns/:id
+func (h *ReturnHandler) GetMyReturn(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	returnRequest, err := h.returnService.GetMyReturn(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Return retrieved successfully", returnRequest)
+}
+
+// GetSellerReturns handles listing return requests for the current user's shop
+// GET /api/v1/sellers/me/returns?page=1&limit=10&status=requested
+func (h *ReturnHandler) GetSellerReturns(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	returns, total, err := h.returnService.GetSellerReturns(c.Request.Context(), userID.(string), c.Query("status"), page, limit)
+	if err != nil {
+		if err.Error() == "seller not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Returns retrieved successfully", gin.H{
+		"returns": returns,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// ApproveReturn handles the seller accepting a return request
+// PUT /api/v1/sellers/me/returns/:id/approve
+func (h *ReturnHandler) ApproveReturn(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req struct {
+		Note *string `json:"note,omitempty"` // e.g. where to send the item
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	returnRequest, err := h.returnService.ApproveReturn(c.Request.Context(), userID.(string), c.Param("id"), req.Note)
+	if err != nil {
+		h.handleSellerError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Return approved successfully", returnRequest)
+}
+
+// RejectReturn handles the seller turning down a return request
+// PUT /api/v1/sellers/me/returns/:id/reject
+func (h *ReturnHandler) RejectReturn(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required,max=1000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	returnRequest, err := h.returnService.RejectReturn(c.Request.Context(), userID.(string), c.Param("id"), req.Reason)
+	if err != nil {
+		h.handleSellerError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Return rejected successfully", returnRequest)
+}
+
+// ReceiveReturn handles the seller confirming the returned item arrived, which refunds the buyer.
+// Calling it again retries a refund that failed.
+// PUT /api/v1/sellers/me/returns/:id/receive
+func (h *ReturnHandler) ReceiveReturn(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	returnRequest, err := h.returnService.ReceiveReturn(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, service.ErrRefundFailed) {
+			util.ErrorResponse(c, http.StatusBadGateway, err.Error(), nil)
+			return
+		}
+		h.handleSellerError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Return received and refunded successfully", returnRequest)
+}
+
+func (h *ReturnHandler) handleSellerError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrInvalidReturnTransition) {
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		return
+	}
+	if err.Error() == "return not found" || err.Error() == "seller not found" {
+		util.NotFound(c, err.Error())
+		return
+	}
+	util.BadRequest(c, err.Error())
+}
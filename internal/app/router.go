@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"log"
 	"time"
 	"yourapp/internal/config"
@@ -15,7 +16,9 @@ import (
 	"gorm.io/gorm"
 )
 
-func NewRouter(cfg *config.Config) *gin.Engine {
+// NewRouter builds the HTTP router. The returned shutdown func stops background
+// workers and periodic jobs and waits for in-flight payment charges to complete.
+func NewRouter(cfg *config.Config) (*gin.Engine, func(ctx context.Context) error) {
 	// Set Gin mode
 	if cfg.ServerPort == "5000" {
 		gin.SetMode(gin.DebugMode)
@@ -28,6 +31,9 @@ func NewRouter(cfg *config.Config) *gin.Engine {
 	// CORS middleware
 	r.Use(corsMiddleware(cfg.ClientURL))
 
+	// Client IP in the request context (fraud rules)
+	r.Use(middleware.ClientIP())
+
 	// Rate limiting middleware (if enabled)
 	if cfg.RateLimitEnabled {
 		rateLimiter := middleware.NewRateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst)
@@ -35,12 +41,28 @@ func NewRouter(cfg *config.Config) *gin.Engine {
 		log.Printf("Rate limiting enabled: %d req/sec, burst: %d", cfg.RateLimitRPS, cfg.RateLimitBurst)
 	}
 
+	// Request deadlines (timeouts are counted for GET /admin/metrics/timeouts)
+	timeoutMetrics := util.NewTimeoutMetrics()
+	r.Use(middleware.Timeout(time.Duration(cfg.RequestTimeoutSeconds)*time.Second, middleware.ParseRouteTimeouts(cfg.RouteTimeouts), timeoutMetrics))
+
 	// Initialize database
-	db, err := initDB(cfg)
+	db, err := InitDB(cfg)
 	if err != nil {
 		panic("Failed to connect to database: " + err.Error())
 	}
 
+	// Fill seller_id on old order items so AutoMigrate can make it NOT NULL
+	if backfilled, err := repository.BackfillOrderItemSellers(db); err != nil {
+		panic("Failed to migrate database: " + err.Error())
+	} else if backfilled > 0 {
+		log.Printf("🔧 Backfilled seller_id on %d order item(s)", backfilled)
+	}
+	if backfilled, err := repository.BackfillProductSoldCounts(db); err != nil {
+		panic("Failed to migrate database: " + err.Error())
+	} else if backfilled > 0 {
+		log.Printf("🔧 Backfilled sold_count on %d product(s)", backfilled)
+	}
+
 	// Auto migrate
 	if err := db.AutoMigrate(
 		&model.User{},
@@ -48,15 +70,87 @@ func NewRouter(cfg *config.Config) *gin.Engine {
 		&model.Category{},
 		&model.Product{},
 		&model.ProductImage{},
+		&model.ProductVariant{},
+		&model.Tag{},
 		&model.Address{},
 		&model.Cart{},
 		&model.CartItem{},
+		&model.SavedForLaterItem{},
+		&model.WishlistItem{},
+		&model.OrderChatMessage{},
+		&model.OrderChatRead{},
+		&model.SellerWebhook{},
+		&model.SellerWebhookDelivery{},
+		&model.ScheduledReport{},
+		&model.ScheduledReportRun{},
+		&model.ProductStockState{},
+		&model.CartReminder{},
 		&model.Order{},
 		&model.OrderItem{},
+		&model.SellerOrder{},
+		&model.SellerSettlement{},
+		&model.ShippingLabel{},
+		&model.ReturnRequest{},
+		&model.ReturnRequestPhoto{},
+		&model.Dispute{},
+		&model.DisputePhoto{},
+		&model.DisputeMessage{},
+		&model.InsuranceClaim{},
+		&model.InsuranceClaimPhoto{},
+		&model.Warranty{},
+		&model.WarrantyClaim{},
+		&model.WarrantyClaimEvent{},
+		&model.DonationCause{},
+		&model.DonationLedgerEntry{},
+		&model.SupportTicket{},
+		&model.SupportTicketMessage{},
+		&model.SupportTicketAttachment{},
+		&model.CancellationRequest{},
+		&model.OrderTaxLine{},
+		&model.FraudRule{},
+		&model.FraudCheck{},
+		&model.ProductPriceSchedule{},
+		&model.ProductPriceHistory{},
+		&model.OrderStatusHistory{},
+		&model.OrderNote{},
+		&model.ProductFunnelStat{},
 		&model.Payment{},
+		&model.PaymentStatusRetry{},
+		&model.SavedCard{},
+		&model.PartnerAPIKey{},
+		&model.PartnerAPIUsage{},
+		&model.PartnerUsageAlert{},
+		&model.SandboxProduct{},
+		&model.SandboxStockAdjustment{},
+		&model.SandboxOrder{},
+		&model.SandboxOrderItem{},
+		&model.SandboxNotification{},
+		&model.StockAdjustment{},
+		&model.StockTake{},
+		&model.StockTakeCount{},
+		&model.Holiday{},
+		&model.Region{},
+		&model.AddressValidation{},
+		&model.AddressValidationRun{},
+		&model.DeliverySlot{},
+		&model.LicenseKey{},
+		&model.DigitalDelivery{},
+		&model.DeviceToken{},
+		&model.AffiliateAPIKey{},
+		&model.AffiliateAPIUsage{},
+		&model.AffiliateLink{},
+		&model.AffiliateCommission{},
+		&model.AffiliatePayout{},
+		&model.IdempotencyKey{},
+		&model.Coupon{},
+		&model.CouponRedemption{},
+		&model.CouponBatch{},
 	); err != nil {
 		panic("Failed to migrate database: " + err.Error())
 	}
+	if err := repository.EnsureSearchIndexes(db); err != nil {
+		log.Printf("Warning: %v. Order search will work without trigram indexes but slower.", err)
+	}
 
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
@@ -65,12 +159,63 @@ func NewRouter(cfg *config.Config) *gin.Engine {
 	productRepo := repository.NewProductRepository(db)
 	addressRepo := repository.NewAddressRepository(db)
 	cartRepo := repository.NewCartRepository(db)
+	couponRepo := repository.NewCouponRepository(db)
+	savedForLaterRepo := repository.NewSavedForLaterRepository(db)
+	wishlistRepo := repository.NewWishlistRepository(db)
+	sellerWebhookRepo := repository.NewSellerWebhookRepository(db)
+	scheduledReportRepo := repository.NewScheduledReportRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	cartReminderRepo := repository.NewCartReminderRepository(db)
+	retentionRepo := repository.NewRetentionRepository(db)
 	orderRepo := repository.NewOrderRepository(db)
 	paymentRepo := repository.NewPaymentRepository(db)
+	paymentRetryRepo := repository.NewPaymentStatusRetryRepository(db)
+	savedCardRepo := repository.NewSavedCardRepository(db)
+	partnerAPIKeyRepo := repository.NewPartnerAPIKeyRepository(db)
+	partnerUsageRepo := repository.NewPartnerUsageRepository(db)
+	sandboxRepo := repository.NewSandboxRepository(db)
+	affiliateRepo := repository.NewAffiliateRepository(db)
+	inventoryRepo := repository.NewInventoryRepository(db)
+	holidayRepo := repository.NewHolidayRepository(db)
+	referenceDataRepo := repository.NewReferenceDataRepository(db)
+	regionRepo := repository.NewRegionRepository(db)
+	addressValidationRepo := repository.NewAddressValidationRepository(db)
+	sellerOrderRepo := repository.NewSellerOrderRepository(db)
+	orderChatRepo := repository.NewOrderChatRepository(db)
+	shippingLabelRepo := repository.NewShippingLabelRepository(db)
+	fraudRepo := repository.NewFraudRepository(db)
+	orderItemRepo := repository.NewOrderItemRepository(db)
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+	returnRepo := repository.NewReturnRequestRepository(db)
+	disputeRepo := repository.NewDisputeRepository(db)
+	insuranceClaimRepo := repository.NewInsuranceClaimRepository(db)
+	warrantyRepo := repository.NewWarrantyRepository(db)
+	donationRepo := repository.NewDonationRepository(db)
+	supportTicketRepo := repository.NewSupportTicketRepository(db)
+	cancellationRepo := repository.NewCancellationRequestRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+	productPriceRepo := repository.NewProductPriceRepository(db)
+	productVariantRepo := repository.NewProductVariantRepository(db)
+	idempotencyRepo := repository.NewIdempotencyKeyRepository(db)
+	stockTakeRepo := repository.NewStockTakeRepository(db)
+	deliverySlotRepo := repository.NewDeliverySlotRepository(db)
+	digitalGoodsRepo := repository.NewDigitalGoodsRepository(db)
+	deviceTokenRepo := repository.NewDeviceTokenRepository(db)
 
 	// Initialize RabbitMQ with retry logic
 	rabbitMQ := initRabbitMQWithRetry(cfg)
 
+	// Initialize Redis (optional: used for payment status long-polling, stock counters and the cart cache)
+	redisClient, err := util.NewRedisClient(cfg)
+	if err != nil {
+		log.Printf("Warning: %v. Payment status long-polling and stock checks will fall back to the database.", err)
+	} else {
+		log.Println("Redis connected successfully")
+	}
+
+	// Periodic background jobs started by the services; stopped by the returned shutdown func
+	jobs := util.NewBackgroundJobs()
+
 	// Initialize email service
 	emailService := service.NewEmailService(cfg)
 
@@ -108,21 +253,141 @@ func NewRouter(cfg *config.Config) *gin.Engine {
 
 	// Initialize services
 	authService := service.NewAuthServiceWithConfig(userRepo, cfg.JWTSecret, rabbitMQ, cfg)
-	sellerService := service.NewSellerService(sellerRepo, userRepo)
 	categoryService := service.NewCategoryService(categoryRepo)
-	productService := service.NewProductService(productRepo, categoryRepo, sellerRepo)
-	cartService := service.NewCartService(cartRepo, productRepo)
-	orderService := service.NewOrderService(orderRepo, productRepo, addressRepo)
-	paymentService := service.NewPaymentService(paymentRepo, orderRepo, cfg)
+	calendarService := service.NewBusinessCalendarService(holidayRepo, cfg)
+	analyticsService := service.NewAnalyticsService(analyticsRepo, sellerRepo, calendarService)
+
+	// Lifecycle hooks: modules subscribe here instead of being wired into the services
+	hooks := service.NewHookRegistry()
+	hooks.OnAfterPaymentSuccess("analytics.purchase", func(ctx context.Context, order *model.Order) error {
+		analyticsService.RecordPurchase(ctx, order)
+		return nil
+	})
+	digitalGoodsService := service.NewDigitalGoodsService(digitalGoodsRepo, orderRepo, productRepo, sellerRepo, cfg)
+	hooks.OnAfterPaymentSuccess("digital.fulfillment", digitalGoodsService.FulfillOrder)
+
+	var fcmGateway service.FCMGateway
+	if cfg.FCMCredentialsFile != "" {
+		if fcmGateway, err = service.NewFCMGateway(cfg.FCMCredentialsFile); err != nil {
+			log.Printf("Warning: %v. Push notifications are disabled.", err)
+			fcmGateway = nil
+		}
+	}
+	pushService := service.NewPushService(deviceTokenRepo, orderRepo, fcmGateway)
+	hooks.OnAfterPaymentSuccess("push.payment_success", pushService.OnPaymentSuccess)
+	hooks.OnAfterPaymentStatusChange("push.payment_status", pushService.OnPaymentStatusChange)
+	hooks.OnAfterOrderStatusChange("push.order_status", pushService.OnOrderStatusChange)
+	fraudService := service.NewFraudService(fraudRepo, userRepo, addressRepo, calendarService, jobs)
+	hooks.OnBeforeOrderCreate("fraud.rules", fraudService.CheckOrder)
+	hooks.OnBeforePaymentCreate("fraud.rules", fraudService.CheckPayment)
+	affiliateCommissionService := service.NewAffiliateCommissionService(affiliateRepo, productRepo, cfg)
+	hooks.OnBeforeOrderCreate("affiliate.attribution", affiliateCommissionService.AttributeOrder)
+	hooks.OnAfterOrderStatusChange("affiliate.commission", affiliateCommissionService.OnOrderStatusChange)
+	orderChatService := service.NewOrderChatService(orderChatRepo, orderRepo, sellerOrderRepo, sellerRepo, pushService)
+	hooks.OnAfterPaymentSuccess("chat.payment_success", orderChatService.OnPaymentSuccess)
+	hooks.OnAfterOrderStatusChange("chat.order_status", orderChatService.OnOrderStatusChange)
+	hooks.OnAfterSellerOrderStatusChange("chat.seller_order_status", orderChatService.OnSellerOrderStatusChange)
+
+	productEventService := service.NewProductEventService(redisClient)
+	stockCacheService := service.NewStockCacheService(productRepo, redisClient, productEventService, cfg, jobs)
+	cartRepo = service.NewCachedCartRepository(cartRepo, redisClient, productEventService, cfg)
+	sellerWebhookService := service.NewSellerWebhookService(sellerWebhookRepo, sellerRepo, productRepo, stockCacheService, productEventService, cfg, jobs)
+	scheduledReportService := service.NewScheduledReportService(scheduledReportRepo, reportRepo, calendarService, rabbitMQ, cfg, jobs)
+	productQuotaService := service.NewProductQuotaService(productRepo, sellerRepo, cfg)
+	productPriceService := service.NewProductPriceService(productPriceRepo, productRepo, sellerRepo, productEventService, cfg, jobs)
+	productVariantService := service.NewProductVariantService(productVariantRepo, productRepo, sellerRepo, stockCacheService, productEventService)
+	productService := service.NewProductService(productRepo, categoryRepo, sellerRepo, analyticsService, stockCacheService, productQuotaService, productPriceService, productEventService, hooks, jobs)
+	hooks.OnAfterPaymentSuccess("catalog.sold_count", productService.RecordSale)
+	hooks.OnAfterOrderStatusChange("catalog.sold_count", productService.OnOrderStatusChange)
+	pricingService := service.NewPricingService(cfg)
+	couponService := service.NewCouponService(couponRepo, cartRepo, sellerRepo, pricingService, stockCacheService)
+	cartService := service.NewCartService(cartRepo, savedForLaterRepo, wishlistRepo, productRepo, analyticsService, stockCacheService, pricingService, userRepo, rabbitMQ, productEventService, cfg)
+	sellerService := service.NewSellerService(sellerRepo, userRepo, productRepo, orderRepo, cartService)
+	sandboxService := service.NewSandboxService(sandboxRepo)
+	partnerUsageService := service.NewPartnerUsageService(partnerUsageRepo, partnerAPIKeyRepo, sellerRepo, redisClient, rabbitMQ, cfg, jobs)
+	partnerService := service.NewPartnerService(partnerAPIKeyRepo, inventoryRepo, sellerRepo, productRepo, stockCacheService, sandboxService)
+	midtransBreaker := service.NewMidtransBreaker(cfg)
+	midtransGateway := service.NewMidtransGateway(cfg, timeoutMetrics, midtransBreaker)
+	paymentParser := service.NewPaymentNotificationParser()
+	paymentUpdater := service.NewPaymentStatusUpdater(paymentRepo, paymentRetryRepo, orderRepo, hooks, redisClient, cfg)
+	midtransBudget := service.NewMidtransBudget(cfg)
+	paymentPoller := service.NewPaymentPoller(paymentRepo, midtransGateway, paymentParser, paymentUpdater, midtransBudget, midtransBreaker)
+	paymentService := service.NewPaymentService(paymentRepo, orderRepo, savedCardRepo, midtransGateway, midtransBreaker, paymentParser, paymentUpdater, paymentPoller, hooks, rabbitMQ, redisClient, cfg)
+	deliverySlotService := service.NewDeliverySlotService(deliverySlotRepo, sellerRepo, calendarService)
+	donationService := service.NewDonationService(donationRepo, calendarService, cfg)
+	hooks.OnAfterPaymentSuccess("donation.ledger", donationService.RecordDonation)
+	scheduledReportService.RegisterReport(donationService.Report())
+	orderService := service.NewOrderService(orderRepo, productRepo, addressRepo, cartRepo, paymentService, calendarService, pricingService, analyticsService, stockCacheService, hooks, deliverySlotService, productPriceService, productEventService, couponService, donationService)
+	sellerOrderService := service.NewSellerOrderService(sellerOrderRepo, sellerRepo, orderRepo, orderItemRepo, calendarService, hooks)
+	var courierGateway service.CourierGateway
+	if cfg.CourierAPIKey != "" {
+		courierGateway = service.NewCourierGateway(cfg.CourierAPIURL, cfg.CourierAPIKey)
+	}
+	shippingLabelService := service.NewShippingLabelService(shippingLabelRepo, sellerOrderRepo, sellerRepo, orderRepo, courierGateway, cfg)
+	previewService := service.NewStorefrontPreviewService(sellerRepo, productRepo, cfg)
+	tagService := service.NewTagService(tagRepo, productRepo, sellerRepo)
+	stockTakeService := service.NewStockTakeService(stockTakeRepo, productRepo, sellerRepo, stockCacheService)
+	returnService := service.NewReturnService(returnRepo, orderRepo, sellerOrderRepo, sellerRepo, paymentService, cfg)
+	disputeService := service.NewDisputeService(disputeRepo, orderRepo, sellerOrderRepo, sellerRepo, paymentService, cfg)
+	insuranceClaimService := service.NewInsuranceClaimService(insuranceClaimRepo, orderRepo, sellerOrderRepo, sellerRepo, shippingLabelRepo, paymentService, cfg)
+	warrantyService := service.NewWarrantyService(warrantyRepo, sellerRepo, cfg)
+	hooks.OnAfterOrderStatusChange("warranty.activate", warrantyService.OnOrderStatusChange)
+	supportTicketService := service.NewSupportTicketService(supportTicketRepo, orderRepo, userRepo, rabbitMQ, cfg, jobs)
+	cancellationService := service.NewCancellationRequestService(cancellationRepo, orderRepo, sellerOrderRepo, sellerRepo, paymentService, stockCacheService, hooks, cfg, jobs)
+	configBundleService := service.NewConfigBundleService(referenceDataRepo, cfg)
+	affiliateService := service.NewAffiliateService(affiliateRepo, productRepo, categoryRepo, cfg)
+	retentionService := service.NewRetentionService(retentionRepo, cfg, jobs)
+	addressValidationService := service.NewAddressValidationService(addressValidationRepo, regionRepo)
+	fulfillmentService := service.NewFulfillmentService(sellerOrderRepo, orderRepo, productVariantRepo, partnerAPIKeyRepo, sellerRepo, hooks)
+
+	// Start background jobs that have no handlers
+	service.NewUnpaidOrderService(orderRepo, paymentService, stockCacheService, rabbitMQ, cfg, jobs)
+	service.NewCartReminderService(cartReminderRepo, cartRepo, userRepo, pushService, rabbitMQ, cfg, jobs)
 
 	// Initialize handlers
 	authHandler := NewAuthHandler(authService, cfg.JWTSecret)
 	sellerHandler := NewSellerHandler(sellerService)
 	categoryHandler := NewCategoryHandler(categoryService)
 	productHandler := NewProductHandler(productService, cfg)
-	cartHandler := NewCartHandler(cartService)
+	cartHandler := NewCartHandler(cartService, cfg)
 	orderHandler := NewOrderHandler(orderService)
-	paymentHandler := NewPaymentHandler(paymentService)
+	paymentHandler := NewPaymentHandler(paymentService, cfg)
+	partnerHandler := NewPartnerHandler(partnerService, sandboxService, partnerUsageService)
+	sellerWebhookHandler := NewSellerWebhookHandler(sellerWebhookService)
+	scheduledReportHandler := NewScheduledReportHandler(scheduledReportService)
+	calendarHandler := NewBusinessCalendarHandler(calendarService)
+	configBundleHandler := NewConfigBundleHandler(configBundleService)
+	addressValidationHandler := NewAddressValidationHandler(addressValidationService)
+	couponHandler := NewCouponHandler(couponService)
+	sellerOrderHandler := NewSellerOrderHandler(sellerOrderService)
+	shippingLabelHandler := NewShippingLabelHandler(shippingLabelService)
+	fraudHandler := NewFraudHandler(fraudService)
+	analyticsHandler := NewAnalyticsHandler(analyticsService)
+	previewHandler := NewStorefrontPreviewHandler(previewService)
+	productQuotaHandler := NewProductQuotaHandler(productQuotaService)
+	returnHandler := NewReturnHandler(returnService, cfg)
+	disputeHandler := NewDisputeHandler(disputeService, cfg)
+	insuranceClaimHandler := NewInsuranceClaimHandler(insuranceClaimService, cfg)
+	warrantyHandler := NewWarrantyHandler(warrantyService)
+	donationHandler := NewDonationHandler(donationService)
+	supportTicketHandler := NewSupportTicketHandler(supportTicketService, cfg)
+	orderChatHandler := NewOrderChatHandler(orderChatService)
+	cancellationHandler := NewCancellationHandler(cancellationService)
+	tagHandler := NewTagHandler(tagService)
+	productPriceHandler := NewProductPriceHandler(productPriceService)
+	productVariantHandler := NewProductVariantHandler(productVariantService)
+	stockTakeHandler := NewStockTakeHandler(stockTakeService)
+	deliverySlotHandler := NewDeliverySlotHandler(deliverySlotService)
+	digitalGoodsHandler := NewDigitalGoodsHandler(digitalGoodsService)
+	pushHandler := NewPushHandler(pushService)
+	affiliateCommissionHandler := NewAffiliateCommissionHandler(affiliateCommissionService, cfg.AffiliateCookieDays)
+	affiliateHandler := NewAffiliateHandler(affiliateService, middleware.NewRateLimiter(cfg.AffiliateRateRPS, cfg.AffiliateRateBurst))
+	metricsHandler := NewMetricsHandler(timeoutMetrics, retentionService)
+	fulfillmentHandler := NewFulfillmentHandler(fulfillmentService)
+
+	// Idempotency-Key replay for create endpoints (after auth)
+	idempotency := middleware.NewIdempotency(idempotencyRepo, jobs)
+	fieldSelection := middleware.NewFieldSelection(cfg.FieldSelectionRolloutPercent, cfg.FieldSelectionUserIDs)
 
 	// API routes
 	api := r.Group("/api/v1")
@@ -149,14 +414,70 @@ func NewRouter(cfg *config.Config) *gin.Engine {
 		sellers := api.Group("/sellers")
 		{
 			// Public: Get seller by ID
-			sellers.GET("/:id", sellerHandler.GetSeller)
+			sellers.GET("/:id", fieldSelection.Middleware(util.SellerFieldSet), sellerHandler.GetSeller)
+			sellers.GET("/:id/products", fieldSelection.Middleware(util.ProductFieldSet), productHandler.GetSellerProducts)
+			sellers.GET("/:id/delivery-slots", deliverySlotHandler.GetAvailableSlots)
 
 			// Protected: CRUD operations (requires auth)
 			sellersProtected := sellers.Group("")
 			sellersProtected.Use(authHandler.AuthMiddleware())
 			{
 				sellersProtected.POST("", sellerHandler.CreateSeller)
-				sellersProtected.GET("/me", sellerHandler.GetMySeller)
+				sellersProtected.GET("/me", fieldSelection.Middleware(util.SellerFieldSet), sellerHandler.GetMySeller)
+				sellersProtected.GET("/me/dashboard", sellerHandler.GetMyDashboard)
+				sellersProtected.GET("/me/scorecard", sellerHandler.GetMyScorecard)
+				sellersProtected.POST("/me/preview-token", previewHandler.CreateMyPreviewToken)
+				sellersProtected.GET("/me/product-quota", productQuotaHandler.GetMyQuota)
+				sellersProtected.GET("/me/analytics/products", analyticsHandler.GetProductFunnel)
+				sellersProtected.GET("/me/orders", sellerOrderHandler.GetMyOrders)
+				sellersProtected.GET("/me/orders/export", sellerOrderHandler.ExportMyOrders)
+				sellersProtected.GET("/me/orders/:id", sellerOrderHandler.GetMyOrder)
+				sellersProtected.PUT("/me/orders/:id/status", sellerOrderHandler.UpdateOrderStatus)
+				sellersProtected.PUT("/me/orders/:id/ship", sellerOrderHandler.ShipOrder)
+				sellersProtected.PUT("/me/orders/:id/items/:item_id/status", sellerOrderHandler.UpdateItemStatus)
+				sellersProtected.GET("/me/orders/:id/packing-slip", sellerOrderHandler.GetPackingSlip)
+				sellersProtected.GET("/me/orders/:id/chat", orderChatHandler.GetSellerThread)
+				sellersProtected.POST("/me/orders/:id/chat/messages", orderChatHandler.SellerSend)
+				sellersProtected.POST("/me/orders/:id/shipping-label", shippingLabelHandler.CreateLabel)
+				sellersProtected.GET("/me/orders/:id/shipping-label", shippingLabelHandler.GetLabel)
+				sellersProtected.GET("/me/sales", sellerOrderHandler.GetMySales)
+				sellersProtected.GET("/me/settlements", sellerOrderHandler.GetMySettlements)
+				sellersProtected.GET("/me/tax-report", sellerOrderHandler.GetMyTaxReport)
+				sellersProtected.GET("/me/tax-report/export", sellerOrderHandler.ExportMyTaxReport)
+				sellersProtected.GET("/me/returns", returnHandler.GetSellerReturns)
+				sellersProtected.PUT("/me/returns/:id/approve", returnHandler.ApproveReturn)
+				sellersProtected.PUT("/me/returns/:id/reject", returnHandler.RejectReturn)
+				sellersProtected.PUT("/me/returns/:id/receive", returnHandler.ReceiveReturn)
+				sellersProtected.GET("/me/disputes", disputeHandler.GetSellerDisputes)
+				sellersProtected.PUT("/me/disputes/:id/respond", disputeHandler.Respond)
+				sellersProtected.GET("/me/insurance-claims", insuranceClaimHandler.GetSellerClaims)
+				sellersProtected.GET("/me/warranty-claims", warrantyHandler.GetSellerClaims)
+				sellersProtected.PUT("/me/warranty-claims/:id/status", warrantyHandler.UpdateClaimStatus)
+				sellersProtected.GET("/me/cancellation-requests", cancellationHandler.GetSellerRequests)
+				sellersProtected.PUT("/me/cancellation-requests/:id/approve", cancellationHandler.Approve)
+				sellersProtected.PUT("/me/cancellation-requests/:id/reject", cancellationHandler.Reject)
+				sellersProtected.POST("/me/stock-takes", stockTakeHandler.StartStockTake)
+				sellersProtected.GET("/me/stock-takes", stockTakeHandler.GetStockTakes)
+				sellersProtected.GET("/me/stock-takes/:id", stockTakeHandler.GetStockTake)
+				sellersProtected.PUT("/me/stock-takes/:id/counts", stockTakeHandler.SubmitCounts)
+				sellersProtected.GET("/me/stock-takes/:id/variance", stockTakeHandler.GetVarianceReport)
+				sellersProtected.POST("/me/stock-takes/:id/apply", stockTakeHandler.ApplyStockTake)
+				sellersProtected.POST("/me/stock-takes/:id/cancel", stockTakeHandler.CancelStockTake)
+				sellersProtected.POST("/me/delivery-slots", deliverySlotHandler.CreateSlot)
+				sellersProtected.GET("/me/delivery-slots", deliverySlotHandler.GetMySlots)
+				sellersProtected.PUT("/me/delivery-slots/:id", deliverySlotHandler.UpdateSlot)
+				sellersProtected.DELETE("/me/delivery-slots/:id", deliverySlotHandler.DeleteSlot)
+				sellersProtected.POST("/me/api-keys", partnerHandler.CreateAPIKey)
+				sellersProtected.GET("/me/api-keys", partnerHandler.GetAPIKeys)
+				sellersProtected.DELETE("/me/api-keys/:id", partnerHandler.RevokeAPIKey)
+				sellersProtected.GET("/me/api-keys/:id/usage", partnerHandler.GetAPIKeyUsage)
+				sellersProtected.POST("/me/webhooks", sellerWebhookHandler.CreateWebhook)
+				sellersProtected.GET("/me/webhooks", sellerWebhookHandler.GetWebhooks)
+				sellersProtected.PUT("/me/webhooks/:id", sellerWebhookHandler.UpdateWebhook)
+				sellersProtected.DELETE("/me/webhooks/:id", sellerWebhookHandler.DeleteWebhook)
+				sellersProtected.GET("/me/webhooks/:id/deliveries", sellerWebhookHandler.GetDeliveries)
+				sellersProtected.POST("/me/webhooks/:id/test", sellerWebhookHandler.SendTest)
+				sellersProtected.POST("/me/api-keys/:id/callback-secret", fulfillmentHandler.RotateCallbackSecret)
 				sellersProtected.PUT("", sellerHandler.UpdateSeller)
 				sellersProtected.DELETE("", sellerHandler.DeleteSeller)
 			}
@@ -176,23 +497,54 @@ func NewRouter(cfg *config.Config) *gin.Engine {
 		// Product routes
 		products := api.Group("/products")
 		{
-			products.GET("", productHandler.GetProducts)
-			products.GET("/search", productHandler.SearchProducts)
-			products.GET("/:id", productHandler.GetProduct)
+			products.GET("", fieldSelection.Middleware(util.ProductFieldSet), productHandler.GetProducts)
+			products.GET("/search", fieldSelection.Middleware(util.ProductFieldSet), productHandler.SearchProducts)
+			products.GET("/:id", fieldSelection.Middleware(util.ProductFieldSet), productHandler.GetProduct)
+			products.GET("/:id/tags", tagHandler.GetProductTags)
+			products.GET("/:id/price-history", productPriceHandler.GetPriceHistory)
+			products.GET("/:id/variants", productVariantHandler.GetVariants)
 
 			// Protected routes (requires auth)
 			productsProtected := products.Group("")
 			productsProtected.Use(authHandler.AuthMiddleware())
 			{
 				productsProtected.POST("", productHandler.CreateProduct)
+				productsProtected.PUT("/schedule", productHandler.ScheduleProducts)
 				productsProtected.PUT("/:id", productHandler.UpdateProduct)
 				productsProtected.DELETE("/:id", productHandler.DeleteProduct)
 				productsProtected.POST("/:id/images", productHandler.AddProductImage)
 				productsProtected.POST("/:id/images/upload", productHandler.UploadMultipleProductImages)
 				productsProtected.DELETE("/images/:imageId", productHandler.DeleteProductImage)
+				productsProtected.PUT("/:id/tags", tagHandler.SetProductTags)
+				productsProtected.POST("/:id/tags", tagHandler.AddProductTag)
+				productsProtected.DELETE("/:id/tags/:tag", tagHandler.RemoveProductTag)
+				productsProtected.POST("/:id/price-schedules", productPriceHandler.SchedulePrice)
+				productsProtected.GET("/:id/price-schedules", productPriceHandler.GetPriceSchedules)
+				productsProtected.DELETE("/:id/price-schedules/:scheduleId", productPriceHandler.CancelPriceSchedule)
+				productsProtected.POST("/:id/variants", productVariantHandler.CreateVariant)
+				productsProtected.PUT("/:id/variants/:variantId", productVariantHandler.UpdateVariant)
+				productsProtected.DELETE("/:id/variants/:variantId", productVariantHandler.DeleteVariant)
+				productsProtected.POST("/:id/license-keys", digitalGoodsHandler.AddLicenseKeys)
 			}
 		}
 
+		// Tag routes (public)
+		tags := api.Group("/tags")
+		{
+			tags.GET("/suggest", tagHandler.SuggestTags)
+			tags.GET("/:tag/products", tagHandler.GetProductsByTag)
+		}
+
+		// Storefront preview (signed preview token instead of login; shows drafts)
+		preview := api.Group("/preview")
+		{
+			preview.GET("/storefront", previewHandler.GetStorefront)
+			preview.GET("/products/:id", previewHandler.GetProduct)
+		}
+
+		// Digital downloads (signed, expiring link instead of login)
+		api.GET("/downloads/:token", digitalGoodsHandler.Download)
+
 		// Cart routes (protected)
 		carts := api.Group("/carts")
 		carts.Use(authHandler.AuthMiddleware())
@@ -200,8 +552,22 @@ func NewRouter(cfg *config.Config) *gin.Engine {
 			carts.GET("", cartHandler.GetCart)
 			carts.DELETE("", cartHandler.ClearCart)
 			carts.GET("/items", cartHandler.GetCartItems)
+			carts.GET("/count", cartHandler.GetCartCount)
+			carts.GET("/summary", orderHandler.GetCartSummary)
+			carts.POST("/validate", cartHandler.ValidateCart)
+			carts.POST("/apply-voucher", couponHandler.ApplyVoucher)
+			carts.DELETE("/apply-voucher", couponHandler.RemoveVoucher)
+			carts.GET("/stock/stream", cartHandler.StreamCartStock)
 			carts.POST("/items", cartHandler.AddItemToCart)
+			carts.POST("/items/bulk", cartHandler.AddItemsToCart)
+			carts.PUT("/items/select", cartHandler.SelectCartItems)
 			carts.PUT("/items/:id", cartHandler.UpdateCartItem)
+			carts.PUT("/items/:id/select", cartHandler.SelectCartItem)
+			carts.POST("/items/:id/save-for-later", cartHandler.SaveForLater)
+			carts.GET("/saved", cartHandler.GetSavedForLater)
+			carts.POST("/saved/:id/move-to-cart", cartHandler.MoveToCart)
+			carts.DELETE("/saved/:id", cartHandler.RemoveSavedForLater)
+			carts.POST("/items/:id/move-to-wishlist", cartHandler.MoveToWishlist)
 			carts.DELETE("/items/:id", cartHandler.RemoveCartItem)
 		}
 
@@ -209,11 +575,102 @@ func NewRouter(cfg *config.Config) *gin.Engine {
 		orders := api.Group("/orders")
 		orders.Use(authHandler.AuthMiddleware())
 		{
-			orders.POST("", orderHandler.CreateOrder)
-			orders.GET("", orderHandler.GetOrders)
-			orders.GET("/:id", orderHandler.GetOrder)
+			orders.POST("", idempotency.Middleware(), orderHandler.CreateOrder)
+			orders.GET("", fieldSelection.Middleware(util.OrderFieldSet), orderHandler.GetOrders)
+			orders.GET("/search", fieldSelection.Middleware(util.OrderFieldSet), orderHandler.SearchOrders)
+			orders.GET("/:id", fieldSelection.Middleware(util.OrderFieldSet), orderHandler.GetOrder)
+			orders.GET("/:id/timeline", orderHandler.GetOrderTimeline)
+			orders.GET("/:id/invoice", orderHandler.GetInvoice)
+			orders.POST("/:id/cancel", orderHandler.CancelOrder)
+			orders.POST("/:id/cancellation-requests", cancellationHandler.RequestCancellation) // Paid orders; the seller decides
+			orders.POST("/:id/confirm-delivery", orderHandler.ConfirmDelivery)
+			orders.POST("/:id/reorder", orderHandler.Reorder)
+			orders.PUT("/:id/archive", orderHandler.ArchiveOrder)
+			orders.PUT("/:id/unarchive", orderHandler.UnarchiveOrder)
+			orders.POST("/:id/returns", returnHandler.OpenReturn)
+			orders.POST("/:id/disputes", disputeHandler.OpenDispute)
+			orders.POST("/:id/support-tickets", supportTicketHandler.OpenTicket)
+			orders.GET("/:id/chat", orderChatHandler.GetBuyerThreads)
+			orders.POST("/:id/chat/:seller_order_id/messages", orderChatHandler.BuyerSend)
+			orders.GET("/:id/downloads", digitalGoodsHandler.GetOrderDownloads)
+		}
+
+		// Return routes (buyer)
+		returns := api.Group("/returns")
+		returns.Use(authHandler.AuthMiddleware())
+		{
+			returns.GET("", returnHandler.GetMyReturns)
+			returns.GET("/:id", returnHandler.GetMyReturn)
+		}
+
+		// Cancellation request routes (buyer)
+		api.GET("/cancellation-requests", authHandler.AuthMiddleware(), cancellationHandler.GetMyRequests)
+
+		// Dispute routes (protected; buyer and seller of the dispute)
+		disputes := api.Group("/disputes")
+		disputes.Use(authHandler.AuthMiddleware())
+		{
+			disputes.GET("", disputeHandler.GetMyDisputes)
+			disputes.GET("/:id", disputeHandler.GetDispute)
+			disputes.POST("/:id/messages", disputeHandler.AddMessage)
+			disputes.PUT("/:id/escalate", disputeHandler.Escalate)
+			disputes.PUT("/:id/withdraw", disputeHandler.Withdraw)
+		}
+
+		// Shipping insurance claim routes (protected; buyer and seller of the order)
+		insuranceClaims := api.Group("/insurance-claims")
+		insuranceClaims.Use(authHandler.AuthMiddleware())
+		{
+			insuranceClaims.POST("", insuranceClaimHandler.FileClaim)
+			insuranceClaims.GET("", insuranceClaimHandler.GetMyClaims)
+			insuranceClaims.GET("/:id", insuranceClaimHandler.GetClaim)
 		}
 
+		// Warranty routes (protected; warranties are activated when an order with warranty protection is delivered)
+		warranties := api.Group("/warranties")
+		warranties.Use(authHandler.AuthMiddleware())
+		{
+			warranties.GET("", warrantyHandler.GetMyWarranties)
+			warranties.GET("/:id", warrantyHandler.GetWarranty)
+			warranties.POST("/:id/claims", warrantyHandler.FileClaim)
+		}
+		warrantyClaims := api.Group("/warranty-claims")
+		warrantyClaims.Use(authHandler.AuthMiddleware())
+		{
+			warrantyClaims.GET("", warrantyHandler.GetMyClaims)
+			warrantyClaims.GET("/:id", warrantyHandler.GetClaim)
+		}
+
+		// Donation routes (public; the cause checkout offers donations to)
+		api.GET("/donations/cause", donationHandler.GetActiveCause)
+
+		// Wishlist routes (protected)
+		wishlist := api.Group("/wishlist")
+		wishlist.Use(authHandler.AuthMiddleware())
+		{
+			wishlist.GET("", cartHandler.GetWishlist)
+			wishlist.POST("", cartHandler.AddToWishlist)
+			wishlist.DELETE("/:id", cartHandler.RemoveFromWishlist)
+		}
+
+		// Support ticket routes (protected; the buyer who opened the ticket)
+		supportTickets := api.Group("/support-tickets")
+		supportTickets.Use(authHandler.AuthMiddleware())
+		{
+			supportTickets.GET("", supportTicketHandler.GetMyTickets)
+			supportTickets.GET("/:id", supportTicketHandler.GetTicket)
+			supportTickets.POST("/:id/messages", supportTicketHandler.Reply)
+			supportTickets.PUT("/:id/close", supportTicketHandler.CloseTicket)
+		}
+
+		// Checkout routes
+		api.POST("/checkout", authHandler.AuthMiddleware(), idempotency.Middleware(), orderHandler.Checkout) // Converts cart items into an order
+		api.POST("/checkout/preview", authHandler.AuthMiddleware(), orderHandler.PreviewCheckout)
+		api.GET("/checkout/estimate", calendarHandler.GetShippingEstimate)
+
+		// Business calendar (public)
+		api.GET("/holidays", calendarHandler.GetHolidays)
+
 		// Payment routes
 		payments := api.Group("/payments")
 		{
@@ -223,23 +680,179 @@ func NewRouter(cfg *config.Config) *gin.Engine {
 			// Protected payment endpoints
 			payments.Use(authHandler.AuthMiddleware())
 			{
-				payments.POST("", paymentHandler.CreatePayment)
+				payments.POST("", idempotency.Middleware(), paymentHandler.CreatePayment)
 				payments.GET("/:id", paymentHandler.GetPayment)
 				payments.GET("/order/:order_id", paymentHandler.GetPaymentByOrder)
 				payments.GET("/:id/status", paymentHandler.CheckPaymentStatus)
+				payments.GET("/:id/qr.png", paymentHandler.GetPaymentQRCode)
+				payments.POST("/:id/proof", paymentHandler.UploadTransferProof)
+				payments.POST("/:id/resend-instructions", paymentHandler.ResendPaymentInstructions)
 			}
 		}
+
+		// User routes (protected)
+		users := api.Group("/users")
+		users.Use(authHandler.AuthMiddleware())
+		{
+			users.GET("/me/cards", paymentHandler.GetSavedCards)
+			users.DELETE("/me/cards/:id", paymentHandler.DeleteSavedCard)
+			users.POST("/me/devices", pushHandler.RegisterDevice)
+			users.DELETE("/me/devices", pushHandler.UnregisterDevice)
+			users.POST("/me/affiliate-keys", affiliateHandler.CreateAPIKey)
+			users.GET("/me/affiliate-keys", affiliateHandler.GetAPIKeys)
+			users.DELETE("/me/affiliate-keys/:id", affiliateHandler.RevokeAPIKey)
+			users.GET("/me/affiliate-keys/:id/usage", affiliateHandler.GetUsage)
+			users.POST("/me/affiliate-links", affiliateCommissionHandler.CreateLink)
+			users.GET("/me/affiliate-links", affiliateCommissionHandler.GetLinks)
+			users.GET("/me/affiliate/earnings", affiliateCommissionHandler.GetEarnings)
+			users.GET("/me/affiliate/payouts", affiliateCommissionHandler.GetMyPayouts)
+			users.GET("/me/address-suggestions", addressValidationHandler.GetSuggestions)
+			users.POST("/me/address-suggestions/:id/confirm", addressValidationHandler.ConfirmSuggestion)
+			users.POST("/me/address-suggestions/:id/dismiss", addressValidationHandler.DismissSuggestion)
+		}
+
+		// Partner routes (API key auth, for seller POS / inventory integrations)
+		partner := api.Group("/partner")
+		partner.Use(partnerHandler.APIKeyMiddleware())
+		{
+			partner.GET("/inventory", partnerHandler.GetInventoryChanges)
+			partner.POST("/inventory/adjustments", partnerHandler.AdjustStock)
+
+			// Live keys only: third-party fulfillment (3PL) warehouses working through paid sub-orders
+			partner.GET("/fulfillment/orders", fulfillmentHandler.GetReadyOrders)
+			partner.POST("/fulfillment/orders/:id/acknowledge", fulfillmentHandler.Acknowledge)
+			partner.POST("/fulfillment/orders/:id/shipment", fulfillmentHandler.PushShipment)
+			partner.POST("/fulfillment/reconcile", fulfillmentHandler.Reconcile)
+
+			// Sandbox keys only: fake orders, payments and notifications against sandbox data
+			partner.POST("/sandbox/orders", partnerHandler.CreateSandboxOrder)
+			partner.GET("/sandbox/orders", partnerHandler.GetSandboxOrders)
+			partner.GET("/sandbox/orders/:id", partnerHandler.GetSandboxOrder)
+			partner.POST("/sandbox/orders/:id/pay", partnerHandler.SimulateSandboxPayment)
+			partner.GET("/sandbox/notifications", partnerHandler.GetSandboxNotifications)
+			partner.POST("/sandbox/reset", partnerHandler.ResetSandbox)
+		}
+
+		// Affiliate routes (API key auth, read-only catalog with per-key quotas)
+		affiliate := api.Group("/affiliate")
+		affiliate.Use(affiliateHandler.APIKeyMiddleware())
+		{
+			affiliate.GET("/products", affiliateHandler.GetProducts)
+			affiliate.GET("/products/:id", affiliateHandler.GetProduct)
+			affiliate.GET("/categories", affiliateHandler.GetCategories)
+		}
+
+		// Fulfillment callbacks (signed with the API key's callback secret instead of X-API-Key)
+		api.POST("/partner/fulfillment/callbacks/:keyId", fulfillmentHandler.Callback)
+
+		// Admin routes (requires auth + admin role)
+		admin := api.Group("/admin")
+		admin.Use(authHandler.AuthMiddleware(), authHandler.AdminMiddleware())
+		{
+			admin.PUT("/payments/:id/verify", paymentHandler.VerifyManualTransfer)
+			admin.GET("/payments/midtrans-budget", paymentHandler.GetMidtransBudgetStats)
+			admin.GET("/payments/midtrans-breaker", paymentHandler.GetMidtransBreakerStats)
+			admin.GET("/metrics/timeouts", metricsHandler.GetTimeoutMetrics)
+			admin.GET("/metrics/retention", metricsHandler.GetRetentionMetrics)
+			admin.POST("/retention/run", metricsHandler.RunRetention)
+			admin.GET("/orders", orderHandler.AdminListOrders)
+			admin.GET("/orders/:id", orderHandler.AdminGetOrder)
+			admin.PUT("/orders/:id/status", orderHandler.AdminUpdateOrderStatus)
+			admin.POST("/orders/:id/notes", orderHandler.AdminAddOrderNote)
+			admin.PUT("/orders/:id/3ds", orderHandler.SetOrder3DSOverride)
+			admin.GET("/categories/trash", categoryHandler.GetDeletedCategories)
+			admin.PUT("/categories/:id/restore", categoryHandler.RestoreCategory)
+			admin.GET("/sellers/trash", sellerHandler.GetDeletedSellers)
+			admin.PUT("/sellers/:id/restore", sellerHandler.RestoreSeller)
+			admin.POST("/sellers/:id/preview-token", previewHandler.CreateSellerPreviewToken)
+			admin.PUT("/sellers/:id/product-limit", productQuotaHandler.SetSellerLimit)
+			admin.POST("/holidays", calendarHandler.CreateHoliday)
+			admin.DELETE("/holidays/:id", calendarHandler.DeleteHoliday)
+			admin.GET("/config-bundle", configBundleHandler.ExportBundle)
+			admin.POST("/config-bundle/import", configBundleHandler.ImportBundle)
+			admin.GET("/regions", addressValidationHandler.GetRegions)
+			admin.POST("/regions", addressValidationHandler.ImportRegions)
+			admin.POST("/address-validations/runs", addressValidationHandler.StartRun)
+			admin.GET("/address-validations/runs/:id", addressValidationHandler.GetRun)
+			admin.GET("/address-validations", addressValidationHandler.GetFlagged)
+			admin.PUT("/affiliate-keys/:id/quota", affiliateHandler.SetQuota)
+			admin.GET("/partner-usage", partnerHandler.GetUsageBilling)
+			admin.PUT("/partner-keys/:id/plan", partnerHandler.SetAPIKeyPlan)
+			admin.GET("/disputes", disputeHandler.AdminListDisputes)
+			admin.GET("/disputes/:id", disputeHandler.AdminGetDispute)
+			admin.POST("/disputes/:id/messages", disputeHandler.AdminAddMessage)
+			admin.PUT("/disputes/:id/resolve", disputeHandler.AdminResolveDispute)
+			admin.GET("/insurance-claims", insuranceClaimHandler.AdminListClaims)
+			admin.GET("/insurance-claims/:id", insuranceClaimHandler.AdminGetClaim)
+			admin.PUT("/insurance-claims/:id/decide", insuranceClaimHandler.AdminDecideClaim)
+			admin.GET("/support-tickets", supportTicketHandler.AdminListTickets)
+			admin.GET("/support-tickets/:id", supportTicketHandler.AdminGetTicket)
+			admin.PUT("/support-tickets/:id/assign", supportTicketHandler.AdminAssignTicket)
+			admin.POST("/support-tickets/:id/replies", supportTicketHandler.AdminReply)
+			admin.PUT("/support-tickets/:id/status", supportTicketHandler.AdminSetTicketStatus)
+			admin.GET("/affiliate-payouts", affiliateCommissionHandler.ListPayouts)
+			admin.POST("/affiliate-payouts", affiliateCommissionHandler.CreatePayout)
+			admin.PUT("/affiliate-payouts/:id/paid", affiliateCommissionHandler.MarkPayoutPaid)
+			admin.PUT("/affiliate-payouts/:id/cancel", affiliateCommissionHandler.CancelPayout)
+			admin.PUT("/affiliate-commissions/:id/void", affiliateCommissionHandler.VoidCommission)
+			admin.GET("/fraud-rules", fraudHandler.GetRules)
+			admin.POST("/fraud-rules", fraudHandler.CreateRule)
+			admin.PUT("/fraud-rules/:id", fraudHandler.UpdateRule)
+			admin.DELETE("/fraud-rules/:id", fraudHandler.DeleteRule)
+			admin.GET("/fraud-blocks", fraudHandler.GetBlockedAttempts)
+			admin.GET("/coupons", couponHandler.ListCoupons)
+			admin.POST("/coupons", couponHandler.CreateCoupon)
+			admin.PUT("/coupons/:id", couponHandler.UpdateCoupon)
+			admin.GET("/coupon-batches", couponHandler.ListCouponBatches)
+			admin.POST("/coupon-batches", couponHandler.GenerateCouponBatch)
+			admin.GET("/coupon-batches/:id", couponHandler.GetCouponBatch)
+			admin.GET("/coupon-batches/:id/export", couponHandler.ExportCouponBatch)
+			admin.GET("/scheduled-reports/definitions", scheduledReportHandler.GetDefinitions)
+			admin.GET("/scheduled-reports", scheduledReportHandler.GetReports)
+			admin.POST("/scheduled-reports", scheduledReportHandler.CreateReport)
+			admin.GET("/scheduled-reports/:id", scheduledReportHandler.GetReport)
+			admin.PUT("/scheduled-reports/:id", scheduledReportHandler.UpdateReport)
+			admin.DELETE("/scheduled-reports/:id", scheduledReportHandler.DeleteReport)
+			admin.GET("/scheduled-reports/:id/runs", scheduledReportHandler.GetRuns)
+			admin.POST("/scheduled-reports/:id/run", scheduledReportHandler.RunReport)
+			admin.GET("/donation-causes", donationHandler.ListCauses)
+			admin.POST("/donation-causes", donationHandler.CreateCause)
+			admin.GET("/donation-causes/:id", donationHandler.GetCause)
+			admin.PUT("/donation-causes/:id", donationHandler.UpdateCause)
+			admin.GET("/donation-causes/:id/ledger", donationHandler.GetLedger)
+			admin.POST("/donation-causes/:id/disbursements", donationHandler.RecordDisbursement)
+			admin.GET("/analytics/donations", donationHandler.GetSummary)
+		}
 	}
 
+	// Affiliate short links (kept outside /api/v1 so shared URLs stay short)
+	r.GET("/r/:slug", affiliateCommissionHandler.FollowLink)
+
 	// Health check
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
-	return r
+	shutdown := func(ctx context.Context) error {
+		// Jobs first: some of them still call into the payment and push services
+		err := jobs.Shutdown(ctx)
+		if paymentErr := paymentService.Shutdown(ctx); err == nil {
+			err = paymentErr
+		}
+		if pushErr := pushService.Shutdown(ctx); err == nil {
+			err = pushErr
+		}
+		if redisClient != nil {
+			redisClient.Close()
+		}
+		return err
+	}
+
+	return r, shutdown
 }
 
-func initDB(cfg *config.Config) (*gorm.DB, error) {
+// InitDB opens the Postgres connection described by the config
+func InitDB(cfg *config.Config) (*gorm.DB, error) {
 	dsn := cfg.DatabaseURL
 	if dsn == "" {
 		dsn = "host=" + cfg.PostgresHost +
@@ -293,7 +906,7 @@ func corsMiddleware(clientURL string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", clientURL)
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Idempotency-Key")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 
 		if c.Request.Method == "OPTIONS" {
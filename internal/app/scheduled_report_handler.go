@@ -0,0 +1,156 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ScheduledReportHandler struct {
+	scheduledReportService service.ScheduledReportService
+}
+
+func NewScheduledReportHandler(scheduledReportService service.ScheduledReportService) *ScheduledReportHandler {
+	return &ScheduledReportHandler{
+		scheduledReportService: scheduledReportService,
+	}
+}
+
+// GetDefinitions handles listing the reports that can be scheduled (admin only)
+// GET /api/v1/admin/scheduled-reports/definitions
+func (h *ScheduledReportHandler) GetDefinitions(c *gin.Context) {
+	util.SuccessResponse(c, http.StatusOK, "Report definitions retrieved successfully", h.scheduledReportService.GetDefinitions())
+}
+
+// CreateReport handles scheduling a report; a webhook signing secret is only returned here (admin only)
+// POST /api/v1/admin/scheduled-reports
+func (h *ScheduledReportHandler) CreateReport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.ScheduledReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.scheduledReportService.CreateReport(c.Request.Context(), userID.(string), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Scheduled report created successfully", result)
+}
+
+// GetReports handles listing scheduled reports (admin only)
+// GET /api/v1/admin/scheduled-reports?page=1&limit=20
+func (h *ScheduledReportHandler) GetReports(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	reports, total, err := h.scheduledReportService.GetReports(c.Request.Context(), page, limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Scheduled reports retrieved successfully", gin.H{
+		"reports": reports,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// GetReport handles getting a scheduled report (admin only)
+// GET /api/v1/admin/scheduled-reports/:id
+func (h *ScheduledReportHandler) GetReport(c *gin.Context) {
+	report, err := h.scheduledReportService.GetReport(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Scheduled report retrieved successfully", report)
+}
+
+// UpdateReport handles replacing a scheduled report's settings, e.g. to change its schedule or pause it (admin only)
+// PUT /api/v1/admin/scheduled-reports/:id
+func (h *ScheduledReportHandler) UpdateReport(c *gin.Context) {
+	var req service.ScheduledReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.scheduledReportService.UpdateReport(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Scheduled report updated successfully", result)
+}
+
+// DeleteReport handles removing a scheduled report and its run history (admin only)
+// DELETE /api/v1/admin/scheduled-reports/:id
+func (h *ScheduledReportHandler) DeleteReport(c *gin.Context) {
+	if err := h.scheduledReportService.DeleteReport(c.Request.Context(), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Scheduled report deleted successfully", nil)
+}
+
+// GetRuns handles listing a scheduled report's runs, newest first (admin only)
+// GET /api/v1/admin/scheduled-reports/:id/runs?page=1&limit=20
+func (h *ScheduledReportHandler) GetRuns(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	runs, total, err := h.scheduledReportService.GetRuns(c.Request.Context(), c.Param("id"), page, limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Report runs retrieved successfully", gin.H{
+		"runs":  runs,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// RunReport handles running a scheduled report now and delivering it to its recipients (admin only)
+// POST /api/v1/admin/scheduled-reports/:id/run
+func (h *ScheduledReportHandler) RunReport(c *gin.Context) {
+	run, err := h.scheduledReportService.RunNow(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Report run completed", run)
+}
+
+func (h *ScheduledReportHandler) handleError(c *gin.Context, err error) {
+	var internalErr *service.InternalError
+	switch {
+	case err.Error() == "scheduled report not found":
+		util.NotFound(c, err.Error())
+	case errors.As(err, &internalErr):
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+	default:
+		util.BadRequest(c, err.Error())
+	}
+}
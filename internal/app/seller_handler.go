@@ -2,6 +2,7 @@ package app
 
 import (
 	"net/http"
+	"strconv"
 
 	"yourapp/internal/service"
 	"yourapp/internal/util"
@@ -128,3 +129,75 @@ func (h *SellerHandler) DeleteSeller(c *gin.Context) {
 
 	util.SuccessResponse(c, http.StatusOK, "Shop deleted successfully", nil)
 }
+
+// GetMyDashboard handles getting the current user's shop dashboard
+// GET /api/v1/sellers/me/dashboard
+func (h *SellerHandler) GetMyDashboard(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	dashboard, err := h.sellerService.GetDashboard(userID.(string))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Dashboard retrieved successfully", dashboard)
+}
+
+// GetMyScorecard handles getting the current user's shop delivery scorecard
+// GET /api/v1/sellers/me/scorecard
+func (h *SellerHandler) GetMyScorecard(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	scorecard, err := h.sellerService.GetScorecard(c.Request.Context(), userID.(string))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Scorecard retrieved successfully", scorecard)
+}
+
+// GetDeletedSellers handles listing soft-deleted shops for admins
+// GET /api/v1/admin/sellers/trash?page=1&limit=10
+func (h *SellerHandler) GetDeletedSellers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	sellers, total, err := h.sellerService.GetDeletedSellers(page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Deleted sellers retrieved successfully", gin.H{
+		"sellers": sellers,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// RestoreSeller handles restoring a soft-deleted shop
+// PUT /api/v1/admin/sellers/:id/restore
+func (h *SellerHandler) RestoreSeller(c *gin.Context) {
+	seller, err := h.sellerService.RestoreSeller(c.Param("id"))
+	if err != nil {
+		if err.Error() == "seller not found in trash" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Seller restored successfully", seller)
+}
@@ -0,0 +1,367 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"yourapp/internal/repository"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SellerOrderHandler struct {
+	sellerOrderService service.SellerOrderService
+}
+
+func NewSellerOrderHandler(sellerOrderService service.SellerOrderService) *SellerOrderHandler {
+	return &SellerOrderHandler{
+		sellerOrderService: sellerOrderService,
+	}
+}
+
+// GetMyOrders handles listing the current user's shop sub-orders
+// GET /api/v1/sellers/me/orders?page=1&limit=10&status=paid&from=2024-01-01&to=2024-01-31&q=keyword
+func (h *SellerOrderHandler) GetMyOrders(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	query := service.SellerOrderQuery{
+		Status: c.Query("status"), // Optional: pending, paid, processing, shipped, delivered, cancelled
+		From:   c.Query("from"),   // Optional: YYYY-MM-DD
+		To:     c.Query("to"),     // Optional: YYYY-MM-DD
+		Search: c.Query("q"),      // Optional: sub-order number, product name or recipient name
+	}
+
+	orders, total, err := h.sellerOrderService.GetOrders(c.Request.Context(), userID.(string), query, page, limit)
+	if err != nil {
+		var dateRangeErr *service.DateRangeError
+		switch {
+		case err.Error() == "seller not found":
+			util.NotFound(c, err.Error())
+		case errors.As(err, &dateRangeErr):
+			util.BadRequest(c, err.Error())
+		default:
+			util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		}
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Orders retrieved successfully", gin.H{
+		"orders": orders,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// ExportMyOrders handles streaming the current user's shop sub-orders as CSV, one row per line item
+// GET /api/v1/sellers/me/orders/export?from=2024-01-01&to=2024-01-31&status=delivered
+func (h *SellerOrderHandler) ExportMyOrders(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	query := service.SellerOrderQuery{
+		Status: c.Query("status"), // Optional
+		From:   c.Query("from"),   // Optional: YYYY-MM-DD
+		To:     c.Query("to"),     // Optional: YYYY-MM-DD
+		Search: c.Query("q"),      // Optional
+	}
+
+	filename := "orders"
+	if query.From != "" {
+		filename += "-" + query.From
+	}
+	if query.To != "" {
+		filename += "-" + query.To
+	}
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".csv"))
+
+	err := h.sellerOrderService.ExportOrders(c.Request.Context(), userID.(string), query, c.Writer)
+	if err != nil && !c.Writer.Written() {
+		c.Header("Content-Type", "")
+		c.Header("Content-Disposition", "")
+		var dateRangeErr *service.DateRangeError
+		switch {
+		case err.Error() == "seller not found":
+			util.NotFound(c, err.Error())
+		case errors.As(err, &dateRangeErr):
+			util.BadRequest(c, err.Error())
+		default:
+			util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		}
+	}
+}
+
+// GetMyOrder handles getting one of the current user's shop sub-orders
+// GET /api/v1/sellers/me/orders/:id
+func (h *SellerOrderHandler) GetMyOrder(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	order, err := h.sellerOrderService.GetOrder(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Order retrieved successfully", order)
+}
+
+// UpdateSellerOrderStatusRequest moves a paid sub-order forward
+type UpdateSellerOrderStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=processing"`
+}
+
+// UpdateOrderStatus handles the seller moving their sub-order to processing
+// PUT /api/v1/sellers/me/orders/:id/status
+func (h *SellerOrderHandler) UpdateOrderStatus(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req UpdateSellerOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	order, err := h.sellerOrderService.UpdateOrderStatus(c.Request.Context(), userID.(string), c.Param("id"), req.Status)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidSellerOrderTransition) {
+			util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
+		if err.Error() == "order not found" || err.Error() == "seller not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Order status updated successfully", order)
+}
+
+// ShipOrder handles the seller handing their sub-order to the courier with its tracking number (AWB)
+// PUT /api/v1/sellers/me/orders/:id/ship
+func (h *SellerOrderHandler) ShipOrder(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.ShipSellerOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	order, err := h.sellerOrderService.ShipOrder(c.Request.Context(), userID.(string), c.Param("id"), req)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidSellerOrderTransition) {
+			util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
+		if err.Error() == "order not found" || err.Error() == "seller not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Order shipped successfully", order)
+}
+
+// UpdateItemStatus handles the shop updating the fulfillment status of one item in its sub-order
+// PUT /api/v1/sellers/me/orders/:id/items/:item_id/status
+func (h *SellerOrderHandler) UpdateItemStatus(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.UpdateOrderItemStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	order, err := h.sellerOrderService.UpdateItemStatus(c.Request.Context(), userID.(string), c.Param("id"), c.Param("item_id"), req)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidOrderItemTransition) {
+			util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
+		if err.Error() == "order not found" || err.Error() == "seller not found" || err.Error() == "order item not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Order item status updated successfully", order)
+}
+
+// GetMySales handles summarising the shop's paid sales, overall and per product
+// GET /api/v1/sellers/me/sales?from=2024-01-01&to=2024-01-31
+func (h *SellerOrderHandler) GetMySales(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	sales, err := h.sellerOrderService.GetSales(c.Request.Context(), userID.(string), c.Query("from"), c.Query("to"))
+	if err != nil {
+		var dateRangeErr *service.DateRangeError
+		switch {
+		case err.Error() == "seller not found":
+			util.NotFound(c, err.Error())
+		case errors.As(err, &dateRangeErr):
+			util.BadRequest(c, err.Error())
+		default:
+			util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		}
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Sales retrieved successfully", sales)
+}
+
+// GetMyTaxReport handles getting the PPN the current user's shop charged in a month
+// GET /api/v1/sellers/me/tax-report?month=2024-01
+func (h *SellerOrderHandler) GetMyTaxReport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	report, err := h.sellerOrderService.GetTaxReport(c.Request.Context(), userID.(string), c.Query("month"))
+	if err != nil {
+		if err.Error() == "seller not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Tax report retrieved successfully", report)
+}
+
+// ExportMyTaxReport handles downloading the month's tax report as CSV, one row per sub-order
+// GET /api/v1/sellers/me/tax-report/export?month=2024-01
+func (h *SellerOrderHandler) ExportMyTaxReport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	filename := "tax-report"
+	if month := c.Query("month"); month != "" {
+		filename += "-" + month
+	}
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".csv"))
+
+	err := h.sellerOrderService.ExportTaxReport(c.Request.Context(), userID.(string), c.Query("month"), c.Writer)
+	if err != nil && !c.Writer.Written() {
+		c.Header("Content-Type", "")
+		c.Header("Content-Disposition", "")
+		if err.Error() == "seller not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.BadRequest(c, err.Error())
+	}
+}
+
+// GetMySettlements handles listing what the marketplace owes the shop for delivered orders
+// GET /api/v1/sellers/me/settlements?page=1&limit=10&status=pending
+func (h *SellerOrderHandler) GetMySettlements(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	status := c.Query("status") // Optional: pending, paid
+
+	settlements, total, err := h.sellerOrderService.GetSettlements(c.Request.Context(), userID.(string), status, page, limit)
+	if err != nil {
+		if err.Error() == "seller not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Settlements retrieved successfully", gin.H{
+		"settlements": settlements,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+	})
+}
+
+// GetPackingSlip handles getting the packing slip for a sub-order (prices are hidden for gifts).
+// The slip is returned as a printable PDF with format=pdf or an Accept: application/pdf header.
+// GET /api/v1/sellers/me/orders/:id/packing-slip?format=pdf
+func (h *SellerOrderHandler) GetPackingSlip(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	slip, err := h.sellerOrderService.GetPackingSlip(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	if wantsPDF(c) {
+		pdf, err := service.RenderPackingSlipPDF(slip)
+		if err != nil {
+			util.ErrorResponse(c, http.StatusInternalServerError, "failed to render packing slip: "+err.Error(), nil)
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", "packing-slip-"+slip.SubOrderNumber+".pdf"))
+		c.Data(http.StatusOK, "application/pdf", pdf)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Packing slip retrieved successfully", slip)
+}
+
+// wantsPDF reports whether the client asked for a PDF document instead of JSON
+func wantsPDF(c *gin.Context) bool {
+	return c.Query("format") == "pdf" || strings.Contains(c.GetHeader("Accept"), "application/pdf")
+}
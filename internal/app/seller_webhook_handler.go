@@ -0,0 +1,159 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SellerWebhookHandler struct {
+	webhookService service.SellerWebhookService
+}
+
+func NewSellerWebhookHandler(webhookService service.SellerWebhookService) *SellerWebhookHandler {
+	return &SellerWebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhook handles registering an endpoint for product events; the signing secret is only returned here
+// POST /api/v1/sellers/me/webhooks
+func (h *SellerWebhookHandler) CreateWebhook(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.SellerWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.webhookService.CreateWebhook(c.Request.Context(), userID.(string), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Webhook created successfully", result)
+}
+
+// GetWebhooks handles listing the current seller's webhooks
+// GET /api/v1/sellers/me/webhooks
+func (h *SellerWebhookHandler) GetWebhooks(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	webhooks, err := h.webhookService.GetWebhooks(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Webhooks retrieved successfully", webhooks)
+}
+
+// UpdateWebhook handles changing a webhook's URL, events or active flag
+// PUT /api/v1/sellers/me/webhooks/:id
+func (h *SellerWebhookHandler) UpdateWebhook(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.SellerWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateWebhook(c.Request.Context(), userID.(string), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Webhook updated successfully", webhook)
+}
+
+// DeleteWebhook handles removing a webhook and its delivery log
+// DELETE /api/v1/sellers/me/webhooks/:id
+func (h *SellerWebhookHandler) DeleteWebhook(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), userID.(string), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Webhook deleted successfully", nil)
+}
+
+// GetDeliveries handles listing a webhook's deliveries
+// GET /api/v1/sellers/me/webhooks/:id/deliveries?status=failed&page=1&limit=20
+func (h *SellerWebhookHandler) GetDeliveries(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	deliveries, total, err := h.webhookService.GetDeliveries(c.Request.Context(), userID.(string), c.Param("id"), c.Query("status"), page, limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Deliveries retrieved successfully", gin.H{
+		"deliveries": deliveries,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+	})
+}
+
+// SendTest handles queueing a webhook.test event to check an endpoint
+// POST /api/v1/sellers/me/webhooks/:id/test
+func (h *SellerWebhookHandler) SendTest(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	delivery, err := h.webhookService.SendTest(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusAccepted, "Test event queued successfully", delivery)
+}
+
+func (h *SellerWebhookHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "seller not found" || err.Error() == "webhook not found":
+		util.NotFound(c, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+	default:
+		util.BadRequest(c, err.Error())
+	}
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"yourapp/internal/repository"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShippingLabelHandler struct {
+	labelService service.ShippingLabelService
+}
+
+func NewShippingLabelHandler(labelService service.ShippingLabelService) *ShippingLabelHandler {
+	return &ShippingLabelHandler{
+		labelService: labelService,
+	}
+}
+
+// CreateLabel handles booking a courier pickup for one of the current user's shop sub-orders
+// POST /api/v1/sellers/me/orders/:id/shipping-label
+func (h *ShippingLabelHandler) CreateLabel(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.CreateShippingLabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	label, err := h.labelService.CreateLabel(c.Request.Context(), userID.(string), c.Param("id"), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrShippingLabelsUnavailable):
+			util.ErrorResponse(c, http.StatusServiceUnavailable, err.Error(), nil)
+		case errors.Is(err, repository.ErrShippingLabelExists):
+			util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		case err.Error() == "seller not found" || err.Error() == "order not found":
+			util.NotFound(c, err.Error())
+		case strings.HasPrefix(err.Error(), "failed to book shipment"):
+			util.ErrorResponse(c, http.StatusBadGateway, err.Error(), nil)
+		case strings.HasPrefix(err.Error(), "failed to"):
+			util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		default:
+			util.BadRequest(c, err.Error())
+		}
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Shipping label created successfully", label)
+}
+
+// GetLabel handles getting a sub-order's shipping label; format=pdf (or Accept: application/pdf)
+// returns the printable 100 x 150 mm label
+// GET /api/v1/sellers/me/orders/:id/shipping-label?format=pdf
+func (h *ShippingLabelHandler) GetLabel(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if wantsPDF(c) {
+		label, pdf, err := h.labelService.GetLabelPDF(c.Request.Context(), userID.(string), c.Param("id"))
+		if err != nil {
+			if strings.HasPrefix(err.Error(), "failed to") {
+				util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+				return
+			}
+			util.NotFound(c, err.Error())
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", "label-"+*label.Waybill+".pdf"))
+		c.Data(http.StatusOK, "application/pdf", pdf)
+		return
+	}
+
+	label, err := h.labelService.GetLabel(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Shipping label retrieved successfully", label)
+}
@@ -0,0 +1,181 @@
+package app
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"yourapp/internal/repository"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockTakeHandler struct {
+	stockTakeService service.StockTakeService
+}
+
+func NewStockTakeHandler(stockTakeService service.StockTakeService) *StockTakeHandler {
+	return &StockTakeHandler{
+		stockTakeService: stockTakeService,
+	}
+}
+
+// StartStockTake handles opening a stock take (stock opname) for the current user's shop
+// POST /api/v1/sellers/me/stock-takes
+func (h *StockTakeHandler) StartStockTake(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.StartStockTakeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	take, err := h.stockTakeService.StartStockTake(c.Request.Context(), userID.(string), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Stock take started successfully", take)
+}
+
+// GetStockTakes handles listing the current user's stock takes
+// GET /api/v1/sellers/me/stock-takes?page=1&limit=10
+func (h *StockTakeHandler) GetStockTakes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	takes, total, err := h.stockTakeService.GetStockTakes(c.Request.Context(), userID.(string), page, limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Stock takes retrieved successfully", gin.H{
+		"stock_takes": takes,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+	})
+}
+
+// GetStockTake handles getting a stock take with its counts
+// GET /api/v1/sellers/me/stock-takes/:id
+func (h *StockTakeHandler) GetStockTake(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	take, err := h.stockTakeService.GetStockTake(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Stock take retrieved successfully", take)
+}
+
+// SubmitCounts handles submitting counted quantities; counting a product again replaces its count
+// PUT /api/v1/sellers/me/stock-takes/:id/counts
+func (h *StockTakeHandler) SubmitCounts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.SubmitStockCountsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	take, err := h.stockTakeService.SubmitCounts(c.Request.Context(), userID.(string), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Counts saved successfully", take)
+}
+
+// GetVarianceReport handles comparing counted quantities with system stock
+// GET /api/v1/sellers/me/stock-takes/:id/variance
+func (h *StockTakeHandler) GetVarianceReport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	report, err := h.stockTakeService.GetVarianceReport(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Variance report retrieved successfully", report)
+}
+
+// ApplyStockTake handles correcting product stock from the counts and closing the stock take
+// POST /api/v1/sellers/me/stock-takes/:id/apply
+func (h *StockTakeHandler) ApplyStockTake(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	response, err := h.stockTakeService.ApplyStockTake(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Stock take applied successfully", response)
+}
+
+// CancelStockTake handles discarding an open stock take without changing stock
+// POST /api/v1/sellers/me/stock-takes/:id/cancel
+func (h *StockTakeHandler) CancelStockTake(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	take, err := h.stockTakeService.CancelStockTake(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Stock take cancelled successfully", take)
+}
+
+func (h *StockTakeHandler) handleError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrStockTakeNotOpen) {
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		return
+	}
+	if err.Error() == "stock take not found" || err.Error() == "seller not found" {
+		util.NotFound(c, err.Error())
+		return
+	}
+	util.BadRequest(c, err.Error())
+}
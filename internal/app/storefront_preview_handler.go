@@ -0,0 +1,102 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StorefrontPreviewHandler struct {
+	previewService service.StorefrontPreviewService
+}
+
+func NewStorefrontPreviewHandler(previewService service.StorefrontPreviewService) *StorefrontPreviewHandler {
+	return &StorefrontPreviewHandler{
+		previewService: previewService,
+	}
+}
+
+// CreateMyPreviewToken handles creating a storefront preview link for the current user's shop
+// POST /api/v1/sellers/me/preview-token
+func (h *StorefrontPreviewHandler) CreateMyPreviewToken(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	token, err := h.previewService.CreateSellerPreviewToken(userID.(string))
+	if err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Preview token created successfully", token)
+}
+
+// CreateSellerPreviewToken handles creating a storefront preview link for any shop (admin only)
+// POST /api/v1/admin/sellers/:id/preview-token
+func (h *StorefrontPreviewHandler) CreateSellerPreviewToken(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	token, err := h.previewService.CreateAdminPreviewToken(adminID.(string), c.Param("id"))
+	if err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Preview token created successfully", token)
+}
+
+// GetStorefront handles previewing a shop's storefront, drafts included
+// GET /api/v1/preview/storefront?token=...&page=1&limit=10 (or X-Preview-Token header)
+func (h *StorefrontPreviewHandler) GetStorefront(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	preview, err := h.previewService.GetStorefront(previewToken(c), page, limit)
+	if err != nil {
+		h.previewError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Storefront preview retrieved successfully", preview)
+}
+
+// GetProduct handles previewing one of the shop's products, drafts included
+// GET /api/v1/preview/products/:id?token=... (or X-Preview-Token header)
+func (h *StorefrontPreviewHandler) GetProduct(c *gin.Context) {
+	product, err := h.previewService.GetProduct(previewToken(c), c.Param("id"))
+	if err != nil {
+		h.previewError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Product preview retrieved successfully", product)
+}
+
+func (h *StorefrontPreviewHandler) previewError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "preview token is required", "invalid or expired preview token":
+		util.Unauthorized(c, err.Error())
+	case "seller not found", "product not found":
+		util.NotFound(c, err.Error())
+	default:
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+	}
+}
+
+// previewToken reads the preview token from the X-Preview-Token header or the token query parameter
+func previewToken(c *gin.Context) string {
+	if token := c.GetHeader("X-Preview-Token"); token != "" {
+		return token
+	}
+	return c.Query("token")
+}
@@ -0,0 +1,360 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"yourapp/internal/config"
+	"yourapp/internal/repository"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SupportTicketHandler struct {
+	ticketService    service.SupportTicketService
+	cloudinaryUpload *util.CloudinaryUploader
+}
+
+func NewSupportTicketHandler(ticketService service.SupportTicketService, cfg *config.Config) *SupportTicketHandler {
+	var uploader *util.CloudinaryUploader
+	if cfg.CloudinaryCloudName != "" && cfg.CloudinaryAPIKey != "" && cfg.CloudinaryAPISecret != "" {
+		uploader = util.NewCloudinaryUploader(cfg.CloudinaryCloudName, cfg.CloudinaryAPIKey, cfg.CloudinaryAPISecret)
+	}
+
+	return &SupportTicketHandler{
+		ticketService:    ticketService,
+		cloudinaryUpload: uploader,
+	}
+}
+
+type SupportMessageRequest struct {
+	Message string `form:"message" binding:"required,max=5000"`
+}
+
+type AssignSupportTicketRequest struct {
+	AssigneeID string `json:"assignee_id"` // Empty to unassign
+}
+
+type SupportTicketStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=open resolved closed"`
+}
+
+// OpenTicket handles a buyer opening a support ticket about an order
+// POST /api/v1/orders/:id/support-tickets (multipart form: subject, category, message, attachments[])
+func (h *SupportTicketHandler) OpenTicket(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.OpenSupportTicketRequest
+	if err := c.ShouldBind(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	attachments, ok := h.uploadAttachments(c, fmt.Sprintf("support/%s", userID.(string)))
+	if !ok {
+		return
+	}
+
+	ticket, err := h.ticketService.OpenTicket(c.Request.Context(), userID.(string), c.Param("id"), req, attachments)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Support ticket opened successfully", ticket)
+}
+
+// GetMyTickets handles listing the current user's support tickets
+// GET /api/v1/support-tickets?page=1&limit=10&status=open
+func (h *SupportTicketHandler) GetMyTickets(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	tickets, total, err := h.ticketService.GetMyTickets(c.Request.Context(), userID.(string), c.Query("status"), page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Support tickets retrieved successfully", gin.H{
+		"tickets": tickets,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// GetTicket handles getting one of the current user's tickets with its thread
+// GET /api/v1/support-tickets/:id
+func (h *SupportTicketHandler) GetTicket(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	ticket, err := h.ticketService.GetTicket(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Support ticket retrieved successfully", ticket)
+}
+
+// Reply handles the buyer replying to their ticket
+// POST /api/v1/support-tickets/:id/messages (multipart form: message, attachments[])
+func (h *SupportTicketHandler) Reply(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req SupportMessageRequest
+	if err := c.ShouldBind(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	attachments, ok := h.uploadAttachments(c, fmt.Sprintf("support/%s", userID.(string)))
+	if !ok {
+		return
+	}
+
+	ticket, err := h.ticketService.Reply(c.Request.Context(), userID.(string), c.Param("id"), req.Message, attachments)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Reply sent successfully", ticket)
+}
+
+// CloseTicket handles the buyer closing their ticket
+// PUT /api/v1/support-tickets/:id/close
+func (h *SupportTicketHandler) CloseTicket(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	ticket, err := h.ticketService.Close(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Support ticket closed successfully", ticket)
+}
+
+// AdminListTickets handles listing support tickets for agents
+// GET /api/v1/admin/support-tickets?page=1&limit=20&status=open&assignee_id=me&unassigned=true&breached=true
+func (h *SupportTicketHandler) AdminListTickets(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	filter := repository.SupportTicketFilter{
+		Status:     c.Query("status"),
+		AssigneeID: c.Query("assignee_id"),
+		Unassigned: c.Query("unassigned") == "true",
+		Breached:   c.Query("breached") == "true",
+	}
+	if filter.AssigneeID == "me" {
+		filter.AssigneeID = adminID.(string)
+	}
+
+	tickets, total, err := h.ticketService.ListTickets(c.Request.Context(), filter, page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Support tickets retrieved successfully", gin.H{
+		"tickets": tickets,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// AdminGetTicket handles getting any ticket with its thread
+// GET /api/v1/admin/support-tickets/:id
+func (h *SupportTicketHandler) AdminGetTicket(c *gin.Context) {
+	ticket, err := h.ticketService.GetTicketForAdmin(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Support ticket retrieved successfully", ticket)
+}
+
+// AdminAssignTicket handles assigning a ticket to an admin, or unassigning it
+// PUT /api/v1/admin/support-tickets/:id/assign
+func (h *SupportTicketHandler) AdminAssignTicket(c *gin.Context) {
+	var req AssignSupportTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	ticket, err := h.ticketService.Assign(c.Request.Context(), c.Param("id"), req.AssigneeID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Support ticket assigned successfully", ticket)
+}
+
+// AdminReply handles an agent answering a ticket; the buyer is emailed
+// POST /api/v1/admin/support-tickets/:id/replies (multipart form: message, attachments[])
+func (h *SupportTicketHandler) AdminReply(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req SupportMessageRequest
+	if err := c.ShouldBind(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	attachments, ok := h.uploadAttachments(c, fmt.Sprintf("support/agents/%s", adminID.(string)))
+	if !ok {
+		return
+	}
+
+	ticket, err := h.ticketService.AgentReply(c.Request.Context(), adminID.(string), c.Param("id"), req.Message, attachments)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Reply sent successfully", ticket)
+}
+
+// AdminSetTicketStatus handles resolving, closing or reopening a ticket
+// PUT /api/v1/admin/support-tickets/:id/status
+func (h *SupportTicketHandler) AdminSetTicketStatus(c *gin.Context) {
+	var req SupportTicketStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	ticket, err := h.ticketService.SetStatus(c.Request.Context(), c.Param("id"), req.Status)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Support ticket updated successfully", ticket)
+}
+
+// uploadAttachments uploads the images sent as attachments[] and reports whether the request can
+// go on; on failure the response has been written
+func (h *SupportTicketHandler) uploadAttachments(c *gin.Context, folder string) ([]service.SupportAttachment, bool) {
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["attachments"]) == 0 {
+		return nil, true
+	}
+	if h.cloudinaryUpload == nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "Cloudinary is not configured", nil)
+		return nil, false
+	}
+
+	// Validate MIME type
+	allowedMIMETypes := map[string]bool{
+		"image/jpeg": true,
+		"image/jpg":  true,
+		"image/png":  true,
+		"image/webp": true,
+	}
+	mimeMap := map[string]string{
+		".jpg":  "image/jpeg",
+		".jpeg": "image/jpeg",
+		".png":  "image/png",
+		".webp": "image/webp",
+	}
+
+	files := form.File["attachments"]
+	if len(files) > 5 {
+		util.BadRequest(c, "At most 5 attachments can be added")
+		return nil, false
+	}
+	var attachments []service.SupportAttachment
+	for _, fileHeader := range files {
+		contentType := fileHeader.Header.Get("Content-Type")
+		if contentType == "" {
+			contentType = mimeMap[strings.ToLower(filepath.Ext(fileHeader.Filename))]
+		}
+		if !allowedMIMETypes[contentType] {
+			util.BadRequest(c, "Invalid image format. Allowed: JPEG, PNG, WEBP")
+			return nil, false
+		}
+		if fileHeader.Size > 5<<20 {
+			util.BadRequest(c, "Attachment exceeds 5MB limit")
+			return nil, false
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			util.BadRequest(c, "Failed to open file: "+err.Error())
+			return nil, false
+		}
+		fileData, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			util.BadRequest(c, "Failed to read file: "+err.Error())
+			return nil, false
+		}
+
+		url, err := h.cloudinaryUpload.UploadImage(fileData, fileHeader.Filename, folder)
+		if err != nil {
+			util.ErrorResponse(c, http.StatusInternalServerError, "Failed to upload attachment: "+err.Error(), nil)
+			return nil, false
+		}
+		attachments = append(attachments, service.SupportAttachment{FileName: filepath.Base(fileHeader.Filename), URL: url})
+	}
+	return attachments, true
+}
+
+func (h *SupportTicketHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrSupportTicketClosed):
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+	case err.Error() == "ticket not found" || err.Error() == "order not found":
+		util.NotFound(c, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+	default:
+		util.BadRequest(c, err.Error())
+	}
+}
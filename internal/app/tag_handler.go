@@ -0,0 +1,138 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TagHandler struct {
+	tagService service.TagService
+}
+
+func NewTagHandler(tagService service.TagService) *TagHandler {
+	return &TagHandler{
+		tagService: tagService,
+	}
+}
+
+// GetProductTags handles getting a product's tags
+// GET /api/v1/products/:id/tags
+func (h *TagHandler) GetProductTags(c *gin.Context) {
+	tags, err := h.tagService.GetProductTags(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		util.NotFound(c, err.Error())
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Tags retrieved successfully", tags)
+}
+
+// SetProductTags handles replacing all tags of a product in the current user's shop
+// PUT /api/v1/products/:id/tags
+func (h *TagHandler) SetProductTags(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.SetProductTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	tags, err := h.tagService.SetProductTags(c.Request.Context(), userID.(string), c.Param("id"), req.Tags)
+	if err != nil {
+		h.handleTagError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Tags updated successfully", tags)
+}
+
+// AddProductTag handles adding a tag to a product in the current user's shop
+// POST /api/v1/products/:id/tags
+func (h *TagHandler) AddProductTag(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.AddProductTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	tags, err := h.tagService.AddProductTag(c.Request.Context(), userID.(string), c.Param("id"), req.Tag)
+	if err != nil {
+		h.handleTagError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Tag added successfully", tags)
+}
+
+// RemoveProductTag handles removing a tag from a product in the current user's shop
+// DELETE /api/v1/products/:id/tags/:tag
+func (h *TagHandler) RemoveProductTag(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	tags, err := h.tagService.RemoveProductTag(c.Request.Context(), userID.(string), c.Param("id"), c.Param("tag"))
+	if err != nil {
+		h.handleTagError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Tag removed successfully", tags)
+}
+
+// GetProductsByTag handles browsing active products by tag
+// GET /api/v1/tags/:tag/products?page=1&limit=20
+func (h *TagHandler) GetProductsByTag(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	response, err := h.tagService.GetProductsByTag(c.Request.Context(), c.Param("tag"), page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Products retrieved successfully", response)
+}
+
+// SuggestTags handles tag autocomplete, most used tags first
+// GET /api/v1/tags/suggest?q=sum&limit=10
+func (h *TagHandler) SuggestTags(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	suggestions, err := h.tagService.SuggestTags(c.Request.Context(), c.Query("q"), limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Tags retrieved successfully", suggestions)
+}
+
+func (h *TagHandler) handleTagError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "product not found", "tag not found":
+		util.NotFound(c, err.Error())
+	case "seller not found. Please create a shop first":
+		util.Forbidden(c, err.Error())
+	default:
+		util.BadRequest(c, err.Error())
+	}
+}
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"yourapp/internal/repository"
+	"yourapp/internal/service"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WarrantyHandler struct {
+	warrantyService service.WarrantyService
+}
+
+func NewWarrantyHandler(warrantyService service.WarrantyService) *WarrantyHandler {
+	return &WarrantyHandler{
+		warrantyService: warrantyService,
+	}
+}
+
+// GetMyWarranties handles listing the current user's warranties, newest first
+// GET /api/v1/warranties?page=1&limit=10&active=true
+func (h *WarrantyHandler) GetMyWarranties(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	activeOnly := c.Query("active") == "true"
+
+	warranties, total, err := h.warrantyService.GetMyWarranties(c.Request.Context(), userID.(string), activeOnly, page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Warranties retrieved successfully", gin.H{
+		"warranties": warranties,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+	})
+}
+
+// GetWarranty handles getting one of the current user's warranties with its claims
+// GET /api/v1/warranties/:id
+func (h *WarrantyHandler) GetWarranty(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	warranty, err := h.warrantyService.GetWarranty(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Warranty retrieved successfully", warranty)
+}
+
+// FileClaim handles filing a claim under one of the current user's active warranties
+// POST /api/v1/warranties/:id/claims
+func (h *WarrantyHandler) FileClaim(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.FileWarrantyClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	claim, err := h.warrantyService.FileClaim(c.Request.Context(), userID.(string), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, "Warranty claim filed successfully", claim)
+}
+
+// GetMyClaims handles listing the current user's warranty claims, newest first
+// GET /api/v1/warranty-claims?page=1&limit=10&status=submitted
+func (h *WarrantyHandler) GetMyClaims(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	claims, total, err := h.warrantyService.GetMyClaims(c.Request.Context(), userID.(string), c.Query("status"), page, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Warranty claims retrieved successfully", gin.H{
+		"claims": claims,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// GetClaim handles getting a warranty claim with its status history, for its buyer or seller
+// GET /api/v1/warranty-claims/:id
+func (h *WarrantyHandler) GetClaim(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	claim, err := h.warrantyService.GetClaim(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Warranty claim retrieved successfully", claim)
+}
+
+// GetSellerClaims handles listing warranty claims on the current user's shop's items
+// GET /api/v1/sellers/me/warranty-claims?page=1&limit=10&status=submitted
+func (h *WarrantyHandler) GetSellerClaims(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	claims, total, err := h.warrantyService.GetSellerClaims(c.Request.Context(), userID.(string), c.Query("status"), page, limit)
+	if err != nil {
+		if err.Error() == "seller not found" {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Warranty claims retrieved successfully", gin.H{
+		"claims": claims,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// UpdateClaimStatus handles the seller moving a warranty claim on (in_review, approved, rejected, completed)
+// PUT /api/v1/sellers/me/warranty-claims/:id/status
+func (h *WarrantyHandler) UpdateClaimStatus(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req service.UpdateWarrantyClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.BadRequest(c, err.Error())
+		return
+	}
+
+	claim, err := h.warrantyService.UpdateClaimStatus(c.Request.Context(), userID.(string), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, "Warranty claim updated successfully", claim)
+}
+
+func (h *WarrantyHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrWarrantyClaimOpen), errors.Is(err, repository.ErrWarrantyClaimChanged):
+		util.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+	case err.Error() == "warranty not found", err.Error() == "warranty claim not found", err.Error() == "seller not found":
+		util.NotFound(c, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		util.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+	default:
+		util.BadRequest(c, err.Error())
+	}
+}
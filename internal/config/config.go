@@ -54,10 +54,191 @@ type Config struct {
 	RateLimitRPS     int // Requests per second
 	RateLimitBurst   int // Burst size
 
+	// Request deadlines
+	RequestTimeoutSeconds int    // Default for every API route; 0 disables
+	RouteTimeouts         string // Per-route overrides as METHOD /path=seconds (0 = none), comma-separated
+
+	// Partial responses (?fields=) on product, order and seller endpoints
+	FieldSelectionRolloutPercent int    // Share of callers (by user, or IP when signed out) it is enabled for; 0 disables
+	FieldSelectionUserIDs        string // Users it is always enabled for, comma-separated
+
 	// Midtrans Payment Gateway
 	MidtransServerKey string
 	MidtransClientKey string
 
+	// Midtrans API call deadlines
+	MidtransChargeTimeoutSeconds int // Charges and refunds
+	MidtransStatusTimeoutSeconds int // Status checks and cancellations
+
+	// Midtrans circuit breaker (checkout fails fast and the poller pauses while Midtrans is down)
+	MidtransBreakerFailures        int // Consecutive failed calls that open the breaker; 0 disables it
+	MidtransBreakerCooldownSeconds int // How long the breaker stays open before a trial call
+
+	// Background Midtrans API budget (status poller); buyer-initiated calls are not limited
+	MidtransBudgetPerMinute         int // Status calls per minute; 0 disables the cap
+	MidtransBudgetMaxBackoffSeconds int // Longest pause after Midtrans answers 429/5xx
+
+	// Payment status retries (gateway-confirmed statuses whose database write failed)
+	PaymentRetryIntervalSeconds int // Base delay between attempts; doubles after each failure
+	PaymentRetryMaxAttempts     int // Attempts before a retry is parked for manual reconciliation
+
+	// Credit card 3DS policy
+	CreditCard3DSMode          string // always, risk_based, never
+	CreditCard3DSMaxAmount     int    // risk_based: 3DS may only be skipped at or below this amount
+	CreditCard3DSMinPaidOrders int    // risk_based: 3DS may only be skipped for customers with this many paid orders
+
+	// Manual Bank Transfer (for merchants without a Midtrans account)
+	ManualTransferBank          string
+	ManualTransferAccountNumber string
+	ManualTransferAccountName   string
+
+	// Marketplace business hours (order cutoffs and SLA clocks)
+	BusinessTimezone string // IANA timezone, e.g. Asia/Jakarta
+	BusinessDays     string // Comma-separated weekdays, e.g. mon,tue,wed,thu,fri
+	BusinessOpenTime string // HH:MM; SLA clocks for orders placed outside business hours start here
+	OrderCutoffTime  string // HH:MM; orders after this count from the next business day
+
+	// Courier service levels as name:min-max transit business days, e.g. regular:2-4,express:1-2
+	CourierServiceLevels string
+
+	// Extra transit business days by destination city as city:days, e.g. jakarta:0,medan:2.
+	// Shipments within the shop's own city never get extra days.
+	DeliveryCityExtraDays    string
+	DeliveryDefaultExtraDays int // Extra days for destination cities that are not listed
+
+	// Order pricing (all amounts in IDR; client-sent amounts are only checked against these)
+	ServiceFee               int // Flat fee per order
+	ApplicationFee           int // Flat fee per order
+	ShippingBaseCost         int // Shipping cost for the first kilogram
+	ShippingCostPerKg        int // Shipping cost for each additional (started) kilogram
+	DefaultProductWeight     int // Grams; used for products without a weight
+	InsuranceRateBasisPoints int // Shipping insurance as basis points of the subtotal (e.g. 20 = 0.2%)
+	WarrantyRateBasisPoints  int // Warranty protection as basis points of the subtotal
+	GiftWrapFee              int // Flat fee per order for gift wrapping
+	MinOrderAmount           int // Smallest goods subtotal any order may have; shops can require more. 0 means none
+
+	// VAT (PPN), charged on the goods of sellers registered as PKP (taxable entrepreneurs)
+	TaxRateBasisPoints int  // e.g. 1100 = 11%; 0 disables tax
+	TaxPricesInclusive bool // Product prices already include PPN; otherwise it is added on top
+
+	// Scheduled price changes
+	PriceScheduleCheckIntervalSeconds int // How often due price schedules are applied
+	PriceQuoteTTLMinutes              int // How long a checkout preview keeps its prices through a scheduled price change
+
+	// Storefront preview links (drafts shown as buyers will see them)
+	PreviewTokenTTLMinutes int
+
+	// Digital goods
+	DigitalDownloadTTLMinutes int // Lifetime of the signed download links handed to buyers
+
+	// Redis stock counters (checkout admission in front of Postgres)
+	StockCacheTTLSeconds          int // Idle counters expire and are reloaded from Postgres on next use
+	StockReconcileIntervalSeconds int // How often counters are compared with Postgres and repaired
+
+	// Redis cart cache (write-through; Postgres stays the source of truth)
+	CartCacheTTLSeconds int // How long a cached cart lives without changes; 0 disables the cache
+
+	// Live cart stock stream (Server-Sent Events)
+	CartStockLowThreshold     int // Items at or below this many available units are flagged low_stock
+	CartStockHeartbeatSeconds int // Keep-alive interval; the cart is also re-read on every heartbeat
+	CartStockStreamMaxSeconds int // Streams are closed after this long and the client reconnects
+
+	// Returns
+	ReturnWindowDays int // Days after delivery during which a buyer may open a return
+
+	// Disputes
+	DisputeWindowDays          int // Days after delivery during which a buyer may open a dispute
+	DisputeSellerResponseHours int // How long the seller has to respond before the buyer may escalate
+
+	// Shipping insurance claims
+	InsuranceClaimsEnabled      bool // Soft launch: buyers and sellers can only file claims while enabled
+	InsuranceClaimWindowDays    int  // Days after delivery during which a damaged shipment can be claimed
+	InsuranceClaimLostAfterDays int  // Days after shipping before an undelivered shipment can be claimed as lost
+
+	// Warranties
+	WarrantyDefaultMonths int // Warranty length for products without their own warranty terms
+
+	// Checkout donations
+	DonationRoundUpTo int // Round-up donations bring the total up to the next multiple of this
+	DonationMaxAmount int // Most a buyer can add as a donation to one order
+
+	// Support tickets
+	SupportFirstResponseHours      int    // SLA: an agent should answer a new ticket within this many hours
+	SupportResolutionHours         int    // SLA: a ticket should be resolved within this many hours of opening
+	SupportSLACheckIntervalSeconds int    // How often overdue tickets are flagged; 0 disables the check
+	SupportTicketURL               string // Link to a ticket in emails, with the ticket ID appended; defaults to CLIENT_URL + /support/tickets/
+
+	// Seller webhooks
+	SellerWebhookLowStockThreshold       int // product.low_stock fires when available stock drops to this or below
+	SellerWebhookDispatchIntervalSeconds int // How often due deliveries are sent; 0 disables delivery
+	SellerWebhookMaxAttempts             int // Attempts per delivery before it is given up on
+	SellerWebhookTimeoutSeconds          int // Per-request timeout when calling a seller's endpoint
+
+	// Scheduled reports
+	ScheduledReportCheckIntervalSeconds int // How often due reports are run; 0 disables scheduled runs
+	ScheduledReportTimeoutSeconds       int // Per-request timeout when posting a report to a webhook
+
+	// Seller product listing limits per tier (0 means unlimited)
+	SellerProductLimitUnverified int // Active products a shop may list before it is verified
+	SellerProductLimitVerified   int // Active products a verified shop may list
+
+	// Buyer cancellation requests
+	CancellationResponseHours        int // How long the seller has to answer before the request is approved automatically
+	CancellationCheckIntervalSeconds int // How often overdue requests and pending refunds are processed
+
+	// Unpaid order auto-cancellation
+	UnpaidOrderTimeoutMinutes       int // Pending orders without a payment this long are cancelled (0 disables)
+	UnpaidOrderCheckIntervalSeconds int // How often unpaid orders are looked for
+
+	// Abandoned cart reminders
+	CartReminderIdleHours            int    // Carts with items untouched this long get a reminder (0 disables)
+	CartReminderMaxIdleDays          int    // Carts idle longer than this are not reminded about (0 means no limit)
+	CartReminderCooldownHours        int    // A buyer gets at most one reminder within this window
+	CartReminderCheckIntervalMinutes int    // How often abandoned carts are looked for
+	CartReminderPushEnabled          bool   // Also push the reminder to the buyer's devices
+	CartReminderURL                  string // Link back to the cart; defaults to CLIENT_URL + /cart
+
+	// Partner API metering (monthly quotas per plan; 0 means unlimited)
+	PartnerFreeMonthlyRequests   int
+	PartnerFreeMonthlyTransferMB int
+	PartnerProMonthlyRequests    int
+	PartnerProMonthlyTransferMB  int
+	PartnerUsageAlertPercent     int // Sellers are emailed when a key reaches this share of its quota, and again at 100%
+	PartnerUsageRollupSeconds    int // How often Redis counters are rolled up to the database
+
+	// Affiliate product API
+	AffiliateDailyQuota int // Default requests per UTC day for new affiliate keys
+	AffiliateRateRPS    int // Per-key burst protection on top of the daily quota
+	AffiliateRateBurst  int
+
+	// Affiliate commissions
+	AffiliateCommissionBasisPoints int // Commission as basis points of the referred order's item subtotal (e.g. 500 = 5%)
+	AffiliateCookieDays            int // How long a short link click attributes checkouts to the affiliate
+	AffiliateMinPayout             int // Smallest payout an admin can create, in rupiah
+
+	// Push notifications (Firebase Cloud Messaging HTTP v1)
+	FCMCredentialsFile string // Path to the Firebase service account JSON; empty disables pushes
+
+	// Shipping labels (courier aggregator that books pickups and issues waybills)
+	CourierAPIURL     string
+	CourierAPIKey     string // Empty disables label booking
+	SupportedCouriers string // Comma-separated courier codes sellers may book, e.g. jne,jnt,sicepat
+
+	// Config bundles (reference data export/import between environments)
+	ConfigBundleKey string // Shared secret the bundle is encrypted and authenticated with; empty disables bundles
+
+	// Data retention (days to keep each kind of data; 0 keeps it forever)
+	RetentionGatewayPayloadDays   int    // Raw Midtrans payloads on finished payments and dead status retries
+	RetentionFraudCheckDays       int    // Fraud check log, which holds client IPs
+	RetentionAnalyticsDays        int    // Daily product funnel counters
+	RetentionCartReminderDays     int    // Abandoned cart reminder history
+	RetentionCartUnavailableDays  int    // Cart items of deleted or inactive products, or of closed shops, untouched this long
+	RetentionCartStalePriceDays   int    // Cart items untouched this long whose product price moved beyond the threshold below
+	RetentionCartPriceChangePct   int    // Price change, in percent of the price the item was added at, that makes an item stale
+	RetentionWebhookDeliveryDays  int    // Finished seller webhook deliveries
+	RetentionArchiveDir           string // Purged rows are written here as gzipped JSON lines first; empty purges without archiving
+	RetentionCheckIntervalMinutes int    // How often expired data is purged
+
 	// Cloudinary
 	CloudinaryCloudName string
 	CloudinaryAPIKey    string
@@ -127,10 +308,192 @@ func Load() (*Config, error) {
 		RateLimitRPS:     getEnvInt("RATE_LIMIT_RPS", 100),
 		RateLimitBurst:   getEnvInt("RATE_LIMIT_BURST", 200),
 
+		// Request deadlines (default: 30s; streams, long polls, payments and exports get their own)
+		RequestTimeoutSeconds: getEnvInt("REQUEST_TIMEOUT_SECONDS", 30),
+		RouteTimeouts: getEnv("ROUTE_TIMEOUTS", "GET /api/v1/carts/stock/stream=0,GET /api/v1/payments/:id/status=45,POST /api/v1/payments=45,"+
+			"GET /api/v1/sellers/me/orders/export=300,GET /api/v1/sellers/me/tax-report/export=300,GET /api/v1/admin/coupon-batches/:id/export=300,POST /api/v1/admin/scheduled-reports/:id/run=120,POST /api/v1/admin/config-bundle/import=120"),
+
+		// Partial responses (default: off while being rolled out)
+		FieldSelectionRolloutPercent: getEnvInt("FIELD_SELECTION_ROLLOUT_PERCENT", 0),
+		FieldSelectionUserIDs:        getEnv("FIELD_SELECTION_USER_IDS", ""),
+
 		// Midtrans Payment Gateway
 		MidtransServerKey: getEnv("MIDTRANS_SERVER_KEY", "SB-Mid-server-4zIt7djwCeRdMpgF4gXDjciC"),
 		MidtransClientKey: getEnv("MIDTRANS_CLIENT_KEY", ""),
 
+		// Midtrans API call deadlines
+		MidtransChargeTimeoutSeconds: getEnvInt("MIDTRANS_CHARGE_TIMEOUT_SECONDS", 30),
+		MidtransStatusTimeoutSeconds: getEnvInt("MIDTRANS_STATUS_TIMEOUT_SECONDS", 10),
+
+		// Midtrans circuit breaker
+		MidtransBreakerFailures:        getEnvInt("MIDTRANS_BREAKER_FAILURES", 5),
+		MidtransBreakerCooldownSeconds: getEnvInt("MIDTRANS_BREAKER_COOLDOWN_SECONDS", 30),
+
+		// Background Midtrans API budget
+		MidtransBudgetPerMinute:         getEnvInt("MIDTRANS_BUDGET_PER_MINUTE", 60),
+		MidtransBudgetMaxBackoffSeconds: getEnvInt("MIDTRANS_BUDGET_MAX_BACKOFF_SECONDS", 300),
+
+		// Payment status retries
+		PaymentRetryIntervalSeconds: getEnvInt("PAYMENT_RETRY_INTERVAL_SECONDS", 30),
+		PaymentRetryMaxAttempts:     getEnvInt("PAYMENT_RETRY_MAX_ATTEMPTS", 12),
+
+		// Credit card 3DS policy (default: always enforce 3DS)
+		CreditCard3DSMode:          getEnv("CC_3DS_MODE", "always"),
+		CreditCard3DSMaxAmount:     getEnvInt("CC_3DS_MAX_AMOUNT", 500000),
+		CreditCard3DSMinPaidOrders: getEnvInt("CC_3DS_MIN_PAID_ORDERS", 3),
+
+		// Manual Bank Transfer
+		ManualTransferBank:          getEnv("MANUAL_TRANSFER_BANK", "BCA"),
+		ManualTransferAccountNumber: getEnv("MANUAL_TRANSFER_ACCOUNT_NUMBER", ""),
+		ManualTransferAccountName:   getEnv("MANUAL_TRANSFER_ACCOUNT_NAME", ""),
+
+		// Marketplace business hours
+		BusinessTimezone: getEnv("BUSINESS_TIMEZONE", "Asia/Jakarta"),
+		BusinessDays:     getEnv("BUSINESS_DAYS", "mon,tue,wed,thu,fri,sat"),
+		BusinessOpenTime: getEnv("BUSINESS_OPEN_TIME", "08:00"),
+		OrderCutoffTime:  getEnv("ORDER_CUTOFF_TIME", "15:00"),
+
+		// Courier service levels
+		CourierServiceLevels: getEnv("COURIER_SERVICE_LEVELS", "regular:2-4,express:1-2,same_day:0-0"),
+
+		// Delivery zones
+		DeliveryCityExtraDays:    getEnv("DELIVERY_CITY_EXTRA_DAYS", "jakarta:0,bogor:0,depok:0,tangerang:0,bekasi:0,bandung:1,semarang:1,yogyakarta:1,surabaya:1,denpasar:2,medan:2,makassar:2"),
+		DeliveryDefaultExtraDays: getEnvInt("DELIVERY_DEFAULT_EXTRA_DAYS", 2),
+
+		// Order pricing
+		ServiceFee:               getEnvInt("SERVICE_FEE", 1000),
+		ApplicationFee:           getEnvInt("APPLICATION_FEE", 0),
+		ShippingBaseCost:         getEnvInt("SHIPPING_BASE_COST", 9000),
+		ShippingCostPerKg:        getEnvInt("SHIPPING_COST_PER_KG", 4000),
+		DefaultProductWeight:     getEnvInt("DEFAULT_PRODUCT_WEIGHT", 1000),
+		InsuranceRateBasisPoints: getEnvInt("INSURANCE_RATE_BPS", 20),
+		WarrantyRateBasisPoints:  getEnvInt("WARRANTY_RATE_BPS", 500),
+		GiftWrapFee:              getEnvInt("GIFT_WRAP_FEE", 5000),
+		MinOrderAmount:           getEnvInt("MIN_ORDER_AMOUNT", 0),
+
+		// VAT (PPN)
+		TaxRateBasisPoints: getEnvInt("TAX_RATE_BPS", 1100),
+		TaxPricesInclusive: getEnvBool("TAX_PRICES_INCLUSIVE", true),
+
+		// Scheduled price changes (default: checked every minute, quotes honored for 30 minutes)
+		PriceScheduleCheckIntervalSeconds: getEnvInt("PRICE_SCHEDULE_CHECK_INTERVAL_SECONDS", 60),
+		PriceQuoteTTLMinutes:              getEnvInt("PRICE_QUOTE_TTL_MINUTES", 30),
+
+		// Storefront preview links
+		PreviewTokenTTLMinutes: getEnvInt("PREVIEW_TOKEN_TTL_MINUTES", 60),
+
+		// Digital goods
+		DigitalDownloadTTLMinutes: getEnvInt("DIGITAL_DOWNLOAD_TTL_MINUTES", 60),
+
+		// Redis stock counters
+		StockCacheTTLSeconds:          getEnvInt("STOCK_CACHE_TTL_SECONDS", 86400),
+		StockReconcileIntervalSeconds: getEnvInt("STOCK_RECONCILE_INTERVAL_SECONDS", 300),
+
+		// Redis cart cache (default: 10 minutes)
+		CartCacheTTLSeconds: getEnvInt("CART_CACHE_TTL_SECONDS", 600),
+
+		// Live cart stock stream
+		CartStockLowThreshold:     getEnvInt("CART_STOCK_LOW_THRESHOLD", 5),
+		CartStockHeartbeatSeconds: getEnvInt("CART_STOCK_HEARTBEAT_SECONDS", 20),
+		CartStockStreamMaxSeconds: getEnvInt("CART_STOCK_STREAM_MAX_SECONDS", 600),
+
+		// Returns
+		ReturnWindowDays: getEnvInt("RETURN_WINDOW_DAYS", 7),
+
+		// Disputes (default: 14 days after delivery, seller has 48 hours to respond)
+		DisputeWindowDays:          getEnvInt("DISPUTE_WINDOW_DAYS", 14),
+		DisputeSellerResponseHours: getEnvInt("DISPUTE_SELLER_RESPONSE_HOURS", 48),
+
+		// Shipping insurance claims (off until launched; damaged within 7 days of delivery, lost after 7 days in transit)
+		InsuranceClaimsEnabled:      getEnvBool("INSURANCE_CLAIMS_ENABLED", false),
+		InsuranceClaimWindowDays:    getEnvInt("INSURANCE_CLAIM_WINDOW_DAYS", 7),
+		InsuranceClaimLostAfterDays: getEnvInt("INSURANCE_CLAIM_LOST_AFTER_DAYS", 7),
+
+		// Warranties (default: 12 months from delivery)
+		WarrantyDefaultMonths: getEnvInt("WARRANTY_DEFAULT_MONTHS", 12),
+
+		// Checkout donations (default: round up to the next Rp1.000, at most Rp1.000.000 per order)
+		DonationRoundUpTo: getEnvInt("DONATION_ROUND_UP_TO", 1000),
+		DonationMaxAmount: getEnvInt("DONATION_MAX_AMOUNT", 1000000),
+
+		// Support tickets (default: first answer within 24 hours, resolved within 72)
+		SupportFirstResponseHours:      getEnvInt("SUPPORT_FIRST_RESPONSE_HOURS", 24),
+		SupportResolutionHours:         getEnvInt("SUPPORT_RESOLUTION_HOURS", 72),
+		SupportSLACheckIntervalSeconds: getEnvInt("SUPPORT_SLA_CHECK_INTERVAL_SECONDS", 300),
+		SupportTicketURL:               getEnv("SUPPORT_TICKET_URL", ""),
+
+		// Seller webhooks (default: low stock at 5 units, 6 attempts over about a day)
+		SellerWebhookLowStockThreshold:       getEnvInt("SELLER_WEBHOOK_LOW_STOCK_THRESHOLD", 5),
+		SellerWebhookDispatchIntervalSeconds: getEnvInt("SELLER_WEBHOOK_DISPATCH_INTERVAL_SECONDS", 10),
+		SellerWebhookMaxAttempts:             getEnvInt("SELLER_WEBHOOK_MAX_ATTEMPTS", 6),
+		SellerWebhookTimeoutSeconds:          getEnvInt("SELLER_WEBHOOK_TIMEOUT_SECONDS", 10),
+
+		// Scheduled reports (default: checked every minute, the finest cron resolution)
+		ScheduledReportCheckIntervalSeconds: getEnvInt("SCHEDULED_REPORT_CHECK_INTERVAL_SECONDS", 60),
+		ScheduledReportTimeoutSeconds:       getEnvInt("SCHEDULED_REPORT_TIMEOUT_SECONDS", 30),
+
+		// Seller product listing limits (default: 50 until verified, 1000 after)
+		SellerProductLimitUnverified: getEnvInt("SELLER_PRODUCT_LIMIT_UNVERIFIED", 50),
+		SellerProductLimitVerified:   getEnvInt("SELLER_PRODUCT_LIMIT_VERIFIED", 1000),
+
+		// Buyer cancellation requests (default: seller has 24 hours, checked every 5 minutes)
+		CancellationResponseHours:        getEnvInt("CANCELLATION_RESPONSE_HOURS", 24),
+		CancellationCheckIntervalSeconds: getEnvInt("CANCELLATION_CHECK_INTERVAL_SECONDS", 300),
+
+		// Unpaid order auto-cancellation (default: after 24 hours, checked every 5 minutes)
+		UnpaidOrderTimeoutMinutes:       getEnvInt("UNPAID_ORDER_TIMEOUT_MINUTES", 1440),
+		UnpaidOrderCheckIntervalSeconds: getEnvInt("UNPAID_ORDER_CHECK_INTERVAL_SECONDS", 300),
+
+		// Abandoned cart reminders (default: after 24 hours idle, carts up to 7 days old, one per buyer every 72 hours)
+		CartReminderIdleHours:            getEnvInt("CART_REMINDER_IDLE_HOURS", 24),
+		CartReminderMaxIdleDays:          getEnvInt("CART_REMINDER_MAX_IDLE_DAYS", 7),
+		CartReminderCooldownHours:        getEnvInt("CART_REMINDER_COOLDOWN_HOURS", 72),
+		CartReminderCheckIntervalMinutes: getEnvInt("CART_REMINDER_CHECK_INTERVAL_MINUTES", 30),
+		CartReminderPushEnabled:          getEnvBool("CART_REMINDER_PUSH_ENABLED", true),
+		CartReminderURL:                  getEnv("CART_REMINDER_URL", ""),
+
+		// Partner API metering (default: free 10k requests / 1 GB, pro 500k requests / 50 GB, alert at 80%, rollup every minute)
+		PartnerFreeMonthlyRequests:   getEnvInt("PARTNER_FREE_MONTHLY_REQUESTS", 10000),
+		PartnerFreeMonthlyTransferMB: getEnvInt("PARTNER_FREE_MONTHLY_TRANSFER_MB", 1024),
+		PartnerProMonthlyRequests:    getEnvInt("PARTNER_PRO_MONTHLY_REQUESTS", 500000),
+		PartnerProMonthlyTransferMB:  getEnvInt("PARTNER_PRO_MONTHLY_TRANSFER_MB", 51200),
+		PartnerUsageAlertPercent:     getEnvInt("PARTNER_USAGE_ALERT_PERCENT", 80),
+		PartnerUsageRollupSeconds:    getEnvInt("PARTNER_USAGE_ROLLUP_SECONDS", 60),
+
+		// Affiliate product API
+		AffiliateDailyQuota: getEnvInt("AFFILIATE_DAILY_QUOTA", 10000),
+		AffiliateRateRPS:    getEnvInt("AFFILIATE_RATE_RPS", 5),
+		AffiliateRateBurst:  getEnvInt("AFFILIATE_RATE_BURST", 20),
+
+		// Affiliate commissions (default: 5%, 30-day attribution, Rp50.000 minimum payout)
+		AffiliateCommissionBasisPoints: getEnvInt("AFFILIATE_COMMISSION_BPS", 500),
+		AffiliateCookieDays:            getEnvInt("AFFILIATE_COOKIE_DAYS", 30),
+		AffiliateMinPayout:             getEnvInt("AFFILIATE_MIN_PAYOUT", 50000),
+
+		// Push notifications (disabled unless credentials are set)
+		FCMCredentialsFile: getEnv("FCM_CREDENTIALS_FILE", ""),
+
+		// Shipping labels (disabled unless an API key is set)
+		CourierAPIURL:     getEnv("COURIER_API_URL", "https://api.biteship.com"),
+		CourierAPIKey:     getEnv("COURIER_API_KEY", ""),
+		SupportedCouriers: getEnv("SUPPORTED_COURIERS", "jne,jnt,sicepat,anteraja,pos"),
+
+		// Config bundles (disabled unless a key is set)
+		ConfigBundleKey: getEnv("CONFIG_BUNDLE_KEY", ""),
+
+		// Data retention (default: payloads 90 days, fraud checks 1 year, analytics 2 years, reminders 180 days,
+		// unavailable cart items 30 days, cart items 90 days after a price change of over 25%, hourly)
+		RetentionGatewayPayloadDays:   getEnvInt("RETENTION_GATEWAY_PAYLOAD_DAYS", 90),
+		RetentionFraudCheckDays:       getEnvInt("RETENTION_FRAUD_CHECK_DAYS", 365),
+		RetentionAnalyticsDays:        getEnvInt("RETENTION_ANALYTICS_DAYS", 730),
+		RetentionCartReminderDays:     getEnvInt("RETENTION_CART_REMINDER_DAYS", 180),
+		RetentionCartUnavailableDays:  getEnvInt("RETENTION_CART_UNAVAILABLE_DAYS", 30),
+		RetentionCartStalePriceDays:   getEnvInt("RETENTION_CART_STALE_PRICE_DAYS", 90),
+		RetentionCartPriceChangePct:   getEnvInt("RETENTION_CART_PRICE_CHANGE_PCT", 25),
+		RetentionWebhookDeliveryDays:  getEnvInt("RETENTION_WEBHOOK_DELIVERY_DAYS", 30),
+		RetentionArchiveDir:           getEnv("RETENTION_ARCHIVE_DIR", ""),
+		RetentionCheckIntervalMinutes: getEnvInt("RETENTION_CHECK_INTERVAL_MINUTES", 60),
+
 		// Cloudinary
 		CloudinaryCloudName: getEnv("CLOUDINARY_CLOUD_NAME", "dgmlqboeq"),
 		CloudinaryAPIKey:    getEnv("CLOUDINARY_API_KEY", "736499913818945"),
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientIP copies the caller's IP address into the request context (see util.ClientIPFromContext)
+func ClientIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		if ip == "" {
+			ip = c.RemoteIP()
+		}
+		c.Request = c.Request.WithContext(util.WithClientIP(c.Request.Context(), ip))
+		c.Next()
+	}
+}
@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is the request header clients set to make a create request safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Idempotency key lifetime and cleanup
+const (
+	idempotencyKeyTTL             = 24 * time.Hour
+	idempotencyKeyMaxLength       = 255
+	idempotencyKeyCleanupInterval = time.Hour
+)
+
+// Idempotency replays the stored response when a request is retried with the same Idempotency-Key,
+// so a double-tapped checkout button creates only one order. Keys are scoped per user, so the
+// middleware must run after AuthMiddleware.
+type Idempotency struct {
+	repo repository.IdempotencyKeyRepository
+}
+
+// NewIdempotency creates the idempotency middleware and starts removing expired keys
+func NewIdempotency(repo repository.IdempotencyKeyRepository, jobs *util.BackgroundJobs) *Idempotency {
+	idempotency := &Idempotency{repo: repo}
+
+	jobs.Every(idempotencyKeyCleanupInterval, idempotency.deleteExpiredKeys)
+
+	return idempotency
+}
+
+// deleteExpiredKeys deletes keys that can no longer be replayed
+func (i *Idempotency) deleteExpiredKeys(ctx context.Context) {
+	deleted, err := i.repo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		log.Printf("⚠️  Failed to delete expired idempotency keys: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("🧹 Deleted %d expired idempotency keys", deleted)
+	}
+}
+
+// Middleware returns the idempotency middleware function. Requests without the header pass through.
+func (i *Idempotency) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > idempotencyKeyMaxLength {
+			util.BadRequest(c, "Idempotency-Key must be at most 255 characters")
+			c.Abort()
+			return
+		}
+		userID, exists := c.Get("userID")
+		if !exists {
+			util.Unauthorized(c, "User not authenticated")
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			util.BadRequest(c, "Failed to read request body")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.FullPath() + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		// Keep recording the result even if the client gives up waiting
+		ctx := context.WithoutCancel(c.Request.Context())
+		record, created, err := i.reserve(ctx, &model.IdempotencyKey{
+			UserID:      userID.(string),
+			Key:         key,
+			Method:      c.Request.Method,
+			Path:        c.FullPath(),
+			RequestHash: requestHash,
+			Status:      model.IdempotencyStatusProcessing,
+			ExpiresAt:   time.Now().Add(idempotencyKeyTTL),
+		})
+		if err != nil {
+			util.ErrorResponse(c, http.StatusInternalServerError, "Failed to check Idempotency-Key: "+err.Error(), nil)
+			c.Abort()
+			return
+		}
+
+		if !created {
+			switch {
+			case record.RequestHash != requestHash:
+				util.ErrorResponse(c, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request", nil)
+			case record.Status != model.IdempotencyStatusCompleted:
+				util.ErrorResponse(c, http.StatusConflict, "A request with this Idempotency-Key is still being processed", nil)
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(record.StatusCode, record.ContentType, record.ResponseBody)
+			}
+			c.Abort()
+			return
+		}
+
+		writer := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		completed := false
+		defer func() {
+			// Server errors and panics are not stored, so the client can retry with the same key
+			if !completed {
+				if err := i.repo.Delete(ctx, record.ID); err != nil {
+					log.Printf("⚠️  Failed to release idempotency key %s: %v", record.ID, err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		if err := i.repo.Complete(ctx, record.ID, status, writer.Header().Get("Content-Type"), writer.body.Bytes()); err != nil {
+			log.Printf("⚠️  Failed to store response for idempotency key %s: %v", record.ID, err)
+			return
+		}
+		completed = true
+	}
+}
+
+// reserve claims the key, replacing an expired record the cleanup has not removed yet
+func (i *Idempotency) reserve(ctx context.Context, key *model.IdempotencyKey) (*model.IdempotencyKey, bool, error) {
+	record, created, err := i.repo.Reserve(ctx, key)
+	if err != nil || created || record.ExpiresAt.After(time.Now()) {
+		return record, created, err
+	}
+	if err := i.repo.Delete(ctx, record.ID); err != nil {
+		return nil, false, err
+	}
+	return i.repo.Reserve(ctx, key)
+}
+
+// responseRecorder keeps a copy of the response body so it can be replayed
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
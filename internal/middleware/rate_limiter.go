@@ -33,7 +33,7 @@ func NewRateLimiter(rps int, burst int) *RateLimiter {
 	return rl
 }
 
-// getLimiter returns the rate limiter for a given IP
+// getLimiter returns the rate limiter for a given IP (or other key)
 func (rl *RateLimiter) getLimiter(ip string) *rate.Limiter {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
@@ -69,6 +69,11 @@ func (rl *RateLimiter) cleanupLimiters() {
 	}
 }
 
+// Allow reports whether a request for the given key (an IP, an API key ID, ...) fits the rate
+func (rl *RateLimiter) Allow(key string) bool {
+	return rl.getLimiter(key).Allow()
+}
+
 // Middleware returns the rate limiter middleware function
 func (rl *RateLimiter) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
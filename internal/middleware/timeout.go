@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"yourapp/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout gives every request a deadline through its context, which the database and outbound
+// calls honor. Handlers are not interrupted mid-write: once the handler returns after the
+// deadline, the timeout is counted and the client gets 504 if nothing was written yet.
+// routes overrides the default per route ("METHOD /full/path" as registered); 0 means no deadline,
+// for streams.
+func Timeout(defaultTimeout time.Duration, routes map[string]time.Duration, metrics *util.TimeoutMetrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		timeout, ok := routes[route]
+		if !ok {
+			timeout = defaultTimeout
+		}
+		if timeout <= 0 || c.FullPath() == "" {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return
+		}
+		metrics.Record(route)
+		log.Printf("⏱️  %s timed out after %s", route, timeout)
+		if !c.Writer.Written() {
+			util.ErrorResponse(c, http.StatusGatewayTimeout, "Request timed out", nil)
+			c.Abort()
+		}
+	}
+}
+
+// ParseRouteTimeouts parses "METHOD /path=seconds" pairs separated by commas, e.g.
+// "GET /api/v1/carts/stock/stream=0,POST /api/v1/payments=45"
+func ParseRouteTimeouts(value string) map[string]time.Duration {
+	routes := make(map[string]time.Duration)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		separator := strings.LastIndex(entry, "=")
+		if separator < 0 {
+			log.Printf("⚠️  Ignoring route timeout %q: expected METHOD /path=seconds", entry)
+			continue
+		}
+		route := strings.Join(strings.Fields(entry[:separator]), " ")
+		seconds, err := strconv.Atoi(strings.TrimSpace(entry[separator+1:]))
+		if err != nil || seconds < 0 || !strings.Contains(route, " /") {
+			log.Printf("⚠️  Ignoring route timeout %q: expected METHOD /path=seconds", entry)
+			continue
+		}
+		method, path, _ := strings.Cut(route, " ")
+		routes[strings.ToUpper(method)+" "+path] = time.Duration(seconds) * time.Second
+	}
+	return routes
+}
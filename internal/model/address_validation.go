@@ -0,0 +1,89 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Address validation statuses
+const (
+	AddressValidationPending   = "pending"   // Flagged; waiting for the user
+	AddressValidationConfirmed = "confirmed" // The user applied the suggestion
+	AddressValidationDismissed = "dismissed" // The user kept the address as it is
+)
+
+// Address validation issues
+const (
+	AddressIssuePostalCodeFormat     = "postal_code_format"     // Not a 5-digit postal code
+	AddressIssueUnknownProvince      = "unknown_province"       // No region has the province
+	AddressIssueUnknownCity          = "unknown_city"           // No region has the city
+	AddressIssueCityProvinceMismatch = "city_province_mismatch" // The city belongs to another province
+	AddressIssuePostalCodeMismatch   = "postal_code_mismatch"   // The postal code is not one of the city's
+)
+
+// Address validation run statuses
+const (
+	AddressValidationRunRunning   = "running"
+	AddressValidationRunCompleted = "completed"
+	AddressValidationRunFailed    = "failed"
+)
+
+// AddressValidation flags an address whose city, province and postal code do not match the region
+// reference data, with a corrected suggestion when one could be worked out. There is at most one
+// per address; valid addresses have none.
+type AddressValidation struct {
+	ID                  string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AddressID           string     `gorm:"type:uuid;not null;uniqueIndex" json:"address_id"`
+	UserID              string     `gorm:"type:uuid;not null;index" json:"user_id"`
+	RunID               string     `gorm:"type:uuid;not null;index" json:"run_id"` // Run that last checked the address
+	Status              string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	Issues              string     `gorm:"type:text;not null" json:"issues"` // Comma-separated, e.g. AddressIssueUnknownCity
+	SuggestedCity       *string    `gorm:"type:varchar(100)" json:"suggested_city,omitempty"`
+	SuggestedProvince   *string    `gorm:"type:varchar(100)" json:"suggested_province,omitempty"`
+	SuggestedPostalCode *string    `gorm:"type:varchar(10)" json:"suggested_postal_code,omitempty"`
+	ResolvedAt          *time.Time `gorm:"type:timestamp" json:"resolved_at,omitempty"`
+	CreatedAt           time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Address Address `gorm:"foreignKey:AddressID" json:"address,omitempty"`
+}
+
+func (v *AddressValidation) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == "" {
+		v.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (AddressValidation) TableName() string {
+	return "address_validations"
+}
+
+// AddressValidationRun is one admin-started pass over every address, and its report
+type AddressValidationRun struct {
+	ID             string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Status         string     `gorm:"type:varchar(20);not null;default:'running';index" json:"status"`
+	StartedBy      string     `gorm:"type:uuid;not null" json:"started_by"`
+	Checked        int        `gorm:"not null;default:0" json:"checked"`
+	Valid          int        `gorm:"not null;default:0" json:"valid"`
+	Flagged        int        `gorm:"not null;default:0" json:"flagged"`
+	WithSuggestion int        `gorm:"not null;default:0" json:"with_suggestion"` // Flagged addresses that have a suggestion
+	Skipped        int        `gorm:"not null;default:0" json:"skipped"`         // Flags the user already resolved for the current address
+	IssueCounts    string     `gorm:"type:text" json:"-"`                        // JSON object of issue => flagged addresses
+	Error          *string    `gorm:"type:text" json:"error,omitempty"`
+	StartedAt      time.Time  `gorm:"not null" json:"started_at"`
+	FinishedAt     *time.Time `gorm:"type:timestamp" json:"finished_at,omitempty"`
+}
+
+func (r *AddressValidationRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (AddressValidationRun) TableName() string {
+	return "address_validation_runs"
+}
@@ -0,0 +1,144 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AffiliateAPIKey gives an affiliate read-only access to the public product API. Share URLs
+// returned through the key carry its AffiliateCode so referred traffic can be attributed. Only
+// the SHA-256 hash of the key is stored; the plaintext is shown once on creation.
+type AffiliateAPIKey struct {
+	ID            string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID        string     `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name          string     `gorm:"type:varchar(100);not null" json:"name"`
+	KeyPrefix     string     `gorm:"type:varchar(20);not null" json:"key_prefix"` // First characters of the key, to help affiliates identify it
+	KeyHash       string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	AffiliateCode string     `gorm:"type:varchar(20);uniqueIndex;not null" json:"affiliate_code"`
+	DailyQuota    int        `gorm:"not null" json:"daily_quota"` // Requests per UTC day
+	LastUsedAt    *time.Time `gorm:"type:timestamp" json:"last_used_at,omitempty"`
+	RevokedAt     *time.Time `gorm:"type:timestamp" json:"revoked_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (k *AffiliateAPIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == "" {
+		k.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (AffiliateAPIKey) TableName() string {
+	return "affiliate_api_keys"
+}
+
+// AffiliateAPIUsage counts the requests made with an affiliate key on one UTC day, including the
+// ones refused for being over quota
+type AffiliateAPIUsage struct {
+	APIKeyID  string    `gorm:"type:uuid;primaryKey" json:"api_key_id"`
+	Date      time.Time `gorm:"type:date;primaryKey" json:"date"`
+	Requests  int64     `gorm:"not null;default:0" json:"requests"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (AffiliateAPIUsage) TableName() string {
+	return "affiliate_api_usage"
+}
+
+// Affiliate commission statuses
+const (
+	AffiliateCommissionPending = "pending" // Accrued on a delivered order, waiting for a payout
+	AffiliateCommissionPaid    = "paid"
+	AffiliateCommissionVoid    = "void" // Cancelled or voided by an admin; never paid out
+)
+
+// Affiliate payout statuses
+const (
+	AffiliatePayoutPending   = "pending"
+	AffiliatePayoutPaid      = "paid"
+	AffiliatePayoutCancelled = "cancelled"
+)
+
+// AffiliateLink is a short link an affiliate shares instead of a long tagged URL. Opening it
+// redirects to the storefront (or one product) with the affiliate code attached.
+type AffiliateLink struct {
+	ID            string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID        string    `gorm:"type:uuid;not null;index" json:"user_id"`
+	APIKeyID      string    `gorm:"type:uuid;not null;index" json:"api_key_id"`
+	AffiliateCode string    `gorm:"type:varchar(20);not null" json:"affiliate_code"`
+	Slug          string    `gorm:"type:varchar(16);uniqueIndex;not null" json:"slug"`
+	ProductID     *string   `gorm:"type:uuid" json:"product_id,omitempty"` // Empty links to the storefront home page
+	Clicks        int64     `gorm:"not null;default:0" json:"clicks"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (l *AffiliateLink) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (AffiliateLink) TableName() string {
+	return "affiliate_links"
+}
+
+// AffiliateCommission is what an affiliate earned on one referred order. It accrues when the order
+// is delivered and is paid out as part of an AffiliatePayout.
+type AffiliateCommission struct {
+	ID              string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AffiliateUserID string     `gorm:"type:uuid;not null;index" json:"affiliate_user_id"`
+	AffiliateCode   string     `gorm:"type:varchar(20);not null;index" json:"affiliate_code"`
+	OrderID         string     `gorm:"type:uuid;not null;uniqueIndex" json:"order_id"`
+	OrderNumber     string     `gorm:"type:varchar(50);not null" json:"order_number"`
+	BaseAmount      int        `gorm:"not null" json:"base_amount"` // Item subtotal after discounts; shipping and fees earn nothing
+	RateBasisPoints int        `gorm:"not null" json:"rate_basis_points"`
+	Amount          int        `gorm:"not null" json:"amount"`
+	Status          string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"` // pending, paid, void
+	PayoutID        *string    `gorm:"type:uuid;index" json:"payout_id,omitempty"`
+	VoidReason      *string    `gorm:"type:text" json:"void_reason,omitempty"`
+	VoidedAt        *time.Time `gorm:"type:timestamp" json:"voided_at,omitempty"`
+	PaidAt          *time.Time `gorm:"type:timestamp" json:"paid_at,omitempty"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (c *AffiliateCommission) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (AffiliateCommission) TableName() string {
+	return "affiliate_commissions"
+}
+
+// AffiliatePayout bundles an affiliate's pending commissions into one transfer. The commissions
+// are reserved while the payout is pending and become paid together with it.
+type AffiliatePayout struct {
+	ID              string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AffiliateUserID string     `gorm:"type:uuid;not null;index" json:"affiliate_user_id"`
+	Amount          int        `gorm:"not null" json:"amount"`
+	CommissionCount int        `gorm:"not null" json:"commission_count"`
+	Status          string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"` // pending, paid, cancelled
+	Reference       *string    `gorm:"type:varchar(100)" json:"reference,omitempty"`                    // Bank transfer reference
+	CreatedBy       string     `gorm:"type:uuid;not null" json:"created_by"`
+	PaidAt          *time.Time `gorm:"type:timestamp" json:"paid_at,omitempty"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (p *AffiliatePayout) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (AffiliatePayout) TableName() string {
+	return "affiliate_payouts"
+}
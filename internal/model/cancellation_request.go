@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Cancellation request statuses. A request moves requested -> approved -> refunded, or is
+// rejected by the seller. Approval cancels the sub-order and restocks it; the refund follows and is
+// retried until it succeeds. Requests the seller leaves unanswered past RespondBy are approved
+// automatically.
+const (
+	CancellationStatusRequested = "requested"
+	CancellationStatusApproved  = "approved"
+	CancellationStatusRejected  = "rejected"
+	CancellationStatusRefunded  = "refunded"
+)
+
+// CancellationRequest is a buyer asking to cancel a paid sub-order before it ships. Each
+// sub-order can be asked about once.
+type CancellationRequest struct {
+	ID            string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderID       string     `gorm:"type:uuid;not null;index" json:"order_id"`
+	SellerOrderID string     `gorm:"type:uuid;not null;uniqueIndex" json:"seller_order_id"`
+	UserID        string     `gorm:"type:uuid;not null;index" json:"user_id"` // Buyer
+	SellerID      string     `gorm:"type:uuid;not null;index" json:"seller_id"`
+	Reason        string     `gorm:"type:text;not null" json:"reason"`
+	Status        string     `gorm:"type:varchar(20);not null;default:'requested';index" json:"status"`
+	RespondBy     time.Time  `gorm:"type:timestamp;not null;index" json:"respond_by"` // Auto-approved after this
+	AutoApproved  bool       `gorm:"default:false" json:"auto_approved"`
+	SellerNote    *string    `gorm:"type:text" json:"seller_note,omitempty"` // Rejection reason
+	RefundAmount  int        `gorm:"not null" json:"refund_amount"`          // Sub-order subtotal + shipping
+	RefundMethod  *string    `gorm:"type:varchar(20)" json:"refund_method,omitempty"`
+	ApprovedAt    *time.Time `gorm:"type:timestamp" json:"approved_at,omitempty"`
+	RejectedAt    *time.Time `gorm:"type:timestamp" json:"rejected_at,omitempty"`
+	RefundedAt    *time.Time `gorm:"type:timestamp" json:"refunded_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	SellerOrder SellerOrder `gorm:"foreignKey:SellerOrderID" json:"seller_order,omitempty"`
+}
+
+func (cr *CancellationRequest) BeforeCreate(tx *gorm.DB) error {
+	if cr.ID == "" {
+		cr.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (CancellationRequest) TableName() string {
+	return "cancellation_requests"
+}
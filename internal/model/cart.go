@@ -13,6 +13,9 @@ type Cart struct {
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 
+	// Voucher the buyer applied; it is checked again and redeemed at checkout
+	CouponCode *string `gorm:"type:varchar(50)" json:"coupon_code,omitempty"`
+
 	User       User        `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	CartItems  []CartItem  `gorm:"foreignKey:CartID" json:"cart_items,omitempty"`
 }
@@ -28,17 +31,33 @@ func (Cart) TableName() string {
 	return "carts"
 }
 
+// CartItemUnavailableShopClosed flags items whose shop was deactivated or deleted
+const CartItemUnavailableShopClosed = "shop_closed"
+
 type CartItem struct {
 	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	CartID    string    `gorm:"type:uuid;not null;index" json:"cart_id"`
 	ProductID string    `gorm:"type:uuid;not null;index" json:"product_id"`
 	Quantity  int       `gorm:"not null;default:1" json:"quantity"`
 	Price     int       `gorm:"not null" json:"price"` // Price at time of adding to cart
+
+	// Variant of the product being bought; required for products that have variants
+	VariantID *string `gorm:"type:uuid;index" json:"variant_id,omitempty"`
+
+	// Why the item can no longer be checked out (nil when it can), e.g. CartItemUnavailableShopClosed
+	UnavailableReason *string `gorm:"type:varchar(50)" json:"unavailable_reason,omitempty"`
+
+	// Whether checkout includes the item; items are selected when added
+	Selected bool `gorm:"not null;default:true" json:"selected"`
+
+	// Buyer's note for the seller, e.g. "size M, blue"; copied to the order item at checkout
+	Note *string `gorm:"type:varchar(255)" json:"note,omitempty"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 
-	Cart    Cart    `gorm:"foreignKey:CartID" json:"cart,omitempty"`
-	Product Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Cart    Cart            `gorm:"foreignKey:CartID" json:"cart,omitempty"`
+	Product Product         `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Variant *ProductVariant `gorm:"foreignKey:VariantID" json:"variant,omitempty"` // Nil once the variant was removed
 }
 
 func (ci *CartItem) BeforeCreate(tx *gorm.DB) error {
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CartReminder records an abandoned cart reminder sent to a buyer. A cart is reminded about at
+// most once per change (CartActivityAt) and a buyer gets at most one reminder per cooldown.
+type CartReminder struct {
+	ID             string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CartID         string    `gorm:"type:uuid;not null;index" json:"cart_id"`
+	UserID         string    `gorm:"type:uuid;not null;index" json:"user_id"`
+	CartActivityAt time.Time `gorm:"not null" json:"cart_activity_at"` // Last cart change the reminder was about
+	ItemCount      int       `gorm:"not null" json:"item_count"`
+	Subtotal       int       `gorm:"not null" json:"subtotal"`
+	EmailQueued    bool      `gorm:"not null;default:false" json:"email_queued"`
+	PushSent       bool      `gorm:"not null;default:false" json:"push_sent"`
+	SentAt         time.Time `gorm:"not null;index" json:"sent_at"`
+}
+
+func (r *CartReminder) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (CartReminder) TableName() string {
+	return "cart_reminders"
+}
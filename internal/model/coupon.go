@@ -0,0 +1,93 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Coupon discount types
+const (
+	CouponTypePercentage   = "percentage"    // DiscountValue percent of the eligible subtotal, up to MaxDiscount
+	CouponTypeFixed        = "fixed"         // DiscountValue IDR off the eligible subtotal
+	CouponTypeFreeShipping = "free_shipping" // Shipping of the eligible shops, up to MaxDiscount
+)
+
+// Coupon is an admin-managed voucher code buyers apply to their cart. A coupon with a SellerID
+// only discounts that shop's items; discounts are funded by the marketplace and stay on the
+// parent order.
+type Coupon struct {
+	ID            string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Code          string     `gorm:"type:varchar(50);uniqueIndex;not null" json:"code"` // Upper case
+	Description   *string    `gorm:"type:text" json:"description,omitempty"`
+	DiscountType  string     `gorm:"type:varchar(20);not null" json:"discount_type"`
+	DiscountValue int        `gorm:"not null;default:0" json:"discount_value"` // Percent or IDR; unused for free shipping
+	MaxDiscount   int        `gorm:"not null;default:0" json:"max_discount"`   // 0 means no cap
+	MinSubtotal   int        `gorm:"not null;default:0" json:"min_subtotal"`   // Of the eligible items
+	SellerID      *string    `gorm:"type:uuid;index" json:"seller_id,omitempty"`
+	UsageLimit    int        `gorm:"not null;default:0" json:"usage_limit"`    // Redemptions across all buyers; 0 means unlimited
+	PerUserLimit  int        `gorm:"not null;default:1" json:"per_user_limit"` // Redemptions per buyer; 0 means unlimited
+	StartsAt      *time.Time `gorm:"type:timestamp" json:"starts_at,omitempty"`
+	ExpiresAt     *time.Time `gorm:"type:timestamp" json:"expires_at,omitempty"`
+	IsActive      bool       `gorm:"default:true;index" json:"is_active"`
+	BatchID       *string    `gorm:"type:uuid;index" json:"batch_id,omitempty"` // Set on single-use codes generated in bulk
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (c *Coupon) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (Coupon) TableName() string {
+	return "coupons"
+}
+
+// CouponBatch is a set of unique single-use codes generated at once for a campaign, e.g. to print
+// on flyers. Every code is its own coupon with the same discount settings.
+type CouponBatch struct {
+	ID         string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name       string    `gorm:"type:varchar(100);not null" json:"name"` // Campaign
+	Prefix     string    `gorm:"type:varchar(20)" json:"prefix"`
+	CodeLength int       `gorm:"not null" json:"code_length"` // Random characters after the prefix
+	Quantity   int       `gorm:"not null" json:"quantity"`
+	CreatedBy  string    `gorm:"type:uuid;not null" json:"created_by"` // Admin
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (b *CouponBatch) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (CouponBatch) TableName() string {
+	return "coupon_batches"
+}
+
+// CouponRedemption is a coupon used on an order. Redemptions of cancelled orders no longer count
+// towards the coupon's limits.
+type CouponRedemption struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CouponID  string    `gorm:"type:uuid;not null;index" json:"coupon_id"`
+	UserID    string    `gorm:"type:uuid;not null;index" json:"user_id"`
+	OrderID   string    `gorm:"type:uuid;not null;uniqueIndex" json:"order_id"`
+	Discount  int       `gorm:"not null" json:"discount"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (r *CouponRedemption) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (CouponRedemption) TableName() string {
+	return "coupon_redemptions"
+}
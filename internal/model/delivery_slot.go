@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DeliverySlot is a weekly delivery window a seller offers, e.g. every Saturday 09:00-12:00.
+// Buyers pick a slot and a matching date at checkout; holidays are never offered.
+type DeliverySlot struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SellerID  string    `gorm:"type:uuid;not null;index" json:"seller_id"`
+	Weekday   int       `gorm:"not null" json:"weekday"`                    // 0 = Sunday ... 6 = Saturday
+	StartTime string    `gorm:"type:varchar(5);not null" json:"start_time"` // HH:MM in the business timezone
+	EndTime   string    `gorm:"type:varchar(5);not null" json:"end_time"`
+	Capacity  int       `gorm:"default:0" json:"capacity"` // Orders per date; 0 = unlimited
+	IsActive  bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (s *DeliverySlot) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (DeliverySlot) TableName() string {
+	return "delivery_slots"
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Device platforms a push token can belong to
+const (
+	DevicePlatformAndroid = "android"
+	DevicePlatformIOS     = "ios"
+	DevicePlatformWeb     = "web"
+)
+
+// DeviceToken is an FCM registration token of one of a user's devices. A token belongs to the
+// user who registered it last, so a shared device follows whoever is logged in.
+type DeviceToken struct {
+	ID         string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     string    `gorm:"type:uuid;not null;index" json:"user_id"`
+	Token      string    `gorm:"type:varchar(512);not null;uniqueIndex" json:"token"`
+	Platform   string    `gorm:"type:varchar(20);not null;default:'android'" json:"platform"`
+	LastSeenAt time.Time `gorm:"type:timestamp;not null" json:"last_seen_at"` // Updated each time the app registers the token
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (t *DeviceToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (DeviceToken) TableName() string {
+	return "device_tokens"
+}
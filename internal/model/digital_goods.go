@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LicenseKey is one key in a digital product's pool. A key is free until it is assigned to the
+// order item it was delivered for.
+type LicenseKey struct {
+	ID          string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ProductID   string     `gorm:"type:uuid;not null;uniqueIndex:idx_license_keys_product_key" json:"product_id"`
+	Key         string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_license_keys_product_key" json:"key"`
+	OrderItemID *string    `gorm:"type:uuid;index" json:"order_item_id,omitempty"`
+	AssignedAt  *time.Time `gorm:"type:timestamp" json:"assigned_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (k *LicenseKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == "" {
+		k.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (LicenseKey) TableName() string {
+	return "license_keys"
+}
+
+// DigitalDelivery records that a paid order item of a digital product was delivered. The file
+// link is copied so later product edits do not take away what the buyer paid for.
+type DigitalDelivery struct {
+	ID            string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderID       string    `gorm:"type:uuid;not null;index" json:"order_id"`
+	OrderItemID   string    `gorm:"type:uuid;not null;uniqueIndex" json:"order_item_id"`
+	ProductID     string    `gorm:"type:uuid;not null;index" json:"product_id"`
+	UserID        string    `gorm:"type:uuid;not null;index" json:"user_id"`
+	FileURL       *string   `gorm:"type:text" json:"-"`
+	KeysRequested int       `gorm:"default:0" json:"keys_requested"` // Keys still missing are assigned when the seller adds more to the pool
+	DeliveredAt   time.Time `gorm:"autoCreateTime" json:"delivered_at"`
+
+	Product     Product      `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	LicenseKeys []LicenseKey `gorm:"foreignKey:OrderItemID;references:OrderItemID" json:"license_keys,omitempty"`
+}
+
+func (d *DigitalDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (DigitalDelivery) TableName() string {
+	return "digital_deliveries"
+}
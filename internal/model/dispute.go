@@ -0,0 +1,114 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Dispute statuses. A buyer opens a dispute, the seller responds, and either side may escalate it
+// to an admin, who resolves it. The buyer may withdraw it any time before that. The seller's
+// settlement for the sub-order is held while a dispute is open (any status but resolved/closed).
+const (
+	DisputeStatusOpen            = "open"
+	DisputeStatusSellerResponded = "seller_responded"
+	DisputeStatusUnderReview     = "under_review"
+	DisputeStatusResolved        = "resolved"
+	DisputeStatusClosed          = "closed" // Withdrawn by the buyer
+)
+
+// DisputeOpenStatuses are the statuses in which a dispute still holds the seller's settlement
+var DisputeOpenStatuses = []string{DisputeStatusOpen, DisputeStatusSellerResponded, DisputeStatusUnderReview}
+
+// Dispute outcomes decided by an admin
+const (
+	DisputeOutcomeBuyer  = "buyer"  // In the buyer's favour; may come with a refund
+	DisputeOutcomeSeller = "seller" // In the seller's favour; the settlement is released unchanged
+)
+
+// Dispute message sender roles
+const (
+	DisputeRoleBuyer  = "buyer"
+	DisputeRoleSeller = "seller"
+	DisputeRoleAdmin  = "admin"
+)
+
+// Dispute is a buyer's complaint about one sub-order (item not received, not as described,
+// damaged, ...) that the buyer and seller discuss and an admin can arbitrate
+type Dispute struct {
+	ID                string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderID           string     `gorm:"type:uuid;not null;index" json:"order_id"`
+	SellerOrderID     string     `gorm:"type:uuid;not null;index" json:"seller_order_id"`
+	UserID            string     `gorm:"type:uuid;not null;index" json:"user_id"` // Buyer
+	SellerID          string     `gorm:"type:uuid;not null;index" json:"seller_id"`
+	Reason            string     `gorm:"type:text;not null" json:"reason"`
+	Status            string     `gorm:"type:varchar(20);not null;default:'open';index" json:"status"`
+	Outcome           *string    `gorm:"type:varchar(20)" json:"outcome,omitempty"`
+	ResolutionNote    *string    `gorm:"type:text" json:"resolution_note,omitempty"`
+	RefundAmount      int        `gorm:"default:0" json:"refund_amount"`
+	RefundMethod      *string    `gorm:"type:varchar(20)" json:"refund_method,omitempty"`
+	ResolvedBy        *string    `gorm:"type:uuid" json:"resolved_by,omitempty"`
+	SellerRespondedAt *time.Time `gorm:"type:timestamp" json:"seller_responded_at,omitempty"`
+	EscalatedAt       *time.Time `gorm:"type:timestamp" json:"escalated_at,omitempty"`
+	ResolvedAt        *time.Time `gorm:"type:timestamp" json:"resolved_at,omitempty"`
+	ClosedAt          *time.Time `gorm:"type:timestamp" json:"closed_at,omitempty"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Photos      []DisputePhoto   `gorm:"foreignKey:DisputeID" json:"photos,omitempty"`
+	Messages    []DisputeMessage `gorm:"foreignKey:DisputeID" json:"messages,omitempty"`
+	SellerOrder SellerOrder      `gorm:"foreignKey:SellerOrderID" json:"seller_order,omitempty"`
+}
+
+func (d *Dispute) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (Dispute) TableName() string {
+	return "disputes"
+}
+
+// DisputePhoto is a picture uploaded by the buyer as evidence when opening the dispute
+type DisputePhoto struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DisputeID string    `gorm:"type:uuid;not null;index" json:"dispute_id"`
+	ImageURL  string    `gorm:"type:text;not null" json:"image_url"`
+	SortOrder int       `gorm:"default:0" json:"sort_order"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (p *DisputePhoto) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (DisputePhoto) TableName() string {
+	return "dispute_photos"
+}
+
+// DisputeMessage is one entry in a dispute's thread, written by the buyer, the seller or an admin
+type DisputeMessage struct {
+	ID         string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DisputeID  string    `gorm:"type:uuid;not null;index" json:"dispute_id"`
+	SenderID   string    `gorm:"type:uuid;not null" json:"sender_id"`
+	SenderRole string    `gorm:"type:varchar(10);not null" json:"sender_role"` // buyer, seller, admin
+	Message    string    `gorm:"type:text;not null" json:"message"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (m *DisputeMessage) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (DisputeMessage) TableName() string {
+	return "dispute_messages"
+}
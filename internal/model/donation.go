@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Donation ledger entry types
+const (
+	DonationEntryDonation     = "donation"     // A buyer's donation, credited once the order is paid
+	DonationEntryDisbursement = "disbursement" // Money handed over to the cause, recorded by an admin
+)
+
+// DonationCause is what buyers can donate to at checkout, by rounding their total up or adding an
+// amount. Only one cause is active at a time.
+type DonationCause struct {
+	ID              string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name            string     `gorm:"type:varchar(100);not null" json:"name"`
+	Description     *string    `gorm:"type:text" json:"description,omitempty"`
+	ImageURL        *string    `gorm:"type:text" json:"image_url,omitempty"`
+	TargetAmount    int        `gorm:"default:0" json:"target_amount"`    // 0 means no target
+	RaisedAmount    int        `gorm:"default:0" json:"raised_amount"`    // Sum of the paid donations
+	DisbursedAmount int        `gorm:"default:0" json:"disbursed_amount"` // Sum of the disbursements
+	IsActive        bool       `gorm:"default:false;index" json:"is_active"`
+	EndsAt          *time.Time `gorm:"type:timestamp" json:"ends_at,omitempty"` // Donations close after this
+	CreatedBy       string     `gorm:"type:uuid;not null" json:"-"`             // Admin
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (c *DonationCause) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (DonationCause) TableName() string {
+	return "donation_causes"
+}
+
+// DonationLedgerEntry is one movement of a cause's money: a donation coming in with a paid order,
+// or a disbursement going out to the cause
+type DonationLedgerEntry struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CauseID   string    `gorm:"type:uuid;not null;index:idx_donation_ledger_cause_created,priority:1" json:"cause_id"`
+	Type      string    `gorm:"type:varchar(20);not null" json:"type"`           // donation, disbursement
+	Amount    int       `gorm:"not null" json:"amount"`                          // Negative for disbursements
+	OrderID   *string   `gorm:"type:uuid;uniqueIndex" json:"order_id,omitempty"` // One donation per order
+	UserID    *string   `gorm:"type:uuid;index" json:"user_id,omitempty"`        // Donating buyer
+	Reference *string   `gorm:"type:varchar(100)" json:"reference,omitempty"`    // Transfer reference of a disbursement
+	Note      *string   `gorm:"type:text" json:"note,omitempty"`
+	CreatedBy *string   `gorm:"type:uuid" json:"created_by,omitempty"` // Admin recording a disbursement
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_donation_ledger_cause_created,priority:2" json:"created_at"`
+}
+
+func (e *DonationLedgerEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (DonationLedgerEntry) TableName() string {
+	return "donation_ledger_entries"
+}
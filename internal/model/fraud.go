@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Fraud rule types
+const (
+	FraudRuleUserVelocity       = "user_velocity"         // At most MaxAttempts per buyer within WindowMinutes
+	FraudRuleIPVelocity         = "ip_velocity"           // At most MaxAttempts per client IP within WindowMinutes
+	FraudRuleBlockedEmail       = "blocked_email"         // Value is an email address or a domain such as @example.com
+	FraudRuleBlockedPhone       = "blocked_phone"         // Value is a phone number; compared on its digits
+	FraudRuleNewAccountMaxOrder = "new_account_max_order" // Accounts younger than AccountAgeDays may not exceed MaxAmount
+)
+
+// Checkout stages the rules run at
+const (
+	FraudStageOrder   = "order"   // Order creation (checkout)
+	FraudStagePayment = "payment" // Payment creation
+	FraudStageAll     = "all"     // Rule applies to both stages
+)
+
+// FraudRule is an admin-managed checkout rule. Only the fields of the rule's type are used.
+type FraudRule struct {
+	ID             string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Type           string    `gorm:"type:varchar(40);not null;index" json:"type"`
+	Stage          string    `gorm:"type:varchar(20);not null;default:'all'" json:"stage"`
+	Value          *string   `gorm:"type:varchar(255)" json:"value,omitempty"`
+	MaxAttempts    int       `gorm:"default:0" json:"max_attempts,omitempty"`
+	WindowMinutes  int       `gorm:"default:0" json:"window_minutes,omitempty"`
+	MaxAmount      int       `gorm:"default:0" json:"max_amount,omitempty"`
+	AccountAgeDays int       `gorm:"default:0" json:"account_age_days,omitempty"`
+	IsActive       bool      `gorm:"default:true;index" json:"is_active"`
+	Note           *string   `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (r *FraudRule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (FraudRule) TableName() string {
+	return "fraud_rules"
+}
+
+// FraudCheck records one checkout or payment attempt the rules were evaluated for. Allowed
+// checks feed the velocity rules and are pruned; blocked ones are kept as the audit trail.
+type FraudCheck struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Stage     string    `gorm:"type:varchar(20);not null;index:idx_fraud_checks_user,priority:2;index:idx_fraud_checks_ip,priority:2" json:"stage"`
+	UserID    string    `gorm:"type:uuid;not null;index:idx_fraud_checks_user,priority:1" json:"user_id"`
+	ClientIP  string    `gorm:"type:varchar(45);index:idx_fraud_checks_ip,priority:1" json:"client_ip"`
+	OrderID   *string   `gorm:"type:uuid" json:"order_id,omitempty"` // Payment stage only; orders have no ID before they are created
+	Amount    int       `gorm:"not null" json:"amount"`
+	Blocked   bool      `gorm:"default:false;index" json:"blocked"`
+	RuleID    *string   `gorm:"type:uuid" json:"rule_id,omitempty"`
+	RuleType  *string   `gorm:"type:varchar(40)" json:"rule_type,omitempty"`
+	Reason    *string   `gorm:"type:text" json:"reason,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_fraud_checks_user,priority:3;index:idx_fraud_checks_ip,priority:3" json:"created_at"`
+}
+
+func (c *FraudCheck) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (FraudCheck) TableName() string {
+	return "fraud_checks"
+}
@@ -0,0 +1,103 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Insurance claim types
+const (
+	InsuranceClaimLost    = "lost"    // The shipment never arrived
+	InsuranceClaimDamaged = "damaged" // The shipment arrived damaged
+)
+
+// Insurance claim statuses. A claim is submitted by the buyer or the seller and an admin approves
+// (paying it out) or rejects it.
+const (
+	InsuranceClaimSubmitted = "submitted"
+	InsuranceClaimApproved  = "approved"
+	InsuranceClaimRejected  = "rejected"
+)
+
+// Who filed an insurance claim
+const (
+	ClaimFiledByBuyer  = "buyer"
+	ClaimFiledBySeller = "seller"
+)
+
+// InsuranceClaimActiveStatuses are the statuses that keep another claim from being filed for the
+// same shipment
+var InsuranceClaimActiveStatuses = []string{InsuranceClaimSubmitted, InsuranceClaimApproved}
+
+// Who an approved claim is paid out to
+const (
+	InsurancePayoutBuyer  = "buyer"  // Refunded to the buyer's payment
+	InsurancePayoutSeller = "seller" // Added to the seller's settlement for the sub-order
+)
+
+// ClaimPayoutSettlement is the payout method of claims credited to the seller's settlement; buyer
+// payouts use the refund methods
+const ClaimPayoutSettlement = "settlement"
+
+// InsuranceClaim asks the shipping insurance paid on an order to cover one lost or damaged
+// shipment (sub-order). It records the courier and tracking number the shipment went out with.
+type InsuranceClaim struct {
+	ID              string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderID         string     `gorm:"type:uuid;not null;index" json:"order_id"`
+	SellerOrderID   string     `gorm:"type:uuid;not null;index" json:"seller_order_id"`
+	UserID          string     `gorm:"type:uuid;not null;index" json:"user_id"` // Buyer
+	SellerID        string     `gorm:"type:uuid;not null;index" json:"seller_id"`
+	FiledBy         string     `gorm:"type:uuid;not null" json:"filed_by"`
+	FiledByRole     string     `gorm:"type:varchar(10);not null" json:"filed_by_role"` // buyer, seller
+	Type            string     `gorm:"type:varchar(20);not null" json:"type"`          // lost, damaged
+	Description     string     `gorm:"type:text;not null" json:"description"`
+	ClaimAmount     int        `gorm:"not null" json:"claim_amount"` // At most the sub-order subtotal
+	Courier         *string    `gorm:"type:varchar(50)" json:"courier,omitempty"`
+	TrackingNumber  *string    `gorm:"type:varchar(100)" json:"tracking_number,omitempty"`
+	ShippingLabelID *string    `gorm:"type:uuid" json:"shipping_label_id,omitempty"` // When the shipment was booked through us
+	Status          string     `gorm:"type:varchar(20);not null;default:'submitted';index" json:"status"`
+	PayoutTo        *string    `gorm:"type:varchar(10)" json:"payout_to,omitempty"` // buyer, seller
+	PayoutAmount    int        `gorm:"default:0" json:"payout_amount"`
+	PayoutMethod    *string    `gorm:"type:varchar(20)" json:"payout_method,omitempty"` // midtrans, manual, settlement
+	DecisionNote    *string    `gorm:"type:text" json:"decision_note,omitempty"`
+	DecidedBy       *string    `gorm:"type:uuid" json:"decided_by,omitempty"`
+	DecidedAt       *time.Time `gorm:"type:timestamp" json:"decided_at,omitempty"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Photos      []InsuranceClaimPhoto `gorm:"foreignKey:ClaimID" json:"photos,omitempty"`
+	SellerOrder SellerOrder           `gorm:"foreignKey:SellerOrderID" json:"seller_order,omitempty"`
+}
+
+func (ic *InsuranceClaim) BeforeCreate(tx *gorm.DB) error {
+	if ic.ID == "" {
+		ic.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (InsuranceClaim) TableName() string {
+	return "insurance_claims"
+}
+
+// InsuranceClaimPhoto is a picture uploaded as evidence when filing the claim
+type InsuranceClaimPhoto struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ClaimID   string    `gorm:"type:uuid;not null;index" json:"claim_id"`
+	ImageURL  string    `gorm:"type:text;not null" json:"image_url"`
+	SortOrder int       `gorm:"default:0" json:"sort_order"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (p *InsuranceClaimPhoto) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (InsuranceClaimPhoto) TableName() string {
+	return "insurance_claim_photos"
+}
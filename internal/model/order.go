@@ -16,21 +16,52 @@ type Order struct {
 	ShippingCost      int            `gorm:"default:0" json:"shipping_cost"`
 	InsuranceCost     int            `gorm:"default:0" json:"insurance_cost"`
 	WarrantyCost      int            `gorm:"default:0" json:"warranty_cost"`
+	GiftWrapFee       int            `gorm:"default:0" json:"gift_wrap_fee"`
+	DonationAmount    int            `gorm:"default:0" json:"donation_amount"`                  // Given to DonationCauseID; not part of any sub-order
+	DonationCauseID   *string        `gorm:"type:uuid;index" json:"donation_cause_id,omitempty"`
 	ServiceFee        int            `gorm:"default:0" json:"service_fee"`
 	ApplicationFee    int            `gorm:"default:0" json:"application_fee"`
 	TotalDiscount     int            `gorm:"default:0" json:"total_discount"`
+	CouponCode        *string        `gorm:"type:varchar(50);index" json:"coupon_code,omitempty"` // Voucher the discount came from
 	Bonus             int            `gorm:"default:0" json:"bonus"`
 	TotalAmount       int            `gorm:"not null" json:"total_amount"`
+	TaxAmount         int            `gorm:"default:0" json:"tax_amount"` // PPN on the goods; see TaxLines. Part of TotalAmount only when prices exclude it
 	Status            string         `gorm:"type:varchar(50);not null;default:'pending';index" json:"status"` // pending, processing, shipped, delivered, cancelled
 	Notes             *string        `gorm:"type:text" json:"notes,omitempty"`
+	IsGift            bool           `gorm:"default:false" json:"is_gift"` // Shipped to someone other than the buyer; prices are hidden on the packing slip
+	RecipientName     *string        `gorm:"type:varchar(255)" json:"recipient_name,omitempty"` // Gift recipient contact, overrides the address contact
+	RecipientPhone    *string        `gorm:"type:varchar(20)" json:"recipient_phone,omitempty"`
+	GiftMessage       *string        `gorm:"type:text" json:"gift_message,omitempty"`
+	GiftWrap          bool           `gorm:"default:false" json:"gift_wrap"` // Sellers wrap the items; charged as GiftWrapFee
+	Require3DS        *bool          `gorm:"column:require_3ds" json:"require_3ds,omitempty"` // Per-order override of the credit card 3DS policy (nil = use policy)
+	AffiliateCode     *string        `gorm:"type:varchar(20);index" json:"affiliate_code,omitempty"` // Referring affiliate, captured at checkout
+	CancelledAt       *time.Time     `gorm:"type:timestamp" json:"cancelled_at,omitempty"`
+	CancelReason      *string        `gorm:"type:text" json:"cancel_reason,omitempty"`
+	SLAStartAt        *time.Time     `gorm:"type:timestamp" json:"sla_start_at,omitempty"` // Fulfilment clock start (respects order cutoff and holidays)
+	EstimatedShipDate *time.Time     `gorm:"type:date" json:"estimated_ship_date,omitempty"`
+	CourierService    string         `gorm:"type:varchar(30);default:'regular'" json:"courier_service"`
+	DeliveryETAFrom   *time.Time     `gorm:"type:date" json:"delivery_eta_from,omitempty"` // Promised delivery range (business days)
+	DeliveryETATo     *time.Time     `gorm:"type:date" json:"delivery_eta_to,omitempty"`
+	DeliverySlotID    *string        `gorm:"type:uuid;index" json:"delivery_slot_id,omitempty"` // Buyer-picked delivery window; the times are copied so later slot edits do not move it
+	DeliveryDate      *time.Time     `gorm:"type:date" json:"delivery_date,omitempty"`
+	DeliveryStartTime *string        `gorm:"type:varchar(5)" json:"delivery_start_time,omitempty"`
+	DeliveryEndTime   *string        `gorm:"type:varchar(5)" json:"delivery_end_time,omitempty"`
+	Courier           *string        `gorm:"type:varchar(50)" json:"courier,omitempty"` // Mirrors the shipment of single-seller orders; see SellerOrders otherwise
+	TrackingNumber    *string        `gorm:"type:varchar(100)" json:"tracking_number,omitempty"`
+	ShippedAt         *time.Time     `gorm:"type:timestamp" json:"shipped_at,omitempty"` // Set when the last sub-order ships
+	DeliveredAt       *time.Time     `gorm:"type:timestamp" json:"delivered_at,omitempty"`
+	DeliveredOnTime   *bool          `gorm:"index" json:"delivered_on_time,omitempty"` // Actual vs promised, set when the order is delivered
+	ArchivedAt        *time.Time     `gorm:"type:timestamp;index" json:"archived_at,omitempty"` // Hidden from the buyer's main order history
 	CreatedAt         time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt         time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
 
-	User            User        `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	ShippingAddress Address     `gorm:"foreignKey:ShippingAddressID" json:"shipping_address,omitempty"`
-	OrderItems      []OrderItem `gorm:"foreignKey:OrderID" json:"order_items,omitempty"`
-	Payment         *Payment    `gorm:"foreignKey:OrderUUID" json:"payment,omitempty"`
+	User            User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	ShippingAddress Address       `gorm:"foreignKey:ShippingAddressID" json:"shipping_address,omitempty"`
+	OrderItems      []OrderItem   `gorm:"foreignKey:OrderID" json:"order_items,omitempty"`
+	SellerOrders    []SellerOrder `gorm:"foreignKey:OrderID" json:"seller_orders,omitempty"`
+	Payment         *Payment      `gorm:"foreignKey:OrderUUID" json:"payment,omitempty"`
+	TaxLines        []OrderTaxLine `gorm:"foreignKey:OrderID" json:"tax_lines,omitempty"`
 }
 
 func (o *Order) BeforeCreate(tx *gorm.DB) error {
@@ -51,22 +82,48 @@ type OrderItem struct {
 	ID          string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	OrderID     string    `gorm:"type:uuid;not null;index" json:"order_id"`
 	ProductID   string    `gorm:"type:uuid;not null;index" json:"product_id"`
-	SellerID    string    `gorm:"type:uuid;not null;index" json:"seller_id"`
+	SellerID    string    `gorm:"type:uuid;not null;index;index:idx_order_items_seller_created,priority:1" json:"seller_id"` // Set from the product at checkout
 	ProductName string    `gorm:"type:varchar(255);not null" json:"product_name"`
 	Quantity    int       `gorm:"not null" json:"quantity"`
 	Price       int       `gorm:"not null" json:"price"` // Price at time of order
 	Subtotal    int       `gorm:"not null" json:"subtotal"`
-	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	Note        *string   `gorm:"type:varchar(255)" json:"note,omitempty"` // Buyer's note from the cart item
+	CreatedAt   time.Time `gorm:"autoCreateTime;index:idx_order_items_seller_created,priority:2" json:"created_at"`
+
+	// Per-seller sub-order the item is fulfilled under (empty for orders placed before sub-orders)
+	SellerOrderID *string `gorm:"type:uuid;index" json:"seller_order_id,omitempty"`
+
+	// Variant bought, with its name at the time of order
+	VariantID   *string `gorm:"type:uuid;index" json:"variant_id,omitempty"`
+	VariantName *string `gorm:"type:varchar(255)" json:"variant_name,omitempty"`
+
+	// Fulfillment of this item, so one item can ship while another is back-ordered
+	Status          string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"` // pending, packed, shipped, delivered, returned
+	Courier         *string    `gorm:"type:varchar(50)" json:"courier,omitempty"`
+	TrackingNumber  *string    `gorm:"type:varchar(100)" json:"tracking_number,omitempty"`
+	StatusUpdatedAt *time.Time `gorm:"type:timestamp" json:"status_updated_at,omitempty"`
 
 	Order   Order  `gorm:"foreignKey:OrderID" json:"order,omitempty"`
 	Product Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
 	Seller  Seller  `gorm:"foreignKey:SellerID" json:"seller,omitempty"`
 }
 
+// Order item fulfillment statuses
+const (
+	OrderItemStatusPending   = "pending"
+	OrderItemStatusPacked    = "packed"
+	OrderItemStatusShipped   = "shipped"
+	OrderItemStatusDelivered = "delivered"
+	OrderItemStatusReturned  = "returned"
+)
+
 func (oi *OrderItem) BeforeCreate(tx *gorm.DB) error {
 	if oi.ID == "" {
 		oi.ID = uuid.New().String()
 	}
+	if oi.Status == "" {
+		oi.Status = OrderItemStatusPending
+	}
 	return nil
 }
 
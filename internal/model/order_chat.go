@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Order chat sender roles
+const (
+	OrderChatRoleBuyer  = "buyer"
+	OrderChatRoleSeller = "seller"
+	OrderChatRoleSystem = "system" // Posted on order status changes
+)
+
+// Order chat system events
+const (
+	OrderChatEventPaid       = "paid"
+	OrderChatEventProcessing = "processing"
+	OrderChatEventShipped    = "shipped"
+	OrderChatEventDelivered  = "delivered"
+	OrderChatEventCancelled  = "cancelled"
+)
+
+// OrderChatMessage is a message in the chat between the buyer and one shop of an order. Every
+// sub-order has its own thread; system messages record the order's progress in it.
+type OrderChatMessage struct {
+	ID            string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderID       string    `gorm:"type:uuid;not null;index" json:"order_id"`
+	SellerOrderID string    `gorm:"type:uuid;not null;index:idx_order_chat_thread" json:"seller_order_id"`
+	SellerID      string    `gorm:"type:uuid;not null;index" json:"seller_id"`
+	SenderRole    string    `gorm:"type:varchar(20);not null" json:"sender_role"` // buyer, seller, system
+	SenderID      *string   `gorm:"type:uuid" json:"sender_id,omitempty"`         // Empty for system messages
+	Event         *string   `gorm:"type:varchar(20)" json:"event,omitempty"`      // Status change a system message is about
+	Body          string    `gorm:"type:text;not null" json:"body"`
+	CreatedAt     time.Time `gorm:"autoCreateTime;index:idx_order_chat_thread" json:"created_at"`
+}
+
+func (m *OrderChatMessage) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (OrderChatMessage) TableName() string {
+	return "order_chat_messages"
+}
+
+// OrderChatRead is how far a participant has read a thread, for unread counts
+type OrderChatRead struct {
+	SellerOrderID string    `gorm:"type:uuid;primary_key" json:"seller_order_id"`
+	Role          string    `gorm:"type:varchar(20);primary_key" json:"role"` // buyer or seller
+	LastReadAt    time.Time `gorm:"type:timestamp;not null" json:"last_read_at"`
+}
+
+func (OrderChatRead) TableName() string {
+	return "order_chat_reads"
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrderNote is an internal annotation left on an order by an admin. Notes are never shown to
+// buyers or sellers.
+type OrderNote struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderID   string    `gorm:"type:uuid;not null;index" json:"order_id"`
+	AuthorID  string    `gorm:"type:uuid;not null" json:"author_id"`
+	Note      string    `gorm:"type:text;not null" json:"note"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Author User `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+}
+
+func (n *OrderNote) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == "" {
+		n.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (OrderNote) TableName() string {
+	return "order_notes"
+}
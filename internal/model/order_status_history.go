@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Actors that can change an order's status
+const (
+	StatusActorBuyer   = "buyer"
+	StatusActorSeller  = "seller"
+	StatusActorAdmin   = "admin"
+	StatusActorSystem  = "system"  // Payment callbacks and background jobs
+	StatusActorPartner = "partner" // A seller's system on the partner API, e.g. a 3PL warehouse
+)
+
+// OrderStatusHistory records one order status transition, for the buyer's progress tracker.
+// SellerOrderID is set when the transition belongs to a single seller's sub-order.
+type OrderStatusHistory struct {
+	ID            string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderID       string    `gorm:"type:uuid;not null;index" json:"order_id"`
+	SellerOrderID *string   `gorm:"type:uuid;index" json:"seller_order_id,omitempty"`
+	FromStatus    *string   `gorm:"type:varchar(50)" json:"from_status,omitempty"` // Nil when the order was created
+	ToStatus      string    `gorm:"type:varchar(50);not null" json:"to_status"`
+	ActorType     string    `gorm:"type:varchar(20);not null" json:"actor_type"`
+	ActorID       *string   `gorm:"type:uuid" json:"actor_id,omitempty"`
+	Note          *string   `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt     time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+func (h *OrderStatusHistory) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == "" {
+		h.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (OrderStatusHistory) TableName() string {
+	return "order_status_histories"
+}
+
+// StatusChange describes who is changing an order's status and why
+type StatusChange struct {
+	ActorType string
+	ActorID   string // Empty for system changes
+	Note      string
+}
+
+// NewStatusHistory builds the history row for a transition made by change
+func NewStatusHistory(orderID string, from *string, to string, change StatusChange) *OrderStatusHistory {
+	history := &OrderStatusHistory{
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorType:  change.ActorType,
+	}
+	if change.ActorID != "" {
+		actorID := change.ActorID
+		history.ActorID = &actorID
+	}
+	if change.Note != "" {
+		note := change.Note
+		history.Note = &note
+	}
+	return history
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TaxNamePPN is the Indonesian VAT (Pajak Pertambahan Nilai)
+const TaxNamePPN = "PPN"
+
+// OrderTaxLine is the tax charged on one seller's part of an order. The rate and the seller's
+// NPWP are copied at checkout so later changes do not alter issued invoices or tax reports.
+type OrderTaxLine struct {
+	ID              string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderID         string    `gorm:"type:uuid;not null;index" json:"order_id"`
+	SellerOrderID   *string   `gorm:"type:uuid;index" json:"seller_order_id,omitempty"`
+	SellerID        string    `gorm:"type:uuid;not null;index" json:"seller_id"`
+	Name            string    `gorm:"type:varchar(20);not null" json:"name"`
+	RateBasisPoints int       `gorm:"not null" json:"rate_basis_points"`
+	Inclusive       bool      `gorm:"not null" json:"inclusive"`      // The taxed prices already contained the tax
+	TaxableAmount   int       `gorm:"not null" json:"taxable_amount"` // DPP (Dasar Pengenaan Pajak)
+	TaxAmount       int       `gorm:"not null" json:"tax_amount"`
+	SellerNPWP      *string   `gorm:"type:varchar(16)" json:"seller_npwp,omitempty"`
+	CreatedAt       time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+func (l *OrderTaxLine) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (OrderTaxLine) TableName() string {
+	return "order_tax_lines"
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PartnerAPIKey authenticates a seller's external system (e.g. an offline POS) against the partner API.
+// Only the SHA-256 hash of the key is stored; the plaintext is shown once on creation.
+type PartnerAPIKey struct {
+	ID         string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SellerID   string     `gorm:"type:uuid;not null;index" json:"seller_id"`
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`
+	KeyPrefix  string     `gorm:"type:varchar(20);not null" json:"key_prefix"` // First characters of the key, to help sellers identify it
+	KeyHash    string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	Sandbox    bool       `gorm:"not null;default:false" json:"sandbox"`                // Works only against sandbox data (see SandboxProduct)
+	Plan       string     `gorm:"type:varchar(20);not null;default:'free'" json:"plan"` // Usage quota, e.g. PartnerPlanFree
+	LastUsedAt *time.Time `gorm:"type:timestamp" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `gorm:"type:timestamp" json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Verifies the key's signed fulfillment callbacks; only shown when it is issued
+	CallbackSecret *string `gorm:"type:varchar(100)" json:"-"`
+}
+
+func (k *PartnerAPIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == "" {
+		k.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (PartnerAPIKey) TableName() string {
+	return "partner_api_keys"
+}
+
+// StockAdjustment records a stock change pushed by a partner system.
+// Reference is the partner's own ID for the adjustment and makes retries idempotent.
+type StockAdjustment struct {
+	ID            string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SellerID      string    `gorm:"type:uuid;not null;uniqueIndex:idx_stock_adjustments_seller_reference" json:"seller_id"`
+	Reference     string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_stock_adjustments_seller_reference" json:"reference"`
+	ProductID     string    `gorm:"type:uuid;not null;index" json:"product_id"`
+	SKU           string    `gorm:"type:varchar(100);not null" json:"sku"`
+	Delta         int       `gorm:"not null" json:"delta"`
+	PreviousStock int       `gorm:"not null" json:"previous_stock"`
+	NewStock      int       `gorm:"not null" json:"new_stock"`
+	Source        string    `gorm:"type:varchar(50);default:'pos'" json:"source"`
+	Reason        *string   `gorm:"type:text" json:"reason,omitempty"`
+	APIKeyID      *string   `gorm:"type:uuid" json:"api_key_id,omitempty"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (sa *StockAdjustment) BeforeCreate(tx *gorm.DB) error {
+	if sa.ID == "" {
+		sa.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (StockAdjustment) TableName() string {
+	return "stock_adjustments"
+}
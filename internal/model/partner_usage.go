@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Partner API plans. Quotas are per calendar month (UTC); see PartnerUsageService for the limits.
+const (
+	PartnerPlanFree      = "free"      // Requests over quota are refused
+	PartnerPlanPro       = "pro"       // Requests over quota are allowed and billed as overage
+	PartnerPlanUnlimited = "unlimited" // No quota
+)
+
+// Partner usage metrics a quota applies to
+const (
+	PartnerUsageMetricRequests = "requests"
+	PartnerUsageMetricTransfer = "transfer"
+)
+
+// PartnerAPIUsage counts the requests and bytes of a partner API key on one UTC day. Counters are
+// rolled up from Redis, so the current day lags behind by up to one rollup interval.
+type PartnerAPIUsage struct {
+	APIKeyID  string    `gorm:"type:uuid;primaryKey" json:"api_key_id"`
+	Date      time.Time `gorm:"type:date;primaryKey" json:"date"`
+	Requests  int64     `gorm:"not null;default:0" json:"requests"`
+	BytesIn   int64     `gorm:"not null;default:0" json:"bytes_in"`  // Request bodies
+	BytesOut  int64     `gorm:"not null;default:0" json:"bytes_out"` // Response bodies
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (PartnerAPIUsage) TableName() string {
+	return "partner_api_usage"
+}
+
+// PartnerUsageAlert records a usage alert sent to a seller, so each threshold of a metric is
+// alerted at most once per key and month
+type PartnerUsageAlert struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	APIKeyID  string    `gorm:"type:uuid;not null;uniqueIndex:idx_partner_usage_alerts_key_period" json:"api_key_id"`
+	Period    string    `gorm:"type:varchar(7);not null;uniqueIndex:idx_partner_usage_alerts_key_period" json:"period"` // YYYY-MM
+	Metric    string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_partner_usage_alerts_key_period" json:"metric"`
+	Threshold int       `gorm:"not null;uniqueIndex:idx_partner_usage_alerts_key_period" json:"threshold"` // Percent of the quota
+	Used      int64     `gorm:"not null" json:"used"`
+	Limit     int64     `gorm:"not null" json:"limit"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (a *PartnerUsageAlert) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (PartnerUsageAlert) TableName() string {
+	return "partner_usage_alerts"
+}
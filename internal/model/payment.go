@@ -25,6 +25,8 @@ const (
 	PaymentMethodCreditCard   PaymentMethod = "credit_card"
 	PaymentMethodQRIS         PaymentMethod = "qris"
 	PaymentMethodAlfamart     PaymentMethod = "alfamart"
+	// PaymentMethodManualTransfer is a plain bank transfer verified by hand (no Midtrans involved)
+	PaymentMethodManualTransfer PaymentMethod = "manual_transfer"
 )
 
 type Payment struct {
@@ -34,6 +36,7 @@ type Payment struct {
 	MidtransTransactionID *string       `gorm:"type:varchar(255);index" json:"midtrans_transaction_id,omitempty"`
 	Amount                int           `gorm:"not null" json:"amount"`
 	TotalAmount           int           `gorm:"not null" json:"total_amount"`
+	RefundedAmount        int           `gorm:"default:0" json:"refunded_amount"` // Sum of refunds made for returns
 	Status                PaymentStatus `gorm:"type:varchar(50);not null;default:'pending';index" json:"status"`
 	PaymentMethod         PaymentMethod `gorm:"type:varchar(50);not null" json:"payment_method"`
 	PaymentType           string        `gorm:"type:varchar(50);default:'midtrans'" json:"payment_type"`
@@ -41,8 +44,20 @@ type Payment struct {
 	VANumber              *string       `gorm:"type:varchar(50)" json:"va_number,omitempty"`
 	BankType              *string       `gorm:"type:varchar(50)" json:"bank_type,omitempty"`
 	QRCodeURL             *string       `gorm:"type:text" json:"qr_code_url,omitempty"`
+	QRString              *string       `gorm:"type:text" json:"qr_string,omitempty"` // Raw QR payload (QRIS/GoPay), rendered by GET /payments/:id/qr.png
 	ExpiryTime            *time.Time    `gorm:"type:timestamp" json:"expiry_time,omitempty"`
-	MidtransResponse      *string       `gorm:"type:text" json:"midtrans_response,omitempty"` // Raw JSON response from Midtrans
+	MidtransResponse      *string       `gorm:"type:text" json:"midtrans_response,omitempty"`           // Raw JSON response from Midtrans
+	MidtransStatusCode    *string       `gorm:"type:varchar(10)" json:"midtrans_status_code,omitempty"` // status_code of the latest Midtrans response, e.g. "200", "201"
+	SettlementTime        *time.Time    `gorm:"type:timestamp;index" json:"settlement_time,omitempty"`  // When Midtrans settled the transaction
+	ApprovalCode          *string       `gorm:"type:varchar(100)" json:"approval_code,omitempty"`       // Issuer approval code (credit card)
+	Currency              *string       `gorm:"type:varchar(10)" json:"currency,omitempty"`
+	ProofImageURL         *string       `gorm:"type:text" json:"proof_image_url,omitempty"` // Transfer receipt uploaded by buyer (manual_transfer only)
+	ProofUploadedAt       *time.Time    `gorm:"type:timestamp" json:"proof_uploaded_at,omitempty"`
+	VerifiedBy            *string       `gorm:"type:uuid" json:"verified_by,omitempty"` // Admin user who reviewed the transfer proof
+	VerifiedAt            *time.Time    `gorm:"type:timestamp" json:"verified_at,omitempty"`
+	VerificationNote      *string       `gorm:"type:text" json:"verification_note,omitempty"`
+	ThreeDSEnforced       *bool         `gorm:"column:three_ds_enforced" json:"three_ds_enforced,omitempty"`                   // Credit card only: whether 3DS was requested
+	ThreeDSDecision       *string       `gorm:"column:three_ds_decision;type:varchar(255)" json:"three_ds_decision,omitempty"` // Reason for the 3DS decision (audit)
 	CreatedAt             time.Time     `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt             time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
 
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PaymentStatusRetry is a gateway-reported payment status that could not be saved. The payment
+// service replays it with backoff until it is stored, so a settlement Midtrans confirmed is not
+// lost when the database write fails.
+type PaymentStatusRetry struct {
+	ID               string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderNumber      string     `gorm:"type:varchar(50);not null;index" json:"order_number"`
+	MidtransStatus   string     `gorm:"type:varchar(50);not null" json:"midtrans_status"` // transaction_status as reported by Midtrans
+	TransactionID    string     `gorm:"type:varchar(255)" json:"transaction_id"`
+	VANumber         string     `gorm:"type:varchar(50)" json:"va_number"`
+	BankType         string     `gorm:"type:varchar(50)" json:"bank_type"`
+	QRCodeURL        string     `gorm:"type:text" json:"qr_code_url"`
+	ExpiryTime       *time.Time `gorm:"type:timestamp" json:"expiry_time,omitempty"`
+	MidtransResponse string     `gorm:"type:text" json:"midtrans_response"`
+	Attempts         int        `gorm:"default:0" json:"attempts"`
+	LastError        *string    `gorm:"type:text" json:"last_error,omitempty"`
+	NextAttemptAt    time.Time  `gorm:"not null;index" json:"next_attempt_at"`
+	DeadAt           *time.Time `gorm:"type:timestamp;index" json:"dead_at,omitempty"` // Gave up; needs manual reconciliation
+	ReportedAt       time.Time  `gorm:"not null" json:"reported_at"`                   // When the gateway reported the status
+	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (r *PaymentStatusRetry) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (PaymentStatusRetry) TableName() string {
+	return "payment_status_retries"
+}
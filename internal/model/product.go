@@ -14,19 +14,41 @@ type Product struct {
 	Name        string         `gorm:"type:varchar(255);not null" json:"name"`
 	Description *string        `gorm:"type:text" json:"description,omitempty"`
 	SKU         string         `gorm:"type:varchar(100);uniqueIndex;not null" json:"sku"`
-	Price       int            `gorm:"not null" json:"price"`
+	Price       int            `gorm:"not null;index" json:"price"`
 	Stock       int            `gorm:"default:0" json:"stock"`
 	Weight      *int           `gorm:"type:int" json:"weight,omitempty"`
 	Thumbnail   *string        `gorm:"type:text" json:"thumbnail,omitempty"`
 	IsActive    bool           `gorm:"default:true" json:"is_active"`
 	IsFeatured  bool           `gorm:"default:false" json:"is_featured"`
-	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime;index" json:"created_at"`
 	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 
-	Seller        Seller         `gorm:"foreignKey:SellerID" json:"seller,omitempty"`
-	Category      Category       `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
-	ProductImages []ProductImage `gorm:"foreignKey:ProductID" json:"images,omitempty"`
+	// Seller-scheduled visibility changes, applied by the product scheduler
+	ScheduledPublishAt   *time.Time `gorm:"index" json:"scheduled_publish_at,omitempty"`
+	ScheduledUnpublishAt *time.Time `gorm:"index" json:"scheduled_unpublish_at,omitempty"`
+
+	// Units sold in paid orders, for best-selling sorting; units of orders cancelled after payment
+	// are taken off again
+	SoldCount int `gorm:"not null;default:0;index" json:"sold_count"`
+
+	// Most units of the product one order may contain; 0 means no limit
+	MaxOrderQuantity int `gorm:"default:0" json:"max_order_quantity"`
+
+	// Warranty terms for buyers who pay for warranty protection; 0 months uses the platform default
+	WarrantyMonths int     `gorm:"default:0" json:"warranty_months"`
+	WarrantyTerms  *string `gorm:"type:text" json:"warranty_terms,omitempty"` // What the warranty covers
+
+	// Digital goods are delivered automatically once the order is paid
+	IsDigital          bool    `gorm:"default:false" json:"is_digital"`
+	DigitalFileURL     *string `gorm:"type:text" json:"-"`                        // Only handed out as an expiring signed download link
+	LicenseKeyRequired bool    `gorm:"default:false" json:"license_key_required"` // One key per unit from the product's license key pool
+
+	Seller        Seller           `gorm:"foreignKey:SellerID" json:"seller,omitempty"`
+	Category      Category         `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	ProductImages []ProductImage   `gorm:"foreignKey:ProductID" json:"images,omitempty"`
+	Variants      []ProductVariant `gorm:"foreignKey:ProductID" json:"variants,omitempty"`
+	Tags          []Tag            `gorm:"many2many:product_tags" json:"tags,omitempty"`
 }
 
 func (p *Product) BeforeCreate(tx *gorm.DB) error {
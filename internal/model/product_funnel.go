@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Product funnel events, from browsing to a paid order
+const (
+	FunnelEventView      = "view"
+	FunnelEventAddToCart = "add_to_cart"
+	FunnelEventCheckout  = "checkout"
+	FunnelEventPurchase  = "purchase"
+)
+
+// ProductFunnelStat holds a product's funnel counters for one business day
+type ProductFunnelStat struct {
+	ID         string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ProductID  string    `gorm:"type:uuid;not null;uniqueIndex:idx_product_funnel_stats_product_date" json:"product_id"`
+	SellerID   string    `gorm:"type:uuid;not null;index" json:"seller_id"`
+	Date       time.Time `gorm:"type:date;not null;uniqueIndex:idx_product_funnel_stats_product_date;index" json:"date"`
+	Views      int64     `gorm:"not null;default:0" json:"views"`
+	AddToCarts int64     `gorm:"not null;default:0" json:"add_to_carts"`
+	Checkouts  int64     `gorm:"not null;default:0" json:"checkouts"` // Orders placed containing the product
+	Purchases  int64     `gorm:"not null;default:0" json:"purchases"` // Paid orders containing the product
+	UnitsSold  int64     `gorm:"not null;default:0" json:"units_sold"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (s *ProductFunnelStat) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (ProductFunnelStat) TableName() string {
+	return "product_funnel_stats"
+}
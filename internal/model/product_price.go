@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Price schedule statuses
+const (
+	PriceScheduleScheduled = "scheduled"
+	PriceScheduleApplied   = "applied"
+	PriceScheduleCancelled = "cancelled"
+)
+
+// Price change sources recorded in the price history
+const (
+	PriceChangeManual    = "manual"
+	PriceChangeScheduled = "scheduled"
+)
+
+// ProductPriceSchedule is a price a seller set to take effect later; the price scheduler applies it
+// once EffectiveAt passes
+type ProductPriceSchedule struct {
+	ID          string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ProductID   string     `gorm:"type:uuid;not null;index" json:"product_id"`
+	SellerID    string     `gorm:"type:uuid;not null;index" json:"seller_id"`
+	Price       int        `gorm:"not null" json:"price"`
+	EffectiveAt time.Time  `gorm:"type:timestamp;not null;index" json:"effective_at"`
+	Status      string     `gorm:"type:varchar(20);not null;default:'scheduled';index" json:"status"`
+	CreatedBy   string     `gorm:"type:uuid;not null" json:"created_by"`
+	AppliedAt   *time.Time `gorm:"type:timestamp" json:"applied_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (s *ProductPriceSchedule) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (ProductPriceSchedule) TableName() string {
+	return "product_price_schedules"
+}
+
+// ProductPriceHistory records every change of a product's price
+type ProductPriceHistory struct {
+	ID         string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ProductID  string    `gorm:"type:uuid;not null;index" json:"product_id"`
+	OldPrice   int       `gorm:"not null" json:"old_price"`
+	NewPrice   int       `gorm:"not null" json:"new_price"`
+	Source     string    `gorm:"type:varchar(20);not null" json:"source"` // manual, scheduled
+	ScheduleID *string   `gorm:"type:uuid" json:"schedule_id,omitempty"`
+	ChangedAt  time.Time `gorm:"type:timestamp;not null;index" json:"changed_at"`
+}
+
+func (h *ProductPriceHistory) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == "" {
+		h.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (ProductPriceHistory) TableName() string {
+	return "product_price_history"
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProductVariant is one option a product is sold in, e.g. size L in red. A product with variants
+// is bought as one of them: each has its own SKU and stock and may override the product's price.
+// The product's stock is kept as the sum of its variants' stock.
+type ProductVariant struct {
+	ID        string         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ProductID string         `gorm:"type:uuid;not null;index" json:"product_id"`
+	SKU       string         `gorm:"type:varchar(100);uniqueIndex;not null" json:"sku"`
+	Name      string         `gorm:"type:varchar(255);not null" json:"name"` // e.g. "Merah / L"; built from the attributes when not given
+	Size      *string        `gorm:"type:varchar(50)" json:"size,omitempty"`
+	Color     *string        `gorm:"type:varchar(50)" json:"color,omitempty"`
+	Price     *int           `gorm:"type:int" json:"price,omitempty"` // Overrides the product's price when set
+	Stock     int            `gorm:"default:0" json:"stock"`
+	IsActive  bool           `gorm:"default:true" json:"is_active"`
+	SortOrder int            `gorm:"default:0" json:"sort_order"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (v *ProductVariant) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == "" {
+		v.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (ProductVariant) TableName() string {
+	return "product_variants"
+}
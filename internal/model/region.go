@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Region is a city (kota or kabupaten) of a province with the postal codes it uses, as reference
+// data addresses are validated against. A city with several postal code ranges has one row per range.
+type Region struct {
+	ID             string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Province       string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_regions_province_city_postal" json:"province"`
+	City           string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_regions_province_city_postal" json:"city"`
+	PostalCodeFrom string    `gorm:"type:varchar(5);not null;uniqueIndex:idx_regions_province_city_postal" json:"postal_code_from"`
+	PostalCodeTo   string    `gorm:"type:varchar(5);not null" json:"postal_code_to"` // Inclusive
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (r *Region) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (Region) TableName() string {
+	return "regions"
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Return request statuses. A return moves requested -> approved -> item_received -> refunded;
+// the seller may reject it while it is requested.
+const (
+	ReturnStatusRequested    = "requested"
+	ReturnStatusApproved     = "approved"
+	ReturnStatusRejected     = "rejected"
+	ReturnStatusItemReceived = "item_received"
+	ReturnStatusRefunded     = "refunded"
+)
+
+// Refund methods recorded on a refunded return
+const (
+	RefundMethodMidtrans = "midtrans" // Refunded through the Midtrans refund API
+	RefundMethodManual   = "manual"   // Manual transfer payments; the marketplace pays the buyer back by hand
+)
+
+// ReturnRequest is a buyer asking to send back a delivered sub-order. Each sub-order can be
+// returned at most once.
+type ReturnRequest struct {
+	ID            string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderID       string     `gorm:"type:uuid;not null;index" json:"order_id"`
+	SellerOrderID string     `gorm:"type:uuid;not null;uniqueIndex" json:"seller_order_id"`
+	UserID        string     `gorm:"type:uuid;not null;index" json:"user_id"` // Buyer
+	SellerID      string     `gorm:"type:uuid;not null;index" json:"seller_id"`
+	Reason        string     `gorm:"type:text;not null" json:"reason"`
+	Status        string     `gorm:"type:varchar(20);not null;default:'requested';index" json:"status"`
+	RefundAmount  int        `gorm:"not null" json:"refund_amount"`          // Sub-order subtotal; shipping is not refunded
+	SellerNote    *string    `gorm:"type:text" json:"seller_note,omitempty"` // Rejection reason or approval instructions
+	RefundMethod  *string    `gorm:"type:varchar(20)" json:"refund_method,omitempty"`
+	RefundKey     *string    `gorm:"type:varchar(100);uniqueIndex" json:"refund_key,omitempty"` // Idempotency key sent to Midtrans
+	ApprovedAt    *time.Time `gorm:"type:timestamp" json:"approved_at,omitempty"`
+	RejectedAt    *time.Time `gorm:"type:timestamp" json:"rejected_at,omitempty"`
+	ReceivedAt    *time.Time `gorm:"type:timestamp" json:"received_at,omitempty"`
+	RefundedAt    *time.Time `gorm:"type:timestamp" json:"refunded_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Photos      []ReturnRequestPhoto `gorm:"foreignKey:ReturnRequestID" json:"photos,omitempty"`
+	SellerOrder SellerOrder          `gorm:"foreignKey:SellerOrderID" json:"seller_order,omitempty"`
+}
+
+func (rr *ReturnRequest) BeforeCreate(tx *gorm.DB) error {
+	if rr.ID == "" {
+		rr.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (ReturnRequest) TableName() string {
+	return "return_requests"
+}
+
+// ReturnRequestPhoto is a picture of the item uploaded by the buyer as evidence
+type ReturnRequestPhoto struct {
+	ID              string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ReturnRequestID string    `gorm:"type:uuid;not null;index" json:"return_request_id"`
+	ImageURL        string    `gorm:"type:text;not null" json:"image_url"`
+	SortOrder       int       `gorm:"default:0" json:"sort_order"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (p *ReturnRequestPhoto) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (ReturnRequestPhoto) TableName() string {
+	return "return_request_photos"
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Sandbox data belongs to one sandbox partner API key and lives in its own tables, so partner
+// developers can integrate against realistic responses without touching real products, orders or
+// buyers. Nothing here is ever charged, shipped or sent.
+
+// SandboxProduct is a fake catalog entry seeded for a sandbox key
+type SandboxProduct struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	APIKeyID  string    `gorm:"type:uuid;not null;uniqueIndex:idx_sandbox_products_key_sku" json:"api_key_id"`
+	SKU       string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_sandbox_products_key_sku" json:"sku"`
+	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
+	Price     int       `gorm:"not null" json:"price"`
+	Stock     int       `gorm:"not null;default:0" json:"stock"`
+	IsActive  bool      `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (p *SandboxProduct) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (SandboxProduct) TableName() string {
+	return "sandbox_products"
+}
+
+// SandboxStockAdjustment mirrors StockAdjustment for sandbox products
+type SandboxStockAdjustment struct {
+	ID            string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	APIKeyID      string    `gorm:"type:uuid;not null;uniqueIndex:idx_sandbox_adjustments_key_reference" json:"api_key_id"`
+	Reference     string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_sandbox_adjustments_key_reference" json:"reference"`
+	ProductID     string    `gorm:"type:uuid;not null" json:"product_id"`
+	SKU           string    `gorm:"type:varchar(100);not null" json:"sku"`
+	Delta         int       `gorm:"not null" json:"delta"`
+	PreviousStock int       `gorm:"not null" json:"previous_stock"`
+	NewStock      int       `gorm:"not null" json:"new_stock"`
+	Reason        *string   `gorm:"type:text" json:"reason,omitempty"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (a *SandboxStockAdjustment) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (SandboxStockAdjustment) TableName() string {
+	return "sandbox_stock_adjustments"
+}
+
+// Sandbox order statuses; payments are simulated with SandboxPayment* outcomes
+const (
+	SandboxOrderPendingPayment = "pending_payment"
+	SandboxOrderPaid           = "paid"
+	SandboxOrderCancelled      = "cancelled"
+
+	SandboxPaymentPending = "pending"
+	SandboxPaymentSuccess = "success"
+	SandboxPaymentFailed  = "failed"
+	SandboxPaymentExpired = "expired"
+)
+
+// SandboxOrder is a fake order placed against sandbox products
+type SandboxOrder struct {
+	ID            string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	APIKeyID      string     `gorm:"type:uuid;not null;index" json:"api_key_id"`
+	OrderNumber   string     `gorm:"type:varchar(50);uniqueIndex;not null" json:"order_number"`
+	Status        string     `gorm:"type:varchar(30);not null;default:'pending_payment'" json:"status"`
+	PaymentMethod string     `gorm:"type:varchar(30);not null" json:"payment_method"`
+	PaymentStatus string     `gorm:"type:varchar(30);not null;default:'pending'" json:"payment_status"`
+	VANumber      *string    `gorm:"type:varchar(50)" json:"va_number,omitempty"` // Fake virtual account for bank_transfer
+	TotalAmount   int        `gorm:"not null" json:"total_amount"`
+	ExpiryTime    time.Time  `gorm:"not null" json:"expiry_time"`
+	PaidAt        *time.Time `gorm:"type:timestamp" json:"paid_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Items []SandboxOrderItem `gorm:"foreignKey:SandboxOrderID" json:"items,omitempty"`
+}
+
+func (o *SandboxOrder) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == "" {
+		o.ID = uuid.New().String()
+	}
+	if o.OrderNumber == "" {
+		now := time.Now()
+		o.OrderNumber = "SBX-" + now.Format("20060102") + "-" + now.Format("150405") + "-" + uuid.New().String()[:4]
+	}
+	return nil
+}
+
+func (SandboxOrder) TableName() string {
+	return "sandbox_orders"
+}
+
+type SandboxOrderItem struct {
+	ID             string `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SandboxOrderID string `gorm:"type:uuid;not null;index" json:"sandbox_order_id"`
+	ProductID      string `gorm:"type:uuid;not null" json:"product_id"`
+	SKU            string `gorm:"type:varchar(100);not null" json:"sku"`
+	Name           string `gorm:"type:varchar(255);not null" json:"name"`
+	Quantity       int    `gorm:"not null" json:"quantity"`
+	Price          int    `gorm:"not null" json:"price"`
+}
+
+func (i *SandboxOrderItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (SandboxOrderItem) TableName() string {
+	return "sandbox_order_items"
+}
+
+// SandboxNotification is a notification that would have been sent for a sandbox event (e.g.
+// "order.created", "payment.success"), recorded for the partner to inspect instead
+type SandboxNotification struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	APIKeyID  string    `gorm:"type:uuid;not null;index" json:"api_key_id"`
+	Event     string    `gorm:"type:varchar(50);not null" json:"event"`
+	OrderID   *string   `gorm:"type:uuid" json:"order_id,omitempty"`
+	Channel   string    `gorm:"type:varchar(20);not null" json:"channel"` // email, push
+	Message   string    `gorm:"type:text;not null" json:"message"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+func (n *SandboxNotification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == "" {
+		n.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (SandboxNotification) TableName() string {
+	return "sandbox_notifications"
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SavedCard is a Midtrans saved_token_id stored for one-click credit card checkout.
+// Only stored when the user explicitly consents to saving the card.
+type SavedCard struct {
+	ID           string         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID       string         `gorm:"type:uuid;not null;index" json:"user_id"`
+	SavedTokenID string         `gorm:"type:varchar(255);not null" json:"-"`          // Midtrans saved_token_id (never exposed)
+	MaskedCard   string         `gorm:"type:varchar(50);not null" json:"masked_card"` // e.g. 481111-1114
+	Bank         *string        `gorm:"type:varchar(50)" json:"bank,omitempty"`
+	CardType     *string        `gorm:"type:varchar(50)" json:"card_type,omitempty"` // credit, debit
+	ExpiresAt    *time.Time     `gorm:"type:timestamp" json:"expires_at,omitempty"`  // saved_token_id_expired_at
+	ConsentGiven bool           `gorm:"default:false" json:"consent_given"`
+	ConsentAt    *time.Time     `gorm:"type:timestamp" json:"consent_at,omitempty"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (sc *SavedCard) BeforeCreate(tx *gorm.DB) error {
+	if sc.ID == "" {
+		sc.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (SavedCard) TableName() string {
+	return "saved_cards"
+}
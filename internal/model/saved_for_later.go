@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SavedForLaterItem is a product the buyer moved out of the cart to buy another time. It keeps the
+// quantity so moving it back restores the cart line.
+type SavedForLaterItem struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    string    `gorm:"type:uuid;not null;uniqueIndex:idx_saved_for_later_user_product" json:"user_id"`
+	ProductID string    `gorm:"type:uuid;not null;uniqueIndex:idx_saved_for_later_user_product;index" json:"product_id"`
+	Quantity  int       `gorm:"not null;default:1" json:"quantity"`
+	Price     int       `gorm:"not null" json:"price"` // Price when it was in the cart
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Product Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+func (s *SavedForLaterItem) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (SavedForLaterItem) TableName() string {
+	return "saved_for_later_items"
+}
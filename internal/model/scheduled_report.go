@@ -0,0 +1,84 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Scheduled report output formats
+const (
+	ReportFormatCSV  = "csv"
+	ReportFormatJSON = "json"
+)
+
+// Scheduled report delivery channels
+const (
+	ReportChannelEmail   = "email"
+	ReportChannelWebhook = "webhook"
+)
+
+// Scheduled report run outcomes
+const (
+	ReportRunSucceeded = "succeeded"
+	ReportRunFailed    = "failed"
+)
+
+// ScheduledReport runs one of the built-in reports on a cron schedule and sends the result to its
+// recipients by email, or to a webhook
+type ScheduledReport struct {
+	ID            string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name          string     `gorm:"type:varchar(100);not null" json:"name"`
+	Report        string     `gorm:"type:varchar(50);not null" json:"report"`    // Built-in report key, e.g. daily_sales
+	Format        string     `gorm:"type:varchar(10);not null" json:"format"`    // csv, json
+	Schedule      string     `gorm:"type:varchar(100);not null" json:"schedule"` // Cron expression in the business time zone
+	Channel       string     `gorm:"type:varchar(20);not null" json:"channel"`   // email, webhook
+	Recipients    string     `gorm:"type:text" json:"recipients,omitempty"`      // Comma-separated emails for the email channel
+	WebhookURL    *string    `gorm:"type:text" json:"webhook_url,omitempty"`
+	WebhookSecret string     `gorm:"type:varchar(100)" json:"-"` // Signs webhook deliveries; only shown when set
+	IsActive      bool       `gorm:"default:true;index:idx_scheduled_reports_due" json:"is_active"`
+	NextRunAt     time.Time  `gorm:"type:timestamp;not null;index:idx_scheduled_reports_due" json:"next_run_at"`
+	LastRunAt     *time.Time `gorm:"type:timestamp" json:"last_run_at,omitempty"`
+	LastStatus    *string    `gorm:"type:varchar(20)" json:"last_status,omitempty"`
+	LastSuccessAt *time.Time `gorm:"type:timestamp" json:"last_success_at,omitempty"` // End of the period the last successful run covered
+	CreatedBy     string     `gorm:"type:uuid;not null" json:"created_by"`            // Admin
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (r *ScheduledReport) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (ScheduledReport) TableName() string {
+	return "scheduled_reports"
+}
+
+// ScheduledReportRun is one run of a scheduled report, kept as its delivery history
+type ScheduledReportRun struct {
+	ID          string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ReportID    string    `gorm:"type:uuid;not null;index:idx_scheduled_report_runs_report" json:"report_id"`
+	PeriodFrom  time.Time `gorm:"type:timestamp;not null" json:"period_from"` // Time range the report covered
+	PeriodTo    time.Time `gorm:"type:timestamp;not null" json:"period_to"`
+	Rows        int       `gorm:"default:0" json:"rows"`
+	Status      string    `gorm:"type:varchar(20);not null" json:"status"` // succeeded, failed
+	Error       *string   `gorm:"type:text" json:"error,omitempty"`
+	Manual      bool      `gorm:"default:false" json:"manual"` // Started by an admin rather than the schedule
+	StartedAt   time.Time `gorm:"type:timestamp;not null;index:idx_scheduled_report_runs_report" json:"started_at"`
+	CompletedAt time.Time `gorm:"type:timestamp;not null" json:"completed_at"`
+}
+
+func (r *ScheduledReportRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (ScheduledReportRun) TableName() string {
+	return "scheduled_report_runs"
+}
@@ -27,13 +27,34 @@ type Seller struct {
 	TotalSales      int            `gorm:"default:0" json:"total_sales"`
 	RatingAverage   float64        `gorm:"type:decimal(3,2);default:0.00" json:"rating_average"`
 	TotalReviews    int            `gorm:"default:0" json:"total_reviews"`
+	HandlingDays    int            `gorm:"default:1" json:"handling_days"`    // Business days the shop needs before handing orders to the courier
+	ProductLimit    *int           `json:"product_limit,omitempty"`           // Admin override of the tier's listing limit; 0 means unlimited
+	MinOrderAmount  int            `gorm:"default:0" json:"min_order_amount"` // Smallest subtotal the shop accepts per order; 0 means none
 	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Tax registration: only PKP (Pengusaha Kena Pajak) shops charge PPN
+	IsPKP bool    `gorm:"column:is_pkp;default:false" json:"is_pkp"`
+	NPWP  *string `gorm:"type:varchar(16)" json:"npwp,omitempty"` // Tax ID, digits only (15 or 16)
+
 	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
 }
 
+// Seller tiers decide how many products a shop may list at once
+const (
+	SellerTierUnverified = "unverified"
+	SellerTierVerified   = "verified"
+)
+
+// Tier returns the seller's listing tier
+func (s *Seller) Tier() string {
+	if s.IsVerified {
+		return SellerTierVerified
+	}
+	return SellerTierUnverified
+}
+
 func (s *Seller) BeforeCreate(tx *gorm.DB) error {
 	if s.ID == "" {
 		s.ID = uuid.New().String()
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SellerOrder is the part of a checkout (Order) fulfilled by one seller. The buyer pays for the
+// parent order; each seller only sees and fulfills their own sub-order and its items.
+type SellerOrder struct {
+	ID                string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderID           string     `gorm:"type:uuid;not null;index" json:"order_id"`
+	SellerID          string     `gorm:"type:uuid;not null;index" json:"seller_id"`
+	UserID            string     `gorm:"type:uuid;not null;index" json:"user_id"` // Buyer
+	ShippingAddressID string     `gorm:"type:uuid;not null" json:"shipping_address_id"`
+	SubOrderNumber    string     `gorm:"type:varchar(60);uniqueIndex;not null" json:"sub_order_number"`
+	Subtotal          int        `gorm:"not null" json:"subtotal"`
+	ShippingCost      int        `gorm:"default:0" json:"shipping_cost"`
+	TotalAmount       int        `gorm:"not null" json:"total_amount"`                                    // Subtotal + shipping (+ tax when prices exclude it); marketplace fees stay on the parent order
+	TaxAmount         int        `gorm:"default:0" json:"tax_amount"`                                     // PPN on the subtotal, included in or added to it
+	Status            string     `gorm:"type:varchar(50);not null;default:'pending';index" json:"status"` // pending, paid, processing, shipped, delivered, cancelled
+	Courier           *string    `gorm:"type:varchar(50)" json:"courier,omitempty"`                       // e.g. jne, jnt, sicepat
+	TrackingNumber    *string    `gorm:"type:varchar(100);index" json:"tracking_number,omitempty"`
+	ShippedAt         *time.Time `gorm:"type:timestamp" json:"shipped_at,omitempty"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Set when the seller's third-party fulfillment (3PL) warehouse takes the sub-order over
+	FulfillmentReference      *string    `gorm:"type:varchar(100)" json:"fulfillment_reference,omitempty"` // The warehouse's own ID for it
+	FulfillmentAcknowledgedAt *time.Time `gorm:"type:timestamp;index" json:"fulfillment_acknowledged_at,omitempty"`
+
+	Seller          Seller      `gorm:"foreignKey:SellerID" json:"seller,omitempty"`
+	ShippingAddress Address     `gorm:"foreignKey:ShippingAddressID" json:"shipping_address,omitempty"`
+	OrderItems      []OrderItem `gorm:"foreignKey:SellerOrderID" json:"order_items,omitempty"`
+}
+
+func (so *SellerOrder) BeforeCreate(tx *gorm.DB) error {
+	if so.ID == "" {
+		so.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (SellerOrder) TableName() string {
+	return "seller_orders"
+}
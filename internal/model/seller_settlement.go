@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SellerSettlement is what the marketplace owes a seller for one delivered sub-order. It is created
+// once the order is delivered and stays pending until the payout is made. Settlements on hold wait
+// for a dispute to be resolved first.
+type SellerSettlement struct {
+	ID            string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SellerID      string     `gorm:"type:uuid;not null;index" json:"seller_id"`
+	OrderID       string     `gorm:"type:uuid;not null;index" json:"order_id"`
+	SellerOrderID string     `gorm:"type:uuid;not null;uniqueIndex" json:"seller_order_id"`
+	Amount        int        `gorm:"not null" json:"amount"`                                          // Sub-order subtotal + shipping
+	Status        string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"` // pending, paid
+	OnHold        bool       `gorm:"default:false;index" json:"on_hold"`                              // An open dispute on the sub-order; must not be paid out
+	PaidAt        *time.Time `gorm:"type:timestamp" json:"paid_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	SellerOrder SellerOrder `gorm:"foreignKey:SellerOrderID" json:"seller_order,omitempty"`
+}
+
+func (s *SellerSettlement) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (SellerSettlement) TableName() string {
+	return "seller_settlements"
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Seller webhook events
+const (
+	WebhookEventProductLowStock   = "product.low_stock"    // Available stock dropped to the low stock threshold or below
+	WebhookEventProductOutOfStock = "product.out_of_stock" // Available stock reached zero
+	WebhookEventTest              = "webhook.test"         // Sent on request to check an endpoint
+)
+
+// SellerWebhookEvents are the events a seller can subscribe an endpoint to
+var SellerWebhookEvents = []string{WebhookEventProductLowStock, WebhookEventProductOutOfStock}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending" // Waiting for its first or next attempt
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed" // Gave up after the last attempt
+)
+
+// SellerWebhook is an HTTPS endpoint of a seller's own system (ERP, POS, ...) that receives
+// product events as signed JSON POSTs
+type SellerWebhook struct {
+	ID                  string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SellerID            string     `gorm:"type:uuid;not null;index" json:"seller_id"`
+	URL                 string     `gorm:"type:text;not null" json:"url"`
+	Secret              string     `gorm:"type:varchar(100);not null" json:"-"`      // Signs every delivery; only shown when the webhook is created
+	Events              string     `gorm:"type:varchar(255);not null" json:"events"` // Comma-separated list of subscribed events
+	IsActive            bool       `gorm:"default:true" json:"is_active"`
+	ConsecutiveFailures int        `gorm:"default:0" json:"consecutive_failures"` // Deliveries given up on since the last success
+	LastSuccessAt       *time.Time `gorm:"type:timestamp" json:"last_success_at,omitempty"`
+	LastFailureAt       *time.Time `gorm:"type:timestamp" json:"last_failure_at,omitempty"`
+	CreatedAt           time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (w *SellerWebhook) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (SellerWebhook) TableName() string {
+	return "seller_webhooks"
+}
+
+// SellerWebhookDelivery is one event sent, or to be sent, to a seller webhook. Failed attempts are
+// retried with backoff until the delivery succeeds or runs out of attempts.
+type SellerWebhookDelivery struct {
+	ID             string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	WebhookID      string     `gorm:"type:uuid;not null;index" json:"webhook_id"`
+	SellerID       string     `gorm:"type:uuid;not null;index" json:"seller_id"`
+	Event          string     `gorm:"type:varchar(50);not null" json:"event"`
+	Payload        string     `gorm:"type:text;not null" json:"payload"` // JSON body as sent
+	Status         string     `gorm:"type:varchar(20);not null;default:'pending';index:idx_webhook_deliveries_due" json:"status"`
+	Attempts       int        `gorm:"default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"type:timestamp;not null;index:idx_webhook_deliveries_due" json:"next_attempt_at"`
+	ResponseStatus *int       `json:"response_status,omitempty"` // HTTP status of the last attempt
+	LastError      *string    `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `gorm:"type:timestamp" json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Webhook *SellerWebhook `gorm:"foreignKey:WebhookID" json:"-"`
+}
+
+func (d *SellerWebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (SellerWebhookDelivery) TableName() string {
+	return "seller_webhook_deliveries"
+}
+
+// Product stock levels tracked for stock events
+const (
+	ProductStockInStock    = "in_stock"
+	ProductStockLow        = "low_stock"
+	ProductStockOutOfStock = "out_of_stock"
+)
+
+// ProductStockState is the stock level a product was last seen at, so an event is raised once
+// when the level changes rather than on every stock update
+type ProductStockState struct {
+	ProductID string    `gorm:"type:uuid;primary_key" json:"product_id"`
+	State     string    `gorm:"type:varchar(20);not null" json:"state"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (ProductStockState) TableName() string {
+	return "product_stock_states"
+}
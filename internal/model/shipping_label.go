@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Shipping label statuses; a label is pending while the courier booking is in flight
+const (
+	ShippingLabelStatusPending = "pending"
+	ShippingLabelStatusBooked  = "booked"
+)
+
+// ShippingLabel is a courier booking for a sub-order. The waybill printed on the label is the
+// tracking number the seller ships with.
+type ShippingLabel struct {
+	ID            string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SellerOrderID string    `gorm:"type:uuid;uniqueIndex;not null" json:"seller_order_id"` // One booking per sub-order
+	SellerID      string    `gorm:"type:uuid;not null;index" json:"seller_id"`
+	Courier       string    `gorm:"type:varchar(50);not null" json:"courier"` // e.g. jne, jnt, sicepat
+	Service       string    `gorm:"type:varchar(30);not null" json:"service"` // Courier service level, e.g. regular
+	Status        string    `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Waybill       *string   `gorm:"type:varchar(100)" json:"waybill,omitempty"`    // AWB assigned by the courier
+	BookingID     *string   `gorm:"type:varchar(100)" json:"booking_id,omitempty"` // The courier integration's order ID
+	ShippingFee   int       `gorm:"default:0" json:"shipping_fee"`                 // What the courier charges the marketplace
+	WeightGrams   int       `gorm:"not null" json:"weight_grams"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (l *ShippingLabel) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (ShippingLabel) TableName() string {
+	return "shipping_labels"
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Stock take (stock opname) statuses
+const (
+	StockTakeStatusOpen      = "open"
+	StockTakeStatusApplied   = "applied"
+	StockTakeStatusCancelled = "cancelled"
+)
+
+// StockTake is a seller's physical count of their inventory. Counts are collected while the take
+// is open and applied to product stock in one go.
+type StockTake struct {
+	ID        string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SellerID  string     `gorm:"type:uuid;not null;index" json:"seller_id"`
+	StartedBy string     `gorm:"type:uuid;not null" json:"started_by"` // User who opened the take
+	Status    string     `gorm:"type:varchar(20);not null;default:'open';index" json:"status"`
+	Note      *string    `gorm:"type:text" json:"note,omitempty"`
+	AppliedAt *time.Time `gorm:"type:timestamp" json:"applied_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Counts []StockTakeCount `gorm:"foreignKey:StockTakeID" json:"counts,omitempty"`
+}
+
+func (st *StockTake) BeforeCreate(tx *gorm.DB) error {
+	if st.ID == "" {
+		st.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (StockTake) TableName() string {
+	return "stock_takes"
+}
+
+// StockTakeCount is the counted quantity of one product. SystemStock is the product's stock when
+// the count was submitted, so sales made while counting are not mistaken for shrinkage.
+type StockTakeCount struct {
+	ID              string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	StockTakeID     string    `gorm:"type:uuid;not null;uniqueIndex:idx_stock_take_counts_product" json:"stock_take_id"`
+	ProductID       string    `gorm:"type:uuid;not null;uniqueIndex:idx_stock_take_counts_product" json:"product_id"`
+	SKU             string    `gorm:"type:varchar(100);not null" json:"sku"`
+	ProductName     string    `gorm:"type:varchar(255);not null" json:"product_name"`
+	CountedQuantity int       `gorm:"not null" json:"counted_quantity"`
+	SystemStock     int       `gorm:"not null" json:"system_stock"`
+	CountedAt       time.Time `gorm:"not null" json:"counted_at"`
+}
+
+func (c *StockTakeCount) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (StockTakeCount) TableName() string {
+	return "stock_take_counts"
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Support ticket statuses. A buyer opens a ticket about an order; a support agent's reply moves it
+// to awaiting_customer and the buyer's reply moves it back to open. An agent resolves it, and the
+// buyer replying to a resolved ticket reopens it. Closed tickets are final.
+const (
+	SupportTicketStatusOpen             = "open"
+	SupportTicketStatusAwaitingCustomer = "awaiting_customer"
+	SupportTicketStatusResolved         = "resolved"
+	SupportTicketStatusClosed           = "closed"
+)
+
+// Support ticket categories a buyer picks when opening a ticket
+var SupportTicketCategories = []string{"payment", "shipping", "product", "refund", "account", "other"}
+
+// Support ticket message sender roles
+const (
+	SupportRoleBuyer = "buyer"
+	SupportRoleAgent = "agent" // An admin answering for the marketplace
+)
+
+// SupportTicket is a buyer's question or problem about one of their orders, handled by the
+// marketplace's support agents (admins). The SLA deadlines are set when the ticket is opened; a
+// breach is recorded once and stays on the ticket after it is answered or resolved.
+type SupportTicket struct {
+	ID                    string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TicketNumber          string     `gorm:"type:varchar(50);uniqueIndex;not null" json:"ticket_number"`
+	UserID                string     `gorm:"type:uuid;not null;index" json:"user_id"` // Buyer
+	OrderID               string     `gorm:"type:uuid;not null;index" json:"order_id"`
+	Subject               string     `gorm:"type:varchar(200);not null" json:"subject"`
+	Category              string     `gorm:"type:varchar(20);not null" json:"category"`
+	Status                string     `gorm:"type:varchar(20);not null;default:'open';index" json:"status"`
+	AssigneeID            *string    `gorm:"type:uuid;index" json:"assignee_id,omitempty"` // Admin handling the ticket
+	AssignedAt            *time.Time `gorm:"type:timestamp" json:"assigned_at,omitempty"`
+	FirstResponseDueAt    time.Time  `gorm:"type:timestamp;not null" json:"first_response_due_at"`
+	ResolutionDueAt       time.Time  `gorm:"type:timestamp;not null" json:"resolution_due_at"`
+	FirstRespondedAt      *time.Time `gorm:"type:timestamp" json:"first_responded_at,omitempty"`
+	FirstResponseBreached bool       `gorm:"default:false" json:"first_response_breached"`
+	ResolutionBreached    bool       `gorm:"default:false" json:"resolution_breached"`
+	LastMessageAt         time.Time  `gorm:"type:timestamp;not null" json:"last_message_at"`
+	ResolvedAt            *time.Time `gorm:"type:timestamp" json:"resolved_at,omitempty"`
+	ClosedAt              *time.Time `gorm:"type:timestamp" json:"closed_at,omitempty"`
+	CreatedAt             time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt             time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Messages []SupportTicketMessage `gorm:"foreignKey:TicketID" json:"messages,omitempty"`
+	Order    *Order                 `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	User     *User                  `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Assignee *User                  `gorm:"foreignKey:AssigneeID" json:"assignee,omitempty"`
+}
+
+func (t *SupportTicket) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	if t.TicketNumber == "" {
+		t.TicketNumber = generateTicketNumber()
+	}
+	return nil
+}
+
+func (SupportTicket) TableName() string {
+	return "support_tickets"
+}
+
+// generateTicketNumber generates a unique ticket number
+func generateTicketNumber() string {
+	// Format: TKT-YYYYMMDD-XXXXXX
+	return "TKT-" + time.Now().Format("20060102") + "-" + uuid.New().String()[:6]
+}
+
+// SupportTicketMessage is one entry in a ticket's thread, written by the buyer or an agent
+type SupportTicketMessage struct {
+	ID         string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TicketID   string    `gorm:"type:uuid;not null;index" json:"ticket_id"`
+	SenderID   string    `gorm:"type:uuid;not null" json:"sender_id"`
+	SenderRole string    `gorm:"type:varchar(10);not null" json:"sender_role"` // buyer, agent
+	Message    string    `gorm:"type:text;not null" json:"message"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Attachments []SupportTicketAttachment `gorm:"foreignKey:MessageID" json:"attachments,omitempty"`
+}
+
+func (m *SupportTicketMessage) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (SupportTicketMessage) TableName() string {
+	return "support_ticket_messages"
+}
+
+// SupportTicketAttachment is an image (e.g. a screenshot) uploaded with a ticket message
+type SupportTicketAttachment struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TicketID  string    `gorm:"type:uuid;not null;index" json:"ticket_id"`
+	MessageID string    `gorm:"type:uuid;not null;index" json:"message_id"`
+	FileName  string    `gorm:"type:varchar(255);not null" json:"file_name"`
+	URL       string    `gorm:"type:text;not null" json:"url"`
+	SortOrder int       `gorm:"default:0" json:"sort_order"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (a *SupportTicketAttachment) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (SupportTicketAttachment) TableName() string {
+	return "support_ticket_attachments"
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Warranty claim statuses. The buyer submits a claim and the seller reviews it, then approves it
+// (repair or replacement follows) or rejects it. An approved claim is completed once handled.
+const (
+	WarrantyClaimSubmitted = "submitted"
+	WarrantyClaimInReview  = "in_review"
+	WarrantyClaimApproved  = "approved"
+	WarrantyClaimRejected  = "rejected"
+	WarrantyClaimCompleted = "completed"
+)
+
+// WarrantyClaimOpenStatuses are the statuses that keep another claim from being filed under the
+// same warranty
+var WarrantyClaimOpenStatuses = []string{WarrantyClaimSubmitted, WarrantyClaimInReview, WarrantyClaimApproved}
+
+// Warranty covers one order item the buyer paid warranty protection for. It is activated when
+// the order is delivered and runs for the product's warranty terms from then.
+type Warranty struct {
+	ID          string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderID     string    `gorm:"type:uuid;not null;index" json:"order_id"`
+	OrderItemID string    `gorm:"type:uuid;not null;uniqueIndex" json:"order_item_id"`
+	UserID      string    `gorm:"type:uuid;not null;index" json:"user_id"` // Buyer
+	SellerID    string    `gorm:"type:uuid;not null;index" json:"seller_id"`
+	ProductID   string    `gorm:"type:uuid;not null" json:"product_id"`
+	ProductName string    `gorm:"type:varchar(255);not null" json:"product_name"`
+	VariantName *string   `gorm:"type:varchar(255)" json:"variant_name,omitempty"`
+	Quantity    int       `gorm:"not null" json:"quantity"`
+	Months      int       `gorm:"not null" json:"months"`
+	Terms       *string   `gorm:"type:text" json:"terms,omitempty"` // The product's terms when it was delivered
+	StartsAt    time.Time `gorm:"type:timestamp;not null" json:"starts_at"`
+	ExpiresAt   time.Time `gorm:"type:timestamp;not null;index" json:"expires_at"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Claims []WarrantyClaim `gorm:"foreignKey:WarrantyID" json:"claims,omitempty"`
+}
+
+func (w *Warranty) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (Warranty) TableName() string {
+	return "warranties"
+}
+
+// IsActive reports whether claims can still be filed under the warranty at the given time
+func (w *Warranty) IsActive(at time.Time) bool {
+	return !at.Before(w.StartsAt) && at.Before(w.ExpiresAt)
+}
+
+// WarrantyClaim is a buyer's request to have a faulty item repaired or replaced under its warranty
+type WarrantyClaim struct {
+	ID          string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	WarrantyID  string     `gorm:"type:uuid;not null;index" json:"warranty_id"`
+	UserID      string     `gorm:"type:uuid;not null;index" json:"user_id"`
+	SellerID    string     `gorm:"type:uuid;not null;index" json:"seller_id"`
+	Description string     `gorm:"type:text;not null" json:"description"` // What is wrong with the item
+	Status      string     `gorm:"type:varchar(20);not null;default:'submitted';index" json:"status"`
+	Resolution  *string    `gorm:"type:text" json:"resolution,omitempty"` // Latest note from the seller
+	ResolvedAt  *time.Time `gorm:"type:timestamp" json:"resolved_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Warranty *Warranty            `gorm:"foreignKey:WarrantyID" json:"warranty,omitempty"`
+	Events   []WarrantyClaimEvent `gorm:"foreignKey:ClaimID" json:"events,omitempty"`
+}
+
+func (c *WarrantyClaim) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (WarrantyClaim) TableName() string {
+	return "warranty_claims"
+}
+
+// WarrantyClaimEvent records a claim entering a status, so buyers can follow its progress
+type WarrantyClaimEvent struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ClaimID   string    `gorm:"type:uuid;not null;index" json:"claim_id"`
+	Status    string    `gorm:"type:varchar(20);not null" json:"status"`
+	Note      *string   `gorm:"type:text" json:"note,omitempty"`
+	ActorType string    `gorm:"type:varchar(20);not null" json:"actor_type"` // buyer, seller
+	ActorID   string    `gorm:"type:uuid;not null" json:"-"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (e *WarrantyClaimEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (WarrantyClaimEvent) TableName() string {
+	return "warranty_claim_events"
+}
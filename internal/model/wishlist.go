@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WishlistItem is a product the buyer wants to keep an eye on. Unlike a saved-for-later item it
+// has no quantity; it is a product, not a cart line.
+type WishlistItem struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    string    `gorm:"type:uuid;not null;uniqueIndex:idx_wishlist_user_product" json:"user_id"`
+	ProductID string    `gorm:"type:uuid;not null;uniqueIndex:idx_wishlist_user_product;index" json:"product_id"`
+	Price     int       `gorm:"not null" json:"price"` // Price when it was added
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Product Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+func (w *WishlistItem) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (WishlistItem) TableName() string {
+	return "wishlist_items"
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type AddressValidationRepository interface {
+	CreateRun(ctx context.Context, run *model.AddressValidationRun) error
+	UpdateRun(ctx context.Context, run *model.AddressValidationRun) error
+	FindRunByID(ctx context.Context, id string) (*model.AddressValidationRun, error)
+	FindRunningRun(ctx context.Context) (*model.AddressValidationRun, error)
+	// FailRunningRuns marks runs still running as failed, for runs a restart interrupted
+	FailRunningRuns(ctx context.Context, reason string, finishedAt time.Time) (int64, error)
+
+	// FindAddressesAfter returns up to limit addresses with an ID after afterID, in ID order
+	FindAddressesAfter(ctx context.Context, afterID string, limit int) ([]model.Address, error)
+
+	FindByID(ctx context.Context, id string) (*model.AddressValidation, error)
+	FindByAddressIDs(ctx context.Context, addressIDs []string) ([]model.AddressValidation, error)
+	FindPendingByUserID(ctx context.Context, userID string) ([]model.AddressValidation, error)
+	// List returns validations with the status (all when empty) that have the issue (any when
+	// empty), newest first
+	List(ctx context.Context, status string, issue string, page, limit int) ([]model.AddressValidation, int64, error)
+	Save(ctx context.Context, validation *model.AddressValidation) error
+	// DeletePendingByAddressIDs drops unresolved flags of addresses that are valid now; resolved
+	// ones are kept as history
+	DeletePendingByAddressIDs(ctx context.Context, addressIDs []string) error
+	// ApplySuggestion updates the address with the validation's suggestion and marks the
+	// validation confirmed in one transaction
+	ApplySuggestion(ctx context.Context, validation *model.AddressValidation, address *model.Address) error
+}
+
+type addressValidationRepository struct {
+	db *gorm.DB
+}
+
+func NewAddressValidationRepository(db *gorm.DB) AddressValidationRepository {
+	return &addressValidationRepository{db: db}
+}
+
+func (r *addressValidationRepository) CreateRun(ctx context.Context, run *model.AddressValidationRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+func (r *addressValidationRepository) UpdateRun(ctx context.Context, run *model.AddressValidationRun) error {
+	return r.db.WithContext(ctx).Save(run).Error
+}
+
+func (r *addressValidationRepository) FindRunByID(ctx context.Context, id string) (*model.AddressValidationRun, error) {
+	var run model.AddressValidationRun
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&run).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *addressValidationRepository) FindRunningRun(ctx context.Context) (*model.AddressValidationRun, error) {
+	var run model.AddressValidationRun
+	err := r.db.WithContext(ctx).Where("status = ?", model.AddressValidationRunRunning).Order("started_at DESC").First(&run).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *addressValidationRepository) FailRunningRuns(ctx context.Context, reason string, finishedAt time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&model.AddressValidationRun{}).
+		Where("status = ?", model.AddressValidationRunRunning).
+		Updates(map[string]interface{}{
+			"status":      model.AddressValidationRunFailed,
+			"error":       reason,
+			"finished_at": finishedAt,
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *addressValidationRepository) FindAddressesAfter(ctx context.Context, afterID string, limit int) ([]model.Address, error) {
+	var addresses []model.Address
+	query := r.db.WithContext(ctx).Order("id ASC").Limit(limit)
+	if afterID != "" {
+		query = query.Where("id > ?", afterID)
+	}
+	err := query.Find(&addresses).Error
+	return addresses, err
+}
+
+func (r *addressValidationRepository) FindByID(ctx context.Context, id string) (*model.AddressValidation, error) {
+	var validation model.AddressValidation
+	err := r.db.WithContext(ctx).Preload("Address").Where("id = ?", id).First(&validation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &validation, nil
+}
+
+func (r *addressValidationRepository) FindByAddressIDs(ctx context.Context, addressIDs []string) ([]model.AddressValidation, error) {
+	var validations []model.AddressValidation
+	if len(addressIDs) == 0 {
+		return validations, nil
+	}
+	err := r.db.WithContext(ctx).Where("address_id IN ?", addressIDs).Find(&validations).Error
+	return validations, err
+}
+
+func (r *addressValidationRepository) FindPendingByUserID(ctx context.Context, userID string) ([]model.AddressValidation, error) {
+	var validations []model.AddressValidation
+	err := r.db.WithContext(ctx).Preload("Address").
+		Joins("JOIN addresses ON addresses.id = address_validations.address_id AND addresses.deleted_at IS NULL").
+		Where("address_validations.user_id = ? AND address_validations.status = ?", userID, model.AddressValidationPending).
+		Order("address_validations.created_at DESC").
+		Find(&validations).Error
+	return validations, err
+}
+
+func (r *addressValidationRepository) List(ctx context.Context, status string, issue string, page, limit int) ([]model.AddressValidation, int64, error) {
+	var validations []model.AddressValidation
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.AddressValidation{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if issue != "" {
+		// Issues is a comma-separated list
+		query = query.Where("(',' || issues || ',') LIKE ?", "%,"+issue+",%")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("Address").Order("updated_at DESC").Offset(offset).Limit(limit).Find(&validations).Error
+	return validations, total, err
+}
+
+func (r *addressValidationRepository) Save(ctx context.Context, validation *model.AddressValidation) error {
+	return r.db.WithContext(ctx).Omit("Address").Save(validation).Error
+}
+
+func (r *addressValidationRepository) DeletePendingByAddressIDs(ctx context.Context, addressIDs []string) error {
+	if len(addressIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Where("address_id IN ? AND status = ?", addressIDs, model.AddressValidationPending).
+		Delete(&model.AddressValidation{}).Error
+}
+
+func (r *addressValidationRepository) ApplySuggestion(ctx context.Context, validation *model.AddressValidation, address *model.Address) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Address{}).Where("id = ?", address.ID).Updates(map[string]interface{}{
+			"city":        address.City,
+			"province":    address.Province,
+			"postal_code": address.PostalCode,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Address").Save(validation).Error
+	})
+}
@@ -0,0 +1,342 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNoPayableCommissions is returned when an affiliate has no pending commissions outside a payout
+var ErrNoPayableCommissions = errors.New("no commissions to pay out")
+
+// ErrPayoutBelowMinimum is returned when an affiliate's pending commissions do not reach the
+// minimum payout amount
+var ErrPayoutBelowMinimum = errors.New("pending commissions are below the minimum payout")
+
+// ErrPayoutNotPending is returned when a payout was already paid or cancelled
+var ErrPayoutNotPending = errors.New("payout is not pending")
+
+// AffiliateCommissionTotal sums an affiliate's commissions with one status. InPayout tells pending
+// commissions reserved by a payout apart from the ones still available.
+type AffiliateCommissionTotal struct {
+	Status   string
+	InPayout bool
+	Count    int64
+	Amount   int64
+}
+
+type AffiliateRepository interface {
+	CreateKey(ctx context.Context, key *model.AffiliateAPIKey) error
+	FindKeyByID(ctx context.Context, id string) (*model.AffiliateAPIKey, error)
+	FindActiveKeyByHash(ctx context.Context, keyHash string) (*model.AffiliateAPIKey, error)
+	FindKeysByUserID(ctx context.Context, userID string) ([]model.AffiliateAPIKey, error)
+	UpdateKey(ctx context.Context, key *model.AffiliateAPIKey) error
+	TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error
+	// IncrementUsage counts one request for the key on the date and returns the day's total
+	IncrementUsage(ctx context.Context, keyID string, date time.Time) (int64, error)
+	// FindUsage returns the key's daily counters in [from, to], oldest first; days without
+	// requests have no row
+	FindUsage(ctx context.Context, keyID string, from, to time.Time) ([]model.AffiliateAPIUsage, error)
+	// FindKeyByCode also returns revoked keys, so referrals made before a revocation still pay
+	FindKeyByCode(ctx context.Context, code string) (*model.AffiliateAPIKey, error)
+
+	CreateLink(ctx context.Context, link *model.AffiliateLink) error
+	FindLinkBySlug(ctx context.Context, slug string) (*model.AffiliateLink, error)
+	FindLinksByUserID(ctx context.Context, userID string) ([]model.AffiliateLink, error)
+	IncrementLinkClicks(ctx context.Context, id string) error
+
+	// CreateCommission records the commission unless the order already has one; it reports
+	// whether a row was created
+	CreateCommission(ctx context.Context, commission *model.AffiliateCommission) (bool, error)
+	FindCommissionByID(ctx context.Context, id string) (*model.AffiliateCommission, error)
+	FindCommissionsByUserID(ctx context.Context, userID string, status string, page, limit int) ([]model.AffiliateCommission, int64, error)
+	SumCommissionsByUserID(ctx context.Context, userID string) ([]AffiliateCommissionTotal, error)
+	// VoidCommission voids a pending commission that is not part of a payout; it reports whether
+	// the commission was voided
+	VoidCommission(ctx context.Context, id string, reason string, voidedAt time.Time) (bool, error)
+	VoidCommissionByOrderID(ctx context.Context, orderID string, reason string, voidedAt time.Time) (bool, error)
+
+	// CreatePayout reserves every pending commission of payout.AffiliateUserID that is not in a
+	// payout yet and creates the payout for their total
+	CreatePayout(ctx context.Context, payout *model.AffiliatePayout, minAmount int) error
+	FindPayoutByID(ctx context.Context, id string) (*model.AffiliatePayout, error)
+	// FindPayouts lists payouts, newest first; an empty userID or status matches all
+	FindPayouts(ctx context.Context, userID string, status string, page, limit int) ([]model.AffiliatePayout, int64, error)
+	// MarkPayoutPaid settles a pending payout and every commission it reserved
+	MarkPayoutPaid(ctx context.Context, id string, reference string, paidAt time.Time) error
+	// CancelPayout cancels a pending payout and releases its commissions for the next one
+	CancelPayout(ctx context.Context, id string) error
+}
+
+type affiliateRepository struct {
+	db *gorm.DB
+}
+
+func NewAffiliateRepository(db *gorm.DB) AffiliateRepository {
+	return &affiliateRepository{db: db}
+}
+
+func (r *affiliateRepository) CreateKey(ctx context.Context, key *model.AffiliateAPIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *affiliateRepository) FindKeyByID(ctx context.Context, id string) (*model.AffiliateAPIKey, error) {
+	var key model.AffiliateAPIKey
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *affiliateRepository) FindActiveKeyByHash(ctx context.Context, keyHash string) (*model.AffiliateAPIKey, error) {
+	var key model.AffiliateAPIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ? AND revoked_at IS NULL", keyHash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *affiliateRepository) FindKeysByUserID(ctx context.Context, userID string) ([]model.AffiliateAPIKey, error) {
+	var keys []model.AffiliateAPIKey
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *affiliateRepository) UpdateKey(ctx context.Context, key *model.AffiliateAPIKey) error {
+	return r.db.WithContext(ctx).Save(key).Error
+}
+
+func (r *affiliateRepository) TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.AffiliateAPIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
+
+func (r *affiliateRepository) IncrementUsage(ctx context.Context, keyID string, date time.Time) (int64, error) {
+	usage := model.AffiliateAPIUsage{APIKeyID: keyID, Date: date, Requests: 1}
+	err := r.db.WithContext(ctx).Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "api_key_id"}, {Name: "date"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"requests":   gorm.Expr("affiliate_api_usage.requests + 1"),
+				"updated_at": time.Now(),
+			}),
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "requests"}}},
+	).Create(&usage).Error
+	return usage.Requests, err
+}
+
+func (r *affiliateRepository) FindUsage(ctx context.Context, keyID string, from, to time.Time) ([]model.AffiliateAPIUsage, error) {
+	var usage []model.AffiliateAPIUsage
+	err := r.db.WithContext(ctx).
+		Where("api_key_id = ? AND date BETWEEN ? AND ?", keyID, from, to).
+		Order("date ASC").
+		Find(&usage).Error
+	return usage, err
+}
+
+func (r *affiliateRepository) FindKeyByCode(ctx context.Context, code string) (*model.AffiliateAPIKey, error) {
+	var key model.AffiliateAPIKey
+	err := r.db.WithContext(ctx).Where("affiliate_code = ?", code).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *affiliateRepository) CreateLink(ctx context.Context, link *model.AffiliateLink) error {
+	return r.db.WithContext(ctx).Create(link).Error
+}
+
+func (r *affiliateRepository) FindLinkBySlug(ctx context.Context, slug string) (*model.AffiliateLink, error) {
+	var link model.AffiliateLink
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *affiliateRepository) FindLinksByUserID(ctx context.Context, userID string) ([]model.AffiliateLink, error) {
+	var links []model.AffiliateLink
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&links).Error
+	return links, err
+}
+
+func (r *affiliateRepository) IncrementLinkClicks(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&model.AffiliateLink{}).
+		Where("id = ?", id).
+		UpdateColumn("clicks", gorm.Expr("clicks + 1")).Error
+}
+
+func (r *affiliateRepository) CreateCommission(ctx context.Context, commission *model.AffiliateCommission) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "order_id"}},
+		DoNothing: true,
+	}).Create(commission)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *affiliateRepository) FindCommissionByID(ctx context.Context, id string) (*model.AffiliateCommission, error) {
+	var commission model.AffiliateCommission
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&commission).Error
+	if err != nil {
+		return nil, err
+	}
+	return &commission, nil
+}
+
+func (r *affiliateRepository) FindCommissionsByUserID(ctx context.Context, userID string, status string, page, limit int) ([]model.AffiliateCommission, int64, error) {
+	var commissions []model.AffiliateCommission
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.AffiliateCommission{}).Where("affiliate_user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&commissions).Error
+	return commissions, total, err
+}
+
+func (r *affiliateRepository) SumCommissionsByUserID(ctx context.Context, userID string) ([]AffiliateCommissionTotal, error) {
+	var totals []AffiliateCommissionTotal
+	err := r.db.WithContext(ctx).Model(&model.AffiliateCommission{}).
+		Select("status, payout_id IS NOT NULL AS in_payout, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Where("affiliate_user_id = ?", userID).
+		Group("status, payout_id IS NOT NULL").
+		Scan(&totals).Error
+	return totals, err
+}
+
+func (r *affiliateRepository) VoidCommission(ctx context.Context, id string, reason string, voidedAt time.Time) (bool, error) {
+	return r.voidCommissions(ctx, r.db.WithContext(ctx).Where("id = ?", id), reason, voidedAt)
+}
+
+func (r *affiliateRepository) VoidCommissionByOrderID(ctx context.Context, orderID string, reason string, voidedAt time.Time) (bool, error) {
+	return r.voidCommissions(ctx, r.db.WithContext(ctx).Where("order_id = ?", orderID), reason, voidedAt)
+}
+
+func (r *affiliateRepository) voidCommissions(ctx context.Context, query *gorm.DB, reason string, voidedAt time.Time) (bool, error) {
+	result := query.Model(&model.AffiliateCommission{}).
+		Where("status = ? AND payout_id IS NULL", model.AffiliateCommissionPending).
+		Updates(map[string]interface{}{
+			"status":      model.AffiliateCommissionVoid,
+			"void_reason": reason,
+			"voided_at":   voidedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *affiliateRepository) CreatePayout(ctx context.Context, payout *model.AffiliatePayout, minAmount int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var commissions []model.AffiliateCommission
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("affiliate_user_id = ? AND status = ? AND payout_id IS NULL", payout.AffiliateUserID, model.AffiliateCommissionPending).
+			Find(&commissions).Error; err != nil {
+			return err
+		}
+		if len(commissions) == 0 {
+			return ErrNoPayableCommissions
+		}
+
+		ids := make([]string, 0, len(commissions))
+		payout.Amount = 0
+		for _, commission := range commissions {
+			ids = append(ids, commission.ID)
+			payout.Amount += commission.Amount
+		}
+		if payout.Amount < minAmount {
+			return ErrPayoutBelowMinimum
+		}
+		payout.CommissionCount = len(commissions)
+		payout.Status = model.AffiliatePayoutPending
+
+		if err := tx.Create(payout).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.AffiliateCommission{}).Where("id IN ?", ids).Update("payout_id", payout.ID).Error
+	})
+}
+
+func (r *affiliateRepository) FindPayoutByID(ctx context.Context, id string) (*model.AffiliatePayout, error) {
+	var payout model.AffiliatePayout
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&payout).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payout, nil
+}
+
+func (r *affiliateRepository) FindPayouts(ctx context.Context, userID string, status string, page, limit int) ([]model.AffiliatePayout, int64, error) {
+	var payouts []model.AffiliatePayout
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.AffiliatePayout{})
+	if userID != "" {
+		query = query.Where("affiliate_user_id = ?", userID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&payouts).Error
+	return payouts, total, err
+}
+
+func (r *affiliateRepository) MarkPayoutPaid(ctx context.Context, id string, reference string, paidAt time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockPendingPayout(tx, id); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.AffiliatePayout{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"status":    model.AffiliatePayoutPaid,
+			"reference": reference,
+			"paid_at":   paidAt,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.AffiliateCommission{}).Where("payout_id = ?", id).Updates(map[string]interface{}{
+			"status":  model.AffiliateCommissionPaid,
+			"paid_at": paidAt,
+		}).Error
+	})
+}
+
+func (r *affiliateRepository) CancelPayout(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockPendingPayout(tx, id); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.AffiliatePayout{}).Where("id = ?", id).
+			Update("status", model.AffiliatePayoutCancelled).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.AffiliateCommission{}).Where("payout_id = ?", id).Update("payout_id", nil).Error
+	})
+}
+
+// lockPendingPayout locks the payout row and checks it can still be paid or cancelled
+func lockPendingPayout(tx *gorm.DB, id string) error {
+	var payout model.AffiliatePayout
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&payout).Error; err != nil {
+		return err
+	}
+	if payout.Status != model.AffiliatePayoutPending {
+		return ErrPayoutNotPending
+	}
+	return nil
+}
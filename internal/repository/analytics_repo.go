@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// funnelEventColumns maps funnel events to their counter column
+var funnelEventColumns = map[string]string{
+	model.FunnelEventView:      "views",
+	model.FunnelEventAddToCart: "add_to_carts",
+	model.FunnelEventCheckout:  "checkouts",
+	model.FunnelEventPurchase:  "purchases",
+}
+
+type AnalyticsRepository interface {
+	IncrementFunnel(ctx context.Context, productID, sellerID string, date time.Time, event string, units int) error
+	GetProductFunnel(ctx context.Context, sellerID string, from, to time.Time, productID string) ([]ProductFunnelRow, error)
+}
+
+// ProductFunnelRow is a product's funnel totals over a date range
+type ProductFunnelRow struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Views       int64  `json:"views"`
+	AddToCarts  int64  `json:"add_to_carts"`
+	Checkouts   int64  `json:"checkouts"`
+	Purchases   int64  `json:"purchases"`
+	UnitsSold   int64  `json:"units_sold"`
+}
+
+type analyticsRepository struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+// IncrementFunnel adds one event to the product's counters for the day; units is added to
+// units_sold for purchases
+func (r *analyticsRepository) IncrementFunnel(ctx context.Context, productID, sellerID string, date time.Time, event string, units int) error {
+	column, ok := funnelEventColumns[event]
+	if !ok {
+		return fmt.Errorf("unknown funnel event: %s", event)
+	}
+
+	stat := &model.ProductFunnelStat{
+		ProductID: productID,
+		SellerID:  sellerID,
+		Date:      date,
+	}
+	updates := map[string]interface{}{
+		column:       gorm.Expr("product_funnel_stats." + column + " + 1"),
+		"updated_at": time.Now(),
+	}
+	switch event {
+	case model.FunnelEventView:
+		stat.Views = 1
+	case model.FunnelEventAddToCart:
+		stat.AddToCarts = 1
+	case model.FunnelEventCheckout:
+		stat.Checkouts = 1
+	case model.FunnelEventPurchase:
+		stat.Purchases = 1
+		stat.UnitsSold = int64(units)
+		updates["units_sold"] = gorm.Expr("product_funnel_stats.units_sold + ?", units)
+	}
+
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}, {Name: "date"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(stat).Error
+}
+
+// GetProductFunnel sums the seller's funnel counters per product over [from, to], busiest first
+func (r *analyticsRepository) GetProductFunnel(ctx context.Context, sellerID string, from, to time.Time, productID string) ([]ProductFunnelRow, error) {
+	var rows []ProductFunnelRow
+	query := r.db.WithContext(ctx).Table("product_funnel_stats AS s").
+		Select("s.product_id, p.name AS product_name, "+
+			"SUM(s.views) AS views, SUM(s.add_to_carts) AS add_to_carts, SUM(s.checkouts) AS checkouts, "+
+			"SUM(s.purchases) AS purchases, SUM(s.units_sold) AS units_sold").
+		Joins("JOIN products p ON p.id = s.product_id").
+		Where("s.seller_id = ? AND s.date BETWEEN ? AND ?", sellerID, from, to)
+	if productID != "" {
+		query = query.Where("s.product_id = ?", productID)
+	}
+	err := query.Group("s.product_id, p.name").
+		Order("views DESC").
+		Scan(&rows).Error
+	return rows, err
+}
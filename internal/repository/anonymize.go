@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// AnonymizeStep is one table scrubbed by AnonymizePII and how many rows it touched
+type AnonymizeStep struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+}
+
+// anonymizeStatement scrubs the PII columns of one table. Replacement values are derived from
+// the row ID, so they stay unique where the column is unique and stay the same for a row across
+// runs. IDs and foreign keys are never touched, which keeps every relation intact.
+type anonymizeStatement struct {
+	table string
+	sql   string
+}
+
+// fakeDigits returns SQL for a stable run of n pseudo-random digits derived from the row ID
+func fakeDigits(n int) string {
+	return fmt.Sprintf("lpad((abs(hashtextextended(id::text, 0)) %% 1%s)::text, %d, '0')", strings.Repeat("0", n), n)
+}
+
+var anonymizeStatements = []anonymizeStatement{
+	{"users", `UPDATE users SET
+		email = 'user-' || id::text || '@example.invalid',
+		username = CASE WHEN username IS NULL THEN NULL ELSE 'user_' || replace(id::text, '-', '') END,
+		phone = CASE WHEN phone IS NULL THEN NULL ELSE '08' || ` + fakeDigits(10) + ` END,
+		full_name = 'User ' || left(id::text, 8),
+		password_hash = @password_hash,
+		profile_photo = NULL,
+		date_of_birth = NULL,
+		google_id = NULL,
+		otp_code = NULL,
+		otp_expires_at = NULL,
+		reset_token = NULL,
+		reset_expires_at = NULL`},
+	{"addresses", `UPDATE addresses SET
+		recipient_name = 'Recipient ' || left(id::text, 8),
+		phone = '08' || ` + fakeDigits(10) + `,
+		address_line1 = 'Jl. Contoh No. ' || (abs(hashtext(id::text)) % 200 + 1),
+		address_line2 = NULL`},
+	{"sellers", `UPDATE sellers SET
+		shop_address = CASE WHEN shop_address IS NULL THEN NULL ELSE 'Jl. Toko No. ' || (abs(hashtext(id::text)) % 200 + 1) END,
+		shop_phone = CASE WHEN shop_phone IS NULL THEN NULL ELSE '08' || ` + fakeDigits(10) + ` END,
+		shop_email = CASE WHEN shop_email IS NULL THEN NULL ELSE 'shop-' || id::text || '@example.invalid' END,
+		npwp = CASE WHEN npwp IS NULL THEN NULL ELSE ` + fakeDigits(15) + ` END`},
+	{"order_tax_lines", `UPDATE order_tax_lines SET
+		seller_npwp = CASE WHEN seller_npwp IS NULL THEN NULL ELSE ` + fakeDigits(15) + ` END`},
+	{"orders", `UPDATE orders SET
+		recipient_name = CASE WHEN recipient_name IS NULL THEN NULL ELSE 'Recipient ' || left(id::text, 8) END,
+		recipient_phone = CASE WHEN recipient_phone IS NULL THEN NULL ELSE '08' || ` + fakeDigits(10) + ` END,
+		gift_message = CASE WHEN gift_message IS NULL THEN NULL ELSE 'Gift message' END,
+		notes = CASE WHEN notes IS NULL THEN NULL ELSE 'Order note' END`},
+	{"order_notes", `UPDATE order_notes SET note = 'Internal note'`},
+	{"return_requests", `UPDATE return_requests SET
+		reason = 'Return reason',
+		seller_note = CASE WHEN seller_note IS NULL THEN NULL ELSE 'Seller note' END`},
+	{"disputes", `UPDATE disputes SET
+		reason = 'Dispute reason',
+		resolution_note = CASE WHEN resolution_note IS NULL THEN NULL ELSE 'Resolution note' END`},
+	{"dispute_messages", `UPDATE dispute_messages SET message = 'Dispute message'`},
+	{"support_tickets", `UPDATE support_tickets SET subject = 'Support ticket'`},
+	{"support_ticket_messages", `UPDATE support_ticket_messages SET message = 'Support message'`},
+	// System messages are generated status updates and hold no personal data
+	{"order_chat_messages", `UPDATE order_chat_messages SET body = 'Chat message' WHERE sender_role <> 'system'`},
+	{"cancellation_requests", `UPDATE cancellation_requests SET
+		reason = 'Cancellation reason',
+		seller_note = CASE WHEN seller_note IS NULL THEN NULL ELSE 'Seller note' END`},
+	{"payments", `UPDATE payments SET
+		va_number = CASE WHEN va_number IS NULL THEN NULL ELSE ` + fakeDigits(16) + ` END,
+		midtrans_response = NULL,
+		approval_code = NULL,
+		proof_image_url = NULL,
+		verification_note = CASE WHEN verification_note IS NULL THEN NULL ELSE 'Verification note' END`},
+	{"payment_status_retries", `UPDATE payment_status_retries SET
+		va_number = CASE WHEN va_number = '' THEN '' ELSE ` + fakeDigits(16) + ` END`},
+	{"saved_cards", `UPDATE saved_cards SET saved_token_id = 'anonymized-' || id::text`},
+	{"fraud_checks", `UPDATE fraud_checks SET
+		client_ip = '',
+		reason = CASE WHEN reason IS NULL THEN NULL ELSE 'Blocked by fraud rule' END`},
+	{"license_keys", `UPDATE license_keys SET key = 'KEY-' || upper(replace(id::text, '-', ''))`},
+	{"scheduled_reports", `UPDATE scheduled_reports SET
+		recipients = CASE WHEN recipients IS NULL OR recipients = '' THEN recipients ELSE (
+			SELECT string_agg('report-' || left(id::text, 8) || '-' || n || '@example.invalid', ',' ORDER BY n)
+			FROM unnest(string_to_array(recipients, ',')) WITH ORDINALITY AS r(email, n)
+		) END,
+		webhook_url = NULL`},
+	// Cached responses can hold any of the above
+	{"idempotency_keys", `DELETE FROM idempotency_keys`},
+}
+
+// AnonymizePII scrubs personal data (names, emails, phone numbers, street addresses, tax IDs, VA
+// numbers, payment tokens and free-text notes) from a database restored from production, so it can
+// be used as a staging dataset. Every user's password is replaced by passwordHash, so the team can log in
+// as anyone. Cities, provinces and postal codes are kept for realistic shipping and delivery
+// estimates. Everything runs in one transaction; tables that do not exist yet are skipped.
+func AnonymizePII(ctx context.Context, db *gorm.DB, passwordHash string) ([]AnonymizeStep, error) {
+	steps := make([]AnonymizeStep, 0, len(anonymizeStatements))
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, statement := range anonymizeStatements {
+			if !tx.Migrator().HasTable(statement.table) {
+				continue
+			}
+			result := tx.Exec(statement.sql, map[string]interface{}{"password_hash": passwordHash})
+			if result.Error != nil {
+				return fmt.Errorf("failed to anonymize %s: %w", statement.table, result.Error)
+			}
+			steps = append(steps, AnonymizeStep{Table: statement.table, Rows: result.RowsAffected})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
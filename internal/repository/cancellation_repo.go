@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidCancellationTransition is returned when a cancellation request is not in the status
+// the change requires
+var ErrInvalidCancellationTransition = errors.New("invalid cancellation request status transition")
+
+// ErrSubOrderAlreadyShipped is returned when a sub-order shipped before its cancellation was approved
+var ErrSubOrderAlreadyShipped = errors.New("the order has already been shipped")
+
+type CancellationRequestRepository interface {
+	Create(ctx context.Context, request *model.CancellationRequest) error
+	FindByID(ctx context.Context, id string) (*model.CancellationRequest, error)
+	FindBySellerOrderID(ctx context.Context, sellerOrderID string) (*model.CancellationRequest, error)
+	FindByUserID(ctx context.Context, userID string, status string, page, limit int) ([]model.CancellationRequest, int64, error)
+	FindBySellerID(ctx context.Context, sellerID string, status string, page, limit int) ([]model.CancellationRequest, int64, error)
+	// FindDue returns requests past their response deadline and approved requests still waiting
+	// for their refund, oldest first
+	FindDue(ctx context.Context, now time.Time, limit int) ([]model.CancellationRequest, error)
+	Transition(ctx context.Context, id string, from, to string, updates map[string]interface{}) error
+	// Approve approves a requested cancellation, cancels the sub-order and puts its items back in
+	// stock in one transaction. The parent order is cancelled once none of its sub-orders is left,
+	// or moves to shipped if the remaining ones all shipped.
+	Approve(ctx context.Context, id string, updates map[string]interface{}, change model.StatusChange) error
+	MarkRefunded(ctx context.Context, id string, method string, refundedAt time.Time) error
+}
+
+type cancellationRequestRepository struct {
+	db *gorm.DB
+}
+
+func NewCancellationRequestRepository(db *gorm.DB) CancellationRequestRepository {
+	return &cancellationRequestRepository{db: db}
+}
+
+func (r *cancellationRequestRepository) Create(ctx context.Context, request *model.CancellationRequest) error {
+	return r.db.WithContext(ctx).Create(request).Error
+}
+
+func (r *cancellationRequestRepository) FindByID(ctx context.Context, id string) (*model.CancellationRequest, error) {
+	var request model.CancellationRequest
+	err := r.db.WithContext(ctx).
+		Preload("SellerOrder").
+		Preload("SellerOrder.OrderItems").
+		Where("id = ?", id).First(&request).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *cancellationRequestRepository) FindBySellerOrderID(ctx context.Context, sellerOrderID string) (*model.CancellationRequest, error) {
+	var request model.CancellationRequest
+	if err := r.db.WithContext(ctx).Where("seller_order_id = ?", sellerOrderID).First(&request).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *cancellationRequestRepository) FindByUserID(ctx context.Context, userID string, status string, page, limit int) ([]model.CancellationRequest, int64, error) {
+	return r.list(ctx, "user_id = ?", userID, status, page, limit)
+}
+
+func (r *cancellationRequestRepository) FindBySellerID(ctx context.Context, sellerID string, status string, page, limit int) ([]model.CancellationRequest, int64, error) {
+	return r.list(ctx, "seller_id = ?", sellerID, status, page, limit)
+}
+
+func (r *cancellationRequestRepository) list(ctx context.Context, owner string, ownerID string, status string, page, limit int) ([]model.CancellationRequest, int64, error) {
+	var requests []model.CancellationRequest
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.CancellationRequest{}).Where(owner, ownerID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Preload("SellerOrder").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&requests).Error
+	return requests, total, err
+}
+
+func (r *cancellationRequestRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]model.CancellationRequest, error) {
+	var requests []model.CancellationRequest
+	err := r.db.WithContext(ctx).
+		Preload("SellerOrder").
+		Preload("SellerOrder.OrderItems").
+		Where("(status = ? AND respond_by < ?) OR status = ?",
+			model.CancellationStatusRequested, now, model.CancellationStatusApproved).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&requests).Error
+	return requests, err
+}
+
+func (r *cancellationRequestRepository) Transition(ctx context.Context, id string, from, to string, updates map[string]interface{}) error {
+	return transitionCancellation(r.db.WithContext(ctx), id, from, to, updates)
+}
+
+func (r *cancellationRequestRepository) Approve(ctx context.Context, id string, updates map[string]interface{}, change model.StatusChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var request model.CancellationRequest
+		if err := tx.Where("id = ?", id).First(&request).Error; err != nil {
+			return err
+		}
+		var sellerOrder model.SellerOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", request.SellerOrderID).
+			First(&sellerOrder).Error; err != nil {
+			return err
+		}
+		if sellerOrder.Status != "paid" && sellerOrder.Status != "processing" {
+			return ErrSubOrderAlreadyShipped
+		}
+		if err := transitionCancellation(tx, id, model.CancellationStatusRequested, model.CancellationStatusApproved, updates); err != nil {
+			return err
+		}
+
+		var items []model.OrderItem
+		if err := tx.Where("seller_order_id = ?", sellerOrder.ID).Find(&items).Error; err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := restoreItemStock(tx, item); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&sellerOrder).Update("status", "cancelled").Error; err != nil {
+			return err
+		}
+		from := sellerOrder.Status
+		history := model.NewStatusHistory(sellerOrder.OrderID, &from, "cancelled", change)
+		history.SellerOrderID = &sellerOrder.ID
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		var remaining int64
+		if err := tx.Model(&model.SellerOrder{}).
+			Where("order_id = ? AND status <> ?", sellerOrder.OrderID, "cancelled").
+			Count(&remaining).Error; err != nil {
+			return err
+		}
+		if remaining > 0 {
+			return rollUpShipped(tx, sellerOrder.OrderID, change)
+		}
+
+		result := tx.Model(&model.Order{}).
+			Where("id = ? AND status = ?", sellerOrder.OrderID, "processing").
+			Updates(map[string]interface{}{
+				"status":        "cancelled",
+				"cancel_reason": request.Reason,
+				"cancelled_at":  time.Now(),
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		parentFrom := "processing"
+		return tx.Create(model.NewStatusHistory(sellerOrder.OrderID, &parentFrom, "cancelled", change)).Error
+	})
+}
+
+func (r *cancellationRequestRepository) MarkRefunded(ctx context.Context, id string, method string, refundedAt time.Time) error {
+	return transitionCancellation(r.db.WithContext(ctx), id, model.CancellationStatusApproved, model.CancellationStatusRefunded, map[string]interface{}{
+		"refund_method": method,
+		"refunded_at":   refundedAt,
+	})
+}
+
+func transitionCancellation(db *gorm.DB, id string, from, to string, updates map[string]interface{}) error {
+	values := map[string]interface{}{"status": to}
+	for column, value := range updates {
+		values[column] = value
+	}
+	result := db.Model(&model.CancellationRequest{}).Where("id = ? AND status = ?", id, from).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidCancellationTransition
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// AbandonedCart is a cart with items that has not changed since LastActivityAt
+type AbandonedCart struct {
+	CartID         string
+	UserID         string
+	LastActivityAt time.Time
+	ItemCount      int
+	Subtotal       int
+}
+
+type CartReminderRepository interface {
+	// FindAbandonedCarts returns carts with items whose last change falls between notBefore and
+	// idleBefore, skipping carts already reminded about since that change and buyers reminded
+	// after cooldownSince. Oldest activity first.
+	FindAbandonedCarts(ctx context.Context, idleBefore, notBefore, cooldownSince time.Time, limit int) ([]AbandonedCart, error)
+	Create(ctx context.Context, reminder *model.CartReminder) error
+	Update(ctx context.Context, reminder *model.CartReminder) error
+}
+
+type cartReminderRepository struct {
+	db *gorm.DB
+}
+
+func NewCartReminderRepository(db *gorm.DB) CartReminderRepository {
+	return &cartReminderRepository{db: db}
+}
+
+func (r *cartReminderRepository) FindAbandonedCarts(ctx context.Context, idleBefore, notBefore, cooldownSince time.Time, limit int) ([]AbandonedCart, error) {
+	var carts []AbandonedCart
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT a.cart_id, a.user_id, a.last_activity_at, a.item_count, a.subtotal
+		FROM (
+			SELECT c.id AS cart_id, c.user_id,
+				GREATEST(c.updated_at, MAX(ci.updated_at)) AS last_activity_at,
+				COUNT(ci.id) AS item_count,
+				COALESCE(SUM(ci.quantity * ci.price), 0) AS subtotal
+			FROM carts c
+			JOIN cart_items ci ON ci.cart_id = c.id
+			GROUP BY c.id, c.user_id, c.updated_at
+		) a
+		WHERE a.last_activity_at < ? AND a.last_activity_at >= ?
+			AND NOT EXISTS (
+				SELECT 1 FROM cart_reminders r
+				WHERE r.user_id = a.user_id AND (r.sent_at > ? OR (r.cart_id = a.cart_id AND r.cart_activity_at >= a.last_activity_at))
+			)
+		ORDER BY a.last_activity_at ASC
+		LIMIT ?`,
+		idleBefore, notBefore, cooldownSince, limit).
+		Scan(&carts).Error
+	return carts, err
+}
+
+func (r *cartReminderRepository) Create(ctx context.Context, reminder *model.CartReminder) error {
+	return r.db.WithContext(ctx).Create(reminder).Error
+}
+
+func (r *cartReminderRepository) Update(ctx context.Context, reminder *model.CartReminder) error {
+	return r.db.WithContext(ctx).Save(reminder).Error
+}
@@ -10,12 +10,24 @@ type CartRepository interface {
 	GetOrCreateByUserID(userID string) (*model.Cart, error)
 	GetByUserID(userID string) (*model.Cart, error)
 	GetCartItemByID(cartItemID string) (*model.CartItem, error)
-	GetCartItemByProductID(cartID, productID string) (*model.CartItem, error)
+	// GetCartItemByProductID finds the cart's line for the product, or for one variant of it
+	GetCartItemByProductID(cartID, productID string, variantID *string) (*model.CartItem, error)
 	AddCartItem(cartItem *model.CartItem) error
 	UpdateCartItem(cartItem *model.CartItem) error
 	DeleteCartItem(cartItemID string) error
 	ClearCart(cartID string) error
 	GetCartItems(cartID string) ([]model.CartItem, error)
+	// CountItemsByUserID returns the number of items in the user's cart and their total quantity,
+	// without loading the cart
+	CountItemsByUserID(userID string) (int64, int64, error)
+	FlagItemsBySellerID(sellerID string, reason string) (map[string]int, error)
+	UnflagItemsBySellerID(sellerID string, reason string) (int64, error)
+	SetItemsSelected(cartID string, cartItemIDs []string, selected bool) (int64, error)
+	// SetCouponCode applies a voucher code to the cart, or removes it when code is nil
+	SetCouponCode(cartID string, code *string) error
+	// Invalidate drops any cached copy of the user's cart after its items were changed outside this
+	// repository (e.g. moved to the wishlist or ordered in another repository's transaction)
+	Invalidate(userID string)
 }
 
 type cartRepository struct {
@@ -40,13 +52,13 @@ func (r *cartRepository) GetOrCreateByUserID(userID string) (*model.Cart, error)
 	}
 	
 	// Preload cart items with product details
-	err = r.db.Preload("CartItems").Preload("CartItems.Product").Preload("CartItems.Product.Seller").Preload("CartItems.Product.Category").Preload("CartItems.Product.ProductImages").Where("id = ?", cart.ID).First(&cart).Error
+	err = r.db.Preload("CartItems").Preload("CartItems.Product").Preload("CartItems.Product.Seller").Preload("CartItems.Product.Category").Preload("CartItems.Product.ProductImages").Preload("CartItems.Variant").Where("id = ?", cart.ID).First(&cart).Error
 	return &cart, err
 }
 
 func (r *cartRepository) GetByUserID(userID string) (*model.Cart, error) {
 	var cart model.Cart
-	err := r.db.Preload("CartItems").Preload("CartItems.Product").Preload("CartItems.Product.Seller").Preload("CartItems.Product.Category").Preload("CartItems.Product.ProductImages").Where("user_id = ?", userID).First(&cart).Error
+	err := r.db.Preload("CartItems").Preload("CartItems.Product").Preload("CartItems.Product.Seller").Preload("CartItems.Product.Category").Preload("CartItems.Product.ProductImages").Preload("CartItems.Variant").Where("user_id = ?", userID).First(&cart).Error
 	if err != nil {
 		return nil, err
 	}
@@ -55,16 +67,22 @@ func (r *cartRepository) GetByUserID(userID string) (*model.Cart, error) {
 
 func (r *cartRepository) GetCartItemByID(cartItemID string) (*model.CartItem, error) {
 	var cartItem model.CartItem
-	err := r.db.Preload("Product").Preload("Product.Seller").Preload("Product.Category").Preload("Product.ProductImages").Where("id = ?", cartItemID).First(&cartItem).Error
+	err := r.db.Preload("Product").Preload("Product.Seller").Preload("Product.Category").Preload("Product.ProductImages").Preload("Variant").Where("id = ?", cartItemID).First(&cartItem).Error
 	if err != nil {
 		return nil, err
 	}
 	return &cartItem, nil
 }
 
-func (r *cartRepository) GetCartItemByProductID(cartID, productID string) (*model.CartItem, error) {
+func (r *cartRepository) GetCartItemByProductID(cartID, productID string, variantID *string) (*model.CartItem, error) {
 	var cartItem model.CartItem
-	err := r.db.Where("cart_id = ? AND product_id = ?", cartID, productID).First(&cartItem).Error
+	query := r.db.Where("cart_id = ? AND product_id = ?", cartID, productID)
+	if variantID != nil {
+		query = query.Where("variant_id = ?", *variantID)
+	} else {
+		query = query.Where("variant_id IS NULL")
+	}
+	err := query.First(&cartItem).Error
 	if err != nil {
 		return nil, err
 	}
@@ -89,6 +107,74 @@ func (r *cartRepository) ClearCart(cartID string) error {
 
 func (r *cartRepository) GetCartItems(cartID string) ([]model.CartItem, error) {
 	var cartItems []model.CartItem
-	err := r.db.Preload("Product").Preload("Product.Seller").Preload("Product.Category").Preload("Product.ProductImages").Where("cart_id = ?", cartID).Find(&cartItems).Error
+	err := r.db.Preload("Product").Preload("Product.Seller").Preload("Product.Category").Preload("Product.ProductImages").Preload("Variant").Where("cart_id = ?", cartID).Find(&cartItems).Error
 	return cartItems, err
 }
+
+func (r *cartRepository) CountItemsByUserID(userID string) (int64, int64, error) {
+	var counts struct {
+		Count    int64
+		Quantity int64
+	}
+	err := r.db.Table("cart_items").
+		Select("COUNT(*) AS count, COALESCE(SUM(cart_items.quantity), 0) AS quantity").
+		Joins("JOIN carts ON carts.id = cart_items.cart_id").
+		Where("carts.user_id = ?", userID).
+		Scan(&counts).Error
+	return counts.Count, counts.Quantity, err
+}
+
+// FlagItemsBySellerID marks every cart item of the seller's products as unavailable and returns
+// how many items were flagged per cart owner (user ID)
+func (r *cartRepository) FlagItemsBySellerID(sellerID string, reason string) (map[string]int, error) {
+	affected := make(map[string]int)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var rows []struct {
+			UserID string
+			Items  int
+		}
+		if err := tx.Table("cart_items").
+			Select("carts.user_id AS user_id, COUNT(*) AS items").
+			Joins("JOIN carts ON carts.id = cart_items.cart_id").
+			Joins("JOIN products ON products.id = cart_items.product_id").
+			Where("products.seller_id = ? AND cart_items.unavailable_reason IS NULL", sellerID).
+			Group("carts.user_id").
+			Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			affected[row.UserID] = row.Items
+		}
+
+		return tx.Model(&model.CartItem{}).
+			Where("unavailable_reason IS NULL AND product_id IN (?)", tx.Model(&model.Product{}).Select("id").Where("seller_id = ?", sellerID)).
+			Update("unavailable_reason", reason).Error
+	})
+	return affected, err
+}
+
+// UnflagItemsBySellerID makes the seller's cart items flagged for reason available again
+func (r *cartRepository) UnflagItemsBySellerID(sellerID string, reason string) (int64, error) {
+	result := r.db.Model(&model.CartItem{}).
+		Where("unavailable_reason = ? AND product_id IN (?)", reason, r.db.Model(&model.Product{}).Select("id").Where("seller_id = ?", sellerID)).
+		Update("unavailable_reason", nil)
+	return result.RowsAffected, result.Error
+}
+
+// SetItemsSelected selects or deselects the given items of the cart, or every item when
+// cartItemIDs is empty, and returns how many items matched
+func (r *cartRepository) SetItemsSelected(cartID string, cartItemIDs []string, selected bool) (int64, error) {
+	query := r.db.Model(&model.CartItem{}).Where("cart_id = ?", cartID)
+	if len(cartItemIDs) > 0 {
+		query = query.Where("id IN ?", cartItemIDs)
+	}
+	result := query.Update("selected", selected)
+	return result.RowsAffected, result.Error
+}
+
+func (r *cartRepository) SetCouponCode(cartID string, code *string) error {
+	return r.db.Model(&model.Cart{}).Where("id = ?", cartID).Update("coupon_code", code).Error
+}
+
+// Invalidate is a no-op: reads always go to the database
+func (r *cartRepository) Invalidate(userID string) {}
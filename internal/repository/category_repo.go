@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"errors"
+	"time"
 	"yourapp/internal/model"
 
 	"gorm.io/gorm"
@@ -13,6 +15,11 @@ type CategoryRepository interface {
 	FindAll(activeOnly bool) ([]model.Category, error)
 	Update(category *model.Category) error
 	Delete(id string) error
+	// Trash: soft-deleted categories, newest deletion first
+	FindDeleted(page, limit int) ([]model.Category, int64, error)
+	FindDeletedByID(id string) (*model.Category, error)
+	// Restore undeletes a category under the given slug
+	Restore(id string, slug string) error
 }
 
 type categoryRepository struct {
@@ -62,3 +69,39 @@ func (r *categoryRepository) Update(category *model.Category) error {
 func (r *categoryRepository) Delete(id string) error {
 	return r.db.Delete(&model.Category{}, "id = ?", id).Error
 }
+
+func (r *categoryRepository) FindDeleted(page, limit int) ([]model.Category, int64, error) {
+	var categories []model.Category
+	var total int64
+
+	query := r.db.Unscoped().Model(&model.Category{}).Where("deleted_at IS NOT NULL")
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("deleted_at DESC").Offset(offset).Limit(limit).Find(&categories).Error
+	return categories, total, err
+}
+
+func (r *categoryRepository) FindDeletedByID(id string) (*model.Category, error) {
+	var category model.Category
+	err := r.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) Restore(id string, slug string) error {
+	result := r.db.Unscoped().Model(&model.Category{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		UpdateColumns(map[string]interface{}{"deleted_at": nil, "slug": slug, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("category not found in trash")
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrCouponUsageLimit is returned by Redeem when the coupon has no redemptions left
+	ErrCouponUsageLimit = errors.New("coupon usage limit reached")
+	// ErrCouponUserLimit is returned by Redeem when the buyer used the coupon as often as allowed
+	ErrCouponUserLimit = errors.New("coupon already used")
+)
+
+type CouponRepository interface {
+	Create(ctx context.Context, coupon *model.Coupon) error
+	Update(ctx context.Context, coupon *model.Coupon) error
+	FindByID(ctx context.Context, id string) (*model.Coupon, error)
+	FindByCode(ctx context.Context, code string) (*model.Coupon, error)
+	List(ctx context.Context, page, limit int) ([]model.Coupon, int64, error)
+
+	// CountRedemptions returns how often the coupon was redeemed in total and by the user, not
+	// counting cancelled orders
+	CountRedemptions(ctx context.Context, couponID, userID string) (int64, int64, error)
+	// Redeem records the coupon on an order. The coupon row is locked while its limits are
+	// checked, so concurrent checkouts cannot exceed them.
+	Redeem(ctx context.Context, coupon *model.Coupon, redemption *model.CouponRedemption) error
+	// Release removes the redemption of an order that could not be placed
+	Release(ctx context.Context, orderID string) error
+
+	// CreateBatch stores a batch together with its coupons
+	CreateBatch(ctx context.Context, batch *model.CouponBatch, coupons []model.Coupon) error
+	FindBatchByID(ctx context.Context, id string) (*model.CouponBatch, error)
+	ListBatches(ctx context.Context, page, limit int) ([]model.CouponBatch, int64, error)
+	// FindExistingCodes returns which of the codes are already taken
+	FindExistingCodes(ctx context.Context, codes []string) ([]string, error)
+	// CountBatchRedemptions returns, per batch, how many of its codes were redeemed on an order
+	// that is not cancelled
+	CountBatchRedemptions(ctx context.Context, batchIDs []string) (map[string]int64, error)
+	// FindBatchCoupons returns up to limit codes of the batch after the given code, in code order
+	FindBatchCoupons(ctx context.Context, batchID string, afterCode string, limit int) ([]BatchCoupon, error)
+}
+
+// BatchCoupon is a code of a coupon batch with when it was redeemed, if it was
+type BatchCoupon struct {
+	model.Coupon
+	RedeemedAt *time.Time
+}
+
+type couponRepository struct {
+	db *gorm.DB
+}
+
+func NewCouponRepository(db *gorm.DB) CouponRepository {
+	return &couponRepository{db: db}
+}
+
+func (r *couponRepository) Create(ctx context.Context, coupon *model.Coupon) error {
+	return r.db.WithContext(ctx).Create(coupon).Error
+}
+
+func (r *couponRepository) Update(ctx context.Context, coupon *model.Coupon) error {
+	return r.db.WithContext(ctx).Save(coupon).Error
+}
+
+func (r *couponRepository) FindByID(ctx context.Context, id string) (*model.Coupon, error) {
+	var coupon model.Coupon
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&coupon).Error
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (r *couponRepository) FindByCode(ctx context.Context, code string) (*model.Coupon, error) {
+	var coupon model.Coupon
+	err := r.db.WithContext(ctx).Where("code = ?", code).First(&coupon).Error
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (r *couponRepository) List(ctx context.Context, page, limit int) ([]model.Coupon, int64, error) {
+	var coupons []model.Coupon
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.Coupon{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&coupons).Error
+	return coupons, total, err
+}
+
+func (r *couponRepository) CountRedemptions(ctx context.Context, couponID, userID string) (int64, int64, error) {
+	return countRedemptions(r.db.WithContext(ctx), couponID, userID)
+}
+
+// countRedemptions counts redemptions whose order is not cancelled. A redemption is written just
+// before its order, so one without an order yet still counts.
+func countRedemptions(db *gorm.DB, couponID, userID string) (int64, int64, error) {
+	var counts struct {
+		Total  int64
+		ByUser int64
+	}
+	err := db.Table("coupon_redemptions").
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE coupon_redemptions.user_id = ?) AS by_user", userID).
+		Joins("LEFT JOIN orders ON orders.id = coupon_redemptions.order_id").
+		Where("coupon_redemptions.coupon_id = ? AND (orders.status IS NULL OR orders.status <> 'cancelled')", couponID).
+		Scan(&counts).Error
+	return counts.Total, counts.ByUser, err
+}
+
+func (r *couponRepository) Redeem(ctx context.Context, coupon *model.Coupon, redemption *model.CouponRedemption) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked model.Coupon
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", coupon.ID).First(&locked).Error; err != nil {
+			return err
+		}
+		total, byUser, err := countRedemptions(tx, coupon.ID, redemption.UserID)
+		if err != nil {
+			return err
+		}
+		if locked.UsageLimit > 0 && total >= int64(locked.UsageLimit) {
+			return ErrCouponUsageLimit
+		}
+		if locked.PerUserLimit > 0 && byUser >= int64(locked.PerUserLimit) {
+			return ErrCouponUserLimit
+		}
+		redemption.CouponID = coupon.ID
+		return tx.Create(redemption).Error
+	})
+}
+
+func (r *couponRepository) Release(ctx context.Context, orderID string) error {
+	return r.db.WithContext(ctx).Where("order_id = ?", orderID).Delete(&model.CouponRedemption{}).Error
+}
+
+func (r *couponRepository) CreateBatch(ctx context.Context, batch *model.CouponBatch, coupons []model.Coupon) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+		for i := range coupons {
+			coupons[i].BatchID = &batch.ID
+		}
+		return tx.CreateInBatches(coupons, 500).Error
+	})
+}
+
+func (r *couponRepository) FindBatchByID(ctx context.Context, id string) (*model.CouponBatch, error) {
+	var batch model.CouponBatch
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&batch).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (r *couponRepository) ListBatches(ctx context.Context, page, limit int) ([]model.CouponBatch, int64, error) {
+	var batches []model.CouponBatch
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.CouponBatch{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&batches).Error
+	return batches, total, err
+}
+
+func (r *couponRepository) FindExistingCodes(ctx context.Context, codes []string) ([]string, error) {
+	var existing []string
+	if len(codes) == 0 {
+		return existing, nil
+	}
+	err := r.db.WithContext(ctx).Model(&model.Coupon{}).Where("code IN ?", codes).Pluck("code", &existing).Error
+	return existing, err
+}
+
+func (r *couponRepository) CountBatchRedemptions(ctx context.Context, batchIDs []string) (map[string]int64, error) {
+	counts := make(map[string]int64, len(batchIDs))
+	if len(batchIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		BatchID string
+		Count   int64
+	}
+	err := r.db.WithContext(ctx).Table("coupons").
+		Select("coupons.batch_id, COUNT(DISTINCT coupons.id) AS count").
+		Joins("JOIN coupon_redemptions ON coupon_redemptions.coupon_id = coupons.id").
+		Joins("LEFT JOIN orders ON orders.id = coupon_redemptions.order_id").
+		Where("coupons.batch_id IN ? AND (orders.status IS NULL OR orders.status <> 'cancelled')", batchIDs).
+		Group("coupons.batch_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.BatchID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *couponRepository) FindBatchCoupons(ctx context.Context, batchID string, afterCode string, limit int) ([]BatchCoupon, error) {
+	var coupons []BatchCoupon
+	err := r.db.WithContext(ctx).Model(&model.Coupon{}).
+		Select(`coupons.*, (
+			SELECT MIN(coupon_redemptions.created_at) FROM coupon_redemptions
+			LEFT JOIN orders ON orders.id = coupon_redemptions.order_id
+			WHERE coupon_redemptions.coupon_id = coupons.id AND (orders.status IS NULL OR orders.status <> 'cancelled')
+		) AS redeemed_at`).
+		Where("coupons.batch_id = ? AND coupons.code > ?", batchID, afterCode).
+		Order("coupons.code ASC").
+		Limit(limit).
+		Scan(&coupons).Error
+	return coupons, err
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type DeliverySlotRepository interface {
+	Create(ctx context.Context, slot *model.DeliverySlot) error
+	FindByID(ctx context.Context, id string) (*model.DeliverySlot, error)
+	FindBySellerID(ctx context.Context, sellerID string, activeOnly bool) ([]model.DeliverySlot, error)
+	Update(ctx context.Context, slot *model.DeliverySlot) error
+	Delete(ctx context.Context, id string) error
+	// CountBookings counts orders that are not cancelled booked into a slot on a date
+	CountBookings(ctx context.Context, slotID string, date time.Time) (int64, error)
+}
+
+type deliverySlotRepository struct {
+	db *gorm.DB
+}
+
+func NewDeliverySlotRepository(db *gorm.DB) DeliverySlotRepository {
+	return &deliverySlotRepository{db: db}
+}
+
+func (r *deliverySlotRepository) Create(ctx context.Context, slot *model.DeliverySlot) error {
+	return r.db.WithContext(ctx).Create(slot).Error
+}
+
+func (r *deliverySlotRepository) FindByID(ctx context.Context, id string) (*model.DeliverySlot, error) {
+	var slot model.DeliverySlot
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&slot).Error
+	if err != nil {
+		return nil, err
+	}
+	return &slot, nil
+}
+
+func (r *deliverySlotRepository) FindBySellerID(ctx context.Context, sellerID string, activeOnly bool) ([]model.DeliverySlot, error) {
+	var slots []model.DeliverySlot
+	query := r.db.WithContext(ctx).Where("seller_id = ?", sellerID)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("weekday ASC").Order("start_time ASC").Find(&slots).Error
+	return slots, err
+}
+
+func (r *deliverySlotRepository) Update(ctx context.Context, slot *model.DeliverySlot) error {
+	return r.db.WithContext(ctx).Save(slot).Error
+}
+
+func (r *deliverySlotRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&model.DeliverySlot{}, "id = ?", id).Error
+}
+
+func (r *deliverySlotRepository) CountBookings(ctx context.Context, slotID string, date time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Order{}).
+		Where("delivery_slot_id = ? AND delivery_date = ? AND status <> ?", slotID, date.Format("2006-01-02"), "cancelled").
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DeviceTokenRepository interface {
+	// Upsert registers a token, moving it to the given user if another user had it
+	Upsert(ctx context.Context, token *model.DeviceToken) error
+	FindByUserID(ctx context.Context, userID string) ([]model.DeviceToken, error)
+	Delete(ctx context.Context, userID string, token string) error
+	// DeleteTokens forgets tokens FCM rejected, whoever they belong to
+	DeleteTokens(ctx context.Context, tokens []string) error
+}
+
+type deviceTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewDeviceTokenRepository(db *gorm.DB) DeviceTokenRepository {
+	return &deviceTokenRepository{db: db}
+}
+
+func (r *deviceTokenRepository) Upsert(ctx context.Context, token *model.DeviceToken) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "last_seen_at"}),
+	}).Create(token).Error
+}
+
+func (r *deviceTokenRepository) FindByUserID(ctx context.Context, userID string) ([]model.DeviceToken, error) {
+	var tokens []model.DeviceToken
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *deviceTokenRepository) Delete(ctx context.Context, userID string, token string) error {
+	return r.db.WithContext(ctx).Where("user_id = ? AND token = ?", userID, token).Delete(&model.DeviceToken{}).Error
+}
+
+func (r *deviceTokenRepository) DeleteTokens(ctx context.Context, tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Where("token IN ?", tokens).Delete(&model.DeviceToken{}).Error
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DigitalGoodsRepository stores license key pools and the deliveries of digital order items
+type DigitalGoodsRepository interface {
+	// AddLicenseKeys adds keys to a product's pool, skipping keys it already has
+	AddLicenseKeys(ctx context.Context, productID string, keys []string) (int64, error)
+	CountAvailableKeys(ctx context.Context, productID string) (int64, error)
+	// CreateDelivery records a delivery and assigns as many of its keys as the pool has. It returns
+	// false when the order item was already delivered.
+	CreateDelivery(ctx context.Context, delivery *model.DigitalDelivery) (bool, error)
+	// AssignPendingKeys assigns newly added keys to the product's deliveries that are still short
+	AssignPendingKeys(ctx context.Context, productID string) (int, error)
+	FindByOrderID(ctx context.Context, orderID string) ([]model.DigitalDelivery, error)
+	FindByID(ctx context.Context, id string) (*model.DigitalDelivery, error)
+}
+
+type digitalGoodsRepository struct {
+	db *gorm.DB
+}
+
+func NewDigitalGoodsRepository(db *gorm.DB) DigitalGoodsRepository {
+	return &digitalGoodsRepository{db: db}
+}
+
+func (r *digitalGoodsRepository) AddLicenseKeys(ctx context.Context, productID string, keys []string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	rows := make([]model.LicenseKey, 0, len(keys))
+	for _, key := range keys {
+		rows = append(rows, model.LicenseKey{ProductID: productID, Key: key})
+	}
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}, {Name: "key"}},
+		DoNothing: true,
+	}).Create(&rows)
+	return result.RowsAffected, result.Error
+}
+
+func (r *digitalGoodsRepository) CountAvailableKeys(ctx context.Context, productID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.LicenseKey{}).
+		Where("product_id = ? AND order_item_id IS NULL", productID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *digitalGoodsRepository) CreateDelivery(ctx context.Context, delivery *model.DigitalDelivery) (bool, error) {
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "order_item_id"}},
+			DoNothing: true,
+		}).Create(delivery)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		created = true
+		_, err := claimLicenseKeys(tx, delivery.ProductID, delivery.OrderItemID, delivery.KeysRequested)
+		return err
+	})
+	return created, err
+}
+
+func (r *digitalGoodsRepository) AssignPendingKeys(ctx context.Context, productID string) (int, error) {
+	assigned := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var deliveries []model.DigitalDelivery
+		if err := tx.Where("product_id = ? AND keys_requested > 0", productID).
+			Order("delivered_at ASC").Find(&deliveries).Error; err != nil {
+			return err
+		}
+		for _, delivery := range deliveries {
+			var have int64
+			if err := tx.Model(&model.LicenseKey{}).Where("order_item_id = ?", delivery.OrderItemID).Count(&have).Error; err != nil {
+				return err
+			}
+			missing := delivery.KeysRequested - int(have)
+			if missing <= 0 {
+				continue
+			}
+			claimed, err := claimLicenseKeys(tx, productID, delivery.OrderItemID, missing)
+			if err != nil {
+				return err
+			}
+			assigned += claimed
+			if claimed < missing {
+				break // Pool is empty again
+			}
+		}
+		return nil
+	})
+	return assigned, err
+}
+
+func (r *digitalGoodsRepository) FindByOrderID(ctx context.Context, orderID string) ([]model.DigitalDelivery, error) {
+	var deliveries []model.DigitalDelivery
+	err := r.db.WithContext(ctx).
+		Preload("Product").
+		Preload("LicenseKeys", func(db *gorm.DB) *gorm.DB { return db.Order("assigned_at ASC") }).
+		Where("order_id = ?", orderID).
+		Order("delivered_at ASC").
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *digitalGoodsRepository) FindByID(ctx context.Context, id string) (*model.DigitalDelivery, error) {
+	var delivery model.DigitalDelivery
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&delivery).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("download not found")
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// claimLicenseKeys assigns up to count free keys of a product to an order item. Locked keys are
+// skipped so concurrent deliveries never hand out the same key.
+func claimLicenseKeys(tx *gorm.DB, productID string, orderItemID string, count int) (int, error) {
+	if count <= 0 {
+		return 0, nil
+	}
+	var ids []string
+	if err := tx.Model(&model.LicenseKey{}).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("product_id = ? AND order_item_id IS NULL", productID).
+		Order("created_at ASC").
+		Limit(count).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := tx.Model(&model.LicenseKey{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"order_item_id": orderItemID,
+		"assigned_at":   time.Now(),
+	}).Error; err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
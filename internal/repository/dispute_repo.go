@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidDisputeTransition is returned when a dispute is not in a status the change requires
+var ErrInvalidDisputeTransition = errors.New("invalid dispute status transition")
+
+type DisputeRepository interface {
+	// Create saves the dispute with its photos and holds the seller's settlement for the sub-order
+	Create(ctx context.Context, dispute *model.Dispute) error
+	FindByID(ctx context.Context, id string) (*model.Dispute, error)
+	FindOpenBySellerOrderID(ctx context.Context, sellerOrderID string) (*model.Dispute, error)
+	FindByUserID(ctx context.Context, userID string, status string, page, limit int) ([]model.Dispute, int64, error)
+	FindBySellerID(ctx context.Context, sellerID string, status string, page, limit int) ([]model.Dispute, int64, error)
+	FindAll(ctx context.Context, status string, page, limit int) ([]model.Dispute, int64, error)
+	AddMessage(ctx context.Context, message *model.DisputeMessage) error
+	// Transition moves a dispute that is in one of the from statuses to another open status
+	Transition(ctx context.Context, id string, from []string, to string, updates map[string]interface{}) error
+	// Finish resolves or closes a dispute, takes refundAmount off the pending settlement for the
+	// sub-order and releases the hold
+	Finish(ctx context.Context, id string, from []string, to string, updates map[string]interface{}, refundAmount int) error
+}
+
+type disputeRepository struct {
+	db *gorm.DB
+}
+
+func NewDisputeRepository(db *gorm.DB) DisputeRepository {
+	return &disputeRepository{db: db}
+}
+
+func (r *disputeRepository) Create(ctx context.Context, dispute *model.Dispute) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(dispute).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.SellerSettlement{}).
+			Where("seller_order_id = ? AND status = ?", dispute.SellerOrderID, "pending").
+			Update("on_hold", true).Error
+	})
+}
+
+func (r *disputeRepository) FindByID(ctx context.Context, id string) (*model.Dispute, error) {
+	var dispute model.Dispute
+	err := r.db.WithContext(ctx).
+		Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order ASC") }).
+		Preload("Messages", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("SellerOrder").
+		Preload("SellerOrder.OrderItems").
+		Where("id = ?", id).First(&dispute).Error
+	if err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+func (r *disputeRepository) FindOpenBySellerOrderID(ctx context.Context, sellerOrderID string) (*model.Dispute, error) {
+	var dispute model.Dispute
+	err := r.db.WithContext(ctx).
+		Where("seller_order_id = ? AND status IN ?", sellerOrderID, model.DisputeOpenStatuses).
+		First(&dispute).Error
+	if err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+func (r *disputeRepository) FindByUserID(ctx context.Context, userID string, status string, page, limit int) ([]model.Dispute, int64, error) {
+	return r.list(ctx, r.db.WithContext(ctx).Where("user_id = ?", userID), status, page, limit)
+}
+
+func (r *disputeRepository) FindBySellerID(ctx context.Context, sellerID string, status string, page, limit int) ([]model.Dispute, int64, error) {
+	return r.list(ctx, r.db.WithContext(ctx).Where("seller_id = ?", sellerID), status, page, limit)
+}
+
+func (r *disputeRepository) FindAll(ctx context.Context, status string, page, limit int) ([]model.Dispute, int64, error) {
+	return r.list(ctx, r.db.WithContext(ctx), status, page, limit)
+}
+
+func (r *disputeRepository) list(ctx context.Context, query *gorm.DB, status string, page, limit int) ([]model.Dispute, int64, error) {
+	var disputes []model.Dispute
+	var total int64
+
+	query = query.Model(&model.Dispute{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order ASC") }).
+		Preload("SellerOrder").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&disputes).Error
+	return disputes, total, err
+}
+
+func (r *disputeRepository) AddMessage(ctx context.Context, message *model.DisputeMessage) error {
+	return r.db.WithContext(ctx).Create(message).Error
+}
+
+func (r *disputeRepository) Transition(ctx context.Context, id string, from []string, to string, updates map[string]interface{}) error {
+	return transitionDispute(r.db.WithContext(ctx), id, from, to, updates)
+}
+
+func (r *disputeRepository) Finish(ctx context.Context, id string, from []string, to string, updates map[string]interface{}, refundAmount int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var dispute model.Dispute
+		if err := tx.Where("id = ?", id).First(&dispute).Error; err != nil {
+			return err
+		}
+		if err := transitionDispute(tx, id, from, to, updates); err != nil {
+			return err
+		}
+		values := map[string]interface{}{"on_hold": false}
+		if refundAmount > 0 {
+			values["amount"] = gorm.Expr("GREATEST(amount - ?, 0)", refundAmount)
+		}
+		return tx.Model(&model.SellerSettlement{}).
+			Where("seller_order_id = ? AND status = ?", dispute.SellerOrderID, "pending").
+			Updates(values).Error
+	})
+}
+
+func transitionDispute(db *gorm.DB, id string, from []string, to string, updates map[string]interface{}) error {
+	values := map[string]interface{}{"status": to}
+	for column, value := range updates {
+		values[column] = value
+	}
+	result := db.Model(&model.Dispute{}).Where("id = ? AND status IN ?", id, from).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidDisputeTransition
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrDisbursementExceedsBalance is returned when a disbursement is larger than what a cause has
+// raised and not yet disbursed
+var ErrDisbursementExceedsBalance = errors.New("disbursement exceeds the cause's undisbursed donations")
+
+type DonationRepository interface {
+	CreateCause(ctx context.Context, cause *model.DonationCause) error
+	UpdateCause(ctx context.Context, cause *model.DonationCause) error
+	// SetActive opens or closes the cause to donations; opening it closes any other
+	SetActive(ctx context.Context, id string, active bool) error
+	FindCauseByID(ctx context.Context, id string) (*model.DonationCause, error)
+	FindActiveCause(ctx context.Context) (*model.DonationCause, error)
+	ListCauses(ctx context.Context, page, limit int) ([]model.DonationCause, int64, error)
+
+	// RecordDonation credits an order's donation to its cause; an order is only credited once.
+	// Returns whether it was credited now.
+	RecordDonation(ctx context.Context, entry *model.DonationLedgerEntry) (bool, error)
+	// RecordDisbursement debits a disbursement from the cause's undisbursed donations
+	RecordDisbursement(ctx context.Context, entry *model.DonationLedgerEntry) error
+	FindEntries(ctx context.Context, causeID string, page, limit int) ([]model.DonationLedgerEntry, int64, error)
+	// Summarize totals each cause's ledger between from and to
+	Summarize(ctx context.Context, from, to time.Time) ([]DonationSummaryRow, error)
+}
+
+// DonationSummaryRow is one cause's ledger totals in a period
+type DonationSummaryRow struct {
+	CauseID      string `json:"cause_id"`
+	CauseName    string `json:"cause_name"`
+	TargetAmount int    `json:"target_amount"`
+	RaisedTotal  int    `json:"raised_total"` // All-time, for progress towards the target
+	Donations    int64  `json:"donations"`    // Donating orders in the period
+	Donors       int64  `json:"donors"`
+	Donated      int64  `json:"donated"`
+	Disbursed    int64  `json:"disbursed"`
+}
+
+type donationRepository struct {
+	db *gorm.DB
+}
+
+func NewDonationRepository(db *gorm.DB) DonationRepository {
+	return &donationRepository{db: db}
+}
+
+func (r *donationRepository) CreateCause(ctx context.Context, cause *model.DonationCause) error {
+	return r.db.WithContext(ctx).Create(cause).Error
+}
+
+func (r *donationRepository) UpdateCause(ctx context.Context, cause *model.DonationCause) error {
+	// The amounts are only changed through the ledger, and is_active through SetActive
+	return r.db.WithContext(ctx).Model(cause).
+		Select("name", "description", "image_url", "target_amount", "ends_at").
+		Updates(cause).Error
+}
+
+func (r *donationRepository) SetActive(ctx context.Context, id string, active bool) error {
+	if !active {
+		return r.db.WithContext(ctx).Model(&model.DonationCause{}).Where("id = ?", id).Update("is_active", false).Error
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.DonationCause{}).
+			Where("is_active = ? AND id <> ?", true, id).
+			Update("is_active", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.DonationCause{}).Where("id = ?", id).Update("is_active", true).Error
+	})
+}
+
+func (r *donationRepository) FindCauseByID(ctx context.Context, id string) (*model.DonationCause, error) {
+	var cause model.DonationCause
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&cause).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cause, nil
+}
+
+func (r *donationRepository) FindActiveCause(ctx context.Context) (*model.DonationCause, error) {
+	var cause model.DonationCause
+	err := r.db.WithContext(ctx).Where("is_active = ?", true).Order("updated_at DESC").First(&cause).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cause, nil
+}
+
+func (r *donationRepository) ListCauses(ctx context.Context, page, limit int) ([]model.DonationCause, int64, error) {
+	var causes []model.DonationCause
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.DonationCause{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("is_active DESC, created_at DESC").Offset(offset).Limit(limit).Find(&causes).Error
+	return causes, total, err
+}
+
+func (r *donationRepository) RecordDonation(ctx context.Context, entry *model.DonationLedgerEntry) (bool, error) {
+	credited := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "order_id"}}, DoNothing: true}).Create(entry)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		credited = true
+		return tx.Model(&model.DonationCause{}).Where("id = ?", entry.CauseID).
+			Update("raised_amount", gorm.Expr("raised_amount + ?", entry.Amount)).Error
+	})
+	return credited, err
+}
+
+func (r *donationRepository) RecordDisbursement(ctx context.Context, entry *model.DonationLedgerEntry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cause model.DonationCause
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", entry.CauseID).
+			First(&cause).Error; err != nil {
+			return err
+		}
+		// Disbursement entries carry a negative amount
+		if cause.DisbursedAmount-entry.Amount > cause.RaisedAmount {
+			return ErrDisbursementExceedsBalance
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return tx.Model(&cause).Update("disbursed_amount", gorm.Expr("disbursed_amount - ?", entry.Amount)).Error
+	})
+}
+
+func (r *donationRepository) FindEntries(ctx context.Context, causeID string, page, limit int) ([]model.DonationLedgerEntry, int64, error) {
+	var entries []model.DonationLedgerEntry
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.DonationLedgerEntry{}).Where("cause_id = ?", causeID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&entries).Error
+	return entries, total, err
+}
+
+func (r *donationRepository) Summarize(ctx context.Context, from, to time.Time) ([]DonationSummaryRow, error) {
+	var rows []DonationSummaryRow
+	err := r.db.WithContext(ctx).Model(&model.DonationLedgerEntry{}).
+		Select("donation_ledger_entries.cause_id, MAX(donation_causes.name) AS cause_name, "+
+			"MAX(donation_causes.target_amount) AS target_amount, MAX(donation_causes.raised_amount) AS raised_total, "+
+			"COUNT(donation_ledger_entries.order_id) AS donations, "+
+			"COUNT(DISTINCT donation_ledger_entries.user_id) AS donors, "+
+			"COALESCE(SUM(CASE WHEN donation_ledger_entries.type = ? THEN donation_ledger_entries.amount ELSE 0 END), 0) AS donated, "+
+			"COALESCE(SUM(CASE WHEN donation_ledger_entries.type = ? THEN -donation_ledger_entries.amount ELSE 0 END), 0) AS disbursed",
+			model.DonationEntryDonation, model.DonationEntryDisbursement).
+		Joins("JOIN donation_causes ON donation_causes.id = donation_ledger_entries.cause_id").
+		Where("donation_ledger_entries.created_at >= ? AND donation_ledger_entries.created_at < ?", from, to).
+		Group("donation_ledger_entries.cause_id").
+		Order("donated DESC").
+		Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// FraudCheckFilter narrows the blocked attempts audit; empty fields match everything
+type FraudCheckFilter struct {
+	Stage  string
+	UserID string
+	RuleID string
+	From   *time.Time
+	To     *time.Time // Exclusive
+}
+
+type FraudRepository interface {
+	CreateRule(ctx context.Context, rule *model.FraudRule) error
+	FindRuleByID(ctx context.Context, id string) (*model.FraudRule, error)
+	UpdateRule(ctx context.Context, rule *model.FraudRule) error
+	DeleteRule(ctx context.Context, id string) error
+	// FindRules lists rules, newest first; activeOnly leaves out disabled rules
+	FindRules(ctx context.Context, activeOnly bool) ([]model.FraudRule, error)
+
+	CreateCheck(ctx context.Context, check *model.FraudCheck) error
+	// BlockCheck marks a recorded check as blocked by the rule
+	BlockCheck(ctx context.Context, id string, rule *model.FraudRule, reason string) error
+	// CountUserChecks counts the buyer's allowed checks at the stage since the given time
+	CountUserChecks(ctx context.Context, stage string, userID string, since time.Time) (int64, error)
+	// CountIPChecks counts allowed checks from the IP at the stage since the given time
+	CountIPChecks(ctx context.Context, stage string, ip string, since time.Time) (int64, error)
+	// FindBlockedChecks lists blocked attempts, newest first
+	FindBlockedChecks(ctx context.Context, filter FraudCheckFilter, page, limit int) ([]model.FraudCheck, int64, error)
+	// DeleteAllowedChecksBefore prunes allowed checks no velocity window can reach any more
+	DeleteAllowedChecksBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type fraudRepository struct {
+	db *gorm.DB
+}
+
+func NewFraudRepository(db *gorm.DB) FraudRepository {
+	return &fraudRepository{db: db}
+}
+
+func (r *fraudRepository) CreateRule(ctx context.Context, rule *model.FraudRule) error {
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+func (r *fraudRepository) FindRuleByID(ctx context.Context, id string) (*model.FraudRule, error) {
+	var rule model.FraudRule
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *fraudRepository) UpdateRule(ctx context.Context, rule *model.FraudRule) error {
+	return r.db.WithContext(ctx).Save(rule).Error
+}
+
+func (r *fraudRepository) DeleteRule(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.FraudRule{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *fraudRepository) FindRules(ctx context.Context, activeOnly bool) ([]model.FraudRule, error) {
+	var rules []model.FraudRule
+	query := r.db.WithContext(ctx)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("created_at DESC").Find(&rules).Error
+	return rules, err
+}
+
+func (r *fraudRepository) CreateCheck(ctx context.Context, check *model.FraudCheck) error {
+	return r.db.WithContext(ctx).Create(check).Error
+}
+
+func (r *fraudRepository) BlockCheck(ctx context.Context, id string, rule *model.FraudRule, reason string) error {
+	return r.db.WithContext(ctx).Model(&model.FraudCheck{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"blocked":   true,
+			"rule_id":   rule.ID,
+			"rule_type": rule.Type,
+			"reason":    reason,
+		}).Error
+}
+
+func (r *fraudRepository) CountUserChecks(ctx context.Context, stage string, userID string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.FraudCheck{}).
+		Where("user_id = ? AND stage = ? AND created_at >= ? AND blocked = ?", userID, stage, since, false).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *fraudRepository) CountIPChecks(ctx context.Context, stage string, ip string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.FraudCheck{}).
+		Where("client_ip = ? AND stage = ? AND created_at >= ? AND blocked = ?", ip, stage, since, false).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *fraudRepository) FindBlockedChecks(ctx context.Context, filter FraudCheckFilter, page, limit int) ([]model.FraudCheck, int64, error) {
+	var checks []model.FraudCheck
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.FraudCheck{}).Where("blocked = ?", true)
+	if filter.Stage != "" {
+		query = query.Where("stage = ?", filter.Stage)
+	}
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.RuleID != "" {
+		query = query.Where("rule_id = ?", filter.RuleID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&checks).Error
+	return checks, total, err
+}
+
+func (r *fraudRepository) DeleteAllowedChecksBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("blocked = ? AND created_at < ?", false, before).
+		Delete(&model.FraudCheck{})
+	return result.RowsAffected, result.Error
+}
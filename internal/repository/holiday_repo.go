@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type HolidayRepository interface {
+	Create(ctx context.Context, holiday *model.Holiday) error
+	FindByID(ctx context.Context, id string) (*model.Holiday, error)
+	FindBetween(ctx context.Context, from, to time.Time) ([]model.Holiday, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type holidayRepository struct {
+	db *gorm.DB
+}
+
+func NewHolidayRepository(db *gorm.DB) HolidayRepository {
+	return &holidayRepository{db: db}
+}
+
+func (r *holidayRepository) Create(ctx context.Context, holiday *model.Holiday) error {
+	return r.db.WithContext(ctx).Create(holiday).Error
+}
+
+func (r *holidayRepository) FindByID(ctx context.Context, id string) (*model.Holiday, error) {
+	var holiday model.Holiday
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&holiday).Error
+	if err != nil {
+		return nil, err
+	}
+	return &holiday, nil
+}
+
+// FindBetween returns holidays with from <= date <= to, ordered by date
+func (r *holidayRepository) FindBetween(ctx context.Context, from, to time.Time) ([]model.Holiday, error) {
+	var holidays []model.Holiday
+	err := r.db.WithContext(ctx).
+		Where("date >= ? AND date <= ?", from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("date ASC").
+		Find(&holidays).Error
+	return holidays, err
+}
+
+func (r *holidayRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&model.Holiday{}, "id = ?", id).Error
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyKeyRepository interface {
+	// Reserve stores a new processing key and reports whether it was created; if the user already
+	// used the key, the existing record is returned instead
+	Reserve(ctx context.Context, key *model.IdempotencyKey) (*model.IdempotencyKey, bool, error)
+	Complete(ctx context.Context, id string, statusCode int, contentType string, body []byte) error
+	Delete(ctx context.Context, id string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type idempotencyKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyKeyRepository(db *gorm.DB) IdempotencyKeyRepository {
+	return &idempotencyKeyRepository{db: db}
+}
+
+func (r *idempotencyKeyRepository) Reserve(ctx context.Context, key *model.IdempotencyKey) (*model.IdempotencyKey, bool, error) {
+	db := r.db.WithContext(ctx)
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "key"}},
+		DoNothing: true,
+	}).Create(key)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return key, true, nil
+	}
+
+	var existing model.IdempotencyKey
+	if err := db.Where("user_id = ? AND key = ?", key.UserID, key.Key).First(&existing).Error; err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+func (r *idempotencyKeyRepository) Complete(ctx context.Context, id string, statusCode int, contentType string, body []byte) error {
+	return r.db.WithContext(ctx).Model(&model.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":        model.IdempotencyStatusCompleted,
+		"status_code":   statusCode,
+		"content_type":  contentType,
+		"response_body": body,
+	}).Error
+}
+
+func (r *idempotencyKeyRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.IdempotencyKey{}).Error
+}
+
+func (r *idempotencyKeyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&model.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
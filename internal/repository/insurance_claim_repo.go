@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidClaimTransition is returned when an insurance claim has already been decided
+var ErrInvalidClaimTransition = errors.New("insurance claim has already been decided")
+
+type InsuranceClaimRepository interface {
+	Create(ctx context.Context, claim *model.InsuranceClaim) error
+	FindByID(ctx context.Context, id string) (*model.InsuranceClaim, error)
+	FindActiveBySellerOrderID(ctx context.Context, sellerOrderID string) (*model.InsuranceClaim, error)
+	FindByUserID(ctx context.Context, userID string, status string, page, limit int) ([]model.InsuranceClaim, int64, error)
+	FindBySellerID(ctx context.Context, sellerID string, status string, page, limit int) ([]model.InsuranceClaim, int64, error)
+	FindAll(ctx context.Context, status string, page, limit int) ([]model.InsuranceClaim, int64, error)
+	// Decide moves a submitted claim to approved or rejected
+	Decide(ctx context.Context, id string, to string, updates map[string]interface{}) error
+	// ApproveToSettlement approves a submitted claim and adds the payout to the seller's settlement
+	// for the sub-order, creating a pending one if the sub-order has none yet. A settlement that was
+	// already paid cannot be added to; the claim is then recorded as a manual payout.
+	ApproveToSettlement(ctx context.Context, id string, updates map[string]interface{}) error
+}
+
+type insuranceClaimRepository struct {
+	db *gorm.DB
+}
+
+func NewInsuranceClaimRepository(db *gorm.DB) InsuranceClaimRepository {
+	return &insuranceClaimRepository{db: db}
+}
+
+func (r *insuranceClaimRepository) Create(ctx context.Context, claim *model.InsuranceClaim) error {
+	return r.db.WithContext(ctx).Create(claim).Error
+}
+
+func (r *insuranceClaimRepository) FindByID(ctx context.Context, id string) (*model.InsuranceClaim, error) {
+	var claim model.InsuranceClaim
+	err := r.db.WithContext(ctx).
+		Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order ASC") }).
+		Preload("SellerOrder").
+		Preload("SellerOrder.OrderItems").
+		Where("id = ?", id).First(&claim).Error
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+func (r *insuranceClaimRepository) FindActiveBySellerOrderID(ctx context.Context, sellerOrderID string) (*model.InsuranceClaim, error) {
+	var claim model.InsuranceClaim
+	err := r.db.WithContext(ctx).
+		Where("seller_order_id = ? AND status IN ?", sellerOrderID, model.InsuranceClaimActiveStatuses).
+		First(&claim).Error
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+func (r *insuranceClaimRepository) FindByUserID(ctx context.Context, userID string, status string, page, limit int) ([]model.InsuranceClaim, int64, error) {
+	return r.list(r.db.WithContext(ctx).Where("user_id = ?", userID), status, page, limit)
+}
+
+func (r *insuranceClaimRepository) FindBySellerID(ctx context.Context, sellerID string, status string, page, limit int) ([]model.InsuranceClaim, int64, error) {
+	return r.list(r.db.WithContext(ctx).Where("seller_id = ?", sellerID), status, page, limit)
+}
+
+func (r *insuranceClaimRepository) FindAll(ctx context.Context, status string, page, limit int) ([]model.InsuranceClaim, int64, error) {
+	return r.list(r.db.WithContext(ctx), status, page, limit)
+}
+
+func (r *insuranceClaimRepository) list(query *gorm.DB, status string, page, limit int) ([]model.InsuranceClaim, int64, error) {
+	var claims []model.InsuranceClaim
+	var total int64
+
+	query = query.Model(&model.InsuranceClaim{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order ASC") }).
+		Preload("SellerOrder").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&claims).Error
+	return claims, total, err
+}
+
+func (r *insuranceClaimRepository) Decide(ctx context.Context, id string, to string, updates map[string]interface{}) error {
+	return decideClaim(r.db.WithContext(ctx), id, to, updates)
+}
+
+func (r *insuranceClaimRepository) ApproveToSettlement(ctx context.Context, id string, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var claim model.InsuranceClaim
+		if err := tx.Where("id = ?", id).First(&claim).Error; err != nil {
+			return err
+		}
+		amount, _ := updates["payout_amount"].(int)
+
+		var settlement model.SellerSettlement
+		err := tx.Where("seller_order_id = ?", claim.SellerOrderID).First(&settlement).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			// A lost shipment is never delivered, so no settlement was created for it
+			settlement = model.SellerSettlement{
+				SellerID:      claim.SellerID,
+				OrderID:       claim.OrderID,
+				SellerOrderID: claim.SellerOrderID,
+				Amount:        amount,
+				Status:        "pending",
+			}
+			if err := tx.Create(&settlement).Error; err != nil {
+				return err
+			}
+			updates["payout_method"] = model.ClaimPayoutSettlement
+		case err != nil:
+			return err
+		case settlement.Status == "pending":
+			if err := tx.Model(&settlement).Update("amount", gorm.Expr("amount + ?", amount)).Error; err != nil {
+				return err
+			}
+			updates["payout_method"] = model.ClaimPayoutSettlement
+		default:
+			updates["payout_method"] = model.RefundMethodManual
+		}
+
+		return decideClaim(tx, id, model.InsuranceClaimApproved, updates)
+	})
+}
+
+func decideClaim(db *gorm.DB, id string, to string, updates map[string]interface{}) error {
+	values := map[string]interface{}{"status": to}
+	for column, value := range updates {
+		values[column] = value
+	}
+	result := db.Model(&model.InsuranceClaim{}).
+		Where("id = ? AND status = ?", id, model.InsuranceClaimSubmitted).
+		Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidClaimTransition
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OrderChatRepository interface {
+	Create(ctx context.Context, message *model.OrderChatMessage) error
+	// CreateSystemMessages adds one message per thread; a thread that already has a message for
+	// the event is skipped, so a status change replayed by a retry is posted once
+	CreateSystemMessages(ctx context.Context, messages []model.OrderChatMessage) error
+	FindBySellerOrderID(ctx context.Context, sellerOrderID string) ([]model.OrderChatMessage, error)
+	// MarkRead records that the role has read the thread up to now
+	MarkRead(ctx context.Context, sellerOrderID string, role string, at time.Time) error
+	// CountUnread returns, per thread, the messages the role has not read (their own excluded)
+	CountUnread(ctx context.Context, sellerOrderIDs []string, role string) (map[string]int64, error)
+}
+
+type orderChatRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderChatRepository(db *gorm.DB) OrderChatRepository {
+	return &orderChatRepository{db: db}
+}
+
+func (r *orderChatRepository) Create(ctx context.Context, message *model.OrderChatMessage) error {
+	return r.db.WithContext(ctx).Create(message).Error
+}
+
+func (r *orderChatRepository) CreateSystemMessages(ctx context.Context, messages []model.OrderChatMessage) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range messages {
+			var count int64
+			if err := tx.Model(&model.OrderChatMessage{}).
+				Where("seller_order_id = ? AND sender_role = ? AND event = ?", messages[i].SellerOrderID, model.OrderChatRoleSystem, messages[i].Event).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+			if err := tx.Create(&messages[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *orderChatRepository) FindBySellerOrderID(ctx context.Context, sellerOrderID string) ([]model.OrderChatMessage, error) {
+	var messages []model.OrderChatMessage
+	err := r.db.WithContext(ctx).Where("seller_order_id = ?", sellerOrderID).Order("created_at ASC").Find(&messages).Error
+	return messages, err
+}
+
+func (r *orderChatRepository) MarkRead(ctx context.Context, sellerOrderID string, role string, at time.Time) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "seller_order_id"}, {Name: "role"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_read_at"}),
+	}).Create(&model.OrderChatRead{SellerOrderID: sellerOrderID, Role: role, LastReadAt: at}).Error
+}
+
+func (r *orderChatRepository) CountUnread(ctx context.Context, sellerOrderIDs []string, role string) (map[string]int64, error) {
+	counts := make(map[string]int64, len(sellerOrderIDs))
+	if len(sellerOrderIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		SellerOrderID string
+		Count         int64
+	}
+	err := r.db.WithContext(ctx).Table("order_chat_messages AS m").
+		Select("m.seller_order_id, COUNT(*) AS count").
+		Joins("LEFT JOIN order_chat_reads AS r ON r.seller_order_id = m.seller_order_id AND r.role = ?", role).
+		Where("m.seller_order_id IN ? AND m.sender_role <> ?", sellerOrderIDs, role).
+		Where("r.last_read_at IS NULL OR m.created_at > r.last_read_at").
+		Group("m.seller_order_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.SellerOrderID] = row.Count
+	}
+	return counts, nil
+}
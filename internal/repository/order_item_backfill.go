@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"fmt"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// BackfillOrderItemSellers fills order_items.seller_id from the product for rows created before
+// it was set. Run it before AutoMigrate: the column is NOT NULL in the model, so it is added as
+// nullable first and AutoMigrate only tightens it once every row has a seller. Safe to run on
+// every start. It returns how many rows were filled.
+func BackfillOrderItemSellers(db *gorm.DB) (int64, error) {
+	if !db.Migrator().HasTable(&model.OrderItem{}) {
+		return 0, nil
+	}
+	if !db.Migrator().HasColumn(&model.OrderItem{}, "seller_id") {
+		if err := db.Exec("ALTER TABLE order_items ADD COLUMN seller_id uuid").Error; err != nil {
+			return 0, fmt.Errorf("failed to add order_items.seller_id: %w", err)
+		}
+	}
+
+	result := db.Exec(`UPDATE order_items SET seller_id = products.seller_id
+		FROM products
+		WHERE order_items.product_id = products.id AND order_items.seller_id IS NULL`)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to backfill order_items.seller_id: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
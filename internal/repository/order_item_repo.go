@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// paidOrderStatuses are the order statuses that count as a sale
+var paidOrderStatuses = []string{"processing", "shipped", "delivered"}
+
+// OrderItemRepository queries order lines by seller, for per-seller sales reporting
+type OrderItemRepository interface {
+	FindBySellerID(ctx context.Context, sellerID string, filter OrderItemFilter, page, limit int) ([]model.OrderItem, int64, error)
+	GetSalesBySellerID(ctx context.Context, sellerID string, from, to *time.Time) (*SellerSales, error)
+}
+
+// OrderItemFilter narrows a seller's order items; zero values are ignored
+type OrderItemFilter struct {
+	Status   string     // Item fulfillment status
+	From     *time.Time // Created at or after
+	To       *time.Time // Created before
+	PaidOnly bool       // Only items of paid, not cancelled orders
+}
+
+// SellerSales are a seller's sales totals over a period. Items of unpaid or cancelled orders and
+// returned items are not counted.
+type SellerSales struct {
+	OrderCount int64          `json:"order_count"`
+	ItemsSold  int64          `json:"items_sold"`
+	Revenue    int64          `json:"revenue"` // Sum of item subtotals, before shipping and fees
+	Products   []ProductSales `json:"products"`
+}
+
+// ProductSales are one product's share of a seller's sales, best sellers first
+type ProductSales struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int64  `json:"quantity"`
+	Revenue     int64  `json:"revenue"`
+}
+
+type orderItemRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderItemRepository(db *gorm.DB) OrderItemRepository {
+	return &orderItemRepository{db: db}
+}
+
+func (r *orderItemRepository) FindBySellerID(ctx context.Context, sellerID string, filter OrderItemFilter, page, limit int) ([]model.OrderItem, int64, error) {
+	var items []model.OrderItem
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.OrderItem{}).Where("order_items.seller_id = ?", sellerID)
+	if filter.Status != "" {
+		query = query.Where("order_items.status = ?", filter.Status)
+	}
+	if filter.From != nil {
+		query = query.Where("order_items.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("order_items.created_at < ?", *filter.To)
+	}
+	if filter.PaidOnly {
+		query = query.Joins("JOIN orders ON orders.id = order_items.order_id").
+			Where("orders.status IN ?", paidOrderStatuses)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Order("order_items.created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&items).Error
+	return items, total, err
+}
+
+func (r *orderItemRepository) GetSalesBySellerID(ctx context.Context, sellerID string, from, to *time.Time) (*SellerSales, error) {
+	sales := &SellerSales{Products: []ProductSales{}}
+	if err := r.salesQuery(ctx, sellerID, from, to).
+		Select("COUNT(DISTINCT order_items.order_id) AS order_count, " +
+			"COALESCE(SUM(order_items.quantity), 0) AS items_sold, " +
+			"COALESCE(SUM(order_items.subtotal), 0) AS revenue").
+		Scan(sales).Error; err != nil {
+		return nil, err
+	}
+
+	err := r.salesQuery(ctx, sellerID, from, to).
+		Select("order_items.product_id, MAX(order_items.product_name) AS product_name, " +
+			"SUM(order_items.quantity) AS quantity, SUM(order_items.subtotal) AS revenue").
+		Group("order_items.product_id").
+		Order("revenue DESC").
+		Scan(&sales.Products).Error
+	if err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
+
+// salesQuery selects the seller's sold items: paid orders only, returned items left out
+func (r *orderItemRepository) salesQuery(ctx context.Context, sellerID string, from, to *time.Time) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&model.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("order_items.seller_id = ?", sellerID).
+		Where("orders.status IN ?", paidOrderStatuses).
+		Where("order_items.status <> ?", model.OrderItemStatusReturned)
+	if from != nil {
+		query = query.Where("order_items.created_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("order_items.created_at < ?", *to)
+	}
+	return query
+}
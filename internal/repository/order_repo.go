@@ -1,18 +1,70 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
 	"yourapp/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrOrderNotPending is returned when an order has already left pending and can no longer be
+// cancelled or marked paid
+var ErrOrderNotPending = errors.New("only pending orders can be cancelled")
+
+// ErrOrderNotShipped is returned when delivery is confirmed for an order that has not shipped
+var ErrOrderNotShipped = errors.New("only shipped orders can be confirmed as delivered")
+
+// ErrOrderNotCompleted is returned when archiving an order that is still in progress
+var ErrOrderNotCompleted = errors.New("only delivered or cancelled orders can be archived")
+
+// ErrInsufficientStock is returned (wrapped with the product name) when stock cannot cover an order
+var ErrInsufficientStock = errors.New("insufficient stock")
+
 type OrderRepository interface {
-	Create(order *model.Order) error
-	FindByID(id string) (*model.Order, error)
-	FindByOrderNumber(orderNumber string) (*model.Order, error)
-	FindByUserID(userID string, page, limit int, status, paymentStatus string) ([]model.Order, int64, error)
-	Update(order *model.Order) error
-	UpdateStatus(orderID string, status string) error
+	Create(ctx context.Context, order *model.Order) error
+	FindByID(ctx context.Context, id string) (*model.Order, error)
+	FindByOrderNumber(ctx context.Context, orderNumber string) (*model.Order, error)
+	FindByUserID(ctx context.Context, userID string, filter UserOrderFilter, page, limit int) ([]model.Order, int64, error)
+	// SetArchived archives (archivedAt set) or unarchives (nil) a delivered or cancelled order
+	SetArchived(ctx context.Context, orderID string, archivedAt *time.Time) error
+	SearchByUserID(ctx context.Context, userID string, keyword string, page, limit int) ([]model.Order, int64, error)
+	FindAll(ctx context.Context, filter OrderFilter, page, limit int) ([]model.Order, int64, error)
+	Update(ctx context.Context, order *model.Order) error
+	UpdateStatus(ctx context.Context, orderID string, from string, status string, change model.StatusChange) error
+	MarkDelivered(ctx context.Context, orderID string, deliveredAt time.Time, onTime *bool, change model.StatusChange) error
+	GetDeliveryStatsBySellerID(ctx context.Context, sellerID string) (*DeliveryStats, error)
+	CancelAndRestoreStock(ctx context.Context, orderID string, reason string, cancelledAt time.Time, change model.StatusChange) error
+	FindUnpaidPendingBefore(ctx context.Context, cutoff time.Time, now time.Time, limit int) ([]model.Order, error)
+	CreateAndReserveStock(ctx context.Context, order *model.Order, cartItemIDs []string) error
+	FindStatusHistory(ctx context.Context, orderID string) ([]model.OrderStatusHistory, error)
+	AddNote(ctx context.Context, note *model.OrderNote) error
+	FindNotes(ctx context.Context, orderID string) ([]model.OrderNote, error)
+}
+
+// UserOrderFilter narrows a buyer's order history; zero values are ignored and unknown statuses
+// are not applied
+type UserOrderFilter struct {
+	Status        string
+	PaymentStatus string
+	Archived      string     // "" or "false" for the main history, "true" for archived orders only, or "all"
+	From          *time.Time // Created at or after
+	To            *time.Time // Created before
+}
+
+// OrderFilter narrows the admin order list; zero values are ignored
+type OrderFilter struct {
+	Status        string
+	PaymentStatus string
+	UserID        string
+	SellerID      string     // Orders containing at least one of the seller's items
+	From          *time.Time // Created at or after
+	To            *time.Time // Created before
+	Search        string     // Order number or buyer email
 }
 
 type orderRepository struct {
@@ -23,17 +75,20 @@ func NewOrderRepository(db *gorm.DB) OrderRepository {
 	return &orderRepository{db: db}
 }
 
-func (r *orderRepository) Create(order *model.Order) error {
-	return r.db.Create(order).Error
+func (r *orderRepository) Create(ctx context.Context, order *model.Order) error {
+	return r.db.WithContext(ctx).Create(order).Error
 }
 
-func (r *orderRepository) FindByID(id string) (*model.Order, error) {
+func (r *orderRepository) FindByID(ctx context.Context, id string) (*model.Order, error) {
 	var order model.Order
-	err := r.db.Preload("User").
+	err := r.db.WithContext(ctx).Preload("User").
 		Preload("ShippingAddress").
 		Preload("OrderItems").
 		Preload("OrderItems.Product").
+		Preload("SellerOrders").
+		Preload("SellerOrders.Seller").
 		Preload("Payment").
+		Preload("TaxLines").
 		Where("id = ?", id).First(&order).Error
 	if err != nil {
 		return nil, err
@@ -41,12 +96,26 @@ func (r *orderRepository) FindByID(id string) (*model.Order, error) {
 	return &order, nil
 }
 
-func (r *orderRepository) FindByOrderNumber(orderNumber string) (*model.Order, error) {
+func (r *orderRepository) SetArchived(ctx context.Context, orderID string, archivedAt *time.Time) error {
+	result := r.db.WithContext(ctx).Model(&model.Order{}).
+		Where("id = ? AND status IN ?", orderID, []string{"delivered", "cancelled"}).
+		Update("archived_at", archivedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOrderNotCompleted
+	}
+	return nil
+}
+
+func (r *orderRepository) FindByOrderNumber(ctx context.Context, orderNumber string) (*model.Order, error) {
 	var order model.Order
-	err := r.db.Preload("User").
+	err := r.db.WithContext(ctx).Preload("User").
 		Preload("ShippingAddress").
 		Preload("OrderItems").
 		Preload("OrderItems.Product").
+		Preload("SellerOrders").
 		Preload("Payment").
 		Where("order_number = ?", orderNumber).First(&order).Error
 	if err != nil {
@@ -55,17 +124,17 @@ func (r *orderRepository) FindByOrderNumber(orderNumber string) (*model.Order, e
 	return &order, nil
 }
 
-func (r *orderRepository) FindByUserID(userID string, page, limit int, status, paymentStatus string) ([]model.Order, int64, error) {
+func (r *orderRepository) FindByUserID(ctx context.Context, userID string, filter UserOrderFilter, page, limit int) ([]model.Order, int64, error) {
 	var orders []model.Order
 	var total int64
 
 	offset := (page - 1) * limit
 
 	// Base query with user_id filter
-	query := r.db.Where("orders.user_id = ?", userID)
+	query := r.db.WithContext(ctx).Where("orders.user_id = ?", userID)
 
 	// Filter by order status if provided
-	if status != "" {
+	if status := filter.Status; status != "" {
 		validStatuses := map[string]bool{
 			"pending":    true,
 			"processing": true,
@@ -79,7 +148,7 @@ func (r *orderRepository) FindByUserID(userID string, page, limit int, status, p
 	}
 
 	// Filter by payment status if provided
-	if paymentStatus != "" {
+	if paymentStatus := filter.PaymentStatus; paymentStatus != "" {
 		validPaymentStatuses := map[string]bool{
 			"pending":   true,
 			"success":   true,
@@ -94,6 +163,22 @@ func (r *orderRepository) FindByUserID(userID string, page, limit int, status, p
 		}
 	}
 
+	if filter.From != nil {
+		query = query.Where("orders.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("orders.created_at < ?", *filter.To)
+	}
+
+	// Archived orders are hidden unless asked for
+	switch filter.Archived {
+	case "true":
+		query = query.Where("orders.archived_at IS NOT NULL")
+	case "all":
+	default:
+		query = query.Where("orders.archived_at IS NULL")
+	}
+
 	// Count total
 	if err := query.Model(&model.Order{}).Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -112,12 +197,429 @@ func (r *orderRepository) FindByUserID(userID string, page, limit int, status, p
 	return orders, total, err
 }
 
-func (r *orderRepository) Update(order *model.Order) error {
-	return r.db.Save(order).Error
+// SearchByUserID finds the user's orders whose order number, product names or seller shop names
+// contain keyword (case-insensitive), newest first. The ILIKE patterns are served by the trigram
+// indexes from EnsureSearchIndexes.
+func (r *orderRepository) SearchByUserID(ctx context.Context, userID string, keyword string, page, limit int) ([]model.Order, int64, error) {
+	var orders []model.Order
+	var total int64
+
+	pattern := "%" + keyword + "%"
+	query := r.db.WithContext(ctx).Model(&model.Order{}).
+		Where("orders.user_id = ?", userID).
+		Where("(orders.order_number ILIKE ? OR "+
+			"EXISTS (SELECT 1 FROM order_items oi WHERE oi.order_id = orders.id AND oi.product_name ILIKE ?) OR "+
+			"EXISTS (SELECT 1 FROM order_items oi JOIN sellers s ON s.id = oi.seller_id WHERE oi.order_id = orders.id AND s.shop_name ILIKE ?))",
+			pattern, pattern, pattern)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("ShippingAddress").
+		Preload("OrderItems").
+		Preload("OrderItems.Product").
+		Preload("Payment").
+		Order("orders.created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&orders).Error
+	return orders, total, err
+}
+
+// FindAll lists orders across all users, newest first
+func (r *orderRepository) FindAll(ctx context.Context, filter OrderFilter, page, limit int) ([]model.Order, int64, error) {
+	var orders []model.Order
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.Order{})
+	if filter.Status != "" {
+		query = query.Where("orders.status = ?", filter.Status)
+	}
+	if filter.PaymentStatus != "" {
+		query = query.Joins("LEFT JOIN payments ON payments.order_uuid = orders.id").
+			Where("payments.status = ?", filter.PaymentStatus)
+	}
+	if filter.UserID != "" {
+		query = query.Where("orders.user_id = ?", filter.UserID)
+	}
+	if filter.SellerID != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM order_items oi WHERE oi.order_id = orders.id AND oi.seller_id = ?)", filter.SellerID)
+	}
+	if filter.From != nil {
+		query = query.Where("orders.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("orders.created_at < ?", *filter.To)
+	}
+	if filter.Search != "" {
+		pattern := "%" + filter.Search + "%"
+		query = query.Where("orders.order_number ILIKE ? OR "+
+			"EXISTS (SELECT 1 FROM users u WHERE u.id = orders.user_id AND u.email ILIKE ?)",
+			pattern, pattern)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("User").
+		Preload("OrderItems").
+		Preload("Payment").
+		Order("orders.created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&orders).Error
+
+	return orders, total, err
+}
+
+func (r *orderRepository) Update(ctx context.Context, order *model.Order) error {
+	return r.db.WithContext(ctx).Save(order).Error
+}
+
+// UpdateStatus sets the order status, carries it over to its seller sub-orders that are not
+// cancelled and records the transition in the status history. A non-empty from is checked
+// against the locked order so a concurrent transition is not overwritten.
+func (r *orderRepository) UpdateStatus(ctx context.Context, orderID string, from string, status string, change model.StatusChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return transitionOrder(tx, orderID, from, status, map[string]interface{}{"status": status}, change)
+	})
 }
 
-func (r *orderRepository) UpdateStatus(orderID string, status string) error {
-	return r.db.Model(&model.Order{}).
+// MarkDelivered finalizes the order as delivered and opens a settlement for every seller that
+// fulfilled part of it
+func (r *orderRepository) MarkDelivered(ctx context.Context, orderID string, deliveredAt time.Time, onTime *bool, change model.StatusChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := transitionOrder(tx, orderID, "", "delivered", map[string]interface{}{
+			"status":            "delivered",
+			"delivered_at":      deliveredAt,
+			"delivered_on_time": onTime,
+		}, change); err != nil {
+			return err
+		}
+		return createSettlements(tx, orderID)
+	})
+}
+
+// createSettlements records what each seller is owed for the order's delivered sub-orders.
+// Sub-orders that already have a settlement are skipped; sub-orders under dispute are held.
+func createSettlements(tx *gorm.DB, orderID string) error {
+	var sellerOrders []model.SellerOrder
+	if err := tx.Where("order_id = ? AND status = ?", orderID, "delivered").Find(&sellerOrders).Error; err != nil {
+		return err
+	}
+	if len(sellerOrders) == 0 {
+		return nil
+	}
+
+	settlements := make([]model.SellerSettlement, 0, len(sellerOrders))
+	for _, sellerOrder := range sellerOrders {
+		settlements = append(settlements, model.SellerSettlement{
+			SellerID:      sellerOrder.SellerID,
+			OrderID:       orderID,
+			SellerOrderID: sellerOrder.ID,
+			Amount:        sellerOrder.TotalAmount,
+			Status:        "pending",
+		})
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "seller_order_id"}},
+		DoNothing: true,
+	}).Create(&settlements).Error; err != nil {
+		return err
+	}
+	// A dispute opened before delivery holds the settlement from the start
+	return tx.Model(&model.SellerSettlement{}).
+		Where("order_id = ? AND seller_order_id IN (?)", orderID,
+			tx.Model(&model.Dispute{}).Select("seller_order_id").Where("order_id = ? AND status IN ?", orderID, model.DisputeOpenStatuses)).
+		Update("on_hold", true).Error
+}
+
+// transitionOrder locks the order, applies updates (which must include the new status), syncs the
+// sub-orders and writes a history row when the status actually changed. When expected is set the
+// order must still be in that status, otherwise nothing is written.
+func transitionOrder(tx *gorm.DB, orderID string, expected string, status string, updates map[string]interface{}, change model.StatusChange) error {
+	var order model.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
 		Where("id = ?", orderID).
+		First(&order).Error; err != nil {
+		return err
+	}
+	if expected != "" && order.Status != expected {
+		if expected == "pending" {
+			return ErrOrderNotPending
+		}
+		return fmt.Errorf("order is %s, expected %s", order.Status, expected)
+	}
+	if err := tx.Model(&order).Updates(updates).Error; err != nil {
+		return err
+	}
+	if err := syncSellerOrderStatus(tx, orderID, status); err != nil {
+		return err
+	}
+	if order.Status == status {
+		return nil
+	}
+	from := order.Status
+	return tx.Create(model.NewStatusHistory(orderID, &from, status, change)).Error
+}
+
+func (r *orderRepository) FindStatusHistory(ctx context.Context, orderID string) ([]model.OrderStatusHistory, error) {
+	var history []model.OrderStatusHistory
+	err := r.db.WithContext(ctx).
+		Where("order_id = ?", orderID).
+		Order("created_at ASC").
+		Find(&history).Error
+	return history, err
+}
+
+func (r *orderRepository) AddNote(ctx context.Context, note *model.OrderNote) error {
+	return r.db.WithContext(ctx).Create(note).Error
+}
+
+func (r *orderRepository) FindNotes(ctx context.Context, orderID string) ([]model.OrderNote, error) {
+	var notes []model.OrderNote
+	err := r.db.WithContext(ctx).
+		Preload("Author").
+		Where("order_id = ?", orderID).
+		Order("created_at ASC").
+		Find(&notes).Error
+	return notes, err
+}
+
+// syncSellerOrderStatus moves an order's sub-orders to the parent's status, leaving cancelled ones
+// alone. A parent moving to processing means it was paid: unpaid sub-orders become paid and wait
+// for their seller to start processing them.
+func syncSellerOrderStatus(tx *gorm.DB, orderID string, status string) error {
+	if status == "processing" {
+		return tx.Model(&model.SellerOrder{}).
+			Where("order_id = ? AND status = ?", orderID, "pending").
+			Update("status", "paid").Error
+	}
+	return tx.Model(&model.SellerOrder{}).
+		Where("order_id = ? AND status <> ?", orderID, "cancelled").
 		Update("status", status).Error
 }
+
+// DeliveryStats counts a seller's delivered orders against the promised delivery range
+type DeliveryStats struct {
+	Delivered int64
+	OnTime    int64
+	Late      int64
+}
+
+// GetDeliveryStatsBySellerID counts delivered orders containing the seller's items that had an ETA
+func (r *orderRepository) GetDeliveryStatsBySellerID(ctx context.Context, sellerID string) (*DeliveryStats, error) {
+	var stats DeliveryStats
+	err := r.db.WithContext(ctx).Model(&model.Order{}).
+		Select("COUNT(*) AS delivered, "+
+			"COUNT(*) FILTER (WHERE delivered_on_time) AS on_time, "+
+			"COUNT(*) FILTER (WHERE NOT delivered_on_time) AS late").
+		Where("delivered_on_time IS NOT NULL").
+		Where("id IN (?)", r.db.Model(&model.OrderItem{}).Select("order_id").Where("seller_id = ?", sellerID)).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// CancelAndRestoreStock cancels a pending order and returns its items to stock in one transaction.
+// The order row is locked so a concurrent payment or cancellation cannot interleave.
+func (r *orderRepository) CancelAndRestoreStock(ctx context.Context, orderID string, reason string, cancelledAt time.Time, change model.StatusChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order model.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", orderID).
+			First(&order).Error; err != nil {
+			return err
+		}
+		if order.Status != "pending" {
+			return ErrOrderNotPending
+		}
+
+		var items []model.OrderItem
+		if err := tx.Where("order_id = ?", orderID).Find(&items).Error; err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := restoreItemStock(tx, item); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&order).Updates(map[string]interface{}{
+			"status":        "cancelled",
+			"cancel_reason": reason,
+			"cancelled_at":  cancelledAt,
+		}).Error; err != nil {
+			return err
+		}
+		if err := syncSellerOrderStatus(tx, orderID, "cancelled"); err != nil {
+			return err
+		}
+		from := "pending"
+		return tx.Create(model.NewStatusHistory(orderID, &from, "cancelled", change)).Error
+	})
+}
+
+// FindUnpaidPendingBefore returns pending orders created before cutoff that are not being paid,
+// oldest first. Orders are skipped while their payment succeeded, awaits verification of an
+// uploaded transfer proof, or can still be paid before its expiry time.
+func (r *orderRepository) FindUnpaidPendingBefore(ctx context.Context, cutoff time.Time, now time.Time, limit int) ([]model.Order, error) {
+	var orders []model.Order
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("OrderItems").
+		Where("orders.status = ? AND orders.created_at < ?", "pending", cutoff).
+		Where(`NOT EXISTS (SELECT 1 FROM payments p WHERE p.order_uuid = orders.id AND (
+			p.status = ? OR
+			(p.status = ? AND (p.proof_uploaded_at IS NOT NULL OR p.expiry_time > ?))))`,
+			model.PaymentStatusSuccess, model.PaymentStatusPending, now).
+		Order("orders.created_at ASC").
+		Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
+
+// CreateAndReserveStock creates the order with its seller sub-orders and items, decrements stock
+// and removes the given cart items in one transaction. Product rows are locked (in ID order, to
+// avoid deadlocks) and stock is re-checked under the lock so concurrent checkouts cannot oversell.
+// Items are linked to the sub-order of their seller.
+func (r *orderRepository) CreateAndReserveStock(ctx context.Context, order *model.Order, cartItemIDs []string) error {
+	quantities := make(map[string]int)
+	variantQuantities := make(map[string]int)
+	for _, item := range order.OrderItems {
+		quantities[item.ProductID] += item.Quantity
+		if item.VariantID != nil {
+			variantQuantities[*item.VariantID] += item.Quantity
+		}
+	}
+	productIDs := make([]string, 0, len(quantities))
+	for productID := range quantities {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Strings(productIDs)
+	variantIDs := make([]string, 0, len(variantQuantities))
+	for variantID := range variantQuantities {
+		variantIDs = append(variantIDs, variantID)
+	}
+	sort.Strings(variantIDs)
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, productID := range productIDs {
+			var product model.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ?", productID).
+				First(&product).Error; err != nil {
+				return fmt.Errorf("product not found: %s", productID)
+			}
+			if !product.IsActive {
+				return fmt.Errorf("product is not active: %s", product.Name)
+			}
+			if product.Stock < quantities[productID] {
+				return fmt.Errorf("%w for product: %s", ErrInsufficientStock, product.Name)
+			}
+			if err := tx.Model(&product).
+				Update("stock", gorm.Expr("stock - ?", quantities[productID])).Error; err != nil {
+				return err
+			}
+		}
+		// Variants are locked after their products, the same order variant writes lock them in
+		for _, variantID := range variantIDs {
+			var variant model.ProductVariant
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ?", variantID).
+				First(&variant).Error; err != nil {
+				return fmt.Errorf("variant not found: %s", variantID)
+			}
+			if !variant.IsActive {
+				return fmt.Errorf("variant is not available: %s", variant.Name)
+			}
+			if variant.Stock < variantQuantities[variantID] {
+				return fmt.Errorf("%w for variant: %s", ErrInsufficientStock, variant.Name)
+			}
+			if err := tx.Model(&variant).
+				Update("stock", gorm.Expr("stock - ?", variantQuantities[variantID])).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Omit(clause.Associations).Create(order).Error; err != nil {
+			return err
+		}
+
+		sellerOrderIDs := make(map[string]string, len(order.SellerOrders))
+		for i := range order.SellerOrders {
+			sellerOrder := &order.SellerOrders[i]
+			sellerOrder.OrderID = order.ID
+			sellerOrder.SubOrderNumber = fmt.Sprintf("%s-%d", order.OrderNumber, i+1)
+			if err := tx.Omit(clause.Associations).Create(sellerOrder).Error; err != nil {
+				return err
+			}
+			sellerOrderIDs[sellerOrder.SellerID] = sellerOrder.ID
+		}
+
+		for i := range order.OrderItems {
+			item := &order.OrderItems[i]
+			item.OrderID = order.ID
+			if sellerOrderID, ok := sellerOrderIDs[item.SellerID]; ok {
+				item.SellerOrderID = &sellerOrderID
+			}
+		}
+		if len(order.OrderItems) > 0 {
+			if err := tx.Omit(clause.Associations).Create(&order.OrderItems).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range order.TaxLines {
+			line := &order.TaxLines[i]
+			line.OrderID = order.ID
+			if sellerOrderID, ok := sellerOrderIDs[line.SellerID]; ok {
+				line.SellerOrderID = &sellerOrderID
+			}
+		}
+		if len(order.TaxLines) > 0 {
+			if err := tx.Create(&order.TaxLines).Error; err != nil {
+				return err
+			}
+		}
+
+		created := model.NewStatusHistory(order.ID, nil, order.Status, model.StatusChange{
+			ActorType: model.StatusActorBuyer,
+			ActorID:   order.UserID,
+		})
+		if err := tx.Create(created).Error; err != nil {
+			return err
+		}
+
+		if len(cartItemIDs) > 0 {
+			if err := tx.Where("id IN ?", cartItemIDs).Delete(&model.CartItem{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// restoreItemStock puts the order item's units back in stock, on its variant too when it has one.
+// A variant removed since is left alone, and so is the product: its stock no longer counts the variant.
+func restoreItemStock(tx *gorm.DB, item model.OrderItem) error {
+	if item.VariantID != nil {
+		result := tx.Model(&model.ProductVariant{}).
+			Where("id = ?", *item.VariantID).
+			Update("stock", gorm.Expr("stock + ?", item.Quantity))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+	}
+	return tx.Model(&model.Product{}).
+		Where("id = ?", item.ProductID).
+		Update("stock", gorm.Expr("stock + ?", item.Quantity)).Error
+}
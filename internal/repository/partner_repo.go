@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNegativeStock is returned when a stock adjustment would take stock below zero
+var ErrNegativeStock = errors.New("adjustment would make stock negative")
+
+type PartnerAPIKeyRepository interface {
+	Create(ctx context.Context, key *model.PartnerAPIKey) error
+	FindByID(ctx context.Context, id string) (*model.PartnerAPIKey, error)
+	FindActiveByHash(ctx context.Context, keyHash string) (*model.PartnerAPIKey, error)
+	FindBySellerID(ctx context.Context, sellerID string) ([]model.PartnerAPIKey, error)
+	Update(ctx context.Context, key *model.PartnerAPIKey) error
+	TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error
+}
+
+type partnerAPIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewPartnerAPIKeyRepository(db *gorm.DB) PartnerAPIKeyRepository {
+	return &partnerAPIKeyRepository{db: db}
+}
+
+func (r *partnerAPIKeyRepository) Create(ctx context.Context, key *model.PartnerAPIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *partnerAPIKeyRepository) FindByID(ctx context.Context, id string) (*model.PartnerAPIKey, error) {
+	var key model.PartnerAPIKey
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *partnerAPIKeyRepository) FindActiveByHash(ctx context.Context, keyHash string) (*model.PartnerAPIKey, error) {
+	var key model.PartnerAPIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ? AND revoked_at IS NULL", keyHash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *partnerAPIKeyRepository) FindBySellerID(ctx context.Context, sellerID string) ([]model.PartnerAPIKey, error) {
+	var keys []model.PartnerAPIKey
+	err := r.db.WithContext(ctx).Where("seller_id = ?", sellerID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *partnerAPIKeyRepository) Update(ctx context.Context, key *model.PartnerAPIKey) error {
+	return r.db.WithContext(ctx).Save(key).Error
+}
+
+func (r *partnerAPIKeyRepository) TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.PartnerAPIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
+
+type InventoryRepository interface {
+	FindProductChangesSince(ctx context.Context, sellerID string, since time.Time, afterID string, limit int) ([]model.Product, error)
+	FindAdjustmentByReference(ctx context.Context, sellerID, reference string) (*model.StockAdjustment, error)
+	ApplyStockAdjustment(ctx context.Context, adjustment *model.StockAdjustment, setStock *int) error
+}
+
+type inventoryRepository struct {
+	db *gorm.DB
+}
+
+func NewInventoryRepository(db *gorm.DB) InventoryRepository {
+	return &inventoryRepository{db: db}
+}
+
+// productChangedAtSQL is when a product last changed, counting soft deletes (GREATEST ignores NULLs)
+const productChangedAtSQL = "GREATEST(updated_at, deleted_at)"
+
+// FindProductChangesSince returns the seller's products (including soft-deleted ones) changed after
+// the (since, afterID) cursor, oldest change first
+func (r *inventoryRepository) FindProductChangesSince(ctx context.Context, sellerID string, since time.Time, afterID string, limit int) ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("seller_id = ?", sellerID).
+		Where("("+productChangedAtSQL+" > ? OR ("+productChangedAtSQL+" = ? AND id > ?))", since, since, afterID).
+		Order(productChangedAtSQL + " ASC").
+		Order("id ASC").
+		Limit(limit).
+		Find(&products).Error
+	return products, err
+}
+
+func (r *inventoryRepository) FindAdjustmentByReference(ctx context.Context, sellerID, reference string) (*model.StockAdjustment, error) {
+	var adjustment model.StockAdjustment
+	err := r.db.WithContext(ctx).Where("seller_id = ? AND reference = ?", sellerID, reference).First(&adjustment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &adjustment, nil
+}
+
+// ApplyStockAdjustment locks the product row, applies either adjustment.Delta or an absolute
+// setStock, and records the adjustment in the same transaction. PreviousStock, NewStock and
+// Delta are filled in on success.
+func (r *inventoryRepository) ApplyStockAdjustment(ctx context.Context, adjustment *model.StockAdjustment, setStock *int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var product model.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", adjustment.ProductID).
+			First(&product).Error; err != nil {
+			return err
+		}
+		var variants int64
+		if err := tx.Model(&model.ProductVariant{}).Where("product_id = ?", product.ID).Count(&variants).Error; err != nil {
+			return err
+		}
+		if variants > 0 {
+			return ErrProductHasVariants
+		}
+
+		newStock := product.Stock + adjustment.Delta
+		if setStock != nil {
+			newStock = *setStock
+		}
+		if newStock < 0 {
+			return ErrNegativeStock
+		}
+
+		adjustment.PreviousStock = product.Stock
+		adjustment.NewStock = newStock
+		adjustment.Delta = newStock - product.Stock
+
+		if err := tx.Model(&product).Update("stock", newStock).Error; err != nil {
+			return err
+		}
+		return tx.Create(adjustment).Error
+	})
+}
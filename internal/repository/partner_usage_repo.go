@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PartnerUsageTotals sums a partner key's usage over a period
+type PartnerUsageTotals struct {
+	Requests int64 `json:"requests"`
+	BytesIn  int64 `json:"bytes_in"`
+	BytesOut int64 `json:"bytes_out"`
+}
+
+// PartnerKeyUsage is one key's usage over a period, with what billing needs to know about the key
+type PartnerKeyUsage struct {
+	APIKeyID  string `json:"api_key_id"`
+	SellerID  string `json:"seller_id"`
+	Name      string `json:"name"`
+	KeyPrefix string `json:"key_prefix"`
+	Plan      string `json:"plan"`
+	Sandbox   bool   `json:"sandbox"`
+	PartnerUsageTotals
+}
+
+type PartnerUsageRepository interface {
+	// AddUsage adds to the key's counters of the day
+	AddUsage(ctx context.Context, keyID string, date time.Time, totals PartnerUsageTotals) error
+	// SumUsage sums the key's counters over [from, to]
+	SumUsage(ctx context.Context, keyID string, from, to time.Time) (*PartnerUsageTotals, error)
+	// FindUsage returns the key's daily counters in [from, to], oldest first; days without
+	// requests have no row
+	FindUsage(ctx context.Context, keyID string, from, to time.Time) ([]model.PartnerAPIUsage, error)
+	// FindUsageByKey sums every key's counters over [from, to]; keys without usage are left out
+	FindUsageByKey(ctx context.Context, from, to time.Time) ([]PartnerKeyUsage, error)
+	// CreateAlert records an alert and reports false when the same alert was already recorded
+	CreateAlert(ctx context.Context, alert *model.PartnerUsageAlert) (bool, error)
+}
+
+type partnerUsageRepository struct {
+	db *gorm.DB
+}
+
+func NewPartnerUsageRepository(db *gorm.DB) PartnerUsageRepository {
+	return &partnerUsageRepository{db: db}
+}
+
+func (r *partnerUsageRepository) AddUsage(ctx context.Context, keyID string, date time.Time, totals PartnerUsageTotals) error {
+	usage := model.PartnerAPIUsage{
+		APIKeyID: keyID,
+		Date:     date,
+		Requests: totals.Requests,
+		BytesIn:  totals.BytesIn,
+		BytesOut: totals.BytesOut,
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "api_key_id"}, {Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"requests":   gorm.Expr("partner_api_usage.requests + ?", totals.Requests),
+			"bytes_in":   gorm.Expr("partner_api_usage.bytes_in + ?", totals.BytesIn),
+			"bytes_out":  gorm.Expr("partner_api_usage.bytes_out + ?", totals.BytesOut),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&usage).Error
+}
+
+func (r *partnerUsageRepository) SumUsage(ctx context.Context, keyID string, from, to time.Time) (*PartnerUsageTotals, error) {
+	var totals PartnerUsageTotals
+	err := r.db.WithContext(ctx).Model(&model.PartnerAPIUsage{}).
+		Select("COALESCE(SUM(requests), 0) AS requests, COALESCE(SUM(bytes_in), 0) AS bytes_in, COALESCE(SUM(bytes_out), 0) AS bytes_out").
+		Where("api_key_id = ? AND date BETWEEN ? AND ?", keyID, from, to).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	return &totals, nil
+}
+
+func (r *partnerUsageRepository) FindUsage(ctx context.Context, keyID string, from, to time.Time) ([]model.PartnerAPIUsage, error) {
+	var usage []model.PartnerAPIUsage
+	err := r.db.WithContext(ctx).
+		Where("api_key_id = ? AND date BETWEEN ? AND ?", keyID, from, to).
+		Order("date ASC").
+		Find(&usage).Error
+	return usage, err
+}
+
+func (r *partnerUsageRepository) FindUsageByKey(ctx context.Context, from, to time.Time) ([]PartnerKeyUsage, error) {
+	var usage []PartnerKeyUsage
+	err := r.db.WithContext(ctx).Table("partner_api_usage u").
+		Select(`k.id AS api_key_id, k.seller_id, k.name, k.key_prefix, k.plan, k.sandbox,
+			SUM(u.requests) AS requests, SUM(u.bytes_in) AS bytes_in, SUM(u.bytes_out) AS bytes_out`).
+		Joins("JOIN partner_api_keys k ON k.id = u.api_key_id").
+		Where("u.date BETWEEN ? AND ?", from, to).
+		Group("k.id, k.seller_id, k.name, k.key_prefix, k.plan, k.sandbox").
+		Order("requests DESC").
+		Scan(&usage).Error
+	return usage, err
+}
+
+func (r *partnerUsageRepository) CreateAlert(ctx context.Context, alert *model.PartnerUsageAlert) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(alert)
+	return result.RowsAffected > 0, result.Error
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"time"
 	"yourapp/internal/model"
 
@@ -8,14 +9,17 @@ import (
 )
 
 type PaymentRepository interface {
-	Create(payment *model.Payment) error
-	FindByID(id string) (*model.Payment, error)
-	FindByOrderID(orderID string) (*model.Payment, error)
-	FindByOrderNumber(orderNumber string) (*model.Payment, error)
-	FindByMidtransTransactionID(transactionID string) (*model.Payment, error)
-	FindPendingPayments() ([]*model.Payment, error) // Get all pending payments for background check
-	Update(payment *model.Payment) error
-	UpdateStatus(paymentID string, status model.PaymentStatus) error
+	Create(ctx context.Context, payment *model.Payment) error
+	FindByID(ctx context.Context, id string) (*model.Payment, error)
+	FindByOrderID(ctx context.Context, orderID string) (*model.Payment, error)
+	FindByOrderNumber(ctx context.Context, orderNumber string) (*model.Payment, error)
+	FindByMidtransTransactionID(ctx context.Context, transactionID string) (*model.Payment, error)
+	FindPendingPayments(ctx context.Context) ([]*model.Payment, error) // Get all pending payments for background check
+	Update(ctx context.Context, payment *model.Payment) error
+	Delete(ctx context.Context, id string) error
+	UpdateStatus(ctx context.Context, paymentID string, status model.PaymentStatus) error
+	CountByUserID(ctx context.Context, userID string, status model.PaymentStatus) (int64, error)
+	CountFraudFlaggedByUserID(ctx context.Context, userID string) (int64, error)
 }
 
 type paymentRepository struct {
@@ -26,13 +30,13 @@ func NewPaymentRepository(db *gorm.DB) PaymentRepository {
 	return &paymentRepository{db: db}
 }
 
-func (r *paymentRepository) Create(payment *model.Payment) error {
-	return r.db.Create(payment).Error
+func (r *paymentRepository) Create(ctx context.Context, payment *model.Payment) error {
+	return r.db.WithContext(ctx).Create(payment).Error
 }
 
-func (r *paymentRepository) FindByID(id string) (*model.Payment, error) {
+func (r *paymentRepository) FindByID(ctx context.Context, id string) (*model.Payment, error) {
 	var payment model.Payment
-	err := r.db.Preload("Order").
+	err := r.db.WithContext(ctx).Preload("Order").
 		Preload("Order.OrderItems").
 		Preload("Order.OrderItems.Product").
 		Where("id = ?", id).First(&payment).Error
@@ -42,9 +46,9 @@ func (r *paymentRepository) FindByID(id string) (*model.Payment, error) {
 	return &payment, nil
 }
 
-func (r *paymentRepository) FindByOrderID(orderID string) (*model.Payment, error) {
+func (r *paymentRepository) FindByOrderID(ctx context.Context, orderID string) (*model.Payment, error) {
 	var payment model.Payment
-	err := r.db.Preload("Order").
+	err := r.db.WithContext(ctx).Preload("Order").
 		Preload("Order.OrderItems").
 		Preload("Order.OrderItems.Product").
 		Where("order_uuid = ?", orderID).First(&payment).Error
@@ -54,9 +58,9 @@ func (r *paymentRepository) FindByOrderID(orderID string) (*model.Payment, error
 	return &payment, nil
 }
 
-func (r *paymentRepository) FindByOrderNumber(orderNumber string) (*model.Payment, error) {
+func (r *paymentRepository) FindByOrderNumber(ctx context.Context, orderNumber string) (*model.Payment, error) {
 	var payment model.Payment
-	err := r.db.Preload("Order").
+	err := r.db.WithContext(ctx).Preload("Order").
 		Preload("Order.OrderItems").
 		Preload("Order.OrderItems.Product").
 		Where("order_id = ?", orderNumber).First(&payment).Error
@@ -66,9 +70,9 @@ func (r *paymentRepository) FindByOrderNumber(orderNumber string) (*model.Paymen
 	return &payment, nil
 }
 
-func (r *paymentRepository) FindByMidtransTransactionID(transactionID string) (*model.Payment, error) {
+func (r *paymentRepository) FindByMidtransTransactionID(ctx context.Context, transactionID string) (*model.Payment, error) {
 	var payment model.Payment
-	err := r.db.Preload("Order").
+	err := r.db.WithContext(ctx).Preload("Order").
 		Preload("Order.OrderItems").
 		Preload("Order.OrderItems.Product").
 		Where("midtrans_transaction_id = ?", transactionID).First(&payment).Error
@@ -78,11 +82,11 @@ func (r *paymentRepository) FindByMidtransTransactionID(transactionID string) (*
 	return &payment, nil
 }
 
-func (r *paymentRepository) FindPendingPayments() ([]*model.Payment, error) {
+func (r *paymentRepository) FindPendingPayments(ctx context.Context) ([]*model.Payment, error) {
 	var payments []*model.Payment
 	// Get all pending payments created in last 48 hours
 	// We'll filter by transaction ID in Go code for reliability
-	err := r.db.Where("status = ?", model.PaymentStatusPending).
+	err := r.db.WithContext(ctx).Where("status = ?", model.PaymentStatusPending).
 		Where("created_at > ?", time.Now().Add(-48*time.Hour)). // Check payments created in last 48 hours
 		Find(&payments).Error
 	if err != nil {
@@ -100,12 +104,36 @@ func (r *paymentRepository) FindPendingPayments() ([]*model.Payment, error) {
 	return validPayments, nil
 }
 
-func (r *paymentRepository) Update(payment *model.Payment) error {
-	return r.db.Save(payment).Error
+func (r *paymentRepository) Update(ctx context.Context, payment *model.Payment) error {
+	return r.db.WithContext(ctx).Save(payment).Error
 }
 
-func (r *paymentRepository) UpdateStatus(paymentID string, status model.PaymentStatus) error {
-	return r.db.Model(&model.Payment{}).
+func (r *paymentRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&model.Payment{}, "id = ?", id).Error
+}
+
+func (r *paymentRepository) UpdateStatus(ctx context.Context, paymentID string, status model.PaymentStatus) error {
+	return r.db.WithContext(ctx).Model(&model.Payment{}).
 		Where("id = ?", paymentID).
 		Update("status", status).Error
 }
+
+// CountByUserID counts payments with the given status across all orders of a user
+func (r *paymentRepository) CountByUserID(ctx context.Context, userID string, status model.PaymentStatus) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Payment{}).
+		Joins("JOIN orders ON orders.id = payments.order_uuid").
+		Where("orders.user_id = ? AND payments.status = ?", userID, status).
+		Count(&count).Error
+	return count, err
+}
+
+// CountFraudFlaggedByUserID counts payments of a user that Midtrans flagged as deny or challenge
+func (r *paymentRepository) CountFraudFlaggedByUserID(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Payment{}).
+		Joins("JOIN orders ON orders.id = payments.order_uuid").
+		Where("orders.user_id = ? AND payments.fraud_status IN ?", userID, []string{"deny", "challenge"}).
+		Count(&count).Error
+	return count, err
+}
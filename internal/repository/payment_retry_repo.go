@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type PaymentStatusRetryRepository interface {
+	Create(ctx context.Context, retry *model.PaymentStatusRetry) error
+	FindDue(ctx context.Context, now time.Time, limit int) ([]model.PaymentStatusRetry, error)
+	Update(ctx context.Context, retry *model.PaymentStatusRetry) error
+	Delete(ctx context.Context, id string) error
+}
+
+type paymentStatusRetryRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentStatusRetryRepository(db *gorm.DB) PaymentStatusRetryRepository {
+	return &paymentStatusRetryRepository{db: db}
+}
+
+func (r *paymentStatusRetryRepository) Create(ctx context.Context, retry *model.PaymentStatusRetry) error {
+	return r.db.WithContext(ctx).Create(retry).Error
+}
+
+// FindDue returns live retries whose next attempt is due, oldest report first so updates for the
+// same payment are replayed in the order the gateway sent them
+func (r *paymentStatusRetryRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]model.PaymentStatusRetry, error) {
+	var retries []model.PaymentStatusRetry
+	err := r.db.WithContext(ctx).
+		Where("dead_at IS NULL AND next_attempt_at <= ?", now).
+		Order("reported_at ASC").
+		Limit(limit).
+		Find(&retries).Error
+	return retries, err
+}
+
+func (r *paymentStatusRetryRepository) Update(ctx context.Context, retry *model.PaymentStatusRetry) error {
+	return r.db.WithContext(ctx).Save(retry).Error
+}
+
+func (r *paymentStatusRetryRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.PaymentStatusRetry{}).Error
+}
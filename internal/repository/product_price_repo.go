@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPriceScheduleNotPending is returned when cancelling a price schedule that already applied or was cancelled
+var ErrPriceScheduleNotPending = errors.New("price schedule is no longer pending")
+
+type ProductPriceRepository interface {
+	CreateSchedule(ctx context.Context, schedule *model.ProductPriceSchedule) error
+	FindScheduleByID(ctx context.Context, id string) (*model.ProductPriceSchedule, error)
+	// FindSchedulesByProductID lists a product's schedules, upcoming first; status "" lists all
+	FindSchedulesByProductID(ctx context.Context, productID string, status string) ([]model.ProductPriceSchedule, error)
+	CancelSchedule(ctx context.Context, id string) error
+	// FindDueSchedules returns pending schedules whose effective time has passed, earliest first
+	FindDueSchedules(ctx context.Context, now time.Time, limit int) ([]model.ProductPriceSchedule, error)
+	// ApplySchedule sets the product's price, records the history entry and marks the schedule
+	// applied in one transaction
+	ApplySchedule(ctx context.Context, id string, appliedAt time.Time) (*model.ProductPriceHistory, error)
+	RecordHistory(ctx context.Context, history *model.ProductPriceHistory) error
+	FindHistory(ctx context.Context, productID string, limit int) ([]model.ProductPriceHistory, error)
+	// FindScheduledChangeSince returns the newest scheduled change of the product from oldPrice made
+	// after since
+	FindScheduledChangeSince(ctx context.Context, productID string, oldPrice int, since time.Time) (*model.ProductPriceHistory, error)
+}
+
+type productPriceRepository struct {
+	db *gorm.DB
+}
+
+func NewProductPriceRepository(db *gorm.DB) ProductPriceRepository {
+	return &productPriceRepository{db: db}
+}
+
+func (r *productPriceRepository) CreateSchedule(ctx context.Context, schedule *model.ProductPriceSchedule) error {
+	return r.db.WithContext(ctx).Create(schedule).Error
+}
+
+func (r *productPriceRepository) FindScheduleByID(ctx context.Context, id string) (*model.ProductPriceSchedule, error) {
+	var schedule model.ProductPriceSchedule
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&schedule).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *productPriceRepository) FindSchedulesByProductID(ctx context.Context, productID string, status string) ([]model.ProductPriceSchedule, error) {
+	var schedules []model.ProductPriceSchedule
+	query := r.db.WithContext(ctx).Where("product_id = ?", productID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("effective_at DESC").Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *productPriceRepository) CancelSchedule(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Model(&model.ProductPriceSchedule{}).
+		Where("id = ? AND status = ?", id, model.PriceScheduleScheduled).
+		Update("status", model.PriceScheduleCancelled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPriceScheduleNotPending
+	}
+	return nil
+}
+
+func (r *productPriceRepository) FindDueSchedules(ctx context.Context, now time.Time, limit int) ([]model.ProductPriceSchedule, error) {
+	var schedules []model.ProductPriceSchedule
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND effective_at <= ?", model.PriceScheduleScheduled, now).
+		Order("effective_at ASC").
+		Limit(limit).
+		Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *productPriceRepository) ApplySchedule(ctx context.Context, id string, appliedAt time.Time) (*model.ProductPriceHistory, error) {
+	var history *model.ProductPriceHistory
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var schedule model.ProductPriceSchedule
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND status = ?", id, model.PriceScheduleScheduled).
+			First(&schedule).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPriceScheduleNotPending
+			}
+			return err
+		}
+		var product model.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", schedule.ProductID).
+			First(&product).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// The product was deleted; its pending price change goes with it
+				return tx.Model(&schedule).Update("status", model.PriceScheduleCancelled).Error
+			}
+			return err
+		}
+
+		if product.Price != schedule.Price {
+			if err := tx.Model(&product).Update("price", schedule.Price).Error; err != nil {
+				return err
+			}
+			history = &model.ProductPriceHistory{
+				ProductID:  product.ID,
+				OldPrice:   product.Price,
+				NewPrice:   schedule.Price,
+				Source:     model.PriceChangeScheduled,
+				ScheduleID: &schedule.ID,
+				ChangedAt:  appliedAt,
+			}
+			if err := tx.Create(history).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&schedule).Updates(map[string]interface{}{
+			"status":     model.PriceScheduleApplied,
+			"applied_at": appliedAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (r *productPriceRepository) RecordHistory(ctx context.Context, history *model.ProductPriceHistory) error {
+	return r.db.WithContext(ctx).Create(history).Error
+}
+
+func (r *productPriceRepository) FindHistory(ctx context.Context, productID string, limit int) ([]model.ProductPriceHistory, error) {
+	var history []model.ProductPriceHistory
+	err := r.db.WithContext(ctx).
+		Where("product_id = ?", productID).
+		Order("changed_at DESC").
+		Limit(limit).
+		Find(&history).Error
+	return history, err
+}
+
+func (r *productPriceRepository) FindScheduledChangeSince(ctx context.Context, productID string, oldPrice int, since time.Time) (*model.ProductPriceHistory, error) {
+	var history model.ProductPriceHistory
+	err := r.db.WithContext(ctx).
+		Where("product_id = ? AND source = ? AND old_price = ? AND changed_at > ?", productID, model.PriceChangeScheduled, oldPrice, since).
+		Order("changed_at DESC").
+		First(&history).Error
+	if err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
@@ -1,8 +1,7 @@
 package repository
 
 import (
-	"fmt"
-	"strings"
+	"time"
 	"yourapp/internal/model"
 
 	"gorm.io/gorm"
@@ -12,13 +11,84 @@ type ProductRepository interface {
 	Create(product *model.Product) error
 	FindByID(id string) (*model.Product, error)
 	FindBySKU(sku string) (*model.Product, error)
-	FindAll(page, limit int, categoryID *string, featured *bool, activeOnly bool) ([]model.Product, int64, error)
-	Search(page, limit int, keyword string, activeOnly bool) ([]model.Product, int64, error)
+	// FindAll lists products, newest first unless the filter sorts them otherwise
+	FindAll(page, limit int, filter ProductListFilter) ([]model.Product, int64, error)
+	// Search ranks products matching keyword, best match first unless the filter sorts them otherwise
+	Search(page, limit int, keyword string, filter ProductListFilter) ([]model.Product, int64, error)
+	SearchBySellerID(sellerID string, page, limit int, keyword string, categoryID *string) ([]model.Product, int64, error)
 	Update(product *model.Product) error
 	Delete(id string) error
 	CreateImage(image *model.ProductImage) error
 	DeleteImage(id string) error
 	FindImagesByProductID(productID string) ([]model.ProductImage, error)
+	FindByIDsAndSellerID(ids []string, sellerID string) ([]model.Product, error)
+	UpdateByIDs(ids []string, updates map[string]interface{}) error
+	FindScheduledBySellerID(sellerID string) ([]model.Product, error)
+	ApplyScheduledPublish(now time.Time) (int64, error)
+	ApplyScheduledUnpublish(now time.Time) (int64, error)
+	FindStocks(ids []string) (map[string]int, error)
+	// AddSoldCounts adds the units (negative to take them off) to the products' sold counts
+	AddSoldCounts(units map[string]int) error
+	FindBySellerID(sellerID string, page, limit int) ([]model.Product, int64, error)
+	// CountListedBySellerID counts the seller's active and scheduled-to-publish products, leaving
+	// out excludeIDs
+	CountListedBySellerID(sellerID string, excludeIDs []string) (int64, error)
+}
+
+// Product list sort orders
+const (
+	ProductSortPriceAsc    = "price_asc"
+	ProductSortPriceDesc   = "price_desc"
+	ProductSortNewest      = "newest"
+	ProductSortBestSelling = "best_selling" // Units sold
+	ProductSortRating      = "rating"       // The shop's rating; products have no reviews of their own
+)
+
+// productSortOrders maps each sort to its ORDER BY. The rating sort uses a subquery rather than a
+// join so the product columns stay unambiguous.
+var productSortOrders = map[string]string{
+	ProductSortPriceAsc:    "products.price ASC, products.created_at DESC",
+	ProductSortPriceDesc:   "products.price DESC, products.created_at DESC",
+	ProductSortNewest:      "products.created_at DESC",
+	ProductSortBestSelling: "products.sold_count DESC, products.created_at DESC",
+	ProductSortRating: "(SELECT sellers.rating_average FROM sellers WHERE sellers.id = products.seller_id) DESC NULLS LAST, " +
+		"products.sold_count DESC, products.created_at DESC",
+}
+
+// IsProductSort reports whether sort is a supported product list sort
+func IsProductSort(sort string) bool {
+	_, ok := productSortOrders[sort]
+	return ok
+}
+
+// ProductListFilter narrows and orders a product list or search
+type ProductListFilter struct {
+	CategoryID *string
+	Featured   *bool
+	ActiveOnly bool
+	MinPrice   *int // Inclusive
+	MaxPrice   *int // Inclusive
+	Sort       string
+}
+
+// applyProductListFilter adds the filter's conditions to a product query
+func applyProductListFilter(query *gorm.DB, filter ProductListFilter) *gorm.DB {
+	if filter.CategoryID != nil {
+		query = query.Where("products.category_id = ?", *filter.CategoryID)
+	}
+	if filter.Featured != nil {
+		query = query.Where("products.is_featured = ?", *filter.Featured)
+	}
+	if filter.ActiveOnly {
+		query = query.Where("products.is_active = ?", true)
+	}
+	if filter.MinPrice != nil {
+		query = query.Where("products.price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where("products.price <= ?", *filter.MaxPrice)
+	}
+	return query
 }
 
 type productRepository struct {
@@ -35,8 +105,10 @@ func (r *productRepository) Create(product *model.Product) error {
 
 func (r *productRepository) FindByID(id string) (*model.Product, error) {
 	var product model.Product
-	err := r.db.Preload("Seller").Preload("Category").Preload("ProductImages", func(db *gorm.DB) *gorm.DB {
+	err := r.db.Preload("Seller").Preload("Category").Preload("Tags").Preload("ProductImages", func(db *gorm.DB) *gorm.DB {
 		return db.Order("sort_order ASC")
+	}).Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC, created_at ASC")
 	}).Where("id = ?", id).First(&product).Error
 	if err != nil {
 		return nil, err
@@ -53,53 +125,72 @@ func (r *productRepository) FindBySKU(sku string) (*model.Product, error) {
 	return &product, nil
 }
 
-func (r *productRepository) FindAll(page, limit int, categoryID *string, featured *bool, activeOnly bool) ([]model.Product, int64, error) {
+func (r *productRepository) FindAll(page, limit int, filter ProductListFilter) ([]model.Product, int64, error) {
 	var products []model.Product
 	var total int64
 
 	query := r.db.Model(&model.Product{}).Preload("Category").Preload("ProductImages", func(db *gorm.DB) *gorm.DB {
 		return db.Order("sort_order ASC")
 	})
+	query = applyProductListFilter(query, filter)
 
-	if categoryID != nil {
-		query = query.Where("category_id = ?", *categoryID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
 
-	if featured != nil {
-		query = query.Where("is_featured = ?", *featured)
+	order, ok := productSortOrders[filter.Sort]
+	if !ok {
+		order = productSortOrders[ProductSortNewest]
 	}
+	offset := (page - 1) * limit
+	err := query.Order(order).Limit(limit).Offset(offset).Find(&products).Error
+	return products, total, err
+}
 
-	if activeOnly {
-		query = query.Where("is_active = ?", true)
-	}
+func (r *productRepository) Search(page, limit int, keyword string, filter ProductListFilter) ([]model.Product, int64, error) {
+	var products []model.Product
+	var total int64
+
+	query := r.db.Model(&model.Product{}).Preload("Category").Preload("ProductImages", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC")
+	})
+	query = applyKeywordSearch(query, keyword)
+	query = applyProductListFilter(query, filter)
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	offset := (page - 1) * limit
-	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&products).Error
+	if order, ok := productSortOrders[filter.Sort]; ok {
+		query = query.Order(order)
+	} else {
+		query = selectKeywordRank(query, keyword).Order("search_rank DESC")
+	}
+	err := query.
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&products).Error
+
 	return products, total, err
 }
 
-func (r *productRepository) Search(page, limit int, keyword string, activeOnly bool) ([]model.Product, int64, error) {
+// SearchBySellerID lists a seller's active products for their storefront, optionally narrowed by
+// keyword (matched like Search) and category
+func (r *productRepository) SearchBySellerID(sellerID string, page, limit int, keyword string, categoryID *string) ([]model.Product, int64, error) {
 	var products []model.Product
 	var total int64
 
 	query := r.db.Model(&model.Product{}).Preload("Category").Preload("ProductImages", func(db *gorm.DB) *gorm.DB {
 		return db.Order("sort_order ASC")
-	})
+	}).Where("seller_id = ? AND is_active = ?", sellerID, true)
 
-	// Search in name, description, and SKU using ILIKE for case-insensitive search (PostgreSQL)
-	// ILIKE is PostgreSQL specific for case-insensitive search
-	searchPattern := "%" + keyword + "%"
-	query = query.Where(
-		"(LOWER(name) LIKE LOWER(?) OR LOWER(description) LIKE LOWER(?) OR LOWER(sku) LIKE LOWER(?))",
-		searchPattern, searchPattern, searchPattern,
-	)
-
-	if activeOnly {
-		query = query.Where("is_active = ?", true)
+	if keyword != "" {
+		query = applyKeywordSearch(query, keyword)
+	}
+	if categoryID != nil {
+		query = query.Where("category_id = ?", *categoryID)
 	}
 
 	if err := query.Count(&total).Error; err != nil {
@@ -107,15 +198,10 @@ func (r *productRepository) Search(page, limit int, keyword string, activeOnly b
 	}
 
 	offset := (page - 1) * limit
-	// Order by relevance: exact match in name first, then SKU, then description
-	// Build CASE expression with keyword directly in SQL (safe as it's already validated)
-	exactKeywordLower := strings.ToLower(keyword)
-	exactKeywordPattern := "%" + exactKeywordLower + "%"
-	orderSQL := fmt.Sprintf("CASE WHEN LOWER(name) LIKE LOWER('%s') THEN 1 WHEN LOWER(sku) LIKE LOWER('%s') THEN 2 ELSE 3 END",
-		strings.ReplaceAll(exactKeywordPattern, "'", "''"),
-		strings.ReplaceAll(exactKeywordPattern, "'", "''"))
+	if keyword != "" {
+		query = selectKeywordRank(query, keyword).Order("search_rank DESC")
+	}
 	err := query.
-		Order(orderSQL).
 		Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -124,6 +210,27 @@ func (r *productRepository) Search(page, limit int, keyword string, activeOnly b
 	return products, total, err
 }
 
+// applyKeywordSearch matches keyword against name, description, and SKU: whole words through the
+// full-text document, and partial or misspelled names and SKUs through the trigram indexes (e.g.
+// "sepat" or "sepatuu" still find "Sepatu Lari")
+func applyKeywordSearch(query *gorm.DB, keyword string) *gorm.DB {
+	searchPattern := "%" + keyword + "%"
+	return query.Where(
+		"("+productSearchDocument+" @@ plainto_tsquery('simple', ?) OR name ILIKE ? OR sku ILIKE ? OR name % ?)",
+		keyword, searchPattern, searchPattern, keyword,
+	)
+}
+
+// selectKeywordRank adds the search_rank column to order keyword matches by: how often the words
+// occur, plus how close the name is to the keyword, with a bonus for a name starting with it
+func selectKeywordRank(query *gorm.DB, keyword string) *gorm.DB {
+	return query.Select(
+		"products.*, ts_rank("+productSearchDocument+", plainto_tsquery('simple', ?)) + similarity(name, ?) + "+
+			"CASE WHEN name ILIKE ? THEN 1 ELSE 0 END AS search_rank",
+		keyword, keyword, keyword+"%",
+	)
+}
+
 func (r *productRepository) Update(product *model.Product) error {
 	return r.db.Save(product).Error
 }
@@ -145,3 +252,93 @@ func (r *productRepository) FindImagesByProductID(productID string) ([]model.Pro
 	err := r.db.Where("product_id = ?", productID).Order("sort_order ASC").Find(&images).Error
 	return images, err
 }
+
+func (r *productRepository) FindByIDsAndSellerID(ids []string, sellerID string) ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.Where("id IN ? AND seller_id = ?", ids, sellerID).Find(&products).Error
+	return products, err
+}
+
+func (r *productRepository) UpdateByIDs(ids []string, updates map[string]interface{}) error {
+	return r.db.Model(&model.Product{}).Where("id IN ?", ids).Updates(updates).Error
+}
+
+// FindScheduledBySellerID returns the seller's products that have a pending publish or unpublish
+func (r *productRepository) FindScheduledBySellerID(sellerID string) ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.Where("seller_id = ? AND (scheduled_publish_at IS NOT NULL OR scheduled_unpublish_at IS NOT NULL)", sellerID).
+		Find(&products).Error
+	return products, err
+}
+
+// ApplyScheduledPublish activates products whose publish time has passed and clears the schedule
+func (r *productRepository) ApplyScheduledPublish(now time.Time) (int64, error) {
+	result := r.db.Model(&model.Product{}).
+		Where("scheduled_publish_at IS NOT NULL AND scheduled_publish_at <= ?", now).
+		Updates(map[string]interface{}{"is_active": true, "scheduled_publish_at": nil})
+	return result.RowsAffected, result.Error
+}
+
+// ApplyScheduledUnpublish deactivates products whose unpublish time has passed and clears the schedule
+func (r *productRepository) ApplyScheduledUnpublish(now time.Time) (int64, error) {
+	result := r.db.Model(&model.Product{}).
+		Where("scheduled_unpublish_at IS NOT NULL AND scheduled_unpublish_at <= ?", now).
+		Updates(map[string]interface{}{"is_active": false, "scheduled_unpublish_at": nil})
+	return result.RowsAffected, result.Error
+}
+
+func (r *productRepository) AddSoldCounts(units map[string]int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for productID, delta := range units {
+			if err := tx.Model(&model.Product{}).Where("id = ?", productID).
+				UpdateColumn("sold_count", gorm.Expr("GREATEST(sold_count + ?, 0)", delta)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindStocks returns the current stock of the given products, keyed by product ID
+func (r *productRepository) FindStocks(ids []string) (map[string]int, error) {
+	var rows []struct {
+		ID    string
+		Stock int
+	}
+	if err := r.db.Model(&model.Product{}).Select("id, stock").Where("id IN ?", ids).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	stocks := make(map[string]int, len(rows))
+	for _, row := range rows {
+		stocks[row.ID] = row.Stock
+	}
+	return stocks, nil
+}
+
+// FindBySellerID lists all of a seller's products, drafts included, newest first
+func (r *productRepository) FindBySellerID(sellerID string, page, limit int) ([]model.Product, int64, error) {
+	var products []model.Product
+	var total int64
+
+	query := r.db.Model(&model.Product{}).Where("seller_id = ?", sellerID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("Category").Preload("ProductImages", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC")
+	}).Order("created_at DESC").Limit(limit).Offset(offset).Find(&products).Error
+	return products, total, err
+}
+
+func (r *productRepository) CountListedBySellerID(sellerID string, excludeIDs []string) (int64, error) {
+	var count int64
+	query := r.db.Model(&model.Product{}).
+		Where("seller_id = ? AND (is_active = ? OR scheduled_publish_at IS NOT NULL)", sellerID, true)
+	if len(excludeIDs) > 0 {
+		query = query.Where("id NOT IN ?", excludeIDs)
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"fmt"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// BackfillProductSoldCounts adds products.sold_count and fills it from the paid orders placed so
+// far. Run it before AutoMigrate; it only does work the first time, when the column is missing,
+// since the count is kept up to date from then on. It returns how many products were filled.
+func BackfillProductSoldCounts(db *gorm.DB) (int64, error) {
+	if !db.Migrator().HasTable(&model.Product{}) || db.Migrator().HasColumn(&model.Product{}, "sold_count") {
+		return 0, nil
+	}
+
+	var filled int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("ALTER TABLE products ADD COLUMN sold_count integer NOT NULL DEFAULT 0").Error; err != nil {
+			return fmt.Errorf("failed to add products.sold_count: %w", err)
+		}
+		result := tx.Exec(`UPDATE products SET sold_count = sold.units
+			FROM (SELECT order_items.product_id, SUM(order_items.quantity) AS units
+				FROM order_items JOIN orders ON orders.id = order_items.order_id
+				WHERE orders.status IN ?
+				GROUP BY order_items.product_id) AS sold
+			WHERE products.id = sold.product_id`, paidOrderStatuses)
+		if result.Error != nil {
+			return fmt.Errorf("failed to backfill products.sold_count: %w", result.Error)
+		}
+		filled = result.RowsAffected
+		return nil
+	})
+	return filled, err
+}
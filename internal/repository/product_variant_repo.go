@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrProductHasVariants is returned when setting the stock of a product sold in variants; its stock
+// is kept as the sum of its variants' and is changed through them
+var ErrProductHasVariants = errors.New("product has variants; adjust the stock of its variants instead")
+
+// ProductVariantRepository stores product variants. Every change to a variant's stock is applied to
+// its product's stock in the same transaction, so the product's stock stays the sum of its
+// variants'. Each write returns how much the product's stock changed.
+type ProductVariantRepository interface {
+	// Create adds the variant. The first variant of a product replaces the product's own stock.
+	Create(ctx context.Context, variant *model.ProductVariant) (int, error)
+	FindByID(ctx context.Context, id string) (*model.ProductVariant, error)
+	FindBySKU(ctx context.Context, sku string) (*model.ProductVariant, error)
+	FindByProductID(ctx context.Context, productID string, activeOnly bool) ([]model.ProductVariant, error)
+	Update(ctx context.Context, variant *model.ProductVariant) (int, error)
+	// Delete removes the variant and takes its stock off the product
+	Delete(ctx context.Context, id string) (int, error)
+}
+
+type productVariantRepository struct {
+	db *gorm.DB
+}
+
+func NewProductVariantRepository(db *gorm.DB) ProductVariantRepository {
+	return &productVariantRepository{db: db}
+}
+
+func (r *productVariantRepository) Create(ctx context.Context, variant *model.ProductVariant) (int, error) {
+	delta := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var product model.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", variant.ProductID).
+			First(&product).Error; err != nil {
+			return err
+		}
+		var existing int64
+		if err := tx.Model(&model.ProductVariant{}).Where("product_id = ?", product.ID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(variant).Error; err != nil {
+			return err
+		}
+
+		delta = variant.Stock
+		if existing == 0 {
+			delta = variant.Stock - product.Stock
+		}
+		return tx.Model(&product).Update("stock", gorm.Expr("stock + ?", delta)).Error
+	})
+	return delta, err
+}
+
+func (r *productVariantRepository) FindByID(ctx context.Context, id string) (*model.ProductVariant, error) {
+	var variant model.ProductVariant
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&variant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func (r *productVariantRepository) FindBySKU(ctx context.Context, sku string) (*model.ProductVariant, error) {
+	var variant model.ProductVariant
+	err := r.db.WithContext(ctx).Where("sku = ?", sku).First(&variant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func (r *productVariantRepository) FindByProductID(ctx context.Context, productID string, activeOnly bool) ([]model.ProductVariant, error) {
+	var variants []model.ProductVariant
+	query := r.db.WithContext(ctx).Where("product_id = ?", productID)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("sort_order ASC, created_at ASC").Find(&variants).Error
+	return variants, err
+}
+
+func (r *productVariantRepository) Update(ctx context.Context, variant *model.ProductVariant) (int, error) {
+	delta := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockProduct(tx, variant.ProductID); err != nil {
+			return err
+		}
+		var current model.ProductVariant
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", variant.ID).
+			First(&current).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(variant).Error; err != nil {
+			return err
+		}
+
+		// Measured against the locked row, so the product's stock stays the sum of its variants'
+		// even if units were sold since the variant was read
+		delta = variant.Stock - current.Stock
+		if delta == 0 {
+			return nil
+		}
+		return tx.Model(&model.Product{}).Where("id = ?", variant.ProductID).
+			Update("stock", gorm.Expr("stock + ?", delta)).Error
+	})
+	return delta, err
+}
+
+func (r *productVariantRepository) Delete(ctx context.Context, id string) (int, error) {
+	delta := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var variant model.ProductVariant
+		if err := tx.Where("id = ?", id).First(&variant).Error; err != nil {
+			return err
+		}
+		if err := lockProduct(tx, variant.ProductID); err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", id).
+			First(&variant).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&variant).Error; err != nil {
+			return err
+		}
+
+		delta = -variant.Stock
+		return tx.Model(&model.Product{}).Where("id = ?", variant.ProductID).
+			Update("stock", gorm.Expr("GREATEST(stock + ?, 0)", delta)).Error
+	})
+	return delta, err
+}
+
+// lockProduct locks the product row. Variant writes lock the product before the variant, the same
+// order checkout reserves stock in, so the two cannot deadlock.
+func lockProduct(tx *gorm.DB, productID string) error {
+	var product model.Product
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", productID).
+		First(&product).Error
+}
@@ -0,0 +1,293 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// errReferenceDryRun rolls back a dry-run import after it has been counted
+var errReferenceDryRun = errors.New("dry run")
+
+// ReferenceDataRepository reads and writes the marketplace's reference data (categories, tags, the
+// holidays calendar and regions) by natural key, so it can be moved between environments whose IDs differ
+type ReferenceDataRepository interface {
+	Export(ctx context.Context) (*ReferenceData, error)
+	// Import creates or updates every record in one transaction; nothing is deleted. A dry run
+	// reports what would change and rolls back.
+	Import(ctx context.Context, data *ReferenceData, dryRun bool) (*ReferenceImportResult, error)
+}
+
+// ReferenceData is reference data keyed by slug, tag name and date instead of IDs
+type ReferenceData struct {
+	Categories []ReferenceCategory `json:"categories"` // Parents before children
+	Tags       []string            `json:"tags"`
+	Holidays   []ReferenceHoliday  `json:"holidays"`
+	Regions    []ReferenceRegion   `json:"regions"`
+}
+
+type ReferenceCategory struct {
+	Slug        string  `json:"slug"`
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+	ImageURL    *string `json:"image_url,omitempty"`
+	ParentSlug  *string `json:"parent_slug,omitempty"`
+	IsActive    bool    `json:"is_active"`
+}
+
+type ReferenceHoliday struct {
+	Date string `json:"date"` // YYYY-MM-DD
+	Name string `json:"name"`
+}
+
+type ReferenceRegion struct {
+	Province       string `json:"province"`
+	City           string `json:"city"`
+	PostalCodeFrom string `json:"postal_code_from"`
+	PostalCodeTo   string `json:"postal_code_to"`
+}
+
+// ReferenceImportResult counts what an import changed (or would change, for a dry run)
+type ReferenceImportResult struct {
+	CategoriesCreated int `json:"categories_created"`
+	CategoriesUpdated int `json:"categories_updated"`
+	TagsCreated       int `json:"tags_created"`
+	HolidaysCreated   int `json:"holidays_created"`
+	HolidaysUpdated   int `json:"holidays_updated"`
+	RegionsCreated    int `json:"regions_created"`
+	RegionsUpdated    int `json:"regions_updated"`
+}
+
+type referenceDataRepository struct {
+	db *gorm.DB
+}
+
+func NewReferenceDataRepository(db *gorm.DB) ReferenceDataRepository {
+	return &referenceDataRepository{db: db}
+}
+
+func (r *referenceDataRepository) Export(ctx context.Context) (*ReferenceData, error) {
+	db := r.db.WithContext(ctx)
+	data := &ReferenceData{
+		Categories: []ReferenceCategory{},
+		Tags:       []string{},
+		Holidays:   []ReferenceHoliday{},
+		Regions:    []ReferenceRegion{},
+	}
+
+	var categories []model.Category
+	if err := db.Order("created_at ASC").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]model.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+	// Emit parents first so an import can resolve every parent_slug in one pass
+	emitted := make(map[string]bool, len(categories))
+	var emit func(category model.Category)
+	emit = func(category model.Category) {
+		if emitted[category.ID] {
+			return
+		}
+		emitted[category.ID] = true
+		var parentSlug *string
+		if category.ParentID != nil {
+			if parent, ok := byID[*category.ParentID]; ok {
+				emit(parent)
+				parentSlug = &parent.Slug
+			}
+		}
+		data.Categories = append(data.Categories, ReferenceCategory{
+			Slug:        category.Slug,
+			Name:        category.Name,
+			Description: category.Description,
+			ImageURL:    category.ImageURL,
+			ParentSlug:  parentSlug,
+			IsActive:    category.IsActive,
+		})
+	}
+	for _, category := range categories {
+		emit(category)
+	}
+
+	if err := db.Model(&model.Tag{}).Order("name ASC").Pluck("name", &data.Tags).Error; err != nil {
+		return nil, err
+	}
+
+	var holidays []model.Holiday
+	if err := db.Order("date ASC").Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	for _, holiday := range holidays {
+		data.Holidays = append(data.Holidays, ReferenceHoliday{Date: holiday.Date.Format("2006-01-02"), Name: holiday.Name})
+	}
+
+	var regions []model.Region
+	if err := db.Order("province ASC, city ASC, postal_code_from ASC").Find(&regions).Error; err != nil {
+		return nil, err
+	}
+	for _, region := range regions {
+		data.Regions = append(data.Regions, ReferenceRegion{
+			Province:       region.Province,
+			City:           region.City,
+			PostalCodeFrom: region.PostalCodeFrom,
+			PostalCodeTo:   region.PostalCodeTo,
+		})
+	}
+	return data, nil
+}
+
+func (r *referenceDataRepository) Import(ctx context.Context, data *ReferenceData, dryRun bool) (*ReferenceImportResult, error) {
+	result := &ReferenceImportResult{}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := importCategories(tx, data.Categories, result); err != nil {
+			return err
+		}
+		if err := importTags(tx, data.Tags, result); err != nil {
+			return err
+		}
+		if err := importHolidays(tx, data.Holidays, result); err != nil {
+			return err
+		}
+		if err := importRegions(tx, data.Regions, result); err != nil {
+			return err
+		}
+		if dryRun {
+			return errReferenceDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errReferenceDryRun) {
+		return nil, err
+	}
+	return result, nil
+}
+
+// importCategories upserts categories by slug, restoring deleted ones, and links parents by slug
+func importCategories(tx *gorm.DB, categories []ReferenceCategory, result *ReferenceImportResult) error {
+	ids := make(map[string]string, len(categories))
+	for _, item := range categories {
+		var category model.Category
+		err := tx.Unscoped().Where("slug = ?", item.Slug).First(&category).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			category = model.Category{
+				Name:        item.Name,
+				Slug:        item.Slug,
+				Description: item.Description,
+				ImageURL:    item.ImageURL,
+				IsActive:    item.IsActive,
+			}
+			if err := tx.Create(&category).Error; err != nil {
+				return err
+			}
+			// gorm skips zero values on create, so an inactive category needs an explicit update
+			if !item.IsActive {
+				if err := tx.Model(&category).Update("is_active", false).Error; err != nil {
+					return err
+				}
+			}
+			result.CategoriesCreated++
+		case err != nil:
+			return err
+		default:
+			if err := tx.Unscoped().Model(&category).Updates(map[string]interface{}{
+				"name":        item.Name,
+				"description": item.Description,
+				"image_url":   item.ImageURL,
+				"is_active":   item.IsActive,
+				"deleted_at":  nil,
+			}).Error; err != nil {
+				return err
+			}
+			result.CategoriesUpdated++
+		}
+		ids[item.Slug] = category.ID
+	}
+
+	for _, item := range categories {
+		var parentID *string
+		if item.ParentSlug != nil {
+			id, ok := ids[*item.ParentSlug]
+			if !ok {
+				var parent model.Category
+				if err := tx.Where("slug = ?", *item.ParentSlug).First(&parent).Error; err != nil {
+					return errors.New("parent category not found: " + *item.ParentSlug)
+				}
+				id = parent.ID
+			}
+			parentID = &id
+		}
+		if err := tx.Model(&model.Category{}).Where("id = ?", ids[item.Slug]).Update("parent_id", parentID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func importTags(tx *gorm.DB, names []string, result *ReferenceImportResult) error {
+	for _, name := range names {
+		var tag model.Tag
+		err := tx.Where("name = ?", name).First(&tag).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := tx.Create(&model.Tag{Name: name}).Error; err != nil {
+				return err
+			}
+			result.TagsCreated++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func importHolidays(tx *gorm.DB, holidays []ReferenceHoliday, result *ReferenceImportResult) error {
+	for _, item := range holidays {
+		date, err := time.Parse("2006-01-02", item.Date)
+		if err != nil {
+			return errors.New("invalid holiday date: " + item.Date)
+		}
+		var holiday model.Holiday
+		err = tx.Where("date = ?", date).First(&holiday).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Create(&model.Holiday{Date: date, Name: item.Name}).Error; err != nil {
+				return err
+			}
+			result.HolidaysCreated++
+		case err != nil:
+			return err
+		case holiday.Name != item.Name:
+			if err := tx.Model(&holiday).Update("name", item.Name).Error; err != nil {
+				return err
+			}
+			result.HolidaysUpdated++
+		}
+	}
+	return nil
+}
+
+func importRegions(tx *gorm.DB, items []ReferenceRegion, result *ReferenceImportResult) error {
+	regions := make([]model.Region, 0, len(items))
+	for _, item := range items {
+		regions = append(regions, model.Region{
+			Province:       item.Province,
+			City:           item.City,
+			PostalCodeFrom: item.PostalCodeFrom,
+			PostalCodeTo:   item.PostalCodeTo,
+		})
+	}
+	created, updated, err := upsertRegions(tx, regions)
+	if err != nil {
+		return err
+	}
+	result.RegionsCreated += created
+	result.RegionsUpdated += updated
+	return nil
+}
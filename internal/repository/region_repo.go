@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidRegion is returned when a region to import lacks a name or has a bad postal code range
+var ErrInvalidRegion = errors.New("invalid region")
+
+type RegionRepository interface {
+	FindAll(ctx context.Context) ([]model.Region, error)
+	// Upsert creates or updates regions by province, city and first postal code in one
+	// transaction, and returns how many were created and updated
+	Upsert(ctx context.Context, regions []model.Region) (int, int, error)
+}
+
+type regionRepository struct {
+	db *gorm.DB
+}
+
+func NewRegionRepository(db *gorm.DB) RegionRepository {
+	return &regionRepository{db: db}
+}
+
+func (r *regionRepository) FindAll(ctx context.Context) ([]model.Region, error) {
+	var regions []model.Region
+	err := r.db.WithContext(ctx).Order("province ASC, city ASC, postal_code_from ASC").Find(&regions).Error
+	return regions, err
+}
+
+func (r *regionRepository) Upsert(ctx context.Context, regions []model.Region) (int, int, error) {
+	var created, updated int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		created, updated, err = upsertRegions(tx, regions)
+		return err
+	})
+	return created, updated, err
+}
+
+// upsertRegions creates or updates regions by their natural key; nothing is deleted
+func upsertRegions(tx *gorm.DB, regions []model.Region) (int, int, error) {
+	var created, updated int
+	for _, item := range regions {
+		item.Province = strings.TrimSpace(item.Province)
+		item.City = strings.TrimSpace(item.City)
+		if err := validateRegion(item); err != nil {
+			return 0, 0, err
+		}
+		var region model.Region
+		err := tx.Where("province = ? AND city = ? AND postal_code_from = ?", item.Province, item.City, item.PostalCodeFrom).
+			First(&region).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			region = model.Region{
+				Province:       item.Province,
+				City:           item.City,
+				PostalCodeFrom: item.PostalCodeFrom,
+				PostalCodeTo:   item.PostalCodeTo,
+			}
+			if err := tx.Create(&region).Error; err != nil {
+				return 0, 0, err
+			}
+			created++
+		case err != nil:
+			return 0, 0, err
+		case region.PostalCodeTo != item.PostalCodeTo:
+			if err := tx.Model(&region).Update("postal_code_to", item.PostalCodeTo).Error; err != nil {
+				return 0, 0, err
+			}
+			updated++
+		}
+	}
+	return created, updated, nil
+}
+
+func validateRegion(region model.Region) error {
+	if region.Province == "" || region.City == "" {
+		return fmt.Errorf("%w: province and city are required", ErrInvalidRegion)
+	}
+	if !isPostalCode(region.PostalCodeFrom) || !isPostalCode(region.PostalCodeTo) {
+		return fmt.Errorf("%w: postal codes of %s must be 5 digits", ErrInvalidRegion, region.City)
+	}
+	if region.PostalCodeFrom > region.PostalCodeTo {
+		return fmt.Errorf("%w: postal code range of %s is reversed", ErrInvalidRegion, region.City)
+	}
+	return nil
+}
+
+func isPostalCode(code string) bool {
+	if len(code) != 5 {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ReportRepository runs the queries behind the built-in scheduled reports
+type ReportRepository interface {
+	// DailySales sums paid item sales per shop between from and to, returned items left out
+	DailySales(ctx context.Context, from, to time.Time) ([]SalesReportRow, error)
+	// PendingSettlements lists settlements not paid out yet, oldest first
+	PendingSettlements(ctx context.Context) ([]PendingSettlementRow, error)
+	// FailedWebhookDeliveries lists seller webhook deliveries given up on between from and to
+	FailedWebhookDeliveries(ctx context.Context, from, to time.Time) ([]FailedWebhookDeliveryRow, error)
+}
+
+// SalesReportRow is one shop's sales in a period
+type SalesReportRow struct {
+	SellerID  string
+	ShopName  string
+	Orders    int64
+	ItemsSold int64
+	Revenue   int64
+}
+
+// PendingSettlementRow is a settlement waiting to be paid out
+type PendingSettlementRow struct {
+	SettlementID   string
+	SellerID       string
+	ShopName       string
+	SubOrderNumber string
+	Amount         int
+	OnHold         bool
+	CreatedAt      time.Time
+}
+
+// FailedWebhookDeliveryRow is a seller webhook delivery that ran out of attempts
+type FailedWebhookDeliveryRow struct {
+	DeliveryID     string
+	SellerID       string
+	ShopName       string
+	URL            string
+	Event          string
+	Attempts       int
+	ResponseStatus *int
+	LastError      *string
+	CreatedAt      time.Time
+	FailedAt       time.Time
+}
+
+type reportRepository struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+func (r *reportRepository) DailySales(ctx context.Context, from, to time.Time) ([]SalesReportRow, error) {
+	var rows []SalesReportRow
+	err := r.db.WithContext(ctx).Model(&model.OrderItem{}).
+		Select("order_items.seller_id, MAX(sellers.shop_name) AS shop_name, "+
+			"COUNT(DISTINCT order_items.order_id) AS orders, "+
+			"COALESCE(SUM(order_items.quantity), 0) AS items_sold, "+
+			"COALESCE(SUM(order_items.subtotal), 0) AS revenue").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Joins("LEFT JOIN sellers ON sellers.id = order_items.seller_id").
+		Where("orders.status IN ?", paidOrderStatuses).
+		Where("order_items.status <> ?", model.OrderItemStatusReturned).
+		Where("order_items.created_at >= ? AND order_items.created_at < ?", from, to).
+		Group("order_items.seller_id").
+		Order("revenue DESC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *reportRepository) PendingSettlements(ctx context.Context) ([]PendingSettlementRow, error) {
+	var rows []PendingSettlementRow
+	err := r.db.WithContext(ctx).Model(&model.SellerSettlement{}).
+		Select("seller_settlements.id AS settlement_id, seller_settlements.seller_id, sellers.shop_name, "+
+			"seller_orders.sub_order_number, seller_settlements.amount, seller_settlements.on_hold, seller_settlements.created_at").
+		Joins("LEFT JOIN sellers ON sellers.id = seller_settlements.seller_id").
+		Joins("LEFT JOIN seller_orders ON seller_orders.id = seller_settlements.seller_order_id").
+		Where("seller_settlements.status = ?", "pending").
+		Order("seller_settlements.created_at ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *reportRepository) FailedWebhookDeliveries(ctx context.Context, from, to time.Time) ([]FailedWebhookDeliveryRow, error) {
+	var rows []FailedWebhookDeliveryRow
+	err := r.db.WithContext(ctx).Model(&model.SellerWebhookDelivery{}).
+		Select("seller_webhook_deliveries.id AS delivery_id, seller_webhook_deliveries.seller_id, sellers.shop_name, "+
+			"seller_webhooks.url, seller_webhook_deliveries.event, seller_webhook_deliveries.attempts, "+
+			"seller_webhook_deliveries.response_status, seller_webhook_deliveries.last_error, "+
+			"seller_webhook_deliveries.created_at, seller_webhook_deliveries.updated_at AS failed_at").
+		Joins("LEFT JOIN seller_webhooks ON seller_webhooks.id = seller_webhook_deliveries.webhook_id").
+		Joins("LEFT JOIN sellers ON sellers.id = seller_webhook_deliveries.seller_id").
+		Where("seller_webhook_deliveries.status = ?", model.WebhookDeliveryFailed).
+		Where("seller_webhook_deliveries.updated_at >= ? AND seller_webhook_deliveries.updated_at < ?", from, to).
+		Order("seller_webhook_deliveries.updated_at ASC").
+		Scan(&rows).Error
+	return rows, err
+}
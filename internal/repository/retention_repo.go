@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RetentionTarget describes rows that expire: rows of Table whose TimeColumn is before the cutoff
+// and that match Filter. Expired rows are deleted, or when ClearColumn is set only that column
+// is overwritten with ClearValue (used for raw payload blobs on rows that must be kept).
+type RetentionTarget struct {
+	Table       string
+	TimeColumn  string
+	Filter      string // Extra SQL condition, may be empty
+	ClearColumn string
+	ClearValue  interface{}
+}
+
+type RetentionRepository interface {
+	// PurgeBatch purges up to limit expired rows of the target in one transaction. archive, when
+	// not nil, receives the rows (all columns) before they are purged; an archive error rolls the
+	// batch back. Returns how many rows were purged.
+	PurgeBatch(ctx context.Context, target RetentionTarget, cutoff time.Time, limit int, archive func(rows []map[string]interface{}) error) (int64, error)
+}
+
+type retentionRepository struct {
+	db *gorm.DB
+}
+
+func NewRetentionRepository(db *gorm.DB) RetentionRepository {
+	return &retentionRepository{db: db}
+}
+
+func (r *retentionRepository) PurgeBatch(ctx context.Context, target RetentionTarget, cutoff time.Time, limit int, archive func(rows []map[string]interface{}) error) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Table(target.Table).Where(fmt.Sprintf("%s < ?", target.TimeColumn), cutoff)
+		if target.Filter != "" {
+			query = query.Where(target.Filter)
+		}
+
+		// Skip rows another writer holds so the purge never waits on live traffic
+		var rows []map[string]interface{}
+		if err := query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Order(target.TimeColumn).Limit(limit).Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		ids := make([]interface{}, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row["id"])
+		}
+		if archive != nil {
+			if err := archive(rows); err != nil {
+				return err
+			}
+		}
+
+		var result *gorm.DB
+		if target.ClearColumn != "" {
+			result = tx.Table(target.Table).Where("id IN ?", ids).Update(target.ClearColumn, target.ClearValue)
+		} else {
+			result = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE id IN ?", target.Table), ids)
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		purged = result.RowsAffected
+		return nil
+	})
+	return purged, err
+}
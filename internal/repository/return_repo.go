@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidReturnTransition is returned when a return request is not in the status the change requires
+var ErrInvalidReturnTransition = errors.New("invalid return status transition")
+
+type ReturnRequestRepository interface {
+	Create(ctx context.Context, returnRequest *model.ReturnRequest) error
+	FindByID(ctx context.Context, id string) (*model.ReturnRequest, error)
+	FindBySellerOrderID(ctx context.Context, sellerOrderID string) (*model.ReturnRequest, error)
+	FindByUserID(ctx context.Context, userID string, status string, page, limit int) ([]model.ReturnRequest, int64, error)
+	FindBySellerID(ctx context.Context, sellerID string, status string, page, limit int) ([]model.ReturnRequest, int64, error)
+	Transition(ctx context.Context, id string, from, to string, updates map[string]interface{}) error
+	MarkRefunded(ctx context.Context, id string, method string, refundedAt time.Time) error
+}
+
+type returnRequestRepository struct {
+	db *gorm.DB
+}
+
+func NewReturnRequestRepository(db *gorm.DB) ReturnRequestRepository {
+	return &returnRequestRepository{db: db}
+}
+
+// Create saves the return request together with its photos
+func (r *returnRequestRepository) Create(ctx context.Context, returnRequest *model.ReturnRequest) error {
+	return r.db.WithContext(ctx).Create(returnRequest).Error
+}
+
+func (r *returnRequestRepository) FindByID(ctx context.Context, id string) (*model.ReturnRequest, error) {
+	var returnRequest model.ReturnRequest
+	err := r.db.WithContext(ctx).
+		Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order ASC") }).
+		Preload("SellerOrder").
+		Preload("SellerOrder.OrderItems").
+		Where("id = ?", id).First(&returnRequest).Error
+	if err != nil {
+		return nil, err
+	}
+	return &returnRequest, nil
+}
+
+func (r *returnRequestRepository) FindBySellerOrderID(ctx context.Context, sellerOrderID string) (*model.ReturnRequest, error) {
+	var returnRequest model.ReturnRequest
+	if err := r.db.WithContext(ctx).Where("seller_order_id = ?", sellerOrderID).First(&returnRequest).Error; err != nil {
+		return nil, err
+	}
+	return &returnRequest, nil
+}
+
+func (r *returnRequestRepository) FindByUserID(ctx context.Context, userID string, status string, page, limit int) ([]model.ReturnRequest, int64, error) {
+	return r.list(ctx, "user_id = ?", userID, status, page, limit)
+}
+
+func (r *returnRequestRepository) FindBySellerID(ctx context.Context, sellerID string, status string, page, limit int) ([]model.ReturnRequest, int64, error) {
+	return r.list(ctx, "seller_id = ?", sellerID, status, page, limit)
+}
+
+func (r *returnRequestRepository) list(ctx context.Context, owner string, ownerID string, status string, page, limit int) ([]model.ReturnRequest, int64, error) {
+	var returnRequests []model.ReturnRequest
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.ReturnRequest{}).Where(owner, ownerID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order ASC") }).
+		Preload("SellerOrder").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&returnRequests).Error
+	return returnRequests, total, err
+}
+
+// Transition moves a return request from one status to another, applying updates alongside.
+// It fails with ErrInvalidReturnTransition if the request is no longer in the from status.
+func (r *returnRequestRepository) Transition(ctx context.Context, id string, from, to string, updates map[string]interface{}) error {
+	return transitionReturn(r.db.WithContext(ctx), id, from, to, updates)
+}
+
+// MarkRefunded completes a received return and takes the refund off the seller's pending
+// settlement for the sub-order. Settlements that were already paid out are left alone.
+func (r *returnRequestRepository) MarkRefunded(ctx context.Context, id string, method string, refundedAt time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var returnRequest model.ReturnRequest
+		if err := tx.Where("id = ?", id).First(&returnRequest).Error; err != nil {
+			return err
+		}
+		if err := transitionReturn(tx, id, model.ReturnStatusItemReceived, model.ReturnStatusRefunded, map[string]interface{}{
+			"refund_method": method,
+			"refunded_at":   refundedAt,
+		}); err != nil {
+			return err
+		}
+		return tx.Model(&model.SellerSettlement{}).
+			Where("seller_order_id = ? AND status = ?", returnRequest.SellerOrderID, "pending").
+			Update("amount", gorm.Expr("GREATEST(amount - ?, 0)", returnRequest.RefundAmount)).Error
+	})
+}
+
+func transitionReturn(db *gorm.DB, id string, from, to string, updates map[string]interface{}) error {
+	values := map[string]interface{}{"status": to}
+	for column, value := range updates {
+		values[column] = value
+	}
+	result := db.Model(&model.ReturnRequest{}).Where("id = ? AND status = ?", id, from).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidReturnTransition
+	}
+	return nil
+}
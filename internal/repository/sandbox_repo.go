@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSandboxInsufficientStock is returned when a sandbox order asks for more than a product has
+var ErrSandboxInsufficientStock = errors.New("insufficient stock")
+
+type SandboxRepository interface {
+	CreateProducts(ctx context.Context, products []model.SandboxProduct) error
+	// Reset deletes every sandbox record of the API key
+	Reset(ctx context.Context, apiKeyID string) error
+	FindProductBySKU(ctx context.Context, apiKeyID, sku string) (*model.SandboxProduct, error)
+	FindProductChangesSince(ctx context.Context, apiKeyID string, since time.Time, afterID string, limit int) ([]model.SandboxProduct, error)
+	FindAdjustmentByReference(ctx context.Context, apiKeyID, reference string) (*model.SandboxStockAdjustment, error)
+	ApplyStockAdjustment(ctx context.Context, adjustment *model.SandboxStockAdjustment, setStock *int) error
+	// CreateOrder takes the ordered quantities off the products' stock and saves the order
+	CreateOrder(ctx context.Context, order *model.SandboxOrder) error
+	FindOrderByID(ctx context.Context, apiKeyID, id string) (*model.SandboxOrder, error)
+	FindOrders(ctx context.Context, apiKeyID string, page, limit int) ([]model.SandboxOrder, int64, error)
+	// UpdateOrder saves the order, putting its quantities back in stock when restock is set
+	UpdateOrder(ctx context.Context, order *model.SandboxOrder, restock bool) error
+	CreateNotification(ctx context.Context, notification *model.SandboxNotification) error
+	FindNotifications(ctx context.Context, apiKeyID string, limit int) ([]model.SandboxNotification, error)
+}
+
+type sandboxRepository struct {
+	db *gorm.DB
+}
+
+func NewSandboxRepository(db *gorm.DB) SandboxRepository {
+	return &sandboxRepository{db: db}
+}
+
+func (r *sandboxRepository) CreateProducts(ctx context.Context, products []model.SandboxProduct) error {
+	return r.db.WithContext(ctx).Create(&products).Error
+}
+
+func (r *sandboxRepository) Reset(ctx context.Context, apiKeyID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("sandbox_order_id IN (?)", tx.Model(&model.SandboxOrder{}).Select("id").Where("api_key_id = ?", apiKeyID)).
+			Delete(&model.SandboxOrderItem{}).Error; err != nil {
+			return err
+		}
+		for _, table := range []interface{}{&model.SandboxOrder{}, &model.SandboxNotification{}, &model.SandboxStockAdjustment{}, &model.SandboxProduct{}} {
+			if err := tx.Where("api_key_id = ?", apiKeyID).Delete(table).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *sandboxRepository) FindProductBySKU(ctx context.Context, apiKeyID, sku string) (*model.SandboxProduct, error) {
+	var product model.SandboxProduct
+	err := r.db.WithContext(ctx).Where("api_key_id = ? AND sku = ?", apiKeyID, sku).First(&product).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// FindProductChangesSince returns the key's products changed after the (since, afterID) cursor,
+// oldest change first
+func (r *sandboxRepository) FindProductChangesSince(ctx context.Context, apiKeyID string, since time.Time, afterID string, limit int) ([]model.SandboxProduct, error) {
+	var products []model.SandboxProduct
+	err := r.db.WithContext(ctx).
+		Where("api_key_id = ?", apiKeyID).
+		Where("(updated_at > ? OR (updated_at = ? AND id > ?))", since, since, afterID).
+		Order("updated_at ASC").
+		Order("id ASC").
+		Limit(limit).
+		Find(&products).Error
+	return products, err
+}
+
+func (r *sandboxRepository) FindAdjustmentByReference(ctx context.Context, apiKeyID, reference string) (*model.SandboxStockAdjustment, error) {
+	var adjustment model.SandboxStockAdjustment
+	err := r.db.WithContext(ctx).Where("api_key_id = ? AND reference = ?", apiKeyID, reference).First(&adjustment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &adjustment, nil
+}
+
+// ApplyStockAdjustment works like InventoryRepository.ApplyStockAdjustment on a sandbox product
+func (r *sandboxRepository) ApplyStockAdjustment(ctx context.Context, adjustment *model.SandboxStockAdjustment, setStock *int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var product model.SandboxProduct
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", adjustment.ProductID).
+			First(&product).Error; err != nil {
+			return err
+		}
+
+		newStock := product.Stock + adjustment.Delta
+		if setStock != nil {
+			newStock = *setStock
+		}
+		if newStock < 0 {
+			return ErrNegativeStock
+		}
+
+		adjustment.PreviousStock = product.Stock
+		adjustment.NewStock = newStock
+		adjustment.Delta = newStock - product.Stock
+
+		if err := tx.Model(&product).Update("stock", newStock).Error; err != nil {
+			return err
+		}
+		return tx.Create(adjustment).Error
+	})
+}
+
+func (r *sandboxRepository) CreateOrder(ctx context.Context, order *model.SandboxOrder) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, item := range order.Items {
+			result := tx.Model(&model.SandboxProduct{}).
+				Where("id = ? AND stock >= ?", item.ProductID, item.Quantity).
+				Update("stock", gorm.Expr("stock - ?", item.Quantity))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrSandboxInsufficientStock
+			}
+		}
+		return tx.Create(order).Error
+	})
+}
+
+func (r *sandboxRepository) FindOrderByID(ctx context.Context, apiKeyID, id string) (*model.SandboxOrder, error) {
+	var order model.SandboxOrder
+	err := r.db.WithContext(ctx).Preload("Items").Where("api_key_id = ? AND id = ?", apiKeyID, id).First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *sandboxRepository) FindOrders(ctx context.Context, apiKeyID string, page, limit int) ([]model.SandboxOrder, int64, error) {
+	var orders []model.SandboxOrder
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.SandboxOrder{}).Where("api_key_id = ?", apiKeyID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("Items").Order("created_at DESC").Offset(offset).Limit(limit).Find(&orders).Error
+	return orders, total, err
+}
+
+func (r *sandboxRepository) UpdateOrder(ctx context.Context, order *model.SandboxOrder, restock bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if restock {
+			for _, item := range order.Items {
+				if err := tx.Model(&model.SandboxProduct{}).Where("id = ?", item.ProductID).
+					Update("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Omit("Items").Save(order).Error
+	})
+}
+
+func (r *sandboxRepository) CreateNotification(ctx context.Context, notification *model.SandboxNotification) error {
+	return r.db.WithContext(ctx).Create(notification).Error
+}
+
+func (r *sandboxRepository) FindNotifications(ctx context.Context, apiKeyID string, limit int) ([]model.SandboxNotification, error) {
+	var notifications []model.SandboxNotification
+	err := r.db.WithContext(ctx).Where("api_key_id = ?", apiKeyID).Order("created_at DESC").Limit(limit).Find(&notifications).Error
+	return notifications, err
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type SavedCardRepository interface {
+	Create(ctx context.Context, card *model.SavedCard) error
+	FindByID(ctx context.Context, id string) (*model.SavedCard, error)
+	FindByUserID(ctx context.Context, userID string) ([]model.SavedCard, error)
+	FindByUserAndMaskedCard(ctx context.Context, userID, maskedCard string) (*model.SavedCard, error)
+	Update(ctx context.Context, card *model.SavedCard) error
+	Delete(ctx context.Context, id string) error
+}
+
+type savedCardRepository struct {
+	db *gorm.DB
+}
+
+func NewSavedCardRepository(db *gorm.DB) SavedCardRepository {
+	return &savedCardRepository{db: db}
+}
+
+func (r *savedCardRepository) Create(ctx context.Context, card *model.SavedCard) error {
+	return r.db.WithContext(ctx).Create(card).Error
+}
+
+func (r *savedCardRepository) FindByID(ctx context.Context, id string) (*model.SavedCard, error) {
+	var card model.SavedCard
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&card).Error
+	if err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+func (r *savedCardRepository) FindByUserID(ctx context.Context, userID string) ([]model.SavedCard, error) {
+	var cards []model.SavedCard
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("updated_at DESC").Find(&cards).Error
+	return cards, err
+}
+
+func (r *savedCardRepository) FindByUserAndMaskedCard(ctx context.Context, userID, maskedCard string) (*model.SavedCard, error) {
+	var card model.SavedCard
+	err := r.db.WithContext(ctx).Where("user_id = ? AND masked_card = ?", userID, maskedCard).First(&card).Error
+	if err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+func (r *savedCardRepository) Update(ctx context.Context, card *model.SavedCard) error {
+	return r.db.WithContext(ctx).Save(card).Error
+}
+
+func (r *savedCardRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&model.SavedCard{}, "id = ?", id).Error
+}
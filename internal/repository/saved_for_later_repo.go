@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type SavedForLaterRepository interface {
+	FindByID(ctx context.Context, id string) (*model.SavedForLaterItem, error)
+	FindByUserID(ctx context.Context, userID string) ([]model.SavedForLaterItem, error)
+	// MoveFromCart removes the cart item and saves it for the user, adding to the quantity when
+	// the product is already saved
+	MoveFromCart(ctx context.Context, userID string, cartItem *model.CartItem) (*model.SavedForLaterItem, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type savedForLaterRepository struct {
+	db *gorm.DB
+}
+
+func NewSavedForLaterRepository(db *gorm.DB) SavedForLaterRepository {
+	return &savedForLaterRepository{db: db}
+}
+
+func (r *savedForLaterRepository) FindByID(ctx context.Context, id string) (*model.SavedForLaterItem, error) {
+	var item model.SavedForLaterItem
+	err := r.db.WithContext(ctx).Preload("Product").Preload("Product.Seller").Preload("Product.ProductImages").Where("id = ?", id).First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *savedForLaterRepository) FindByUserID(ctx context.Context, userID string) ([]model.SavedForLaterItem, error) {
+	var items []model.SavedForLaterItem
+	err := r.db.WithContext(ctx).Preload("Product").Preload("Product.Seller").Preload("Product.ProductImages").
+		Where("user_id = ?", userID).Order("updated_at DESC").Find(&items).Error
+	return items, err
+}
+
+func (r *savedForLaterRepository) MoveFromCart(ctx context.Context, userID string, cartItem *model.CartItem) (*model.SavedForLaterItem, error) {
+	var item model.SavedForLaterItem
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND product_id = ?", userID, cartItem.ProductID).First(&item).Error
+		switch {
+		case err == nil:
+			item.Quantity += cartItem.Quantity
+			item.Price = cartItem.Price
+			if err := tx.Save(&item).Error; err != nil {
+				return err
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			item = model.SavedForLaterItem{
+				UserID:    userID,
+				ProductID: cartItem.ProductID,
+				Quantity:  cartItem.Quantity,
+				Price:     cartItem.Price,
+			}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+		return tx.Delete(&model.CartItem{}, "id = ?", cartItem.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *savedForLaterRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&model.SavedForLaterItem{}, "id = ?", id).Error
+}
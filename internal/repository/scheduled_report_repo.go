@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ScheduledReportRepository interface {
+	Create(ctx context.Context, report *model.ScheduledReport) error
+	Update(ctx context.Context, report *model.ScheduledReport) error
+	Delete(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (*model.ScheduledReport, error)
+	List(ctx context.Context, page, limit int) ([]model.ScheduledReport, int64, error)
+	// ClaimDue returns active reports whose run is due and pushes their next run back by lease, so
+	// other instances do not run them meanwhile
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.ScheduledReport, error)
+
+	CreateRun(ctx context.Context, run *model.ScheduledReportRun) error
+	FindRuns(ctx context.Context, reportID string, page, limit int) ([]model.ScheduledReportRun, int64, error)
+}
+
+type scheduledReportRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduledReportRepository(db *gorm.DB) ScheduledReportRepository {
+	return &scheduledReportRepository{db: db}
+}
+
+func (r *scheduledReportRepository) Create(ctx context.Context, report *model.ScheduledReport) error {
+	return r.db.WithContext(ctx).Create(report).Error
+}
+
+func (r *scheduledReportRepository) Update(ctx context.Context, report *model.ScheduledReport) error {
+	return r.db.WithContext(ctx).Save(report).Error
+}
+
+func (r *scheduledReportRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("report_id = ?", id).Delete(&model.ScheduledReportRun{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.ScheduledReport{}, "id = ?", id).Error
+	})
+}
+
+func (r *scheduledReportRepository) FindByID(ctx context.Context, id string) (*model.ScheduledReport, error) {
+	var report model.ScheduledReport
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&report).Error
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *scheduledReportRepository) List(ctx context.Context, page, limit int) ([]model.ScheduledReport, int64, error) {
+	var reports []model.ScheduledReport
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.ScheduledReport{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&reports).Error
+	return reports, total, err
+}
+
+func (r *scheduledReportRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.ScheduledReport, error) {
+	var reports []model.ScheduledReport
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("is_active = ? AND next_run_at <= ?", true, now).
+			Order("next_run_at ASC").
+			Limit(limit).
+			Find(&reports).Error; err != nil {
+			return err
+		}
+		if len(reports) == 0 {
+			return nil
+		}
+		ids := make([]string, len(reports))
+		for i := range reports {
+			ids[i] = reports[i].ID
+		}
+		return tx.Model(&model.ScheduledReport{}).Where("id IN ?", ids).
+			Update("next_run_at", now.Add(lease)).Error
+	})
+	return reports, err
+}
+
+func (r *scheduledReportRepository) CreateRun(ctx context.Context, run *model.ScheduledReportRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+func (r *scheduledReportRepository) FindRuns(ctx context.Context, reportID string, page, limit int) ([]model.ScheduledReportRun, int64, error) {
+	var runs []model.ScheduledReportRun
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.ScheduledReportRun{}).Where("report_id = ?", reportID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("started_at DESC").Offset(offset).Limit(limit).Find(&runs).Error
+	return runs, total, err
+}
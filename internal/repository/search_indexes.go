@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// searchIndexes are trigram GIN indexes backing substring (ILIKE '%...%') searches, which plain
+// B-tree indexes cannot serve
+var searchIndexes = []struct {
+	name, table, column string
+}{
+	{"idx_orders_order_number_trgm", "orders", "order_number"},
+	{"idx_order_items_product_name_trgm", "order_items", "product_name"},
+	{"idx_sellers_shop_name_trgm", "sellers", "shop_name"},
+	{"idx_products_name_trgm", "products", "name"},
+	{"idx_products_sku_trgm", "products", "sku"},
+}
+
+// productSearchDocument is the text product search matches words against. The 'simple'
+// configuration only lowercases, since Postgres has no Indonesian stemmer. Queries must use the
+// exact same expression for the index to be used.
+const productSearchDocument = "to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(description, '') || ' ' || coalesce(sku, ''))"
+
+// EnsureSearchIndexes enables pg_trgm and creates the trigram and full-text indexes used by search. Run it after
+// AutoMigrate; it is safe to run on every start.
+func EnsureSearchIndexes(db *gorm.DB) error {
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		return fmt.Errorf("failed to enable pg_trgm: %w", err)
+	}
+	for _, index := range searchIndexes {
+		sql := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING gin (%s gin_trgm_ops)", index.name, index.table, index.column)
+		if err := db.Exec(sql).Error; err != nil {
+			return fmt.Errorf("failed to create %s: %w", index.name, err)
+		}
+	}
+	sql := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_products_search_document ON products USING gin ((%s))", productSearchDocument)
+	if err := db.Exec(sql).Error; err != nil {
+		return fmt.Errorf("failed to create idx_products_search_document: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,448 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidSellerOrderTransition is returned when a seller moves a sub-order to a status it cannot reach
+var ErrInvalidSellerOrderTransition = errors.New("invalid sub-order status transition")
+
+// sellerOrderTransitions are the status changes a seller may make, by current status
+var sellerOrderTransitions = map[string]map[string]bool{
+	"paid":       {"processing": true, "shipped": true},
+	"processing": {"shipped": true},
+}
+
+// ErrFulfillmentAcknowledged is returned when a sub-order was already acknowledged by a fulfillment
+// system under another reference
+var ErrFulfillmentAcknowledged = errors.New("sub-order was already acknowledged under another reference")
+
+// ErrInvalidOrderItemTransition is returned when a seller moves an order item to a status it cannot reach
+var ErrInvalidOrderItemTransition = errors.New("invalid order item status transition")
+
+// orderItemTransitions are the fulfillment status changes a seller may make to an item, by current status
+var orderItemTransitions = map[string]map[string]bool{
+	model.OrderItemStatusPending:   {model.OrderItemStatusPacked: true, model.OrderItemStatusShipped: true},
+	model.OrderItemStatusPacked:    {model.OrderItemStatusShipped: true},
+	model.OrderItemStatusShipped:   {model.OrderItemStatusDelivered: true, model.OrderItemStatusReturned: true},
+	model.OrderItemStatusDelivered: {model.OrderItemStatusReturned: true},
+}
+
+type SellerOrderRepository interface {
+	FindByID(ctx context.Context, id string) (*model.SellerOrder, error)
+	FindBySellerID(ctx context.Context, sellerID string, filter SellerOrderFilter, page, limit int) ([]model.SellerOrder, int64, error)
+	EachBatchBySellerID(ctx context.Context, sellerID string, filter SellerOrderFilter, batchSize int, fn func([]model.SellerOrder) error) error
+	UpdateStatus(ctx context.Context, id string, status string, change model.StatusChange) error
+	Ship(ctx context.Context, id string, courier, trackingNumber string, change model.StatusChange) error
+	UpdateItemStatus(ctx context.Context, sellerOrderID string, itemID string, update OrderItemStatusUpdate, change model.StatusChange) error
+	FindSettlementsBySellerID(ctx context.Context, sellerID string, status string, page, limit int) ([]model.SellerSettlement, int64, error)
+	// FindReadyForFulfillment returns the seller's paid or processing sub-orders no fulfillment
+	// system has acknowledged yet, oldest first
+	FindReadyForFulfillment(ctx context.Context, sellerID string, limit int) ([]model.SellerOrder, error)
+	// FindAcknowledgedOpen returns the seller's acknowledged sub-orders that have not shipped yet, oldest first
+	FindAcknowledgedOpen(ctx context.Context, sellerID string, limit int) ([]model.SellerOrder, error)
+	// AcknowledgeFulfillment records that a fulfillment system took the sub-order over, moving a paid
+	// sub-order to processing. Acknowledging again with the same reference changes nothing.
+	AcknowledgeFulfillment(ctx context.Context, id string, reference string, change model.StatusChange) error
+	// FindTaxLines returns the seller's PPN on sub-orders placed in [from, to) that were paid and not cancelled
+	FindTaxLines(ctx context.Context, sellerID string, from, to time.Time) ([]SellerTaxLine, error)
+}
+
+// SellerTaxLine is the PPN charged on one sub-order, as listed in the seller's tax report
+type SellerTaxLine struct {
+	SubOrderNumber  string    `json:"sub_order_number"`
+	OrderedAt       time.Time `json:"ordered_at"`
+	Status          string    `json:"status"`
+	RateBasisPoints int       `json:"rate_basis_points"`
+	Inclusive       bool      `json:"inclusive"`
+	TaxableAmount   int       `json:"taxable_amount"`
+	TaxAmount       int       `json:"tax_amount"`
+	SellerNPWP      *string   `json:"seller_npwp,omitempty"`
+}
+
+// SellerOrderFilter narrows a seller's sub-order list; zero values are ignored
+type SellerOrderFilter struct {
+	Status string
+	From   *time.Time // Created at or after
+	To     *time.Time // Created before
+	Search string     // Sub-order number, product name or recipient name
+}
+
+// OrderItemStatusUpdate is a seller's fulfillment change to one item; Courier and TrackingNumber
+// are only stored when shipping
+type OrderItemStatusUpdate struct {
+	Status         string
+	Courier        string
+	TrackingNumber string
+}
+
+type sellerOrderRepository struct {
+	db *gorm.DB
+}
+
+func NewSellerOrderRepository(db *gorm.DB) SellerOrderRepository {
+	return &sellerOrderRepository{db: db}
+}
+
+func (r *sellerOrderRepository) FindByID(ctx context.Context, id string) (*model.SellerOrder, error) {
+	var sellerOrder model.SellerOrder
+	err := r.db.WithContext(ctx).
+		Preload("ShippingAddress").
+		Preload("OrderItems").
+		Preload("OrderItems.Product").
+		Where("id = ?", id).First(&sellerOrder).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sellerOrder, nil
+}
+
+func (r *sellerOrderRepository) FindBySellerID(ctx context.Context, sellerID string, filter SellerOrderFilter, page, limit int) ([]model.SellerOrder, int64, error) {
+	var sellerOrders []model.SellerOrder
+	var total int64
+
+	query := r.filteredBySeller(ctx, sellerID, filter)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Preload("ShippingAddress").
+		Preload("OrderItems").
+		Order("seller_orders.created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&sellerOrders).Error
+	return sellerOrders, total, err
+}
+
+// EachBatchBySellerID walks a seller's filtered sub-orders oldest first, batchSize at a time, so
+// large exports never hold more than one batch in memory. It stops at the first error from fn.
+func (r *sellerOrderRepository) EachBatchBySellerID(ctx context.Context, sellerID string, filter SellerOrderFilter, batchSize int, fn func([]model.SellerOrder) error) error {
+	var lastCreatedAt time.Time
+	var lastID string
+	for {
+		var batch []model.SellerOrder
+		query := r.filteredBySeller(ctx, sellerID, filter)
+		if lastID != "" {
+			// Keyset pagination: offsets get slower the further the export goes
+			query = query.Where("(seller_orders.created_at, seller_orders.id) > (?, ?)", lastCreatedAt, lastID)
+		}
+		err := query.
+			Preload("ShippingAddress").
+			Preload("OrderItems").
+			Order("seller_orders.created_at ASC").
+			Order("seller_orders.id ASC").
+			Limit(batchSize).
+			Find(&batch).Error
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+		last := batch[len(batch)-1]
+		lastCreatedAt, lastID = last.CreatedAt, last.ID
+	}
+}
+
+// filteredBySeller builds the sub-order query shared by listing and export
+func (r *sellerOrderRepository) filteredBySeller(ctx context.Context, sellerID string, filter SellerOrderFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&model.SellerOrder{}).Where("seller_orders.seller_id = ?", sellerID)
+	if filter.Status != "" {
+		query = query.Where("seller_orders.status = ?", filter.Status)
+	}
+	if filter.From != nil {
+		query = query.Where("seller_orders.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("seller_orders.created_at < ?", *filter.To)
+	}
+	if filter.Search != "" {
+		pattern := "%" + filter.Search + "%"
+		query = query.Where("seller_orders.sub_order_number ILIKE ? OR "+
+			"EXISTS (SELECT 1 FROM order_items oi WHERE oi.seller_order_id = seller_orders.id AND oi.product_name ILIKE ?) OR "+
+			"EXISTS (SELECT 1 FROM addresses a WHERE a.id = seller_orders.shipping_address_id AND a.recipient_name ILIKE ?)",
+			pattern, pattern, pattern)
+	}
+	return query
+}
+
+// UpdateStatus applies a seller's status change to a sub-order and records it in the order's status
+// history. Shipping goes through Ship so the tracking number is always recorded.
+func (r *sellerOrderRepository) UpdateStatus(ctx context.Context, id string, status string, change model.StatusChange) error {
+	return r.transition(ctx, id, status, map[string]interface{}{"status": status}, change)
+}
+
+// Ship marks a sub-order as shipped with the courier's tracking number (AWB). Once every sub-order
+// of the parent order has shipped (or was cancelled), the parent order moves to shipped as well.
+func (r *sellerOrderRepository) Ship(ctx context.Context, id string, courier, trackingNumber string, change model.StatusChange) error {
+	return r.transition(ctx, id, "shipped", map[string]interface{}{
+		"status":          "shipped",
+		"courier":         courier,
+		"tracking_number": trackingNumber,
+		"shipped_at":      time.Now(),
+	}, change)
+}
+
+// transition locks the sub-order, checks the move is allowed, applies updates (which must include
+// the new status) and writes the history row
+func (r *sellerOrderRepository) transition(ctx context.Context, id string, status string, updates map[string]interface{}, change model.StatusChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sellerOrder model.SellerOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", id).
+			First(&sellerOrder).Error; err != nil {
+			return err
+		}
+		if !sellerOrderTransitions[sellerOrder.Status][status] {
+			return ErrInvalidSellerOrderTransition
+		}
+		if err := tx.Model(&sellerOrder).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		from := sellerOrder.Status
+		history := model.NewStatusHistory(sellerOrder.OrderID, &from, status, change)
+		history.SellerOrderID = &sellerOrder.ID
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+		if status != "shipped" {
+			return nil
+		}
+		// Shipping the whole sub-order ships whatever items were not sent separately
+		if err := tx.Model(&model.OrderItem{}).
+			Where("seller_order_id = ? AND status IN ?", sellerOrder.ID, []string{model.OrderItemStatusPending, model.OrderItemStatusPacked}).
+			Updates(map[string]interface{}{
+				"status":            model.OrderItemStatusShipped,
+				"courier":           updates["courier"],
+				"tracking_number":   updates["tracking_number"],
+				"status_updated_at": time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+		return rollUpShipped(tx, sellerOrder.OrderID, change)
+	})
+}
+
+// rollUpShipped moves the parent order to shipped once none of its sub-orders is still waiting to
+// ship. A single-seller order also takes over its sub-order's tracking number.
+func rollUpShipped(tx *gorm.DB, orderID string, change model.StatusChange) error {
+	var sellerOrders []model.SellerOrder
+	if err := tx.Where("order_id = ?", orderID).Find(&sellerOrders).Error; err != nil {
+		return err
+	}
+	for _, sellerOrder := range sellerOrders {
+		switch sellerOrder.Status {
+		case "pending", "paid", "processing":
+			return nil
+		}
+	}
+
+	updates := map[string]interface{}{"status": "shipped", "shipped_at": time.Now()}
+	if len(sellerOrders) == 1 {
+		updates["courier"] = sellerOrders[0].Courier
+		updates["tracking_number"] = sellerOrders[0].TrackingNumber
+	}
+	result := tx.Model(&model.Order{}).
+		Where("id = ? AND status = ?", orderID, "processing").
+		Updates(updates)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+	from := "processing"
+	return tx.Create(model.NewStatusHistory(orderID, &from, "shipped", change)).Error
+}
+
+// UpdateItemStatus moves one item of a paid sub-order through fulfillment. Packing the first item
+// moves a paid sub-order to processing; once every item has shipped the sub-order ships too, with
+// the tracking number of the last parcel.
+func (r *sellerOrderRepository) UpdateItemStatus(ctx context.Context, sellerOrderID string, itemID string, update OrderItemStatusUpdate, change model.StatusChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sellerOrder model.SellerOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", sellerOrderID).
+			First(&sellerOrder).Error; err != nil {
+			return err
+		}
+		switch sellerOrder.Status {
+		case "paid", "processing", "shipped", "delivered":
+		default:
+			return ErrInvalidOrderItemTransition
+		}
+
+		var item model.OrderItem
+		if err := tx.Where("id = ? AND seller_order_id = ?", itemID, sellerOrderID).First(&item).Error; err != nil {
+			return err
+		}
+		if !orderItemTransitions[item.Status][update.Status] {
+			return ErrInvalidOrderItemTransition
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{"status": update.Status, "status_updated_at": now}
+		if update.Status == model.OrderItemStatusShipped {
+			updates["courier"] = update.Courier
+			updates["tracking_number"] = update.TrackingNumber
+		}
+		if err := tx.Model(&item).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		switch update.Status {
+		case model.OrderItemStatusPacked:
+			if sellerOrder.Status != "paid" {
+				return nil
+			}
+			from := sellerOrder.Status
+			if err := tx.Model(&sellerOrder).Update("status", "processing").Error; err != nil {
+				return err
+			}
+			history := model.NewStatusHistory(sellerOrder.OrderID, &from, "processing", change)
+			history.SellerOrderID = &sellerOrder.ID
+			return tx.Create(history).Error
+		case model.OrderItemStatusShipped:
+			if sellerOrder.Status != "paid" && sellerOrder.Status != "processing" {
+				return nil
+			}
+			var waiting int64
+			if err := tx.Model(&model.OrderItem{}).
+				Where("seller_order_id = ? AND status IN ?", sellerOrderID, []string{model.OrderItemStatusPending, model.OrderItemStatusPacked}).
+				Count(&waiting).Error; err != nil {
+				return err
+			}
+			if waiting > 0 {
+				return nil
+			}
+			if err := tx.Model(&sellerOrder).Updates(map[string]interface{}{
+				"status":          "shipped",
+				"courier":         update.Courier,
+				"tracking_number": update.TrackingNumber,
+				"shipped_at":      now,
+			}).Error; err != nil {
+				return err
+			}
+			from := sellerOrder.Status
+			history := model.NewStatusHistory(sellerOrder.OrderID, &from, "shipped", change)
+			history.SellerOrderID = &sellerOrder.ID
+			if err := tx.Create(history).Error; err != nil {
+				return err
+			}
+			return rollUpShipped(tx, sellerOrder.OrderID, change)
+		}
+		return nil
+	})
+}
+
+func (r *sellerOrderRepository) FindReadyForFulfillment(ctx context.Context, sellerID string, limit int) ([]model.SellerOrder, error) {
+	return r.findForFulfillment(ctx, sellerID, "fulfillment_acknowledged_at IS NULL", limit)
+}
+
+func (r *sellerOrderRepository) FindAcknowledgedOpen(ctx context.Context, sellerID string, limit int) ([]model.SellerOrder, error) {
+	return r.findForFulfillment(ctx, sellerID, "fulfillment_acknowledged_at IS NOT NULL", limit)
+}
+
+// findForFulfillment lists the seller's paid or processing sub-orders matching the acknowledgement
+// condition, oldest first
+func (r *sellerOrderRepository) findForFulfillment(ctx context.Context, sellerID string, acknowledged string, limit int) ([]model.SellerOrder, error) {
+	var sellerOrders []model.SellerOrder
+	err := r.db.WithContext(ctx).
+		Preload("ShippingAddress").
+		Preload("OrderItems").
+		Preload("OrderItems.Product").
+		Where("seller_id = ? AND status IN ?", sellerID, []string{"paid", "processing"}).
+		Where(acknowledged).
+		Order("created_at ASC").
+		Order("id ASC").
+		Limit(limit).
+		Find(&sellerOrders).Error
+	return sellerOrders, err
+}
+
+func (r *sellerOrderRepository) AcknowledgeFulfillment(ctx context.Context, id string, reference string, change model.StatusChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sellerOrder model.SellerOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", id).
+			First(&sellerOrder).Error; err != nil {
+			return err
+		}
+		if sellerOrder.FulfillmentReference != nil {
+			if *sellerOrder.FulfillmentReference == reference {
+				return nil
+			}
+			return ErrFulfillmentAcknowledged
+		}
+		if sellerOrder.Status != "paid" && sellerOrder.Status != "processing" {
+			return ErrInvalidSellerOrderTransition
+		}
+
+		from := sellerOrder.Status
+		updates := map[string]interface{}{
+			"fulfillment_reference":       reference,
+			"fulfillment_acknowledged_at": time.Now(),
+		}
+		if from == "paid" {
+			updates["status"] = "processing"
+		}
+		if err := tx.Model(&sellerOrder).Updates(updates).Error; err != nil {
+			return err
+		}
+		if from != "paid" {
+			return nil
+		}
+		history := model.NewStatusHistory(sellerOrder.OrderID, &from, "processing", change)
+		history.SellerOrderID = &sellerOrder.ID
+		return tx.Create(history).Error
+	})
+}
+
+func (r *sellerOrderRepository) FindSettlementsBySellerID(ctx context.Context, sellerID string, status string, page, limit int) ([]model.SellerSettlement, int64, error) {
+	var settlements []model.SellerSettlement
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.SellerSettlement{}).Where("seller_id = ?", sellerID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Preload("SellerOrder").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&settlements).Error
+	return settlements, total, err
+}
+
+func (r *sellerOrderRepository) FindTaxLines(ctx context.Context, sellerID string, from, to time.Time) ([]SellerTaxLine, error) {
+	var lines []SellerTaxLine
+	err := r.db.WithContext(ctx).Table("order_tax_lines").
+		Select("seller_orders.sub_order_number, order_tax_lines.created_at AS ordered_at, seller_orders.status, "+
+			"order_tax_lines.rate_basis_points, order_tax_lines.inclusive, order_tax_lines.taxable_amount, "+
+			"order_tax_lines.tax_amount, order_tax_lines.seller_npwp").
+		Joins("JOIN seller_orders ON seller_orders.id = order_tax_lines.seller_order_id").
+		Where("order_tax_lines.seller_id = ? AND order_tax_lines.created_at >= ? AND order_tax_lines.created_at < ?", sellerID, from, to).
+		Where("seller_orders.status NOT IN ?", []string{"pending", "cancelled"}).
+		Order("order_tax_lines.created_at ASC").
+		Scan(&lines).Error
+	return lines, err
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"errors"
+	"time"
 
 	"yourapp/internal/model"
 
@@ -15,6 +16,12 @@ type SellerRepository interface {
 	FindBySlug(slug string) (*model.Seller, error)
 	Update(seller *model.Seller) error
 	Delete(sellerID string) error
+	FindByShopName(shopName string) (*model.Seller, error)
+	// Trash: soft-deleted shops, newest deletion first
+	FindDeleted(page, limit int) ([]model.Seller, int64, error)
+	FindDeletedByID(id string) (*model.Seller, error)
+	// Restore undeletes a shop under the given name and slug
+	Restore(id string, shopName, shopSlug string) error
 }
 
 type sellerRepository struct {
@@ -80,3 +87,60 @@ func (r *sellerRepository) Delete(sellerID string) error {
 	}
 	return nil
 }
+
+func (r *sellerRepository) FindByShopName(shopName string) (*model.Seller, error) {
+	var seller model.Seller
+	err := r.db.Where("shop_name = ?", shopName).First(&seller).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("seller not found")
+		}
+		return nil, err
+	}
+	return &seller, nil
+}
+
+func (r *sellerRepository) FindDeleted(page, limit int) ([]model.Seller, int64, error) {
+	var sellers []model.Seller
+	var total int64
+
+	query := r.db.Unscoped().Model(&model.Seller{}).Where("deleted_at IS NOT NULL")
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("User").Order("deleted_at DESC").Offset(offset).Limit(limit).Find(&sellers).Error
+	return sellers, total, err
+}
+
+func (r *sellerRepository) FindDeletedByID(id string) (*model.Seller, error) {
+	var seller model.Seller
+	err := r.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&seller).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("seller not found in trash")
+		}
+		return nil, err
+	}
+	return &seller, nil
+}
+
+func (r *sellerRepository) Restore(id string, shopName, shopSlug string) error {
+	// UpdateColumns skips the BeforeUpdate hook, which would regenerate the slug from the name
+	result := r.db.Unscoped().Model(&model.Seller{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		UpdateColumns(map[string]interface{}{
+			"deleted_at": nil,
+			"shop_name":  shopName,
+			"shop_slug":  shopSlug,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("seller not found in trash")
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SellerWebhookRepository interface {
+	Create(ctx context.Context, webhook *model.SellerWebhook) error
+	Update(ctx context.Context, webhook *model.SellerWebhook) error
+	Delete(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (*model.SellerWebhook, error)
+	FindBySellerID(ctx context.Context, sellerID string) ([]model.SellerWebhook, error)
+	// FindActiveForEvent returns the seller's active webhooks subscribed to the event
+	FindActiveForEvent(ctx context.Context, sellerID string, event string) ([]model.SellerWebhook, error)
+
+	CreateDeliveries(ctx context.Context, deliveries []model.SellerWebhookDelivery) error
+	FindDeliveries(ctx context.Context, webhookID string, status string, page, limit int) ([]model.SellerWebhookDelivery, int64, error)
+	// ClaimDueDeliveries returns pending deliveries whose next attempt is due, with their webhook,
+	// and pushes their next attempt back by lease so other instances do not pick them up meanwhile
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.SellerWebhookDelivery, error)
+	// RecordAttempt saves the outcome of a delivery attempt and the webhook's success/failure streak
+	RecordAttempt(ctx context.Context, delivery *model.SellerWebhookDelivery) error
+
+	// SetStockState records the product's stock level and reports whether it changed. Concurrent
+	// calls with the same level report the change once.
+	SetStockState(ctx context.Context, productID string, state string) (bool, error)
+}
+
+type sellerWebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewSellerWebhookRepository(db *gorm.DB) SellerWebhookRepository {
+	return &sellerWebhookRepository{db: db}
+}
+
+func (r *sellerWebhookRepository) Create(ctx context.Context, webhook *model.SellerWebhook) error {
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+func (r *sellerWebhookRepository) Update(ctx context.Context, webhook *model.SellerWebhook) error {
+	return r.db.WithContext(ctx).Save(webhook).Error
+}
+
+func (r *sellerWebhookRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&model.SellerWebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.SellerWebhook{}, "id = ?", id).Error
+	})
+}
+
+func (r *sellerWebhookRepository) FindByID(ctx context.Context, id string) (*model.SellerWebhook, error) {
+	var webhook model.SellerWebhook
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&webhook).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *sellerWebhookRepository) FindBySellerID(ctx context.Context, sellerID string) ([]model.SellerWebhook, error) {
+	var webhooks []model.SellerWebhook
+	err := r.db.WithContext(ctx).Where("seller_id = ?", sellerID).Order("created_at ASC").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *sellerWebhookRepository) FindActiveForEvent(ctx context.Context, sellerID string, event string) ([]model.SellerWebhook, error) {
+	var webhooks []model.SellerWebhook
+	err := r.db.WithContext(ctx).
+		Where("seller_id = ? AND is_active = ? AND (',' || events || ',') LIKE ?", sellerID, true, "%,"+event+",%").
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *sellerWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []model.SellerWebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Omit("Webhook").Create(&deliveries).Error
+}
+
+func (r *sellerWebhookRepository) FindDeliveries(ctx context.Context, webhookID string, status string, page, limit int) ([]model.SellerWebhookDelivery, int64, error) {
+	var deliveries []model.SellerWebhookDelivery
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.SellerWebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+func (r *sellerWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.SellerWebhookDelivery, error) {
+	var deliveries []model.SellerWebhookDelivery
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		ids := make([]string, len(deliveries))
+		for i := range deliveries {
+			ids[i] = deliveries[i].ID
+		}
+		return tx.Model(&model.SellerWebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+
+	// Attach the webhooks outside the locking query
+	webhookIDs := make([]string, 0, len(deliveries))
+	for i := range deliveries {
+		webhookIDs = append(webhookIDs, deliveries[i].WebhookID)
+	}
+	var webhooks []model.SellerWebhook
+	if err := r.db.WithContext(ctx).Where("id IN ?", webhookIDs).Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*model.SellerWebhook, len(webhooks))
+	for i := range webhooks {
+		byID[webhooks[i].ID] = &webhooks[i]
+	}
+	for i := range deliveries {
+		deliveries[i].Webhook = byID[deliveries[i].WebhookID]
+	}
+	return deliveries, nil
+}
+
+func (r *sellerWebhookRepository) RecordAttempt(ctx context.Context, delivery *model.SellerWebhookDelivery) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.SellerWebhookDelivery{}).Where("id = ?", delivery.ID).Updates(map[string]interface{}{
+			"status":          delivery.Status,
+			"attempts":        delivery.Attempts,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"response_status": delivery.ResponseStatus,
+			"last_error":      delivery.LastError,
+			"delivered_at":    delivery.DeliveredAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		webhook := tx.Model(&model.SellerWebhook{}).Where("id = ?", delivery.WebhookID)
+		switch delivery.Status {
+		case model.WebhookDeliveryDelivered:
+			return webhook.Updates(map[string]interface{}{"consecutive_failures": 0, "last_success_at": delivery.DeliveredAt}).Error
+		case model.WebhookDeliveryFailed:
+			return webhook.Updates(map[string]interface{}{
+				"consecutive_failures": gorm.Expr("consecutive_failures + 1"),
+				"last_failure_at":      time.Now(),
+			}).Error
+		}
+		return nil
+	})
+}
+
+func (r *sellerWebhookRepository) SetStockState(ctx context.Context, productID string, state string) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "product_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"state":      state,
+			"updated_at": time.Now(),
+		}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Neq{Column: clause.Column{Table: "product_stock_states", Name: "state"}, Value: state},
+		}},
+	}).Create(&model.ProductStockState{ProductID: productID, State: state})
+	return result.RowsAffected > 0, result.Error
+}
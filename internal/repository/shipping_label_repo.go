@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrShippingLabelExists is returned when a sub-order already has (or is booking) a shipping label
+var ErrShippingLabelExists = errors.New("shipping label already exists")
+
+type ShippingLabelRepository interface {
+	// Create reserves the sub-order's label before the courier is called, so a sub-order is never
+	// booked twice
+	Create(ctx context.Context, label *model.ShippingLabel) error
+	Update(ctx context.Context, label *model.ShippingLabel) error
+	Delete(ctx context.Context, id string) error
+	FindBySellerOrderID(ctx context.Context, sellerOrderID string) (*model.ShippingLabel, error)
+}
+
+type shippingLabelRepository struct {
+	db *gorm.DB
+}
+
+func NewShippingLabelRepository(db *gorm.DB) ShippingLabelRepository {
+	return &shippingLabelRepository{db: db}
+}
+
+func (r *shippingLabelRepository) Create(ctx context.Context, label *model.ShippingLabel) error {
+	err := r.db.WithContext(ctx).Create(label).Error
+	if err != nil && (errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate")) {
+		return ErrShippingLabelExists
+	}
+	return err
+}
+
+func (r *shippingLabelRepository) Update(ctx context.Context, label *model.ShippingLabel) error {
+	return r.db.WithContext(ctx).Save(label).Error
+}
+
+func (r *shippingLabelRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.ShippingLabel{}).Error
+}
+
+func (r *shippingLabelRepository) FindBySellerOrderID(ctx context.Context, sellerOrderID string) (*model.ShippingLabel, error) {
+	var label model.ShippingLabel
+	if err := r.db.WithContext(ctx).Where("seller_order_id = ?", sellerOrderID).First(&label).Error; err != nil {
+		return nil, err
+	}
+	return &label, nil
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrStockTakeNotOpen is returned when counting, applying or cancelling a stock take that is no longer open
+var ErrStockTakeNotOpen = errors.New("stock take is not open")
+
+// stockTakeSource is the StockAdjustment source for changes applied from a stock take
+const stockTakeSource = "stock_opname"
+
+type StockTakeRepository interface {
+	Create(ctx context.Context, take *model.StockTake) error
+	FindByID(ctx context.Context, id string) (*model.StockTake, error)
+	FindOpenBySellerID(ctx context.Context, sellerID string) (*model.StockTake, error)
+	FindBySellerID(ctx context.Context, sellerID string, page, limit int) ([]model.StockTake, int64, error)
+	UpsertCounts(ctx context.Context, counts []model.StockTakeCount) error
+	CountUncountedProducts(ctx context.Context, takeID, sellerID string) (int64, error)
+	Cancel(ctx context.Context, id string) error
+	Apply(ctx context.Context, id string, appliedAt time.Time) ([]model.StockAdjustment, error)
+}
+
+type stockTakeRepository struct {
+	db *gorm.DB
+}
+
+func NewStockTakeRepository(db *gorm.DB) StockTakeRepository {
+	return &stockTakeRepository{db: db}
+}
+
+func (r *stockTakeRepository) Create(ctx context.Context, take *model.StockTake) error {
+	return r.db.WithContext(ctx).Create(take).Error
+}
+
+func (r *stockTakeRepository) FindByID(ctx context.Context, id string) (*model.StockTake, error) {
+	var take model.StockTake
+	err := r.db.WithContext(ctx).
+		Preload("Counts", func(db *gorm.DB) *gorm.DB { return db.Order("sku ASC") }).
+		Where("id = ?", id).First(&take).Error
+	if err != nil {
+		return nil, err
+	}
+	return &take, nil
+}
+
+func (r *stockTakeRepository) FindOpenBySellerID(ctx context.Context, sellerID string) (*model.StockTake, error) {
+	var take model.StockTake
+	err := r.db.WithContext(ctx).Where("seller_id = ? AND status = ?", sellerID, model.StockTakeStatusOpen).First(&take).Error
+	if err != nil {
+		return nil, err
+	}
+	return &take, nil
+}
+
+func (r *stockTakeRepository) FindBySellerID(ctx context.Context, sellerID string, page, limit int) ([]model.StockTake, int64, error) {
+	var takes []model.StockTake
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.StockTake{}).Where("seller_id = ?", sellerID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&takes).Error
+	return takes, total, err
+}
+
+// UpsertCounts saves counted quantities; counting a product again replaces its earlier count
+func (r *stockTakeRepository) UpsertCounts(ctx context.Context, counts []model.StockTakeCount) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "stock_take_id"}, {Name: "product_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"counted_quantity", "system_stock", "counted_at"}),
+	}).Create(&counts).Error
+}
+
+// CountUncountedProducts returns how many of the seller's products have no count in the take yet
+func (r *stockTakeRepository) CountUncountedProducts(ctx context.Context, takeID, sellerID string) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&model.Product{}).
+		Where("seller_id = ?", sellerID).
+		Where("NOT EXISTS (SELECT 1 FROM stock_take_counts c WHERE c.stock_take_id = ? AND c.product_id = products.id)", takeID).
+		Count(&total).Error
+	return total, err
+}
+
+func (r *stockTakeRepository) Cancel(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Model(&model.StockTake{}).
+		Where("id = ? AND status = ?", id, model.StockTakeStatusOpen).
+		Update("status", model.StockTakeStatusCancelled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStockTakeNotOpen
+	}
+	return nil
+}
+
+// Apply corrects product stock by each count's variance (counted minus the stock at count time) and
+// records every change in the stock adjustment ledger, all in one transaction. Products with no
+// variance are left untouched. The adjustments made are returned.
+func (r *stockTakeRepository) Apply(ctx context.Context, id string, appliedAt time.Time) ([]model.StockAdjustment, error) {
+	var adjustments []model.StockAdjustment
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var take model.StockTake
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&take).Error; err != nil {
+			return err
+		}
+		if take.Status != model.StockTakeStatusOpen {
+			return ErrStockTakeNotOpen
+		}
+
+		var counts []model.StockTakeCount
+		if err := tx.Where("stock_take_id = ?", id).Order("product_id ASC").Find(&counts).Error; err != nil {
+			return err
+		}
+
+		reason := "Stock take " + take.ID
+		for _, count := range counts {
+			variance := count.CountedQuantity - count.SystemStock
+			if variance == 0 {
+				continue
+			}
+
+			var product model.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", count.ProductID).First(&product).Error; err != nil {
+				return err
+			}
+			// Stock sold since the count may leave less than the shrinkage to remove
+			newStock := product.Stock + variance
+			if newStock < 0 {
+				newStock = 0
+			}
+			if newStock == product.Stock {
+				continue
+			}
+			if err := tx.Model(&product).Update("stock", newStock).Error; err != nil {
+				return err
+			}
+
+			adjustment := model.StockAdjustment{
+				SellerID:      take.SellerID,
+				Reference:     "opname-" + take.ID + "-" + product.ID,
+				ProductID:     product.ID,
+				SKU:           product.SKU,
+				Delta:         newStock - product.Stock,
+				PreviousStock: product.Stock,
+				NewStock:      newStock,
+				Source:        stockTakeSource,
+				Reason:        &reason,
+			}
+			if err := tx.Create(&adjustment).Error; err != nil {
+				return err
+			}
+			adjustments = append(adjustments, adjustment)
+		}
+
+		return tx.Model(&take).Updates(map[string]interface{}{
+			"status":     model.StockTakeStatusApplied,
+			"applied_at": appliedAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return adjustments, nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrSupportTicketClosed is returned when a message is added to a closed ticket
+var ErrSupportTicketClosed = errors.New("support ticket is closed")
+
+// SupportTicketFilter narrows the admin ticket list. Empty fields do not filter.
+type SupportTicketFilter struct {
+	Status     string
+	AssigneeID string
+	Unassigned bool
+	Breached   bool // Only tickets that breached either SLA deadline
+}
+
+type SupportTicketRepository interface {
+	// Create saves the ticket with its first message and attachments
+	Create(ctx context.Context, ticket *model.SupportTicket) error
+	FindByID(ctx context.Context, id string) (*model.SupportTicket, error)
+	FindByUserID(ctx context.Context, userID string, status string, page, limit int) ([]model.SupportTicket, int64, error)
+	FindAll(ctx context.Context, filter SupportTicketFilter, page, limit int) ([]model.SupportTicket, int64, error)
+	// AddMessage saves the message with its attachments and applies updates to the ticket, unless
+	// the ticket was closed in the meantime
+	AddMessage(ctx context.Context, message *model.SupportTicketMessage, updates map[string]interface{}) error
+	Update(ctx context.Context, id string, updates map[string]interface{}) error
+
+	// FindSLABreaches returns tickets whose first response or resolution is overdue and not yet
+	// recorded as breached
+	FindSLABreaches(ctx context.Context, now time.Time, limit int) ([]model.SupportTicket, error)
+	// MarkBreached records a breach of the first_response or resolution deadline and reports
+	// whether it was newly recorded
+	MarkBreached(ctx context.Context, id string, column string) (bool, error)
+}
+
+type supportTicketRepository struct {
+	db *gorm.DB
+}
+
+func NewSupportTicketRepository(db *gorm.DB) SupportTicketRepository {
+	return &supportTicketRepository{db: db}
+}
+
+func (r *supportTicketRepository) Create(ctx context.Context, ticket *model.SupportTicket) error {
+	return r.db.WithContext(ctx).Create(ticket).Error
+}
+
+func (r *supportTicketRepository) FindByID(ctx context.Context, id string) (*model.SupportTicket, error) {
+	var ticket model.SupportTicket
+	err := r.db.WithContext(ctx).
+		Preload("Messages", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Messages.Attachments", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order ASC") }).
+		Preload("Order").
+		Preload("User").
+		Preload("Assignee").
+		Where("id = ?", id).First(&ticket).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+func (r *supportTicketRepository) FindByUserID(ctx context.Context, userID string, status string, page, limit int) ([]model.SupportTicket, int64, error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	return r.list(query, page, limit)
+}
+
+func (r *supportTicketRepository) FindAll(ctx context.Context, filter SupportTicketFilter, page, limit int) ([]model.SupportTicket, int64, error) {
+	query := r.db.WithContext(ctx)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.AssigneeID != "" {
+		query = query.Where("assignee_id = ?", filter.AssigneeID)
+	}
+	if filter.Unassigned {
+		query = query.Where("assignee_id IS NULL")
+	}
+	if filter.Breached {
+		query = query.Where("(first_response_breached OR resolution_breached)")
+	}
+	return r.list(query, page, limit)
+}
+
+func (r *supportTicketRepository) list(query *gorm.DB, page, limit int) ([]model.SupportTicket, int64, error) {
+	var tickets []model.SupportTicket
+	var total int64
+
+	query = query.Model(&model.SupportTicket{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Preload("Assignee").
+		Order("last_message_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&tickets).Error
+	return tickets, total, err
+}
+
+func (r *supportTicketRepository) AddMessage(ctx context.Context, message *model.SupportTicketMessage, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.SupportTicket{}).
+			Where("id = ? AND status <> ?", message.TicketID, model.SupportTicketStatusClosed).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrSupportTicketClosed
+		}
+		return tx.Create(message).Error
+	})
+}
+
+func (r *supportTicketRepository) Update(ctx context.Context, id string, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.SupportTicket{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *supportTicketRepository) FindSLABreaches(ctx context.Context, now time.Time, limit int) ([]model.SupportTicket, error) {
+	var tickets []model.SupportTicket
+	err := r.db.WithContext(ctx).
+		Preload("Assignee").
+		Where("(NOT first_response_breached AND first_responded_at IS NULL AND status = ? AND first_response_due_at < ?) OR "+
+			"(NOT resolution_breached AND status = ? AND resolution_due_at < ?)",
+			model.SupportTicketStatusOpen, now, model.SupportTicketStatusOpen, now).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&tickets).Error
+	return tickets, err
+}
+
+func (r *supportTicketRepository) MarkBreached(ctx context.Context, id string, column string) (bool, error) {
+	if column != "first_response" && column != "resolution" {
+		return false, fmt.Errorf("unknown SLA deadline %q", column)
+	}
+	column += "_breached"
+	result := r.db.WithContext(ctx).Model(&model.SupportTicket{}).
+		Where("id = ? AND NOT "+column, id).
+		Update(column, true)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TagSuggestion is a tag with the number of live products carrying it
+type TagSuggestion struct {
+	Name         string `json:"name"`
+	ProductCount int64  `json:"product_count"`
+}
+
+type TagRepository interface {
+	FindOrCreate(ctx context.Context, names []string) ([]model.Tag, error)
+	FindByName(ctx context.Context, name string) (*model.Tag, error)
+	FindByProductID(ctx context.Context, productID string) ([]model.Tag, error)
+	ReplaceProductTags(ctx context.Context, productID string, tags []model.Tag) error
+	RemoveProductTag(ctx context.Context, productID string, tag *model.Tag) error
+	FindProductsByTagID(ctx context.Context, tagID string, page, limit int) ([]model.Product, int64, error)
+	Suggest(ctx context.Context, prefix string, limit int) ([]TagSuggestion, error)
+}
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+// FindOrCreate returns the tags with the given (already normalized) names, creating missing ones
+func (r *tagRepository) FindOrCreate(ctx context.Context, names []string) ([]model.Tag, error) {
+	if len(names) == 0 {
+		return []model.Tag{}, nil
+	}
+
+	tags := make([]model.Tag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, model.Tag{Name: name})
+	}
+	db := r.db.WithContext(ctx)
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoNothing: true,
+	}).Create(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	// Tags that already existed keep their own IDs, so read them all back
+	var existing []model.Tag
+	err := db.Where("name IN ?", names).Order("name ASC").Find(&existing).Error
+	return existing, err
+}
+
+func (r *tagRepository) FindByName(ctx context.Context, name string) (*model.Tag, error) {
+	var tag model.Tag
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *tagRepository) FindByProductID(ctx context.Context, productID string) ([]model.Tag, error) {
+	var tags []model.Tag
+	err := r.db.WithContext(ctx).
+		Joins("JOIN product_tags pt ON pt.tag_id = tags.id").
+		Where("pt.product_id = ?", productID).
+		Order("tags.name ASC").
+		Find(&tags).Error
+	return tags, err
+}
+
+func (r *tagRepository) ReplaceProductTags(ctx context.Context, productID string, tags []model.Tag) error {
+	product := model.Product{ID: productID}
+	return r.db.WithContext(ctx).Model(&product).Association("Tags").Replace(tags)
+}
+
+func (r *tagRepository) RemoveProductTag(ctx context.Context, productID string, tag *model.Tag) error {
+	product := model.Product{ID: productID}
+	return r.db.WithContext(ctx).Model(&product).Association("Tags").Delete(tag)
+}
+
+// FindProductsByTagID lists active products with the tag, newest first
+func (r *tagRepository) FindProductsByTagID(ctx context.Context, tagID string, page, limit int) ([]model.Product, int64, error) {
+	var products []model.Product
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.Product{}).
+		Joins("JOIN product_tags pt ON pt.product_id = products.id").
+		Where("pt.tag_id = ? AND products.is_active = ?", tagID, true)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("Category").Preload("Tags").Preload("ProductImages", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC")
+	}).Order("products.created_at DESC").Limit(limit).Offset(offset).Find(&products).Error
+	return products, total, err
+}
+
+// Suggest returns tags starting with prefix, most used (by active products) first
+func (r *tagRepository) Suggest(ctx context.Context, prefix string, limit int) ([]TagSuggestion, error) {
+	var suggestions []TagSuggestion
+	err := r.db.WithContext(ctx).Model(&model.Tag{}).
+		Select("tags.name, COUNT(p.id) AS product_count").
+		Joins("JOIN product_tags pt ON pt.tag_id = tags.id").
+		Joins("JOIN products p ON p.id = pt.product_id AND p.is_active = ? AND p.deleted_at IS NULL", true).
+		Where("tags.name LIKE ?", prefix+"%").
+		Group("tags.id, tags.name").
+		Order("product_count DESC").
+		Order("tags.name ASC").
+		Limit(limit).
+		Scan(&suggestions).Error
+	return suggestions, err
+}
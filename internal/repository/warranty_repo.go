@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrWarrantyClaimOpen is returned when a warranty already has a claim being handled
+var ErrWarrantyClaimOpen = errors.New("a claim for this warranty is already being handled")
+
+// ErrWarrantyClaimChanged is returned when a warranty claim left the status it was read in before
+// it could be moved on
+var ErrWarrantyClaimChanged = errors.New("warranty claim was updated meanwhile; please reload it")
+
+type WarrantyRepository interface {
+	// CreateForOrder saves the warranties of a delivered order; items that already have one are
+	// skipped, so activating an order twice is harmless. Returns how many were created.
+	CreateForOrder(ctx context.Context, warranties []model.Warranty) (int64, error)
+	FindByID(ctx context.Context, id string) (*model.Warranty, error)
+	// FindByUserID lists the buyer's warranties, newest first; activeOnly leaves out expired ones
+	FindByUserID(ctx context.Context, userID string, activeOnly bool, now time.Time, page, limit int) ([]model.Warranty, int64, error)
+
+	// CreateClaim files the claim and its first event, unless the warranty has an open claim
+	CreateClaim(ctx context.Context, claim *model.WarrantyClaim, event *model.WarrantyClaimEvent) error
+	FindClaimByID(ctx context.Context, id string) (*model.WarrantyClaim, error)
+	FindClaimsByUserID(ctx context.Context, userID string, status string, page, limit int) ([]model.WarrantyClaim, int64, error)
+	FindClaimsBySellerID(ctx context.Context, sellerID string, status string, page, limit int) ([]model.WarrantyClaim, int64, error)
+	// UpdateClaimStatus moves the claim from one status to another and records the event
+	UpdateClaimStatus(ctx context.Context, id string, from string, updates map[string]interface{}, event *model.WarrantyClaimEvent) error
+}
+
+type warrantyRepository struct {
+	db *gorm.DB
+}
+
+func NewWarrantyRepository(db *gorm.DB) WarrantyRepository {
+	return &warrantyRepository{db: db}
+}
+
+func (r *warrantyRepository) CreateForOrder(ctx context.Context, warranties []model.Warranty) (int64, error) {
+	if len(warranties) == 0 {
+		return 0, nil
+	}
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "order_item_id"}}, DoNothing: true}).
+		Create(&warranties)
+	return result.RowsAffected, result.Error
+}
+
+func (r *warrantyRepository) FindByID(ctx context.Context, id string) (*model.Warranty, error) {
+	var warranty model.Warranty
+	err := r.db.WithContext(ctx).
+		Preload("Claims", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC") }).
+		Where("id = ?", id).First(&warranty).Error
+	if err != nil {
+		return nil, err
+	}
+	return &warranty, nil
+}
+
+func (r *warrantyRepository) FindByUserID(ctx context.Context, userID string, activeOnly bool, now time.Time, page, limit int) ([]model.Warranty, int64, error) {
+	var warranties []model.Warranty
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.Warranty{}).Where("user_id = ?", userID)
+	if activeOnly {
+		query = query.Where("expires_at > ?", now)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("starts_at DESC").Offset(offset).Limit(limit).Find(&warranties).Error
+	return warranties, total, err
+}
+
+func (r *warrantyRepository) CreateClaim(ctx context.Context, claim *model.WarrantyClaim, event *model.WarrantyClaimEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the warranty keeps two concurrent claims from both seeing no open one
+		var warranty model.Warranty
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", claim.WarrantyID).
+			First(&warranty).Error; err != nil {
+			return err
+		}
+		var open int64
+		if err := tx.Model(&model.WarrantyClaim{}).
+			Where("warranty_id = ? AND status IN ?", warranty.ID, model.WarrantyClaimOpenStatuses).
+			Count(&open).Error; err != nil {
+			return err
+		}
+		if open > 0 {
+			return ErrWarrantyClaimOpen
+		}
+
+		if err := tx.Create(claim).Error; err != nil {
+			return err
+		}
+		event.ClaimID = claim.ID
+		return tx.Create(event).Error
+	})
+}
+
+func (r *warrantyRepository) FindClaimByID(ctx context.Context, id string) (*model.WarrantyClaim, error) {
+	var claim model.WarrantyClaim
+	err := r.db.WithContext(ctx).
+		Preload("Warranty").
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Where("id = ?", id).First(&claim).Error
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+func (r *warrantyRepository) FindClaimsByUserID(ctx context.Context, userID string, status string, page, limit int) ([]model.WarrantyClaim, int64, error) {
+	return r.listClaims(r.db.WithContext(ctx).Where("user_id = ?", userID), status, page, limit)
+}
+
+func (r *warrantyRepository) FindClaimsBySellerID(ctx context.Context, sellerID string, status string, page, limit int) ([]model.WarrantyClaim, int64, error) {
+	return r.listClaims(r.db.WithContext(ctx).Where("seller_id = ?", sellerID), status, page, limit)
+}
+
+func (r *warrantyRepository) listClaims(query *gorm.DB, status string, page, limit int) ([]model.WarrantyClaim, int64, error) {
+	var claims []model.WarrantyClaim
+	var total int64
+
+	query = query.Model(&model.WarrantyClaim{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("Warranty").Order("created_at DESC").Offset(offset).Limit(limit).Find(&claims).Error
+	return claims, total, err
+}
+
+func (r *warrantyRepository) UpdateClaimStatus(ctx context.Context, id string, from string, updates map[string]interface{}, event *model.WarrantyClaimEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		values := map[string]interface{}{"status": event.Status}
+		for column, value := range updates {
+			values[column] = value
+		}
+		result := tx.Model(&model.WarrantyClaim{}).
+			Where("id = ? AND status = ?", id, from).
+			Updates(values)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrWarrantyClaimChanged
+		}
+		event.ClaimID = id
+		return tx.Create(event).Error
+	})
+}
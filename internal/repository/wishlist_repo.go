@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"yourapp/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WishlistRepository interface {
+	FindByID(ctx context.Context, id string) (*model.WishlistItem, error)
+	FindByUserID(ctx context.Context, userID string) ([]model.WishlistItem, error)
+	// Add puts the product on the user's wishlist; a product already on it is left as it is
+	Add(ctx context.Context, item *model.WishlistItem) error
+	// MoveFromCart removes the cart item and adds its product to the user's wishlist in one
+	// transaction
+	MoveFromCart(ctx context.Context, userID string, cartItem *model.CartItem) error
+	Delete(ctx context.Context, id string) error
+}
+
+type wishlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWishlistRepository(db *gorm.DB) WishlistRepository {
+	return &wishlistRepository{db: db}
+}
+
+func (r *wishlistRepository) FindByID(ctx context.Context, id string) (*model.WishlistItem, error) {
+	var item model.WishlistItem
+	err := r.db.WithContext(ctx).Preload("Product").Preload("Product.Seller").Preload("Product.ProductImages").Where("id = ?", id).First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *wishlistRepository) FindByUserID(ctx context.Context, userID string) ([]model.WishlistItem, error) {
+	var items []model.WishlistItem
+	err := r.db.WithContext(ctx).Preload("Product").Preload("Product.Seller").Preload("Product.ProductImages").
+		Where("user_id = ?", userID).Order("created_at DESC").Find(&items).Error
+	return items, err
+}
+
+func (r *wishlistRepository) Add(ctx context.Context, item *model.WishlistItem) error {
+	return addWishlistItem(r.db.WithContext(ctx), item)
+}
+
+func (r *wishlistRepository) MoveFromCart(ctx context.Context, userID string, cartItem *model.CartItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		item := &model.WishlistItem{
+			UserID:    userID,
+			ProductID: cartItem.ProductID,
+			Price:     cartItem.Price,
+		}
+		if err := addWishlistItem(tx, item); err != nil {
+			return err
+		}
+		return tx.Delete(&model.CartItem{}, "id = ?", cartItem.ID).Error
+	})
+}
+
+func (r *wishlistRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&model.WishlistItem{}, "id = ?", id).Error
+}
+
+func addWishlistItem(db *gorm.DB, item *model.WishlistItem) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "product_id"}},
+		DoNothing: true,
+	}).Create(item).Error
+}
@@ -0,0 +1,546 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// addressValidationBatchSize is how many addresses a run checks per query
+const addressValidationBatchSize = 500
+
+var (
+	ErrAddressValidationRunning = errors.New("an address validation run is already in progress")
+	ErrNoRegions                = errors.New("no region reference data to validate against")
+	ErrAddressSuggestionStale   = errors.New("address changed after it was checked")
+	ErrNoAddressSuggestion      = errors.New("no corrected address could be suggested")
+)
+
+// AddressValidationService checks saved addresses against the region reference data. An admin
+// starts a run in the background; addresses with an unknown or mismatched city, province or
+// postal code are flagged, with a corrected suggestion the owner can confirm or dismiss.
+type AddressValidationService interface {
+	// ImportRegions creates or updates region reference data
+	ImportRegions(ctx context.Context, regions []model.Region) (*RegionImportResult, error)
+	GetRegions(ctx context.Context) ([]model.Region, error)
+
+	// StartRun starts validating every address in the background and returns the new run
+	StartRun(ctx context.Context, adminID string) (*AddressValidationReport, error)
+	GetRun(ctx context.Context, runID string) (*AddressValidationReport, error)
+	ListFlagged(ctx context.Context, status string, issue string, page, limit int) ([]model.AddressValidation, int64, error)
+
+	GetSuggestions(ctx context.Context, userID string) ([]model.AddressValidation, error)
+	// ConfirmSuggestion applies the suggestion to the user's address and returns the address
+	ConfirmSuggestion(ctx context.Context, userID, validationID string) (*model.Address, error)
+	DismissSuggestion(ctx context.Context, userID, validationID string) (*model.AddressValidation, error)
+}
+
+type RegionImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+// AddressValidationReport is a run with its flagged addresses counted per issue
+type AddressValidationReport struct {
+	*model.AddressValidationRun
+	IssueCounts map[string]int `json:"issue_counts"`
+}
+
+type addressValidationService struct {
+	repo       repository.AddressValidationRepository
+	regionRepo repository.RegionRepository
+	runMu      sync.Mutex // Held while a run is in progress in this process
+}
+
+func NewAddressValidationService(repo repository.AddressValidationRepository, regionRepo repository.RegionRepository) AddressValidationService {
+	s := &addressValidationService{
+		repo:       repo,
+		regionRepo: regionRepo,
+	}
+	// A run that was in progress when the process stopped will never finish
+	if n, err := repo.FailRunningRuns(context.Background(), "interrupted by a restart", time.Now()); err != nil {
+		log.Printf("Warning: Failed to close interrupted address validation runs: %v", err)
+	} else if n > 0 {
+		log.Printf("Marked %d interrupted address validation run(s) as failed", n)
+	}
+	return s
+}
+
+func (s *addressValidationService) ImportRegions(ctx context.Context, regions []model.Region) (*RegionImportResult, error) {
+	created, updated, err := s.regionRepo.Upsert(ctx, regions)
+	if err != nil {
+		return nil, err
+	}
+	return &RegionImportResult{Created: created, Updated: updated}, nil
+}
+
+func (s *addressValidationService) GetRegions(ctx context.Context) ([]model.Region, error) {
+	return s.regionRepo.FindAll(ctx)
+}
+
+func (s *addressValidationService) StartRun(ctx context.Context, adminID string) (*AddressValidationReport, error) {
+	if !s.runMu.TryLock() {
+		return nil, ErrAddressValidationRunning
+	}
+	// Another instance may be running one
+	if _, err := s.repo.FindRunningRun(ctx); err == nil {
+		s.runMu.Unlock()
+		return nil, ErrAddressValidationRunning
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.runMu.Unlock()
+		return nil, err
+	}
+
+	regions, err := s.regionRepo.FindAll(ctx)
+	if err != nil {
+		s.runMu.Unlock()
+		return nil, err
+	}
+	if len(regions) == 0 {
+		s.runMu.Unlock()
+		return nil, ErrNoRegions
+	}
+
+	run := &model.AddressValidationRun{
+		Status:    model.AddressValidationRunRunning,
+		StartedBy: adminID,
+		StartedAt: time.Now(),
+	}
+	if err := s.repo.CreateRun(ctx, run); err != nil {
+		s.runMu.Unlock()
+		return nil, err
+	}
+
+	report := &AddressValidationReport{AddressValidationRun: run, IssueCounts: map[string]int{}}
+	// Work on a copy; the caller gets the run as it was created
+	working := *run
+	go func() {
+		defer s.runMu.Unlock()
+		s.execute(&working, newRegionIndex(regions))
+	}()
+	return report, nil
+}
+
+// execute checks every address in ID order and records the outcome on the run
+func (s *addressValidationService) execute(run *model.AddressValidationRun, index *regionIndex) {
+	ctx := context.Background()
+	issueCounts := map[string]int{}
+
+	fail := func(err error) {
+		msg := err.Error()
+		now := time.Now()
+		run.Status = model.AddressValidationRunFailed
+		run.Error = &msg
+		run.FinishedAt = &now
+		if err := s.repo.UpdateRun(ctx, run); err != nil {
+			log.Printf("Failed to record failed address validation run %s: %v", run.ID, err)
+		}
+		log.Printf("Address validation run %s failed: %v", run.ID, msg)
+	}
+
+	afterID := ""
+	for {
+		addresses, err := s.repo.FindAddressesAfter(ctx, afterID, addressValidationBatchSize)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if len(addresses) == 0 {
+			break
+		}
+		afterID = addresses[len(addresses)-1].ID
+
+		addressIDs := make([]string, 0, len(addresses))
+		for _, address := range addresses {
+			addressIDs = append(addressIDs, address.ID)
+		}
+		existing, err := s.repo.FindByAddressIDs(ctx, addressIDs)
+		if err != nil {
+			fail(err)
+			return
+		}
+		byAddress := make(map[string]model.AddressValidation, len(existing))
+		for _, validation := range existing {
+			byAddress[validation.AddressID] = validation
+		}
+
+		var validIDs []string
+		for _, address := range addresses {
+			run.Checked++
+			previous, hasPrevious := byAddress[address.ID]
+			// Do not ask again about an address the user already answered for and has not changed since
+			if hasPrevious && previous.Status != model.AddressValidationPending &&
+				previous.ResolvedAt != nil && !address.UpdatedAt.After(*previous.ResolvedAt) {
+				run.Skipped++
+				continue
+			}
+
+			result := index.validate(address)
+			if len(result.issues) == 0 {
+				run.Valid++
+				validIDs = append(validIDs, address.ID)
+				continue
+			}
+
+			run.Flagged++
+			for _, issue := range result.issues {
+				issueCounts[issue]++
+			}
+			if result.hasSuggestion() {
+				run.WithSuggestion++
+			}
+
+			validation := model.AddressValidation{
+				AddressID: address.ID,
+				UserID:    address.UserID,
+			}
+			if hasPrevious {
+				validation.ID = previous.ID
+				validation.CreatedAt = previous.CreatedAt
+			}
+			validation.RunID = run.ID
+			validation.Status = model.AddressValidationPending
+			validation.Issues = strings.Join(result.issues, ",")
+			validation.SuggestedCity = result.city
+			validation.SuggestedProvince = result.province
+			validation.SuggestedPostalCode = result.postalCode
+			if err := s.repo.Save(ctx, &validation); err != nil {
+				fail(err)
+				return
+			}
+		}
+		if err := s.repo.DeletePendingByAddressIDs(ctx, validIDs); err != nil {
+			fail(err)
+			return
+		}
+
+		// Report progress as the run goes
+		if err := s.repo.UpdateRun(ctx, run); err != nil {
+			fail(err)
+			return
+		}
+	}
+
+	counts, err := json.Marshal(issueCounts)
+	if err != nil {
+		fail(err)
+		return
+	}
+	now := time.Now()
+	run.IssueCounts = string(counts)
+	run.Status = model.AddressValidationRunCompleted
+	run.FinishedAt = &now
+	if err := s.repo.UpdateRun(ctx, run); err != nil {
+		fail(err)
+		return
+	}
+	log.Printf("✅ Address validation run %s completed: %d checked, %d flagged, %d skipped", run.ID, run.Checked, run.Flagged, run.Skipped)
+}
+
+func (s *addressValidationService) GetRun(ctx context.Context, runID string) (*AddressValidationReport, error) {
+	run, err := s.repo.FindRunByID(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	report := &AddressValidationReport{AddressValidationRun: run, IssueCounts: map[string]int{}}
+	if run.IssueCounts != "" {
+		if err := json.Unmarshal([]byte(run.IssueCounts), &report.IssueCounts); err != nil {
+			return nil, fmt.Errorf("failed to decode issue counts: %w", err)
+		}
+	}
+	return report, nil
+}
+
+func (s *addressValidationService) ListFlagged(ctx context.Context, status string, issue string, page, limit int) ([]model.AddressValidation, int64, error) {
+	switch status {
+	case "", model.AddressValidationPending, model.AddressValidationConfirmed, model.AddressValidationDismissed:
+	default:
+		return nil, 0, errors.New("invalid status: " + status)
+	}
+	switch issue {
+	case "", model.AddressIssuePostalCodeFormat, model.AddressIssueUnknownProvince, model.AddressIssueUnknownCity,
+		model.AddressIssueCityProvinceMismatch, model.AddressIssuePostalCodeMismatch:
+	default:
+		return nil, 0, errors.New("invalid issue: " + issue)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	validations, total, err := s.repo.List(ctx, status, issue, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get address validations: " + err.Error())
+	}
+	return validations, total, nil
+}
+
+func (s *addressValidationService) GetSuggestions(ctx context.Context, userID string) ([]model.AddressValidation, error) {
+	return s.repo.FindPendingByUserID(ctx, userID)
+}
+
+// findPending loads a pending validation of the user's (still existing) address
+func (s *addressValidationService) findPending(ctx context.Context, userID, validationID string) (*model.AddressValidation, error) {
+	validation, err := s.repo.FindByID(ctx, validationID)
+	if err != nil {
+		return nil, err
+	}
+	// A deleted address is not preloaded
+	if validation.UserID != userID || validation.Address.ID == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if validation.Status != model.AddressValidationPending {
+		return nil, errors.New("suggestion was already " + validation.Status)
+	}
+	return validation, nil
+}
+
+func (s *addressValidationService) ConfirmSuggestion(ctx context.Context, userID, validationID string) (*model.Address, error) {
+	validation, err := s.findPending(ctx, userID, validationID)
+	if err != nil {
+		return nil, err
+	}
+	if validation.SuggestedCity == nil && validation.SuggestedProvince == nil && validation.SuggestedPostalCode == nil {
+		return nil, ErrNoAddressSuggestion
+	}
+	// The suggestion was worked out for the address as it was then
+	address := validation.Address
+	if address.UpdatedAt.After(validation.UpdatedAt) {
+		return nil, ErrAddressSuggestionStale
+	}
+
+	if validation.SuggestedCity != nil {
+		address.City = *validation.SuggestedCity
+	}
+	if validation.SuggestedProvince != nil {
+		address.Province = *validation.SuggestedProvince
+	}
+	if validation.SuggestedPostalCode != nil {
+		address.PostalCode = *validation.SuggestedPostalCode
+	}
+	now := time.Now()
+	validation.Status = model.AddressValidationConfirmed
+	validation.ResolvedAt = &now
+	if err := s.repo.ApplySuggestion(ctx, validation, &address); err != nil {
+		return nil, err
+	}
+	address.UpdatedAt = now
+	return &address, nil
+}
+
+func (s *addressValidationService) DismissSuggestion(ctx context.Context, userID, validationID string) (*model.AddressValidation, error) {
+	validation, err := s.findPending(ctx, userID, validationID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	validation.Status = model.AddressValidationDismissed
+	validation.ResolvedAt = &now
+	if err := s.repo.Save(ctx, validation); err != nil {
+		return nil, err
+	}
+	return validation, nil
+}
+
+// regionIndex looks regions up by normalized province and city name
+type regionIndex struct {
+	provinces map[string]string         // Normalized name => reference name
+	cities    map[string][]model.Region // Normalized city name => its regions, one per postal code range
+	regions   []model.Region
+}
+
+func newRegionIndex(regions []model.Region) *regionIndex {
+	index := &regionIndex{
+		provinces: make(map[string]string),
+		cities:    make(map[string][]model.Region),
+		regions:   regions,
+	}
+	for _, region := range regions {
+		index.provinces[normalizeRegionName(region.Province)] = region.Province
+		key := normalizeRegionName(region.City)
+		index.cities[key] = append(index.cities[key], region)
+	}
+	return index
+}
+
+// addressCheck is the outcome of validating one address; suggested fields are only set when
+// they differ from the address and the corrected address would be valid
+type addressCheck struct {
+	issues     []string
+	city       *string
+	province   *string
+	postalCode *string
+}
+
+func (c addressCheck) hasSuggestion() bool {
+	return c.city != nil || c.province != nil || c.postalCode != nil
+}
+
+func (idx *regionIndex) validate(address model.Address) addressCheck {
+	issues := idx.issues(address.City, address.Province, address.PostalCode)
+	check := addressCheck{issues: issues}
+	if len(issues) == 0 {
+		return check
+	}
+
+	city, province, postalCode := idx.suggest(address.City, address.Province, address.PostalCode)
+	if len(idx.issues(city, province, postalCode)) > 0 {
+		return check
+	}
+	if normalizeRegionName(city) != normalizeRegionName(address.City) {
+		check.city = &city
+	}
+	if normalizeRegionName(province) != normalizeRegionName(address.Province) {
+		check.province = &province
+	}
+	if postalCode != address.PostalCode {
+		check.postalCode = &postalCode
+	}
+	return check
+}
+
+func (idx *regionIndex) issues(city, province, postalCode string) []string {
+	var issues []string
+	validPostalCode := isPostalCode(postalCode)
+	if !validPostalCode {
+		issues = append(issues, model.AddressIssuePostalCodeFormat)
+	}
+
+	provinceKey := normalizeRegionName(province)
+	if _, ok := idx.provinces[provinceKey]; !ok {
+		issues = append(issues, model.AddressIssueUnknownProvince)
+	}
+
+	candidates, ok := idx.cities[normalizeRegionName(city)]
+	if !ok {
+		return append(issues, model.AddressIssueUnknownCity)
+	}
+	inProvince := filterRegions(candidates, func(r model.Region) bool { return normalizeRegionName(r.Province) == provinceKey })
+	if _, known := idx.provinces[provinceKey]; known && len(inProvince) == 0 {
+		issues = append(issues, model.AddressIssueCityProvinceMismatch)
+	}
+	if len(inProvince) > 0 {
+		candidates = inProvince
+	}
+	if validPostalCode && len(filterRegions(candidates, regionHasPostalCode(postalCode))) == 0 {
+		issues = append(issues, model.AddressIssuePostalCodeMismatch)
+	}
+	return issues
+}
+
+// suggest works out the reference city, province and postal code the address most likely means.
+// The city is trusted first, then the province, then the postal code; a field is only replaced
+// when the others point to a single answer.
+func (idx *regionIndex) suggest(city, province, postalCode string) (string, string, string) {
+	// A postal code typed with spaces, dots or dashes
+	if !isPostalCode(postalCode) {
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			if r == ' ' || r == '.' || r == '-' {
+				return -1
+			}
+			return 'x'
+		}, postalCode)
+		if isPostalCode(digits) {
+			postalCode = digits
+		}
+	}
+	validPostalCode := isPostalCode(postalCode)
+
+	candidates, ok := idx.cities[normalizeRegionName(city)]
+	if !ok {
+		// Fall back to the regions the postal code belongs to
+		if !validPostalCode {
+			return city, province, postalCode
+		}
+		candidates = filterRegions(idx.regions, regionHasPostalCode(postalCode))
+	}
+
+	provinceKey := normalizeRegionName(province)
+	if inProvince := filterRegions(candidates, func(r model.Region) bool { return normalizeRegionName(r.Province) == provinceKey }); len(inProvince) > 0 {
+		candidates = inProvince
+	}
+	if validPostalCode {
+		if matching := filterRegions(candidates, regionHasPostalCode(postalCode)); len(matching) > 0 {
+			candidates = matching
+		}
+	}
+
+	// The remaining candidates must agree on a single city
+	places := make(map[string]model.Region)
+	for _, region := range candidates {
+		places[region.Province+"|"+region.City] = region
+	}
+	if len(places) != 1 {
+		return city, province, postalCode
+	}
+	region := candidates[0]
+	city, province = region.City, region.Province
+	// A postal code is only suggested when the city has just one
+	if (!validPostalCode || !regionHasPostalCode(postalCode)(region)) && len(candidates) == 1 && region.PostalCodeFrom == region.PostalCodeTo {
+		postalCode = region.PostalCodeFrom
+	}
+	return city, province, postalCode
+}
+
+// normalizeRegionName lowercases a place name and drops punctuation and administrative prefixes,
+// so "Kota Bandung" and "bandung" or "Prov. Jawa Barat" and "Jawa Barat" match
+func normalizeRegionName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return ' '
+	}, name)
+	words := strings.Fields(name)
+	for len(words) > 1 {
+		switch words[0] {
+		case "kota", "kabupaten", "kab", "provinsi", "prov", "propinsi":
+			words = words[1:]
+			continue
+		}
+		break
+	}
+	return strings.Join(words, " ")
+}
+
+func regionHasPostalCode(postalCode string) func(model.Region) bool {
+	return func(r model.Region) bool {
+		return postalCode >= r.PostalCodeFrom && postalCode <= r.PostalCodeTo
+	}
+}
+
+func filterRegions(regions []model.Region, keep func(model.Region) bool) []model.Region {
+	var filtered []model.Region
+	for _, region := range regions {
+		if keep(region) {
+			filtered = append(filtered, region)
+		}
+	}
+	return filtered
+}
+
+func isPostalCode(code string) bool {
+	if len(code) != 5 {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
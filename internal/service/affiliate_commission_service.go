@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// affiliateLinkSlugBytes makes 10-character short link slugs
+const affiliateLinkSlugBytes = 5
+
+// AffiliateCommissionService attributes orders to affiliates and pays them for it. The affiliate
+// code comes from the checkout request or a short link click; a commission accrues once the
+// referred order is delivered and is paid out in bundles by an admin.
+type AffiliateCommissionService interface {
+	// AttributeOrder is a before-order-create hook: it keeps the order's affiliate code only when
+	// it belongs to an active affiliate other than the buyer
+	AttributeOrder(ctx context.Context, order *model.Order) error
+	// OnOrderStatusChange is an after-order-status-change hook: delivered orders accrue a
+	// commission, cancelled ones void it
+	OnOrderStatusChange(ctx context.Context, order *model.Order, from string) error
+
+	CreateLink(ctx context.Context, userID string, req CreateAffiliateLinkRequest) (*AffiliateLinkResponse, error)
+	GetLinks(ctx context.Context, userID string) ([]AffiliateLinkResponse, error)
+	// ResolveLink counts a click and returns where the short link points to
+	ResolveLink(ctx context.Context, slug string) (*ResolvedAffiliateLink, error)
+
+	GetEarnings(ctx context.Context, userID string, status string, page, limit int) (*AffiliateEarnings, error)
+	GetPayouts(ctx context.Context, userID string, page, limit int) ([]model.AffiliatePayout, int64, error)
+
+	ListPayouts(ctx context.Context, affiliateUserID string, status string, page, limit int) ([]model.AffiliatePayout, int64, error)
+	CreatePayout(ctx context.Context, adminID string, affiliateUserID string) (*model.AffiliatePayout, error)
+	MarkPayoutPaid(ctx context.Context, payoutID string, reference string) (*model.AffiliatePayout, error)
+	CancelPayout(ctx context.Context, payoutID string) (*model.AffiliatePayout, error)
+	VoidCommission(ctx context.Context, commissionID string, reason string) (*model.AffiliateCommission, error)
+}
+
+type affiliateCommissionService struct {
+	affiliateRepo repository.AffiliateRepository
+	productRepo   repository.ProductRepository
+	clientURL     string
+	serverURL     string
+	rate          int
+	minPayout     int
+}
+
+type CreateAffiliateLinkRequest struct {
+	APIKeyID  string  `json:"api_key_id" binding:"required"` // The key whose affiliate code the link carries
+	ProductID *string `json:"product_id,omitempty"`          // Optional: links to the home page when empty
+}
+
+type AffiliateLinkResponse struct {
+	model.AffiliateLink
+	ShortURL string `json:"short_url"`
+}
+
+type ResolvedAffiliateLink struct {
+	TargetURL     string
+	AffiliateCode string
+}
+
+// AffiliateEarnings is the affiliate portal overview: totals per state plus one page of commissions
+type AffiliateEarnings struct {
+	Summary     AffiliateEarningsSummary    `json:"summary"`
+	Commissions []model.AffiliateCommission `json:"commissions"`
+	Total       int64                       `json:"total"`
+	Page        int                         `json:"page"`
+	Limit       int                         `json:"limit"`
+}
+
+type AffiliateEarningsSummary struct {
+	Available AffiliateEarningsBucket `json:"available"` // Accrued, not in a payout yet
+	InPayout  AffiliateEarningsBucket `json:"in_payout"` // Reserved by a payout that is not paid yet
+	Paid      AffiliateEarningsBucket `json:"paid"`
+	Void      AffiliateEarningsBucket `json:"void"`
+}
+
+type AffiliateEarningsBucket struct {
+	Count  int64 `json:"count"`
+	Amount int64 `json:"amount"`
+}
+
+func NewAffiliateCommissionService(
+	affiliateRepo repository.AffiliateRepository,
+	productRepo repository.ProductRepository,
+	cfg *config.Config,
+) AffiliateCommissionService {
+	return &affiliateCommissionService{
+		affiliateRepo: affiliateRepo,
+		productRepo:   productRepo,
+		clientURL:     strings.TrimRight(cfg.ClientURL, "/"),
+		serverURL:     strings.TrimRight(cfg.ServerURL, "/"),
+		rate:          cfg.AffiliateCommissionBasisPoints,
+		minPayout:     cfg.AffiliateMinPayout,
+	}
+}
+
+func (s *affiliateCommissionService) AttributeOrder(ctx context.Context, order *model.Order) error {
+	if order.AffiliateCode == nil {
+		return nil
+	}
+	code := strings.ToLower(strings.TrimSpace(*order.AffiliateCode))
+	order.AffiliateCode = nil
+	if code == "" {
+		return nil
+	}
+
+	// An unknown code never blocks a checkout; the order just is not attributed
+	key, err := s.affiliateRepo.FindKeyByCode(ctx, code)
+	if err != nil || key.RevokedAt != nil {
+		return nil
+	}
+	if key.UserID == order.UserID {
+		log.Printf("🔗 Ignoring self-referral with affiliate code %s by user %s", code, order.UserID)
+		return nil
+	}
+	order.AffiliateCode = &code
+	return nil
+}
+
+func (s *affiliateCommissionService) OnOrderStatusChange(ctx context.Context, order *model.Order, from string) error {
+	if order.AffiliateCode == nil {
+		return nil
+	}
+
+	switch order.Status {
+	case "delivered":
+		return s.accrue(ctx, order)
+	case "cancelled":
+		voided, err := s.affiliateRepo.VoidCommissionByOrderID(ctx, order.ID, "Order cancelled", time.Now())
+		if err != nil {
+			return err
+		}
+		if voided {
+			log.Printf("🔗 Voided affiliate commission for cancelled order %s", order.OrderNumber)
+		}
+	}
+	return nil
+}
+
+// accrue records the commission for a delivered order; delivering an order twice does not pay twice
+func (s *affiliateCommissionService) accrue(ctx context.Context, order *model.Order) error {
+	key, err := s.affiliateRepo.FindKeyByCode(ctx, *order.AffiliateCode)
+	if err != nil {
+		return fmt.Errorf("affiliate code %s not found: %w", *order.AffiliateCode, err)
+	}
+
+	base := order.Subtotal - order.TotalDiscount
+	if base <= 0 || s.rate <= 0 {
+		return nil
+	}
+	commission := &model.AffiliateCommission{
+		AffiliateUserID: key.UserID,
+		AffiliateCode:   key.AffiliateCode,
+		OrderID:         order.ID,
+		OrderNumber:     order.OrderNumber,
+		BaseAmount:      base,
+		RateBasisPoints: s.rate,
+		Amount:          base * s.rate / 10000,
+		Status:          model.AffiliateCommissionPending,
+	}
+	created, err := s.affiliateRepo.CreateCommission(ctx, commission)
+	if err != nil {
+		return err
+	}
+	if created {
+		log.Printf("🔗 Affiliate %s earned %d on order %s", key.AffiliateCode, commission.Amount, order.OrderNumber)
+	}
+	return nil
+}
+
+func (s *affiliateCommissionService) CreateLink(ctx context.Context, userID string, req CreateAffiliateLinkRequest) (*AffiliateLinkResponse, error) {
+	key, err := s.affiliateRepo.FindKeyByID(ctx, req.APIKeyID)
+	if err != nil || key.UserID != userID {
+		return nil, errors.New("API key not found")
+	}
+	if key.RevokedAt != nil {
+		return nil, errors.New("API key has been revoked")
+	}
+
+	if req.ProductID != nil && *req.ProductID == "" {
+		req.ProductID = nil
+	}
+	if req.ProductID != nil {
+		product, err := s.productRepo.FindByID(*req.ProductID)
+		if err != nil || !product.IsActive {
+			return nil, errors.New("product not found")
+		}
+	}
+
+	slug := make([]byte, affiliateLinkSlugBytes)
+	if _, err := rand.Read(slug); err != nil {
+		return nil, fmt.Errorf("failed to generate link: %w", err)
+	}
+	link := &model.AffiliateLink{
+		UserID:        userID,
+		APIKeyID:      key.ID,
+		AffiliateCode: key.AffiliateCode,
+		Slug:          hex.EncodeToString(slug),
+		ProductID:     req.ProductID,
+	}
+	if err := s.affiliateRepo.CreateLink(ctx, link); err != nil {
+		return nil, fmt.Errorf("failed to create link: %w", err)
+	}
+	return s.toLinkResponse(*link), nil
+}
+
+func (s *affiliateCommissionService) GetLinks(ctx context.Context, userID string) ([]AffiliateLinkResponse, error) {
+	links, err := s.affiliateRepo.FindLinksByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]AffiliateLinkResponse, 0, len(links))
+	for _, link := range links {
+		result = append(result, *s.toLinkResponse(link))
+	}
+	return result, nil
+}
+
+func (s *affiliateCommissionService) ResolveLink(ctx context.Context, slug string) (*ResolvedAffiliateLink, error) {
+	link, err := s.affiliateRepo.FindLinkBySlug(ctx, slug)
+	if err != nil {
+		return nil, errors.New("link not found")
+	}
+	if err := s.affiliateRepo.IncrementLinkClicks(ctx, link.ID); err != nil {
+		log.Printf("⚠️  Failed to count click on affiliate link %s: %v", link.Slug, err)
+	}
+
+	productID := ""
+	if link.ProductID != nil {
+		productID = *link.ProductID
+	}
+	return &ResolvedAffiliateLink{
+		TargetURL:     affiliateShareURL(s.clientURL, productID, link.AffiliateCode),
+		AffiliateCode: link.AffiliateCode,
+	}, nil
+}
+
+func (s *affiliateCommissionService) toLinkResponse(link model.AffiliateLink) *AffiliateLinkResponse {
+	return &AffiliateLinkResponse{
+		AffiliateLink: link,
+		ShortURL:      fmt.Sprintf("%s/r/%s", s.serverURL, link.Slug),
+	}
+}
+
+func (s *affiliateCommissionService) GetEarnings(ctx context.Context, userID string, status string, page, limit int) (*AffiliateEarnings, error) {
+	totals, err := s.affiliateRepo.SumCommissionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("failed to get earnings: " + err.Error())
+	}
+	commissions, total, err := s.affiliateRepo.FindCommissionsByUserID(ctx, userID, status, page, limit)
+	if err != nil {
+		return nil, errors.New("failed to get commissions: " + err.Error())
+	}
+
+	earnings := &AffiliateEarnings{
+		Commissions: commissions,
+		Total:       total,
+		Page:        page,
+		Limit:       limit,
+	}
+	for _, row := range totals {
+		var bucket *AffiliateEarningsBucket
+		switch {
+		case row.Status == model.AffiliateCommissionPending && row.InPayout:
+			bucket = &earnings.Summary.InPayout
+		case row.Status == model.AffiliateCommissionPending:
+			bucket = &earnings.Summary.Available
+		case row.Status == model.AffiliateCommissionPaid:
+			bucket = &earnings.Summary.Paid
+		case row.Status == model.AffiliateCommissionVoid:
+			bucket = &earnings.Summary.Void
+		default:
+			continue
+		}
+		bucket.Count += row.Count
+		bucket.Amount += row.Amount
+	}
+	return earnings, nil
+}
+
+func (s *affiliateCommissionService) GetPayouts(ctx context.Context, userID string, page, limit int) ([]model.AffiliatePayout, int64, error) {
+	return s.affiliateRepo.FindPayouts(ctx, userID, "", page, limit)
+}
+
+func (s *affiliateCommissionService) ListPayouts(ctx context.Context, affiliateUserID string, status string, page, limit int) ([]model.AffiliatePayout, int64, error) {
+	return s.affiliateRepo.FindPayouts(ctx, affiliateUserID, status, page, limit)
+}
+
+func (s *affiliateCommissionService) CreatePayout(ctx context.Context, adminID string, affiliateUserID string) (*model.AffiliatePayout, error) {
+	payout := &model.AffiliatePayout{
+		AffiliateUserID: affiliateUserID,
+		CreatedBy:       adminID,
+	}
+	if err := s.affiliateRepo.CreatePayout(ctx, payout, s.minPayout); err != nil {
+		if errors.Is(err, repository.ErrPayoutBelowMinimum) {
+			return nil, fmt.Errorf("%w (minimum %d)", err, s.minPayout)
+		}
+		return nil, err
+	}
+
+	log.Printf("💸 Affiliate payout %s of %d created for user %s by admin %s", payout.ID, payout.Amount, affiliateUserID, adminID)
+	return payout, nil
+}
+
+func (s *affiliateCommissionService) MarkPayoutPaid(ctx context.Context, payoutID string, reference string) (*model.AffiliatePayout, error) {
+	if _, err := s.affiliateRepo.FindPayoutByID(ctx, payoutID); err != nil {
+		return nil, errors.New("payout not found")
+	}
+	if err := s.affiliateRepo.MarkPayoutPaid(ctx, payoutID, reference, time.Now()); err != nil {
+		return nil, err
+	}
+
+	log.Printf("💸 Affiliate payout %s marked paid (ref %s)", payoutID, reference)
+	return s.affiliateRepo.FindPayoutByID(ctx, payoutID)
+}
+
+func (s *affiliateCommissionService) CancelPayout(ctx context.Context, payoutID string) (*model.AffiliatePayout, error) {
+	if _, err := s.affiliateRepo.FindPayoutByID(ctx, payoutID); err != nil {
+		return nil, errors.New("payout not found")
+	}
+	if err := s.affiliateRepo.CancelPayout(ctx, payoutID); err != nil {
+		return nil, err
+	}
+
+	log.Printf("💸 Affiliate payout %s cancelled; its commissions are available again", payoutID)
+	return s.affiliateRepo.FindPayoutByID(ctx, payoutID)
+}
+
+func (s *affiliateCommissionService) VoidCommission(ctx context.Context, commissionID string, reason string) (*model.AffiliateCommission, error) {
+	if _, err := s.affiliateRepo.FindCommissionByID(ctx, commissionID); err != nil {
+		return nil, errors.New("commission not found")
+	}
+	voided, err := s.affiliateRepo.VoidCommission(ctx, commissionID, reason, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !voided {
+		return nil, errors.New("only pending commissions outside a payout can be voided")
+	}
+	return s.affiliateRepo.FindCommissionByID(ctx, commissionID)
+}
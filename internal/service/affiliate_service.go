@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// Affiliate API limits
+const (
+	affiliateAPIKeyPrefix      = "ak_"
+	affiliateProductsMaxLimit  = 100
+	affiliateUsageMaxDays      = 90
+	affiliateCodeBytes         = 4 // 8 hex characters
+	affiliateShareSource       = "affiliate"
+	affiliateShareMedium       = "api"
+	affiliateShareCodeQueryKey = "aff"
+)
+
+// ErrAffiliateQuotaExceeded is returned when an affiliate key used up its daily quota
+var ErrAffiliateQuotaExceeded = errors.New("daily API quota exceeded")
+
+// AffiliateService runs the read-only public product API for affiliates. Each affiliate key has a
+// daily request quota; product responses include share URLs tagged with the key's affiliate code.
+type AffiliateService interface {
+	CreateKey(ctx context.Context, userID string, name string) (*CreateAffiliateKeyResponse, error)
+	GetKeys(ctx context.Context, userID string) ([]model.AffiliateAPIKey, error)
+	RevokeKey(ctx context.Context, userID string, keyID string) error
+	SetQuota(ctx context.Context, keyID string, dailyQuota int) (*model.AffiliateAPIKey, error)
+	GetUsage(ctx context.Context, userID string, keyID string, from, to string) (*AffiliateUsageReport, error)
+
+	Authenticate(ctx context.Context, rawKey string) (*model.AffiliateAPIKey, error)
+	// ConsumeQuota counts a request against the key's daily quota. The returned quota is filled in
+	// even when the request is refused with ErrAffiliateQuotaExceeded.
+	ConsumeQuota(ctx context.Context, key *model.AffiliateAPIKey) (*AffiliateQuota, error)
+
+	GetProducts(ctx context.Context, key *model.AffiliateAPIKey, query AffiliateProductQuery) (*AffiliateProductList, error)
+	GetProduct(ctx context.Context, key *model.AffiliateAPIKey, productID string) (*AffiliateProduct, error)
+	GetCategories(ctx context.Context) ([]AffiliateCategory, error)
+}
+
+type affiliateService struct {
+	affiliateRepo repository.AffiliateRepository
+	productRepo   repository.ProductRepository
+	categoryRepo  repository.CategoryRepository
+	clientURL     string
+	defaultQuota  int
+}
+
+// CreateAffiliateKeyResponse contains the plaintext key, which is only returned once
+type CreateAffiliateKeyResponse struct {
+	APIKey *model.AffiliateAPIKey `json:"api_key"`
+	Key    string                 `json:"key"`
+}
+
+// AffiliateQuota is the state of a key's quota for the current UTC day
+type AffiliateQuota struct {
+	Limit     int       `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+type AffiliateProductQuery struct {
+	Page       int
+	Limit      int
+	CategoryID string
+	Keyword    string
+}
+
+// AffiliateProduct is the public view of a product: no stock levels or seller internals
+type AffiliateProduct struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Description  *string  `json:"description,omitempty"`
+	Price        int      `json:"price"`
+	InStock      bool     `json:"in_stock"`
+	Thumbnail    *string  `json:"thumbnail,omitempty"`
+	Images       []string `json:"images"`
+	CategoryID   string   `json:"category_id"`
+	CategoryName string   `json:"category_name"`
+	ShareURL     string   `json:"share_url"` // Product page tagged with the affiliate code
+}
+
+type AffiliateProductList struct {
+	Products []AffiliateProduct `json:"products"`
+	Total    int64              `json:"total"`
+	Page     int                `json:"page"`
+	Limit    int                `json:"limit"`
+}
+
+type AffiliateCategory struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Slug     string  `json:"slug"`
+	ParentID *string `json:"parent_id,omitempty"`
+}
+
+// AffiliateUsageReport lists a key's requests per day; Refused counts requests over quota
+type AffiliateUsageReport struct {
+	APIKeyID      string              `json:"api_key_id"`
+	DailyQuota    int                 `json:"daily_quota"`
+	From          string              `json:"from"`
+	To            string              `json:"to"`
+	TotalRequests int64               `json:"total_requests"`
+	TotalRefused  int64               `json:"total_refused"`
+	Days          []AffiliateUsageDay `json:"days"`
+}
+
+type AffiliateUsageDay struct {
+	Date     string `json:"date"`
+	Requests int64  `json:"requests"`
+	Refused  int64  `json:"refused"`
+}
+
+func NewAffiliateService(
+	affiliateRepo repository.AffiliateRepository,
+	productRepo repository.ProductRepository,
+	categoryRepo repository.CategoryRepository,
+	cfg *config.Config,
+) AffiliateService {
+	return &affiliateService{
+		affiliateRepo: affiliateRepo,
+		productRepo:   productRepo,
+		categoryRepo:  categoryRepo,
+		clientURL:     strings.TrimRight(cfg.ClientURL, "/"),
+		defaultQuota:  cfg.AffiliateDailyQuota,
+	}
+}
+
+func (s *affiliateService) CreateKey(ctx context.Context, userID string, name string) (*CreateAffiliateKeyResponse, error) {
+	secret := make([]byte, 32)
+	code := make([]byte, affiliateCodeBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	if _, err := rand.Read(code); err != nil {
+		return nil, fmt.Errorf("failed to generate affiliate code: %w", err)
+	}
+	rawKey := affiliateAPIKeyPrefix + hex.EncodeToString(secret)
+
+	apiKey := &model.AffiliateAPIKey{
+		UserID:        userID,
+		Name:          name,
+		KeyPrefix:     rawKey[:len(affiliateAPIKeyPrefix)+8],
+		KeyHash:       hashAPIKey(rawKey),
+		AffiliateCode: hex.EncodeToString(code),
+		DailyQuota:    s.defaultQuota,
+	}
+	if err := s.affiliateRepo.CreateKey(ctx, apiKey); err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	log.Printf("🔑 Affiliate API key %s created for user %s", apiKey.KeyPrefix, userID)
+	return &CreateAffiliateKeyResponse{APIKey: apiKey, Key: rawKey}, nil
+}
+
+func (s *affiliateService) GetKeys(ctx context.Context, userID string) ([]model.AffiliateAPIKey, error) {
+	return s.affiliateRepo.FindKeysByUserID(ctx, userID)
+}
+
+func (s *affiliateService) RevokeKey(ctx context.Context, userID string, keyID string) error {
+	apiKey, err := s.findOwned(ctx, userID, keyID)
+	if err != nil {
+		return err
+	}
+	if apiKey.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	apiKey.RevokedAt = &now
+	return s.affiliateRepo.UpdateKey(ctx, apiKey)
+}
+
+func (s *affiliateService) SetQuota(ctx context.Context, keyID string, dailyQuota int) (*model.AffiliateAPIKey, error) {
+	if dailyQuota < 0 {
+		return nil, errors.New("daily quota cannot be negative")
+	}
+	apiKey, err := s.affiliateRepo.FindKeyByID(ctx, keyID)
+	if err != nil {
+		return nil, errors.New("API key not found")
+	}
+	apiKey.DailyQuota = dailyQuota
+	if err := s.affiliateRepo.UpdateKey(ctx, apiKey); err != nil {
+		return nil, fmt.Errorf("failed to update quota: %w", err)
+	}
+
+	log.Printf("🔑 Affiliate API key %s quota set to %d/day", apiKey.KeyPrefix, dailyQuota)
+	return apiKey, nil
+}
+
+// GetUsage reports the key's requests per day over [from, to] (YYYY-MM-DD, UTC). Defaults to the
+// last 30 days; at most 90 days at once.
+func (s *affiliateService) GetUsage(ctx context.Context, userID string, keyID string, from, to string) (*AffiliateUsageReport, error) {
+	apiKey, err := s.findOwned(ctx, userID, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	toDate := utcDate(time.Now())
+	if to != "" {
+		if toDate, err = time.Parse("2006-01-02", to); err != nil {
+			return nil, errors.New("invalid to date, expected YYYY-MM-DD")
+		}
+	}
+	fromDate := toDate.AddDate(0, 0, -29)
+	if from != "" {
+		if fromDate, err = time.Parse("2006-01-02", from); err != nil {
+			return nil, errors.New("invalid from date, expected YYYY-MM-DD")
+		}
+	}
+	if fromDate.After(toDate) {
+		return nil, errors.New("from must not be after to")
+	}
+	if toDate.Sub(fromDate) >= affiliateUsageMaxDays*24*time.Hour {
+		return nil, fmt.Errorf("date range cannot exceed %d days", affiliateUsageMaxDays)
+	}
+
+	rows, err := s.affiliateRepo.FindUsage(ctx, apiKey.ID, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AffiliateUsageReport{
+		APIKeyID:   apiKey.ID,
+		DailyQuota: apiKey.DailyQuota,
+		From:       fromDate.Format("2006-01-02"),
+		To:         toDate.Format("2006-01-02"),
+		Days:       make([]AffiliateUsageDay, 0, len(rows)),
+	}
+	for _, row := range rows {
+		// Quota changes are not historized, so refusals are computed against the current quota
+		refused := row.Requests - int64(apiKey.DailyQuota)
+		if refused < 0 {
+			refused = 0
+		}
+		report.Days = append(report.Days, AffiliateUsageDay{
+			Date:     row.Date.Format("2006-01-02"),
+			Requests: row.Requests,
+			Refused:  refused,
+		})
+		report.TotalRequests += row.Requests
+		report.TotalRefused += refused
+	}
+	return report, nil
+}
+
+func (s *affiliateService) Authenticate(ctx context.Context, rawKey string) (*model.AffiliateAPIKey, error) {
+	if !strings.HasPrefix(rawKey, affiliateAPIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.affiliateRepo.FindActiveKeyByHash(ctx, hashAPIKey(rawKey))
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err := s.affiliateRepo.TouchLastUsed(ctx, apiKey.ID, time.Now()); err != nil {
+		log.Printf("⚠️  Failed to update last_used_at for affiliate key %s: %v", apiKey.ID, err)
+	}
+	return apiKey, nil
+}
+
+func (s *affiliateService) ConsumeQuota(ctx context.Context, key *model.AffiliateAPIKey) (*AffiliateQuota, error) {
+	today := utcDate(time.Now())
+	used, err := s.affiliateRepo.IncrementUsage(ctx, key.ID, today)
+	if err != nil {
+		return nil, err
+	}
+
+	quota := &AffiliateQuota{
+		Limit:   key.DailyQuota,
+		Used:    used,
+		ResetAt: today.AddDate(0, 0, 1),
+	}
+	if remaining := int64(key.DailyQuota) - used; remaining > 0 {
+		quota.Remaining = remaining
+	}
+	if used > int64(key.DailyQuota) {
+		return quota, ErrAffiliateQuotaExceeded
+	}
+	return quota, nil
+}
+
+func (s *affiliateService) GetProducts(ctx context.Context, key *model.AffiliateAPIKey, query AffiliateProductQuery) (*AffiliateProductList, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 || query.Limit > affiliateProductsMaxLimit {
+		query.Limit = 20
+	}
+
+	var products []model.Product
+	var total int64
+	var err error
+	var categoryID *string
+	if query.CategoryID != "" {
+		categoryID = &query.CategoryID
+	}
+	filter := repository.ProductListFilter{CategoryID: categoryID, ActiveOnly: true}
+	if keyword := strings.TrimSpace(query.Keyword); keyword != "" {
+		products, total, err = s.productRepo.Search(query.Page, query.Limit, keyword, filter)
+	} else {
+		products, total, err = s.productRepo.FindAll(query.Page, query.Limit, filter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	list := &AffiliateProductList{
+		Products: make([]AffiliateProduct, 0, len(products)),
+		Total:    total,
+		Page:     query.Page,
+		Limit:    query.Limit,
+	}
+	for i := range products {
+		list.Products = append(list.Products, s.toAffiliateProduct(&products[i], key))
+	}
+	return list, nil
+}
+
+func (s *affiliateService) GetProduct(ctx context.Context, key *model.AffiliateAPIKey, productID string) (*AffiliateProduct, error) {
+	product, err := s.productRepo.FindByID(productID)
+	if err != nil || !product.IsActive {
+		return nil, errors.New("product not found")
+	}
+	result := s.toAffiliateProduct(product, key)
+	return &result, nil
+}
+
+func (s *affiliateService) GetCategories(ctx context.Context) ([]AffiliateCategory, error) {
+	categories, err := s.categoryRepo.FindAll(true)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]AffiliateCategory, 0, len(categories))
+	for _, category := range categories {
+		result = append(result, AffiliateCategory{
+			ID:       category.ID,
+			Name:     category.Name,
+			Slug:     category.Slug,
+			ParentID: category.ParentID,
+		})
+	}
+	return result, nil
+}
+
+func (s *affiliateService) toAffiliateProduct(product *model.Product, key *model.AffiliateAPIKey) AffiliateProduct {
+	images := make([]string, 0, len(product.ProductImages))
+	for _, image := range product.ProductImages {
+		images = append(images, image.ImageURL)
+	}
+	return AffiliateProduct{
+		ID:           product.ID,
+		Name:         product.Name,
+		Description:  product.Description,
+		Price:        product.Price,
+		InStock:      product.Stock > 0,
+		Thumbnail:    product.Thumbnail,
+		Images:       images,
+		CategoryID:   product.CategoryID,
+		CategoryName: product.Category.Name,
+		ShareURL:     affiliateShareURL(s.clientURL, product.ID, key.AffiliateCode),
+	}
+}
+
+// affiliateShareURL links to a storefront product page (or the home page when productID is empty)
+// with the affiliate code and UTM tags, so the frontend can attribute the visit and the analytics
+// tool can report on the campaign
+func affiliateShareURL(clientURL string, productID string, affiliateCode string) string {
+	params := url.Values{}
+	params.Set(affiliateShareCodeQueryKey, affiliateCode)
+	params.Set("utm_source", affiliateShareSource)
+	params.Set("utm_medium", affiliateShareMedium)
+	params.Set("utm_campaign", affiliateCode)
+	if productID == "" {
+		return fmt.Sprintf("%s/?%s", clientURL, params.Encode())
+	}
+	return fmt.Sprintf("%s/products/%s?%s", clientURL, productID, params.Encode())
+}
+
+func (s *affiliateService) findOwned(ctx context.Context, userID string, keyID string) (*model.AffiliateAPIKey, error) {
+	apiKey, err := s.affiliateRepo.FindKeyByID(ctx, keyID)
+	if err != nil || apiKey.UserID != userID {
+		return nil, errors.New("API key not found")
+	}
+	return apiKey, nil
+}
+
+// utcDate truncates a time to midnight of its UTC day
+func utcDate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
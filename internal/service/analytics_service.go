@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// maxFunnelReportDays bounds the date range of a funnel report
+const maxFunnelReportDays = 366
+
+// AnalyticsService tracks the product funnel (views → add to cart → checkout → purchase) and
+// reports it to sellers. Recording never fails the caller; errors are only logged.
+type AnalyticsService interface {
+	RecordProductView(ctx context.Context, product *model.Product)
+	RecordAddToCart(ctx context.Context, product *model.Product)
+	RecordCheckout(ctx context.Context, order *model.Order)
+	RecordPurchase(ctx context.Context, order *model.Order)
+	GetProductFunnel(ctx context.Context, userID string, from, to, productID string) (*ProductFunnelReport, error)
+}
+
+type analyticsService struct {
+	analyticsRepo repository.AnalyticsRepository
+	sellerRepo    repository.SellerRepository
+	calendar      BusinessCalendarService
+}
+
+// ProductFunnelReport is a seller's per-product conversion report
+type ProductFunnelReport struct {
+	From     string                 `json:"from"`
+	To       string                 `json:"to"`
+	Products []ProductFunnelMetrics `json:"products"`
+}
+
+// ProductFunnelMetrics are a product's funnel totals with conversion rates (percentages)
+type ProductFunnelMetrics struct {
+	repository.ProductFunnelRow
+	CartRate       float64 `json:"cart_rate"`       // Add to carts per view
+	CheckoutRate   float64 `json:"checkout_rate"`   // Checkouts per add to cart
+	PurchaseRate   float64 `json:"purchase_rate"`   // Purchases per checkout
+	ConversionRate float64 `json:"conversion_rate"` // Purchases per view
+}
+
+func NewAnalyticsService(analyticsRepo repository.AnalyticsRepository, sellerRepo repository.SellerRepository, calendar BusinessCalendarService) AnalyticsService {
+	return &analyticsService{
+		analyticsRepo: analyticsRepo,
+		sellerRepo:    sellerRepo,
+		calendar:      calendar,
+	}
+}
+
+func (s *analyticsService) RecordProductView(ctx context.Context, product *model.Product) {
+	s.record(ctx, product.ID, product.SellerID, model.FunnelEventView, 0)
+}
+
+func (s *analyticsService) RecordAddToCart(ctx context.Context, product *model.Product) {
+	s.record(ctx, product.ID, product.SellerID, model.FunnelEventAddToCart, 0)
+}
+
+// RecordCheckout counts the order once for every product in it
+func (s *analyticsService) RecordCheckout(ctx context.Context, order *model.Order) {
+	for _, item := range uniqueOrderProducts(order) {
+		s.record(ctx, item.ProductID, item.SellerID, model.FunnelEventCheckout, 0)
+	}
+}
+
+// RecordPurchase counts the paid order once for every product in it, with the units bought
+func (s *analyticsService) RecordPurchase(ctx context.Context, order *model.Order) {
+	for _, item := range uniqueOrderProducts(order) {
+		s.record(ctx, item.ProductID, item.SellerID, model.FunnelEventPurchase, item.Quantity)
+	}
+}
+
+func (s *analyticsService) GetProductFunnel(ctx context.Context, userID string, from, to, productID string) (*ProductFunnelReport, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+
+	// Default range: the last 30 days, today included
+	toDate := s.calendar.BusinessDate(time.Now())
+	if to != "" {
+		if toDate, err = time.Parse("2006-01-02", to); err != nil {
+			return nil, errors.New("to must be in YYYY-MM-DD format")
+		}
+	}
+	fromDate := toDate.AddDate(0, 0, -29)
+	if from != "" {
+		if fromDate, err = time.Parse("2006-01-02", from); err != nil {
+			return nil, errors.New("from must be in YYYY-MM-DD format")
+		}
+	}
+	if fromDate.After(toDate) {
+		return nil, errors.New("from must not be after to")
+	}
+	if toDate.Sub(fromDate) > maxFunnelReportDays*24*time.Hour {
+		return nil, errors.New("date range must not exceed 366 days")
+	}
+
+	rows, err := s.analyticsRepo.GetProductFunnel(ctx, seller.ID, fromDate, toDate, productID)
+	if err != nil {
+		return nil, errors.New("failed to get product funnel: " + err.Error())
+	}
+
+	report := &ProductFunnelReport{
+		From:     fromDate.Format("2006-01-02"),
+		To:       toDate.Format("2006-01-02"),
+		Products: make([]ProductFunnelMetrics, 0, len(rows)),
+	}
+	for _, row := range rows {
+		report.Products = append(report.Products, ProductFunnelMetrics{
+			ProductFunnelRow: row,
+			CartRate:         percentage(row.AddToCarts, row.Views),
+			CheckoutRate:     percentage(row.Checkouts, row.AddToCarts),
+			PurchaseRate:     percentage(row.Purchases, row.Checkouts),
+			ConversionRate:   percentage(row.Purchases, row.Views),
+		})
+	}
+	return report, nil
+}
+
+func (s *analyticsService) record(ctx context.Context, productID, sellerID, event string, units int) {
+	date := s.calendar.BusinessDate(time.Now())
+	if err := s.analyticsRepo.IncrementFunnel(ctx, productID, sellerID, date, event, units); err != nil {
+		log.Printf("⚠️  Failed to record %s event for product %s: %v", event, productID, err)
+	}
+}
+
+// uniqueOrderProducts returns one entry per product in the order, with quantities summed
+func uniqueOrderProducts(order *model.Order) []model.OrderItem {
+	var items []model.OrderItem
+	index := make(map[string]int)
+	for _, item := range order.OrderItems {
+		if i, ok := index[item.ProductID]; ok {
+			items[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.ProductID] = len(items)
+		items = append(items, item)
+	}
+	return items
+}
+
+// percentage returns part/whole * 100, rounded to two decimals (0 when whole is 0)
+func percentage(part, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part*10000/whole) / 100
+}
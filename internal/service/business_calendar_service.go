@@ -0,0 +1,464 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// businessCalendarLookahead bounds the search for the next business day
+const businessCalendarLookahead = 60
+
+// DefaultCourierService is used when an order does not pick a courier service level
+const DefaultCourierService = "regular"
+
+// ErrUnsupportedCourierService is returned for a courier service level that is not configured
+var ErrUnsupportedCourierService = errors.New("unsupported courier service")
+
+type BusinessCalendarService interface {
+	GetShippingEstimate(ctx context.Context, orderedAt time.Time) (*ShippingEstimate, error)
+	SLAStart(ctx context.Context, orderedAt time.Time) (time.Time, error)
+	AddBusinessDays(ctx context.Context, from time.Time, days int) (time.Time, error)
+	EstimateDelivery(ctx context.Context, orderedAt time.Time, handlingDays int, courierService string, route DeliveryRoute) (*DeliveryEstimate, error)
+	BusinessDate(t time.Time) time.Time
+	Location() *time.Location
+	GetHolidays(ctx context.Context, year int) ([]model.Holiday, error)
+	HolidayDates(ctx context.Context, from, to time.Time) (map[string]bool, error)
+	CreateHoliday(ctx context.Context, req CreateHolidayRequest) (*model.Holiday, error)
+	DeleteHoliday(ctx context.Context, id string) error
+}
+
+type businessCalendarService struct {
+	holidayRepo  repository.HolidayRepository
+	location     *time.Location
+	businessDays map[time.Weekday]bool
+	openTime     string
+	cutoffTime   string
+	openMinute   int // Minutes after midnight
+	cutoffMinute int
+	couriers     map[string]courierServiceLevel
+	cityDays     map[string]int // Extra transit days by normalised destination city
+	defaultDays  int            // Extra transit days for destination cities not in cityDays
+}
+
+// courierServiceLevel is the transit time range of a courier service, in business days
+type courierServiceLevel struct {
+	minDays int
+	maxDays int
+}
+
+type CreateHolidayRequest struct {
+	Date string `json:"date" binding:"required"` // YYYY-MM-DD
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+// ShippingEstimate tells the buyer when an order placed now is expected to ship
+type ShippingEstimate struct {
+	OrderedAt         time.Time `json:"ordered_at"`
+	CutoffTime        string    `json:"cutoff_time"`
+	AfterCutoff       bool      `json:"after_cutoff"`  // True when the order counts from the next business day
+	SLAStartsAt       time.Time `json:"sla_starts_at"` // When the seller's fulfilment clock starts
+	EstimatedShipDate string    `json:"estimated_ship_date"`
+}
+
+// DeliveryRoute is where an order ships from and to; empty cities are ignored
+type DeliveryRoute struct {
+	OriginCities    []string // Shop cities of the sellers in the order
+	DestinationCity string
+}
+
+// DeliveryEstimate is the ETA range for an order: the seller ships after its handling time and the
+// courier delivers within its service level plus the destination's extra days, counting business
+// days only
+type DeliveryEstimate struct {
+	CourierService    string    `json:"courier_service"`
+	HandlingDays      int       `json:"handling_days"`
+	DestinationCity   string    `json:"destination_city,omitempty"`
+	ExtraTransitDays  int       `json:"extra_transit_days"` // Added to the courier service level for the destination
+	SLAStartsAt       time.Time `json:"sla_starts_at"`
+	EstimatedShipDate string    `json:"estimated_ship_date"`
+	EarliestDelivery  string    `json:"earliest_delivery"`
+	LatestDelivery    string    `json:"latest_delivery"`
+}
+
+func NewBusinessCalendarService(holidayRepo repository.HolidayRepository, cfg *config.Config) BusinessCalendarService {
+	location, err := time.LoadLocation(cfg.BusinessTimezone)
+	if err != nil {
+		log.Printf("⚠️  Invalid BUSINESS_TIMEZONE %q, falling back to UTC+7: %v", cfg.BusinessTimezone, err)
+		location = time.FixedZone("WIB", 7*60*60)
+	}
+
+	businessDays := parseBusinessDays(cfg.BusinessDays)
+	if len(businessDays) == 0 {
+		log.Printf("⚠️  Invalid BUSINESS_DAYS %q, falling back to Monday-Saturday", cfg.BusinessDays)
+		businessDays = parseBusinessDays("mon,tue,wed,thu,fri,sat")
+	}
+
+	openTime, openMinute := parseClock(cfg.BusinessOpenTime, "08:00")
+	cutoffTime, cutoffMinute := parseClock(cfg.OrderCutoffTime, "15:00")
+
+	couriers := parseCourierServiceLevels(cfg.CourierServiceLevels)
+	if _, ok := couriers[DefaultCourierService]; !ok {
+		log.Printf("⚠️  COURIER_SERVICE_LEVELS %q has no %q service, adding 2-4 days", cfg.CourierServiceLevels, DefaultCourierService)
+		couriers[DefaultCourierService] = courierServiceLevel{minDays: 2, maxDays: 4}
+	}
+
+	cityDays := parseCityExtraDays(cfg.DeliveryCityExtraDays)
+	defaultDays := cfg.DeliveryDefaultExtraDays
+	if defaultDays < 0 {
+		defaultDays = 0
+	}
+
+	return &businessCalendarService{
+		holidayRepo:  holidayRepo,
+		location:     location,
+		businessDays: businessDays,
+		openTime:     openTime,
+		cutoffTime:   cutoffTime,
+		openMinute:   openMinute,
+		cutoffMinute: cutoffMinute,
+		couriers:     couriers,
+		cityDays:     cityDays,
+		defaultDays:  defaultDays,
+	}
+}
+
+func (s *businessCalendarService) GetShippingEstimate(ctx context.Context, orderedAt time.Time) (*ShippingEstimate, error) {
+	slaStart, err := s.SLAStart(ctx, orderedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	local := orderedAt.In(s.location)
+	return &ShippingEstimate{
+		OrderedAt:         local,
+		CutoffTime:        s.cutoffTime,
+		AfterCutoff:       !sameDate(local, slaStart),
+		SLAStartsAt:       slaStart,
+		EstimatedShipDate: slaStart.Format("2006-01-02"),
+	}, nil
+}
+
+// SLAStart returns when the fulfilment clock starts for an order: immediately if placed on a
+// business day before the cutoff (but not before opening time), otherwise at opening time on the
+// next business day.
+func (s *businessCalendarService) SLAStart(ctx context.Context, orderedAt time.Time) (time.Time, error) {
+	local := orderedAt.In(s.location)
+	day := startOfDay(local)
+
+	holidays, err := s.holidaySet(ctx, day, day.AddDate(0, 0, businessCalendarLookahead))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if s.isBusinessDay(day, holidays) && local.Before(day.Add(time.Duration(s.cutoffMinute)*time.Minute)) {
+		open := day.Add(time.Duration(s.openMinute) * time.Minute)
+		if local.Before(open) {
+			return open, nil
+		}
+		return local, nil
+	}
+
+	for i := 1; i <= businessCalendarLookahead; i++ {
+		next := day.AddDate(0, 0, i)
+		if s.isBusinessDay(next, holidays) {
+			return next.Add(time.Duration(s.openMinute) * time.Minute), nil
+		}
+	}
+	return time.Time{}, errors.New("no business day found in the next 60 days")
+}
+
+// AddBusinessDays returns the date that is the given number of business days after from
+func (s *businessCalendarService) AddBusinessDays(ctx context.Context, from time.Time, days int) (time.Time, error) {
+	day := startOfDay(from.In(s.location))
+	if days <= 0 {
+		return day, nil
+	}
+
+	// Allow for weekends and holidays on top of the requested business days
+	holidays, err := s.holidaySet(ctx, day, day.AddDate(0, 0, days*2+businessCalendarLookahead))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	remaining := days
+	for i := 1; i <= days*2+businessCalendarLookahead; i++ {
+		next := day.AddDate(0, 0, i)
+		if s.isBusinessDay(next, holidays) {
+			remaining--
+			if remaining == 0 {
+				return next, nil
+			}
+		}
+	}
+	return time.Time{}, errors.New("could not find enough business days")
+}
+
+// EstimateDelivery combines the SLA start, the seller's handling time, the courier service level
+// and the destination's extra transit days into an ETA range
+func (s *businessCalendarService) EstimateDelivery(ctx context.Context, orderedAt time.Time, handlingDays int, courierService string, route DeliveryRoute) (*DeliveryEstimate, error) {
+	if courierService == "" {
+		courierService = DefaultCourierService
+	}
+	level, ok := s.couriers[courierService]
+	if !ok {
+		names := make([]string, 0, len(s.couriers))
+		for name := range s.couriers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%w: %s (available: %s)", ErrUnsupportedCourierService, courierService, strings.Join(names, ", "))
+	}
+	if handlingDays < 0 {
+		handlingDays = 0
+	}
+
+	slaStart, err := s.SLAStart(ctx, orderedAt)
+	if err != nil {
+		return nil, err
+	}
+	shipDate, err := s.AddBusinessDays(ctx, slaStart, handlingDays)
+	if err != nil {
+		return nil, err
+	}
+	extraDays := s.extraTransitDays(route)
+	earliest, err := s.AddBusinessDays(ctx, shipDate, level.minDays+extraDays)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := s.AddBusinessDays(ctx, shipDate, level.maxDays+extraDays)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeliveryEstimate{
+		CourierService:    courierService,
+		HandlingDays:      handlingDays,
+		DestinationCity:   route.DestinationCity,
+		ExtraTransitDays:  extraDays,
+		SLAStartsAt:       slaStart,
+		EstimatedShipDate: shipDate.Format("2006-01-02"),
+		EarliestDelivery:  earliest.Format("2006-01-02"),
+		LatestDelivery:    latest.Format("2006-01-02"),
+	}, nil
+}
+
+// extraTransitDays returns the extra courier days for the route's destination. Shipments where
+// every shop is in the destination city get none; an unknown destination gets none either.
+func (s *businessCalendarService) extraTransitDays(route DeliveryRoute) int {
+	destination := normalizeCity(route.DestinationCity)
+	if destination == "" {
+		return 0
+	}
+
+	sameCity := len(route.OriginCities) > 0
+	for _, origin := range route.OriginCities {
+		if normalizeCity(origin) != destination {
+			sameCity = false
+			break
+		}
+	}
+	if sameCity {
+		return 0
+	}
+
+	if days, ok := s.cityDays[destination]; ok {
+		return days
+	}
+	return s.defaultDays
+}
+
+// BusinessDate returns the calendar date of t in the business timezone, pinned to UTC midnight so
+// date-only columns do not shift the day
+func (s *businessCalendarService) BusinessDate(t time.Time) time.Time {
+	local := t.In(s.location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Location returns the business timezone
+func (s *businessCalendarService) Location() *time.Location {
+	return s.location
+}
+
+// DateRangeError is a list filter date range the caller got wrong
+type DateRangeError struct {
+	Message string
+}
+
+func (e *DateRangeError) Error() string {
+	return e.Message
+}
+
+// parseDateRange parses optional YYYY-MM-DD list filter bounds in the given timezone. Both ends
+// are inclusive, so the returned upper bound is the start of the day after to.
+func parseDateRange(from, to string, location *time.Location) (*time.Time, *time.Time, error) {
+	var fromTime, toTime *time.Time
+	if from != "" {
+		t, err := time.ParseInLocation("2006-01-02", from, location)
+		if err != nil {
+			return nil, nil, &DateRangeError{Message: "from must be in YYYY-MM-DD format"}
+		}
+		fromTime = &t
+	}
+	if to != "" {
+		t, err := time.ParseInLocation("2006-01-02", to, location)
+		if err != nil {
+			return nil, nil, &DateRangeError{Message: "to must be in YYYY-MM-DD format"}
+		}
+		t = t.AddDate(0, 0, 1)
+		toTime = &t
+	}
+	if fromTime != nil && toTime != nil && !fromTime.Before(*toTime) {
+		return nil, nil, &DateRangeError{Message: "from must not be after to"}
+	}
+	return fromTime, toTime, nil
+}
+
+func (s *businessCalendarService) GetHolidays(ctx context.Context, year int) ([]model.Holiday, error) {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	return s.holidayRepo.FindBetween(ctx, from, to)
+}
+
+func (s *businessCalendarService) CreateHoliday(ctx context.Context, req CreateHolidayRequest) (*model.Holiday, error) {
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, errors.New("date must be in YYYY-MM-DD format")
+	}
+
+	holiday := &model.Holiday{
+		Date: date,
+		Name: req.Name,
+	}
+	if err := s.holidayRepo.Create(ctx, holiday); err != nil {
+		if strings.Contains(err.Error(), "duplicate") {
+			return nil, errors.New("a holiday already exists on this date")
+		}
+		return nil, fmt.Errorf("failed to create holiday: %w", err)
+	}
+	return holiday, nil
+}
+
+func (s *businessCalendarService) DeleteHoliday(ctx context.Context, id string) error {
+	if _, err := s.holidayRepo.FindByID(ctx, id); err != nil {
+		return errors.New("holiday not found")
+	}
+	return s.holidayRepo.Delete(ctx, id)
+}
+
+// HolidayDates returns the holidays in [from, to] keyed by YYYY-MM-DD
+func (s *businessCalendarService) HolidayDates(ctx context.Context, from, to time.Time) (map[string]bool, error) {
+	return s.holidaySet(ctx, from, to)
+}
+
+// holidaySet loads holidays in [from, to] keyed by YYYY-MM-DD
+func (s *businessCalendarService) holidaySet(ctx context.Context, from, to time.Time) (map[string]bool, error) {
+	holidays, err := s.holidayRepo.FindBetween(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load holidays: %w", err)
+	}
+	set := make(map[string]bool, len(holidays))
+	for _, holiday := range holidays {
+		set[holiday.Date.Format("2006-01-02")] = true
+	}
+	return set, nil
+}
+
+func (s *businessCalendarService) isBusinessDay(day time.Time, holidays map[string]bool) bool {
+	return s.businessDays[day.Weekday()] && !holidays[day.Format("2006-01-02")]
+}
+
+func parseBusinessDays(value string) map[time.Weekday]bool {
+	names := map[string]time.Weekday{
+		"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+		"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+	}
+	days := make(map[time.Weekday]bool)
+	for _, part := range strings.Split(value, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if len(name) > 3 {
+			name = name[:3]
+		}
+		if day, ok := names[name]; ok {
+			days[day] = true
+		}
+	}
+	return days
+}
+
+// parseCourierServiceLevels parses "name:min-max" pairs, e.g. "regular:2-4,express:1-2"
+func parseCourierServiceLevels(value string) map[string]courierServiceLevel {
+	levels := make(map[string]courierServiceLevel)
+	for _, part := range strings.Split(value, ",") {
+		name, days, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			continue
+		}
+		minDays, maxDays, ok := strings.Cut(days, "-")
+		if !ok {
+			maxDays = minDays
+		}
+		lo, errMin := strconv.Atoi(strings.TrimSpace(minDays))
+		hi, errMax := strconv.Atoi(strings.TrimSpace(maxDays))
+		if errMin != nil || errMax != nil || lo < 0 || hi < lo {
+			log.Printf("⚠️  Ignoring invalid courier service level %q", part)
+			continue
+		}
+		levels[strings.ToLower(strings.TrimSpace(name))] = courierServiceLevel{minDays: lo, maxDays: hi}
+	}
+	return levels
+}
+
+// parseCityExtraDays parses "city:days" pairs, e.g. "jakarta:0,medan:2"
+func parseCityExtraDays(value string) map[string]int {
+	cities := make(map[string]int)
+	for _, part := range strings.Split(value, ",") {
+		city, days, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			continue
+		}
+		extra, err := strconv.Atoi(strings.TrimSpace(days))
+		if err != nil || extra < 0 || normalizeCity(city) == "" {
+			log.Printf("⚠️  Ignoring invalid delivery city extra days %q", part)
+			continue
+		}
+		cities[normalizeCity(city)] = extra
+	}
+	return cities
+}
+
+// normalizeCity lowercases a city name and drops the "Kota"/"Kabupaten" prefix addresses often carry
+func normalizeCity(city string) string {
+	city = strings.ToLower(strings.Join(strings.Fields(city), " "))
+	for _, prefix := range []string{"kota ", "kabupaten ", "kab. ", "kab "} {
+		city = strings.TrimPrefix(city, prefix)
+	}
+	return city
+}
+
+// parseClock parses HH:MM into minutes after midnight, using fallback when invalid
+func parseClock(value string, fallback string) (string, int) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		log.Printf("⚠️  Invalid time %q, falling back to %s", value, fallback)
+		value = fallback
+		t, _ = time.Parse("15:04", fallback)
+	}
+	return value, t.Hour()*60 + t.Minute()
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func sameDate(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+)
+
+// cancellationBatchSize is how many overdue requests one background pass handles at most
+const cancellationBatchSize = 100
+
+// CancellationRequestService lets a buyer ask to cancel a paid sub-order before it ships. The
+// seller approves or rejects it before the deadline; silence counts as approval. Approval cancels
+// the sub-order, puts its stock back and refunds the buyer through the payment provider.
+type CancellationRequestService interface {
+	RequestCancellation(ctx context.Context, userID string, orderID string, req CancellationRequestInput) (*model.CancellationRequest, error)
+	GetMyRequests(ctx context.Context, userID string, status string, page, limit int) ([]model.CancellationRequest, int64, error)
+	GetSellerRequests(ctx context.Context, userID string, status string, page, limit int) ([]model.CancellationRequest, int64, error)
+	Approve(ctx context.Context, userID string, requestID string) (*model.CancellationRequest, error)
+	Reject(ctx context.Context, userID string, requestID string, note string) (*model.CancellationRequest, error)
+	// ProcessDue approves requests the seller left unanswered and retries failed refunds; it
+	// returns how many requests were handled
+	ProcessDue(ctx context.Context) (int, error)
+}
+
+type cancellationRequestService struct {
+	cancellationRepo repository.CancellationRequestRepository
+	orderRepo        repository.OrderRepository
+	sellerOrderRepo  repository.SellerOrderRepository
+	sellerRepo       repository.SellerRepository
+	paymentService   PaymentService
+	stock            StockCacheService
+	hooks            *HookRegistry
+	responseWindow   time.Duration
+}
+
+type CancellationRequestInput struct {
+	SellerOrderID string `json:"seller_order_id" binding:"required"`
+	Reason        string `json:"reason" binding:"required,max=1000"`
+}
+
+func NewCancellationRequestService(
+	cancellationRepo repository.CancellationRequestRepository,
+	orderRepo repository.OrderRepository,
+	sellerOrderRepo repository.SellerOrderRepository,
+	sellerRepo repository.SellerRepository,
+	paymentService PaymentService,
+	stock StockCacheService,
+	hooks *HookRegistry,
+	cfg *config.Config,
+	jobs *util.BackgroundJobs,
+) CancellationRequestService {
+	service := &cancellationRequestService{
+		cancellationRepo: cancellationRepo,
+		orderRepo:        orderRepo,
+		sellerOrderRepo:  sellerOrderRepo,
+		sellerRepo:       sellerRepo,
+		paymentService:   paymentService,
+		stock:            stock,
+		hooks:            hooks,
+		responseWindow:   time.Duration(cfg.CancellationResponseHours) * time.Hour,
+	}
+
+	// Start background job to approve unanswered requests and retry refunds
+	if cfg.CancellationCheckIntervalSeconds > 0 {
+		interval := time.Duration(cfg.CancellationCheckIntervalSeconds) * time.Second
+		jobs.Every(interval, service.runCancellationProcessor)
+		log.Printf("✅ Cancellation request processor started (sellers have %s to respond, checking every %s)", service.responseWindow, interval)
+	}
+
+	return service
+}
+
+// runCancellationProcessor handles overdue cancellation requests
+func (s *cancellationRequestService) runCancellationProcessor(ctx context.Context) {
+	if _, err := s.ProcessDue(ctx); err != nil {
+		log.Printf("⚠️  Failed to process cancellation requests: %v", err)
+	}
+}
+
+// RequestCancellation asks the seller to cancel one paid, not yet shipped sub-order of the buyer's order
+func (s *cancellationRequestService) RequestCancellation(ctx context.Context, userID string, orderID string, req CancellationRequestInput) (*model.CancellationRequest, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil || order.UserID != userID {
+		return nil, errors.New("order not found")
+	}
+	sellerOrder, err := s.sellerOrderRepo.FindByID(ctx, req.SellerOrderID)
+	if err != nil || sellerOrder.OrderID != order.ID {
+		return nil, errors.New("order not found")
+	}
+	switch sellerOrder.Status {
+	case "paid", "processing":
+	case "pending":
+		return nil, errors.New("unpaid orders can be cancelled directly")
+	default:
+		return nil, fmt.Errorf("cannot request cancellation of a %s order", sellerOrder.Status)
+	}
+	if _, err := s.cancellationRepo.FindBySellerOrderID(ctx, sellerOrder.ID); err == nil {
+		return nil, errors.New("cancellation has already been requested for this order")
+	}
+
+	request := &model.CancellationRequest{
+		OrderID:       order.ID,
+		SellerOrderID: sellerOrder.ID,
+		UserID:        userID,
+		SellerID:      sellerOrder.SellerID,
+		Reason:        reason,
+		Status:        model.CancellationStatusRequested,
+		RespondBy:     time.Now().Add(s.responseWindow),
+		RefundAmount:  sellerOrder.TotalAmount,
+	}
+	if err := s.cancellationRepo.Create(ctx, request); err != nil {
+		return nil, errors.New("failed to create cancellation request: " + err.Error())
+	}
+
+	log.Printf("🛑 Cancellation requested for sub-order %s by user %s", sellerOrder.SubOrderNumber, userID)
+	return s.cancellationRepo.FindByID(ctx, request.ID)
+}
+
+func (s *cancellationRequestService) GetMyRequests(ctx context.Context, userID string, status string, page, limit int) ([]model.CancellationRequest, int64, error) {
+	requests, total, err := s.cancellationRepo.FindByUserID(ctx, userID, status, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get cancellation requests: " + err.Error())
+	}
+	return requests, total, nil
+}
+
+func (s *cancellationRequestService) GetSellerRequests(ctx context.Context, userID string, status string, page, limit int) ([]model.CancellationRequest, int64, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, 0, errors.New("seller not found")
+	}
+	requests, total, err := s.cancellationRepo.FindBySellerID(ctx, seller.ID, status, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get cancellation requests: " + err.Error())
+	}
+	return requests, total, nil
+}
+
+// Approve is the seller accepting the cancellation
+func (s *cancellationRequestService) Approve(ctx context.Context, userID string, requestID string) (*model.CancellationRequest, error) {
+	request, err := s.findOwned(ctx, userID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	change := model.StatusChange{ActorType: model.StatusActorSeller, ActorID: userID, Note: "Cancellation requested by buyer"}
+	if err := s.approve(ctx, request, change, false); err != nil {
+		return nil, err
+	}
+	return s.cancellationRepo.FindByID(ctx, request.ID)
+}
+
+// Reject keeps the sub-order; the seller must say why
+func (s *cancellationRequestService) Reject(ctx context.Context, userID string, requestID string, note string) (*model.CancellationRequest, error) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil, errors.New("note is required")
+	}
+	request, err := s.findOwned(ctx, userID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cancellationRepo.Transition(ctx, request.ID, model.CancellationStatusRequested, model.CancellationStatusRejected,
+		map[string]interface{}{"seller_note": note, "rejected_at": time.Now()}); err != nil {
+		return nil, s.transitionError(err, request, model.CancellationStatusRejected)
+	}
+
+	log.Printf("🛑 Cancellation request %s rejected by seller %s", request.ID, request.SellerID)
+	return s.cancellationRepo.FindByID(ctx, request.ID)
+}
+
+func (s *cancellationRequestService) ProcessDue(ctx context.Context) (int, error) {
+	requests, err := s.cancellationRepo.FindDue(ctx, time.Now(), cancellationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	handled := 0
+	for i := range requests {
+		request := &requests[i]
+		if request.Status == model.CancellationStatusApproved {
+			if err := s.refund(ctx, request); err != nil {
+				log.Printf("⚠️  Refund for cancellation request %s still failing: %v", request.ID, err)
+				continue
+			}
+			handled++
+			continue
+		}
+
+		change := model.StatusChange{ActorType: model.StatusActorSystem, Note: "Cancellation approved automatically: seller did not respond in time"}
+		err := s.approve(ctx, request, change, true)
+		if errors.Is(err, repository.ErrSubOrderAlreadyShipped) {
+			// The seller shipped instead of answering; the buyer can still return the items
+			err = s.cancellationRepo.Transition(ctx, request.ID, model.CancellationStatusRequested, model.CancellationStatusRejected,
+				map[string]interface{}{"seller_note": "The order was shipped before the request was answered", "rejected_at": time.Now()})
+		}
+		if err != nil {
+			log.Printf("⚠️  Failed to process cancellation request %s: %v", request.ID, err)
+			continue
+		}
+		handled++
+	}
+
+	if handled > 0 {
+		log.Printf("🛑 Processed %d overdue cancellation request(s)", handled)
+	}
+	return handled, nil
+}
+
+// approve cancels the sub-order and restocks it, then refunds the buyer. A failed refund leaves the
+// request approved for the background job to retry; the cancellation itself stands.
+func (s *cancellationRequestService) approve(ctx context.Context, request *model.CancellationRequest, change model.StatusChange, automatic bool) error {
+	parentStatus := ""
+	if order, err := s.orderRepo.FindByID(ctx, request.OrderID); err == nil {
+		parentStatus = order.Status
+	}
+
+	updates := map[string]interface{}{"approved_at": time.Now(), "auto_approved": automatic}
+	if err := s.cancellationRepo.Approve(ctx, request.ID, updates, change); err != nil {
+		if errors.Is(err, repository.ErrSubOrderAlreadyShipped) {
+			return err
+		}
+		return s.transitionError(err, request, model.CancellationStatusApproved)
+	}
+	log.Printf("🛑 Sub-order %s cancelled at the buyer's request (cancellation %s)", request.SellerOrder.SubOrderNumber, request.ID)
+
+	quantities := make(map[string]int)
+	for _, item := range request.SellerOrder.OrderItems {
+		quantities[item.ProductID] += item.Quantity
+	}
+	s.stock.Release(ctx, quantities)
+
+	if parentStatus != "" {
+		if order, err := s.orderRepo.FindByID(ctx, request.OrderID); err == nil && order.Status != parentStatus {
+			s.hooks.RunAfterOrderStatusChange(ctx, order, parentStatus)
+		}
+	}
+
+	if err := s.refund(ctx, request); err != nil {
+		log.Printf("⚠️  Refund for cancellation request %s failed, will retry: %v", request.ID, err)
+	}
+	return nil
+}
+
+func (s *cancellationRequestService) refund(ctx context.Context, request *model.CancellationRequest) error {
+	method, err := s.paymentService.RefundPayment(ctx, request.OrderID, "cancel-"+request.ID, request.RefundAmount, "Order cancelled: "+request.Reason)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRefundFailed, err)
+	}
+	if err := s.cancellationRepo.MarkRefunded(ctx, request.ID, method, time.Now()); err != nil {
+		return err
+	}
+	log.Printf("💸 Refunded %d for cancellation request %s via %s", request.RefundAmount, request.ID, method)
+	return nil
+}
+
+func (s *cancellationRequestService) findOwned(ctx context.Context, userID string, requestID string) (*model.CancellationRequest, error) {
+	request, err := s.cancellationRepo.FindByID(ctx, requestID)
+	if err != nil {
+		return nil, errors.New("cancellation request not found")
+	}
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil || seller.ID != request.SellerID {
+		return nil, errors.New("cancellation request not found")
+	}
+	return request, nil
+}
+
+func (s *cancellationRequestService) transitionError(err error, request *model.CancellationRequest, to string) error {
+	if errors.Is(err, repository.ErrInvalidCancellationTransition) {
+		return fmt.Errorf("%w: %s to %s", err, request.Status, to)
+	}
+	return errors.New("failed to update cancellation request: " + err.Error())
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"log"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+)
+
+// cachedCartRepository keeps each user's cart, with the items and product associations the cart
+// endpoints preload, in Redis. Postgres stays the source of truth:
+//   - reads fill the cache only when it is empty, so they never overwrite a newer write-through
+//   - every mutation writes to Postgres first, then re-reads the cart and stores it (write-through)
+//   - a product change (stock, price, publishing) drops the cached carts holding the product
+//
+// Entries expire after the TTL, which also bounds staleness from changes made elsewhere (e.g. a
+// shop renamed, or cart items purged by the retention job).
+type cachedCartRepository struct {
+	repository.CartRepository
+	redis *util.RedisClient
+	ttl   time.Duration
+}
+
+// NewCachedCartRepository wraps the cart repository with the Redis cache. Without Redis, or with
+// CART_CACHE_TTL_SECONDS=0, it returns the repository unchanged.
+func NewCachedCartRepository(repo repository.CartRepository, redisClient *util.RedisClient, events ProductEventService, cfg *config.Config) repository.CartRepository {
+	if redisClient == nil || cfg.CartCacheTTLSeconds <= 0 {
+		return repo
+	}
+
+	cache := &cachedCartRepository{
+		CartRepository: repo,
+		redis:          redisClient,
+		ttl:            time.Duration(cfg.CartCacheTTLSeconds) * time.Second,
+	}
+	go cache.watchProducts(events.Subscribe(context.Background()))
+	log.Printf("✅ Cart cache enabled (TTL %s)", cache.ttl)
+	return cache
+}
+
+// watchProducts drops cached carts holding a changed product
+func (r *cachedCartRepository) watchProducts(productIDs <-chan string) {
+	ctx := context.Background()
+	for productID := range productIDs {
+		owners, err := r.redis.TakeSetMembers(ctx, util.CartProductIndexKeyPrefix+productID)
+		if err != nil {
+			log.Printf("⚠️  Failed to look up cached carts holding product %s: %v", productID, err)
+			continue
+		}
+		r.drop(ctx, owners...)
+	}
+}
+
+func (r *cachedCartRepository) GetOrCreateByUserID(userID string) (*model.Cart, error) {
+	if cart := r.get(userID); cart != nil {
+		return cart, nil
+	}
+	cart, err := r.CartRepository.GetOrCreateByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	r.fill(cart)
+	return cart, nil
+}
+
+func (r *cachedCartRepository) GetByUserID(userID string) (*model.Cart, error) {
+	if cart := r.get(userID); cart != nil {
+		return cart, nil
+	}
+	cart, err := r.CartRepository.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	r.fill(cart)
+	return cart, nil
+}
+
+func (r *cachedCartRepository) AddCartItem(cartItem *model.CartItem) error {
+	if err := r.CartRepository.AddCartItem(cartItem); err != nil {
+		return err
+	}
+	r.writeThrough(cartItem.CartID)
+	return nil
+}
+
+func (r *cachedCartRepository) UpdateCartItem(cartItem *model.CartItem) error {
+	if err := r.CartRepository.UpdateCartItem(cartItem); err != nil {
+		return err
+	}
+	r.writeThrough(cartItem.CartID)
+	return nil
+}
+
+func (r *cachedCartRepository) DeleteCartItem(cartItemID string) error {
+	cartItem, lookupErr := r.CartRepository.GetCartItemByID(cartItemID)
+	if err := r.CartRepository.DeleteCartItem(cartItemID); err != nil {
+		return err
+	}
+	if lookupErr == nil {
+		r.writeThrough(cartItem.CartID)
+	}
+	return nil
+}
+
+func (r *cachedCartRepository) ClearCart(cartID string) error {
+	if err := r.CartRepository.ClearCart(cartID); err != nil {
+		return err
+	}
+	r.writeThrough(cartID)
+	return nil
+}
+
+func (r *cachedCartRepository) SetItemsSelected(cartID string, cartItemIDs []string, selected bool) (int64, error) {
+	updated, err := r.CartRepository.SetItemsSelected(cartID, cartItemIDs, selected)
+	if err != nil {
+		return 0, err
+	}
+	r.writeThrough(cartID)
+	return updated, nil
+}
+
+func (r *cachedCartRepository) SetCouponCode(cartID string, code *string) error {
+	if err := r.CartRepository.SetCouponCode(cartID, code); err != nil {
+		return err
+	}
+	r.writeThrough(cartID)
+	return nil
+}
+
+func (r *cachedCartRepository) FlagItemsBySellerID(sellerID string, reason string) (map[string]int, error) {
+	affected, err := r.CartRepository.FlagItemsBySellerID(sellerID, reason)
+	if err != nil {
+		return nil, err
+	}
+	owners := make([]string, 0, len(affected))
+	for userID := range affected {
+		owners = append(owners, userID)
+	}
+	r.drop(context.Background(), owners...)
+	return affected, nil
+}
+
+// UnflagItemsBySellerID does not report which carts changed, so every cached cart is dropped. It
+// only runs when a closed shop reopens.
+func (r *cachedCartRepository) UnflagItemsBySellerID(sellerID string, reason string) (int64, error) {
+	unflagged, err := r.CartRepository.UnflagItemsBySellerID(sellerID, reason)
+	if err != nil {
+		return 0, err
+	}
+	if unflagged > 0 {
+		ctx := context.Background()
+		err := r.redis.ScanKeys(ctx, util.CartCacheKeyPrefix+"*", func(keys []string) error {
+			return r.redis.Delete(ctx, keys...)
+		})
+		if err != nil {
+			log.Printf("⚠️  Failed to drop cached carts after reopening shop %s: %v", sellerID, err)
+		}
+	}
+	return unflagged, nil
+}
+
+func (r *cachedCartRepository) Invalidate(userID string) {
+	r.drop(context.Background(), userID)
+}
+
+// get returns the user's cached cart, nil on a miss or error
+func (r *cachedCartRepository) get(userID string) *model.Cart {
+	data, err := r.redis.GetBytes(context.Background(), util.CartCacheKeyPrefix+userID)
+	if err != nil {
+		log.Printf("⚠️  Failed to read cached cart of user %s: %v", userID, err)
+		return nil
+	}
+	if data == nil {
+		return nil
+	}
+	var cart model.Cart
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&cart); err != nil {
+		log.Printf("⚠️  Failed to decode cached cart of user %s: %v", userID, err)
+		return nil
+	}
+	return &cart
+}
+
+// fill caches a cart read from Postgres unless a fresher copy was written meanwhile
+func (r *cachedCartRepository) fill(cart *model.Cart) {
+	r.store(cart, false)
+}
+
+// writeThrough re-reads the cart after a mutation and replaces the cached copy. The old copy is
+// dropped first so a failed re-read or write leaves no stale entry. When the owner is unknown the
+// cart has never been cached, so there is nothing to update.
+func (r *cachedCartRepository) writeThrough(cartID string) {
+	ctx := context.Background()
+	owner, err := r.redis.GetBytes(ctx, util.CartOwnerKeyPrefix+cartID)
+	if err != nil {
+		log.Printf("⚠️  Failed to look up owner of cart %s: %v", cartID, err)
+		return
+	}
+	if owner == nil {
+		return
+	}
+
+	userID := string(owner)
+	r.drop(ctx, userID)
+	cart, err := r.CartRepository.GetByUserID(userID)
+	if err != nil {
+		return
+	}
+	r.store(cart, true)
+}
+
+func (r *cachedCartRepository) store(cart *model.Cart, overwrite bool) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(cart); err != nil {
+		log.Printf("⚠️  Failed to encode cart %s for the cache: %v", cart.ID, err)
+		return
+	}
+
+	// The owner mapping never changes and lets mutations, which only know the cart ID, find the entry
+	if err := r.redis.SetBytes(ctx, util.CartOwnerKeyPrefix+cart.ID, []byte(cart.UserID), 0); err != nil {
+		log.Printf("⚠️  Failed to cache owner of cart %s: %v", cart.ID, err)
+		return
+	}
+	productKeys := make([]string, 0, len(cart.CartItems))
+	for _, item := range cart.CartItems {
+		productKeys = append(productKeys, util.CartProductIndexKeyPrefix+item.ProductID)
+	}
+	if err := r.redis.AddToSets(ctx, productKeys, cart.UserID, r.ttl); err != nil {
+		log.Printf("⚠️  Failed to index cached cart %s by product: %v", cart.ID, err)
+		return
+	}
+
+	key := util.CartCacheKeyPrefix + cart.UserID
+	var err error
+	if overwrite {
+		err = r.redis.SetBytes(ctx, key, buf.Bytes(), r.ttl)
+	} else {
+		err = r.redis.SetBytesIfAbsent(ctx, key, buf.Bytes(), r.ttl)
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to cache cart %s: %v", cart.ID, err)
+	}
+}
+
+// drop removes the users' cached carts
+func (r *cachedCartRepository) drop(ctx context.Context, userIDs ...string) {
+	if len(userIDs) == 0 {
+		return
+	}
+	keys := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		keys[i] = util.CartCacheKeyPrefix + userID
+	}
+	if err := r.redis.Delete(ctx, keys...); err != nil {
+		log.Printf("⚠️  Failed to drop cached carts: %v", err)
+	}
+}
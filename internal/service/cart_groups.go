@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"time"
+	"yourapp/internal/model"
+)
+
+// Reasons a seller group of the cart cannot be shipped as it is
+const (
+	CartShippingShopClosed      = "shop_closed"      // The shop was deactivated
+	CartShippingNothingSelected = "nothing_selected" // No line of the shop is selected and available
+	CartShippingBelowMinOrder   = "below_min_order"  // The selected lines do not reach the shop's minimum order
+)
+
+// CartView is the cart grouped per seller, since every shop ships separately and checkout
+// calculates shipping per shop. Totals cover the groups that can ship.
+type CartView struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Sellers          []CartSellerGroup `json:"sellers"`                     // In the order their first item was added
+	UnavailableItems []model.CartItem  `json:"unavailable_items,omitempty"` // Lines whose product no longer exists
+
+	ItemCount    int `json:"item_count"` // Units across all lines
+	LineCount    int `json:"line_count"`
+	Subtotal     int `json:"subtotal"`      // Subtotals of the groups that can ship
+	ShippingCost int `json:"shipping_cost"` // Estimated shipping of the groups that can ship
+
+	MinOrderAmount int  `json:"min_order_amount"` // Marketplace minimum for the subtotal; 0 means none
+	BelowMinOrder  bool `json:"below_min_order"`  // The subtotal does not reach the marketplace minimum
+
+	CouponCode *string `json:"coupon_code,omitempty"` // Voucher applied to the cart; see the cart summary for its discount
+}
+
+// CartSeller is the shop a group of cart lines comes from
+type CartSeller struct {
+	ID             string  `json:"id"`
+	ShopName       string  `json:"shop_name"`
+	ShopSlug       string  `json:"shop_slug"`
+	ShopLogo       *string `json:"shop_logo,omitempty"`
+	ShopCity       *string `json:"shop_city,omitempty"`
+	IsVerified     bool    `json:"is_verified"`
+	IsActive       bool    `json:"is_active"`
+	MinOrderAmount int     `json:"min_order_amount"`
+}
+
+// CartSellerGroup is one shop's lines with what checkout would charge for them. Only selected
+// lines that can be checked out count towards the subtotal and shipping.
+type CartSellerGroup struct {
+	Seller    CartSeller       `json:"seller"`
+	Items     []model.CartItem `json:"items"`
+	ItemCount int              `json:"item_count"` // Units across the shop's lines
+	Subtotal  int              `json:"subtotal"`   // Selected, available lines at current prices
+	Shipping  CartShipping     `json:"shipping"`
+}
+
+// CartShipping is whether a seller group can be shipped and what it would roughly cost, by weight
+// with the default courier service
+type CartShipping struct {
+	Eligible      bool   `json:"eligible"`
+	Reason        string `json:"reason,omitempty"` // Why it is not eligible, e.g. CartShippingBelowMinOrder
+	TotalWeight   int    `json:"total_weight"`     // Grams
+	EstimatedCost int    `json:"estimated_cost"`
+}
+
+// GetCart returns the user's cart grouped per seller, creating an empty cart when there is none
+func (s *cartService) GetCart(ctx context.Context, userID string) (*CartView, error) {
+	cart, err := s.cartRepo.GetOrCreateByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &CartView{
+		ID:         cart.ID,
+		UserID:     cart.UserID,
+		CreatedAt:  cart.CreatedAt,
+		UpdatedAt:  cart.UpdatedAt,
+		Sellers:    []CartSellerGroup{},
+		CouponCode: cart.CouponCode,
+	}
+
+	groupIndex := make(map[string]int)
+	groupLines := make(map[string][]QuoteLine)
+	for _, item := range cart.CartItems {
+		view.LineCount++
+		view.ItemCount += item.Quantity
+
+		product := cartItemProduct(item)
+		if product.ID == "" || product.Seller.ID == "" {
+			view.UnavailableItems = append(view.UnavailableItems, item)
+			continue
+		}
+
+		i, ok := groupIndex[product.SellerID]
+		if !ok {
+			i = len(view.Sellers)
+			groupIndex[product.SellerID] = i
+			seller := product.Seller
+			view.Sellers = append(view.Sellers, CartSellerGroup{
+				Seller: CartSeller{
+					ID:             seller.ID,
+					ShopName:       seller.ShopName,
+					ShopSlug:       seller.ShopSlug,
+					ShopLogo:       seller.ShopLogo,
+					ShopCity:       seller.ShopCity,
+					IsVerified:     seller.IsVerified,
+					IsActive:       seller.IsActive,
+					MinOrderAmount: seller.MinOrderAmount,
+				},
+			})
+		}
+		group := &view.Sellers[i]
+		group.Items = append(group.Items, item)
+		group.ItemCount += item.Quantity
+
+		if item.Selected && item.UnavailableReason == nil && product.IsActive && product.Seller.IsActive &&
+			cartItemAvailable(ctx, s.stock, item) >= item.Quantity {
+			groupLines[product.SellerID] = append(groupLines[product.SellerID], QuoteLine{Product: &product, Quantity: item.Quantity})
+		}
+	}
+
+	for i := range view.Sellers {
+		group := &view.Sellers[i]
+		lines := groupLines[group.Seller.ID]
+		if len(lines) > 0 {
+			quote := s.pricing.QuoteOrder(lines, QuoteOptions{})
+			group.Subtotal = quote.Subtotal
+			group.Shipping.TotalWeight = quote.TotalWeight
+			group.Shipping.EstimatedCost = quote.ShippingCost
+		}
+
+		switch {
+		case !group.Seller.IsActive:
+			group.Shipping.Reason = CartShippingShopClosed
+		case len(lines) == 0:
+			group.Shipping.Reason = CartShippingNothingSelected
+		case group.Seller.MinOrderAmount > 0 && group.Subtotal < group.Seller.MinOrderAmount:
+			group.Shipping.Reason = CartShippingBelowMinOrder
+		}
+		group.Shipping.Eligible = group.Shipping.Reason == ""
+
+		if group.Shipping.Eligible {
+			view.Subtotal += group.Subtotal
+			view.ShippingCost += group.Shipping.EstimatedCost
+		}
+	}
+	view.MinOrderAmount = s.pricing.MinOrderAmount()
+	view.BelowMinOrder = view.MinOrderAmount > 0 && view.Subtotal < view.MinOrderAmount
+	return view, nil
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+)
+
+// cartReminderBatchSize is how many abandoned carts one pass reminds at most
+const cartReminderBatchSize = 200
+
+// cartReminderProductNames is how many product names the reminder email lists
+const cartReminderProductNames = 3
+
+// CartReminderService reminds buyers about carts they left with items in them, by email and
+// optionally push, linking back to the cart. Every reminder is recorded so a cart is reminded
+// about once per change and a buyer at most once per cooldown.
+type CartReminderService interface {
+	// SendReminders reminds the owners of abandoned carts and returns how many were reminded
+	SendReminders(ctx context.Context) (int, error)
+}
+
+type cartReminderService struct {
+	reminderRepo repository.CartReminderRepository
+	cartRepo     repository.CartRepository
+	userRepo     repository.UserRepository
+	push         PushService          // Optional; nil skips pushes
+	rabbitMQ     *util.RabbitMQClient // Optional; nil skips emails
+	idle         time.Duration
+	maxIdle      time.Duration
+	cooldown     time.Duration
+	pushEnabled  bool
+	cartURL      string
+}
+
+func NewCartReminderService(
+	reminderRepo repository.CartReminderRepository,
+	cartRepo repository.CartRepository,
+	userRepo repository.UserRepository,
+	push PushService,
+	rabbitMQ *util.RabbitMQClient,
+	cfg *config.Config,
+	jobs *util.BackgroundJobs,
+) CartReminderService {
+	cartURL := cfg.CartReminderURL
+	if cartURL == "" {
+		cartURL = strings.TrimRight(cfg.ClientURL, "/") + "/cart"
+	}
+	service := &cartReminderService{
+		reminderRepo: reminderRepo,
+		cartRepo:     cartRepo,
+		userRepo:     userRepo,
+		push:         push,
+		rabbitMQ:     rabbitMQ,
+		idle:         time.Duration(cfg.CartReminderIdleHours) * time.Hour,
+		maxIdle:      time.Duration(cfg.CartReminderMaxIdleDays) * 24 * time.Hour,
+		cooldown:     time.Duration(cfg.CartReminderCooldownHours) * time.Hour,
+		pushEnabled:  cfg.CartReminderPushEnabled,
+		cartURL:      cartURL,
+	}
+
+	// Start background job to remind buyers about abandoned carts
+	if service.idle > 0 && cfg.CartReminderCheckIntervalMinutes > 0 {
+		interval := time.Duration(cfg.CartReminderCheckIntervalMinutes) * time.Minute
+		jobs.Every(interval, service.runCartReminder)
+		log.Printf("✅ Abandoned cart reminders started (carts idle for %s, checking every %s)", service.idle, interval)
+	}
+
+	return service
+}
+
+// runCartReminder reminds buyers about abandoned carts
+func (s *cartReminderService) runCartReminder(ctx context.Context) {
+	if _, err := s.SendReminders(ctx); err != nil {
+		log.Printf("⚠️  Failed to send abandoned cart reminders: %v", err)
+	}
+}
+
+func (s *cartReminderService) SendReminders(ctx context.Context) (int, error) {
+	if s.rabbitMQ == nil && (s.push == nil || !s.pushEnabled) {
+		return 0, nil // Nothing to send reminders with; do not burn the history
+	}
+
+	now := time.Now()
+	notBefore := time.Time{}
+	if s.maxIdle > 0 {
+		notBefore = now.Add(-s.maxIdle)
+	}
+	carts, err := s.reminderRepo.FindAbandonedCarts(ctx, now.Add(-s.idle), notBefore, now.Add(-s.cooldown), cartReminderBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	reminded := 0
+	for i := range carts {
+		if s.remind(ctx, &carts[i]) {
+			reminded++
+		}
+	}
+
+	if reminded > 0 {
+		log.Printf("🛒 Sent %d abandoned cart reminder(s)", reminded)
+	}
+	return reminded, nil
+}
+
+// remind records the reminder and sends it; the record is written first so a failing send is
+// not retried on every pass
+func (s *cartReminderService) remind(ctx context.Context, abandoned *repository.AbandonedCart) bool {
+	user, err := s.userRepo.FindByID(abandoned.UserID)
+	if err != nil || !user.IsActive {
+		return false
+	}
+	cart, err := s.cartRepo.GetByUserID(abandoned.UserID)
+	if err != nil || len(cart.CartItems) == 0 {
+		return false
+	}
+
+	reminder := &model.CartReminder{
+		CartID:         abandoned.CartID,
+		UserID:         abandoned.UserID,
+		CartActivityAt: abandoned.LastActivityAt,
+		ItemCount:      abandoned.ItemCount,
+		Subtotal:       abandoned.Subtotal,
+		EmailQueued:    s.rabbitMQ != nil && user.Email != "",
+		PushSent:       s.push != nil && s.pushEnabled,
+		SentAt:         time.Now(),
+	}
+	if err := s.reminderRepo.Create(ctx, reminder); err != nil {
+		log.Printf("⚠️  Failed to record cart reminder for user %s: %v", abandoned.UserID, err)
+		return false
+	}
+
+	link := s.cartURL + "?utm_source=cart_reminder&reminder_id=" + reminder.ID
+	if reminder.EmailQueued && !s.sendEmail(user, cart, reminder, link) {
+		reminder.EmailQueued = false
+		if err := s.reminderRepo.Update(ctx, reminder); err != nil {
+			log.Printf("⚠️  Failed to update cart reminder %s: %v", reminder.ID, err)
+		}
+	}
+	if reminder.PushSent {
+		s.push.NotifyUser(abandoned.UserID, PushMessage{
+			Title: "Keranjangmu menunggu",
+			Body:  "Masih ada " + strconv.Itoa(abandoned.ItemCount) + " produk di keranjangmu. Yuk, selesaikan belanjamu!",
+			Data: map[string]string{
+				"event":       PushEventCartReminder,
+				"cart_id":     abandoned.CartID,
+				"reminder_id": reminder.ID,
+				"link":        link,
+			},
+		})
+	}
+	return reminder.EmailQueued || reminder.PushSent
+}
+
+// sendEmail enqueues the reminder email and reports whether it was queued
+func (s *cartReminderService) sendEmail(user *model.User, cart *model.Cart, reminder *model.CartReminder, link string) bool {
+	names := make([]string, 0, cartReminderProductNames)
+	for _, item := range cart.CartItems {
+		if len(names) == cartReminderProductNames {
+			break
+		}
+		if item.Product.Name != "" {
+			names = append(names, item.Product.Name)
+		}
+	}
+
+	emailMsg := util.EmailMessage{
+		To:      user.Email,
+		Subject: "Barang di Keranjangmu Masih Menunggu",
+		Type:    "abandoned_cart",
+		Data: map[string]string{
+			"name":          user.FullName,
+			"item_count":    strconv.Itoa(reminder.ItemCount),
+			"subtotal":      strconv.Itoa(reminder.Subtotal),
+			"product_names": strings.Join(names, ", "),
+			"cart_url":      link,
+		},
+	}
+	if err := s.rabbitMQ.PublishEmail(emailMsg); err != nil {
+		log.Printf("Failed to publish abandoned cart email for user %s: %v", user.ID, err)
+		return false
+	}
+	return true
+}
@@ -1,50 +1,135 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"yourapp/internal/config"
 	"yourapp/internal/model"
 	"yourapp/internal/repository"
+	"yourapp/internal/util"
 )
 
 type CartService interface {
-	GetCart(userID string) (*model.Cart, error)
+	// GetCart returns the cart grouped per seller with per-seller subtotals and shipping eligibility
+	GetCart(ctx context.Context, userID string) (*CartView, error)
 	AddItemToCart(userID string, req *AddCartItemRequest) (*model.CartItem, error)
+	// AddItemsToCart adds each item independently; one failure does not undo the others
+	AddItemsToCart(userID string, req *BulkAddCartItemsRequest) (*BulkAddCartItemsResponse, error)
 	UpdateCartItem(userID string, cartItemID string, req *UpdateCartItemRequest) (*model.CartItem, error)
 	RemoveCartItem(userID string, cartItemID string) error
 	ClearCart(userID string) error
 	GetCartItems(userID string) ([]model.CartItem, error)
+	// CountCartItems returns the counts for the cart badge; a user without a cart has 0
+	CountCartItems(userID string) (*CartCount, error)
+	// ValidateCart reports price, stock and availability changes of the cart's items
+	ValidateCart(ctx context.Context, userID string) (*CartValidation, error)
+	SelectCartItem(userID string, cartItemID string, req *SelectCartItemRequest) (*model.CartItem, error)
+	SelectCartItems(userID string, req *SelectCartItemsRequest) ([]model.CartItem, error)
+	SaveForLater(ctx context.Context, userID string, cartItemID string) (*model.SavedForLaterItem, error)
+	GetSavedForLater(ctx context.Context, userID string) ([]model.SavedForLaterItem, error)
+	MoveToCart(ctx context.Context, userID string, savedItemID string) (*model.CartItem, error)
+	RemoveSavedForLater(ctx context.Context, userID string, savedItemID string) error
+	// MoveToWishlist removes the cart item, adds its product to the wishlist and returns the cart
+	MoveToWishlist(ctx context.Context, userID string, cartItemID string) (*CartView, error)
+	GetWishlist(ctx context.Context, userID string) ([]model.WishlistItem, error)
+	AddToWishlist(ctx context.Context, userID string, productID string) error
+	RemoveFromWishlist(ctx context.Context, userID string, wishlistItemID string) error
+	FlagShopItems(sellerID string, shopName string)
+	UnflagShopItems(sellerID string)
+	// WatchCartStock streams the live stock and price of the user's cart items until ctx is done
+	WatchCartStock(ctx context.Context, userID string) <-chan CartStockUpdate
 }
 
 type cartService struct {
-	cartRepo    repository.CartRepository
-	productRepo repository.ProductRepository
+	cartRepo     repository.CartRepository
+	savedRepo    repository.SavedForLaterRepository
+	wishlistRepo repository.WishlistRepository
+	productRepo  repository.ProductRepository
+	analytics    AnalyticsService
+	stock        StockCacheService
+	pricing      PricingService
+	userRepo     repository.UserRepository
+	rabbitMQ     *util.RabbitMQClient // Optional; used to notify buyers about unavailable items
+
+	events            ProductEventService
+	lowStockThreshold int
+	stockRefresh      time.Duration
 }
 
 type AddCartItemRequest struct {
-	ProductID string `json:"product_id" binding:"required"`
-	Quantity  int    `json:"quantity" binding:"required,min=1"`
+	ProductID string  `json:"product_id" binding:"required"`
+	VariantID *string `json:"variant_id"` // Required for a product with variants
+	Quantity  int     `json:"quantity" binding:"required,min=1"`
+	Note      *string `json:"note" binding:"omitempty,max=255"` // Optional: replaces the note of an item already in the cart
+}
+
+type BulkAddCartItemsRequest struct {
+	Items []AddCartItemRequest `json:"items" binding:"required,min=1,max=100,dive"`
+}
+
+// BulkAddCartItemResult is the outcome of one item: "added" or "failed"
+type BulkAddCartItemResult struct {
+	ProductID  string                     `json:"product_id"`
+	Quantity   int                        `json:"quantity"`
+	Status     string                     `json:"status"`
+	CartItem   *model.CartItem            `json:"cart_item,omitempty"`
+	Error      string                     `json:"error,omitempty"`
+	Violations []OrderConstraintViolation `json:"violations,omitempty"` // Quantity limits the item broke
+}
+
+type BulkAddCartItemsResponse struct {
+	Results []BulkAddCartItemResult `json:"results"`
+	Added   int                     `json:"added"`
+	Failed  int                     `json:"failed"`
 }
 
 type UpdateCartItemRequest struct {
-	Quantity int `json:"quantity" binding:"required,min=1"`
+	Quantity int     `json:"quantity" binding:"required,min=1"`
+	Note     *string `json:"note" binding:"omitempty,max=255"` // Optional: omit to keep the note, empty to clear it
+}
+
+type SelectCartItemRequest struct {
+	Selected *bool `json:"selected" binding:"required"`
+}
+
+// SelectCartItemsRequest selects or deselects several items at once; no IDs means the whole cart
+type SelectCartItemsRequest struct {
+	CartItemIDs []string `json:"cart_item_ids"`
+	Selected    *bool    `json:"selected" binding:"required"`
 }
 
 func NewCartService(
 	cartRepo repository.CartRepository,
+	savedRepo repository.SavedForLaterRepository,
+	wishlistRepo repository.WishlistRepository,
 	productRepo repository.ProductRepository,
+	analytics AnalyticsService,
+	stock StockCacheService,
+	pricing PricingService,
+	userRepo repository.UserRepository,
+	rabbitMQ *util.RabbitMQClient,
+	events ProductEventService,
+	cfg *config.Config,
 ) CartService {
 	return &cartService{
-		cartRepo:    cartRepo,
-		productRepo: productRepo,
-	}
-}
-
-func (s *cartService) GetCart(userID string) (*model.Cart, error) {
-	cart, err := s.cartRepo.GetOrCreateByUserID(userID)
-	if err != nil {
-		return nil, err
+		cartRepo:     cartRepo,
+		savedRepo:    savedRepo,
+		wishlistRepo: wishlistRepo,
+		productRepo:  productRepo,
+		analytics:    analytics,
+		stock:        stock,
+		pricing:      pricing,
+		userRepo:     userRepo,
+		rabbitMQ:     rabbitMQ,
+
+		events:            events,
+		lowStockThreshold: cfg.CartStockLowThreshold,
+		stockRefresh:      time.Duration(cfg.CartStockHeartbeatSeconds) * time.Second,
 	}
-	return cart, nil
 }
 
 func (s *cartService) AddItemToCart(userID string, req *AddCartItemRequest) (*model.CartItem, error) {
@@ -60,29 +145,53 @@ func (s *cartService) AddItemToCart(userID string, req *AddCartItemRequest) (*mo
 		return nil, errors.New("product not found")
 	}
 
-	// Check if product is active
-	if !product.IsActive {
+	// Check if product and its shop are active
+	if !product.IsActive || product.Seller.ID == "" || !product.Seller.IsActive {
 		return nil, errors.New("product is not available")
 	}
 
+	variant, err := resolveVariant(product, req.VariantID)
+	if err != nil {
+		return nil, err
+	}
+	var variantID *string
+	if variant != nil {
+		variantID = &variant.ID
+	}
+	price := applyVariant(*product, variant).Price
+
 	// Check stock
-	if product.Stock < req.Quantity {
+	available := availableUnits(context.Background(), s.stock, product, variant)
+	if available < req.Quantity {
 		return nil, errors.New("insufficient stock")
 	}
+	if err := checkMaxQuantity(product, req.Quantity); err != nil {
+		return nil, err
+	}
 
-	// Check if item already exists in cart
-	existingItem, err := s.cartRepo.GetCartItemByProductID(cart.ID, req.ProductID)
+	// Check if item already exists in cart; each variant is its own item
+	existingItem, err := s.cartRepo.GetCartItemByProductID(cart.ID, req.ProductID, variantID)
 	if err == nil {
 		// Update quantity if item exists
 		newQuantity := existingItem.Quantity + req.Quantity
-		if product.Stock < newQuantity {
+		if available < newQuantity {
 			return nil, errors.New("insufficient stock")
 		}
+		if err := checkMaxQuantity(product, newQuantity); err != nil {
+			return nil, err
+		}
 		existingItem.Quantity = newQuantity
-		existingItem.Price = product.Price // Update price to current price
+		existingItem.Price = price // Update price to current price
+		// A stale flag (e.g. the shop was closed and reopened) would keep the item out of checkout
+		existingItem.UnavailableReason = nil
+		existingItem.Selected = true
+		if req.Note != nil {
+			existingItem.Note = normalizeCartItemNote(req.Note)
+		}
 		if err := s.cartRepo.UpdateCartItem(existingItem); err != nil {
 			return nil, err
 		}
+		s.analytics.RecordAddToCart(context.Background(), product)
 		return existingItem, nil
 	}
 
@@ -90,13 +199,17 @@ func (s *cartService) AddItemToCart(userID string, req *AddCartItemRequest) (*mo
 	cartItem := &model.CartItem{
 		CartID:    cart.ID,
 		ProductID: req.ProductID,
+		VariantID: variantID,
 		Quantity:  req.Quantity,
-		Price:     product.Price,
+		Price:     price,
+		Selected:  true,
+		Note:      normalizeCartItemNote(req.Note),
 	}
 
 	if err := s.cartRepo.AddCartItem(cartItem); err != nil {
 		return nil, err
 	}
+	s.analytics.RecordAddToCart(context.Background(), product)
 
 	// Load product details
 	cartItem, err = s.cartRepo.GetCartItemByID(cartItem.ID)
@@ -107,6 +220,38 @@ func (s *cartService) AddItemToCart(userID string, req *AddCartItemRequest) (*mo
 	return cartItem, nil
 }
 
+func (s *cartService) AddItemsToCart(userID string, req *BulkAddCartItemsRequest) (*BulkAddCartItemsResponse, error) {
+	// Create the cart up front so concurrent first adds cannot race to create it
+	if _, err := s.cartRepo.GetOrCreateByUserID(userID); err != nil {
+		return nil, err
+	}
+
+	resp := &BulkAddCartItemsResponse{Results: make([]BulkAddCartItemResult, 0, len(req.Items))}
+	for i := range req.Items {
+		item := &req.Items[i]
+		result := BulkAddCartItemResult{ProductID: item.ProductID, Quantity: item.Quantity}
+
+		cartItem, err := s.AddItemToCart(userID, item)
+		if err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			var constraints *OrderConstraintError
+			if errors.As(err, &constraints) {
+				result.Violations = constraints.Violations
+			}
+			resp.Failed++
+		} else {
+			result.Status = "added"
+			result.CartItem = cartItem
+			resp.Added++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	log.Printf("🛒 Bulk add to the cart of user %s: %d added, %d failed", userID, resp.Added, resp.Failed)
+	return resp, nil
+}
+
 func (s *cartService) UpdateCartItem(userID string, cartItemID string, req *UpdateCartItemRequest) (*model.CartItem, error) {
 	// Get cart to verify ownership
 	cart, err := s.cartRepo.GetByUserID(userID)
@@ -124,6 +269,9 @@ func (s *cartService) UpdateCartItem(userID string, cartItemID string, req *Upda
 	if cartItem.CartID != cart.ID {
 		return nil, errors.New("unauthorized")
 	}
+	if cartItem.UnavailableReason != nil {
+		return nil, errors.New("product is not available")
+	}
 
 	// Get product to check stock
 	product, err := s.productRepo.FindByID(cartItem.ProductID)
@@ -131,14 +279,27 @@ func (s *cartService) UpdateCartItem(userID string, cartItemID string, req *Upda
 		return nil, errors.New("product not found")
 	}
 
+	var variant *model.ProductVariant
+	if cartItem.VariantID != nil {
+		if variant, err = resolveVariant(product, cartItem.VariantID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Check stock
-	if product.Stock < req.Quantity {
+	if availableUnits(context.Background(), s.stock, product, variant) < req.Quantity {
 		return nil, errors.New("insufficient stock")
 	}
+	if err := checkMaxQuantity(product, req.Quantity); err != nil {
+		return nil, err
+	}
 
 	// Update cart item
 	cartItem.Quantity = req.Quantity
-	cartItem.Price = product.Price // Update price to current price
+	cartItem.Price = applyVariant(*product, variant).Price // Update price to current price
+	if req.Note != nil {
+		cartItem.Note = normalizeCartItemNote(req.Note)
+	}
 
 	if err := s.cartRepo.UpdateCartItem(cartItem); err != nil {
 		return nil, err
@@ -191,3 +352,124 @@ func (s *cartService) GetCartItems(userID string) ([]model.CartItem, error) {
 
 	return s.cartRepo.GetCartItems(cart.ID)
 }
+
+// CartCount is what the cart badge shows
+type CartCount struct {
+	Count    int64 `json:"count"`    // Items (products) in the cart
+	Quantity int64 `json:"quantity"` // Sum of their quantities
+}
+
+func (s *cartService) CountCartItems(userID string) (*CartCount, error) {
+	count, quantity, err := s.cartRepo.CountItemsByUserID(userID)
+	if err != nil {
+		return nil, errors.New("failed to count cart items: " + err.Error())
+	}
+	return &CartCount{Count: count, Quantity: quantity}, nil
+}
+
+// SelectCartItem includes the item in checkout or leaves it in the cart for later
+func (s *cartService) SelectCartItem(userID string, cartItemID string, req *SelectCartItemRequest) (*model.CartItem, error) {
+	cart, err := s.cartRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, errors.New("cart not found")
+	}
+
+	cartItem, err := s.cartRepo.GetCartItemByID(cartItemID)
+	if err != nil {
+		return nil, errors.New("cart item not found")
+	}
+	if cartItem.CartID != cart.ID {
+		return nil, errors.New("unauthorized")
+	}
+
+	if _, err := s.cartRepo.SetItemsSelected(cart.ID, []string{cartItemID}, *req.Selected); err != nil {
+		return nil, err
+	}
+	cartItem.Selected = *req.Selected
+	return cartItem, nil
+}
+
+// SelectCartItems selects or deselects the given items, or the whole cart when no IDs are given,
+// and returns the cart's items
+func (s *cartService) SelectCartItems(userID string, req *SelectCartItemsRequest) ([]model.CartItem, error) {
+	cart, err := s.cartRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, errors.New("cart not found")
+	}
+
+	ids := uniqueStrings(req.CartItemIDs)
+	matched, err := s.cartRepo.SetItemsSelected(cart.ID, ids, *req.Selected)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 && matched != int64(len(ids)) {
+		return nil, errors.New("cart item not found")
+	}
+
+	return s.cartRepo.GetCartItems(cart.ID)
+}
+
+// FlagShopItems marks every cart item from a deactivated or deleted shop as unavailable and emails
+// the affected buyers. It runs in the background so closing a shop does not wait on it.
+func (s *cartService) FlagShopItems(sellerID string, shopName string) {
+	go func() {
+		affected, err := s.cartRepo.FlagItemsBySellerID(sellerID, model.CartItemUnavailableShopClosed)
+		if err != nil {
+			log.Printf("⚠️  Failed to flag cart items of closed shop %s: %v", sellerID, err)
+			return
+		}
+		if len(affected) == 0 {
+			return
+		}
+		log.Printf("🧹 Flagged cart items of closed shop %s in %d cart(s)", sellerID, len(affected))
+
+		if s.rabbitMQ == nil {
+			log.Printf("Warning: RabbitMQ not available, %d buyer(s) not notified about closed shop %s", len(affected), sellerID)
+			return
+		}
+		for userID, items := range affected {
+			user, err := s.userRepo.FindByID(userID)
+			if err != nil {
+				continue
+			}
+			emailMsg := util.EmailMessage{
+				To:      user.Email,
+				Subject: "Produk di Keranjang Tidak Tersedia",
+				Type:    "cart_items_unavailable",
+				Data: map[string]string{
+					"shop_name":  shopName,
+					"item_count": strconv.Itoa(items),
+				},
+			}
+			if err := s.rabbitMQ.PublishEmail(emailMsg); err != nil {
+				log.Printf("Failed to publish cart items unavailable email for user %s: %v", userID, err)
+			}
+		}
+	}()
+}
+
+// UnflagShopItems makes the cart items of a reactivated shop available again, in the background
+func (s *cartService) UnflagShopItems(sellerID string) {
+	go func() {
+		restored, err := s.cartRepo.UnflagItemsBySellerID(sellerID, model.CartItemUnavailableShopClosed)
+		if err != nil {
+			log.Printf("⚠️  Failed to restore cart items of reopened shop %s: %v", sellerID, err)
+			return
+		}
+		if restored > 0 {
+			log.Printf("♻️  Restored %d cart item(s) of reopened shop %s", restored, sellerID)
+		}
+	}()
+}
+
+// normalizeCartItemNote trims a buyer note; a blank note is stored as none
+func normalizeCartItemNote(note *string) *string {
+	if note == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*note)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
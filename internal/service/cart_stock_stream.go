@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+	"yourapp/internal/model"
+)
+
+// defaultCartStockRefresh is used when no refresh interval is configured
+const defaultCartStockRefresh = 20 * time.Second
+
+// CartStockItem is the live availability of one cart item
+type CartStockItem struct {
+	CartItemID string `json:"cart_item_id"`
+	ProductID  string `json:"product_id"`
+	Quantity   int    `json:"quantity"`
+	Available  int    `json:"available"`  // Units that can still be bought; 0 once the product is unavailable
+	Price      int    `json:"price"`      // Current product price
+	CartPrice  int    `json:"cart_price"` // Price held by the cart item; differs when the price changed
+	InStock    bool   `json:"in_stock"`   // Available covers the quantity in the cart
+	LowStock   bool   `json:"low_stock"`  // Only a few units left ("only 2 left")
+}
+
+// CartStockUpdate is one message of the live cart stock stream. The first update is Full and
+// lists every item; later ones list only items that changed and the cart items that were removed.
+type CartStockUpdate struct {
+	Full    bool            `json:"full"`
+	Items   []CartStockItem `json:"items"`
+	Removed []string        `json:"removed,omitempty"`
+}
+
+// WatchCartStock re-reads the cart whenever a product in it changes (and on every refresh
+// interval, which also picks up items added or removed meanwhile) and sends what differs from
+// the previous update
+func (s *cartService) WatchCartStock(ctx context.Context, userID string) <-chan CartStockUpdate {
+	updates := make(chan CartStockUpdate, 1)
+	events := s.events.Subscribe(ctx)
+
+	go func() {
+		defer close(updates)
+
+		interval := s.stockRefresh
+		if interval <= 0 {
+			interval = defaultCartStockRefresh
+		}
+		refresh := time.NewTicker(interval)
+		defer refresh.Stop()
+
+		var last map[string]CartStockItem
+		send := func() bool {
+			current := s.cartStock(ctx, userID)
+			update := diffCartStock(last, current)
+			last = current
+			if !update.Full && len(update.Items) == 0 && len(update.Removed) == 0 {
+				return true
+			}
+			select {
+			case updates <- update:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if !send() {
+			return
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case productID, ok := <-events:
+				if !ok {
+					return
+				}
+				if !cartHasProduct(last, productID) {
+					continue
+				}
+				// One order touches several products; coalesce the burst into a single re-read
+				drainEvents(events)
+			case <-refresh.C:
+			}
+			if !send() {
+				return
+			}
+		}
+	}()
+
+	return updates
+}
+
+// cartStock returns the live stock of the user's cart items keyed by cart item ID
+func (s *cartService) cartStock(ctx context.Context, userID string) map[string]CartStockItem {
+	items := make(map[string]CartStockItem)
+	cart, err := s.cartRepo.GetByUserID(userID)
+	if err != nil {
+		return items // No cart yet
+	}
+
+	for _, item := range cart.CartItems {
+		stock := CartStockItem{
+			CartItemID: item.ID,
+			ProductID:  item.ProductID,
+			Quantity:   item.Quantity,
+			Price:      cartItemProduct(item).Price,
+			CartPrice:  item.Price,
+		}
+		if isCartProductAvailable(item) {
+			stock.Available = cartItemAvailable(ctx, s.stock, item)
+			if stock.Available < 0 {
+				stock.Available = 0
+			}
+		}
+		stock.InStock = stock.Available >= item.Quantity
+		stock.LowStock = stock.Available > 0 && stock.Available <= s.lowStockThreshold
+		items[item.ID] = stock
+	}
+	return items
+}
+
+// isCartProductAvailable reports whether the cart item's product, and its variant, can still be
+// bought at all
+func isCartProductAvailable(item model.CartItem) bool {
+	product := cartItemProduct(item)
+	return item.UnavailableReason == nil && product.ID != "" && product.IsActive &&
+		product.Seller.ID != "" && product.Seller.IsActive
+}
+
+// diffCartStock returns a full update when there is no previous state, otherwise the changed
+// and removed items
+func diffCartStock(previous, current map[string]CartStockItem) CartStockUpdate {
+	if previous == nil {
+		update := CartStockUpdate{Full: true, Items: make([]CartStockItem, 0, len(current))}
+		for _, item := range current {
+			update.Items = append(update.Items, item)
+		}
+		sortCartStock(update.Items)
+		return update
+	}
+
+	var update CartStockUpdate
+	for id, item := range current {
+		if old, ok := previous[id]; !ok || old != item {
+			update.Items = append(update.Items, item)
+		}
+	}
+	for id := range previous {
+		if _, ok := current[id]; !ok {
+			update.Removed = append(update.Removed, id)
+		}
+	}
+	sortCartStock(update.Items)
+	sort.Strings(update.Removed)
+	return update
+}
+
+func sortCartStock(items []CartStockItem) {
+	sort.Slice(items, func(i, j int) bool { return items[i].CartItemID < items[j].CartItemID })
+}
+
+func cartHasProduct(items map[string]CartStockItem, productID string) bool {
+	for _, item := range items {
+		if item.ProductID == productID {
+			return true
+		}
+	}
+	return false
+}
+
+// drainEvents discards events already queued behind the one being handled
+func drainEvents(events <-chan string) {
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
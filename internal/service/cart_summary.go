@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// CartSummary is the cart's totals as checkout would compute them, for the cart and checkout
+// screens. Only selected lines are priced; lines that cannot be checked out (unavailable product
+// or shop, not enough stock) are counted but left out of the totals. Shipping is estimated for the user's default address and
+// the default courier service, without optional extras such as insurance or gift wrap.
+type CartSummary struct {
+	ItemCount        int  `json:"item_count"`        // Units across the lines in the totals
+	LineCount        int  `json:"line_count"`        // Cart lines in the totals
+	UnavailableCount int  `json:"unavailable_count"` // Selected cart lines left out of the totals
+	UnselectedCount  int  `json:"unselected_count"`  // Cart lines the buyer deselected
+	PriceChanged     bool `json:"price_changed"`     // A line's price changed since it was added; totals use current prices
+
+	Subtotal       int  `json:"subtotal"`
+	ShippingCost   int  `json:"shipping_cost"`
+	ServiceFee     int  `json:"service_fee"`
+	ApplicationFee int  `json:"application_fee"`
+	TotalDiscount  int  `json:"total_discount"`
+	TaxAmount      int  `json:"tax_amount"`
+	TaxInclusive   bool `json:"tax_inclusive"` // The tax is contained in the subtotal rather than added to the total
+	TotalAmount    int  `json:"total_amount"`
+
+	ShippingAddressID *string           `json:"shipping_address_id,omitempty"` // Default address the estimate is for
+	Delivery          *DeliveryEstimate `json:"delivery,omitempty"`
+
+	Violations []OrderConstraintViolation `json:"violations,omitempty"` // Order minimums and quantity limits checkout would reject
+	Voucher    *CartVoucher               `json:"voucher,omitempty"`    // Voucher applied to the cart; its discount is in the totals
+}
+
+// GetCartSummary prices the user's cart at current prices. An empty or missing cart has an
+// all-zero summary.
+func (s *orderService) GetCartSummary(ctx context.Context, userID string) (*CartSummary, error) {
+	summary := &CartSummary{}
+	cart, err := s.cartRepo.GetByUserID(userID)
+	if err != nil || len(cart.CartItems) == 0 {
+		return summary, nil
+	}
+
+	var lines []QuoteLine
+	for _, item := range cart.CartItems {
+		if !item.Selected {
+			summary.UnselectedCount++
+			continue
+		}
+		product := cartItemProduct(item)
+		if item.UnavailableReason != nil || product.ID == "" || !product.IsActive || product.Seller.ID == "" || !product.Seller.IsActive ||
+			cartItemAvailable(ctx, s.stock, item) < item.Quantity {
+			summary.UnavailableCount++
+			continue
+		}
+		lines = append(lines, QuoteLine{Product: &product, Quantity: item.Quantity})
+		summary.LineCount++
+		summary.ItemCount += item.Quantity
+		if item.Price != product.Price {
+			summary.PriceChanged = true
+		}
+	}
+	if len(lines) == 0 {
+		return summary, nil
+	}
+
+	quote, _, voucherErr := s.quoteCart(ctx, userID, cart, lines, QuoteOptions{})
+	summary.Voucher = cartVoucher(cart, quote, voucherErr)
+	summary.Subtotal = quote.Subtotal
+	summary.ShippingCost = quote.ShippingCost
+	summary.ServiceFee = quote.ServiceFee
+	summary.ApplicationFee = quote.ApplicationFee
+	summary.TotalDiscount = quote.TotalDiscount
+	summary.TaxAmount = quote.TaxAmount
+	summary.TaxInclusive = quote.TaxInclusive
+	summary.TotalAmount = quote.TotalAmount
+
+	var constraints *OrderConstraintError
+	if errors.As(checkOrderConstraints(lines, quote, s.pricing.MinOrderAmount()), &constraints) {
+		summary.Violations = constraints.Violations
+	}
+
+	address := s.previewDestination(userID, "")
+	if address != nil {
+		summary.ShippingAddressID = &address.ID
+	}
+	// The estimate is a nice-to-have on this screen; the totals are still returned without it
+	delivery, err := s.calendar.EstimateDelivery(ctx, time.Now(), handlingDaysFor(lines), DefaultCourierService, deliveryRouteFor(lines, address))
+	if err != nil {
+		log.Printf("⚠️  Failed to estimate delivery for the cart of user %s: %v", userID, err)
+	} else {
+		summary.Delivery = delivery
+	}
+	return summary, nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+)
+
+// CartItemValidation is one cart line re-checked against its product as it is now
+type CartItemValidation struct {
+	CartItemID   string `json:"cart_item_id"`
+	ProductID    string `json:"product_id"`
+	ProductName  string `json:"product_name"`
+	Quantity     int    `json:"quantity"`
+	Available    int    `json:"available"` // Units that can still be bought; 0 once the product is inactive
+	Selected     bool   `json:"selected"`
+	CartPrice    int    `json:"cart_price"`    // Price held by the cart item
+	CurrentPrice int    `json:"current_price"` // Price checkout would charge
+	PriceDelta   int    `json:"price_delta"`   // CurrentPrice - CartPrice; positive when it went up
+
+	PriceChanged bool `json:"price_changed"`
+	OutOfStock   bool `json:"out_of_stock"` // Available no longer covers the quantity
+	Inactive     bool `json:"inactive"`     // The product or its shop can no longer be bought
+}
+
+// CartValidation reports what changed in the cart since items were added. Valid is false when a
+// selected line would not check out as the buyer last saw it.
+type CartValidation struct {
+	Valid             bool                 `json:"valid"`
+	PriceChangedCount int                  `json:"price_changed_count"`
+	OutOfStockCount   int                  `json:"out_of_stock_count"`
+	InactiveCount     int                  `json:"inactive_count"`
+	Items             []CartItemValidation `json:"items"`
+}
+
+// ValidateCart re-checks every cart item's price, stock and active status. Nothing is changed; the
+// client decides what to prompt the buyer with before checkout.
+func (s *cartService) ValidateCart(ctx context.Context, userID string) (*CartValidation, error) {
+	validation := &CartValidation{Valid: true, Items: []CartItemValidation{}}
+	cart, err := s.cartRepo.GetByUserID(userID)
+	if err != nil {
+		return validation, nil // No cart yet
+	}
+
+	for _, item := range cart.CartItems {
+		line := CartItemValidation{
+			CartItemID:   item.ID,
+			ProductID:    item.ProductID,
+			ProductName:  item.Product.Name,
+			Quantity:     item.Quantity,
+			Selected:     item.Selected,
+			CartPrice:    item.Price,
+			CurrentPrice: cartItemProduct(item).Price,
+			PriceDelta:   cartItemProduct(item).Price - item.Price,
+		}
+		line.PriceChanged = line.PriceDelta != 0
+		if isCartProductAvailable(item) {
+			line.Available = max(cartItemAvailable(ctx, s.stock, item), 0)
+		} else {
+			line.Inactive = true
+		}
+		line.OutOfStock = !line.Inactive && line.Available < item.Quantity
+
+		if line.PriceChanged {
+			validation.PriceChangedCount++
+		}
+		if line.OutOfStock {
+			validation.OutOfStockCount++
+		}
+		if line.Inactive {
+			validation.InactiveCount++
+		}
+		if item.Selected && (line.PriceChanged || line.OutOfStock || line.Inactive) {
+			validation.Valid = false
+		}
+		validation.Items = append(validation.Items, line)
+	}
+	return validation, nil
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 
 	"yourapp/internal/model"
@@ -16,6 +17,10 @@ type CategoryService interface {
 	GetCategories(activeOnly bool) ([]model.Category, error)
 	UpdateCategory(id string, req UpdateCategoryRequest) (*model.Category, error)
 	DeleteCategory(id string) error
+	GetDeletedCategories(page, limit int) ([]model.Category, int64, error)
+	// RestoreCategory undeletes a category. If another category took its slug in the meantime, the
+	// restored one gets the first free numbered slug.
+	RestoreCategory(id string) (*model.Category, error)
 }
 
 type categoryService struct {
@@ -182,6 +187,50 @@ func (s *categoryService) DeleteCategory(id string) error {
 	return s.categoryRepo.Delete(id)
 }
 
+func (s *categoryService) GetDeletedCategories(page, limit int) ([]model.Category, int64, error) {
+	categories, total, err := s.categoryRepo.FindDeleted(page, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get deleted categories: %w", err)
+	}
+	return categories, total, nil
+}
+
+func (s *categoryService) RestoreCategory(id string) (*model.Category, error) {
+	category, err := s.categoryRepo.FindDeletedByID(id)
+	if err != nil {
+		return nil, errors.New("category not found in trash")
+	}
+	if category.ParentID != nil {
+		if _, err := s.categoryRepo.FindByID(*category.ParentID); err != nil {
+			return nil, errors.New("parent category is deleted; restore it first")
+		}
+	}
+
+	slug := availableSlug(category.Slug, func(candidate string) bool {
+		existing, _ := s.categoryRepo.FindBySlug(candidate)
+		return existing != nil
+	})
+	if err := s.categoryRepo.Restore(category.ID, slug); err != nil {
+		return nil, fmt.Errorf("failed to restore category: %w", err)
+	}
+
+	if slug != category.Slug {
+		log.Printf("♻️  Category %s restored as %q (slug %q was taken)", category.ID, slug, category.Slug)
+	} else {
+		log.Printf("♻️  Category %s restored", category.ID)
+	}
+	return s.categoryRepo.FindByID(category.ID)
+}
+
+// availableSlug returns slug, or slug with the first numeric suffix that taken reports as free
+func availableSlug(slug string, taken func(string) bool) string {
+	candidate := slug
+	for i := 2; taken(candidate); i++ {
+		candidate = fmt.Sprintf("%s-%d", slug, i)
+	}
+	return candidate
+}
+
 // generateSlug generates a URL-friendly slug from a string
 func generateSlug(text string) string {
 	slug := strings.ToLower(text)
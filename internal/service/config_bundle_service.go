@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/repository"
+)
+
+// configBundleVersion is bumped when the bundle layout changes incompatibly
+const configBundleVersion = 1
+
+// configBundleFormat identifies bundle files and is bound into the encryption, so a bundle cannot
+// be passed off as another kind of encrypted payload
+const configBundleFormat = "yourapp-config-bundle"
+
+var (
+	ErrConfigBundleDisabled = errors.New("config bundles are disabled: CONFIG_BUNDLE_KEY is not set")
+	ErrConfigBundleInvalid  = errors.New("config bundle is invalid or was encrypted with a different key")
+)
+
+// ConfigBundleService exports the marketplace's reference data as an encrypted, tamper-proof
+// bundle and imports it into another environment (e.g. staging to production). Environments
+// must share CONFIG_BUNDLE_KEY. Fees come from environment variables, so they are exported for
+// comparison only; an import reports where they differ instead of changing them.
+type ConfigBundleService interface {
+	Export(ctx context.Context) ([]byte, error)
+	Import(ctx context.Context, bundle []byte, dryRun bool) (*ConfigBundleImportResult, error)
+}
+
+// ConfigBundle is the decrypted content of a bundle
+type ConfigBundle struct {
+	Version    int                      `json:"version"`
+	ExportedAt time.Time                `json:"exported_at"`
+	Source     string                   `json:"source"` // SERVER_URL of the exporting environment
+	Data       repository.ReferenceData `json:"data"`
+	Fees       ConfigBundleFees         `json:"fees"`
+}
+
+// ConfigBundleFees are the order pricing settings of the exporting environment
+type ConfigBundleFees struct {
+	ServiceFee               int `json:"service_fee"`
+	ApplicationFee           int `json:"application_fee"`
+	ShippingBaseCost         int `json:"shipping_base_cost"`
+	ShippingCostPerKg        int `json:"shipping_cost_per_kg"`
+	DefaultProductWeight     int `json:"default_product_weight"`
+	InsuranceRateBasisPoints int `json:"insurance_rate_bps"`
+	WarrantyRateBasisPoints  int `json:"warranty_rate_bps"`
+	GiftWrapFee              int `json:"gift_wrap_fee"`
+	MinOrderAmount           int `json:"min_order_amount"`
+}
+
+// FeeDifference is a pricing setting whose value in the bundle differs from this environment
+type FeeDifference struct {
+	Field   string `json:"field"`
+	Bundle  int    `json:"bundle"`
+	Current int    `json:"current"`
+}
+
+type ConfigBundleImportResult struct {
+	DryRun         bool                              `json:"dry_run"`
+	Source         string                            `json:"source"`
+	ExportedAt     time.Time                         `json:"exported_at"`
+	Changes        *repository.ReferenceImportResult `json:"changes"`
+	FeeDifferences []FeeDifference                   `json:"fee_differences"` // Update the environment variables to apply these
+}
+
+// configBundleEnvelope is the bundle as stored: AES-256-GCM ciphertext of a ConfigBundle
+type configBundleEnvelope struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+type configBundleService struct {
+	referenceRepo repository.ReferenceDataRepository
+	cfg           *config.Config
+}
+
+func NewConfigBundleService(referenceRepo repository.ReferenceDataRepository, cfg *config.Config) ConfigBundleService {
+	return &configBundleService{
+		referenceRepo: referenceRepo,
+		cfg:           cfg,
+	}
+}
+
+func (s *configBundleService) Export(ctx context.Context) ([]byte, error) {
+	aead, err := s.cipher()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.referenceRepo.Export(ctx)
+	if err != nil {
+		return nil, errors.New("failed to export reference data: " + err.Error())
+	}
+	plaintext, err := json.Marshal(ConfigBundle{
+		Version:    configBundleVersion,
+		ExportedAt: time.Now(),
+		Source:     s.cfg.ServerURL,
+		Data:       *data,
+		Fees:       s.currentFees(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	envelope := configBundleEnvelope{
+		Format:     configBundleFormat,
+		Version:    configBundleVersion,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, bundleAdditionalData(configBundleVersion)),
+	}
+
+	log.Printf("📦 Exported config bundle: %d categories, %d tags, %d holidays, %d regions",
+		len(data.Categories), len(data.Tags), len(data.Holidays), len(data.Regions))
+	return json.Marshal(envelope)
+}
+
+func (s *configBundleService) Import(ctx context.Context, raw []byte, dryRun bool) (*ConfigBundleImportResult, error) {
+	aead, err := s.cipher()
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope configBundleEnvelope
+	if err := json.Unmarshal(raw, &envelope); err != nil || envelope.Format != configBundleFormat {
+		return nil, ErrConfigBundleInvalid
+	}
+	if envelope.Version != configBundleVersion {
+		return nil, fmt.Errorf("unsupported config bundle version %d", envelope.Version)
+	}
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, ErrConfigBundleInvalid
+	}
+	plaintext, err := aead.Open(nil, envelope.Nonce, envelope.Ciphertext, bundleAdditionalData(envelope.Version))
+	if err != nil {
+		return nil, ErrConfigBundleInvalid
+	}
+
+	var bundle ConfigBundle
+	if err := json.Unmarshal(plaintext, &bundle); err != nil {
+		return nil, ErrConfigBundleInvalid
+	}
+
+	changes, err := s.referenceRepo.Import(ctx, &bundle.Data, dryRun)
+	if err != nil {
+		return nil, errors.New("failed to import config bundle: " + err.Error())
+	}
+
+	result := &ConfigBundleImportResult{
+		DryRun:         dryRun,
+		Source:         bundle.Source,
+		ExportedAt:     bundle.ExportedAt,
+		Changes:        changes,
+		FeeDifferences: diffFees(bundle.Fees, s.currentFees()),
+	}
+	if !dryRun {
+		log.Printf("📦 Imported config bundle from %s (exported %s): %+v",
+			bundle.Source, bundle.ExportedAt.Format(time.RFC3339), *changes)
+	}
+	return result, nil
+}
+
+// cipher derives the AES-256-GCM cipher from CONFIG_BUNDLE_KEY
+func (s *configBundleService) cipher() (cipher.AEAD, error) {
+	if s.cfg.ConfigBundleKey == "" {
+		return nil, ErrConfigBundleDisabled
+	}
+	key := sha256.Sum256([]byte(s.cfg.ConfigBundleKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *configBundleService) currentFees() ConfigBundleFees {
+	return ConfigBundleFees{
+		ServiceFee:               s.cfg.ServiceFee,
+		ApplicationFee:           s.cfg.ApplicationFee,
+		ShippingBaseCost:         s.cfg.ShippingBaseCost,
+		ShippingCostPerKg:        s.cfg.ShippingCostPerKg,
+		DefaultProductWeight:     s.cfg.DefaultProductWeight,
+		InsuranceRateBasisPoints: s.cfg.InsuranceRateBasisPoints,
+		WarrantyRateBasisPoints:  s.cfg.WarrantyRateBasisPoints,
+		GiftWrapFee:              s.cfg.GiftWrapFee,
+		MinOrderAmount:           s.cfg.MinOrderAmount,
+	}
+}
+
+// bundleAdditionalData authenticates the format and version alongside the ciphertext
+func bundleAdditionalData(version int) []byte {
+	return []byte(fmt.Sprintf("%s:v%d", configBundleFormat, version))
+}
+
+// diffFees lists the pricing settings that differ between the bundle and this environment
+func diffFees(bundle, current ConfigBundleFees) []FeeDifference {
+	fields := []struct {
+		name            string
+		bundle, current int
+	}{
+		{"SERVICE_FEE", bundle.ServiceFee, current.ServiceFee},
+		{"APPLICATION_FEE", bundle.ApplicationFee, current.ApplicationFee},
+		{"SHIPPING_BASE_COST", bundle.ShippingBaseCost, current.ShippingBaseCost},
+		{"SHIPPING_COST_PER_KG", bundle.ShippingCostPerKg, current.ShippingCostPerKg},
+		{"DEFAULT_PRODUCT_WEIGHT", bundle.DefaultProductWeight, current.DefaultProductWeight},
+		{"INSURANCE_RATE_BPS", bundle.InsuranceRateBasisPoints, current.InsuranceRateBasisPoints},
+		{"WARRANTY_RATE_BPS", bundle.WarrantyRateBasisPoints, current.WarrantyRateBasisPoints},
+		{"GIFT_WRAP_FEE", bundle.GiftWrapFee, current.GiftWrapFee},
+		{"MIN_ORDER_AMOUNT", bundle.MinOrderAmount, current.MinOrderAmount},
+	}
+
+	differences := []FeeDifference{}
+	for _, field := range fields {
+		if field.bundle != field.current {
+			differences = append(differences, FeeDifference{Field: field.name, Bundle: field.bundle, Current: field.current})
+		}
+	}
+	return differences
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+	"yourapp/internal/model"
+)
+
+const (
+	// couponBatchAlphabet leaves out characters that are easily misread on print (0/O, 1/I)
+	couponBatchAlphabet   = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	couponBatchCodeLength = 8
+	// couponBatchMaxAttempts bounds the rounds of regenerating codes that are already taken
+	couponBatchMaxAttempts  = 5
+	couponBatchExportSize   = 1000
+	couponBatchMaxCodeTotal = 50
+)
+
+var couponBatchExportHeader = []string{"code", "discount_type", "discount_value", "max_discount", "min_subtotal", "starts_at", "expires_at", "redeemed", "redeemed_at"}
+
+// CouponBatchRequest generates single-use codes sharing the same discount. Each code can be
+// redeemed once in total.
+type CouponBatchRequest struct {
+	Name          string     `json:"name" binding:"required,max=100"`              // Campaign
+	Prefix        string     `json:"prefix" binding:"max=20"`                      // e.g. "FLYER-"
+	CodeLength    int        `json:"code_length" binding:"omitempty,min=6,max=16"` // Random characters after the prefix; default 8
+	Quantity      int        `json:"quantity" binding:"required,min=1,max=10000"`
+	Description   *string    `json:"description"`
+	DiscountType  string     `json:"discount_type" binding:"required,oneof=percentage fixed free_shipping"`
+	DiscountValue int        `json:"discount_value" binding:"min=0"`
+	MaxDiscount   int        `json:"max_discount" binding:"min=0"`
+	MinSubtotal   int        `json:"min_subtotal" binding:"min=0"`
+	SellerID      *string    `json:"seller_id"`
+	StartsAt      *time.Time `json:"starts_at"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+}
+
+// CouponBatchSummary is a batch with how many of its codes were redeemed
+type CouponBatchSummary struct {
+	model.CouponBatch
+	Redeemed       int64   `json:"redeemed"`
+	RedemptionRate float64 `json:"redemption_rate"` // Redeemed share of the codes, 0-1
+}
+
+func (s *couponService) GenerateBatch(ctx context.Context, adminID string, req CouponBatchRequest) (*CouponBatchSummary, error) {
+	if req.CodeLength == 0 {
+		req.CodeLength = couponBatchCodeLength
+	}
+	prefix := normalizeCouponCode(req.Prefix)
+	if len(prefix)+req.CodeLength > couponBatchMaxCodeTotal {
+		return nil, errors.New("prefix and code_length together may not exceed 50 characters")
+	}
+
+	// The discount settings are validated once, with the prefix standing in for the code
+	one := 1
+	var template model.Coupon
+	err := s.applyCouponRequest(&template, CouponRequest{
+		Code:          prefix,
+		Description:   req.Description,
+		DiscountType:  req.DiscountType,
+		DiscountValue: req.DiscountValue,
+		MaxDiscount:   req.MaxDiscount,
+		MinSubtotal:   req.MinSubtotal,
+		SellerID:      req.SellerID,
+		UsageLimit:    1,
+		PerUserLimit:  &one,
+		StartsAt:      req.StartsAt,
+		ExpiresAt:     req.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := s.generateBatchCodes(ctx, prefix, req.CodeLength, req.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	coupons := make([]model.Coupon, len(codes))
+	for i, code := range codes {
+		coupons[i] = template
+		coupons[i].Code = code
+	}
+
+	batch := &model.CouponBatch{
+		Name:       req.Name,
+		Prefix:     prefix,
+		CodeLength: req.CodeLength,
+		Quantity:   req.Quantity,
+		CreatedBy:  adminID,
+	}
+	if err := s.couponRepo.CreateBatch(ctx, batch, coupons); err != nil {
+		return nil, errors.New("failed to create coupon batch: " + err.Error())
+	}
+	log.Printf("🎟️  Coupon batch %s (%s) created with %d codes", batch.ID, batch.Name, batch.Quantity)
+	return &CouponBatchSummary{CouponBatch: *batch}, nil
+}
+
+// generateBatchCodes returns quantity distinct codes that are not in use yet
+func (s *couponService) generateBatchCodes(ctx context.Context, prefix string, length, quantity int) ([]string, error) {
+	codes := make([]string, 0, quantity)
+	seen := make(map[string]bool, quantity)
+	for attempt := 0; attempt < couponBatchMaxAttempts && len(codes) < quantity; attempt++ {
+		candidates := make([]string, 0, quantity-len(codes))
+		for len(candidates) < quantity-len(codes) {
+			code, err := randomCouponCode(prefix, length)
+			if err != nil {
+				return nil, errors.New("failed to generate coupon codes: " + err.Error())
+			}
+			if !seen[code] {
+				seen[code] = true
+				candidates = append(candidates, code)
+			}
+		}
+
+		taken := make(map[string]bool)
+		for start := 0; start < len(candidates); start += couponBatchExportSize {
+			end := min(start+couponBatchExportSize, len(candidates))
+			existing, err := s.couponRepo.FindExistingCodes(ctx, candidates[start:end])
+			if err != nil {
+				return nil, errors.New("failed to check coupon codes: " + err.Error())
+			}
+			for _, code := range existing {
+				taken[code] = true
+			}
+		}
+		for _, code := range candidates {
+			if !taken[code] {
+				codes = append(codes, code)
+			}
+		}
+	}
+	if len(codes) < quantity {
+		return nil, errors.New("not enough unique codes available; use a longer code_length or another prefix")
+	}
+	return codes, nil
+}
+
+func randomCouponCode(prefix string, length int) (string, error) {
+	code := make([]byte, length)
+	alphabetSize := big.NewInt(int64(len(couponBatchAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		code[i] = couponBatchAlphabet[n.Int64()]
+	}
+	return prefix + string(code), nil
+}
+
+func (s *couponService) ListBatches(ctx context.Context, page, limit int) ([]CouponBatchSummary, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	batches, total, err := s.couponRepo.ListBatches(ctx, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get coupon batches: " + err.Error())
+	}
+	summaries, err := s.summarizeBatches(ctx, batches)
+	if err != nil {
+		return nil, 0, err
+	}
+	return summaries, total, nil
+}
+
+func (s *couponService) GetBatch(ctx context.Context, id string) (*CouponBatchSummary, error) {
+	batch, err := s.couponRepo.FindBatchByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("coupon batch not found")
+	}
+	summaries, err := s.summarizeBatches(ctx, []model.CouponBatch{*batch})
+	if err != nil {
+		return nil, err
+	}
+	return &summaries[0], nil
+}
+
+func (s *couponService) summarizeBatches(ctx context.Context, batches []model.CouponBatch) ([]CouponBatchSummary, error) {
+	ids := make([]string, len(batches))
+	for i, batch := range batches {
+		ids[i] = batch.ID
+	}
+	redeemed, err := s.couponRepo.CountBatchRedemptions(ctx, ids)
+	if err != nil {
+		return nil, errors.New("failed to count coupon redemptions: " + err.Error())
+	}
+
+	summaries := make([]CouponBatchSummary, len(batches))
+	for i, batch := range batches {
+		summaries[i] = CouponBatchSummary{CouponBatch: batch, Redeemed: redeemed[batch.ID]}
+		if batch.Quantity > 0 {
+			summaries[i].RedemptionRate = float64(redeemed[batch.ID]) / float64(batch.Quantity)
+		}
+	}
+	return summaries, nil
+}
+
+// ExportBatch writes the codes in code order. Like the order export, rows are flushed batch by
+// batch, so only an unknown batch can still be reported normally.
+func (s *couponService) ExportBatch(ctx context.Context, id string, w io.Writer) error {
+	batch, err := s.couponRepo.FindBatchByID(ctx, id)
+	if err != nil {
+		return errors.New("coupon batch not found")
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(couponBatchExportHeader); err != nil {
+		return err
+	}
+
+	rows, after := 0, ""
+	for {
+		coupons, err := s.couponRepo.FindBatchCoupons(ctx, batch.ID, after, couponBatchExportSize)
+		if err != nil {
+			log.Printf("⚠️  Export of coupon batch %s stopped after %d rows: %v", batch.ID, rows, err)
+			return err
+		}
+		for _, coupon := range coupons {
+			record := []string{
+				coupon.Code,
+				coupon.DiscountType,
+				strconv.Itoa(coupon.DiscountValue),
+				strconv.Itoa(coupon.MaxDiscount),
+				strconv.Itoa(coupon.MinSubtotal),
+				formatExportTime(coupon.StartsAt),
+				formatExportTime(coupon.ExpiresAt),
+				strconv.FormatBool(coupon.RedeemedAt != nil),
+				formatExportTime(coupon.RedeemedAt),
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+			rows++
+		}
+		writer.Flush()
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if err := writer.Error(); err != nil {
+			return err
+		}
+		if len(coupons) < couponBatchExportSize {
+			return nil
+		}
+		after = coupons[len(coupons)-1].Code
+	}
+}
+
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Coupon error codes, returned with each rejected voucher so clients can explain it
+const (
+	CouponErrorNotFound      = "not_found"
+	CouponErrorInactive      = "inactive"
+	CouponErrorNotStarted    = "not_started"
+	CouponErrorExpired       = "expired"
+	CouponErrorEmptyCart     = "empty_cart"     // Nothing selected that can be checked out
+	CouponErrorNotApplicable = "not_applicable" // None of the items are from the voucher's shop
+	CouponErrorMinSubtotal   = "min_subtotal"
+	CouponErrorUsageLimit    = "usage_limit"
+	CouponErrorUserLimit     = "user_limit"
+)
+
+// CouponError is a voucher that cannot be used on the cart as it is
+type CouponError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *CouponError) Error() string {
+	return e.Message
+}
+
+// CouponService manages vouchers and applies them to carts. A voucher applied to the cart is only
+// remembered there; it is checked again and redeemed when the cart is checked out.
+type CouponService interface {
+	CreateCoupon(ctx context.Context, req CouponRequest) (*model.Coupon, error)
+	UpdateCoupon(ctx context.Context, id string, req CouponRequest) (*model.Coupon, error)
+	ListCoupons(ctx context.Context, page, limit int) ([]model.Coupon, int64, error)
+
+	// GenerateBatch creates a batch of unique single-use codes for a campaign
+	GenerateBatch(ctx context.Context, adminID string, req CouponBatchRequest) (*CouponBatchSummary, error)
+	ListBatches(ctx context.Context, page, limit int) ([]CouponBatchSummary, int64, error)
+	GetBatch(ctx context.Context, id string) (*CouponBatchSummary, error)
+	// ExportBatch writes the batch's codes and whether each was redeemed to w as CSV
+	ExportBatch(ctx context.Context, id string, w io.Writer) error
+
+	// ApplyToCart validates the code against the selected cart items, remembers it on the cart and
+	// returns the discount it gives
+	ApplyToCart(ctx context.Context, userID string, code string) (*VoucherPreview, error)
+	RemoveFromCart(ctx context.Context, userID string) error
+
+	// Validate checks that the code can be used by the buyer on the priced order and returns the
+	// coupon; a rejection is a *CouponError
+	Validate(ctx context.Context, userID string, code string, quote *OrderQuote) (*model.Coupon, error)
+	// Redeem records the coupon on an order about to be placed; a rejection is a *CouponError
+	Redeem(ctx context.Context, coupon *model.Coupon, userID, orderID string, discount int) error
+	// Release gives back the redemption of an order that could not be placed
+	Release(ctx context.Context, orderID string)
+}
+
+type CouponRequest struct {
+	Code          string     `json:"code" binding:"required,min=3,max=50"`
+	Description   *string    `json:"description"`
+	DiscountType  string     `json:"discount_type" binding:"required,oneof=percentage fixed free_shipping"`
+	DiscountValue int        `json:"discount_value" binding:"min=0"` // Percent (1-100) or IDR; unused for free_shipping
+	MaxDiscount   int        `json:"max_discount" binding:"min=0"`   // 0 means no cap
+	MinSubtotal   int        `json:"min_subtotal" binding:"min=0"`
+	SellerID      *string    `json:"seller_id"`                                // Optional: only this shop's items are discounted
+	UsageLimit    int        `json:"usage_limit" binding:"min=0"`              // 0 means unlimited
+	PerUserLimit  *int       `json:"per_user_limit" binding:"omitempty,min=0"` // Default: 1; 0 means unlimited
+	StartsAt      *time.Time `json:"starts_at"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	IsActive      *bool      `json:"is_active"` // Default: true
+}
+
+// VoucherPreview is what a voucher takes off the selected cart items
+type VoucherPreview struct {
+	Code         string  `json:"code"`
+	Description  *string `json:"description,omitempty"`
+	DiscountType string  `json:"discount_type"`
+	CouponBreakdown
+	Subtotal            int `json:"subtotal"`
+	ShippingCost        int `json:"shipping_cost"`
+	TotalBeforeDiscount int `json:"total_before_discount"`
+	TotalAmount         int `json:"total_amount"`
+}
+
+// CartVoucher is the state of the voucher applied to a cart, shown with the cart's totals
+type CartVoucher struct {
+	Code      string `json:"code"`
+	Applied   bool   `json:"applied"` // False when the voucher no longer fits the cart; checkout would reject it
+	Discount  int    `json:"discount"`
+	ErrorCode string `json:"error_code,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type couponService struct {
+	couponRepo repository.CouponRepository
+	cartRepo   repository.CartRepository
+	sellerRepo repository.SellerRepository
+	pricing    PricingService
+	stock      StockCacheService
+}
+
+func NewCouponService(
+	couponRepo repository.CouponRepository,
+	cartRepo repository.CartRepository,
+	sellerRepo repository.SellerRepository,
+	pricing PricingService,
+	stock StockCacheService,
+) CouponService {
+	return &couponService{
+		couponRepo: couponRepo,
+		cartRepo:   cartRepo,
+		sellerRepo: sellerRepo,
+		pricing:    pricing,
+		stock:      stock,
+	}
+}
+
+func (s *couponService) CreateCoupon(ctx context.Context, req CouponRequest) (*model.Coupon, error) {
+	coupon := &model.Coupon{}
+	if err := s.applyCouponRequest(coupon, req); err != nil {
+		return nil, err
+	}
+	if _, err := s.couponRepo.FindByCode(ctx, coupon.Code); err == nil {
+		return nil, errors.New("coupon code already exists")
+	}
+	if err := s.couponRepo.Create(ctx, coupon); err != nil {
+		return nil, internalError("failed to create coupon", err)
+	}
+	log.Printf("🎟️  Coupon %s (%s) created", coupon.Code, coupon.DiscountType)
+	return coupon, nil
+}
+
+func (s *couponService) UpdateCoupon(ctx context.Context, id string, req CouponRequest) (*model.Coupon, error) {
+	coupon, err := s.couponRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("coupon not found")
+	}
+	previousCode := coupon.Code
+	if err := s.applyCouponRequest(coupon, req); err != nil {
+		return nil, err
+	}
+	if coupon.Code != previousCode {
+		if _, err := s.couponRepo.FindByCode(ctx, coupon.Code); err == nil {
+			return nil, errors.New("coupon code already exists")
+		}
+	}
+	if err := s.couponRepo.Update(ctx, coupon); err != nil {
+		return nil, internalError("failed to update coupon", err)
+	}
+	log.Printf("🎟️  Coupon %s (%s) updated", coupon.Code, coupon.DiscountType)
+	return coupon, nil
+}
+
+func (s *couponService) ListCoupons(ctx context.Context, page, limit int) ([]model.Coupon, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	coupons, total, err := s.couponRepo.List(ctx, page, limit)
+	if err != nil {
+		return nil, 0, internalError("failed to get coupons", err)
+	}
+	return coupons, total, nil
+}
+
+func (s *couponService) applyCouponRequest(coupon *model.Coupon, req CouponRequest) error {
+	code := normalizeCouponCode(req.Code)
+	for _, c := range code {
+		if !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '-' && c != '_' {
+			return errors.New("code may only contain letters, digits, - and _")
+		}
+	}
+	switch req.DiscountType {
+	case model.CouponTypePercentage:
+		if req.DiscountValue < 1 || req.DiscountValue > 100 {
+			return errors.New("discount_value must be between 1 and 100 for percentage coupons")
+		}
+	case model.CouponTypeFixed:
+		if req.DiscountValue < 1 {
+			return errors.New("discount_value is required for fixed coupons")
+		}
+	}
+	if req.StartsAt != nil && req.ExpiresAt != nil && !req.ExpiresAt.After(*req.StartsAt) {
+		return errors.New("expires_at must be after starts_at")
+	}
+	if req.SellerID != nil {
+		if _, err := s.sellerRepo.FindByID(*req.SellerID); err != nil {
+			return errors.New("seller not found")
+		}
+	}
+
+	coupon.Code = code
+	coupon.Description = req.Description
+	coupon.DiscountType = req.DiscountType
+	coupon.DiscountValue = req.DiscountValue
+	if req.DiscountType == model.CouponTypeFreeShipping {
+		coupon.DiscountValue = 0
+	}
+	coupon.MaxDiscount = req.MaxDiscount
+	coupon.MinSubtotal = req.MinSubtotal
+	coupon.SellerID = req.SellerID
+	coupon.UsageLimit = req.UsageLimit
+	coupon.PerUserLimit = 1
+	if req.PerUserLimit != nil {
+		coupon.PerUserLimit = *req.PerUserLimit
+	}
+	coupon.StartsAt = req.StartsAt
+	coupon.ExpiresAt = req.ExpiresAt
+	coupon.IsActive = true
+	if req.IsActive != nil {
+		coupon.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+func (s *couponService) ApplyToCart(ctx context.Context, userID string, code string) (*VoucherPreview, error) {
+	cart, err := s.cartRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, &CouponError{Code: CouponErrorEmptyCart, Message: "cart is empty"}
+	}
+	lines := s.checkoutLines(ctx, cart)
+	if len(lines) == 0 {
+		return nil, &CouponError{Code: CouponErrorEmptyCart, Message: "no items in the cart can be checked out"}
+	}
+
+	quote := s.pricing.QuoteOrder(lines, QuoteOptions{})
+	coupon, err := s.Validate(ctx, userID, code, quote)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cartRepo.SetCouponCode(cart.ID, &coupon.Code); err != nil {
+		return nil, internalError("failed to apply voucher", err)
+	}
+
+	breakdown := couponDiscount(coupon, quote)
+	total := quote.TotalAmount - breakdown.Discount
+	if total < 0 {
+		total = 0
+	}
+	return &VoucherPreview{
+		Code:                coupon.Code,
+		Description:         coupon.Description,
+		DiscountType:        coupon.DiscountType,
+		CouponBreakdown:     breakdown,
+		Subtotal:            quote.Subtotal,
+		ShippingCost:        quote.ShippingCost,
+		TotalBeforeDiscount: quote.TotalAmount,
+		TotalAmount:         total,
+	}, nil
+}
+
+func (s *couponService) RemoveFromCart(ctx context.Context, userID string) error {
+	cart, err := s.cartRepo.GetOrCreateByUserID(userID)
+	if err != nil {
+		return internalError("failed to get cart", err)
+	}
+	if cart.CouponCode == nil {
+		return errors.New("no voucher is applied")
+	}
+	if err := s.cartRepo.SetCouponCode(cart.ID, nil); err != nil {
+		return internalError("failed to remove voucher", err)
+	}
+	return nil
+}
+
+// checkoutLines returns the cart lines checkout would include by default
+func (s *couponService) checkoutLines(ctx context.Context, cart *model.Cart) []QuoteLine {
+	var lines []QuoteLine
+	for _, item := range cart.CartItems {
+		product := cartItemProduct(item)
+		if !item.Selected || item.UnavailableReason != nil || product.ID == "" || !product.IsActive ||
+			product.Seller.ID == "" || !product.Seller.IsActive || cartItemAvailable(ctx, s.stock, item) < item.Quantity {
+			continue
+		}
+		lines = append(lines, QuoteLine{Product: &product, Quantity: item.Quantity})
+	}
+	return lines
+}
+
+func (s *couponService) Validate(ctx context.Context, userID string, code string, quote *OrderQuote) (*model.Coupon, error) {
+	coupon, err := s.couponRepo.FindByCode(ctx, normalizeCouponCode(code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &CouponError{Code: CouponErrorNotFound, Message: "voucher not found"}
+		}
+		return nil, internalError("failed to get voucher", err)
+	}
+
+	now := time.Now()
+	switch {
+	case !coupon.IsActive:
+		return nil, &CouponError{Code: CouponErrorInactive, Message: "voucher is no longer active"}
+	case coupon.StartsAt != nil && now.Before(*coupon.StartsAt):
+		return nil, &CouponError{Code: CouponErrorNotStarted, Message: "voucher is not valid yet"}
+	case coupon.ExpiresAt != nil && !now.Before(*coupon.ExpiresAt):
+		return nil, &CouponError{Code: CouponErrorExpired, Message: "voucher has expired"}
+	}
+
+	breakdown := couponDiscount(coupon, quote)
+	if breakdown.EligibleSubtotal == 0 {
+		return nil, &CouponError{Code: CouponErrorNotApplicable, Message: "voucher does not apply to any item in the cart"}
+	}
+	if breakdown.EligibleSubtotal < coupon.MinSubtotal {
+		return nil, &CouponError{
+			Code:    CouponErrorMinSubtotal,
+			Message: fmt.Sprintf("voucher requires a minimum purchase of %d (add %d more)", coupon.MinSubtotal, coupon.MinSubtotal-breakdown.EligibleSubtotal),
+		}
+	}
+
+	total, byUser, err := s.couponRepo.CountRedemptions(ctx, coupon.ID, userID)
+	if err != nil {
+		return nil, internalError("failed to check voucher usage", err)
+	}
+	if coupon.UsageLimit > 0 && total >= int64(coupon.UsageLimit) {
+		return nil, &CouponError{Code: CouponErrorUsageLimit, Message: "voucher has been fully redeemed"}
+	}
+	if coupon.PerUserLimit > 0 && byUser >= int64(coupon.PerUserLimit) {
+		return nil, &CouponError{Code: CouponErrorUserLimit, Message: "you have already used this voucher"}
+	}
+	return coupon, nil
+}
+
+func (s *couponService) Redeem(ctx context.Context, coupon *model.Coupon, userID, orderID string, discount int) error {
+	redemption := &model.CouponRedemption{UserID: userID, OrderID: orderID, Discount: discount}
+	err := s.couponRepo.Redeem(ctx, coupon, redemption)
+	switch {
+	case errors.Is(err, repository.ErrCouponUsageLimit):
+		return &CouponError{Code: CouponErrorUsageLimit, Message: "voucher has been fully redeemed"}
+	case errors.Is(err, repository.ErrCouponUserLimit):
+		return &CouponError{Code: CouponErrorUserLimit, Message: "you have already used this voucher"}
+	case err != nil:
+		return internalError("failed to redeem voucher", err)
+	}
+	return nil
+}
+
+func (s *couponService) Release(ctx context.Context, orderID string) {
+	if err := s.couponRepo.Release(ctx, orderID); err != nil {
+		log.Printf("⚠️  Failed to release voucher of order %s: %v", orderID, err)
+	}
+}
+
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// courierBookingTimeout is the deadline for one courier booking request
+const courierBookingTimeout = 20 * time.Second
+
+// CourierGateway books pickups through a courier aggregator (Biteship-style API) that issues
+// waybills for all supported couriers
+type CourierGateway interface {
+	BookShipment(ctx context.Context, booking ShipmentBooking) (*ShipmentBookingResult, error)
+}
+
+// ShipmentBooking is one parcel to be picked up at the shop and delivered to the buyer
+type ShipmentBooking struct {
+	Reference   string // Sub-order number
+	Courier     string // e.g. jne, jnt, sicepat
+	Service     string // Courier service level, e.g. regular
+	Origin      ShipmentContact
+	Destination ShipmentContact
+	Items       []ShipmentItem
+	WeightGrams int
+}
+
+type ShipmentContact struct {
+	Name       string
+	Phone      string
+	Address    string
+	City       string
+	Province   string
+	PostalCode string
+}
+
+type ShipmentItem struct {
+	Name        string
+	Quantity    int
+	Value       int // Price per unit in IDR
+	WeightGrams int // Per unit
+}
+
+// ShipmentBookingResult is what the courier assigned to the parcel
+type ShipmentBookingResult struct {
+	BookingID string
+	Waybill   string
+	Fee       int
+}
+
+type courierGateway struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewCourierGateway creates the aggregator client; baseURL is e.g. https://api.biteship.com
+func NewCourierGateway(baseURL, apiKey string) CourierGateway {
+	return &courierGateway{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{},
+	}
+}
+
+type courierOrderRequest struct {
+	ReferenceID             string             `json:"reference_id"`
+	OriginContactName       string             `json:"origin_contact_name"`
+	OriginContactPhone      string             `json:"origin_contact_phone"`
+	OriginAddress           string             `json:"origin_address"`
+	OriginPostalCode        string             `json:"origin_postal_code,omitempty"`
+	DestinationContactName  string             `json:"destination_contact_name"`
+	DestinationContactPhone string             `json:"destination_contact_phone"`
+	DestinationAddress      string             `json:"destination_address"`
+	DestinationPostalCode   string             `json:"destination_postal_code,omitempty"`
+	CourierCompany          string             `json:"courier_company"`
+	CourierType             string             `json:"courier_type"`
+	DeliveryType            string             `json:"delivery_type"`
+	Items                   []courierOrderItem `json:"items"`
+}
+
+type courierOrderItem struct {
+	Name     string `json:"name"`
+	Value    int    `json:"value"`
+	Quantity int    `json:"quantity"`
+	Weight   int    `json:"weight"`
+}
+
+type courierOrderResponse struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+	ID      string `json:"id"`
+	Price   int    `json:"price"`
+	Courier struct {
+		WaybillID string `json:"waybill_id"`
+	} `json:"courier"`
+}
+
+func (g *courierGateway) BookShipment(ctx context.Context, booking ShipmentBooking) (*ShipmentBookingResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, courierBookingTimeout)
+	defer cancel()
+
+	payload := courierOrderRequest{
+		ReferenceID:             booking.Reference,
+		OriginContactName:       booking.Origin.Name,
+		OriginContactPhone:      booking.Origin.Phone,
+		OriginAddress:           joinShipmentAddress(booking.Origin),
+		OriginPostalCode:        booking.Origin.PostalCode,
+		DestinationContactName:  booking.Destination.Name,
+		DestinationContactPhone: booking.Destination.Phone,
+		DestinationAddress:      joinShipmentAddress(booking.Destination),
+		DestinationPostalCode:   booking.Destination.PostalCode,
+		CourierCompany:          booking.Courier,
+		CourierType:             booking.Service,
+		DeliveryType:            "now",
+		Items:                   make([]courierOrderItem, 0, len(booking.Items)),
+	}
+	for _, item := range booking.Items {
+		payload.Items = append(payload.Items, courierOrderItem{
+			Name:     item.Name,
+			Value:    item.Value,
+			Quantity: item.Quantity,
+			Weight:   item.WeightGrams,
+		})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+"/v1/orders", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", g.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to book shipment: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+
+	var result courierOrderResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("courier API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+	if resp.StatusCode != http.StatusOK || !result.Success {
+		if result.Error != "" {
+			return nil, fmt.Errorf("courier rejected the booking: %s", result.Error)
+		}
+		return nil, fmt.Errorf("courier API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+	if result.Courier.WaybillID == "" {
+		return nil, errors.New("courier did not assign a waybill")
+	}
+
+	return &ShipmentBookingResult{
+		BookingID: result.ID,
+		Waybill:   result.Courier.WaybillID,
+		Fee:       result.Price,
+	}, nil
+}
+
+func joinShipmentAddress(contact ShipmentContact) string {
+	parts := []string{contact.Address}
+	for _, part := range []string{contact.City, contact.Province} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
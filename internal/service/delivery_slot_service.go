@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// deliverySlotHorizonDays is how far ahead buyers can book a delivery slot
+const deliverySlotHorizonDays = 14
+
+// ErrDeliverySlotUnavailable is returned when a picked slot cannot be booked for the picked date
+var ErrDeliverySlotUnavailable = errors.New("delivery slot is not available")
+
+// DeliverySlotService manages the weekly delivery windows sellers offer and books them at checkout
+type DeliverySlotService interface {
+	CreateSlot(ctx context.Context, userID string, req DeliverySlotRequest) (*model.DeliverySlot, error)
+	GetMySlots(ctx context.Context, userID string) ([]model.DeliverySlot, error)
+	UpdateSlot(ctx context.Context, userID string, slotID string, req DeliverySlotRequest) (*model.DeliverySlot, error)
+	DeleteSlot(ctx context.Context, userID string, slotID string) error
+	// GetAvailableSlots lists the seller's bookable slot dates from the given date (YYYY-MM-DD,
+	// default tomorrow), skipping holidays and full slots
+	GetAvailableSlots(ctx context.Context, sellerID string, from string) ([]AvailableDeliverySlot, error)
+	// ApplySelection checks a buyer's pick against the seller's slots and stores it on the order.
+	// earliest is the first date the order can arrive.
+	ApplySelection(ctx context.Context, order *model.Order, sellerID string, selection *DeliverySlotSelection, earliest time.Time) error
+}
+
+type deliverySlotService struct {
+	slotRepo   repository.DeliverySlotRepository
+	sellerRepo repository.SellerRepository
+	calendar   BusinessCalendarService
+}
+
+// DeliverySlotRequest creates or replaces a seller's delivery slot
+type DeliverySlotRequest struct {
+	Weekday   *int   `json:"weekday" binding:"required,min=0,max=6"` // 0 = Sunday
+	StartTime string `json:"start_time" binding:"required"`          // HH:MM
+	EndTime   string `json:"end_time" binding:"required"`            // HH:MM
+	Capacity  int    `json:"capacity" binding:"min=0"`               // Orders per date; 0 = unlimited
+	IsActive  *bool  `json:"is_active,omitempty"`
+}
+
+// DeliverySlotSelection is the buyer's pick at checkout
+type DeliverySlotSelection struct {
+	SlotID string `json:"slot_id" binding:"required"`
+	Date   string `json:"date" binding:"required"` // YYYY-MM-DD
+}
+
+// AvailableDeliverySlot is one bookable slot on one date
+type AvailableDeliverySlot struct {
+	SlotID    string `json:"slot_id"`
+	Date      string `json:"date"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Remaining *int   `json:"remaining,omitempty"` // Omitted for unlimited slots
+}
+
+func NewDeliverySlotService(slotRepo repository.DeliverySlotRepository, sellerRepo repository.SellerRepository, calendar BusinessCalendarService) DeliverySlotService {
+	return &deliverySlotService{
+		slotRepo:   slotRepo,
+		sellerRepo: sellerRepo,
+		calendar:   calendar,
+	}
+}
+
+func (s *deliverySlotService) CreateSlot(ctx context.Context, userID string, req DeliverySlotRequest) (*model.DeliverySlot, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	slot := &model.DeliverySlot{SellerID: seller.ID, IsActive: true}
+	if err := applySlotRequest(slot, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.slotRepo.Create(ctx, slot); err != nil {
+		return nil, errors.New("failed to create delivery slot: " + err.Error())
+	}
+	// gorm skips false on create, so a slot created inactive needs a save
+	if !slot.IsActive {
+		if err := s.slotRepo.Update(ctx, slot); err != nil {
+			return nil, errors.New("failed to create delivery slot: " + err.Error())
+		}
+	}
+	return slot, nil
+}
+
+func (s *deliverySlotService) GetMySlots(ctx context.Context, userID string) ([]model.DeliverySlot, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	return s.slotRepo.FindBySellerID(ctx, seller.ID, false)
+}
+
+func (s *deliverySlotService) UpdateSlot(ctx context.Context, userID string, slotID string, req DeliverySlotRequest) (*model.DeliverySlot, error) {
+	slot, err := s.findOwned(ctx, userID, slotID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applySlotRequest(slot, req); err != nil {
+		return nil, err
+	}
+	if err := s.slotRepo.Update(ctx, slot); err != nil {
+		return nil, errors.New("failed to update delivery slot: " + err.Error())
+	}
+	return slot, nil
+}
+
+// DeleteSlot removes a slot; orders already booked into it keep their copied window
+func (s *deliverySlotService) DeleteSlot(ctx context.Context, userID string, slotID string) error {
+	if _, err := s.findOwned(ctx, userID, slotID); err != nil {
+		return err
+	}
+	return s.slotRepo.Delete(ctx, slotID)
+}
+
+func (s *deliverySlotService) GetAvailableSlots(ctx context.Context, sellerID string, from string) ([]AvailableDeliverySlot, error) {
+	location := s.calendar.Location()
+	// Nothing can be delivered today, so tomorrow is the earliest date
+	first := startOfDay(time.Now().In(location)).AddDate(0, 0, 1)
+	if from != "" {
+		date, err := time.ParseInLocation("2006-01-02", from, location)
+		if err != nil {
+			return nil, errors.New("invalid from date, expected YYYY-MM-DD")
+		}
+		if date.After(first) {
+			first = date
+		}
+	}
+
+	slots, err := s.slotRepo.FindBySellerID(ctx, sellerID, true)
+	if err != nil {
+		return nil, err
+	}
+	available := []AvailableDeliverySlot{}
+	if len(slots) == 0 {
+		return available, nil
+	}
+
+	last := first.AddDate(0, 0, deliverySlotHorizonDays-1)
+	holidays, err := s.calendar.HolidayDates(ctx, first, last)
+	if err != nil {
+		return nil, err
+	}
+
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if holidays[day.Format("2006-01-02")] {
+			continue
+		}
+		for _, slot := range slots {
+			if time.Weekday(slot.Weekday) != day.Weekday() {
+				continue
+			}
+			remaining, err := s.remaining(ctx, &slot, day)
+			if err != nil {
+				return nil, err
+			}
+			if remaining != nil && *remaining <= 0 {
+				continue
+			}
+			available = append(available, AvailableDeliverySlot{
+				SlotID:    slot.ID,
+				Date:      day.Format("2006-01-02"),
+				StartTime: slot.StartTime,
+				EndTime:   slot.EndTime,
+				Remaining: remaining,
+			})
+		}
+	}
+	return available, nil
+}
+
+func (s *deliverySlotService) ApplySelection(ctx context.Context, order *model.Order, sellerID string, selection *DeliverySlotSelection, earliest time.Time) error {
+	slot, err := s.slotRepo.FindByID(ctx, selection.SlotID)
+	if err != nil || slot.SellerID != sellerID || !slot.IsActive {
+		return ErrDeliverySlotUnavailable
+	}
+	date, err := time.ParseInLocation("2006-01-02", selection.Date, s.calendar.Location())
+	if err != nil {
+		return errors.New("invalid delivery date, expected YYYY-MM-DD")
+	}
+
+	first := startOfDay(earliest.In(s.calendar.Location()))
+	if date.Before(first) || !date.Before(first.AddDate(0, 0, deliverySlotHorizonDays)) {
+		return ErrDeliverySlotUnavailable
+	}
+	if time.Weekday(slot.Weekday) != date.Weekday() {
+		return ErrDeliverySlotUnavailable
+	}
+	holidays, err := s.calendar.HolidayDates(ctx, date, date)
+	if err != nil {
+		return err
+	}
+	if holidays[selection.Date] {
+		return ErrDeliverySlotUnavailable
+	}
+	// Capacity is checked, not locked: two buyers racing for the last place can both get it
+	remaining, err := s.remaining(ctx, slot, date)
+	if err != nil {
+		return err
+	}
+	if remaining != nil && *remaining <= 0 {
+		return ErrDeliverySlotUnavailable
+	}
+
+	// Date-only column: pin to UTC midnight so the database does not shift the day
+	deliveryDate, _ := time.Parse("2006-01-02", selection.Date)
+	order.DeliverySlotID = &slot.ID
+	order.DeliveryDate = &deliveryDate
+	order.DeliveryStartTime = &slot.StartTime
+	order.DeliveryEndTime = &slot.EndTime
+	log.Printf("🗓️  Delivery slot %s %s-%s booked for user %s", selection.Date, slot.StartTime, slot.EndTime, order.UserID)
+	return nil
+}
+
+// remaining returns how many orders a slot can still take on a date, nil when unlimited
+func (s *deliverySlotService) remaining(ctx context.Context, slot *model.DeliverySlot, date time.Time) (*int, error) {
+	if slot.Capacity <= 0 {
+		return nil, nil
+	}
+	booked, err := s.slotRepo.CountBookings(ctx, slot.ID, date)
+	if err != nil {
+		return nil, err
+	}
+	remaining := slot.Capacity - int(booked)
+	return &remaining, nil
+}
+
+func (s *deliverySlotService) findOwned(ctx context.Context, userID string, slotID string) (*model.DeliverySlot, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	slot, err := s.slotRepo.FindByID(ctx, slotID)
+	if err != nil || slot.SellerID != seller.ID {
+		return nil, errors.New("delivery slot not found")
+	}
+	return slot, nil
+}
+
+// applySlotRequest validates a slot request and copies it onto the slot
+func applySlotRequest(slot *model.DeliverySlot, req DeliverySlotRequest) error {
+	start, err := time.Parse("15:04", req.StartTime)
+	if err != nil {
+		return errors.New("invalid start_time, expected HH:MM")
+	}
+	end, err := time.Parse("15:04", req.EndTime)
+	if err != nil {
+		return errors.New("invalid end_time, expected HH:MM")
+	}
+	if !end.After(start) {
+		return errors.New("end_time must be after start_time")
+	}
+
+	slot.Weekday = *req.Weekday
+	slot.StartTime = start.Format("15:04")
+	slot.EndTime = end.Format("15:04")
+	slot.Capacity = req.Capacity
+	if req.IsActive != nil {
+		slot.IsActive = *req.IsActive
+	}
+	return nil
+}
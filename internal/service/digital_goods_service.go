@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+)
+
+var (
+	// ErrDownloadFetchFailed is returned when the file host cannot serve a digital product file
+	ErrDownloadFetchFailed = errors.New("failed to fetch file")
+	// ErrDownloadOrderNotFound is returned when the order is unknown or belongs to someone else
+	ErrDownloadOrderNotFound = errors.New("order not found")
+	// ErrDownloadNotFound is returned when a download link no longer points at a deliverable file
+	ErrDownloadNotFound = errors.New("download not found")
+	// ErrLicenseSellerNotFound is returned when a user without a shop manages license keys
+	ErrLicenseSellerNotFound = errors.New("seller not found")
+	// ErrLicenseProductNotFound is returned when the product is unknown or sold by another shop
+	ErrLicenseProductNotFound = errors.New("product not found")
+)
+
+// DigitalGoodsService delivers digital products: a download link and/or license keys per paid
+// order item. Delivery runs as an after-payment-success hook.
+type DigitalGoodsService interface {
+	// FulfillOrder delivers the order's digital items; items already delivered are skipped
+	FulfillOrder(ctx context.Context, order *model.Order) error
+	GetDownloads(ctx context.Context, userID string, orderID string) ([]DigitalDownload, error)
+	// OpenDownload checks a signed download token and opens the file for streaming, so the
+	// permanent file URL is never handed to the buyer. The caller closes the file's Body.
+	OpenDownload(ctx context.Context, token string) (*DownloadFile, error)
+	AddLicenseKeys(ctx context.Context, userID string, productID string, req AddLicenseKeysRequest) (*LicenseKeyPool, error)
+}
+
+type digitalGoodsService struct {
+	digitalRepo repository.DigitalGoodsRepository
+	orderRepo   repository.OrderRepository
+	productRepo repository.ProductRepository
+	sellerRepo  repository.SellerRepository
+	client      *http.Client
+	jwtSecret   string
+	serverURL   string
+	ttl         time.Duration
+}
+
+type AddLicenseKeysRequest struct {
+	Keys []string `json:"keys" binding:"required,min=1,max=1000"`
+}
+
+// LicenseKeyPool reports a product's key pool after keys were added
+type LicenseKeyPool struct {
+	Added     int64 `json:"added"`     // Duplicates are skipped
+	Assigned  int   `json:"assigned"`  // Handed straight to buyers who were waiting for keys
+	Available int64 `json:"available"` // Free keys left in the pool
+}
+
+// DownloadFile is a digital product file being streamed to the buyer
+type DownloadFile struct {
+	Body          io.ReadCloser
+	ContentType   string
+	ContentLength int64 // -1 when the file host does not report it
+	FileName      string
+}
+
+// DigitalDownload is what a buyer gets for one digital order item
+type DigitalDownload struct {
+	DeliveryID  string     `json:"delivery_id"`
+	OrderItemID string     `json:"order_item_id"`
+	ProductID   string     `json:"product_id"`
+	ProductName string     `json:"product_name"`
+	DownloadURL *string    `json:"download_url,omitempty"` // Signed link, valid until ExpiresAt
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LicenseKeys []string   `json:"license_keys"`
+	KeysPending int        `json:"keys_pending"` // Keys the seller still has to add to the pool
+	DeliveredAt time.Time  `json:"delivered_at"`
+}
+
+func NewDigitalGoodsService(
+	digitalRepo repository.DigitalGoodsRepository,
+	orderRepo repository.OrderRepository,
+	productRepo repository.ProductRepository,
+	sellerRepo repository.SellerRepository,
+	cfg *config.Config,
+) DigitalGoodsService {
+	return &digitalGoodsService{
+		digitalRepo: digitalRepo,
+		orderRepo:   orderRepo,
+		productRepo: productRepo,
+		sellerRepo:  sellerRepo,
+		client:      &http.Client{}, // No timeout: large files are bounded by the request context instead
+		jwtSecret:   cfg.JWTSecret,
+		serverURL:   cfg.ServerURL,
+		ttl:         time.Duration(cfg.DigitalDownloadTTLMinutes) * time.Minute,
+	}
+}
+
+func (s *digitalGoodsService) FulfillOrder(ctx context.Context, order *model.Order) error {
+	var failed []string
+	for _, item := range order.OrderItems {
+		if !item.Product.IsDigital {
+			continue
+		}
+		delivery := &model.DigitalDelivery{
+			OrderID:     order.ID,
+			OrderItemID: item.ID,
+			ProductID:   item.ProductID,
+			UserID:      order.UserID,
+			FileURL:     item.Product.DigitalFileURL,
+		}
+		if item.Product.LicenseKeyRequired {
+			delivery.KeysRequested = item.Quantity
+		}
+
+		created, err := s.digitalRepo.CreateDelivery(ctx, delivery)
+		if err != nil {
+			failed = append(failed, item.ID)
+			log.Printf("⚠️  Failed to deliver digital item %s of order %s: %v", item.ID, order.OrderNumber, err)
+			continue
+		}
+		if created {
+			log.Printf("📥 Delivered digital product %s for order %s", item.Product.SKU, order.OrderNumber)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to deliver digital items %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (s *digitalGoodsService) GetDownloads(ctx context.Context, userID string, orderID string) ([]DigitalDownload, error) {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil || order.UserID != userID {
+		return nil, ErrDownloadOrderNotFound
+	}
+	if order.Status == "cancelled" {
+		return nil, errors.New("downloads are not available for cancelled orders")
+	}
+
+	deliveries, err := s.digitalRepo.FindByOrderID(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	downloads := make([]DigitalDownload, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		download := DigitalDownload{
+			DeliveryID:  delivery.ID,
+			OrderItemID: delivery.OrderItemID,
+			ProductID:   delivery.ProductID,
+			ProductName: delivery.Product.Name,
+			LicenseKeys: make([]string, 0, len(delivery.LicenseKeys)),
+			DeliveredAt: delivery.DeliveredAt,
+		}
+		for _, key := range delivery.LicenseKeys {
+			download.LicenseKeys = append(download.LicenseKeys, key.Key)
+		}
+		if pending := delivery.KeysRequested - len(delivery.LicenseKeys); pending > 0 {
+			download.KeysPending = pending
+		}
+		if delivery.FileURL != nil {
+			token, expiresAt, err := util.GenerateDownloadToken(delivery.ID, userID, s.jwtSecret, s.ttl)
+			if err != nil {
+				return nil, internalError("failed to sign download link", nil)
+			}
+			url := fmt.Sprintf("%s/api/v1/downloads/%s", s.serverURL, token)
+			download.DownloadURL = &url
+			download.ExpiresAt = &expiresAt
+		}
+		downloads = append(downloads, download)
+	}
+	return downloads, nil
+}
+
+func (s *digitalGoodsService) OpenDownload(ctx context.Context, token string) (*DownloadFile, error) {
+	claims, err := util.ValidateDownloadToken(token, s.jwtSecret)
+	if err != nil {
+		return nil, errors.New("download link is invalid or has expired")
+	}
+	delivery, err := s.digitalRepo.FindByID(ctx, claims.DeliveryID)
+	if err != nil || delivery.UserID != claims.UserID || delivery.FileURL == nil {
+		return nil, ErrDownloadNotFound
+	}
+	// Links signed before a cancellation must stop working too
+	order, err := s.orderRepo.FindByID(ctx, delivery.OrderID)
+	if err != nil || order.Status == "cancelled" {
+		return nil, ErrDownloadNotFound
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *delivery.FileURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDownloadFetchFailed, err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDownloadFetchFailed, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		log.Printf("⚠️  File host returned %d for delivery %s", resp.StatusCode, delivery.ID)
+		return nil, fmt.Errorf("%w: file host returned %d", ErrDownloadFetchFailed, resp.StatusCode)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &DownloadFile{
+		Body:          resp.Body,
+		ContentType:   contentType,
+		ContentLength: resp.ContentLength,
+		FileName:      path.Base(req.URL.Path),
+	}, nil
+}
+
+func (s *digitalGoodsService) AddLicenseKeys(ctx context.Context, userID string, productID string, req AddLicenseKeysRequest) (*LicenseKeyPool, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, ErrLicenseSellerNotFound
+	}
+	product, err := s.productRepo.FindByID(productID)
+	if err != nil || product.SellerID != seller.ID {
+		return nil, ErrLicenseProductNotFound
+	}
+	if !product.IsDigital || !product.LicenseKeyRequired {
+		return nil, errors.New("product does not use license keys")
+	}
+
+	keys := make([]string, 0, len(req.Keys))
+	for _, key := range req.Keys {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no license keys given")
+	}
+
+	added, err := s.digitalRepo.AddLicenseKeys(ctx, product.ID, keys)
+	if err != nil {
+		return nil, internalError("failed to add license keys", err)
+	}
+	assigned, err := s.digitalRepo.AssignPendingKeys(ctx, product.ID)
+	if err != nil {
+		return nil, internalError("failed to assign license keys", err)
+	}
+	available, err := s.digitalRepo.CountAvailableKeys(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if assigned > 0 {
+		log.Printf("🔑 Assigned %d waiting license keys for product %s", assigned, product.SKU)
+	}
+	return &LicenseKeyPool{Added: added, Assigned: assigned, Available: available}, nil
+}
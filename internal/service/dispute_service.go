@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// maxDisputePhotos caps how many evidence photos a buyer can attach to a dispute
+const maxDisputePhotos = 5
+
+// ErrDisputeWindowClosed is returned when a buyer opens a dispute too long after delivery
+var ErrDisputeWindowClosed = errors.New("the dispute window for this order has closed")
+
+// DisputeService handles buyer complaints about a sub-order: the buyer opens a dispute, the seller
+// responds, either side may escalate, and an admin resolves it (optionally with a refund). The
+// seller's settlement for the sub-order is held until the dispute is resolved or withdrawn.
+type DisputeService interface {
+	OpenDispute(ctx context.Context, userID string, orderID string, req OpenDisputeRequest, photoURLs []string) (*model.Dispute, error)
+	GetMyDisputes(ctx context.Context, userID string, status string, page, limit int) ([]model.Dispute, int64, error)
+	GetSellerDisputes(ctx context.Context, userID string, status string, page, limit int) ([]model.Dispute, int64, error)
+	// GetDispute returns a dispute to its buyer or seller
+	GetDispute(ctx context.Context, userID string, disputeID string) (*model.Dispute, error)
+	AddMessage(ctx context.Context, userID string, disputeID string, message string) (*model.DisputeMessage, error)
+	Respond(ctx context.Context, userID string, disputeID string, message string) (*model.Dispute, error)
+	Escalate(ctx context.Context, userID string, disputeID string) (*model.Dispute, error)
+	Withdraw(ctx context.Context, userID string, disputeID string) (*model.Dispute, error)
+
+	ListDisputes(ctx context.Context, status string, page, limit int) ([]model.Dispute, int64, error)
+	GetDisputeForAdmin(ctx context.Context, disputeID string) (*model.Dispute, error)
+	AddAdminMessage(ctx context.Context, adminID string, disputeID string, message string) (*model.DisputeMessage, error)
+	Resolve(ctx context.Context, adminID string, disputeID string, req ResolveDisputeRequest) (*model.Dispute, error)
+}
+
+type disputeService struct {
+	disputeRepo     repository.DisputeRepository
+	orderRepo       repository.OrderRepository
+	sellerOrderRepo repository.SellerOrderRepository
+	sellerRepo      repository.SellerRepository
+	paymentService  PaymentService
+	windowDays      int
+	responseWindow  time.Duration
+}
+
+// OpenDisputeRequest is the buyer's dispute form; photos are uploaded alongside as multipart files
+type OpenDisputeRequest struct {
+	SellerOrderID string `form:"seller_order_id" binding:"required"`
+	Reason        string `form:"reason" binding:"required,max=2000"`
+}
+
+type ResolveDisputeRequest struct {
+	Outcome      string `json:"outcome" binding:"required,oneof=buyer seller"`
+	RefundAmount int    `json:"refund_amount" binding:"min=0"` // Only for outcome buyer; at most the sub-order total
+	Note         string `json:"note" binding:"required,max=2000"`
+}
+
+func NewDisputeService(
+	disputeRepo repository.DisputeRepository,
+	orderRepo repository.OrderRepository,
+	sellerOrderRepo repository.SellerOrderRepository,
+	sellerRepo repository.SellerRepository,
+	paymentService PaymentService,
+	cfg *config.Config,
+) DisputeService {
+	return &disputeService{
+		disputeRepo:     disputeRepo,
+		orderRepo:       orderRepo,
+		sellerOrderRepo: sellerOrderRepo,
+		sellerRepo:      sellerRepo,
+		paymentService:  paymentService,
+		windowDays:      cfg.DisputeWindowDays,
+		responseWindow:  time.Duration(cfg.DisputeSellerResponseHours) * time.Hour,
+	}
+}
+
+// OpenDispute complains about one paid sub-order of the buyer's order
+func (s *disputeService) OpenDispute(ctx context.Context, userID string, orderID string, req OpenDisputeRequest, photoURLs []string) (*model.Dispute, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	if len(photoURLs) > maxDisputePhotos {
+		return nil, fmt.Errorf("at most %d photos can be attached", maxDisputePhotos)
+	}
+
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil || order.UserID != userID {
+		return nil, errors.New("order not found")
+	}
+	sellerOrder, err := s.sellerOrderRepo.FindByID(ctx, req.SellerOrderID)
+	if err != nil || sellerOrder.OrderID != order.ID {
+		return nil, errors.New("order not found")
+	}
+	switch sellerOrder.Status {
+	case "pending", "cancelled":
+		return nil, errors.New("only paid orders can be disputed")
+	case "delivered":
+		if order.DeliveredAt != nil && time.Since(*order.DeliveredAt) > time.Duration(s.windowDays)*24*time.Hour {
+			return nil, fmt.Errorf("%w (%d days after delivery)", ErrDisputeWindowClosed, s.windowDays)
+		}
+	}
+	if _, err := s.disputeRepo.FindOpenBySellerOrderID(ctx, sellerOrder.ID); err == nil {
+		return nil, errors.New("a dispute is already open for this order")
+	}
+
+	dispute := &model.Dispute{
+		OrderID:       order.ID,
+		SellerOrderID: sellerOrder.ID,
+		UserID:        userID,
+		SellerID:      sellerOrder.SellerID,
+		Reason:        reason,
+		Status:        model.DisputeStatusOpen,
+	}
+	for i, url := range photoURLs {
+		dispute.Photos = append(dispute.Photos, model.DisputePhoto{ImageURL: url, SortOrder: i})
+	}
+	if err := s.disputeRepo.Create(ctx, dispute); err != nil {
+		return nil, errors.New("failed to open dispute: " + err.Error())
+	}
+
+	log.Printf("⚖️  Dispute %s opened for sub-order %s by user %s", dispute.ID, sellerOrder.SubOrderNumber, userID)
+	return s.disputeRepo.FindByID(ctx, dispute.ID)
+}
+
+func (s *disputeService) GetMyDisputes(ctx context.Context, userID string, status string, page, limit int) ([]model.Dispute, int64, error) {
+	disputes, total, err := s.disputeRepo.FindByUserID(ctx, userID, status, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get disputes: " + err.Error())
+	}
+	return disputes, total, nil
+}
+
+func (s *disputeService) GetSellerDisputes(ctx context.Context, userID string, status string, page, limit int) ([]model.Dispute, int64, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, 0, errors.New("seller not found")
+	}
+	disputes, total, err := s.disputeRepo.FindBySellerID(ctx, seller.ID, status, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get disputes: " + err.Error())
+	}
+	return disputes, total, nil
+}
+
+func (s *disputeService) GetDispute(ctx context.Context, userID string, disputeID string) (*model.Dispute, error) {
+	dispute, _, err := s.findAsParticipant(ctx, userID, disputeID)
+	return dispute, err
+}
+
+func (s *disputeService) AddMessage(ctx context.Context, userID string, disputeID string, message string) (*model.DisputeMessage, error) {
+	dispute, role, err := s.findAsParticipant(ctx, userID, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	return s.addMessage(ctx, dispute, userID, role, message)
+}
+
+// Respond is the seller's answer to a newly opened dispute
+func (s *disputeService) Respond(ctx context.Context, userID string, disputeID string, message string) (*model.Dispute, error) {
+	dispute, role, err := s.findAsParticipant(ctx, userID, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if role != model.DisputeRoleSeller {
+		return nil, errors.New("dispute not found")
+	}
+	if _, err := s.addMessage(ctx, dispute, userID, role, message); err != nil {
+		return nil, err
+	}
+
+	err = s.disputeRepo.Transition(ctx, dispute.ID, []string{model.DisputeStatusOpen}, model.DisputeStatusSellerResponded,
+		map[string]interface{}{"seller_responded_at": time.Now()})
+	if err != nil && !errors.Is(err, repository.ErrInvalidDisputeTransition) {
+		return nil, s.transitionError(err, dispute, model.DisputeStatusSellerResponded)
+	}
+	// Responding again after the first time just adds to the thread
+	return s.disputeRepo.FindByID(ctx, dispute.ID)
+}
+
+// Escalate hands the dispute to an admin. The seller can escalate any time; the buyer once the
+// seller responded or let the response window pass.
+func (s *disputeService) Escalate(ctx context.Context, userID string, disputeID string) (*model.Dispute, error) {
+	dispute, role, err := s.findAsParticipant(ctx, userID, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if role == model.DisputeRoleBuyer && dispute.Status == model.DisputeStatusOpen && time.Since(dispute.CreatedAt) < s.responseWindow {
+		return nil, fmt.Errorf("the seller has until %s to respond before the dispute can be escalated",
+			dispute.CreatedAt.Add(s.responseWindow).Format(time.RFC3339))
+	}
+
+	from := []string{model.DisputeStatusOpen, model.DisputeStatusSellerResponded}
+	if err := s.disputeRepo.Transition(ctx, dispute.ID, from, model.DisputeStatusUnderReview,
+		map[string]interface{}{"escalated_at": time.Now()}); err != nil {
+		return nil, s.transitionError(err, dispute, model.DisputeStatusUnderReview)
+	}
+
+	log.Printf("⚖️  Dispute %s escalated by the %s", dispute.ID, role)
+	return s.disputeRepo.FindByID(ctx, dispute.ID)
+}
+
+// Withdraw lets the buyer drop the dispute; the seller's settlement is released
+func (s *disputeService) Withdraw(ctx context.Context, userID string, disputeID string) (*model.Dispute, error) {
+	dispute, role, err := s.findAsParticipant(ctx, userID, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if role != model.DisputeRoleBuyer {
+		return nil, errors.New("only the buyer can withdraw a dispute")
+	}
+
+	if err := s.disputeRepo.Finish(ctx, dispute.ID, model.DisputeOpenStatuses, model.DisputeStatusClosed,
+		map[string]interface{}{"closed_at": time.Now()}, 0); err != nil {
+		return nil, s.transitionError(err, dispute, model.DisputeStatusClosed)
+	}
+
+	log.Printf("⚖️  Dispute %s withdrawn by the buyer", dispute.ID)
+	return s.disputeRepo.FindByID(ctx, dispute.ID)
+}
+
+func (s *disputeService) ListDisputes(ctx context.Context, status string, page, limit int) ([]model.Dispute, int64, error) {
+	disputes, total, err := s.disputeRepo.FindAll(ctx, status, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get disputes: " + err.Error())
+	}
+	return disputes, total, nil
+}
+
+func (s *disputeService) GetDisputeForAdmin(ctx context.Context, disputeID string) (*model.Dispute, error) {
+	dispute, err := s.disputeRepo.FindByID(ctx, disputeID)
+	if err != nil {
+		return nil, errors.New("dispute not found")
+	}
+	return dispute, nil
+}
+
+func (s *disputeService) AddAdminMessage(ctx context.Context, adminID string, disputeID string, message string) (*model.DisputeMessage, error) {
+	dispute, err := s.GetDisputeForAdmin(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	return s.addMessage(ctx, dispute, adminID, model.DisputeRoleAdmin, message)
+}
+
+// Resolve decides the dispute. A decision for the buyer may refund part or all of the sub-order;
+// the refund is taken off the seller's settlement, which is then released. If the refund fails
+// the dispute stays open and resolving it again retries with the same refund key.
+func (s *disputeService) Resolve(ctx context.Context, adminID string, disputeID string, req ResolveDisputeRequest) (*model.Dispute, error) {
+	dispute, err := s.GetDisputeForAdmin(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status == model.DisputeStatusResolved || dispute.Status == model.DisputeStatusClosed {
+		return nil, s.transitionError(repository.ErrInvalidDisputeTransition, dispute, model.DisputeStatusResolved)
+	}
+	if req.Outcome == model.DisputeOutcomeSeller && req.RefundAmount > 0 {
+		return nil, errors.New("a refund can only be given when resolving for the buyer")
+	}
+	if req.RefundAmount > dispute.SellerOrder.TotalAmount {
+		return nil, fmt.Errorf("refund cannot exceed the order total of %d", dispute.SellerOrder.TotalAmount)
+	}
+
+	updates := map[string]interface{}{
+		"outcome":         req.Outcome,
+		"resolution_note": req.Note,
+		"resolved_by":     adminID,
+		"resolved_at":     time.Now(),
+		"refund_amount":   req.RefundAmount,
+	}
+	if req.RefundAmount > 0 {
+		method, err := s.paymentService.RefundPayment(ctx, dispute.OrderID, "dispute-"+dispute.ID, req.RefundAmount, "Dispute resolved: "+req.Note)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrRefundFailed, err)
+		}
+		updates["refund_method"] = method
+	}
+
+	if err := s.disputeRepo.Finish(ctx, dispute.ID, model.DisputeOpenStatuses, model.DisputeStatusResolved, updates, req.RefundAmount); err != nil {
+		return nil, s.transitionError(err, dispute, model.DisputeStatusResolved)
+	}
+	if _, err := s.addMessage(ctx, dispute, adminID, model.DisputeRoleAdmin, req.Note); err != nil {
+		log.Printf("⚠️  Failed to post resolution note on dispute %s: %v", dispute.ID, err)
+	}
+
+	log.Printf("⚖️  Dispute %s resolved for the %s by admin %s (refund %d)", dispute.ID, req.Outcome, adminID, req.RefundAmount)
+	return s.disputeRepo.FindByID(ctx, dispute.ID)
+}
+
+func (s *disputeService) addMessage(ctx context.Context, dispute *model.Dispute, senderID string, role string, message string) (*model.DisputeMessage, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil, errors.New("message is required")
+	}
+	if dispute.Status == model.DisputeStatusClosed {
+		return nil, errors.New("dispute has been withdrawn")
+	}
+
+	entry := &model.DisputeMessage{
+		DisputeID:  dispute.ID,
+		SenderID:   senderID,
+		SenderRole: role,
+		Message:    message,
+	}
+	if err := s.disputeRepo.AddMessage(ctx, entry); err != nil {
+		return nil, errors.New("failed to add message: " + err.Error())
+	}
+	return entry, nil
+}
+
+// findAsParticipant loads a dispute for its buyer or the seller it is against, and tells which
+// one the user is
+func (s *disputeService) findAsParticipant(ctx context.Context, userID string, disputeID string) (*model.Dispute, string, error) {
+	dispute, err := s.disputeRepo.FindByID(ctx, disputeID)
+	if err != nil {
+		return nil, "", errors.New("dispute not found")
+	}
+	if dispute.UserID == userID {
+		return dispute, model.DisputeRoleBuyer, nil
+	}
+	if seller, err := s.sellerRepo.FindByUserID(userID); err == nil && seller.ID == dispute.SellerID {
+		return dispute, model.DisputeRoleSeller, nil
+	}
+	return nil, "", errors.New("dispute not found")
+}
+
+func (s *disputeService) transitionError(err error, dispute *model.Dispute, to string) error {
+	if errors.Is(err, repository.ErrInvalidDisputeTransition) {
+		return fmt.Errorf("%w: %s to %s", err, dispute.Status, to)
+	}
+	return errors.New("failed to update dispute: " + err.Error())
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// ReportDonations is the scheduled report of donations per cause
+const ReportDonations = "donations"
+
+// DonationService runs the optional donation line at checkout: buyers round their total up, or add
+// an amount, for the cause an admin has opened. Donations are credited to the cause's ledger once
+// the order is paid, and admins record the disbursements to the cause against it.
+type DonationService interface {
+	// GetActiveCause returns the cause checkout offers donations to
+	GetActiveCause(ctx context.Context) (*model.DonationCause, error)
+	// ApplyDonation adds the buyer's donation choice to the quote options and returns the cause it
+	// goes to; nil when the buyer does not donate
+	ApplyDonation(ctx context.Context, selection *DonationSelection, opts *QuoteOptions) (*model.DonationCause, error)
+	// RecordDonation credits a paid order's donation to its cause
+	RecordDonation(ctx context.Context, order *model.Order) error
+
+	CreateCause(ctx context.Context, adminID string, req DonationCauseRequest) (*model.DonationCause, error)
+	UpdateCause(ctx context.Context, causeID string, req DonationCauseRequest) (*model.DonationCause, error)
+	GetCauses(ctx context.Context, page, limit int) ([]model.DonationCause, int64, error)
+	GetCause(ctx context.Context, causeID string) (*model.DonationCause, error)
+	GetLedger(ctx context.Context, causeID string, page, limit int) ([]model.DonationLedgerEntry, int64, error)
+	RecordDisbursement(ctx context.Context, adminID string, causeID string, req DonationDisbursementRequest) (*model.DonationLedgerEntry, error)
+	// GetSummary totals the donations and disbursements per cause between from and to (YYYY-MM-DD,
+	// inclusive); the last 30 days by default
+	GetSummary(ctx context.Context, from, to string) (*DonationSummary, error)
+	// Report is the donations report for the scheduled reports
+	Report() ReportDefinition
+}
+
+type donationService struct {
+	donationRepo repository.DonationRepository
+	calendar     BusinessCalendarService
+	roundUpTo    int
+	maxAmount    int
+}
+
+// DonationSelection is the buyer's donation at checkout: round the total up, or add an amount
+type DonationSelection struct {
+	RoundUp bool `json:"round_up"`
+	Amount  int  `json:"amount" binding:"min=0"`
+}
+
+type DonationCauseRequest struct {
+	Name         string     `json:"name" binding:"required,max=100"`
+	Description  *string    `json:"description,omitempty"`
+	ImageURL     *string    `json:"image_url,omitempty"`
+	TargetAmount int        `json:"target_amount" binding:"min=0"` // 0 means no target
+	EndsAt       *time.Time `json:"ends_at,omitempty"`
+	IsActive     bool       `json:"is_active"` // Activating a cause closes the active one
+}
+
+type DonationDisbursementRequest struct {
+	Amount    int    `json:"amount" binding:"required,min=1"`
+	Reference string `json:"reference" binding:"required,max=100"` // Transfer reference
+	Note      string `json:"note" binding:"max=2000"`
+}
+
+// DonationSummary is the donations report shown to admins
+type DonationSummary struct {
+	From   time.Time                       `json:"from"`
+	To     time.Time                       `json:"to"`
+	Causes []repository.DonationSummaryRow `json:"causes"`
+	Total  int64                           `json:"total"` // Donated in the period across causes
+}
+
+func NewDonationService(donationRepo repository.DonationRepository, calendar BusinessCalendarService, cfg *config.Config) DonationService {
+	return &donationService{
+		donationRepo: donationRepo,
+		calendar:     calendar,
+		roundUpTo:    cfg.DonationRoundUpTo,
+		maxAmount:    cfg.DonationMaxAmount,
+	}
+}
+
+func (s *donationService) GetActiveCause(ctx context.Context) (*model.DonationCause, error) {
+	cause, err := s.donationRepo.FindActiveCause(ctx)
+	if err != nil || !causeOpen(cause, time.Now()) {
+		return nil, errors.New("no donation cause is open")
+	}
+	return cause, nil
+}
+
+func (s *donationService) ApplyDonation(ctx context.Context, selection *DonationSelection, opts *QuoteOptions) (*model.DonationCause, error) {
+	if selection == nil || (!selection.RoundUp && selection.Amount == 0) {
+		return nil, nil
+	}
+	if selection.RoundUp && selection.Amount > 0 {
+		return nil, errors.New("choose either a round-up or a donation amount")
+	}
+	if selection.Amount > s.maxAmount {
+		return nil, fmt.Errorf("donations are limited to %d per order", s.maxAmount)
+	}
+	cause, err := s.GetActiveCause(ctx)
+	if err != nil {
+		return nil, err
+	}
+	opts.RoundUpDonation = selection.RoundUp
+	opts.Donation = selection.Amount
+	return cause, nil
+}
+
+// RecordDonation credits the donation of an order that was just paid. Paying is what makes the
+// donation final: cancelling a paid order refunds its sub-orders, not the donation.
+func (s *donationService) RecordDonation(ctx context.Context, order *model.Order) error {
+	if order.DonationAmount <= 0 || order.DonationCauseID == nil {
+		return nil
+	}
+	credited, err := s.donationRepo.RecordDonation(ctx, &model.DonationLedgerEntry{
+		CauseID: *order.DonationCauseID,
+		Type:    model.DonationEntryDonation,
+		Amount:  order.DonationAmount,
+		OrderID: &order.ID,
+		UserID:  &order.UserID,
+	})
+	if err != nil {
+		return internalError(fmt.Sprintf("failed to record donation of order %s", order.OrderNumber), err)
+	}
+	if credited {
+		log.Printf("💝 Donation of %d from order %s credited to cause %s", order.DonationAmount, order.OrderNumber, *order.DonationCauseID)
+	}
+	return nil
+}
+
+func (s *donationService) CreateCause(ctx context.Context, adminID string, req DonationCauseRequest) (*model.DonationCause, error) {
+	cause := &model.DonationCause{CreatedBy: adminID}
+	if err := applyCauseRequest(cause, req); err != nil {
+		return nil, err
+	}
+	if err := s.donationRepo.CreateCause(ctx, cause); err != nil {
+		return nil, internalError("failed to create donation cause", err)
+	}
+	if req.IsActive {
+		if err := s.donationRepo.SetActive(ctx, cause.ID, true); err != nil {
+			return nil, internalError("failed to activate donation cause", err)
+		}
+	}
+
+	log.Printf("💝 Donation cause %q created by admin %s", cause.Name, adminID)
+	return s.GetCause(ctx, cause.ID)
+}
+
+func (s *donationService) UpdateCause(ctx context.Context, causeID string, req DonationCauseRequest) (*model.DonationCause, error) {
+	cause, err := s.GetCause(ctx, causeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyCauseRequest(cause, req); err != nil {
+		return nil, err
+	}
+	if err := s.donationRepo.UpdateCause(ctx, cause); err != nil {
+		return nil, internalError("failed to update donation cause", err)
+	}
+	if req.IsActive != cause.IsActive {
+		if err := s.donationRepo.SetActive(ctx, cause.ID, req.IsActive); err != nil {
+			return nil, internalError("failed to update donation cause", err)
+		}
+	}
+	return s.GetCause(ctx, cause.ID)
+}
+
+func (s *donationService) GetCauses(ctx context.Context, page, limit int) ([]model.DonationCause, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	causes, total, err := s.donationRepo.ListCauses(ctx, page, limit)
+	if err != nil {
+		return nil, 0, internalError("failed to get donation causes", err)
+	}
+	return causes, total, nil
+}
+
+func (s *donationService) GetCause(ctx context.Context, causeID string) (*model.DonationCause, error) {
+	cause, err := s.donationRepo.FindCauseByID(ctx, causeID)
+	if err != nil {
+		return nil, errors.New("donation cause not found")
+	}
+	return cause, nil
+}
+
+func (s *donationService) GetLedger(ctx context.Context, causeID string, page, limit int) ([]model.DonationLedgerEntry, int64, error) {
+	if _, err := s.GetCause(ctx, causeID); err != nil {
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	entries, total, err := s.donationRepo.FindEntries(ctx, causeID, page, limit)
+	if err != nil {
+		return nil, 0, internalError("failed to get donation ledger", err)
+	}
+	return entries, total, nil
+}
+
+// RecordDisbursement records money handed over to the cause; it cannot exceed what the cause has
+// raised and not yet been given
+func (s *donationService) RecordDisbursement(ctx context.Context, adminID string, causeID string, req DonationDisbursementRequest) (*model.DonationLedgerEntry, error) {
+	cause, err := s.GetCause(ctx, causeID)
+	if err != nil {
+		return nil, err
+	}
+	reference := strings.TrimSpace(req.Reference)
+	entry := &model.DonationLedgerEntry{
+		CauseID:   cause.ID,
+		Type:      model.DonationEntryDisbursement,
+		Amount:    -req.Amount,
+		Reference: &reference,
+		CreatedBy: &adminID,
+	}
+	if note := strings.TrimSpace(req.Note); note != "" {
+		entry.Note = &note
+	}
+	if err := s.donationRepo.RecordDisbursement(ctx, entry); err != nil {
+		if errors.Is(err, repository.ErrDisbursementExceedsBalance) {
+			return nil, err
+		}
+		return nil, internalError("failed to record disbursement", err)
+	}
+
+	log.Printf("💝 Disbursed %d to donation cause %q (%s) by admin %s", req.Amount, cause.Name, reference, adminID)
+	return entry, nil
+}
+
+func (s *donationService) GetSummary(ctx context.Context, from, to string) (*DonationSummary, error) {
+	location := s.calendar.Location()
+	fromTime, toTime, err := parseDateRange(from, to, location)
+	if err != nil {
+		return nil, err
+	}
+	if toTime == nil {
+		now := time.Now().In(location)
+		tomorrow := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location).AddDate(0, 0, 1)
+		toTime = &tomorrow
+	}
+	if fromTime == nil {
+		start := toTime.AddDate(0, 0, -30)
+		fromTime = &start
+	}
+
+	rows, err := s.donationRepo.Summarize(ctx, *fromTime, *toTime)
+	if err != nil {
+		return nil, internalError("failed to summarize donations", err)
+	}
+	summary := &DonationSummary{From: *fromTime, To: *toTime, Causes: rows}
+	for _, row := range rows {
+		summary.Total += row.Donated
+	}
+	return summary, nil
+}
+
+func (s *donationService) Report() ReportDefinition {
+	location := s.calendar.Location()
+	return ReportDefinition{
+		Key:         ReportDonations,
+		Title:       "Donasi",
+		Description: "Donations and disbursements per cause since the last successful run, with progress towards the target",
+		Columns:     []string{"cause_id", "cause_name", "donations", "donors", "donated", "disbursed", "raised_total", "target_amount", "period_from", "period_to"},
+		Query: func(ctx context.Context, period ReportPeriod) ([][]string, error) {
+			summary, err := s.donationRepo.Summarize(ctx, period.From, period.To)
+			if err != nil {
+				return nil, err
+			}
+			rows := make([][]string, len(summary))
+			for i, row := range summary {
+				rows[i] = []string{
+					row.CauseID,
+					row.CauseName,
+					strconv.FormatInt(row.Donations, 10),
+					strconv.FormatInt(row.Donors, 10),
+					strconv.FormatInt(row.Donated, 10),
+					strconv.FormatInt(row.Disbursed, 10),
+					strconv.Itoa(row.RaisedTotal),
+					strconv.Itoa(row.TargetAmount),
+					period.From.In(location).Format("2006-01-02 15:04:05"),
+					period.To.In(location).Format("2006-01-02 15:04:05"),
+				}
+			}
+			return rows, nil
+		},
+	}
+}
+
+func applyCauseRequest(cause *model.DonationCause, req DonationCauseRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if req.EndsAt != nil && !req.EndsAt.After(time.Now()) {
+		return errors.New("ends_at must be in the future")
+	}
+	cause.Name = name
+	cause.Description = req.Description
+	cause.ImageURL = req.ImageURL
+	cause.TargetAmount = req.TargetAmount
+	cause.EndsAt = req.EndsAt
+	return nil
+}
+
+// causeOpen reports whether the cause takes donations at the given time
+func causeOpen(cause *model.DonationCause, at time.Time) bool {
+	return cause.IsActive && (cause.EndsAt == nil || at.Before(*cause.EndsAt))
+}
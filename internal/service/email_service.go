@@ -1,7 +1,9 @@
 package service
 
 import (
+	"encoding/base64"
 	"fmt"
+	"html"
 	"net/smtp"
 	"strings"
 	"time"
@@ -15,6 +17,11 @@ type EmailService interface {
 	SendResetPasswordEmail(to, resetLink string) error
 	SendVerificationEmail(to, token string) error
 	SendWelcomeEmail(to, name string) error
+	SendPaymentInstructionsEmail(to string, data map[string]string) error
+	SendCartItemsUnavailableEmail(to string, data map[string]string) error
+	SendOrderAutoCancelledEmail(to string, data map[string]string) error
+	SendSupportTicketReplyEmail(to string, data map[string]string) error
+	SendScheduledReportEmail(to string, data map[string]string) error
 }
 
 type emailService struct {
@@ -33,11 +40,26 @@ func (s *emailService) sendEmail(to, subject, body string) error {
 	return s.sendEmailHTML(to, subject, body, body)
 }
 
+// emailAttachment adalah file yang dilampirkan pada email.
+type emailAttachment struct {
+	filename    string
+	contentType string
+	content     []byte
+}
+
 // sendEmailHTML mengirim email multipart dengan versi HTML dan plain text.
 func (s *emailService) sendEmailHTML(to, subject, htmlBody, textBody string) error {
+	return s.sendEmailWithAttachment(to, subject, htmlBody, textBody, nil)
+}
+
+// sendEmailWithAttachment mengirim email HTML dan plain text, dengan lampiran jika attachment tidak nil.
+func (s *emailService) sendEmailWithAttachment(to, subject, htmlBody, textBody string, attachment *emailAttachment) error {
 	if s.config.SMTPUsername == "" || s.config.SMTPPassword == "" {
 		// In development, just log the email
 		fmt.Printf("[EMAIL] To: %s, Subject: %s\nBody: %s\n", to, subject, textBody)
+		if attachment != nil {
+			fmt.Printf("[EMAIL] Attachment: %s (%d bytes)\n", attachment.filename, len(attachment.content))
+		}
 		return nil
 	}
 
@@ -70,6 +92,9 @@ func (s *emailService) sendEmailHTML(to, subject, htmlBody, textBody string) err
 	endBoundary := fmt.Sprintf("--%s--\r\n", boundary)
 
 	msg := []byte(headers + textPart + htmlPart + endBoundary)
+	if attachment != nil {
+		msg = wrapWithAttachment(fromHeader, to, subject, boundary, textPart+htmlPart+endBoundary, attachment)
+	}
 	addr := fmt.Sprintf("%s:%s", s.config.SMTPHost, s.config.SMTPPort)
 
 	err := smtp.SendMail(addr, auth, from, []string{to}, msg)
@@ -80,6 +105,29 @@ func (s *emailService) sendEmailHTML(to, subject, htmlBody, textBody string) err
 	return nil
 }
 
+// wrapWithAttachment membungkus bagian HTML dan plain text dalam multipart/mixed beserta lampirannya.
+func wrapWithAttachment(fromHeader, to, subject, alternativeBoundary, alternativeBody string, attachment *emailAttachment) []byte {
+	boundary := alternativeBoundary + "_mixed"
+	headers := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=\"%s\"\r\n\r\n",
+		fromHeader, to, subject, boundary)
+
+	bodyPart := fmt.Sprintf("--%s\r\nContent-Type: multipart/alternative; boundary=\"%s\"\r\n\r\n%s\r\n",
+		boundary, alternativeBoundary, alternativeBody)
+
+	// Lampiran dikodekan base64 dengan baris maksimal 76 karakter
+	encoded := base64.StdEncoding.EncodeToString(attachment.content)
+	var lines strings.Builder
+	for len(encoded) > 76 {
+		lines.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	lines.WriteString(encoded + "\r\n")
+	attachmentPart := fmt.Sprintf("--%s\r\nContent-Type: %s; name=\"%s\"\r\nContent-Transfer-Encoding: base64\r\nContent-Disposition: attachment; filename=\"%s\"\r\n\r\n%s",
+		boundary, attachment.contentType, attachment.filename, attachment.filename, lines.String())
+
+	return []byte(headers + bodyPart + attachmentPart + fmt.Sprintf("--%s--\r\n", boundary))
+}
+
 func (s *emailService) SendOTPEmail(to, otpCode string) error {
 	subject := "Email Verification - Kode OTP Anda"
 	emailName := s.config.EmailName
@@ -672,3 +720,367 @@ Tim %s
 
 	return s.sendEmailHTML(to, subject, htmlBody, textBody)
 }
+
+// SendPaymentInstructionsEmail mengirim ulang instruksi pembayaran (VA / QR / kode bayar).
+// Key yang didukung di data: order_number, amount, payment_method, bank, va_number, qr_code_url, expiry_time.
+func (s *emailService) SendPaymentInstructionsEmail(to string, data map[string]string) error {
+	subject := "Instruksi Pembayaran - " + data["order_number"]
+
+	labels := []struct{ key, label string }{
+		{"order_number", "Nomor Pesanan"},
+		{"amount", "Total Pembayaran"},
+		{"payment_method", "Metode Pembayaran"},
+		{"bank", "Bank"},
+		{"va_number", "Nomor Virtual Account / Rekening"},
+		{"qr_code_url", "QR Code"},
+		{"expiry_time", "Bayar Sebelum"},
+	}
+
+	var htmlRows, textRows strings.Builder
+	for _, l := range labels {
+		value := data[l.key]
+		if value == "" {
+			continue
+		}
+		htmlValue := html.EscapeString(value)
+		if l.key == "qr_code_url" {
+			htmlValue = fmt.Sprintf(`<a href="%s" style="color: #1e40af;">Buka QR Code</a>`, htmlValue)
+		}
+		htmlRows.WriteString(fmt.Sprintf(`
+                                            <tr>
+                                                <td style="padding: 10px 0; border-bottom: 1px solid #e5e7eb; color: #6b7280; font-size: 14px;">%s</td>
+                                                <td style="padding: 10px 0; border-bottom: 1px solid #e5e7eb; color: #1f2937; font-size: 14px; font-weight: 600; text-align: right;">%s</td>
+                                            </tr>`, l.label, htmlValue))
+		textRows.WriteString(fmt.Sprintf("%s: %s\n", l.label, value))
+	}
+
+	htmlBody := fmt.Sprintf(`
+<!DOCTYPE html>
+<html lang="id">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+</head>
+<body style="margin: 0; padding: 0; font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: #f4f6f8;">
+    <table role="presentation" cellpadding="0" cellspacing="0" border="0" width="100%%" style="background-color: #f4f6f8; padding: 40px 20px;">
+        <tr>
+            <td align="center">
+                <table role="presentation" cellpadding="0" cellspacing="0" border="0" width="600" style="max-width: 600px; width: 100%%; background-color: #ffffff; border: 1px solid #e5e7eb; border-radius: 4px;">
+                    <!-- Header -->
+                    <tr>
+                        <td style="background-color: #1e3a8a; padding: 30px 40px; border-bottom: 3px solid #1e40af;">
+                            <h1 style="margin: 0; color: #ffffff; font-size: 24px; font-weight: 600;">Instruksi Pembayaran</h1>
+                        </td>
+                    </tr>
+
+                    <!-- Content -->
+                    <tr>
+                        <td style="padding: 40px;">
+                            <p style="margin: 0 0 24px; color: #374151; font-size: 15px; line-height: 1.7;">
+                                Berikut adalah detail pembayaran untuk pesanan Anda. Silakan selesaikan pembayaran sebelum batas waktu berakhir.
+                            </p>
+                            <table role="presentation" cellpadding="0" cellspacing="0" border="0" width="100%%" style="margin: 0 0 24px;">%s
+                            </table>
+                            <p style="margin: 0; color: #6b7280; font-size: 13px; line-height: 1.6;">
+                                Abaikan email ini jika Anda sudah menyelesaikan pembayaran.
+                            </p>
+                        </td>
+                    </tr>
+
+                    <!-- Footer -->
+                    <tr>
+                        <td style="background-color: #f9fafb; border-top: 1px solid #e5e7eb; padding: 20px 40px;">
+                            <p style="margin: 0; color: #9ca3af; font-size: 11px; line-height: 1.6;">
+                                © %d %s. Hak Cipta Dilindungi.
+                            </p>
+                        </td>
+                    </tr>
+                </table>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>
+`, htmlRows.String(), time.Now().Year(), s.config.EmailName)
+
+	textBody := fmt.Sprintf(`
+Instruksi Pembayaran
+
+Berikut adalah detail pembayaran untuk pesanan Anda:
+
+%s
+Abaikan email ini jika Anda sudah menyelesaikan pembayaran.
+
+Tim %s
+`, textRows.String(), s.config.EmailName)
+
+	return s.sendEmailHTML(to, subject, htmlBody, textBody)
+}
+
+// SendCartItemsUnavailableEmail memberi tahu pembeli bahwa produk di keranjangnya tidak bisa dibeli
+// lagi karena tokonya ditutup. Key yang didukung di data: shop_name, item_count.
+func (s *emailService) SendCartItemsUnavailableEmail(to string, data map[string]string) error {
+	subject := "Produk di Keranjang Tidak Tersedia - " + data["shop_name"]
+
+	htmlBody := fmt.Sprintf(`
+<!DOCTYPE html>
+<html lang="id">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+</head>
+<body style="margin: 0; padding: 0; font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: #f4f6f8;">
+    <table role="presentation" cellpadding="0" cellspacing="0" border="0" width="100%%" style="background-color: #f4f6f8; padding: 40px 20px;">
+        <tr>
+            <td align="center">
+                <table role="presentation" cellpadding="0" cellspacing="0" border="0" width="600" style="max-width: 600px; width: 100%%; background-color: #ffffff; border: 1px solid #e5e7eb; border-radius: 4px;">
+                    <!-- Header -->
+                    <tr>
+                        <td style="background-color: #1e3a8a; padding: 30px 40px; border-bottom: 3px solid #1e40af;">
+                            <h1 style="margin: 0; color: #ffffff; font-size: 24px; font-weight: 600;">Produk Tidak Tersedia</h1>
+                        </td>
+                    </tr>
+
+                    <!-- Content -->
+                    <tr>
+                        <td style="padding: 40px;">
+                            <p style="margin: 0 0 24px; color: #374151; font-size: 15px; line-height: 1.7;">
+                                Toko <strong>%s</strong> sudah tidak aktif, sehingga %s produk dari toko tersebut di keranjang Anda tidak dapat dibeli untuk saat ini.
+                            </p>
+                            <p style="margin: 0; color: #6b7280; font-size: 13px; line-height: 1.6;">
+                                Produk tersebut ditandai di keranjang Anda dan tidak akan ikut saat checkout. Anda dapat menghapusnya atau mencari produk serupa dari toko lain.
+                            </p>
+                        </td>
+                    </tr>
+
+                    <!-- Footer -->
+                    <tr>
+                        <td style="background-color: #f9fafb; border-top: 1px solid #e5e7eb; padding: 20px 40px;">
+                            <p style="margin: 0; color: #9ca3af; font-size: 11px; line-height: 1.6;">
+                                © %d %s. Hak Cipta Dilindungi.
+                            </p>
+                        </td>
+                    </tr>
+                </table>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>
+`, html.EscapeString(data["shop_name"]), data["item_count"], time.Now().Year(), s.config.EmailName)
+
+	textBody := fmt.Sprintf(`
+Produk Tidak Tersedia
+
+Toko %s sudah tidak aktif, sehingga %s produk dari toko tersebut di keranjang Anda tidak dapat dibeli untuk saat ini.
+
+Produk tersebut ditandai di keranjang Anda dan tidak akan ikut saat checkout.
+
+Tim %s
+`, data["shop_name"], data["item_count"], s.config.EmailName)
+
+	return s.sendEmailHTML(to, subject, htmlBody, textBody)
+}
+
+// SendOrderAutoCancelledEmail memberi tahu pembeli bahwa pesanannya dibatalkan karena belum dibayar.
+func (s *emailService) SendOrderAutoCancelledEmail(to string, data map[string]string) error {
+	subject := "Pesanan Dibatalkan - " + data["order_number"]
+
+	htmlBody := fmt.Sprintf(`
+<!DOCTYPE html>
+<html lang="id">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+</head>
+<body style="margin: 0; padding: 0; font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: #f4f6f8;">
+    <table role="presentation" cellpadding="0" cellspacing="0" border="0" width="100%%" style="background-color: #f4f6f8; padding: 40px 20px;">
+        <tr>
+            <td align="center">
+                <table role="presentation" cellpadding="0" cellspacing="0" border="0" width="600" style="max-width: 600px; width: 100%%; background-color: #ffffff; border: 1px solid #e5e7eb; border-radius: 4px;">
+                    <!-- Header -->
+                    <tr>
+                        <td style="background-color: #1e3a8a; padding: 30px 40px; border-bottom: 3px solid #1e40af;">
+                            <h1 style="margin: 0; color: #ffffff; font-size: 24px; font-weight: 600;">Pesanan Dibatalkan</h1>
+                        </td>
+                    </tr>
+
+                    <!-- Content -->
+                    <tr>
+                        <td style="padding: 40px;">
+                            <p style="margin: 0 0 24px; color: #374151; font-size: 15px; line-height: 1.7;">
+                                Halo %s, pesanan <strong>%s</strong> yang dibuat pada %s dengan total Rp %s telah dibatalkan otomatis karena pembayaran belum kami terima.
+                            </p>
+                            <p style="margin: 0; color: #6b7280; font-size: 13px; line-height: 1.6;">
+                                Stok produk telah dikembalikan. Jika Anda masih ingin membeli, silakan buat pesanan baru.
+                            </p>
+                        </td>
+                    </tr>
+
+                    <!-- Footer -->
+                    <tr>
+                        <td style="background-color: #f9fafb; border-top: 1px solid #e5e7eb; padding: 20px 40px;">
+                            <p style="margin: 0; color: #9ca3af; font-size: 11px; line-height: 1.6;">
+                                © %d %s. Hak Cipta Dilindungi.
+                            </p>
+                        </td>
+                    </tr>
+                </table>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>
+`, html.EscapeString(data["name"]), html.EscapeString(data["order_number"]), data["created_at"], data["total_amount"], time.Now().Year(), s.config.EmailName)
+
+	textBody := fmt.Sprintf(`
+Pesanan Dibatalkan
+
+Halo %s, pesanan %s yang dibuat pada %s dengan total Rp %s telah dibatalkan otomatis karena pembayaran belum kami terima.
+
+Stok produk telah dikembalikan. Jika Anda masih ingin membeli, silakan buat pesanan baru.
+
+Tim %s
+`, data["name"], data["order_number"], data["created_at"], data["total_amount"], s.config.EmailName)
+
+	return s.sendEmailHTML(to, subject, htmlBody, textBody)
+}
+
+// SendSupportTicketReplyEmail memberi tahu pembeli atau agen support bahwa ada balasan baru di tiket.
+// Key yang didukung di data: name, ticket_number, subject, sender, message, ticket_url.
+func (s *emailService) SendSupportTicketReplyEmail(to string, data map[string]string) error {
+	subject := fmt.Sprintf("Balasan Tiket %s - %s", data["ticket_number"], data["subject"])
+	message := html.EscapeString(data["message"])
+
+	htmlBody := fmt.Sprintf(`
+<!DOCTYPE html>
+<html lang="id">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+</head>
+<body style="margin: 0; padding: 0; font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: #f4f6f8;">
+    <table role="presentation" cellpadding="0" cellspacing="0" border="0" width="100%%" style="background-color: #f4f6f8; padding: 40px 20px;">
+        <tr>
+            <td align="center">
+                <table role="presentation" cellpadding="0" cellspacing="0" border="0" width="600" style="max-width: 600px; width: 100%%; background-color: #ffffff; border: 1px solid #e5e7eb; border-radius: 4px;">
+                    <!-- Header -->
+                    <tr>
+                        <td style="background-color: #1e3a8a; padding: 30px 40px; border-bottom: 3px solid #1e40af;">
+                            <h1 style="margin: 0; color: #ffffff; font-size: 24px; font-weight: 600;">Balasan Tiket Bantuan</h1>
+                        </td>
+                    </tr>
+
+                    <!-- Content -->
+                    <tr>
+                        <td style="padding: 40px;">
+                            <p style="margin: 0 0 24px; color: #374151; font-size: 15px; line-height: 1.7;">
+                                Halo %s, %s membalas tiket <strong>%s</strong> (%s):
+                            </p>
+                            <p style="margin: 0 0 24px; padding: 16px; background-color: #f9fafb; border-left: 3px solid #1e40af; color: #374151; font-size: 14px; line-height: 1.6; white-space: pre-line;">%s</p>
+                            <p style="margin: 0; color: #6b7280; font-size: 13px; line-height: 1.6;">
+                                Lihat dan balas tiket di <a href="%s" style="color: #1e40af;">%s</a>.
+                            </p>
+                        </td>
+                    </tr>
+
+                    <!-- Footer -->
+                    <tr>
+                        <td style="background-color: #f9fafb; border-top: 1px solid #e5e7eb; padding: 20px 40px;">
+                            <p style="margin: 0; color: #9ca3af; font-size: 11px; line-height: 1.6;">
+                                © %d %s. Hak Cipta Dilindungi.
+                            </p>
+                        </td>
+                    </tr>
+                </table>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>
+`, html.EscapeString(data["name"]), html.EscapeString(data["sender"]), data["ticket_number"], html.EscapeString(data["subject"]),
+		message, data["ticket_url"], data["ticket_url"], time.Now().Year(), s.config.EmailName)
+
+	textBody := fmt.Sprintf(`
+Balasan Tiket Bantuan
+
+Halo %s, %s membalas tiket %s (%s):
+
+%s
+
+Lihat dan balas tiket di %s
+
+Tim %s
+`, data["name"], data["sender"], data["ticket_number"], data["subject"], data["message"], data["ticket_url"], s.config.EmailName)
+
+	return s.sendEmailHTML(to, subject, htmlBody, textBody)
+}
+
+// SendScheduledReportEmail mengirim hasil laporan terjadwal ke penerimanya dengan hasil sebagai lampiran.
+// Key yang didukung di data: name, title, period, rows, filename, content_type, content.
+func (s *emailService) SendScheduledReportEmail(to string, data map[string]string) error {
+	subject := fmt.Sprintf("Laporan %s - %s", data["name"], data["period"])
+
+	htmlBody := fmt.Sprintf(`
+<!DOCTYPE html>
+<html lang="id">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+</head>
+<body style="margin: 0; padding: 0; font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: #f4f6f8;">
+    <table role="presentation" cellpadding="0" cellspacing="0" border="0" width="100%%" style="background-color: #f4f6f8; padding: 40px 20px;">
+        <tr>
+            <td align="center">
+                <table role="presentation" cellpadding="0" cellspacing="0" border="0" width="600" style="max-width: 600px; width: 100%%; background-color: #ffffff; border: 1px solid #e5e7eb; border-radius: 4px;">
+                    <!-- Header -->
+                    <tr>
+                        <td style="background-color: #1e3a8a; padding: 30px 40px; border-bottom: 3px solid #1e40af;">
+                            <h1 style="margin: 0; color: #ffffff; font-size: 24px; font-weight: 600;">%s</h1>
+                        </td>
+                    </tr>
+
+                    <!-- Content -->
+                    <tr>
+                        <td style="padding: 40px;">
+                            <p style="margin: 0 0 16px; color: #374151; font-size: 15px; line-height: 1.7;">
+                                Laporan terjadwal <strong>%s</strong> untuk periode %s berisi %s baris.
+                            </p>
+                            <p style="margin: 0; color: #6b7280; font-size: 13px; line-height: 1.6;">
+                                Hasil lengkap terlampir sebagai <strong>%s</strong>.
+                            </p>
+                        </td>
+                    </tr>
+
+                    <!-- Footer -->
+                    <tr>
+                        <td style="background-color: #f9fafb; border-top: 1px solid #e5e7eb; padding: 20px 40px;">
+                            <p style="margin: 0; color: #9ca3af; font-size: 11px; line-height: 1.6;">
+                                © %d %s. Hak Cipta Dilindungi.
+                            </p>
+                        </td>
+                    </tr>
+                </table>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>
+`, html.EscapeString(data["title"]), html.EscapeString(data["name"]), data["period"], data["rows"], data["filename"],
+		time.Now().Year(), s.config.EmailName)
+
+	textBody := fmt.Sprintf(`
+%s
+
+Laporan terjadwal %s untuk periode %s berisi %s baris.
+Hasil lengkap terlampir sebagai %s.
+
+Tim %s
+`, data["title"], data["name"], data["period"], data["rows"], data["filename"], s.config.EmailName)
+
+	return s.sendEmailWithAttachment(to, subject, htmlBody, textBody, &emailAttachment{
+		filename:    data["filename"],
+		contentType: data["content_type"],
+		content:     []byte(data["content"]),
+	})
+}
@@ -76,6 +76,16 @@ func (w *EmailWorker) processEmailMessage(msg amqp.Delivery) error {
 		return w.emailService.SendVerificationEmail(emailMsg.To, emailMsg.Body)
 	case "welcome":
 		return w.emailService.SendWelcomeEmail(emailMsg.To, emailMsg.Subject) // Using Subject as name
+	case "payment_instructions":
+		return w.emailService.SendPaymentInstructionsEmail(emailMsg.To, emailMsg.Data)
+	case "cart_items_unavailable":
+		return w.emailService.SendCartItemsUnavailableEmail(emailMsg.To, emailMsg.Data)
+	case "order_auto_cancelled":
+		return w.emailService.SendOrderAutoCancelledEmail(emailMsg.To, emailMsg.Data)
+	case "support_ticket_reply":
+		return w.emailService.SendSupportTicketReplyEmail(emailMsg.To, emailMsg.Data)
+	case "scheduled_report":
+		return w.emailService.SendScheduledReportEmail(emailMsg.To, emailMsg.Data)
 	default:
 		// Generic email
 		return w.emailService.SendOTPEmail(emailMsg.To, emailMsg.Body)
@@ -0,0 +1,24 @@
+package service
+
+// InternalError is a failure on the server side (database, file host, key generation) rather
+// than a request the caller got wrong, so handlers report it as a 500 instead of a 400
+type InternalError struct {
+	Message string // What failed, e.g. "failed to create coupon"
+	Err     error
+}
+
+func (e *InternalError) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *InternalError) Unwrap() error {
+	return e.Err
+}
+
+// internalError wraps err (which may be nil) as an InternalError described by message
+func internalError(message string, err error) error {
+	return &InternalError{Message: message, Err: err}
+}
@@ -0,0 +1,409 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+
+	"gorm.io/gorm"
+)
+
+// ErrFraudBlocked is shown to buyers whose checkout or payment a fraud rule blocked; the rule
+// itself is only visible to admins
+var ErrFraudBlocked = errors.New("this order cannot be processed, please contact customer support")
+
+// Velocity windows are capped so allowed checks can be pruned after a week
+const (
+	fraudMaxWindowMinutes  = 7 * 24 * 60
+	fraudCheckPruneEvery   = time.Hour
+	fraudCheckPruneTimeout = time.Minute
+)
+
+// FraudService evaluates admin-managed rules (velocity limits, blocked emails and phones, order
+// value limits for new accounts) when orders and payments are created
+type FraudService interface {
+	// CheckOrder is the before-order-create hook
+	CheckOrder(ctx context.Context, order *model.Order) error
+	// CheckPayment is the before-payment-create hook
+	CheckPayment(ctx context.Context, order *model.Order) error
+
+	GetRules(ctx context.Context) ([]model.FraudRule, error)
+	CreateRule(ctx context.Context, req FraudRuleRequest) (*model.FraudRule, error)
+	UpdateRule(ctx context.Context, id string, req FraudRuleRequest) (*model.FraudRule, error)
+	DeleteRule(ctx context.Context, id string) error
+	GetBlockedAttempts(ctx context.Context, query FraudBlockQuery, page, limit int) ([]model.FraudCheck, int64, error)
+}
+
+type fraudService struct {
+	fraudRepo   repository.FraudRepository
+	userRepo    repository.UserRepository
+	addressRepo repository.AddressRepository
+	calendar    BusinessCalendarService
+}
+
+// FraudRuleRequest creates or replaces a rule; which limits are required depends on the type
+type FraudRuleRequest struct {
+	Type           string  `json:"type" binding:"required,oneof=user_velocity ip_velocity blocked_email blocked_phone new_account_max_order"`
+	Stage          string  `json:"stage" binding:"omitempty,oneof=order payment all"` // Default: all
+	Value          *string `json:"value" binding:"omitempty,max=255"`
+	MaxAttempts    int     `json:"max_attempts" binding:"min=0"`
+	WindowMinutes  int     `json:"window_minutes" binding:"min=0"`
+	MaxAmount      int     `json:"max_amount" binding:"min=0"`
+	AccountAgeDays int     `json:"account_age_days" binding:"min=0"`
+	IsActive       *bool   `json:"is_active"` // Default: true
+	Note           *string `json:"note"`
+}
+
+// FraudBlockQuery filters the blocked attempts audit; dates are YYYY-MM-DD in the business
+// timezone and both ends are inclusive
+type FraudBlockQuery struct {
+	Stage  string
+	UserID string
+	RuleID string
+	From   string
+	To     string
+}
+
+func NewFraudService(fraudRepo repository.FraudRepository, userRepo repository.UserRepository, addressRepo repository.AddressRepository, calendar BusinessCalendarService, jobs *util.BackgroundJobs) FraudService {
+	service := &fraudService{
+		fraudRepo:   fraudRepo,
+		userRepo:    userRepo,
+		addressRepo: addressRepo,
+		calendar:    calendar,
+	}
+
+	// Start background job to prune allowed checks older than the longest velocity window
+	jobs.Every(fraudCheckPruneEvery, service.runCheckPruner)
+	log.Printf("✅ Fraud check pruner started (checking every %s)", fraudCheckPruneEvery)
+
+	return service
+}
+
+func (s *fraudService) CheckOrder(ctx context.Context, order *model.Order) error {
+	return s.check(ctx, model.FraudStageOrder, order, nil)
+}
+
+func (s *fraudService) CheckPayment(ctx context.Context, order *model.Order) error {
+	return s.check(ctx, model.FraudStagePayment, order, &order.ID)
+}
+
+// check evaluates the stage's active rules. The attempt is recorded before the velocity rules
+// count, so concurrent attempts see each other. Rule engine failures are logged and let the
+// attempt through rather than blocking every checkout.
+func (s *fraudService) check(ctx context.Context, stage string, order *model.Order, orderID *string) error {
+	rules, err := s.fraudRepo.FindRules(ctx, true)
+	if err != nil {
+		log.Printf("⚠️  Failed to load fraud rules, skipping %s checks: %v", stage, err)
+		return nil
+	}
+	var applicable []model.FraudRule
+	for _, rule := range rules {
+		if rule.Stage == stage || rule.Stage == model.FraudStageAll {
+			applicable = append(applicable, rule)
+		}
+	}
+	if len(applicable) == 0 {
+		return nil
+	}
+
+	attempt := &model.FraudCheck{
+		Stage:    stage,
+		UserID:   order.UserID,
+		ClientIP: util.ClientIPFromContext(ctx),
+		OrderID:  orderID,
+		Amount:   order.TotalAmount,
+	}
+
+	// Rules on who is buying are decided before the attempt is recorded
+	if rule, reason := s.matchBuyerRules(ctx, applicable, order); rule != nil {
+		attempt.Blocked = true
+		attempt.RuleID = &rule.ID
+		attempt.RuleType = &rule.Type
+		attempt.Reason = &reason
+		if err := s.fraudRepo.CreateCheck(ctx, attempt); err != nil {
+			log.Printf("⚠️  Failed to record blocked %s attempt of user %s: %v", stage, order.UserID, err)
+		}
+		log.Printf("🚫 Blocked %s attempt of user %s by %s rule %s: %s", stage, order.UserID, rule.Type, rule.ID, reason)
+		return ErrFraudBlocked
+	}
+
+	if err := s.fraudRepo.CreateCheck(ctx, attempt); err != nil {
+		log.Printf("⚠️  Failed to record %s attempt of user %s, skipping velocity rules: %v", stage, order.UserID, err)
+		return nil
+	}
+	for i := range applicable {
+		rule := &applicable[i]
+		reason, err := s.matchVelocityRule(ctx, rule, attempt)
+		if err != nil {
+			log.Printf("⚠️  Failed to evaluate %s rule %s: %v", rule.Type, rule.ID, err)
+			continue
+		}
+		if reason == "" {
+			continue
+		}
+		if err := s.fraudRepo.BlockCheck(ctx, attempt.ID, rule, reason); err != nil {
+			log.Printf("⚠️  Failed to record blocked %s attempt of user %s: %v", stage, order.UserID, err)
+		}
+		log.Printf("🚫 Blocked %s attempt of user %s by %s rule %s: %s", stage, order.UserID, rule.Type, rule.ID, reason)
+		return ErrFraudBlocked
+	}
+	return nil
+}
+
+// matchBuyerRules returns the first blocked email, blocked phone or new account rule the order
+// breaks, with the reason shown in the audit
+func (s *fraudService) matchBuyerRules(ctx context.Context, rules []model.FraudRule, order *model.Order) (*model.FraudRule, string) {
+	user, err := s.userRepo.FindByID(order.UserID)
+	if err != nil {
+		log.Printf("⚠️  Failed to load user %s for fraud rules: %v", order.UserID, err)
+		return nil, ""
+	}
+	email := strings.ToLower(user.Email)
+	phones := s.orderPhones(user, order)
+
+	for i := range rules {
+		rule := &rules[i]
+		switch rule.Type {
+		case model.FraudRuleBlockedEmail:
+			if rule.Value == nil {
+				continue
+			}
+			blocked := *rule.Value
+			if email == blocked || (strings.HasPrefix(blocked, "@") && strings.HasSuffix(email, blocked)) {
+				return rule, "email " + email + " is blocked"
+			}
+		case model.FraudRuleBlockedPhone:
+			if rule.Value == nil {
+				continue
+			}
+			for _, phone := range phones {
+				if phone == *rule.Value {
+					return rule, "phone " + phone + " is blocked"
+				}
+			}
+		case model.FraudRuleNewAccountMaxOrder:
+			accountAge := time.Since(user.CreatedAt)
+			if accountAge < time.Duration(rule.AccountAgeDays)*24*time.Hour && order.TotalAmount > rule.MaxAmount {
+				return rule, fmt.Sprintf("order of %d exceeds %d for an account created %s", order.TotalAmount, rule.MaxAmount, user.CreatedAt.Format(time.RFC3339))
+			}
+		}
+	}
+	return nil, ""
+}
+
+// orderPhones returns the normalized phone numbers involved in the order: the buyer's, the
+// shipping address' and the gift recipient's
+func (s *fraudService) orderPhones(user *model.User, order *model.Order) []string {
+	var phones []string
+	add := func(phone string) {
+		if normalized := normalizeFraudPhone(phone); normalized != "" {
+			phones = append(phones, normalized)
+		}
+	}
+	if user.Phone != nil {
+		add(*user.Phone)
+	}
+	if order.ShippingAddress.ID != "" {
+		add(order.ShippingAddress.Phone)
+	} else if order.ShippingAddressID != "" {
+		if address, err := s.addressRepo.FindByID(order.ShippingAddressID); err == nil {
+			add(address.Phone)
+		}
+	}
+	if order.RecipientPhone != nil {
+		add(*order.RecipientPhone)
+	}
+	return phones
+}
+
+// matchVelocityRule returns why the recorded attempt breaks a velocity rule, "" if it does not
+func (s *fraudService) matchVelocityRule(ctx context.Context, rule *model.FraudRule, attempt *model.FraudCheck) (string, error) {
+	since := time.Now().Add(-time.Duration(rule.WindowMinutes) * time.Minute)
+	var count int64
+	var err error
+	switch rule.Type {
+	case model.FraudRuleUserVelocity:
+		count, err = s.fraudRepo.CountUserChecks(ctx, attempt.Stage, attempt.UserID, since)
+	case model.FraudRuleIPVelocity:
+		if attempt.ClientIP == "" {
+			return "", nil
+		}
+		count, err = s.fraudRepo.CountIPChecks(ctx, attempt.Stage, attempt.ClientIP, since)
+	default:
+		return "", nil
+	}
+	if err != nil || count <= int64(rule.MaxAttempts) {
+		return "", err
+	}
+
+	subject := "user " + attempt.UserID
+	if rule.Type == model.FraudRuleIPVelocity {
+		subject = "IP " + attempt.ClientIP
+	}
+	return fmt.Sprintf("%d %s attempts from %s within %d minutes (limit %d)", count, attempt.Stage, subject, rule.WindowMinutes, rule.MaxAttempts), nil
+}
+
+func (s *fraudService) GetRules(ctx context.Context) ([]model.FraudRule, error) {
+	rules, err := s.fraudRepo.FindRules(ctx, false)
+	if err != nil {
+		return nil, internalError("failed to get fraud rules", err)
+	}
+	return rules, nil
+}
+
+func (s *fraudService) CreateRule(ctx context.Context, req FraudRuleRequest) (*model.FraudRule, error) {
+	rule := &model.FraudRule{}
+	if err := applyFraudRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.fraudRepo.CreateRule(ctx, rule); err != nil {
+		return nil, internalError("failed to create fraud rule", err)
+	}
+	log.Printf("🛡️  Fraud rule %s (%s) created", rule.ID, rule.Type)
+	return rule, nil
+}
+
+func (s *fraudService) UpdateRule(ctx context.Context, id string, req FraudRuleRequest) (*model.FraudRule, error) {
+	rule, err := s.fraudRepo.FindRuleByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("fraud rule not found")
+	}
+	if err := applyFraudRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.fraudRepo.UpdateRule(ctx, rule); err != nil {
+		return nil, internalError("failed to update fraud rule", err)
+	}
+	log.Printf("🛡️  Fraud rule %s (%s) updated", rule.ID, rule.Type)
+	return rule, nil
+}
+
+func (s *fraudService) DeleteRule(ctx context.Context, id string) error {
+	if err := s.fraudRepo.DeleteRule(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("fraud rule not found")
+		}
+		return internalError("failed to delete fraud rule", err)
+	}
+	log.Printf("🛡️  Fraud rule %s deleted", id)
+	return nil
+}
+
+func (s *fraudService) GetBlockedAttempts(ctx context.Context, query FraudBlockQuery, page, limit int) ([]model.FraudCheck, int64, error) {
+	from, to, err := parseDateRange(query.From, query.To, s.calendar.Location())
+	if err != nil {
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	filter := repository.FraudCheckFilter{
+		Stage:  query.Stage,
+		UserID: query.UserID,
+		RuleID: query.RuleID,
+		From:   from,
+		To:     to,
+	}
+	checks, total, err := s.fraudRepo.FindBlockedChecks(ctx, filter, page, limit)
+	if err != nil {
+		return nil, 0, internalError("failed to get blocked attempts", err)
+	}
+	return checks, total, nil
+}
+
+// applyFraudRuleRequest validates the request for the rule's type and copies it onto the rule;
+// limits that do not belong to the type are cleared
+func applyFraudRuleRequest(rule *model.FraudRule, req FraudRuleRequest) error {
+	next := model.FraudRule{
+		Type:     req.Type,
+		Stage:    req.Stage,
+		IsActive: true,
+		Note:     req.Note,
+	}
+	if next.Stage == "" {
+		next.Stage = model.FraudStageAll
+	}
+	if req.IsActive != nil {
+		next.IsActive = *req.IsActive
+	}
+
+	switch req.Type {
+	case model.FraudRuleUserVelocity, model.FraudRuleIPVelocity:
+		if req.MaxAttempts < 1 {
+			return errors.New("max_attempts must be at least 1")
+		}
+		if req.WindowMinutes < 1 || req.WindowMinutes > fraudMaxWindowMinutes {
+			return fmt.Errorf("window_minutes must be between 1 and %d", fraudMaxWindowMinutes)
+		}
+		next.MaxAttempts = req.MaxAttempts
+		next.WindowMinutes = req.WindowMinutes
+	case model.FraudRuleBlockedEmail:
+		if req.Value == nil || !strings.Contains(*req.Value, "@") {
+			return errors.New("value must be an email address or a domain like @example.com")
+		}
+		value := strings.ToLower(strings.TrimSpace(*req.Value))
+		next.Value = &value
+	case model.FraudRuleBlockedPhone:
+		if req.Value == nil {
+			return errors.New("value must be a phone number")
+		}
+		value := normalizeFraudPhone(*req.Value)
+		if len(value) < 8 {
+			return errors.New("value must be a phone number")
+		}
+		next.Value = &value
+	case model.FraudRuleNewAccountMaxOrder:
+		if req.MaxAmount < 1 || req.AccountAgeDays < 1 {
+			return errors.New("max_amount and account_age_days must be at least 1")
+		}
+		next.MaxAmount = req.MaxAmount
+		next.AccountAgeDays = req.AccountAgeDays
+	default:
+		return errors.New("invalid rule type")
+	}
+
+	next.ID, next.CreatedAt = rule.ID, rule.CreatedAt
+	*rule = next
+	return nil
+}
+
+// normalizeFraudPhone keeps the digits of a phone number in local form, so +62 812..., 62812...
+// and 0812... compare equal
+func normalizeFraudPhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	normalized := digits.String()
+	switch {
+	case strings.HasPrefix(normalized, "62"):
+		normalized = "0" + normalized[2:]
+	case strings.HasPrefix(normalized, "8"):
+		normalized = "0" + normalized
+	}
+	return normalized
+}
+
+// runCheckPruner deletes allowed checks that no velocity window reaches any more
+func (s *fraudService) runCheckPruner(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, fraudCheckPruneTimeout)
+	defer cancel()
+
+	before := time.Now().Add(-fraudMaxWindowMinutes * time.Minute)
+	if deleted, err := s.fraudRepo.DeleteAllowedChecksBefore(ctx, before); err != nil {
+		log.Printf("⚠️  Failed to prune fraud checks: %v", err)
+	} else if deleted > 0 {
+		log.Printf("🧹 Pruned %d fraud check(s)", deleted)
+	}
+}
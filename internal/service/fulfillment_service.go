@@ -0,0 +1,489 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Fulfillment API limits
+const (
+	fulfillmentDefaultLimit     = 50
+	fulfillmentMaxLimit         = 200
+	fulfillmentCallbackMaxSkew  = 5 * time.Minute // How far a callback's timestamp may be from now
+	fulfillmentReconcileMissing = 200             // Acknowledged sub-orders checked against a complete reconcile list
+)
+
+// Fulfillment callback events
+const (
+	FulfillmentEventAcknowledged = "order.acknowledged"
+	FulfillmentEventShipped      = "order.shipped"
+)
+
+// Reconcile actions: what the warehouse should do to get back in sync with the marketplace
+const (
+	FulfillmentActionNone           = "none"
+	FulfillmentActionCancel         = "cancel"          // Cancelled on the marketplace; stop fulfilling it
+	FulfillmentActionHold           = "hold"            // Not paid; do not ship it
+	FulfillmentActionAcknowledge    = "acknowledge"     // Not acknowledged on the marketplace; acknowledge it again
+	FulfillmentActionPushShipment   = "push_shipment"   // Shipped by the warehouse only; push the shipment again
+	FulfillmentActionCheckTracking  = "check_tracking"  // Shipped under another tracking number on the marketplace
+	FulfillmentActionAlreadyShipped = "already_shipped" // Shipped on the marketplace already; do not ship it again
+	FulfillmentActionNotFound       = "not_found"
+)
+
+var (
+	// ErrFulfillmentLiveOnly is returned when a sandbox API key calls the fulfillment API
+	ErrFulfillmentLiveOnly = errors.New("fulfillment requires a live API key")
+	// ErrInvalidCallbackSignature is returned when a fulfillment callback is unsigned, stale or
+	// signed with the wrong secret
+	ErrInvalidCallbackSignature = errors.New("invalid callback signature")
+)
+
+// FulfillmentService lets a seller's third-party fulfillment (3PL) warehouse work through the
+// partner API: it pulls paid sub-orders, acknowledges the ones it takes over, pushes back tracking
+// numbers and reconciles its state with the marketplace's. Acknowledgements and shipments can also
+// be pushed as signed callbacks.
+//
+// Callbacks are POSTs to /api/v1/partner/fulfillment/callbacks/:key_id with a JSON body
+// FulfillmentCallback and the headers X-Callback-Timestamp (Unix seconds) and
+// X-Callback-Signature: sha256=HMAC-SHA256(secret, timestamp + "." + body) in hex, signed like
+// seller webhooks with the API key's callback secret.
+type FulfillmentService interface {
+	// GetReadyOrders returns paid sub-orders the warehouse has not acknowledged yet, oldest first
+	GetReadyOrders(ctx context.Context, apiKey *model.PartnerAPIKey, limit int) ([]FulfillmentOrder, error)
+	Acknowledge(ctx context.Context, apiKey *model.PartnerAPIKey, sellerOrderID string, reference string) (*FulfillmentOrder, error)
+	PushShipment(ctx context.Context, apiKey *model.PartnerAPIKey, sellerOrderID string, req FulfillmentShipmentRequest) (*FulfillmentOrder, error)
+	// Reconcile compares the warehouse's view of its sub-orders with the marketplace's
+	Reconcile(ctx context.Context, apiKey *model.PartnerAPIKey, req FulfillmentReconcileRequest) (*FulfillmentReconcileResponse, error)
+	// HandleCallback verifies a signed callback for the API key and applies it
+	HandleCallback(ctx context.Context, keyID string, timestamp string, signature string, body []byte) (*FulfillmentOrder, error)
+	// RotateCallbackSecret issues a new callback secret for the user's API key; the old one stops working
+	RotateCallbackSecret(ctx context.Context, userID string, keyID string) (string, error)
+}
+
+type fulfillmentService struct {
+	sellerOrderRepo repository.SellerOrderRepository
+	orderRepo       repository.OrderRepository
+	variantRepo     repository.ProductVariantRepository
+	apiKeyRepo      repository.PartnerAPIKeyRepository
+	sellerRepo      repository.SellerRepository
+	hooks           *HookRegistry
+}
+
+// FulfillmentOrder is a sub-order as the warehouse needs it to pick, pack and ship
+type FulfillmentOrder struct {
+	SubOrderID     string               `json:"sub_order_id"`
+	SubOrderNumber string               `json:"sub_order_number"`
+	Status         string               `json:"status"`
+	Reference      *string              `json:"reference,omitempty"` // The warehouse's ID, once acknowledged
+	AcknowledgedAt *time.Time           `json:"acknowledged_at,omitempty"`
+	Courier        *string              `json:"courier,omitempty"`
+	TrackingNumber *string              `json:"tracking_number,omitempty"`
+	IsGift         bool                 `json:"is_gift"`
+	GiftWrap       bool                 `json:"gift_wrap"`
+	GiftMessage    *string              `json:"gift_message,omitempty"`
+	Recipient      PackingSlipRecipient `json:"recipient"`
+	Items          []FulfillmentItem    `json:"items"`
+	OrderedAt      time.Time            `json:"ordered_at"`
+}
+
+type FulfillmentItem struct {
+	OrderItemID string  `json:"order_item_id"`
+	SKU         string  `json:"sku"` // The variant's SKU for variant items
+	ProductName string  `json:"product_name"`
+	VariantName *string `json:"variant_name,omitempty"`
+	Quantity    int     `json:"quantity"`
+	Note        *string `json:"note,omitempty"`
+}
+
+// FulfillmentShipmentRequest hands a sub-order to the courier
+type FulfillmentShipmentRequest struct {
+	Courier        string `json:"courier" binding:"required,max=50"`
+	TrackingNumber string `json:"tracking_number" binding:"required,max=100"` // AWB
+}
+
+type FulfillmentReconcileRequest struct {
+	Orders []FulfillmentState `json:"orders" binding:"required,min=1,max=200,dive"`
+	// Complete says the list is every sub-order the warehouse still has open; the response then
+	// also lists acknowledged sub-orders it is missing
+	Complete bool `json:"complete"`
+}
+
+// FulfillmentState is the warehouse's view of one sub-order
+type FulfillmentState struct {
+	SubOrderID     string `json:"sub_order_id" binding:"required"`
+	Status         string `json:"status" binding:"required,oneof=acknowledged shipped"`
+	TrackingNumber string `json:"tracking_number" binding:"max=100"`
+}
+
+type FulfillmentReconcileResult struct {
+	SubOrderID     string  `json:"sub_order_id"`
+	SubOrderNumber string  `json:"sub_order_number,omitempty"`
+	Status         string  `json:"status,omitempty"` // On the marketplace
+	TrackingNumber *string `json:"tracking_number,omitempty"`
+	Action         string  `json:"action"`
+}
+
+type FulfillmentReconcileResponse struct {
+	Results   []FulfillmentReconcileResult `json:"results"`
+	Missing   []FulfillmentOrder           `json:"missing,omitempty"` // Acknowledged and not shipped, but not in a complete list
+	InSync    int                          `json:"in_sync"`
+	OutOfSync int                          `json:"out_of_sync"`
+}
+
+// FulfillmentCallback is the body of a signed callback. Reference is required to acknowledge,
+// courier and tracking number to ship.
+type FulfillmentCallback struct {
+	Event          string `json:"event"`
+	SubOrderID     string `json:"sub_order_id"`
+	Reference      string `json:"reference"`
+	Courier        string `json:"courier"`
+	TrackingNumber string `json:"tracking_number"`
+}
+
+func NewFulfillmentService(
+	sellerOrderRepo repository.SellerOrderRepository,
+	orderRepo repository.OrderRepository,
+	variantRepo repository.ProductVariantRepository,
+	apiKeyRepo repository.PartnerAPIKeyRepository,
+	sellerRepo repository.SellerRepository,
+	hooks *HookRegistry,
+) FulfillmentService {
+	return &fulfillmentService{
+		sellerOrderRepo: sellerOrderRepo,
+		orderRepo:       orderRepo,
+		variantRepo:     variantRepo,
+		apiKeyRepo:      apiKeyRepo,
+		sellerRepo:      sellerRepo,
+		hooks:           hooks,
+	}
+}
+
+func (s *fulfillmentService) GetReadyOrders(ctx context.Context, apiKey *model.PartnerAPIKey, limit int) ([]FulfillmentOrder, error) {
+	if apiKey.Sandbox {
+		return nil, ErrFulfillmentLiveOnly
+	}
+	if limit < 1 {
+		limit = fulfillmentDefaultLimit
+	}
+	if limit > fulfillmentMaxLimit {
+		limit = fulfillmentMaxLimit
+	}
+
+	sellerOrders, err := s.sellerOrderRepo.FindReadyForFulfillment(ctx, apiKey.SellerID, limit)
+	if err != nil {
+		return nil, internalError("failed to get orders", err)
+	}
+	return s.toFulfillmentOrders(ctx, sellerOrders)
+}
+
+func (s *fulfillmentService) Acknowledge(ctx context.Context, apiKey *model.PartnerAPIKey, sellerOrderID string, reference string) (*FulfillmentOrder, error) {
+	reference = strings.TrimSpace(reference)
+	if reference == "" {
+		return nil, errors.New("reference is required")
+	}
+	sellerOrder, err := s.findOwned(ctx, apiKey, sellerOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	parentStatus := s.parentStatus(ctx, sellerOrder)
+	change := model.StatusChange{ActorType: model.StatusActorPartner, ActorID: apiKey.ID, Note: "Acknowledged as " + reference}
+	if err := s.sellerOrderRepo.AcknowledgeFulfillment(ctx, sellerOrder.ID, reference, change); err != nil {
+		if errors.Is(err, repository.ErrFulfillmentAcknowledged) {
+			return nil, err
+		}
+		if errors.Is(err, repository.ErrInvalidSellerOrderTransition) {
+			return nil, fmt.Errorf("%w: %s sub-orders cannot be acknowledged", err, sellerOrder.Status)
+		}
+		return nil, internalError("failed to acknowledge order", err)
+	}
+
+	log.Printf("🏭 Sub-order %s acknowledged by fulfillment key %s as %s", sellerOrder.SubOrderNumber, apiKey.KeyPrefix, reference)
+	return s.reloadAndNotify(ctx, sellerOrder, parentStatus)
+}
+
+// PushShipment ships the sub-order with the warehouse's tracking number. Pushing the tracking
+// number a sub-order already shipped with again is accepted, so retries are safe.
+func (s *fulfillmentService) PushShipment(ctx context.Context, apiKey *model.PartnerAPIKey, sellerOrderID string, req FulfillmentShipmentRequest) (*FulfillmentOrder, error) {
+	courier := strings.ToLower(strings.TrimSpace(req.Courier))
+	trackingNumber := strings.ToUpper(strings.TrimSpace(req.TrackingNumber))
+	if courier == "" || trackingNumber == "" {
+		return nil, errors.New("courier and tracking number are required")
+	}
+	sellerOrder, err := s.findOwned(ctx, apiKey, sellerOrderID)
+	if err != nil {
+		return nil, err
+	}
+	if sellerOrder.TrackingNumber != nil && *sellerOrder.TrackingNumber == trackingNumber {
+		return s.toFulfillmentOrder(ctx, sellerOrder, nil)
+	}
+
+	parentStatus := s.parentStatus(ctx, sellerOrder)
+	change := model.StatusChange{ActorType: model.StatusActorPartner, ActorID: apiKey.ID, Note: courier + " " + trackingNumber}
+	if err := s.sellerOrderRepo.Ship(ctx, sellerOrder.ID, courier, trackingNumber, change); err != nil {
+		if errors.Is(err, repository.ErrInvalidSellerOrderTransition) {
+			return nil, fmt.Errorf("%w: %s to shipped", err, sellerOrder.Status)
+		}
+		return nil, internalError("failed to ship order", err)
+	}
+
+	log.Printf("🏭 Sub-order %s shipped by fulfillment key %s via %s (%s)", sellerOrder.SubOrderNumber, apiKey.KeyPrefix, courier, trackingNumber)
+	return s.reloadAndNotify(ctx, sellerOrder, parentStatus)
+}
+
+func (s *fulfillmentService) Reconcile(ctx context.Context, apiKey *model.PartnerAPIKey, req FulfillmentReconcileRequest) (*FulfillmentReconcileResponse, error) {
+	if apiKey.Sandbox {
+		return nil, ErrFulfillmentLiveOnly
+	}
+
+	resp := &FulfillmentReconcileResponse{Results: make([]FulfillmentReconcileResult, 0, len(req.Orders))}
+	listed := make(map[string]bool, len(req.Orders))
+	for _, state := range req.Orders {
+		listed[state.SubOrderID] = true
+		result := FulfillmentReconcileResult{SubOrderID: state.SubOrderID, Action: FulfillmentActionNotFound}
+		sellerOrder, err := s.sellerOrderRepo.FindByID(ctx, state.SubOrderID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, internalError("failed to reconcile orders", err)
+		}
+		if err == nil && sellerOrder.SellerID == apiKey.SellerID {
+			result.SubOrderNumber = sellerOrder.SubOrderNumber
+			result.Status = sellerOrder.Status
+			result.TrackingNumber = sellerOrder.TrackingNumber
+			result.Action = reconcileAction(sellerOrder, state)
+		}
+		if result.Action == FulfillmentActionNone {
+			resp.InSync++
+		} else {
+			resp.OutOfSync++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	if req.Complete {
+		acknowledged, err := s.sellerOrderRepo.FindAcknowledgedOpen(ctx, apiKey.SellerID, fulfillmentReconcileMissing)
+		if err != nil {
+			return nil, internalError("failed to reconcile orders", err)
+		}
+		var missing []model.SellerOrder
+		for _, sellerOrder := range acknowledged {
+			if !listed[sellerOrder.ID] {
+				missing = append(missing, sellerOrder)
+			}
+		}
+		if resp.Missing, err = s.toFulfillmentOrders(ctx, missing); err != nil {
+			return nil, err
+		}
+		resp.OutOfSync += len(resp.Missing)
+	}
+	return resp, nil
+}
+
+// reconcileAction is what the warehouse should do about a sub-order it sees in the given state
+func reconcileAction(sellerOrder *model.SellerOrder, state FulfillmentState) string {
+	switch sellerOrder.Status {
+	case "cancelled":
+		return FulfillmentActionCancel
+	case "paid", "processing":
+		if state.Status == "shipped" {
+			return FulfillmentActionPushShipment
+		}
+		if sellerOrder.FulfillmentAcknowledgedAt == nil {
+			return FulfillmentActionAcknowledge
+		}
+		return FulfillmentActionNone
+	case "shipped", "delivered":
+		if state.Status != "shipped" {
+			return FulfillmentActionAlreadyShipped
+		}
+		trackingNumber := strings.ToUpper(strings.TrimSpace(state.TrackingNumber))
+		if trackingNumber != "" && (sellerOrder.TrackingNumber == nil || *sellerOrder.TrackingNumber != trackingNumber) {
+			return FulfillmentActionCheckTracking
+		}
+		return FulfillmentActionNone
+	default:
+		return FulfillmentActionHold
+	}
+}
+
+func (s *fulfillmentService) HandleCallback(ctx context.Context, keyID string, timestamp string, signature string, body []byte) (*FulfillmentOrder, error) {
+	apiKey, err := s.apiKeyRepo.FindByID(ctx, keyID)
+	if err != nil || apiKey.RevokedAt != nil || apiKey.CallbackSecret == nil {
+		return nil, ErrInvalidCallbackSignature
+	}
+	if !validCallbackSignature(*apiKey.CallbackSecret, timestamp, signature, body, time.Now()) {
+		return nil, ErrInvalidCallbackSignature
+	}
+
+	var callback FulfillmentCallback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return nil, errors.New("invalid callback body")
+	}
+	if callback.SubOrderID == "" {
+		return nil, errors.New("sub_order_id is required")
+	}
+	switch callback.Event {
+	case FulfillmentEventAcknowledged:
+		return s.Acknowledge(ctx, apiKey, callback.SubOrderID, callback.Reference)
+	case FulfillmentEventShipped:
+		return s.PushShipment(ctx, apiKey, callback.SubOrderID, FulfillmentShipmentRequest{
+			Courier:        callback.Courier,
+			TrackingNumber: callback.TrackingNumber,
+		})
+	default:
+		return nil, fmt.Errorf("unknown callback event: %s", callback.Event)
+	}
+}
+
+// validCallbackSignature checks a "sha256=<hex>" signature over timestamp + "." + body and that the
+// timestamp is recent, so a captured callback cannot be replayed later
+func validCallbackSignature(secret, timestamp, signature string, body []byte, now time.Time) bool {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	skew := now.Sub(time.Unix(unix, 0))
+	if skew > fulfillmentCallbackMaxSkew || skew < -fulfillmentCallbackMaxSkew {
+		return false
+	}
+	expected := "sha256=" + signWebhookPayload(secret, timestamp, string(body))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func (s *fulfillmentService) RotateCallbackSecret(ctx context.Context, userID string, keyID string) (string, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return "", errors.New("seller not found")
+	}
+	apiKey, err := s.apiKeyRepo.FindByID(ctx, keyID)
+	if err != nil || apiKey.SellerID != seller.ID {
+		return "", errors.New("API key not found")
+	}
+	if apiKey.RevokedAt != nil {
+		return "", errors.New("API key is revoked")
+	}
+	if apiKey.Sandbox {
+		return "", ErrFulfillmentLiveOnly
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return "", internalError("failed to generate callback secret", err)
+	}
+	apiKey.CallbackSecret = &secret
+	if err := s.apiKeyRepo.Update(ctx, apiKey); err != nil {
+		return "", internalError("failed to save callback secret", err)
+	}
+
+	log.Printf("🔑 Fulfillment callback secret rotated for API key %s", apiKey.KeyPrefix)
+	return secret, nil
+}
+
+// findOwned loads a sub-order of the API key's seller
+func (s *fulfillmentService) findOwned(ctx context.Context, apiKey *model.PartnerAPIKey, sellerOrderID string) (*model.SellerOrder, error) {
+	if apiKey.Sandbox {
+		return nil, ErrFulfillmentLiveOnly
+	}
+	sellerOrder, err := s.sellerOrderRepo.FindByID(ctx, sellerOrderID)
+	if err != nil || sellerOrder.SellerID != apiKey.SellerID {
+		return nil, errors.New("order not found")
+	}
+	return sellerOrder, nil
+}
+
+// parentStatus returns the status of the buyer's order a sub-order belongs to, "" if unknown
+func (s *fulfillmentService) parentStatus(ctx context.Context, sellerOrder *model.SellerOrder) string {
+	order, err := s.orderRepo.FindByID(ctx, sellerOrder.OrderID)
+	if err != nil {
+		return ""
+	}
+	return order.Status
+}
+
+// reloadAndNotify returns the sub-order as saved and runs the status hooks for the sub-order and,
+// when the change rolled up to it, the buyer's order
+func (s *fulfillmentService) reloadAndNotify(ctx context.Context, before *model.SellerOrder, parentFrom string) (*FulfillmentOrder, error) {
+	updated, err := s.sellerOrderRepo.FindByID(ctx, before.ID)
+	if err != nil {
+		return nil, err
+	}
+	if updated.Status != before.Status {
+		s.hooks.RunAfterSellerOrderStatusChange(ctx, updated, before.Status)
+	}
+
+	order, err := s.orderRepo.FindByID(ctx, updated.OrderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+	if parentFrom != "" && order.Status != parentFrom {
+		s.hooks.RunAfterOrderStatusChange(ctx, order, parentFrom)
+	}
+	return s.toFulfillmentOrder(ctx, updated, order)
+}
+
+func (s *fulfillmentService) toFulfillmentOrders(ctx context.Context, sellerOrders []model.SellerOrder) ([]FulfillmentOrder, error) {
+	orders := make([]FulfillmentOrder, 0, len(sellerOrders))
+	for i := range sellerOrders {
+		order, err := s.toFulfillmentOrder(ctx, &sellerOrders[i], nil)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+	return orders, nil
+}
+
+// toFulfillmentOrder builds the warehouse's view of a sub-order loaded with its address and items;
+// the buyer's order is loaded when nil
+func (s *fulfillmentService) toFulfillmentOrder(ctx context.Context, sellerOrder *model.SellerOrder, order *model.Order) (*FulfillmentOrder, error) {
+	if order == nil {
+		var err error
+		if order, err = s.orderRepo.FindByID(ctx, sellerOrder.OrderID); err != nil {
+			return nil, internalError(fmt.Sprintf("failed to load order %s", sellerOrder.SubOrderNumber), err)
+		}
+	}
+
+	fulfillmentOrder := &FulfillmentOrder{
+		SubOrderID:     sellerOrder.ID,
+		SubOrderNumber: sellerOrder.SubOrderNumber,
+		Status:         sellerOrder.Status,
+		Reference:      sellerOrder.FulfillmentReference,
+		AcknowledgedAt: sellerOrder.FulfillmentAcknowledgedAt,
+		Courier:        sellerOrder.Courier,
+		TrackingNumber: sellerOrder.TrackingNumber,
+		IsGift:         order.IsGift,
+		GiftWrap:       order.GiftWrap,
+		GiftMessage:    order.GiftMessage,
+		Recipient:      packingRecipient(sellerOrder, order),
+		Items:          make([]FulfillmentItem, 0, len(sellerOrder.OrderItems)),
+		OrderedAt:      sellerOrder.CreatedAt,
+	}
+	for _, item := range sellerOrder.OrderItems {
+		sku := item.Product.SKU
+		if item.VariantID != nil {
+			if variant, err := s.variantRepo.FindByID(ctx, *item.VariantID); err == nil {
+				sku = variant.SKU
+			}
+		}
+		fulfillmentOrder.Items = append(fulfillmentOrder.Items, FulfillmentItem{
+			OrderItemID: item.ID,
+			SKU:         sku,
+			ProductName: item.ProductName,
+			VariantName: item.VariantName,
+			Quantity:    item.Quantity,
+			Note:        item.Note,
+		})
+	}
+	return fulfillmentOrder, nil
+}
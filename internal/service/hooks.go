@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"yourapp/internal/model"
+)
+
+// BeforeOrderCreateHook runs after an order is priced and before it is saved. Returning an error
+// rejects the order; the error is shown to the buyer.
+type BeforeOrderCreateHook func(ctx context.Context, order *model.Order) error
+
+// BeforePaymentCreateHook runs before a payment is started for an order. Returning an error
+// rejects the payment; the error is shown to the buyer.
+type BeforePaymentCreateHook func(ctx context.Context, order *model.Order) error
+
+// AfterPaymentSuccessHook runs once a paid order has moved to processing. Errors are logged and
+// never undo the payment.
+type AfterPaymentSuccessHook func(ctx context.Context, order *model.Order) error
+
+// AfterOrderStatusChangeHook runs after an order moved from one status to another by a seller,
+// admin or buyer action (e.g. shipped, delivered). Errors are logged only.
+type AfterOrderStatusChangeHook func(ctx context.Context, order *model.Order, from string) error
+
+// AfterSellerOrderStatusChangeHook runs after a seller moved their sub-order to a new status (e.g.
+// processing, or shipped with a tracking number). Errors are logged only.
+type AfterSellerOrderStatusChangeHook func(ctx context.Context, sellerOrder *model.SellerOrder, from string) error
+
+// AfterPaymentStatusChangeHook runs after a payment's status changed (success, expired, failed,
+// cancelled, ...). Errors are logged only.
+type AfterPaymentStatusChangeHook func(ctx context.Context, payment *model.Payment) error
+
+// BeforeProductPublishHook runs before a seller makes a product visible, on create or update.
+// Returning an error keeps the product from being saved. Scheduled publishes are applied in bulk
+// and do not run it.
+type BeforeProductPublishHook func(ctx context.Context, product *model.Product) error
+
+// HookRegistry lets internal modules (loyalty, notifications, analytics, ...) subscribe to order
+// and product lifecycle events without changes to the services that raise them. Register hooks
+// while wiring the app; they run in registration order.
+type HookRegistry struct {
+	mu                   sync.RWMutex
+	beforeOrderCreate    []beforeOrderCreateEntry
+	beforePaymentCreate  []beforePaymentCreateEntry
+	afterPaymentSuccess  []afterPaymentSuccessEntry
+	beforeProductPublish []beforeProductPublishEntry
+	afterOrderStatus     []afterOrderStatusChangeEntry
+	afterSellerOrder     []afterSellerOrderStatusChangeEntry
+	afterPaymentStatus   []afterPaymentStatusChangeEntry
+}
+
+// Registered hooks keep the subscriber's name for logs
+type beforeOrderCreateEntry struct {
+	name string
+	fn   BeforeOrderCreateHook
+}
+
+type beforePaymentCreateEntry struct {
+	name string
+	fn   BeforePaymentCreateHook
+}
+
+type afterPaymentSuccessEntry struct {
+	name string
+	fn   AfterPaymentSuccessHook
+}
+
+type beforeProductPublishEntry struct {
+	name string
+	fn   BeforeProductPublishHook
+}
+
+type afterOrderStatusChangeEntry struct {
+	name string
+	fn   AfterOrderStatusChangeHook
+}
+
+type afterSellerOrderStatusChangeEntry struct {
+	name string
+	fn   AfterSellerOrderStatusChangeHook
+}
+
+type afterPaymentStatusChangeEntry struct {
+	name string
+	fn   AfterPaymentStatusChangeHook
+}
+
+func NewHookRegistry() *HookRegistry {
+	return &HookRegistry{}
+}
+
+// OnBeforeOrderCreate subscribes to orders about to be created
+func (r *HookRegistry) OnBeforeOrderCreate(name string, hook BeforeOrderCreateHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.beforeOrderCreate = append(r.beforeOrderCreate, beforeOrderCreateEntry{name, hook})
+}
+
+// OnBeforePaymentCreate subscribes to payments about to be started
+func (r *HookRegistry) OnBeforePaymentCreate(name string, hook BeforePaymentCreateHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.beforePaymentCreate = append(r.beforePaymentCreate, beforePaymentCreateEntry{name, hook})
+}
+
+// OnAfterPaymentSuccess subscribes to orders whose payment succeeded
+func (r *HookRegistry) OnAfterPaymentSuccess(name string, hook AfterPaymentSuccessHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.afterPaymentSuccess = append(r.afterPaymentSuccess, afterPaymentSuccessEntry{name, hook})
+}
+
+// OnBeforeProductPublish subscribes to products about to become visible
+func (r *HookRegistry) OnBeforeProductPublish(name string, hook BeforeProductPublishHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.beforeProductPublish = append(r.beforeProductPublish, beforeProductPublishEntry{name, hook})
+}
+
+// OnAfterOrderStatusChange subscribes to order status changes
+func (r *HookRegistry) OnAfterOrderStatusChange(name string, hook AfterOrderStatusChangeHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.afterOrderStatus = append(r.afterOrderStatus, afterOrderStatusChangeEntry{name, hook})
+}
+
+// OnAfterSellerOrderStatusChange subscribes to sub-order status changes
+func (r *HookRegistry) OnAfterSellerOrderStatusChange(name string, hook AfterSellerOrderStatusChangeHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.afterSellerOrder = append(r.afterSellerOrder, afterSellerOrderStatusChangeEntry{name, hook})
+}
+
+// OnAfterPaymentStatusChange subscribes to payment status changes
+func (r *HookRegistry) OnAfterPaymentStatusChange(name string, hook AfterPaymentStatusChangeHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.afterPaymentStatus = append(r.afterPaymentStatus, afterPaymentStatusChangeEntry{name, hook})
+}
+
+// RunBeforeOrderCreate runs the hooks until one rejects the order
+func (r *HookRegistry) RunBeforeOrderCreate(ctx context.Context, order *model.Order) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	hooks := r.beforeOrderCreate
+	r.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := runHook(hook.name, func() error { return hook.fn(ctx, order) }); err != nil {
+			log.Printf("🪝 Hook %s rejected order for user %s: %v", hook.name, order.UserID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// RunBeforePaymentCreate runs the hooks until one rejects the payment
+func (r *HookRegistry) RunBeforePaymentCreate(ctx context.Context, order *model.Order) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	hooks := r.beforePaymentCreate
+	r.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := runHook(hook.name, func() error { return hook.fn(ctx, order) }); err != nil {
+			log.Printf("🪝 Hook %s rejected payment of order %s: %v", hook.name, order.OrderNumber, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// RunAfterPaymentSuccess runs every hook; failures are only logged
+func (r *HookRegistry) RunAfterPaymentSuccess(ctx context.Context, order *model.Order) {
+	if r == nil {
+		return
+	}
+	r.mu.RLock()
+	hooks := r.afterPaymentSuccess
+	r.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := runHook(hook.name, func() error { return hook.fn(ctx, order) }); err != nil {
+			log.Printf("⚠️  Hook %s failed for paid order %s: %v", hook.name, order.OrderNumber, err)
+		}
+	}
+}
+
+// RunAfterOrderStatusChange runs every hook; failures are only logged
+func (r *HookRegistry) RunAfterOrderStatusChange(ctx context.Context, order *model.Order, from string) {
+	if r == nil {
+		return
+	}
+	r.mu.RLock()
+	hooks := r.afterOrderStatus
+	r.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := runHook(hook.name, func() error { return hook.fn(ctx, order, from) }); err != nil {
+			log.Printf("⚠️  Hook %s failed for order %s (%s -> %s): %v", hook.name, order.OrderNumber, from, order.Status, err)
+		}
+	}
+}
+
+// RunAfterSellerOrderStatusChange runs every hook; failures are only logged
+func (r *HookRegistry) RunAfterSellerOrderStatusChange(ctx context.Context, sellerOrder *model.SellerOrder, from string) {
+	if r == nil {
+		return
+	}
+	r.mu.RLock()
+	hooks := r.afterSellerOrder
+	r.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := runHook(hook.name, func() error { return hook.fn(ctx, sellerOrder, from) }); err != nil {
+			log.Printf("⚠️  Hook %s failed for sub-order %s (%s -> %s): %v", hook.name, sellerOrder.SubOrderNumber, from, sellerOrder.Status, err)
+		}
+	}
+}
+
+// RunAfterPaymentStatusChange runs every hook; failures are only logged
+func (r *HookRegistry) RunAfterPaymentStatusChange(ctx context.Context, payment *model.Payment) {
+	if r == nil {
+		return
+	}
+	r.mu.RLock()
+	hooks := r.afterPaymentStatus
+	r.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := runHook(hook.name, func() error { return hook.fn(ctx, payment) }); err != nil {
+			log.Printf("⚠️  Hook %s failed for payment %s (%s): %v", hook.name, payment.ID, payment.Status, err)
+		}
+	}
+}
+
+// RunBeforeProductPublish runs the hooks until one rejects the product
+func (r *HookRegistry) RunBeforeProductPublish(ctx context.Context, product *model.Product) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	hooks := r.beforeProductPublish
+	r.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := runHook(hook.name, func() error { return hook.fn(ctx, product) }); err != nil {
+			log.Printf("🪝 Hook %s rejected publishing product %s: %v", hook.name, product.SKU, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// runHook calls a hook, turning a panic into an error so one broken subscriber cannot take down
+// checkout or payment processing
+func runHook(name string, call func() error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("hook %s panicked: %v", name, recovered)
+		}
+	}()
+	return call()
+}
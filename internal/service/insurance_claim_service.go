@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// maxClaimPhotos caps how many evidence photos can be attached to an insurance claim
+const maxClaimPhotos = 5
+
+// ErrInsuranceClaimsDisabled is returned when claims are filed before the feature is launched
+var ErrInsuranceClaimsDisabled = errors.New("insurance claims are not available yet")
+
+// InsuranceClaimService lets the buyer or the seller of an insured order claim a lost or damaged
+// shipment, and an admin approve the claim (paying it out to either side) or reject it
+type InsuranceClaimService interface {
+	FileClaim(ctx context.Context, userID string, req FileInsuranceClaimRequest, photoURLs []string) (*model.InsuranceClaim, error)
+	GetMyClaims(ctx context.Context, userID string, status string, page, limit int) ([]model.InsuranceClaim, int64, error)
+	GetSellerClaims(ctx context.Context, userID string, status string, page, limit int) ([]model.InsuranceClaim, int64, error)
+	// GetClaim returns a claim to the buyer or seller of its order
+	GetClaim(ctx context.Context, userID string, claimID string) (*model.InsuranceClaim, error)
+
+	ListClaims(ctx context.Context, status string, page, limit int) ([]model.InsuranceClaim, int64, error)
+	GetClaimForAdmin(ctx context.Context, claimID string) (*model.InsuranceClaim, error)
+	Decide(ctx context.Context, adminID string, claimID string, req DecideInsuranceClaimRequest) (*model.InsuranceClaim, error)
+}
+
+type insuranceClaimService struct {
+	claimRepo         repository.InsuranceClaimRepository
+	orderRepo         repository.OrderRepository
+	sellerOrderRepo   repository.SellerOrderRepository
+	sellerRepo        repository.SellerRepository
+	shippingLabelRepo repository.ShippingLabelRepository
+	paymentService    PaymentService
+	enabled           bool
+	windowDays        int
+	lostAfterDays     int
+}
+
+// FileInsuranceClaimRequest is the claim form; evidence photos are uploaded alongside as multipart files
+type FileInsuranceClaimRequest struct {
+	SellerOrderID string `form:"seller_order_id" binding:"required"`
+	Type          string `form:"type" binding:"required,oneof=lost damaged"`
+	Description   string `form:"description" binding:"required,max=2000"`
+	ClaimAmount   int    `form:"claim_amount" binding:"min=0"` // Defaults to the sub-order subtotal
+}
+
+type DecideInsuranceClaimRequest struct {
+	Decision     string `json:"decision" binding:"required,oneof=approve reject"`
+	PayoutTo     string `json:"payout_to" binding:"omitempty,oneof=buyer seller"` // Required to approve
+	PayoutAmount int    `json:"payout_amount" binding:"min=0"`                    // Required to approve; at most the sub-order subtotal
+	Note         string `json:"note" binding:"required,max=2000"`
+}
+
+func NewInsuranceClaimService(
+	claimRepo repository.InsuranceClaimRepository,
+	orderRepo repository.OrderRepository,
+	sellerOrderRepo repository.SellerOrderRepository,
+	sellerRepo repository.SellerRepository,
+	shippingLabelRepo repository.ShippingLabelRepository,
+	paymentService PaymentService,
+	cfg *config.Config,
+) InsuranceClaimService {
+	return &insuranceClaimService{
+		claimRepo:         claimRepo,
+		orderRepo:         orderRepo,
+		sellerOrderRepo:   sellerOrderRepo,
+		sellerRepo:        sellerRepo,
+		shippingLabelRepo: shippingLabelRepo,
+		paymentService:    paymentService,
+		enabled:           cfg.InsuranceClaimsEnabled,
+		windowDays:        cfg.InsuranceClaimWindowDays,
+		lostAfterDays:     cfg.InsuranceClaimLostAfterDays,
+	}
+}
+
+// FileClaim claims one shipment of an insured order. A lost claim needs the shipment to have been
+// in transit for a while without being delivered; a damaged claim needs it delivered recently and
+// at least one photo of the damage.
+func (s *insuranceClaimService) FileClaim(ctx context.Context, userID string, req FileInsuranceClaimRequest, photoURLs []string) (*model.InsuranceClaim, error) {
+	if !s.enabled {
+		return nil, ErrInsuranceClaimsDisabled
+	}
+	description := strings.TrimSpace(req.Description)
+	if description == "" {
+		return nil, errors.New("description is required")
+	}
+	if len(photoURLs) > maxClaimPhotos {
+		return nil, fmt.Errorf("at most %d photos can be attached", maxClaimPhotos)
+	}
+
+	sellerOrder, err := s.sellerOrderRepo.FindByID(ctx, req.SellerOrderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+	order, err := s.orderRepo.FindByID(ctx, sellerOrder.OrderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+	role := model.ClaimFiledByBuyer
+	if order.UserID != userID {
+		seller, err := s.sellerRepo.FindByUserID(userID)
+		if err != nil || seller.ID != sellerOrder.SellerID {
+			return nil, errors.New("order not found")
+		}
+		role = model.ClaimFiledBySeller
+	}
+	if order.InsuranceCost <= 0 {
+		return nil, errors.New("this order was not shipped with insurance")
+	}
+
+	switch req.Type {
+	case model.InsuranceClaimLost:
+		if sellerOrder.Status != "shipped" || sellerOrder.ShippedAt == nil {
+			return nil, errors.New("only shipments still in transit can be claimed as lost")
+		}
+		if lostAt := sellerOrder.ShippedAt.Add(time.Duration(s.lostAfterDays) * 24 * time.Hour); time.Now().Before(lostAt) {
+			return nil, fmt.Errorf("the shipment can be claimed as lost from %s", lostAt.Format(time.RFC3339))
+		}
+	case model.InsuranceClaimDamaged:
+		if sellerOrder.Status != "delivered" {
+			return nil, errors.New("only delivered shipments can be claimed as damaged")
+		}
+		if order.DeliveredAt != nil && time.Since(*order.DeliveredAt) > time.Duration(s.windowDays)*24*time.Hour {
+			return nil, fmt.Errorf("damage must be claimed within %d days of delivery", s.windowDays)
+		}
+		if len(photoURLs) == 0 {
+			return nil, errors.New("at least one photo of the damage is required")
+		}
+	}
+
+	amount := req.ClaimAmount
+	if amount == 0 {
+		amount = sellerOrder.Subtotal
+	}
+	if amount > sellerOrder.Subtotal {
+		return nil, fmt.Errorf("claim cannot exceed the insured value of %d", sellerOrder.Subtotal)
+	}
+	if _, err := s.claimRepo.FindActiveBySellerOrderID(ctx, sellerOrder.ID); err == nil {
+		return nil, errors.New("an insurance claim has already been filed for this shipment")
+	}
+
+	claim := &model.InsuranceClaim{
+		OrderID:        order.ID,
+		SellerOrderID:  sellerOrder.ID,
+		UserID:         order.UserID,
+		SellerID:       sellerOrder.SellerID,
+		FiledBy:        userID,
+		FiledByRole:    role,
+		Type:           req.Type,
+		Description:    description,
+		ClaimAmount:    amount,
+		Courier:        sellerOrder.Courier,
+		TrackingNumber: sellerOrder.TrackingNumber,
+		Status:         model.InsuranceClaimSubmitted,
+	}
+	if label, err := s.shippingLabelRepo.FindBySellerOrderID(ctx, sellerOrder.ID); err == nil {
+		claim.ShippingLabelID = &label.ID
+	}
+	for i, url := range photoURLs {
+		claim.Photos = append(claim.Photos, model.InsuranceClaimPhoto{ImageURL: url, SortOrder: i})
+	}
+	if err := s.claimRepo.Create(ctx, claim); err != nil {
+		return nil, errors.New("failed to file insurance claim: " + err.Error())
+	}
+
+	log.Printf("📦 Insurance claim %s (%s) filed for sub-order %s by the %s", claim.ID, claim.Type, sellerOrder.SubOrderNumber, role)
+	return s.claimRepo.FindByID(ctx, claim.ID)
+}
+
+func (s *insuranceClaimService) GetMyClaims(ctx context.Context, userID string, status string, page, limit int) ([]model.InsuranceClaim, int64, error) {
+	claims, total, err := s.claimRepo.FindByUserID(ctx, userID, status, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get insurance claims: " + err.Error())
+	}
+	return claims, total, nil
+}
+
+func (s *insuranceClaimService) GetSellerClaims(ctx context.Context, userID string, status string, page, limit int) ([]model.InsuranceClaim, int64, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, 0, errors.New("seller not found")
+	}
+	claims, total, err := s.claimRepo.FindBySellerID(ctx, seller.ID, status, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get insurance claims: " + err.Error())
+	}
+	return claims, total, nil
+}
+
+func (s *insuranceClaimService) GetClaim(ctx context.Context, userID string, claimID string) (*model.InsuranceClaim, error) {
+	claim, err := s.claimRepo.FindByID(ctx, claimID)
+	if err != nil {
+		return nil, errors.New("insurance claim not found")
+	}
+	if claim.UserID == userID {
+		return claim, nil
+	}
+	if seller, err := s.sellerRepo.FindByUserID(userID); err == nil && seller.ID == claim.SellerID {
+		return claim, nil
+	}
+	return nil, errors.New("insurance claim not found")
+}
+
+func (s *insuranceClaimService) ListClaims(ctx context.Context, status string, page, limit int) ([]model.InsuranceClaim, int64, error) {
+	claims, total, err := s.claimRepo.FindAll(ctx, status, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get insurance claims: " + err.Error())
+	}
+	return claims, total, nil
+}
+
+func (s *insuranceClaimService) GetClaimForAdmin(ctx context.Context, claimID string) (*model.InsuranceClaim, error) {
+	claim, err := s.claimRepo.FindByID(ctx, claimID)
+	if err != nil {
+		return nil, errors.New("insurance claim not found")
+	}
+	return claim, nil
+}
+
+// Decide approves or rejects a submitted claim. An approved claim is paid out to the buyer as a
+// refund of their payment, or to the seller through their settlement for the sub-order. If the
+// refund fails the claim stays submitted and deciding again retries with the same refund key.
+func (s *insuranceClaimService) Decide(ctx context.Context, adminID string, claimID string, req DecideInsuranceClaimRequest) (*model.InsuranceClaim, error) {
+	claim, err := s.GetClaimForAdmin(ctx, claimID)
+	if err != nil {
+		return nil, err
+	}
+	if claim.Status != model.InsuranceClaimSubmitted {
+		return nil, fmt.Errorf("%w: %s", repository.ErrInvalidClaimTransition, claim.Status)
+	}
+
+	updates := map[string]interface{}{
+		"decision_note": req.Note,
+		"decided_by":    adminID,
+		"decided_at":    time.Now(),
+	}
+	if req.Decision == "reject" {
+		if err := s.claimRepo.Decide(ctx, claim.ID, model.InsuranceClaimRejected, updates); err != nil {
+			return nil, s.decisionError(err)
+		}
+		log.Printf("📦 Insurance claim %s rejected by admin %s", claim.ID, adminID)
+		return s.claimRepo.FindByID(ctx, claim.ID)
+	}
+
+	if req.PayoutTo == "" {
+		return nil, errors.New("payout_to is required to approve a claim")
+	}
+	if req.PayoutAmount <= 0 {
+		return nil, errors.New("payout_amount is required to approve a claim")
+	}
+	if req.PayoutAmount > claim.SellerOrder.Subtotal {
+		return nil, fmt.Errorf("payout cannot exceed the insured value of %d", claim.SellerOrder.Subtotal)
+	}
+	updates["payout_to"] = req.PayoutTo
+	updates["payout_amount"] = req.PayoutAmount
+
+	if req.PayoutTo == model.InsurancePayoutSeller {
+		err = s.claimRepo.ApproveToSettlement(ctx, claim.ID, updates)
+	} else {
+		method, refundErr := s.paymentService.RefundPayment(ctx, claim.OrderID, "insurance-claim-"+claim.ID, req.PayoutAmount, "Insurance claim: "+req.Note)
+		if refundErr != nil {
+			return nil, fmt.Errorf("%w: %v", ErrRefundFailed, refundErr)
+		}
+		updates["payout_method"] = method
+		err = s.claimRepo.Decide(ctx, claim.ID, model.InsuranceClaimApproved, updates)
+	}
+	if err != nil {
+		return nil, s.decisionError(err)
+	}
+
+	log.Printf("📦 Insurance claim %s approved by admin %s: %d paid out to the %s", claim.ID, adminID, req.PayoutAmount, req.PayoutTo)
+	return s.claimRepo.FindByID(ctx, claim.ID)
+}
+
+func (s *insuranceClaimService) decisionError(err error) error {
+	if errors.Is(err, repository.ErrInvalidClaimTransition) {
+		return err
+	}
+	return errors.New("failed to update insurance claim: " + err.Error())
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yourapp/internal/model"
+)
+
+// Invoice is the buyer's invoice for an order. Each shop is the party charging PPN on its own
+// goods, so the invoice has one section per seller with that seller's tax registration.
+type Invoice struct {
+	InvoiceNumber string           `json:"invoice_number"`
+	OrderNumber   string           `json:"order_number"`
+	IssuedAt      time.Time        `json:"issued_at"`
+	Buyer         InvoiceBuyer     `json:"buyer"`
+	Sellers       []InvoiceSection `json:"sellers"`
+
+	Subtotal       int  `json:"subtotal"`
+	ShippingCost   int  `json:"shipping_cost"`
+	InsuranceCost  int  `json:"insurance_cost"`
+	WarrantyCost   int  `json:"warranty_cost"`
+	GiftWrapFee    int  `json:"gift_wrap_fee"`
+	ServiceFee     int  `json:"service_fee"`
+	ApplicationFee int  `json:"application_fee"`
+	TotalDiscount  int  `json:"total_discount"`
+	Bonus          int  `json:"bonus"`
+	Donation       int  `json:"donation"`
+	TaxAmount      int  `json:"tax_amount"`
+	TaxInclusive   bool `json:"tax_inclusive"` // Subtotals already contain the tax
+	TotalAmount    int  `json:"total_amount"`
+}
+
+type InvoiceBuyer struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Address string `json:"address"`
+}
+
+// InvoiceSection is the part of the invoice issued by one seller
+type InvoiceSection struct {
+	SubOrderNumber     string        `json:"sub_order_number"`
+	SellerID           string        `json:"seller_id"`
+	ShopName           string        `json:"shop_name"`
+	ShopAddress        *string       `json:"shop_address,omitempty"`
+	IsPKP              bool          `json:"is_pkp"`
+	NPWP               *string       `json:"npwp,omitempty"`
+	Items              []InvoiceItem `json:"items"`
+	Subtotal           int           `json:"subtotal"`
+	ShippingCost       int           `json:"shipping_cost"`
+	TaxName            string        `json:"tax_name,omitempty"`
+	TaxRateBasisPoints int           `json:"tax_rate_basis_points"`
+	TaxableAmount      int           `json:"taxable_amount"` // DPP
+	TaxAmount          int           `json:"tax_amount"`
+	TotalAmount        int           `json:"total_amount"`
+}
+
+type InvoiceItem struct {
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+	Price       int    `json:"price"`
+	Subtotal    int    `json:"subtotal"`
+}
+
+// GetInvoice builds the invoice of a paid order. Tax figures come from the order's tax lines, so
+// the invoice does not change when a seller's registration or the tax rate changes later.
+func (s *orderService) GetInvoice(ctx context.Context, orderID string, userID string) (*Invoice, error) {
+	order, err := s.GetOrderByID(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status == "pending" {
+		return nil, errors.New("invoice is available once the order is paid")
+	}
+
+	invoice := &Invoice{
+		InvoiceNumber: "INV/" + order.OrderNumber,
+		OrderNumber:   order.OrderNumber,
+		IssuedAt:      order.CreatedAt,
+		Buyer: InvoiceBuyer{
+			Name:    order.User.FullName,
+			Email:   order.User.Email,
+			Address: formatInvoiceAddress(order.ShippingAddress),
+		},
+		Subtotal:       order.Subtotal,
+		ShippingCost:   order.ShippingCost,
+		InsuranceCost:  order.InsuranceCost,
+		WarrantyCost:   order.WarrantyCost,
+		GiftWrapFee:    order.GiftWrapFee,
+		ServiceFee:     order.ServiceFee,
+		ApplicationFee: order.ApplicationFee,
+		TotalDiscount:  order.TotalDiscount,
+		Bonus:          order.Bonus,
+		Donation:       order.DonationAmount,
+		TaxAmount:      order.TaxAmount,
+		TotalAmount:    order.TotalAmount,
+		Sellers:        make([]InvoiceSection, 0, len(order.SellerOrders)),
+	}
+	// Issued when the payment settled (or a manual transfer was verified)
+	if order.Payment != nil {
+		if order.Payment.SettlementTime != nil {
+			invoice.IssuedAt = *order.Payment.SettlementTime
+		} else if order.Payment.VerifiedAt != nil {
+			invoice.IssuedAt = *order.Payment.VerifiedAt
+		}
+	}
+
+	taxLines := make(map[string]model.OrderTaxLine, len(order.TaxLines))
+	for _, line := range order.TaxLines {
+		taxLines[line.SellerID] = line
+		invoice.TaxInclusive = line.Inclusive
+	}
+
+	for _, sellerOrder := range order.SellerOrders {
+		section := InvoiceSection{
+			SubOrderNumber: sellerOrder.SubOrderNumber,
+			SellerID:       sellerOrder.SellerID,
+			ShopName:       sellerOrder.Seller.ShopName,
+			ShopAddress:    sellerOrder.Seller.ShopAddress,
+			Subtotal:       sellerOrder.Subtotal,
+			ShippingCost:   sellerOrder.ShippingCost,
+			TotalAmount:    sellerOrder.TotalAmount,
+			TaxableAmount:  sellerOrder.Subtotal,
+		}
+		if line, ok := taxLines[sellerOrder.SellerID]; ok {
+			section.IsPKP = true
+			section.NPWP = line.SellerNPWP
+			section.TaxName = line.Name
+			section.TaxRateBasisPoints = line.RateBasisPoints
+			section.TaxableAmount = line.TaxableAmount
+			section.TaxAmount = line.TaxAmount
+		}
+		for _, item := range order.OrderItems {
+			if item.SellerID != sellerOrder.SellerID {
+				continue
+			}
+			section.Items = append(section.Items, InvoiceItem{
+				ProductName: item.ProductName,
+				Quantity:    item.Quantity,
+				Price:       item.Price,
+				Subtotal:    item.Subtotal,
+			})
+		}
+		invoice.Sellers = append(invoice.Sellers, section)
+	}
+	return invoice, nil
+}
+
+func formatInvoiceAddress(address model.Address) string {
+	line := address.AddressLine1
+	if address.AddressLine2 != nil && *address.AddressLine2 != "" {
+		line += ", " + *address.AddressLine2
+	}
+	return line + ", " + address.City + ", " + address.Province + " " + address.PostalCode
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"yourapp/internal/config"
+)
+
+// MidtransBreaker stops calling Midtrans after repeated failures (network errors, timeouts,
+// 429/5xx), so checkout fails fast with a retry hint instead of waiting on a dead dependency.
+// After the cooldown one trial call is let through; its outcome closes or re-opens the breaker.
+type MidtransBreaker interface {
+	// Allow reserves a call; it returns a *MidtransUnavailableError while the breaker is open
+	Allow() error
+	// Report records the outcome of an allowed call. Calls abandoned by the caller say nothing
+	// about Midtrans and are not reported.
+	Report(failed bool)
+	// RetryAfter is how long until Midtrans is tried again; 0 when calls go through
+	RetryAfter() time.Duration
+	// Stats returns the breaker state and counters
+	Stats() MidtransBreakerStats
+}
+
+// MidtransUnavailableError is returned instead of calling Midtrans while the breaker is open
+type MidtransUnavailableError struct {
+	RetryAfter time.Duration
+}
+
+func (e *MidtransUnavailableError) Error() string {
+	return fmt.Sprintf("payment gateway temporarily unavailable, retry in %d seconds", e.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds rounds up so clients never retry before the breaker lets calls through
+func (e *MidtransUnavailableError) RetryAfterSeconds() int {
+	seconds := int((e.RetryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// Breaker states
+const (
+	MidtransBreakerClosed   = "closed"
+	MidtransBreakerOpen     = "open"
+	MidtransBreakerHalfOpen = "half_open"
+)
+
+// MidtransBreakerStats is a snapshot of the breaker
+type MidtransBreakerStats struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	FailureThreshold    int        `json:"failure_threshold"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	RetryAt             *time.Time `json:"retry_at,omitempty"`
+	TimesOpened         int64      `json:"times_opened"`
+	RejectedCalls       int64      `json:"rejected_calls"` // Calls skipped while open
+}
+
+type midtransBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time // Zero while closed
+	retryAt  time.Time
+	probing  bool      // A trial call is in flight after the cooldown
+	probeAt  time.Time // A trial call that never reports back stops blocking after a cooldown
+	stats    MidtransBreakerStats
+}
+
+func NewMidtransBreaker(cfg *config.Config) MidtransBreaker {
+	cooldown := time.Duration(cfg.MidtransBreakerCooldownSeconds) * time.Second
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	return &midtransBreaker{
+		threshold: cfg.MidtransBreakerFailures,
+		cooldown:  cooldown,
+	}
+}
+
+func (b *midtransBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// A threshold of 0 or less disables the breaker
+	if b.threshold <= 0 || b.openedAt.IsZero() {
+		return nil
+	}
+
+	now := time.Now()
+	probing := b.probing && now.Sub(b.probeAt) < b.cooldown
+	if now.Before(b.retryAt) || probing {
+		b.stats.RejectedCalls++
+		wait := b.retryAt.Sub(now)
+		if probing {
+			wait = b.probeAt.Add(b.cooldown).Sub(now)
+		}
+		return &MidtransUnavailableError{RetryAfter: wait}
+	}
+
+	b.probing = true
+	b.probeAt = now
+	return nil
+}
+
+func (b *midtransBreaker) Report(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 {
+		return
+	}
+
+	if !failed {
+		if !b.openedAt.IsZero() {
+			log.Printf("✅ Midtrans is reachable again, closing the circuit breaker")
+		}
+		b.failures = 0
+		b.openedAt = time.Time{}
+		b.probing = false
+		return
+	}
+
+	b.failures++
+	if b.probing || (b.openedAt.IsZero() && b.failures >= b.threshold) {
+		if b.openedAt.IsZero() {
+			b.openedAt = time.Now()
+			b.stats.TimesOpened++
+			log.Printf("🚨 Midtrans failed %d times in a row, opening the circuit breaker for %s", b.failures, b.cooldown)
+		}
+		b.retryAt = time.Now().Add(b.cooldown)
+		b.probing = false
+	}
+}
+
+func (b *midtransBreaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 || b.openedAt.IsZero() {
+		return 0
+	}
+	if b.probing {
+		return max(time.Until(b.probeAt.Add(b.cooldown)), 0)
+	}
+	return max(time.Until(b.retryAt), 0)
+}
+
+func (b *midtransBreaker) Stats() MidtransBreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := b.stats
+	stats.ConsecutiveFailures = b.failures
+	stats.FailureThreshold = b.threshold
+	switch {
+	case b.openedAt.IsZero():
+		stats.State = MidtransBreakerClosed
+	case b.probing || !time.Now().Before(b.retryAt):
+		stats.State = MidtransBreakerHalfOpen
+	default:
+		stats.State = MidtransBreakerOpen
+	}
+	if !b.openedAt.IsZero() {
+		openedAt, retryAt := b.openedAt, b.retryAt
+		stats.OpenedAt = &openedAt
+		stats.RetryAt = &retryAt
+	}
+	return stats
+}
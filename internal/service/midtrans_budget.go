@@ -0,0 +1,142 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+	"yourapp/internal/config"
+)
+
+// MidtransBudget caps how many Midtrans API calls background jobs make per minute, and backs off
+// when Midtrans signals overload (429 or 5xx) so a recovery storm does not make things worse.
+// Buyer-initiated calls are not budgeted.
+type MidtransBudget interface {
+	// Allow reserves one call for the current minute; false means skip the call for now
+	Allow() bool
+	// Report records the outcome of an allowed call. 429/5xx responses extend the backoff, a
+	// success resets it.
+	Report(err error)
+	// Stats returns the budget consumption counters
+	Stats() MidtransBudgetStats
+}
+
+// MidtransBudgetStats is a snapshot of background Midtrans API budget consumption
+type MidtransBudgetStats struct {
+	LimitPerMinute    int        `json:"limit_per_minute"`
+	UsedThisMinute    int        `json:"used_this_minute"`
+	RemainingMinute   int        `json:"remaining_this_minute"`
+	TotalAllowed      int64      `json:"total_allowed"`
+	TotalBudgetDenied int64      `json:"total_budget_denied"` // Skipped because the minute's budget was used up
+	TotalBackoffSkips int64      `json:"total_backoff_skips"` // Skipped because Midtrans asked us to slow down
+	RateLimited       int64      `json:"rate_limited"`        // 429 responses
+	ServerErrors      int64      `json:"server_errors"`       // 5xx responses
+	BackoffSeconds    int        `json:"backoff_seconds"`     // Current backoff step; 0 when healthy
+	BackoffUntil      *time.Time `json:"backoff_until,omitempty"`
+}
+
+type midtransBudget struct {
+	limit      int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mu           sync.Mutex
+	windowStart  time.Time // Start of the current minute
+	used         int
+	backoff      time.Duration
+	backoffUntil time.Time
+	stats        MidtransBudgetStats
+}
+
+// midtransMinBackoff is the first backoff step after an overload response; it doubles from there
+const midtransMinBackoff = 15 * time.Second
+
+func NewMidtransBudget(cfg *config.Config) MidtransBudget {
+	maxBackoff := time.Duration(cfg.MidtransBudgetMaxBackoffSeconds) * time.Second
+	if maxBackoff < midtransMinBackoff {
+		maxBackoff = midtransMinBackoff
+	}
+	return &midtransBudget{
+		limit:      cfg.MidtransBudgetPerMinute,
+		minBackoff: midtransMinBackoff,
+		maxBackoff: maxBackoff,
+	}
+}
+
+func (b *midtransBudget) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(b.backoffUntil) {
+		b.stats.TotalBackoffSkips++
+		return false
+	}
+
+	if now.Sub(b.windowStart) >= time.Minute {
+		b.windowStart = now.Truncate(time.Minute)
+		b.used = 0
+	}
+	// A limit of 0 or less disables the budget
+	if b.limit > 0 && b.used >= b.limit {
+		b.stats.TotalBudgetDenied++
+		return false
+	}
+
+	b.used++
+	b.stats.TotalAllowed++
+	return true
+}
+
+func (b *midtransBudget) Report(err error) {
+	var apiErr *MidtransAPIError
+	overloaded := errors.As(err, &apiErr) &&
+		(apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !overloaded {
+		// Network errors and 4xx say nothing about Midtrans load; only a success resets the backoff
+		if err == nil {
+			b.backoff = 0
+		}
+		return
+	}
+
+	if apiErr.StatusCode == http.StatusTooManyRequests {
+		b.stats.RateLimited++
+	} else {
+		b.stats.ServerErrors++
+	}
+
+	if b.backoff == 0 {
+		b.backoff = b.minBackoff
+	} else {
+		b.backoff *= 2
+		if b.backoff > b.maxBackoff {
+			b.backoff = b.maxBackoff
+		}
+	}
+	b.backoffUntil = time.Now().Add(b.backoff)
+}
+
+func (b *midtransBudget) Stats() MidtransBudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := b.stats
+	stats.LimitPerMinute = b.limit
+	if time.Since(b.windowStart) < time.Minute {
+		stats.UsedThisMinute = b.used
+	}
+	if b.limit > 0 {
+		stats.RemainingMinute = b.limit - stats.UsedThisMinute
+	}
+	stats.BackoffSeconds = int(b.backoff / time.Second)
+	if time.Now().Before(b.backoffUntil) {
+		until := b.backoffUntil
+		stats.BackoffUntil = &until
+	}
+	return stats
+}
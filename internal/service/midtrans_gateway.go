@@ -0,0 +1,309 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/util"
+)
+
+// MidtransGateway is the HTTP client for the Midtrans Core API
+type MidtransGateway interface {
+	// Charge creates a transaction and returns the parsed response together with the raw body
+	Charge(ctx context.Context, req *MidtransChargeRequest) (*MidtransChargeResponse, []byte, error)
+	// GetStatus fetches the current status of a transaction, returned with the raw body
+	GetStatus(ctx context.Context, transactionID string) (*MidtransNotification, []byte, error)
+	Cancel(ctx context.Context, transactionID string) error
+	// Refund refunds part or all of a settled transaction; refundKey makes retries safe
+	Refund(ctx context.Context, transactionID string, refundKey string, amount int, reason string) error
+}
+
+// MidtransAPIError is returned when Midtrans answers with a non-success HTTP status
+type MidtransAPIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *MidtransAPIError) Error() string {
+	return fmt.Sprintf("Midtrans API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+type midtransGateway struct {
+	serverKey  string
+	httpClient *http.Client // Shared client; deadlines come from the context
+
+	chargeTimeout time.Duration // Charges and refunds
+	statusTimeout time.Duration // Status checks and cancellations
+	metrics       *util.TimeoutMetrics
+	breaker       MidtransBreaker
+}
+
+// Midtrans API request/response structures
+type MidtransChargeRequest struct {
+	PaymentType        string                     `json:"payment_type"`
+	TransactionDetails MidtransTransactionDetails `json:"transaction_details"`
+	CustomerDetails    MidtransCustomerDetails    `json:"customer_details"`
+	ItemDetails        []MidtransItemDetail       `json:"item_details"`
+	BankTransfer       *MidtransBankTransfer      `json:"bank_transfer,omitempty"`
+	Gopay              *MidtransGopay             `json:"gopay,omitempty"`
+	CreditCard         *MidtransCreditCard        `json:"credit_card,omitempty"`
+}
+
+type MidtransTransactionDetails struct {
+	OrderID     string `json:"order_id"`
+	GrossAmount int    `json:"gross_amount"`
+}
+
+type MidtransCustomerDetails struct {
+	FirstName string `json:"first_name"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone,omitempty"`
+}
+
+type MidtransItemDetail struct {
+	ID       string `json:"id"`
+	Price    int    `json:"price"`
+	Quantity int    `json:"quantity"`
+	Name     string `json:"name"`
+	Category string `json:"category,omitempty"`
+}
+
+type MidtransBankTransfer struct {
+	Bank string `json:"bank"`
+}
+
+type MidtransGopay struct {
+	EnableCallback bool   `json:"enable_callback"`
+	CallbackURL    string `json:"callback_url"`
+}
+
+type MidtransCreditCard struct {
+	TokenID        string `json:"token_id,omitempty"`
+	SaveTokenID    bool   `json:"save_token_id,omitempty"`
+	Secure         bool   `json:"secure"`
+	Authentication bool   `json:"authentication"`
+}
+
+type MidtransChargeResponse struct {
+	TransactionID     string             `json:"transaction_id"`
+	OrderID           string             `json:"order_id"`
+	GrossAmount       string             `json:"gross_amount"`
+	PaymentType       string             `json:"payment_type"`
+	TransactionTime   string             `json:"transaction_time"`
+	TransactionStatus string             `json:"transaction_status"`
+	FraudStatus       string             `json:"fraud_status"`
+	StatusMessage     string             `json:"status_message"`
+	VANumbers         []MidtransVANumber `json:"va_numbers,omitempty"`
+	Actions           []MidtransAction   `json:"actions,omitempty"`
+	ExpiryTime        string             `json:"expiry_time,omitempty"`
+	QRCodeURL         string             `json:"qr_code_url,omitempty"`
+	QRString          string             `json:"qr_string,omitempty"`
+
+	// Credit card only
+	MaskedCard            string `json:"masked_card,omitempty"`
+	Bank                  string `json:"bank,omitempty"`
+	CardType              string `json:"card_type,omitempty"`
+	SavedTokenID          string `json:"saved_token_id,omitempty"`
+	SavedTokenIDExpiredAt string `json:"saved_token_id_expired_at,omitempty"`
+}
+
+type MidtransVANumber struct {
+	Bank     string `json:"bank"`
+	VANumber string `json:"va_number"`
+}
+
+type MidtransAction struct {
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// NewMidtransGateway creates the Midtrans client; calls that run out of time are counted in metrics.
+// While breaker is open, calls fail with *MidtransUnavailableError without reaching Midtrans.
+func NewMidtransGateway(cfg *config.Config, metrics *util.TimeoutMetrics, breaker MidtransBreaker) MidtransGateway {
+	return &midtransGateway{
+		serverKey:  cfg.MidtransServerKey,
+		httpClient: &http.Client{},
+
+		chargeTimeout: time.Duration(cfg.MidtransChargeTimeoutSeconds) * time.Second,
+		statusTimeout: time.Duration(cfg.MidtransStatusTimeoutSeconds) * time.Second,
+		metrics:       metrics,
+		breaker:       breaker,
+	}
+}
+
+// baseURL returns Midtrans API base URL based on environment
+func (g *midtransGateway) baseURL() string {
+	// Production keys start with Mid-server, sandbox keys with SB-Mid-server
+	if strings.HasPrefix(g.serverKey, "Mid-server") {
+		return "https://api.midtrans.com/v2"
+	}
+	return "https://api.sandbox.midtrans.com/v2"
+}
+
+// authHeader returns base64 encoded authorization header
+func (g *midtransGateway) authHeader() string {
+	auth := base64.StdEncoding.EncodeToString([]byte(g.serverKey + ":"))
+	return "Basic " + auth
+}
+
+// withTimeout bounds one call; a timeout of 0 (misconfigured) leaves only the caller's deadline
+func (g *midtransGateway) withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// do sends a request to the Midtrans API and returns the HTTP status and response body. op
+// names the call in the timeout metrics.
+func (g *midtransGateway) do(ctx context.Context, op string, method string, path string, payload interface{}) (int, []byte, error) {
+	if err := g.breaker.Allow(); err != nil {
+		return 0, nil, err
+	}
+
+	status, body, err := g.send(ctx, op, method, path, payload)
+	// A caller that gave up says nothing about Midtrans' health
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		g.breaker.Report(err != nil || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError)
+	}
+	return status, body, err
+}
+
+func (g *midtransGateway) send(ctx context.Context, op string, method string, path string, payload interface{}) (int, []byte, error) {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to marshal request: %v", err)
+		}
+		body = bytes.NewBuffer(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.baseURL()+path, body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", g.authHeader())
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			g.metrics.Record("midtrans." + op)
+		}
+		return 0, nil, fmt.Errorf("failed to call Midtrans API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	return resp.StatusCode, respBody, nil
+}
+
+func (g *midtransGateway) Charge(ctx context.Context, req *MidtransChargeRequest) (*MidtransChargeResponse, []byte, error) {
+	chargeCtx, cancel := g.withTimeout(ctx, g.chargeTimeout)
+	defer cancel()
+
+	status, body, err := g.do(chargeCtx, "charge", "POST", "/charge", req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if status != http.StatusOK && status != http.StatusCreated {
+		return nil, body, &MidtransAPIError{StatusCode: status, Body: string(body)}
+	}
+
+	var chargeResp MidtransChargeResponse
+	if err := json.Unmarshal(body, &chargeResp); err != nil {
+		return nil, body, fmt.Errorf("failed to parse response: %v", err)
+	}
+	return &chargeResp, body, nil
+}
+
+func (g *midtransGateway) GetStatus(ctx context.Context, transactionID string) (*MidtransNotification, []byte, error) {
+	statusCtx, cancel := g.withTimeout(ctx, g.statusTimeout)
+	defer cancel()
+
+	status, body, err := g.do(statusCtx, "status", "GET", "/"+transactionID+"/status", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if status != http.StatusOK {
+		log.Printf("⚠️  Midtrans API returned status %d: %s", status, string(body))
+		return nil, body, &MidtransAPIError{StatusCode: status, Body: string(body)}
+	}
+
+	statusResp, err := DecodeMidtransNotification(body)
+	if err != nil {
+		log.Printf("❌ Failed to parse Midtrans response: %v", err)
+		return nil, body, fmt.Errorf("failed to parse response: %v", err)
+	}
+	return statusResp, body, nil
+}
+
+// Cancel calls the Midtrans cancel API for a pending transaction
+func (g *midtransGateway) Cancel(ctx context.Context, transactionID string) error {
+	cancelCtx, cancel := g.withTimeout(ctx, g.statusTimeout)
+	defer cancel()
+
+	status, body, err := g.do(cancelCtx, "cancel", "POST", "/"+transactionID+"/cancel", nil)
+	if err != nil {
+		return err
+	}
+	if message, ok := midtransSucceeded(status, body); !ok {
+		log.Printf("⚠️  Midtrans cancel for transaction %s failed: %s", transactionID, string(body))
+		return fmt.Errorf("failed to cancel payment: %s", message)
+	}
+
+	log.Printf("✅ Midtrans transaction %s cancelled", transactionID)
+	return nil
+}
+
+// Refund calls the Midtrans refund API for a settled transaction
+func (g *midtransGateway) Refund(ctx context.Context, transactionID string, refundKey string, amount int, reason string) error {
+	refundCtx, cancel := g.withTimeout(ctx, g.chargeTimeout)
+	defer cancel()
+
+	status, body, err := g.do(refundCtx, "refund", "POST", "/"+transactionID+"/refund", map[string]interface{}{
+		"refund_key": refundKey,
+		"amount":     amount,
+		"reason":     reason,
+	})
+	if err != nil {
+		return err
+	}
+	if message, ok := midtransSucceeded(status, body); !ok {
+		log.Printf("⚠️  Midtrans refund for transaction %s failed: %s", transactionID, string(body))
+		return fmt.Errorf("failed to refund payment: %s", message)
+	}
+
+	log.Printf("✅ Midtrans transaction %s refunded %d (key: %s)", transactionID, amount, refundKey)
+	return nil
+}
+
+// midtransSucceeded checks a cancel/refund response. Midtrans reports the outcome in the body's
+// status_code, not only the HTTP status. The status message is returned for errors.
+func midtransSucceeded(status int, body []byte) (string, bool) {
+	var result struct {
+		StatusCode    string `json:"status_code"`
+		StatusMessage string `json:"status_message"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "invalid response from Midtrans", false
+	}
+	return result.StatusMessage, status == http.StatusOK && result.StatusCode == "200"
+}
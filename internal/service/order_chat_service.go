@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// maxOrderChatPushRunes is how much of a chat message the push notification shows
+const maxOrderChatPushRunes = 100
+
+// OrderChatService keeps the chat between a buyer and each shop of their order. Every sub-order has
+// its own thread from the moment the order is placed, and status changes (paid, processing,
+// shipped with tracking, delivered, cancelled) post system messages to it so negotiation and
+// fulfillment stay in one place.
+type OrderChatService interface {
+	// GetBuyerThreads returns a thread per shop of the buyer's order and marks them read
+	GetBuyerThreads(ctx context.Context, userID string, orderID string) ([]OrderChatThread, error)
+	BuyerSend(ctx context.Context, userID string, orderID string, sellerOrderID string, body string) (*model.OrderChatMessage, error)
+	// GetSellerThread returns the thread of the seller's sub-order and marks it read
+	GetSellerThread(ctx context.Context, userID string, sellerOrderID string) (*OrderChatThread, error)
+	SellerSend(ctx context.Context, userID string, sellerOrderID string, body string) (*model.OrderChatMessage, error)
+
+	// Hook handlers, registered on the HookRegistry
+	OnPaymentSuccess(ctx context.Context, order *model.Order) error
+	OnOrderStatusChange(ctx context.Context, order *model.Order, from string) error
+	OnSellerOrderStatusChange(ctx context.Context, sellerOrder *model.SellerOrder, from string) error
+}
+
+type OrderChatMessageRequest struct {
+	Message string `json:"message" binding:"required,max=2000"`
+}
+
+// OrderChatThread is the chat of one sub-order. Unread counts the other side's and system messages
+// posted since the caller last opened the thread, before this read.
+type OrderChatThread struct {
+	SellerOrderID  string                   `json:"seller_order_id"`
+	SubOrderNumber string                   `json:"sub_order_number"`
+	SellerID       string                   `json:"seller_id"`
+	ShopName       string                   `json:"shop_name,omitempty"`
+	Status         string                   `json:"status"`
+	Unread         int64                    `json:"unread"`
+	Messages       []model.OrderChatMessage `json:"messages"`
+}
+
+type orderChatService struct {
+	chatRepo        repository.OrderChatRepository
+	orderRepo       repository.OrderRepository
+	sellerOrderRepo repository.SellerOrderRepository
+	sellerRepo      repository.SellerRepository
+	push            PushService
+}
+
+func NewOrderChatService(
+	chatRepo repository.OrderChatRepository,
+	orderRepo repository.OrderRepository,
+	sellerOrderRepo repository.SellerOrderRepository,
+	sellerRepo repository.SellerRepository,
+	push PushService,
+) OrderChatService {
+	return &orderChatService{
+		chatRepo:        chatRepo,
+		orderRepo:       orderRepo,
+		sellerOrderRepo: sellerOrderRepo,
+		sellerRepo:      sellerRepo,
+		push:            push,
+	}
+}
+
+func (s *orderChatService) GetBuyerThreads(ctx context.Context, userID string, orderID string) ([]OrderChatThread, error) {
+	order, err := s.findBuyerOrder(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(order.SellerOrders))
+	for i := range order.SellerOrders {
+		ids[i] = order.SellerOrders[i].ID
+	}
+	unread, err := s.chatRepo.CountUnread(ctx, ids, model.OrderChatRoleBuyer)
+	if err != nil {
+		return nil, errors.New("failed to count unread messages: " + err.Error())
+	}
+
+	threads := make([]OrderChatThread, 0, len(order.SellerOrders))
+	for i := range order.SellerOrders {
+		thread, err := s.loadThread(ctx, &order.SellerOrders[i], model.OrderChatRoleBuyer)
+		if err != nil {
+			return nil, err
+		}
+		thread.ShopName = order.SellerOrders[i].Seller.ShopName
+		thread.Unread = unread[thread.SellerOrderID]
+		threads = append(threads, *thread)
+	}
+	return threads, nil
+}
+
+func (s *orderChatService) BuyerSend(ctx context.Context, userID string, orderID string, sellerOrderID string, body string) (*model.OrderChatMessage, error) {
+	order, err := s.findBuyerOrder(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	var sellerOrder *model.SellerOrder
+	for i := range order.SellerOrders {
+		if order.SellerOrders[i].ID == sellerOrderID {
+			sellerOrder = &order.SellerOrders[i]
+			break
+		}
+	}
+	if sellerOrder == nil {
+		return nil, errors.New("chat not found")
+	}
+
+	message, err := s.send(ctx, sellerOrder, model.OrderChatRoleBuyer, userID, body)
+	if err != nil {
+		return nil, err
+	}
+	if sellerOrder.Seller.UserID != "" {
+		s.notify(sellerOrder.Seller.UserID, sellerOrder, "Pesan baru dari pembeli", message.Body)
+	}
+	return message, nil
+}
+
+func (s *orderChatService) GetSellerThread(ctx context.Context, userID string, sellerOrderID string) (*OrderChatThread, error) {
+	seller, sellerOrder, err := s.findSellerOrder(ctx, userID, sellerOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := s.chatRepo.CountUnread(ctx, []string{sellerOrder.ID}, model.OrderChatRoleSeller)
+	if err != nil {
+		return nil, errors.New("failed to count unread messages: " + err.Error())
+	}
+	thread, err := s.loadThread(ctx, sellerOrder, model.OrderChatRoleSeller)
+	if err != nil {
+		return nil, err
+	}
+	thread.ShopName = seller.ShopName
+	thread.Unread = unread[sellerOrder.ID]
+	return thread, nil
+}
+
+func (s *orderChatService) SellerSend(ctx context.Context, userID string, sellerOrderID string, body string) (*model.OrderChatMessage, error) {
+	seller, sellerOrder, err := s.findSellerOrder(ctx, userID, sellerOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := s.send(ctx, sellerOrder, model.OrderChatRoleSeller, userID, body)
+	if err != nil {
+		return nil, err
+	}
+	s.notify(sellerOrder.UserID, sellerOrder, "Pesan baru dari "+seller.ShopName, message.Body)
+	return message, nil
+}
+
+func (s *orderChatService) OnPaymentSuccess(ctx context.Context, order *model.Order) error {
+	return s.postToOrder(ctx, order, model.OrderChatEventPaid,
+		"Pembayaran pesanan %s sudah diterima. Penjual akan segera memproses pesanan.")
+}
+
+func (s *orderChatService) OnOrderStatusChange(ctx context.Context, order *model.Order, from string) error {
+	switch order.Status {
+	case "delivered":
+		return s.postToOrder(ctx, order, model.OrderChatEventDelivered, "Pesanan %s sudah sampai di tujuan.")
+	case "cancelled":
+		return s.postToOrder(ctx, order, model.OrderChatEventCancelled, "Pesanan %s dibatalkan.")
+	}
+	return nil
+}
+
+func (s *orderChatService) OnSellerOrderStatusChange(ctx context.Context, sellerOrder *model.SellerOrder, from string) error {
+	var event, body string
+	switch sellerOrder.Status {
+	case "processing":
+		event = model.OrderChatEventProcessing
+		body = fmt.Sprintf("Pesanan %s sedang diproses oleh penjual.", sellerOrder.SubOrderNumber)
+	case "shipped":
+		event = model.OrderChatEventShipped
+		body = fmt.Sprintf("Pesanan %s sudah dikirim", sellerOrder.SubOrderNumber)
+		if sellerOrder.Courier != nil && sellerOrder.TrackingNumber != nil {
+			body += fmt.Sprintf(" melalui %s dengan nomor resi %s", strings.ToUpper(*sellerOrder.Courier), *sellerOrder.TrackingNumber)
+		}
+		body += "."
+	default:
+		return nil
+	}
+	return s.chatRepo.CreateSystemMessages(ctx, []model.OrderChatMessage{systemChatMessage(sellerOrder, event, body)})
+}
+
+// postToOrder posts a system message about the order to every shop's thread
+func (s *orderChatService) postToOrder(ctx context.Context, order *model.Order, event string, body string) error {
+	sellerOrders := order.SellerOrders
+	if len(sellerOrders) == 0 {
+		loaded, err := s.orderRepo.FindByID(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		sellerOrders = loaded.SellerOrders
+	}
+
+	messages := make([]model.OrderChatMessage, 0, len(sellerOrders))
+	for i := range sellerOrders {
+		messages = append(messages, systemChatMessage(&sellerOrders[i], event, fmt.Sprintf(body, order.OrderNumber)))
+	}
+	return s.chatRepo.CreateSystemMessages(ctx, messages)
+}
+
+func systemChatMessage(sellerOrder *model.SellerOrder, event string, body string) model.OrderChatMessage {
+	return model.OrderChatMessage{
+		OrderID:       sellerOrder.OrderID,
+		SellerOrderID: sellerOrder.ID,
+		SellerID:      sellerOrder.SellerID,
+		SenderRole:    model.OrderChatRoleSystem,
+		Event:         &event,
+		Body:          body,
+	}
+}
+
+func (s *orderChatService) send(ctx context.Context, sellerOrder *model.SellerOrder, role string, senderID string, body string) (*model.OrderChatMessage, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, errors.New("message is required")
+	}
+
+	message := &model.OrderChatMessage{
+		OrderID:       sellerOrder.OrderID,
+		SellerOrderID: sellerOrder.ID,
+		SellerID:      sellerOrder.SellerID,
+		SenderRole:    role,
+		SenderID:      &senderID,
+		Body:          body,
+	}
+	if err := s.chatRepo.Create(ctx, message); err != nil {
+		return nil, errors.New("failed to send message: " + err.Error())
+	}
+	// Sending implies the sender has seen the thread
+	if err := s.chatRepo.MarkRead(ctx, sellerOrder.ID, role, message.CreatedAt); err != nil {
+		log.Printf("⚠️  Failed to mark chat of sub-order %s read: %v", sellerOrder.SubOrderNumber, err)
+	}
+	return message, nil
+}
+
+// loadThread returns the sub-order's messages and marks them read for the role
+func (s *orderChatService) loadThread(ctx context.Context, sellerOrder *model.SellerOrder, role string) (*OrderChatThread, error) {
+	now := time.Now()
+	messages, err := s.chatRepo.FindBySellerOrderID(ctx, sellerOrder.ID)
+	if err != nil {
+		return nil, errors.New("failed to get messages: " + err.Error())
+	}
+	if err := s.chatRepo.MarkRead(ctx, sellerOrder.ID, role, now); err != nil {
+		log.Printf("⚠️  Failed to mark chat of sub-order %s read: %v", sellerOrder.SubOrderNumber, err)
+	}
+
+	return &OrderChatThread{
+		SellerOrderID:  sellerOrder.ID,
+		SubOrderNumber: sellerOrder.SubOrderNumber,
+		SellerID:       sellerOrder.SellerID,
+		Status:         sellerOrder.Status,
+		Messages:       messages,
+	}, nil
+}
+
+// notify pushes a chat message to the other side of the thread
+func (s *orderChatService) notify(userID string, sellerOrder *model.SellerOrder, title string, body string) {
+	if utf8.RuneCountInString(body) > maxOrderChatPushRunes {
+		body = string([]rune(body)[:maxOrderChatPushRunes]) + "…"
+	}
+	s.push.NotifyUser(userID, PushMessage{
+		Title: title,
+		Body:  body,
+		Data: map[string]string{
+			"event":            PushEventOrderChat,
+			"order_id":         sellerOrder.OrderID,
+			"seller_order_id":  sellerOrder.ID,
+			"sub_order_number": sellerOrder.SubOrderNumber,
+		},
+	})
+}
+
+func (s *orderChatService) findBuyerOrder(ctx context.Context, userID string, orderID string) (*model.Order, error) {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil || order.UserID != userID {
+		return nil, errors.New("order not found")
+	}
+	return order, nil
+}
+
+func (s *orderChatService) findSellerOrder(ctx context.Context, userID string, sellerOrderID string) (*model.Seller, *model.SellerOrder, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, nil, errors.New("seller not found")
+	}
+	sellerOrder, err := s.sellerOrderRepo.FindByID(ctx, sellerOrderID)
+	if err != nil || sellerOrder.SellerID != seller.ID {
+		return nil, nil, errors.New("order not found")
+	}
+	return seller, sellerOrder, nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"yourapp/internal/model"
+)
+
+// Order constraint codes, returned with each violation so clients can show the message next to the
+// offending shop or item
+const (
+	OrderConstraintMarketplaceMinAmount = "marketplace_min_order_amount"
+	OrderConstraintSellerMinAmount      = "seller_min_order_amount"
+	OrderConstraintProductMaxQuantity   = "product_max_quantity"
+)
+
+// OrderConstraintViolation is one order rule a shop or product sets that the order breaks
+type OrderConstraintViolation struct {
+	Code      string `json:"code"`
+	SellerID  string `json:"seller_id,omitempty"`
+	ProductID string `json:"product_id,omitempty"`
+	Limit     int    `json:"limit"`
+	Actual    int    `json:"actual"`
+	Message   string `json:"message"`
+}
+
+// OrderConstraintError is returned when an order (or a cart change) breaks the marketplace or a
+// shop's minimum order amount or a product's per-order quantity limit
+type OrderConstraintError struct {
+	Violations []OrderConstraintViolation
+}
+
+func (e *OrderConstraintError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// checkOrderConstraints checks every product's max quantity, every shop's minimum order amount and
+// the marketplace minimum (0 for none) against a priced order
+func checkOrderConstraints(lines []QuoteLine, quote *OrderQuote, minOrderAmount int) error {
+	var violations []OrderConstraintViolation
+
+	quantities := make(map[string]int)
+	products := make(map[string]*model.Product)
+	var productOrder []string
+	sellers := make(map[string]*model.Seller)
+	for _, line := range lines {
+		if _, ok := products[line.Product.ID]; !ok {
+			products[line.Product.ID] = line.Product
+			productOrder = append(productOrder, line.Product.ID)
+		}
+		quantities[line.Product.ID] += line.Quantity
+		if line.Product.Seller.ID != "" {
+			sellers[line.Product.SellerID] = &line.Product.Seller
+		}
+	}
+	for _, id := range productOrder {
+		if v := maxQuantityViolation(products[id], quantities[id]); v != nil {
+			violations = append(violations, *v)
+		}
+	}
+
+	for _, sellerQuote := range quote.Sellers {
+		seller, ok := sellers[sellerQuote.SellerID]
+		if !ok || seller.MinOrderAmount <= 0 || sellerQuote.Subtotal >= seller.MinOrderAmount {
+			continue
+		}
+		violations = append(violations, OrderConstraintViolation{
+			Code:     OrderConstraintSellerMinAmount,
+			SellerID: seller.ID,
+			Limit:    seller.MinOrderAmount,
+			Actual:   sellerQuote.Subtotal,
+			Message: fmt.Sprintf("%s requires a minimum order of %d (add %d more)",
+				seller.ShopName, seller.MinOrderAmount, seller.MinOrderAmount-sellerQuote.Subtotal),
+		})
+	}
+
+	if minOrderAmount > 0 && quote.Subtotal < minOrderAmount {
+		violations = append(violations, OrderConstraintViolation{
+			Code:    OrderConstraintMarketplaceMinAmount,
+			Limit:   minOrderAmount,
+			Actual:  quote.Subtotal,
+			Message: fmt.Sprintf("orders require a minimum of %d (add %d more)", minOrderAmount, minOrderAmount-quote.Subtotal),
+		})
+	}
+
+	if len(violations) > 0 {
+		return &OrderConstraintError{Violations: violations}
+	}
+	return nil
+}
+
+// checkMaxQuantity checks a single product's per-order limit, e.g. when a cart line changes
+func checkMaxQuantity(product *model.Product, quantity int) error {
+	if v := maxQuantityViolation(product, quantity); v != nil {
+		return &OrderConstraintError{Violations: []OrderConstraintViolation{*v}}
+	}
+	return nil
+}
+
+func maxQuantityViolation(product *model.Product, quantity int) *OrderConstraintViolation {
+	if product.MaxOrderQuantity <= 0 || quantity <= product.MaxOrderQuantity {
+		return nil
+	}
+	return &OrderConstraintViolation{
+		Code:      OrderConstraintProductMaxQuantity,
+		SellerID:  product.SellerID,
+		ProductID: product.ID,
+		Limit:     product.MaxOrderQuantity,
+		Actual:    quantity,
+		Message:   fmt.Sprintf("%s is limited to %d per order", product.Name, product.MaxOrderQuantity),
+	}
+}
@@ -1,93 +1,276 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 	"yourapp/internal/model"
 	"yourapp/internal/repository"
+
+	"github.com/google/uuid"
 )
 
 type OrderService interface {
-	CreateOrder(userID string, req *CreateOrderRequest) (*model.Order, error)
-	GetOrderByID(orderID string, userID string) (*model.Order, error)
-	GetOrdersByUserID(userID string, page, limit int, status, paymentStatus string) ([]model.Order, int64, error)
-	UpdateOrderStatus(orderID string, status string) error
+	CreateOrder(ctx context.Context, userID string, req *CreateOrderRequest) (*model.Order, error)
+	GetOrderByID(ctx context.Context, orderID string, userID string) (*model.Order, error)
+	GetOrdersByUserID(ctx context.Context, userID string, query OrderListQuery, page, limit int) ([]model.Order, int64, error)
+	SearchOrders(ctx context.Context, userID string, keyword string, page, limit int) ([]model.Order, int64, error)
+	UpdateOrderStatus(ctx context.Context, orderID string, status string, change model.StatusChange) error
+	SetRequire3DS(ctx context.Context, orderID string, require3DS *bool) (*model.Order, error)
+	CancelOrder(ctx context.Context, orderID string, userID string, reason string) (*model.Order, error)
+	ConfirmDelivery(ctx context.Context, orderID string, userID string) (*model.Order, error)
+	Reorder(ctx context.Context, orderID string, userID string) (*ReorderResult, error)
+	// ArchiveOrder hides a completed order from the buyer's main history; UnarchiveOrder brings it back
+	ArchiveOrder(ctx context.Context, orderID string, userID string) (*model.Order, error)
+	UnarchiveOrder(ctx context.Context, orderID string, userID string) (*model.Order, error)
+	Checkout(ctx context.Context, userID string, req *CheckoutRequest) (*model.Order, error)
+	PreviewCheckout(ctx context.Context, userID string, req *CheckoutRequest) (*CheckoutPreview, error)
+	GetOrderTimeline(ctx context.Context, orderID string, userID string) (*OrderTimeline, error)
+	GetInvoice(ctx context.Context, orderID string, userID string) (*Invoice, error)
+	GetCartSummary(ctx context.Context, userID string) (*CartSummary, error)
+
+	// Admin order management
+	ListAllOrders(ctx context.Context, query AdminOrderQuery, page, limit int) ([]model.Order, int64, error)
+	GetOrderForAdmin(ctx context.Context, orderID string) (*AdminOrderDetail, error)
+	ForceOrderStatus(ctx context.Context, orderID string, adminID string, status string, reason string) (*model.Order, error)
+	AddOrderNote(ctx context.Context, orderID string, adminID string, note string) (*model.OrderNote, error)
 }
 
 type orderService struct {
-	orderRepo   repository.OrderRepository
-	productRepo repository.ProductRepository
-	addressRepo repository.AddressRepository
+	orderRepo      repository.OrderRepository
+	productRepo    repository.ProductRepository
+	addressRepo    repository.AddressRepository
+	cartRepo       repository.CartRepository
+	paymentService PaymentService
+	calendar       BusinessCalendarService
+	pricing        PricingService
+	analytics      AnalyticsService
+	stock          StockCacheService
+	hooks          *HookRegistry
+	deliverySlots  DeliverySlotService
+	prices         ProductPriceService
+	events         ProductEventService
+	coupons        CouponService
+	donations      DonationService
 }
 
+// CreateOrderRequest creates an order from explicit items. All amounts are computed server-side;
+// the amount fields are optional and, when sent, must match the server's calculation.
 type CreateOrderRequest struct {
 	ShippingAddressID string                   `json:"shipping_address_id"`                  // Optional: will auto-create if not found
 	Items             []CreateOrderItemRequest `json:"order_items" binding:"required,min=1"` // Changed to order_items to match Android
-	Subtotal          int                      `json:"subtotal" binding:"required"`
-	ShippingCost      int                      `json:"shipping_cost"`
-	InsuranceCost     int                      `json:"insurance_cost"`
-	WarrantyCost      int                      `json:"warranty_cost"`
-	ServiceFee        int                      `json:"service_fee"`
-	ApplicationFee    int                      `json:"application_fee"`
-	TotalDiscount     int                      `json:"total_discount"`
-	Bonus             int                      `json:"bonus"`
+	WithInsurance     bool                     `json:"with_insurance"`                       // Also implied by a positive insurance_cost
+	WithWarranty      bool                     `json:"with_warranty"`                        // Also implied by a positive warranty_cost
+	CourierService    string                   `json:"courier_service"`                      // Optional: defaults to regular
+	Subtotal          *int                     `json:"subtotal"`
+	ShippingCost      *int                     `json:"shipping_cost"`
+	InsuranceCost     *int                     `json:"insurance_cost"`
+	WarrantyCost      *int                     `json:"warranty_cost"`
+	GiftWrapFee       *int                     `json:"gift_wrap_fee"`
+	DonationAmount    *int                     `json:"donation_amount"`
+	ServiceFee        *int                     `json:"service_fee"`
+	ApplicationFee    *int                     `json:"application_fee"`
+	TotalDiscount     *int                     `json:"total_discount"`
+	Bonus             *int                     `json:"bonus"`
+	TotalAmount       *int                     `json:"total_amount"`
 	Notes             *string                  `json:"notes,omitempty"`
+	Gift              *GiftOptions             `json:"gift,omitempty"`          // Optional: ship to someone else
+	DeliverySlot      *DeliverySlotSelection   `json:"delivery_slot,omitempty"` // Optional: single-seller orders only
+	GiftWrap          bool                     `json:"gift_wrap"`               // Also implied by a positive gift_wrap_fee
+	GiftMessage       *string                  `json:"gift_message,omitempty" binding:"omitempty,max=500"`
+	Donation          *DonationSelection       `json:"donation,omitempty"` // Optional: to the active donation cause
+}
+
+// CheckoutRequest turns cart items into an order. Prices and totals are computed server-side;
+// shipping_cost and insurance_cost are optional and, when sent, must match.
+type CheckoutRequest struct {
+	CartItemIDs       []string               `json:"cart_item_ids"`       // Optional: defaults to the selected items in the cart
+	ShippingAddressID string                 `json:"shipping_address_id"` // Optional: falls back to the default address
+	WithInsurance     bool                   `json:"with_insurance"`      // Also implied by a positive insurance_cost
+	WithWarranty      bool                   `json:"with_warranty"`
+	CourierService    string                 `json:"courier_service"` // Optional: defaults to regular
+	ShippingCost      *int                   `json:"shipping_cost"`
+	InsuranceCost     *int                   `json:"insurance_cost"`
+	Notes             *string                `json:"notes,omitempty"`
+	Gift              *GiftOptions           `json:"gift,omitempty"`          // Optional: ship to someone else
+	DeliverySlot      *DeliverySlotSelection `json:"delivery_slot,omitempty"` // Optional: single-seller orders only
+	GiftWrap          bool                   `json:"gift_wrap"`
+	GiftMessage       *string                `json:"gift_message,omitempty" binding:"omitempty,max=500"`
+	AffiliateCode     *string                `json:"affiliate_code,omitempty"` // Optional: from the storefront's ?aff= parameter or a short link
+	Donation          *DonationSelection     `json:"donation,omitempty"`       // Optional: to the active donation cause
+
+	QuoteToken string `json:"quote_token,omitempty"` // Optional: from the checkout preview; keeps its prices through a scheduled price change
+}
+
+// OrderTimeline is the buyer-facing progress tracker for an order
+type OrderTimeline struct {
+	OrderID       string                     `json:"order_id"`
+	OrderNumber   string                     `json:"order_number"`
+	CurrentStatus string                     `json:"current_status"`
+	Steps         []OrderTimelineStep        `json:"steps"`
+	History       []model.OrderStatusHistory `json:"history"` // Every transition, oldest first
+}
+
+type OrderTimelineStep struct {
+	Status    string     `json:"status"`
+	Label     string     `json:"label"`
+	Reached   bool       `json:"reached"`
+	ReachedAt *time.Time `json:"reached_at,omitempty"`
+}
+
+// orderProgressSteps are the tracker steps of an order that is not cancelled, in order
+var orderProgressSteps = []struct {
+	status string
+	label  string
+}{
+	{"pending", "Order placed"},
+	{"processing", "Payment confirmed, seller is preparing the order"},
+	{"shipped", "Shipped"},
+	{"delivered", "Delivered"},
+}
+
+// OrderListQuery is the buyer's order history filter as sent by the client; dates are YYYY-MM-DD
+// in the business timezone and both ends are inclusive
+type OrderListQuery struct {
+	Status        string
+	PaymentStatus string
+	Archived      string
+	From          string
+	To            string
+}
+
+// AdminOrderQuery is the admin order list filter as sent by the client; dates are YYYY-MM-DD in
+// the business timezone and both ends are inclusive
+type AdminOrderQuery struct {
+	Status        string
+	PaymentStatus string
+	UserID        string
+	SellerID      string
+	From          string
+	To            string
+	Search        string
+}
+
+// AdminOrderDetail is an order with its full status history and internal notes
+type AdminOrderDetail struct {
+	Order   *model.Order               `json:"order"`
+	History []model.OrderStatusHistory `json:"history"`
+	Notes   []model.OrderNote          `json:"notes"`
+}
+
+// GiftOptions sends the order to a recipient other than the buyer. The recipient's contact is
+// stored on the order and the buyer's prices are hidden from the packing slip.
+type GiftOptions struct {
+	RecipientName  string  `json:"recipient_name" binding:"required,max=255"`
+	RecipientPhone string  `json:"recipient_phone" binding:"required,min=8,max=20"`
+	Message        *string `json:"message,omitempty" binding:"omitempty,max=500"`
+}
+
+// CheckoutPreview shows what checking out the cart would cost and when it would arrive
+type CheckoutPreview struct {
+	Items    []CheckoutPreviewItem `json:"items"`
+	Quote    *OrderQuote           `json:"quote"`
+	Delivery *DeliveryEstimate     `json:"delivery"`
+
+	Violations []OrderConstraintViolation `json:"violations,omitempty"` // Order minimums and quantity limits checkout would reject
+	Voucher    *CartVoucher               `json:"voucher,omitempty"`    // Voucher applied to the cart; its discount is in the quote
+	Donation   *model.DonationCause       `json:"donation,omitempty"`   // Cause the quoted donation goes to
+
+	QuoteToken     string    `json:"quote_token"` // Send back on checkout to keep these prices until quote_expires_at
+	QuoteExpiresAt time.Time `json:"quote_expires_at"`
+}
+
+type CheckoutPreviewItem struct {
+	CartItemID   string `json:"cart_item_id"`
+	ProductID    string `json:"product_id"`
+	ProductName  string `json:"product_name"`
+	SellerID     string `json:"seller_id"`
+	Quantity     int    `json:"quantity"`
+	Price        int    `json:"price"`         // Current product price, or the quoted one it still honors
+	Subtotal     int    `json:"subtotal"`      // Price * quantity
+	PriceChanged bool   `json:"price_changed"` // The cart holds a different price; checkout will ask for review
+	InStock      bool   `json:"in_stock"`
+}
+
+// ReorderResult summarises copying a past order's items back into the cart
+type ReorderResult struct {
+	Added        []ReorderItem `json:"added"`         // Items put in the cart, fewer units than ordered when stock is short
+	OutOfStock   []ReorderItem `json:"out_of_stock"`  // Items that could not be added at all
+	PriceChanged []ReorderItem `json:"price_changed"` // Added items whose current price differs from the order
+	Cart         *model.Cart   `json:"cart"`
+}
+
+type ReorderItem struct {
+	ProductID     string  `json:"product_id"`
+	VariantID     *string `json:"variant_id,omitempty"`
+	ProductName   string  `json:"product_name"`
+	VariantName   *string `json:"variant_name,omitempty"`
+	Quantity      int     `json:"quantity"`       // Units in the original order
+	AddedQuantity int     `json:"added_quantity"` // Units added to the cart
+	OrderPrice    int     `json:"order_price"`
+	CurrentPrice  int     `json:"current_price"`
+	Reason        string  `json:"reason,omitempty"` // Why fewer units than ordered were added
 }
 
 type CreateOrderItemRequest struct {
-	ProductID string `json:"product_id" binding:"required"`
-	Quantity  int    `json:"quantity" binding:"required,min=1"`
-	Price     int    `json:"price"` // Price at checkout time (may include discount)
+	ProductID string  `json:"product_id" binding:"required"`
+	VariantID *string `json:"variant_id"` // Required for a product with variants
+	Quantity  int     `json:"quantity" binding:"required,min=1"`
+	Price     *int    `json:"price"` // Optional: the price the client displayed, must match the current price
 }
 
 func NewOrderService(
 	orderRepo repository.OrderRepository,
 	productRepo repository.ProductRepository,
 	addressRepo repository.AddressRepository,
+	cartRepo repository.CartRepository,
+	paymentService PaymentService,
+	calendar BusinessCalendarService,
+	pricing PricingService,
+	analytics AnalyticsService,
+	stock StockCacheService,
+	hooks *HookRegistry,
+	deliverySlots DeliverySlotService,
+	prices ProductPriceService,
+	events ProductEventService,
+	coupons CouponService,
+	donations DonationService,
 ) OrderService {
 	return &orderService{
-		orderRepo:   orderRepo,
-		productRepo: productRepo,
-		addressRepo: addressRepo,
+		orderRepo:      orderRepo,
+		productRepo:    productRepo,
+		addressRepo:    addressRepo,
+		cartRepo:       cartRepo,
+		paymentService: paymentService,
+		calendar:       calendar,
+		pricing:        pricing,
+		analytics:      analytics,
+		stock:          stock,
+		hooks:          hooks,
+		deliverySlots:  deliverySlots,
+		prices:         prices,
+		events:         events,
+		coupons:        coupons,
+		donations:      donations,
 	}
 }
 
-func (s *orderService) CreateOrder(userID string, req *CreateOrderRequest) (*model.Order, error) {
+func (s *orderService) CreateOrder(ctx context.Context, userID string, req *CreateOrderRequest) (*model.Order, error) {
 	// Validate or auto-create shipping address
-	var address *model.Address
-	var err error
-
-	// If shipping_address_id is provided, try to find it
-	if req.ShippingAddressID != "" && req.ShippingAddressID != "ADDR_1" {
-		address, err = s.addressRepo.FindByID(req.ShippingAddressID)
-		if err != nil {
-			// Address ID not found, auto-create default address
-			address = s.createDefaultAddress(userID)
-			if err := s.addressRepo.Create(address); err != nil {
-				return nil, errors.New("failed to create default address: " + err.Error())
-			}
-		} else if address.UserID != userID {
-			return nil, errors.New("shipping address does not belong to user")
-		}
-		// If address found and belongs to user, use it
-	} else {
-		// No valid shipping_address_id provided, check if user has default address
-		defaultAddr, err := s.addressRepo.FindDefaultByUserID(userID)
-		if err == nil && defaultAddr != nil {
-			address = defaultAddr
-		} else {
-			// No default address found, create one with static data
-			address = s.createDefaultAddress(userID)
-			if err := s.addressRepo.Create(address); err != nil {
-				return nil, errors.New("failed to create default address: " + err.Error())
-			}
-		}
+	address, err := s.resolveShippingAddress(userID, req.ShippingAddressID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Validate products and create order items
+	// Validate products and price every item from the database
 	var orderItems []model.OrderItem
-	var calculatedSubtotal int
+	var lines []QuoteLine
+	var mismatches []PriceMismatch
 
-	for _, item := range req.Items {
+	for i, item := range req.Items {
 		product, err := s.productRepo.FindByID(item.ProductID)
 		if err != nil {
 			return nil, errors.New("product not found: " + item.ProductID)
@@ -95,94 +278,91 @@ func (s *orderService) CreateOrder(userID string, req *CreateOrderRequest) (*mod
 		if !product.IsActive {
 			return nil, errors.New("product is not active: " + item.ProductID)
 		}
-		if product.Stock < item.Quantity {
-			return nil, errors.New("insufficient stock for product: " + product.Name)
+		// A deactivated shop stops selling even though its products stay active
+		if product.Seller.ID == "" || !product.Seller.IsActive {
+			return nil, errors.New("product is no longer available: " + item.ProductID)
 		}
-
-		// Use the price from request (which may already include discount applied on frontend)
-		// But validate it doesn't exceed product price
-		itemPrice := item.Price
-		if itemPrice <= 0 {
-			// If price not provided or invalid, use product price
-			itemPrice = product.Price
-		} else if itemPrice > product.Price {
-			// Safety check: don't allow price higher than product price
-			itemPrice = product.Price
+		variant, err := resolveVariant(product, item.VariantID)
+		if err != nil {
+			return nil, err
 		}
+		if !hasStock(product, variant, item.Quantity) {
+			return nil, fmt.Errorf("%w for product: %s", repository.ErrInsufficientStock, product.Name)
+		}
+		sold := applyVariant(*product, variant)
+		product = &sold
+		mismatches = compareAmount(mismatches, fmt.Sprintf("order_items[%d].price", i), item.Price, product.Price)
 
-		subtotal := itemPrice * item.Quantity
-		calculatedSubtotal += subtotal
-
-		orderItem := model.OrderItem{
+		lines = append(lines, QuoteLine{Product: product, Quantity: item.Quantity})
+		orderItems = append(orderItems, model.OrderItem{
 			ProductID:   product.ID,
+			VariantID:   item.VariantID,
+			VariantName: variantName(variant),
 			SellerID:    product.SellerID,
 			ProductName: product.Name,
 			Quantity:    item.Quantity,
-			Price:       itemPrice,
-			Subtotal:    subtotal,
-		}
-		orderItems = append(orderItems, orderItem)
+			Price:       product.Price,
+			Subtotal:    product.Price * item.Quantity,
+		})
 	}
 
-	// Validate that provided subtotal matches calculated subtotal (allow small difference for rounding)
-	// Use provided subtotal from request (which may include discount already applied)
-	if req.Subtotal < 0 {
-		return nil, errors.New("subtotal cannot be negative")
+	opts := QuoteOptions{
+		WithInsurance: req.WithInsurance || (req.InsuranceCost != nil && *req.InsuranceCost > 0),
+		WithWarranty:  req.WithWarranty || (req.WarrantyCost != nil && *req.WarrantyCost > 0),
+		WithGiftWrap:  req.GiftWrap || (req.GiftWrapFee != nil && *req.GiftWrapFee > 0),
 	}
-
-	// Calculate total amount using provided subtotal from frontend
-	// Total = subtotal + shipping + insurance + warranty + serviceFee + applicationFee - discount - bonus
-	totalAmount := req.Subtotal + req.ShippingCost + req.InsuranceCost + req.WarrantyCost +
-		req.ServiceFee + req.ApplicationFee - req.Bonus - req.TotalDiscount
-
-	if totalAmount < 0 {
-		totalAmount = 0 // Ensure total is not negative
+	cause, err := s.donations.ApplyDonation(ctx, req.Donation, &opts)
+	if err != nil {
+		return nil, err
+	}
+	quote := s.pricing.QuoteOrder(lines, opts)
+	if err := checkOrderConstraints(lines, quote, s.pricing.MinOrderAmount()); err != nil {
+		return nil, err
 	}
 
-	// Create order
-	// Use calculated subtotal from order items (not from request) to ensure consistency
-	// The request subtotal may already include discount, so we use the calculated one
-	finalSubtotal := calculatedSubtotal
-	if calculatedSubtotal == 0 && req.Subtotal > 0 {
-		// Fallback to request subtotal if calculated is 0 (shouldn't happen, but safety check)
-		finalSubtotal = req.Subtotal
+	mismatches = compareAmount(mismatches, "subtotal", req.Subtotal, quote.Subtotal)
+	mismatches = compareAmount(mismatches, "shipping_cost", req.ShippingCost, quote.ShippingCost)
+	mismatches = compareAmount(mismatches, "insurance_cost", req.InsuranceCost, quote.InsuranceCost)
+	mismatches = compareAmount(mismatches, "warranty_cost", req.WarrantyCost, quote.WarrantyCost)
+	mismatches = compareAmount(mismatches, "gift_wrap_fee", req.GiftWrapFee, quote.GiftWrapFee)
+	mismatches = compareAmount(mismatches, "donation_amount", req.DonationAmount, quote.Donation)
+	mismatches = compareAmount(mismatches, "service_fee", req.ServiceFee, quote.ServiceFee)
+	mismatches = compareAmount(mismatches, "application_fee", req.ApplicationFee, quote.ApplicationFee)
+	mismatches = compareAmount(mismatches, "total_discount", req.TotalDiscount, quote.TotalDiscount)
+	mismatches = compareAmount(mismatches, "bonus", req.Bonus, quote.Bonus)
+	mismatches = compareAmount(mismatches, "total_amount", req.TotalAmount, quote.TotalAmount)
+	if len(mismatches) > 0 {
+		return nil, &PriceMismatchError{Mismatches: mismatches}
 	}
 
 	order := &model.Order{
 		UserID:            userID,
 		ShippingAddressID: address.ID,
-		Subtotal:          finalSubtotal, // Use calculated subtotal from items
-		ShippingCost:      req.ShippingCost,
-		InsuranceCost:     req.InsuranceCost,
-		WarrantyCost:      req.WarrantyCost,
-		ServiceFee:        req.ServiceFee,
-		ApplicationFee:    req.ApplicationFee,
-		TotalDiscount:     req.TotalDiscount,
-		Bonus:             req.Bonus,
-		TotalAmount:       totalAmount,
 		Status:            "pending",
 		Notes:             req.Notes,
 		OrderItems:        orderItems,
 	}
-
-	if err := s.orderRepo.Create(order); err != nil {
+	applyQuote(order, quote)
+	applyDonation(order, cause)
+	applyGift(order, req.Gift, req.GiftMessage)
+	if err := s.stampDeliveryEstimate(ctx, order, lines, req.CourierService, address); err != nil {
+		return nil, err
+	}
+	if err := s.applyDeliverySlot(ctx, order, lines, req.DeliverySlot); err != nil {
 		return nil, err
 	}
 
-	// Update product stock
-	for _, item := range req.Items {
-		product, _ := s.productRepo.FindByID(item.ProductID)
-		if product != nil {
-			product.Stock -= item.Quantity
-			s.productRepo.Update(product)
-		}
+	// The checks above only fail fast; placeOrder re-checks stock under row locks
+	if err := s.placeOrder(ctx, order, nil); err != nil {
+		return nil, err
 	}
+	s.analytics.RecordCheckout(ctx, order)
 
 	return order, nil
 }
 
-func (s *orderService) GetOrderByID(orderID string, userID string) (*model.Order, error) {
-	order, err := s.orderRepo.FindByID(orderID)
+func (s *orderService) GetOrderByID(ctx context.Context, orderID string, userID string) (*model.Order, error) {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
 	if err != nil {
 		return nil, errors.New("order not found")
 	}
@@ -192,17 +372,52 @@ func (s *orderService) GetOrderByID(orderID string, userID string) (*model.Order
 	return order, nil
 }
 
-func (s *orderService) GetOrdersByUserID(userID string, page, limit int, status, paymentStatus string) ([]model.Order, int64, error) {
+func (s *orderService) GetOrdersByUserID(ctx context.Context, userID string, query OrderListQuery, page, limit int) ([]model.Order, int64, error) {
+	from, to, err := parseDateRange(query.From, query.To, s.calendar.Location())
+	if err != nil {
+		return nil, 0, err
+	}
 	if page < 1 {
 		page = 1
 	}
 	if limit < 1 {
 		limit = 10
 	}
-	return s.orderRepo.FindByUserID(userID, page, limit, status, paymentStatus)
+
+	filter := repository.UserOrderFilter{
+		Status:        query.Status,
+		PaymentStatus: query.PaymentStatus,
+		Archived:      query.Archived,
+		From:          from,
+		To:            to,
+	}
+	orders, total, err := s.orderRepo.FindByUserID(ctx, userID, filter, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get orders: " + err.Error())
+	}
+	return orders, total, nil
 }
 
-func (s *orderService) UpdateOrderStatus(orderID string, status string) error {
+// SearchOrders finds the user's orders by order number, product name or seller shop name
+func (s *orderService) SearchOrders(ctx context.Context, userID string, keyword string, page, limit int) ([]model.Order, int64, error) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return nil, 0, errors.New("search keyword is required")
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	orders, total, err := s.orderRepo.SearchByUserID(ctx, userID, keyword, page, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search orders: %w", err)
+	}
+	return orders, total, nil
+}
+
+func (s *orderService) UpdateOrderStatus(ctx context.Context, orderID string, status string, change model.StatusChange) error {
 	validStatuses := map[string]bool{
 		"pending":    true,
 		"processing": true,
@@ -213,7 +428,930 @@ func (s *orderService) UpdateOrderStatus(orderID string, status string) error {
 	if !validStatuses[status] {
 		return errors.New("invalid order status")
 	}
-	return s.orderRepo.UpdateStatus(orderID, status)
+	if status == "delivered" {
+		return s.markDelivered(ctx, orderID, change)
+	}
+	return s.orderRepo.UpdateStatus(ctx, orderID, "", status, change)
+}
+
+// markDelivered records the delivery time and whether the promised ETA was met, for seller scorecards
+func (s *orderService) markDelivered(ctx context.Context, orderID string, change model.StatusChange) error {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return errors.New("order not found")
+	}
+
+	deliveredAt := time.Now()
+	var onTime *bool
+	if order.DeliveryETATo != nil {
+		met := !s.calendar.BusinessDate(deliveredAt).After(*order.DeliveryETATo)
+		onTime = &met
+	}
+	return s.orderRepo.MarkDelivered(ctx, orderID, deliveredAt, onTime, change)
+}
+
+// SetRequire3DS sets or clears (nil) the per-order credit card 3DS override
+func (s *orderService) SetRequire3DS(ctx context.Context, orderID string, require3DS *bool) (*model.Order, error) {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+	order.Require3DS = require3DS
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// CancelOrder lets a buyer cancel an unpaid order. The payment is cancelled first (including the
+// Midtrans transaction) so a settled payment blocks the cancellation; stock is then restored.
+func (s *orderService) CancelOrder(ctx context.Context, orderID string, userID string, reason string) (*model.Order, error) {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+	if order.UserID != userID {
+		return nil, errors.New("order does not belong to user")
+	}
+	if order.Status != "pending" {
+		return nil, repository.ErrOrderNotPending
+	}
+
+	if err := s.paymentService.CancelPaymentForOrder(ctx, order.ID); err != nil {
+		return nil, err
+	}
+
+	change := model.StatusChange{ActorType: model.StatusActorBuyer, ActorID: userID, Note: reason}
+	if err := s.orderRepo.CancelAndRestoreStock(ctx, order.ID, reason, time.Now(), change); err != nil {
+		if errors.Is(err, repository.ErrOrderNotPending) {
+			return nil, err
+		}
+		return nil, errors.New("failed to cancel order: " + err.Error())
+	}
+	s.stock.Release(ctx, orderQuantities(order))
+
+	log.Printf("🚫 Order %s cancelled by buyer: %s", order.OrderNumber, reason)
+	return s.orderRepo.FindByID(ctx, order.ID)
+}
+
+// ConfirmDelivery lets the buyer confirm a shipped order has arrived. This finalizes the order and
+// opens the sellers' settlements.
+func (s *orderService) ConfirmDelivery(ctx context.Context, orderID string, userID string) (*model.Order, error) {
+	order, err := s.GetOrderByID(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != "shipped" {
+		return nil, repository.ErrOrderNotShipped
+	}
+
+	change := model.StatusChange{ActorType: model.StatusActorBuyer, ActorID: userID, Note: "Delivery confirmed by buyer"}
+	if err := s.markDelivered(ctx, order.ID, change); err != nil {
+		return nil, errors.New("failed to confirm delivery: " + err.Error())
+	}
+
+	log.Printf("✅ Order %s delivery confirmed by buyer", order.OrderNumber)
+	updated, err := s.orderRepo.FindByID(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.hooks.RunAfterOrderStatusChange(ctx, updated, order.Status)
+	return updated, nil
+}
+
+func (s *orderService) ArchiveOrder(ctx context.Context, orderID string, userID string) (*model.Order, error) {
+	now := time.Now()
+	return s.setArchived(ctx, orderID, userID, &now)
+}
+
+func (s *orderService) UnarchiveOrder(ctx context.Context, orderID string, userID string) (*model.Order, error) {
+	return s.setArchived(ctx, orderID, userID, nil)
+}
+
+func (s *orderService) setArchived(ctx context.Context, orderID string, userID string, archivedAt *time.Time) (*model.Order, error) {
+	order, err := s.GetOrderByID(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.orderRepo.SetArchived(ctx, order.ID, archivedAt); err != nil {
+		if errors.Is(err, repository.ErrOrderNotCompleted) {
+			return nil, err
+		}
+		return nil, errors.New("failed to update order: " + err.Error())
+	}
+	return s.orderRepo.FindByID(ctx, order.ID)
+}
+
+// Reorder copies the items of one of the user's past orders into their cart at current prices.
+// Unavailable products and variants are skipped and quantities are capped at the stock left,
+// counting units of the same product and variant already in the cart.
+func (s *orderService) Reorder(ctx context.Context, orderID string, userID string) (*ReorderResult, error) {
+	order, err := s.GetOrderByID(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := s.cartRepo.GetOrCreateByUserID(userID)
+	if err != nil {
+		return nil, errors.New("failed to get cart: " + err.Error())
+	}
+
+	// Merge order lines of the same product and variant, keeping the order's first-seen sequence
+	var keys []string
+	ordered := make(map[string]*ReorderItem)
+	for _, orderItem := range order.OrderItems {
+		key := orderItem.ProductID
+		if orderItem.VariantID != nil {
+			key += "/" + *orderItem.VariantID
+		}
+		if item, ok := ordered[key]; ok {
+			item.Quantity += orderItem.Quantity
+			continue
+		}
+		keys = append(keys, key)
+		ordered[key] = &ReorderItem{
+			ProductID:   orderItem.ProductID,
+			VariantID:   orderItem.VariantID,
+			ProductName: orderItem.ProductName,
+			VariantName: orderItem.VariantName,
+			Quantity:    orderItem.Quantity,
+			OrderPrice:  orderItem.Price,
+		}
+	}
+
+	result := &ReorderResult{
+		Added:        []ReorderItem{},
+		OutOfStock:   []ReorderItem{},
+		PriceChanged: []ReorderItem{},
+	}
+	for _, key := range keys {
+		item := ordered[key]
+		productID := item.ProductID
+
+		product, err := s.productRepo.FindByID(productID)
+		if err != nil || !product.IsActive || product.Seller.ID == "" || !product.Seller.IsActive {
+			item.Reason = "product is no longer available"
+			result.OutOfStock = append(result.OutOfStock, *item)
+			continue
+		}
+		variant, err := resolveVariant(product, item.VariantID)
+		if err != nil {
+			// The variant was removed, or the product gained variants since the order
+			item.Reason = err.Error()
+			result.OutOfStock = append(result.OutOfStock, *item)
+			continue
+		}
+		price := applyVariant(*product, variant).Price
+		item.ProductName = product.Name
+		item.VariantName = variantName(variant)
+		item.CurrentPrice = price
+
+		inCart := 0
+		existing, err := s.cartRepo.GetCartItemByProductID(cart.ID, productID, item.VariantID)
+		inCartAlready := err == nil
+		if inCartAlready {
+			inCart = existing.Quantity
+		}
+
+		item.AddedQuantity = item.Quantity
+		if room := availableUnits(ctx, s.stock, product, variant) - inCart; room < item.AddedQuantity {
+			item.AddedQuantity = max(room, 0)
+			item.Reason = "insufficient stock"
+		}
+		if limit := product.MaxOrderQuantity; limit > 0 && limit-inCart < item.AddedQuantity {
+			item.AddedQuantity = max(limit-inCart, 0)
+			item.Reason = fmt.Sprintf("limited to %d per order", limit)
+		}
+		if item.AddedQuantity == 0 {
+			result.OutOfStock = append(result.OutOfStock, *item)
+			continue
+		}
+
+		if inCartAlready {
+			existing.Quantity += item.AddedQuantity
+			existing.Price = price // Update price to current price
+			// A stale flag (e.g. the shop was closed and reopened) would keep the item out of checkout
+			existing.UnavailableReason = nil
+			existing.Selected = true
+			err = s.cartRepo.UpdateCartItem(existing)
+		} else {
+			err = s.cartRepo.AddCartItem(&model.CartItem{
+				CartID:    cart.ID,
+				ProductID: productID,
+				VariantID: item.VariantID,
+				Quantity:  item.AddedQuantity,
+				Price:     price,
+				Selected:  true,
+			})
+		}
+		if err != nil {
+			return nil, errors.New("failed to add item to cart: " + err.Error())
+		}
+		s.analytics.RecordAddToCart(ctx, product)
+
+		result.Added = append(result.Added, *item)
+		if item.CurrentPrice != item.OrderPrice {
+			result.PriceChanged = append(result.PriceChanged, *item)
+		}
+	}
+
+	result.Cart, err = s.cartRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, errors.New("failed to get cart: " + err.Error())
+	}
+
+	log.Printf("🔁 Reorder of %s added %d of %d product(s) to the cart of user %s", order.OrderNumber, len(result.Added), len(keys), userID)
+	return result, nil
+}
+
+// Checkout creates an order from the user's cart. Items are priced from the current product data;
+// if a price changed since the item was added, the cart is refreshed and checkout is rejected so
+// the buyer can review it. Stock is reserved and the purchased items leave the cart atomically.
+func (s *orderService) Checkout(ctx context.Context, userID string, req *CheckoutRequest) (*model.Order, error) {
+	cart, selected, err := s.selectCartItems(userID, req.CartItemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderItems []model.OrderItem
+	var lines []QuoteLine
+	var cartItemIDs []string
+	var changedPrices []string
+	priceQuote := s.prices.ValidQuote(userID, req.QuoteToken)
+
+	for _, item := range selected {
+		product := cartItemProduct(item)
+		if product.ID == "" || !product.IsActive || product.Seller.ID == "" || !product.Seller.IsActive {
+			return nil, errors.New("product is no longer available: " + item.ProductID)
+		}
+		if !hasStock(&item.Product, item.Variant, item.Quantity) {
+			return nil, fmt.Errorf("%w for product: %s", repository.ErrInsufficientStock, product.Name)
+		}
+		// Quotes lock product prices, not the price override of a variant
+		if quoted, ok := s.prices.QuotedPrice(ctx, priceQuote, &product); ok && quoted == item.Price && !overridesPrice(item.Variant) {
+			// The buyer was quoted this price before a scheduled increase; honor it until the quote expires
+			product.Price = quoted
+		}
+		if item.Price != product.Price {
+			item.Price = product.Price
+			item.Product = model.Product{}
+			item.Variant = nil
+			if err := s.cartRepo.UpdateCartItem(&item); err != nil {
+				log.Printf("⚠️  Failed to refresh cart item price %s: %v", item.ID, err)
+			}
+			changedPrices = append(changedPrices, product.Name)
+			continue
+		}
+
+		cartItemIDs = append(cartItemIDs, item.ID)
+		lines = append(lines, QuoteLine{Product: &product, Quantity: item.Quantity})
+		orderItems = append(orderItems, model.OrderItem{
+			ProductID:   product.ID,
+			VariantID:   item.VariantID,
+			VariantName: variantName(item.Variant),
+			SellerID:    product.SellerID,
+			ProductName: product.Name,
+			Quantity:    item.Quantity,
+			Price:       product.Price,
+			Subtotal:    product.Price * item.Quantity,
+			Note:        item.Note,
+		})
+	}
+
+	if len(changedPrices) > 0 {
+		return nil, errors.New("prices have changed for: " + strings.Join(changedPrices, ", ") + ". Please review your cart")
+	}
+
+	address, err := s.resolveShippingAddress(userID, req.ShippingAddressID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := QuoteOptions{
+		WithInsurance: req.WithInsurance || (req.InsuranceCost != nil && *req.InsuranceCost > 0),
+		WithWarranty:  req.WithWarranty,
+		WithGiftWrap:  req.GiftWrap,
+	}
+	cause, err := s.donations.ApplyDonation(ctx, req.Donation, &opts)
+	if err != nil {
+		return nil, err
+	}
+	// A voucher that no longer fits the cart rejects checkout so the buyer can review it
+	quote, coupon, err := s.quoteCart(ctx, userID, cart, lines, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOrderConstraints(lines, quote, s.pricing.MinOrderAmount()); err != nil {
+		return nil, err
+	}
+	var mismatches []PriceMismatch
+	mismatches = compareAmount(mismatches, "shipping_cost", req.ShippingCost, quote.ShippingCost)
+	mismatches = compareAmount(mismatches, "insurance_cost", req.InsuranceCost, quote.InsuranceCost)
+	if len(mismatches) > 0 {
+		return nil, &PriceMismatchError{Mismatches: mismatches}
+	}
+
+	order := &model.Order{
+		ID:                uuid.New().String(), // Known up front so the voucher can be redeemed for it
+		UserID:            userID,
+		ShippingAddressID: address.ID,
+		Status:            "pending",
+		Notes:             req.Notes,
+		AffiliateCode:     req.AffiliateCode,
+		OrderItems:        orderItems,
+	}
+	applyQuote(order, quote)
+	applyDonation(order, cause)
+	applyGift(order, req.Gift, req.GiftMessage)
+	if err := s.stampDeliveryEstimate(ctx, order, lines, req.CourierService, address); err != nil {
+		return nil, err
+	}
+	if err := s.applyDeliverySlot(ctx, order, lines, req.DeliverySlot); err != nil {
+		return nil, err
+	}
+
+	if coupon != nil {
+		order.CouponCode = &coupon.Code
+		if err := s.coupons.Redeem(ctx, coupon, userID, order.ID, quote.TotalDiscount); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.placeOrder(ctx, order, cartItemIDs); err != nil {
+		if coupon != nil {
+			s.coupons.Release(ctx, order.ID)
+		}
+		return nil, err
+	}
+	if coupon != nil {
+		if err := s.cartRepo.SetCouponCode(cart.ID, nil); err != nil {
+			log.Printf("⚠️  Failed to remove redeemed voucher from cart %s: %v", cart.ID, err)
+		}
+	}
+	s.analytics.RecordCheckout(ctx, order)
+
+	log.Printf("🛒 Checkout created order %s with %d item(s) for user %s", order.OrderNumber, len(orderItems), userID)
+	return s.orderRepo.FindByID(ctx, order.ID)
+}
+
+// placeOrder admits the order against the stock counters, then creates it and decrements stock
+// in one database transaction (products are locked and stock is re-checked there). The
+// admission is given back if the order cannot be created.
+func (s *orderService) placeOrder(ctx context.Context, order *model.Order, cartItemIDs []string) error {
+	if err := s.hooks.RunBeforeOrderCreate(ctx, order); err != nil {
+		return err
+	}
+
+	quantities := orderQuantities(order)
+	if err := s.stock.Admit(ctx, quantities); err != nil {
+		return err
+	}
+	if err := s.orderRepo.CreateAndReserveStock(ctx, order, cartItemIDs); err != nil {
+		s.stock.Release(ctx, quantities)
+		return err
+	}
+	if len(cartItemIDs) > 0 {
+		s.cartRepo.Invalidate(order.UserID) // The ordered items left the cart in the same transaction
+	}
+	productIDs := make([]string, 0, len(quantities))
+	for productID := range quantities {
+		productIDs = append(productIDs, productID)
+	}
+	s.events.Publish(ctx, productIDs...)
+	return nil
+}
+
+// orderQuantities sums the ordered units per product
+func orderQuantities(order *model.Order) map[string]int {
+	quantities := make(map[string]int)
+	for _, item := range order.OrderItems {
+		quantities[item.ProductID] += item.Quantity
+	}
+	return quantities
+}
+
+// applyDonation points the order's donation at its cause; a round-up of an already round total
+// donates nothing
+func applyDonation(order *model.Order, cause *model.DonationCause) {
+	if cause != nil && order.DonationAmount > 0 {
+		order.DonationCauseID = &cause.ID
+	}
+}
+
+// applyQuote copies server-computed amounts onto the order and splits it into one sub-order per
+// seller. UserID and ShippingAddressID must already be set.
+func applyQuote(order *model.Order, quote *OrderQuote) {
+	order.Subtotal = quote.Subtotal
+	order.ShippingCost = quote.ShippingCost
+	order.InsuranceCost = quote.InsuranceCost
+	order.WarrantyCost = quote.WarrantyCost
+	order.GiftWrapFee = quote.GiftWrapFee
+	order.GiftWrap = quote.GiftWrapFee > 0
+	order.ServiceFee = quote.ServiceFee
+	order.ApplicationFee = quote.ApplicationFee
+	order.TotalDiscount = quote.TotalDiscount
+	order.Bonus = quote.Bonus
+	order.DonationAmount = quote.Donation
+	order.TotalAmount = quote.TotalAmount
+	order.TaxAmount = quote.TaxAmount
+
+	order.SellerOrders = make([]model.SellerOrder, 0, len(quote.Sellers))
+	order.TaxLines = nil
+	for _, seller := range quote.Sellers {
+		total := seller.Subtotal + seller.ShippingCost
+		if !quote.TaxInclusive {
+			total += seller.TaxAmount
+		}
+		order.SellerOrders = append(order.SellerOrders, model.SellerOrder{
+			SellerID:          seller.SellerID,
+			UserID:            order.UserID,
+			ShippingAddressID: order.ShippingAddressID,
+			Subtotal:          seller.Subtotal,
+			ShippingCost:      seller.ShippingCost,
+			TotalAmount:       total,
+			TaxAmount:         seller.TaxAmount,
+			Status:            order.Status,
+		})
+		if seller.TaxRateBasisPoints > 0 {
+			order.TaxLines = append(order.TaxLines, model.OrderTaxLine{
+				SellerID:        seller.SellerID,
+				Name:            model.TaxNamePPN,
+				RateBasisPoints: seller.TaxRateBasisPoints,
+				Inclusive:       quote.TaxInclusive,
+				TaxableAmount:   seller.TaxableAmount,
+				TaxAmount:       seller.TaxAmount,
+				SellerNPWP:      seller.SellerNPWP,
+			})
+		}
+	}
+}
+
+// GetOrderTimeline returns the order's progress steps and full status history
+func (s *orderService) GetOrderTimeline(ctx context.Context, orderID string, userID string) (*OrderTimeline, error) {
+	order, err := s.GetOrderByID(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.orderRepo.FindStatusHistory(ctx, order.ID)
+	if err != nil {
+		return nil, errors.New("failed to get order history: " + err.Error())
+	}
+
+	// First time the whole order reached each status (sub-order rows are detail only)
+	reachedAt := map[string]time.Time{"pending": order.CreatedAt}
+	for _, entry := range history {
+		if entry.SellerOrderID != nil {
+			continue
+		}
+		if _, ok := reachedAt[entry.ToStatus]; !ok {
+			reachedAt[entry.ToStatus] = entry.CreatedAt
+		}
+	}
+
+	current := -1
+	for i, step := range orderProgressSteps {
+		if step.status == order.Status {
+			current = i
+		}
+	}
+
+	timeline := &OrderTimeline{
+		OrderID:       order.ID,
+		OrderNumber:   order.OrderNumber,
+		CurrentStatus: order.Status,
+		History:       history,
+	}
+	for i, step := range orderProgressSteps {
+		entry := OrderTimelineStep{Status: step.status, Label: step.label}
+		if at, ok := reachedAt[step.status]; ok {
+			entry.Reached = true
+			entry.ReachedAt = &at
+		} else if i <= current {
+			// Orders from before the history existed only know their current status
+			entry.Reached = true
+		}
+		if order.Status == "cancelled" && !entry.Reached {
+			continue
+		}
+		timeline.Steps = append(timeline.Steps, entry)
+	}
+	if order.Status == "cancelled" {
+		entry := OrderTimelineStep{Status: "cancelled", Label: "Cancelled", Reached: true, ReachedAt: order.CancelledAt}
+		if at, ok := reachedAt["cancelled"]; ok {
+			entry.ReachedAt = &at
+		}
+		timeline.Steps = append(timeline.Steps, entry)
+	}
+	return timeline, nil
+}
+
+// ListAllOrders lists orders across all users for admins
+func (s *orderService) ListAllOrders(ctx context.Context, query AdminOrderQuery, page, limit int) ([]model.Order, int64, error) {
+	from, to, err := parseDateRange(query.From, query.To, s.calendar.Location())
+	if err != nil {
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	filter := repository.OrderFilter{
+		Status:        query.Status,
+		PaymentStatus: query.PaymentStatus,
+		UserID:        query.UserID,
+		SellerID:      query.SellerID,
+		From:          from,
+		To:            to,
+		Search:        strings.TrimSpace(query.Search),
+	}
+	orders, total, err := s.orderRepo.FindAll(ctx, filter, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get orders: " + err.Error())
+	}
+	return orders, total, nil
+}
+
+// GetOrderForAdmin returns any order with its status history and internal notes
+func (s *orderService) GetOrderForAdmin(ctx context.Context, orderID string) (*AdminOrderDetail, error) {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+	history, err := s.orderRepo.FindStatusHistory(ctx, order.ID)
+	if err != nil {
+		return nil, errors.New("failed to get order history: " + err.Error())
+	}
+	notes, err := s.orderRepo.FindNotes(ctx, order.ID)
+	if err != nil {
+		return nil, errors.New("failed to get order notes: " + err.Error())
+	}
+	return &AdminOrderDetail{Order: order, History: history, Notes: notes}, nil
+}
+
+// ForceOrderStatus moves an order to any status regardless of the normal flow, for support
+// cases. Cancelling a pending order also returns its items to stock.
+func (s *orderService) ForceOrderStatus(ctx context.Context, orderID string, adminID string, status string, reason string) (*model.Order, error) {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+	if order.Status == status {
+		return nil, errors.New("order is already " + status)
+	}
+
+	change := model.StatusChange{ActorType: model.StatusActorAdmin, ActorID: adminID, Note: reason}
+	if status == "cancelled" && order.Status == "pending" {
+		if err = s.orderRepo.CancelAndRestoreStock(ctx, order.ID, reason, time.Now(), change); err == nil {
+			s.stock.Release(ctx, orderQuantities(order))
+		}
+	} else {
+		err = s.UpdateOrderStatus(ctx, order.ID, status, change)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotPending) || err.Error() == "invalid order status" {
+			return nil, err
+		}
+		return nil, errors.New("failed to update order status: " + err.Error())
+	}
+
+	log.Printf("🛠️  Order %s forced from %s to %s by admin %s: %s", order.OrderNumber, order.Status, status, adminID, reason)
+	updated, err := s.orderRepo.FindByID(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.hooks.RunAfterOrderStatusChange(ctx, updated, order.Status)
+	return updated, nil
+}
+
+// AddOrderNote attaches an internal note to an order
+func (s *orderService) AddOrderNote(ctx context.Context, orderID string, adminID string, note string) (*model.OrderNote, error) {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+	orderNote := &model.OrderNote{
+		OrderID:  order.ID,
+		AuthorID: adminID,
+		Note:     strings.TrimSpace(note),
+	}
+	if orderNote.Note == "" {
+		return nil, errors.New("note is required")
+	}
+	if err := s.orderRepo.AddNote(ctx, orderNote); err != nil {
+		return nil, errors.New("failed to add order note: " + err.Error())
+	}
+	return orderNote, nil
+}
+
+// PreviewCheckout prices the selected cart items server-side and estimates the delivery date,
+// without reserving stock or creating an order
+func (s *orderService) PreviewCheckout(ctx context.Context, userID string, req *CheckoutRequest) (*CheckoutPreview, error) {
+	cart, selected, err := s.selectCartItems(userID, req.CartItemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]CheckoutPreviewItem, 0, len(selected))
+	var lines []QuoteLine
+	priceQuote := s.prices.ValidQuote(userID, req.QuoteToken)
+	quotedPrices := make(map[string]int, len(selected))
+	for _, item := range selected {
+		product := cartItemProduct(item)
+		if product.ID == "" || !product.IsActive || product.Seller.ID == "" || !product.Seller.IsActive {
+			return nil, errors.New("product is no longer available: " + item.ProductID)
+		}
+		// Quotes lock product prices, not the price override of a variant
+		if !overridesPrice(item.Variant) {
+			if quoted, ok := s.prices.QuotedPrice(ctx, priceQuote, &product); ok && quoted == item.Price {
+				product.Price = quoted
+			}
+			quotedPrices[product.ID] = product.Price
+		}
+		lines = append(lines, QuoteLine{Product: &product, Quantity: item.Quantity})
+		items = append(items, CheckoutPreviewItem{
+			CartItemID:   item.ID,
+			ProductID:    product.ID,
+			ProductName:  product.Name,
+			SellerID:     product.SellerID,
+			Quantity:     item.Quantity,
+			Price:        product.Price,
+			Subtotal:     product.Price * item.Quantity,
+			PriceChanged: item.Price != product.Price,
+			InStock:      hasStock(&item.Product, item.Variant, item.Quantity),
+		})
+	}
+
+	opts := QuoteOptions{
+		WithInsurance: req.WithInsurance || (req.InsuranceCost != nil && *req.InsuranceCost > 0),
+		WithWarranty:  req.WithWarranty,
+		WithGiftWrap:  req.GiftWrap,
+	}
+	cause, err := s.donations.ApplyDonation(ctx, req.Donation, &opts)
+	if err != nil {
+		return nil, err
+	}
+	quote, _, voucherErr := s.quoteCart(ctx, userID, cart, lines, opts)
+	delivery, err := s.calendar.EstimateDelivery(ctx, time.Now(), handlingDaysFor(lines), req.CourierService, deliveryRouteFor(lines, s.previewDestination(userID, req.ShippingAddressID)))
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &CheckoutPreview{
+		Items:    items,
+		Quote:    quote,
+		Delivery: delivery,
+		Voucher:  cartVoucher(cart, quote, voucherErr),
+	}
+	if quote.Donation > 0 {
+		preview.Donation = cause
+	}
+	var constraints *OrderConstraintError
+	if errors.As(checkOrderConstraints(lines, quote, s.pricing.MinOrderAmount()), &constraints) {
+		preview.Violations = constraints.Violations
+	}
+
+	// A still-valid quote is handed back as is, so previewing again never extends a price lock
+	if priceQuote != nil {
+		preview.QuoteToken = req.QuoteToken
+		preview.QuoteExpiresAt = priceQuote.ExpiresAt.Time
+	} else {
+		preview.QuoteToken, preview.QuoteExpiresAt, err = s.prices.IssueQuote(userID, quotedPrices)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return preview, nil
+}
+
+// quoteCart prices the lines with the voucher applied to the cart, if any. A voucher that no
+// longer fits is left out of the quote and returned as the error (a *CouponError when rejected).
+func (s *orderService) quoteCart(ctx context.Context, userID string, cart *model.Cart, lines []QuoteLine, opts QuoteOptions) (*OrderQuote, *model.Coupon, error) {
+	quote := s.pricing.QuoteOrder(lines, opts)
+	if cart.CouponCode == nil {
+		return quote, nil, nil
+	}
+	coupon, err := s.coupons.Validate(ctx, userID, *cart.CouponCode, quote)
+	if err != nil {
+		return quote, nil, err
+	}
+	opts.Coupon = coupon
+	return s.pricing.QuoteOrder(lines, opts), coupon, nil
+}
+
+// cartVoucher describes the cart's voucher for a quote from quoteCart, nil when there is none
+func cartVoucher(cart *model.Cart, quote *OrderQuote, err error) *CartVoucher {
+	if cart.CouponCode == nil {
+		return nil
+	}
+	voucher := &CartVoucher{Code: *cart.CouponCode, Applied: err == nil, Discount: quote.TotalDiscount}
+	if err != nil {
+		voucher.Error = err.Error()
+		var couponErr *CouponError
+		if errors.As(err, &couponErr) {
+			voucher.ErrorCode = couponErr.Code
+		}
+	}
+	return voucher
+}
+
+// selectCartItems returns the user's cart and its selected items, or exactly cartItemIDs when given
+func (s *orderService) selectCartItems(userID string, cartItemIDs []string) (*model.Cart, []model.CartItem, error) {
+	cart, err := s.cartRepo.GetByUserID(userID)
+	if err != nil || len(cart.CartItems) == 0 {
+		return nil, nil, errors.New("cart is empty")
+	}
+	if len(cartItemIDs) == 0 {
+		// Everything selected that can still be bought; flagged and deselected items stay in the
+		// cart for the buyer to review
+		available := make([]model.CartItem, 0, len(cart.CartItems))
+		anySelected := false
+		for _, item := range cart.CartItems {
+			if !item.Selected {
+				continue
+			}
+			anySelected = true
+			if item.UnavailableReason == nil {
+				available = append(available, item)
+			}
+		}
+		if !anySelected {
+			return nil, nil, errors.New("no items in the cart are selected")
+		}
+		if len(available) == 0 {
+			return nil, nil, errors.New("no items in the cart are available")
+		}
+		return cart, available, nil
+	}
+
+	byID := make(map[string]model.CartItem, len(cart.CartItems))
+	for _, item := range cart.CartItems {
+		byID[item.ID] = item
+	}
+	selected := make([]model.CartItem, 0, len(cartItemIDs))
+	for _, id := range uniqueStrings(cartItemIDs) {
+		item, ok := byID[id]
+		if !ok {
+			return nil, nil, errors.New("cart item not found: " + id)
+		}
+		if item.UnavailableReason != nil {
+			return nil, nil, errors.New("product is no longer available: " + item.ProductID)
+		}
+		selected = append(selected, item)
+	}
+	return cart, selected, nil
+}
+
+// applyGift marks the order as a gift and stores the recipient's contact. A gift message can also
+// come without a recipient (e.g. for gift-wrapped items sent to the buyer); the recipient's own
+// message wins.
+func applyGift(order *model.Order, gift *GiftOptions, message *string) {
+	if message != nil && strings.TrimSpace(*message) != "" {
+		trimmed := strings.TrimSpace(*message)
+		order.GiftMessage = &trimmed
+	}
+	if gift == nil {
+		return
+	}
+	name := strings.TrimSpace(gift.RecipientName)
+	phone := strings.TrimSpace(gift.RecipientPhone)
+	order.IsGift = true
+	order.RecipientName = &name
+	order.RecipientPhone = &phone
+	if gift.Message != nil {
+		order.GiftMessage = gift.Message
+	}
+}
+
+// stampDeliveryEstimate records when the fulfilment clock starts, the estimated ship date and the
+// promised delivery range, based on the marketplace cutoff time, holidays calendar, the slowest
+// seller's handling time and the courier service. Only an unsupported courier service is an
+// error; other failures just skip the stamp.
+func (s *orderService) stampDeliveryEstimate(ctx context.Context, order *model.Order, lines []QuoteLine, courierService string, address *model.Address) error {
+	estimate, err := s.calendar.EstimateDelivery(ctx, time.Now(), handlingDaysFor(lines), courierService, deliveryRouteFor(lines, address))
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedCourierService) {
+			return err
+		}
+		log.Printf("⚠️  Failed to compute delivery estimate: %v", err)
+		return nil
+	}
+
+	// Date-only columns: pin to UTC midnight so the database does not shift the day
+	shipDate, _ := time.Parse("2006-01-02", estimate.EstimatedShipDate)
+	etaFrom, _ := time.Parse("2006-01-02", estimate.EarliestDelivery)
+	etaTo, _ := time.Parse("2006-01-02", estimate.LatestDelivery)
+	order.SLAStartAt = &estimate.SLAStartsAt
+	order.EstimatedShipDate = &shipDate
+	order.CourierService = estimate.CourierService
+	order.DeliveryETAFrom = &etaFrom
+	order.DeliveryETATo = &etaTo
+	return nil
+}
+
+// applyDeliverySlot books the buyer's delivery slot pick, if any. Slots belong to a seller, so
+// they can only be picked when the whole order ships from one seller, and not before the
+// earliest estimated delivery date.
+func (s *orderService) applyDeliverySlot(ctx context.Context, order *model.Order, lines []QuoteLine, selection *DeliverySlotSelection) error {
+	if selection == nil {
+		return nil
+	}
+	sellerID := ""
+	for _, line := range lines {
+		if sellerID != "" && line.Product.SellerID != sellerID {
+			return errors.New("delivery slots can only be picked for orders from a single seller")
+		}
+		sellerID = line.Product.SellerID
+	}
+
+	location := s.calendar.Location()
+	earliest := time.Now().In(location).AddDate(0, 0, 1)
+	if order.DeliveryETAFrom != nil {
+		from := *order.DeliveryETAFrom
+		earliest = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, location)
+	}
+	return s.deliverySlots.ApplySelection(ctx, order, sellerID, selection, earliest)
+}
+
+// handlingDaysFor returns the longest handling time among the sellers of the given lines
+func handlingDaysFor(lines []QuoteLine) int {
+	days := 0
+	for _, line := range lines {
+		sellerDays := 1 // Column default, for sellers that were not loaded
+		if line.Product.Seller.ID != "" {
+			sellerDays = line.Product.Seller.HandlingDays
+		}
+		if sellerDays > days {
+			days = sellerDays
+		}
+	}
+	return days
+}
+
+// deliveryRouteFor collects the shop cities of the ordered products and the destination city
+func deliveryRouteFor(lines []QuoteLine, address *model.Address) DeliveryRoute {
+	var route DeliveryRoute
+	if address != nil {
+		route.DestinationCity = address.City
+	}
+	for _, line := range lines {
+		if line.Product.Seller.ShopCity != nil {
+			route.OriginCities = append(route.OriginCities, *line.Product.Seller.ShopCity)
+		}
+	}
+	return route
+}
+
+// previewDestination looks up the address a checkout preview ships to without creating a default
+// address the way checkout does; nil means unknown
+func (s *orderService) previewDestination(userID string, addressID string) *model.Address {
+	if addressID != "" {
+		if address, err := s.addressRepo.FindByID(addressID); err == nil && address.UserID == userID {
+			return address
+		}
+		return nil
+	}
+	if address, err := s.addressRepo.FindDefaultByUserID(userID); err == nil {
+		return address
+	}
+	return nil
+}
+
+// resolveShippingAddress returns the requested address, falling back to the user's default
+// address (auto-created with static data if the user has none)
+func (s *orderService) resolveShippingAddress(userID string, addressID string) (*model.Address, error) {
+	var address *model.Address
+	var err error
+
+	// If shipping_address_id is provided, try to find it
+	if addressID != "" && addressID != "ADDR_1" {
+		address, err = s.addressRepo.FindByID(addressID)
+		if err != nil {
+			// Address ID not found, auto-create default address
+			address = s.createDefaultAddress(userID)
+			if err := s.addressRepo.Create(address); err != nil {
+				return nil, errors.New("failed to create default address: " + err.Error())
+			}
+		} else if address.UserID != userID {
+			return nil, errors.New("shipping address does not belong to user")
+		}
+		// If address found and belongs to user, use it
+	} else {
+		// No valid shipping_address_id provided, check if user has default address
+		defaultAddr, err := s.addressRepo.FindDefaultByUserID(userID)
+		if err == nil && defaultAddr != nil {
+			address = defaultAddr
+		} else {
+			// No default address found, create one with static data
+			address = s.createDefaultAddress(userID)
+			if err := s.addressRepo.Create(address); err != nil {
+				return nil, errors.New("failed to create default address: " + err.Error())
+			}
+		}
+	}
+
+	return address, nil
 }
 
 // createDefaultAddress creates a default static address for a user
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"yourapp/internal/util"
+)
+
+// RenderPackingSlipPDF lays the packing slip out on A4 pages. Gift slips carry no prices, just
+// like the JSON version.
+func RenderPackingSlipPDF(slip *PackingSlip) ([]byte, error) {
+	const (
+		margin     = 40.0
+		pageBottom = util.PDFPageA4Height - 60
+	)
+	doc := util.NewPDFDocument(util.PDFPageA4Width, util.PDFPageA4Height)
+	right := doc.Width() - margin
+	contentWidth := right - margin
+
+	doc.Text(margin, 60, 18, true, "PACKING SLIP")
+	doc.Text(margin, 80, 11, false, slip.ShopName)
+	doc.Text(right-200, 60, 10, true, slip.SubOrderNumber)
+	doc.Text(right-200, 76, 9, false, "Ordered "+slip.OrderedAt.Format("02 Jan 2006 15:04"))
+	doc.Line(margin, 92, right, 92, 1)
+
+	y := 116.0
+	doc.Text(margin, y, 9, true, "SHIP TO")
+	y += 16
+	recipient := slip.Recipient
+	doc.Text(margin, y, 11, true, recipient.Name)
+	y += 15
+	doc.Text(margin, y, 10, false, recipient.Phone)
+	y += 14
+	address := recipient.AddressLine1
+	if recipient.AddressLine2 != nil && *recipient.AddressLine2 != "" {
+		address += ", " + *recipient.AddressLine2
+	}
+	y = doc.WrapText(margin, y, 10, false, contentWidth/2, address)
+	doc.Text(margin, y, 10, false, recipient.City+", "+recipient.Province+" "+recipient.PostalCode)
+	y += 24
+
+	if slip.IsGift {
+		label := "GIFT ORDER"
+		if slip.GiftWrap {
+			label += " - please gift wrap"
+		}
+		doc.Text(margin, y, 10, true, label)
+		y += 16
+		if slip.GiftMessage != nil && *slip.GiftMessage != "" {
+			y = doc.WrapText(margin, y, 10, false, contentWidth, "\""+*slip.GiftMessage+"\"")
+		}
+		y += 8
+	}
+
+	// Item table; prices only when the slip has them
+	priceColumns := !slip.IsGift
+	header := func() {
+		doc.Line(margin, y, right, y, 0.5)
+		y += 14
+		doc.Text(margin, y, 9, true, "ITEM")
+		doc.Text(right-230, y, 9, true, "QTY")
+		if priceColumns {
+			doc.Text(right-170, y, 9, true, "PRICE")
+			doc.Text(right-80, y, 9, true, "SUBTOTAL")
+		}
+		y += 8
+		doc.Line(margin, y, right, y, 0.5)
+		y += 16
+	}
+	header()
+	for _, item := range slip.Items {
+		lines := util.WrapPDFText(item.ProductName, 10, right-240-margin)
+		if y+float64(len(lines))*13 > pageBottom {
+			doc.AddPage()
+			y = 60
+			header()
+		}
+		doc.Text(right-230, y, 10, false, strconv.Itoa(item.Quantity))
+		if priceColumns && item.Price != nil && item.Subtotal != nil {
+			doc.Text(right-170, y, 10, false, formatRupiah(*item.Price))
+			doc.Text(right-80, y, 10, false, formatRupiah(*item.Subtotal))
+		}
+		for _, line := range lines {
+			doc.Text(margin, y, 10, false, line)
+			y += 13
+		}
+		y += 4
+	}
+	doc.Line(margin, y, right, y, 0.5)
+	y += 18
+	if slip.Subtotal != nil {
+		doc.Text(right-170, y, 10, true, "Subtotal")
+		doc.Text(right-80, y, 10, true, formatRupiah(*slip.Subtotal))
+	}
+
+	return doc.Bytes()
+}
+
+// formatRupiah formats an IDR amount with dot thousand separators, e.g. Rp 150.000
+func formatRupiah(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := strconv.Itoa(amount)
+	var b strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(digit)
+	}
+	return fmt.Sprintf("%sRp %s", sign, b.String())
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Partner inventory sync limits
+const (
+	partnerAPIKeyPrefix          = "pk_"
+	partnerSandboxKeyPrefix      = "pk_test_" // Sandbox keys live in their own key space
+	partnerInventoryDefaultLimit = 100
+	partnerInventoryMaxLimit     = 500
+	nilUUID                      = "00000000-0000-0000-0000-000000000000"
+)
+
+// ErrInvalidAPIKey is returned when a partner API key is unknown or revoked
+var ErrInvalidAPIKey = errors.New("invalid or revoked API key")
+
+type PartnerService interface {
+	// CreateAPIKey creates a live key, or a sandbox key with a freshly seeded sandbox catalog
+	CreateAPIKey(ctx context.Context, userID string, name string, sandbox bool) (*CreatePartnerAPIKeyResponse, error)
+	GetAPIKeys(ctx context.Context, userID string) ([]model.PartnerAPIKey, error)
+	RevokeAPIKey(ctx context.Context, userID string, keyID string) error
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (*model.PartnerAPIKey, error)
+	GetInventoryChanges(ctx context.Context, sellerID string, cursor string, limit int) (*InventoryChangesResponse, error)
+	AdjustStock(ctx context.Context, apiKey *model.PartnerAPIKey, req BulkStockAdjustmentRequest) (*BulkStockAdjustmentResponse, error)
+}
+
+type partnerService struct {
+	apiKeyRepo    repository.PartnerAPIKeyRepository
+	inventoryRepo repository.InventoryRepository
+	sellerRepo    repository.SellerRepository
+	productRepo   repository.ProductRepository
+	stock         StockCacheService
+	sandbox       SandboxService
+}
+
+// CreatePartnerAPIKeyResponse contains the plaintext key, which is only returned once
+type CreatePartnerAPIKeyResponse struct {
+	APIKey *model.PartnerAPIKey `json:"api_key"`
+	Key    string               `json:"key"`
+}
+
+// InventoryChange is the state of a product after its most recent change
+type InventoryChange struct {
+	ProductID string    `json:"product_id"`
+	SKU       string    `json:"sku"`
+	Name      string    `json:"name"`
+	Price     int       `json:"price"`
+	Stock     int       `json:"stock"`
+	IsActive  bool      `json:"is_active"`
+	Deleted   bool      `json:"deleted"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type InventoryChangesResponse struct {
+	Changes    []InventoryChange `json:"changes"`
+	NextCursor string            `json:"next_cursor"` // Pass back as ?cursor= to continue; unchanged when there are no new changes
+	HasMore    bool              `json:"has_more"`
+}
+
+type BulkStockAdjustmentRequest struct {
+	Adjustments []StockAdjustmentItem `json:"adjustments" binding:"required,min=1,max=500,dive"`
+}
+
+// StockAdjustmentItem changes the stock of one product. Exactly one of Delta or SetStock must be set.
+type StockAdjustmentItem struct {
+	Reference string  `json:"reference" binding:"required,max=100"` // Partner-side unique ID; retries with the same reference are ignored
+	SKU       string  `json:"sku" binding:"required"`
+	Delta     *int    `json:"delta,omitempty"`
+	SetStock  *int    `json:"set_stock,omitempty" binding:"omitempty,min=0"`
+	Reason    *string `json:"reason,omitempty"`
+}
+
+// StockAdjustmentResult is the outcome of a single adjustment: "applied", "duplicate" or "failed"
+type StockAdjustmentResult struct {
+	Reference  string                 `json:"reference"`
+	Status     string                 `json:"status"`
+	Adjustment *model.StockAdjustment `json:"adjustment,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+type BulkStockAdjustmentResponse struct {
+	Results   []StockAdjustmentResult `json:"results"`
+	Applied   int                     `json:"applied"`
+	Duplicate int                     `json:"duplicate"`
+	Failed    int                     `json:"failed"`
+}
+
+func NewPartnerService(
+	apiKeyRepo repository.PartnerAPIKeyRepository,
+	inventoryRepo repository.InventoryRepository,
+	sellerRepo repository.SellerRepository,
+	productRepo repository.ProductRepository,
+	stock StockCacheService,
+	sandbox SandboxService,
+) PartnerService {
+	return &partnerService{
+		apiKeyRepo:    apiKeyRepo,
+		inventoryRepo: inventoryRepo,
+		sellerRepo:    sellerRepo,
+		productRepo:   productRepo,
+		stock:         stock,
+		sandbox:       sandbox,
+	}
+}
+
+func (s *partnerService) CreateAPIKey(ctx context.Context, userID string, name string, sandbox bool) (*CreatePartnerAPIKeyResponse, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found. Please create a shop first")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	prefix := partnerAPIKeyPrefix
+	if sandbox {
+		prefix = partnerSandboxKeyPrefix
+	}
+	rawKey := prefix + hex.EncodeToString(secret)
+
+	apiKey := &model.PartnerAPIKey{
+		SellerID:  seller.ID,
+		Name:      name,
+		KeyPrefix: rawKey[:len(prefix)+8],
+		KeyHash:   hashAPIKey(rawKey),
+		Sandbox:   sandbox,
+	}
+	if err := s.apiKeyRepo.Create(ctx, apiKey); err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+	if sandbox {
+		if err := s.sandbox.Seed(ctx, apiKey); err != nil {
+			// The key still works; POST /partner/sandbox/reset seeds the catalog again
+			log.Printf("⚠️  %v for API key %s", err, apiKey.KeyPrefix)
+		}
+	}
+
+	log.Printf("🔑 Partner API key %s created for seller %s", apiKey.KeyPrefix, seller.ID)
+	return &CreatePartnerAPIKeyResponse{APIKey: apiKey, Key: rawKey}, nil
+}
+
+func (s *partnerService) GetAPIKeys(ctx context.Context, userID string) ([]model.PartnerAPIKey, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	return s.apiKeyRepo.FindBySellerID(ctx, seller.ID)
+}
+
+func (s *partnerService) RevokeAPIKey(ctx context.Context, userID string, keyID string) error {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return errors.New("seller not found")
+	}
+
+	apiKey, err := s.apiKeyRepo.FindByID(ctx, keyID)
+	if err != nil || apiKey.SellerID != seller.ID {
+		return errors.New("API key not found")
+	}
+	if apiKey.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	apiKey.RevokedAt = &now
+	return s.apiKeyRepo.Update(ctx, apiKey)
+}
+
+func (s *partnerService) AuthenticateAPIKey(ctx context.Context, rawKey string) (*model.PartnerAPIKey, error) {
+	if !strings.HasPrefix(rawKey, partnerAPIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.apiKeyRepo.FindActiveByHash(ctx, hashAPIKey(rawKey))
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err := s.apiKeyRepo.TouchLastUsed(ctx, apiKey.ID, time.Now()); err != nil {
+		log.Printf("⚠️  Failed to update last_used_at for API key %s: %v", apiKey.ID, err)
+	}
+	return apiKey, nil
+}
+
+// GetInventoryChanges returns products changed after the cursor, oldest first. An empty cursor
+// starts from the beginning, which doubles as a full snapshot.
+func (s *partnerService) GetInventoryChanges(ctx context.Context, sellerID string, cursor string, limit int) (*InventoryChangesResponse, error) {
+	if limit < 1 {
+		limit = partnerInventoryDefaultLimit
+	}
+	if limit > partnerInventoryMaxLimit {
+		limit = partnerInventoryMaxLimit
+	}
+
+	since, afterID, err := decodeInventoryCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra row to know whether another page follows
+	products, err := s.inventoryRepo.FindProductChangesSince(ctx, sellerID, since, afterID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory changes: %w", err)
+	}
+
+	hasMore := len(products) > limit
+	if hasMore {
+		products = products[:limit]
+	}
+
+	changes := make([]InventoryChange, 0, len(products))
+	for _, product := range products {
+		changedAt := product.UpdatedAt
+		if product.DeletedAt.Valid && product.DeletedAt.Time.After(changedAt) {
+			changedAt = product.DeletedAt.Time
+		}
+		changes = append(changes, InventoryChange{
+			ProductID: product.ID,
+			SKU:       product.SKU,
+			Name:      product.Name,
+			Price:     product.Price,
+			Stock:     product.Stock,
+			IsActive:  product.IsActive,
+			Deleted:   product.DeletedAt.Valid,
+			ChangedAt: changedAt,
+		})
+	}
+
+	nextCursor := cursor
+	if len(changes) > 0 {
+		last := changes[len(changes)-1]
+		nextCursor = encodeInventoryCursor(last.ChangedAt, last.ProductID)
+	}
+
+	return &InventoryChangesResponse{
+		Changes:    changes,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}, nil
+}
+
+// AdjustStock applies each adjustment independently; one failure does not roll back the others.
+func (s *partnerService) AdjustStock(ctx context.Context, apiKey *model.PartnerAPIKey, req BulkStockAdjustmentRequest) (*BulkStockAdjustmentResponse, error) {
+	resp := &BulkStockAdjustmentResponse{Results: make([]StockAdjustmentResult, 0, len(req.Adjustments))}
+
+	for _, item := range req.Adjustments {
+		result := s.applyAdjustment(ctx, apiKey, item)
+		switch result.Status {
+		case "applied":
+			resp.Applied++
+		case "duplicate":
+			resp.Duplicate++
+		default:
+			resp.Failed++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	log.Printf("📦 Partner stock sync for seller %s: %d applied, %d duplicate, %d failed",
+		apiKey.SellerID, resp.Applied, resp.Duplicate, resp.Failed)
+	return resp, nil
+}
+
+func (s *partnerService) applyAdjustment(ctx context.Context, apiKey *model.PartnerAPIKey, item StockAdjustmentItem) StockAdjustmentResult {
+	result := StockAdjustmentResult{Reference: item.Reference}
+
+	if existing, err := s.inventoryRepo.FindAdjustmentByReference(ctx, apiKey.SellerID, item.Reference); err == nil {
+		result.Status = "duplicate"
+		result.Adjustment = existing
+		return result
+	}
+
+	if (item.Delta == nil) == (item.SetStock == nil) {
+		result.Status = "failed"
+		result.Error = "exactly one of delta or set_stock is required"
+		return result
+	}
+
+	product, err := s.productRepo.FindBySKU(item.SKU)
+	if err != nil || product.SellerID != apiKey.SellerID {
+		result.Status = "failed"
+		result.Error = "product not found: " + item.SKU
+		return result
+	}
+
+	adjustment := &model.StockAdjustment{
+		SellerID:  apiKey.SellerID,
+		Reference: item.Reference,
+		ProductID: product.ID,
+		SKU:       product.SKU,
+		Source:    "pos",
+		Reason:    item.Reason,
+		APIKeyID:  &apiKey.ID,
+	}
+	if item.Delta != nil {
+		adjustment.Delta = *item.Delta
+	}
+
+	if err := s.inventoryRepo.ApplyStockAdjustment(ctx, adjustment, item.SetStock); err != nil {
+		// A concurrent request with the same reference may have won the unique index
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate") {
+			if existing, findErr := s.inventoryRepo.FindAdjustmentByReference(ctx, apiKey.SellerID, item.Reference); findErr == nil {
+				result.Status = "duplicate"
+				result.Adjustment = existing
+				return result
+			}
+		}
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+
+	s.stock.Adjust(ctx, product.ID, adjustment.Delta)
+	result.Status = "applied"
+	result.Adjustment = adjustment
+	return result
+}
+
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func encodeInventoryCursor(changedAt time.Time, productID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(changedAt.UTC().Format(time.RFC3339Nano) + "|" + productID))
+}
+
+func decodeInventoryCursor(cursor string) (time.Time, string, error) {
+	if cursor == "" {
+		return time.Time{}, nilUUID, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", errors.New("invalid cursor")
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return time.Time{}, "", errors.New("invalid cursor")
+	}
+	changedAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, "", errors.New("invalid cursor")
+	}
+	return changedAt, parts[1], nil
+}
@@ -0,0 +1,559 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+)
+
+// Partner usage counters in Redis: per key and day (drained by the rollup) and per key and month
+// (the quota check, kept in step with the rollups)
+const (
+	partnerUsageKeyPrefix      = "partner_usage:"
+	partnerUsageMonthKeyPrefix = "partner_usage_month:"
+	partnerUsageDayTTL         = 8 * 24 * time.Hour
+	partnerUsageMonthTTL       = 40 * 24 * time.Hour
+	partnerUsageMaxDays        = 92
+)
+
+// ErrPartnerQuotaExceeded is returned when a key on a hard cut-off plan used up its monthly quota
+var ErrPartnerQuotaExceeded = errors.New("monthly API quota exceeded")
+
+// PartnerUsageService meters the partner API per key: requests and bytes transferred are counted
+// in Redis (or straight in Postgres without Redis), rolled up into daily rows, checked against the
+// key's plan and reported for billing. Plans either cut keys off at their quota or let them run
+// into overage; sellers are emailed as usage crosses the alert thresholds.
+type PartnerUsageService interface {
+	// CheckQuota returns the key's quota for the current month. It fails with
+	// ErrPartnerQuotaExceeded (quota still filled in) when a hard cut-off plan is used up.
+	CheckQuota(ctx context.Context, key *model.PartnerAPIKey) (*PartnerQuota, error)
+	// Record counts one request of the key and its body sizes
+	Record(ctx context.Context, key *model.PartnerAPIKey, bytesIn, bytesOut int64)
+	// Rollup moves the Redis counters to Postgres and sends due usage alerts
+	Rollup(ctx context.Context) error
+	GetUsage(ctx context.Context, userID string, keyID string, from, to string) (*PartnerUsageReport, error)
+	GetBillingReport(ctx context.Context, month string) (*PartnerBillingReport, error)
+	SetPlan(ctx context.Context, keyID string, plan string) (*model.PartnerAPIKey, error)
+}
+
+// PartnerPlanLimits is what a plan allows per calendar month (UTC); 0 means unlimited
+type PartnerPlanLimits struct {
+	Plan            string `json:"plan"`
+	MonthlyRequests int64  `json:"monthly_requests"`
+	MonthlyTransfer int64  `json:"monthly_transfer"` // Bytes, request and response bodies together
+	HardCutoff      bool   `json:"hard_cutoff"`      // Refuse requests over quota instead of billing overage
+}
+
+// PartnerQuota is a key's usage of its plan in the current month
+type PartnerQuota struct {
+	PartnerPlanLimits
+	Period       string    `json:"period"` // YYYY-MM
+	RequestsUsed int64     `json:"requests_used"`
+	TransferUsed int64     `json:"transfer_used"`
+	OverQuota    bool      `json:"over_quota"`
+	ResetAt      time.Time `json:"reset_at"`
+	RequestsLeft int64     `json:"requests_left"` // -1 when unlimited
+	TransferLeft int64     `json:"transfer_left"` // -1 when unlimited
+}
+
+// PartnerUsageReport lists a key's usage per day, with the current month's quota
+type PartnerUsageReport struct {
+	APIKeyID string                        `json:"api_key_id"`
+	Plan     string                        `json:"plan"`
+	From     string                        `json:"from"`
+	To       string                        `json:"to"`
+	Totals   repository.PartnerUsageTotals `json:"totals"`
+	Days     []PartnerUsageDay             `json:"days"`
+	Quota    *PartnerQuota                 `json:"quota"`
+}
+
+type PartnerUsageDay struct {
+	Date     string `json:"date"`
+	Requests int64  `json:"requests"`
+	BytesIn  int64  `json:"bytes_in"`
+	BytesOut int64  `json:"bytes_out"`
+}
+
+// PartnerBillingReport lists every key's usage in a month and what it used beyond its plan
+type PartnerBillingReport struct {
+	Period        string               `json:"period"`
+	TotalRequests int64                `json:"total_requests"`
+	TotalTransfer int64                `json:"total_transfer"`
+	Keys          []PartnerBillingLine `json:"keys"`
+}
+
+type PartnerBillingLine struct {
+	repository.PartnerKeyUsage
+	Limits          PartnerPlanLimits `json:"limits"`
+	OverageRequests int64             `json:"overage_requests"`
+	OverageTransfer int64             `json:"overage_transfer"`
+}
+
+type partnerUsageService struct {
+	usageRepo    repository.PartnerUsageRepository
+	apiKeyRepo   repository.PartnerAPIKeyRepository
+	sellerRepo   repository.SellerRepository
+	redis        *util.RedisClient    // Optional; without it every request is written to Postgres
+	rabbitMQ     *util.RabbitMQClient // Optional; used for usage alerts
+	plans        map[string]PartnerPlanLimits
+	alertPercent int
+}
+
+func NewPartnerUsageService(
+	usageRepo repository.PartnerUsageRepository,
+	apiKeyRepo repository.PartnerAPIKeyRepository,
+	sellerRepo repository.SellerRepository,
+	redis *util.RedisClient,
+	rabbitMQ *util.RabbitMQClient,
+	cfg *config.Config,
+	jobs *util.BackgroundJobs,
+) PartnerUsageService {
+	const mb = 1024 * 1024
+	service := &partnerUsageService{
+		usageRepo:    usageRepo,
+		apiKeyRepo:   apiKeyRepo,
+		sellerRepo:   sellerRepo,
+		redis:        redis,
+		rabbitMQ:     rabbitMQ,
+		alertPercent: cfg.PartnerUsageAlertPercent,
+		plans: map[string]PartnerPlanLimits{
+			model.PartnerPlanFree: {
+				Plan:            model.PartnerPlanFree,
+				MonthlyRequests: int64(cfg.PartnerFreeMonthlyRequests),
+				MonthlyTransfer: int64(cfg.PartnerFreeMonthlyTransferMB) * mb,
+				HardCutoff:      true,
+			},
+			model.PartnerPlanPro: {
+				Plan:            model.PartnerPlanPro,
+				MonthlyRequests: int64(cfg.PartnerProMonthlyRequests),
+				MonthlyTransfer: int64(cfg.PartnerProMonthlyTransferMB) * mb,
+			},
+			model.PartnerPlanUnlimited: {Plan: model.PartnerPlanUnlimited},
+		},
+	}
+
+	// Start background job to roll up usage counters and send usage alerts
+	if cfg.PartnerUsageRollupSeconds > 0 {
+		interval := time.Duration(cfg.PartnerUsageRollupSeconds) * time.Second
+		jobs.Every(interval, service.runRollup)
+		log.Printf("✅ Partner API usage rollup started (every %s)", interval)
+	}
+
+	return service
+}
+
+// runRollup rolls up usage counters
+func (s *partnerUsageService) runRollup(ctx context.Context) {
+	if err := s.Rollup(ctx); err != nil {
+		log.Printf("⚠️  Failed to roll up partner API usage: %v", err)
+	}
+}
+
+// limitsFor returns the key's plan, treating unknown plans as the free plan
+func (s *partnerUsageService) limitsFor(plan string) PartnerPlanLimits {
+	if limits, ok := s.plans[plan]; ok {
+		return limits
+	}
+	return s.plans[model.PartnerPlanFree]
+}
+
+func (s *partnerUsageService) CheckQuota(ctx context.Context, key *model.PartnerAPIKey) (*PartnerQuota, error) {
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	used, err := s.monthUsage(ctx, key.ID, monthStart, utcDate(now))
+	if err != nil {
+		return nil, err
+	}
+
+	quota := &PartnerQuota{
+		PartnerPlanLimits: s.limitsFor(key.Plan),
+		Period:            monthStart.Format("2006-01"),
+		RequestsUsed:      used.Requests,
+		TransferUsed:      used.BytesIn + used.BytesOut,
+		ResetAt:           monthStart.AddDate(0, 1, 0),
+	}
+	quota.RequestsLeft = quotaLeft(quota.MonthlyRequests, quota.RequestsUsed)
+	quota.TransferLeft = quotaLeft(quota.MonthlyTransfer, quota.TransferUsed)
+	quota.OverQuota = quota.RequestsLeft == 0 || quota.TransferLeft == 0
+
+	// Sandbox traffic is metered but never cut off
+	if quota.OverQuota && quota.HardCutoff && !key.Sandbox {
+		return quota, ErrPartnerQuotaExceeded
+	}
+	return quota, nil
+}
+
+// quotaLeft returns how much of limit is left, or -1 for no limit
+func quotaLeft(limit, used int64) int64 {
+	if limit <= 0 {
+		return -1
+	}
+	if used >= limit {
+		return 0
+	}
+	return limit - used
+}
+
+// monthUsage returns the key's usage since monthStart, from the Redis month counter when there is
+// one. A missing counter is seeded from the rolled-up rows.
+func (s *partnerUsageService) monthUsage(ctx context.Context, keyID string, monthStart, today time.Time) (*repository.PartnerUsageTotals, error) {
+	if s.redis != nil {
+		key := partnerUsageMonthKey(keyID, monthStart)
+		fields, ok, err := s.redis.GetHashInts(ctx, key)
+		if err == nil && ok {
+			return usageTotals(fields), nil
+		}
+		if err != nil {
+			log.Printf("⚠️  Failed to read partner usage of key %s from Redis: %v", keyID, err)
+		}
+
+		totals, err := s.usageRepo.SumUsage(ctx, keyID, monthStart, today)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.redis.SeedHashIfAbsent(ctx, key, usageFields(*totals), partnerUsageMonthTTL); err != nil {
+			log.Printf("⚠️  Failed to seed partner usage of key %s in Redis: %v", keyID, err)
+		}
+		return totals, nil
+	}
+	return s.usageRepo.SumUsage(ctx, keyID, monthStart, today)
+}
+
+func (s *partnerUsageService) Record(ctx context.Context, key *model.PartnerAPIKey, bytesIn, bytesOut int64) {
+	if bytesIn < 0 {
+		bytesIn = 0
+	}
+	if bytesOut < 0 {
+		bytesOut = 0
+	}
+	totals := repository.PartnerUsageTotals{Requests: 1, BytesIn: bytesIn, BytesOut: bytesOut}
+	now := time.Now().UTC()
+
+	if s.redis != nil {
+		fields := usageFields(totals)
+		err := s.redis.IncrHash(ctx, partnerUsageDayKey(key.ID, now), fields, partnerUsageDayTTL)
+		if err == nil {
+			monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+			if err := s.redis.IncrHash(ctx, partnerUsageMonthKey(key.ID, monthStart), fields, partnerUsageMonthTTL); err != nil {
+				log.Printf("⚠️  Failed to count partner request of key %s towards its quota: %v", key.ID, err)
+			}
+			return
+		}
+		log.Printf("⚠️  Failed to meter partner request of key %s in Redis, writing to the database: %v", key.ID, err)
+	}
+
+	if err := s.usageRepo.AddUsage(ctx, key.ID, utcDate(now), totals); err != nil {
+		log.Printf("⚠️  Failed to meter partner request of key %s: %v", key.ID, err)
+	}
+}
+
+func (s *partnerUsageService) Rollup(ctx context.Context) error {
+	if s.redis != nil {
+		var counterKeys []string
+		if err := s.redis.ScanKeys(ctx, partnerUsageKeyPrefix+"*", func(keys []string) error {
+			counterKeys = append(counterKeys, keys...)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		rolledUp := 0
+		for _, counterKey := range counterKeys {
+			keyID, date, ok := parsePartnerUsageDayKey(counterKey)
+			if !ok {
+				continue
+			}
+			fields, err := s.redis.TakeHashInts(ctx, counterKey)
+			if err != nil {
+				log.Printf("⚠️  Failed to take partner usage counters %s: %v", counterKey, err)
+				continue
+			}
+			totals := usageTotals(fields)
+			if totals.Requests == 0 && totals.BytesIn == 0 && totals.BytesOut == 0 {
+				continue
+			}
+			if err := s.usageRepo.AddUsage(ctx, keyID, date, *totals); err != nil {
+				// Put the counters back so the next rollup retries them
+				if restoreErr := s.redis.IncrHash(ctx, counterKey, fields, partnerUsageDayTTL); restoreErr != nil {
+					log.Printf("❌ Lost partner usage of key %s on %s (%d requests): %v", keyID, date.Format("2006-01-02"), totals.Requests, restoreErr)
+				}
+				log.Printf("⚠️  Failed to roll up partner usage of key %s: %v", keyID, err)
+				continue
+			}
+			rolledUp++
+		}
+		if rolledUp > 0 {
+			log.Printf("📊 Rolled up partner API usage of %d key-day(s)", rolledUp)
+		}
+	}
+
+	return s.sendAlerts(ctx)
+}
+
+// sendAlerts emails sellers whose keys crossed an alert threshold of their quota this month. Each
+// threshold is recorded before sending, so an alert goes out once even with several instances.
+func (s *partnerUsageService) sendAlerts(ctx context.Context) error {
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	usage, err := s.usageRepo.FindUsageByKey(ctx, monthStart, utcDate(now))
+	if err != nil {
+		return err
+	}
+
+	thresholds := []int{100}
+	if s.alertPercent > 0 && s.alertPercent < 100 {
+		thresholds = []int{s.alertPercent, 100}
+	}
+	for _, key := range usage {
+		if key.Sandbox {
+			continue
+		}
+		limits := s.limitsFor(key.Plan)
+		metrics := []struct {
+			name  string
+			used  int64
+			limit int64
+		}{
+			{model.PartnerUsageMetricRequests, key.Requests, limits.MonthlyRequests},
+			{model.PartnerUsageMetricTransfer, key.BytesIn + key.BytesOut, limits.MonthlyTransfer},
+		}
+		for _, metric := range metrics {
+			if metric.limit <= 0 {
+				continue
+			}
+			// Only the highest crossed threshold is alerted; lower ones are recorded as passed
+			for i := len(thresholds) - 1; i >= 0; i-- {
+				if metric.used*100 < metric.limit*int64(thresholds[i]) {
+					continue
+				}
+				alert := &model.PartnerUsageAlert{
+					APIKeyID:  key.APIKeyID,
+					Period:    monthStart.Format("2006-01"),
+					Metric:    metric.name,
+					Threshold: thresholds[i],
+					Used:      metric.used,
+					Limit:     metric.limit,
+				}
+				created, err := s.usageRepo.CreateAlert(ctx, alert)
+				if err != nil {
+					log.Printf("⚠️  Failed to record usage alert for partner key %s: %v", key.APIKeyID, err)
+					break
+				}
+				if created {
+					s.notifySeller(&key, limits, alert)
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// notifySeller emails the shop that its key crossed a usage threshold
+func (s *partnerUsageService) notifySeller(key *repository.PartnerKeyUsage, limits PartnerPlanLimits, alert *model.PartnerUsageAlert) {
+	log.Printf("📈 Partner key %s used %d%% of its monthly %s quota (%d of %d)", key.KeyPrefix, alert.Threshold, alert.Metric, alert.Used, alert.Limit)
+	if s.rabbitMQ == nil {
+		log.Printf("Warning: RabbitMQ not available, seller %s not notified about API usage", key.SellerID)
+		return
+	}
+	seller, err := s.sellerRepo.FindByID(key.SellerID)
+	if err != nil {
+		return
+	}
+	email := seller.User.Email
+	if seller.ShopEmail != nil && *seller.ShopEmail != "" {
+		email = *seller.ShopEmail
+	}
+	if email == "" {
+		return
+	}
+
+	consequence := "overage"
+	if limits.HardCutoff {
+		consequence = "cutoff"
+	}
+	emailMsg := util.EmailMessage{
+		To:      email,
+		Subject: fmt.Sprintf("Pemakaian API Mencapai %d%% - %s", alert.Threshold, key.Name),
+		Type:    "partner_usage_alert",
+		Data: map[string]string{
+			"shop_name":   seller.ShopName,
+			"key_name":    key.Name,
+			"key_prefix":  key.KeyPrefix,
+			"plan":        limits.Plan,
+			"metric":      alert.Metric,
+			"threshold":   strconv.Itoa(alert.Threshold),
+			"used":        strconv.FormatInt(alert.Used, 10),
+			"limit":       strconv.FormatInt(alert.Limit, 10),
+			"period":      alert.Period,
+			"consequence": consequence, // What happens past 100%: "cutoff" or "overage"
+		},
+	}
+	if err := s.rabbitMQ.PublishEmail(emailMsg); err != nil {
+		log.Printf("Failed to publish API usage alert for partner key %s: %v", key.APIKeyID, err)
+	}
+}
+
+// GetUsage reports the key's usage per day over [from, to] (YYYY-MM-DD, UTC). Defaults to the
+// current month; at most 92 days at once. The current day lags by up to one rollup interval.
+func (s *partnerUsageService) GetUsage(ctx context.Context, userID string, keyID string, from, to string) (*PartnerUsageReport, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	apiKey, err := s.apiKeyRepo.FindByID(ctx, keyID)
+	if err != nil || apiKey.SellerID != seller.ID {
+		return nil, errors.New("API key not found")
+	}
+
+	now := time.Now().UTC()
+	toDate := utcDate(now)
+	if to != "" {
+		if toDate, err = time.Parse("2006-01-02", to); err != nil {
+			return nil, errors.New("invalid to date, expected YYYY-MM-DD")
+		}
+	}
+	fromDate := time.Date(toDate.Year(), toDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if from != "" {
+		if fromDate, err = time.Parse("2006-01-02", from); err != nil {
+			return nil, errors.New("invalid from date, expected YYYY-MM-DD")
+		}
+	}
+	if fromDate.After(toDate) {
+		return nil, errors.New("from must not be after to")
+	}
+	if toDate.Sub(fromDate) >= partnerUsageMaxDays*24*time.Hour {
+		return nil, fmt.Errorf("date range cannot exceed %d days", partnerUsageMaxDays)
+	}
+
+	rows, err := s.usageRepo.FindUsage(ctx, apiKey.ID, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	quota, err := s.CheckQuota(ctx, apiKey)
+	if err != nil && !errors.Is(err, ErrPartnerQuotaExceeded) {
+		return nil, err
+	}
+
+	report := &PartnerUsageReport{
+		APIKeyID: apiKey.ID,
+		Plan:     s.limitsFor(apiKey.Plan).Plan,
+		From:     fromDate.Format("2006-01-02"),
+		To:       toDate.Format("2006-01-02"),
+		Days:     make([]PartnerUsageDay, 0, len(rows)),
+		Quota:    quota,
+	}
+	for _, row := range rows {
+		report.Days = append(report.Days, PartnerUsageDay{
+			Date:     row.Date.Format("2006-01-02"),
+			Requests: row.Requests,
+			BytesIn:  row.BytesIn,
+			BytesOut: row.BytesOut,
+		})
+		report.Totals.Requests += row.Requests
+		report.Totals.BytesIn += row.BytesIn
+		report.Totals.BytesOut += row.BytesOut
+	}
+	return report, nil
+}
+
+// GetBillingReport lists every key's usage in the month (YYYY-MM, default the current one) and
+// its overage beyond the plan
+func (s *partnerUsageService) GetBillingReport(ctx context.Context, month string) (*PartnerBillingReport, error) {
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if month != "" {
+		parsed, err := time.Parse("2006-01", month)
+		if err != nil {
+			return nil, errors.New("invalid month, expected YYYY-MM")
+		}
+		monthStart = parsed
+	}
+
+	usage, err := s.usageRepo.FindUsageByKey(ctx, monthStart, monthStart.AddDate(0, 1, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &PartnerBillingReport{
+		Period: monthStart.Format("2006-01"),
+		Keys:   make([]PartnerBillingLine, 0, len(usage)),
+	}
+	for _, key := range usage {
+		line := PartnerBillingLine{PartnerKeyUsage: key, Limits: s.limitsFor(key.Plan)}
+		if !key.Sandbox {
+			if limit := line.Limits.MonthlyRequests; limit > 0 && key.Requests > limit {
+				line.OverageRequests = key.Requests - limit
+			}
+			if limit, transfer := line.Limits.MonthlyTransfer, key.BytesIn+key.BytesOut; limit > 0 && transfer > limit {
+				line.OverageTransfer = transfer - limit
+			}
+		}
+		report.TotalRequests += key.Requests
+		report.TotalTransfer += key.BytesIn + key.BytesOut
+		report.Keys = append(report.Keys, line)
+	}
+	return report, nil
+}
+
+func (s *partnerUsageService) SetPlan(ctx context.Context, keyID string, plan string) (*model.PartnerAPIKey, error) {
+	if _, ok := s.plans[plan]; !ok {
+		return nil, fmt.Errorf("unknown plan %q", plan)
+	}
+	apiKey, err := s.apiKeyRepo.FindByID(ctx, keyID)
+	if err != nil {
+		return nil, errors.New("API key not found")
+	}
+	apiKey.Plan = plan
+	if err := s.apiKeyRepo.Update(ctx, apiKey); err != nil {
+		return nil, fmt.Errorf("failed to update plan: %w", err)
+	}
+
+	log.Printf("🔑 Partner API key %s moved to the %s plan", apiKey.KeyPrefix, plan)
+	return apiKey, nil
+}
+
+func partnerUsageDayKey(keyID string, day time.Time) string {
+	return partnerUsageKeyPrefix + keyID + ":" + day.Format("2006-01-02")
+}
+
+func partnerUsageMonthKey(keyID string, monthStart time.Time) string {
+	return partnerUsageMonthKeyPrefix + keyID + ":" + monthStart.Format("2006-01")
+}
+
+// parsePartnerUsageDayKey splits a day counter key into the API key ID and the day
+func parsePartnerUsageDayKey(counterKey string) (string, time.Time, bool) {
+	parts := strings.Split(strings.TrimPrefix(counterKey, partnerUsageKeyPrefix), ":")
+	if len(parts) != 2 {
+		return "", time.Time{}, false
+	}
+	date, err := time.Parse("2006-01-02", parts[1])
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return parts[0], date, true
+}
+
+func usageFields(totals repository.PartnerUsageTotals) map[string]int64 {
+	return map[string]int64{
+		"requests":  totals.Requests,
+		"bytes_in":  totals.BytesIn,
+		"bytes_out": totals.BytesOut,
+	}
+}
+
+func usageTotals(fields map[string]int64) *repository.PartnerUsageTotals {
+	return &repository.PartnerUsageTotals{
+		Requests: fields["requests"],
+		BytesIn:  fields["bytes_in"],
+		BytesOut: fields["bytes_out"],
+	}
+}
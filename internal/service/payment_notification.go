@@ -0,0 +1,168 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"yourapp/internal/model"
+)
+
+// PaymentNotification is a transaction status reported by Midtrans, either pushed to the
+// callback endpoint or fetched from the status API
+type PaymentNotification struct {
+	OrderNumber       string // Midtrans order_id, which is our order number
+	TransactionID     string
+	TransactionStatus string
+	VANumber          string
+	BankType          string
+	QRCodeURL         string
+	ExpiryTime        *time.Time
+	Raw               string // The payload as received, stored as the payment's midtrans_response
+}
+
+// MidtransNotification is the body of a Midtrans HTTP notification or status API response.
+// status_code and gross_amount are kept exactly as sent, since the signature is computed over them.
+type MidtransNotification struct {
+	TransactionID     string             `json:"transaction_id"`
+	OrderID           string             `json:"order_id"`
+	TransactionStatus string             `json:"transaction_status"`
+	TransactionTime   string             `json:"transaction_time"`
+	StatusCode        json.Number        `json:"status_code"`
+	StatusMessage     string             `json:"status_message"`
+	GrossAmount       json.Number        `json:"gross_amount"`
+	SignatureKey      string             `json:"signature_key"`
+	PaymentType       string             `json:"payment_type"`
+	FraudStatus       string             `json:"fraud_status"`
+	VANumbers         []MidtransVANumber `json:"va_numbers,omitempty"`
+	Actions           []MidtransAction   `json:"actions,omitempty"`
+	QRCodeURL         string             `json:"qr_code_url,omitempty"`
+	ExpiryTime        string             `json:"expiry_time,omitempty"`
+	SettlementTime    string             `json:"settlement_time,omitempty"`
+	ApprovalCode      string             `json:"approval_code,omitempty"`
+	Currency          string             `json:"currency,omitempty"`
+}
+
+// DecodeMidtransNotification decodes a Midtrans notification body, keeping numbers as sent
+func DecodeMidtransNotification(body []byte) (*MidtransNotification, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var notification MidtransNotification
+	if err := decoder.Decode(&notification); err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// PaymentNotificationParser extracts payment details from Midtrans payloads. raw is the body as
+// received and is kept as the payment's midtrans_response.
+type PaymentNotificationParser interface {
+	// ParseCallback parses an HTTP notification; order_id and transaction_id are required
+	ParseCallback(notification *MidtransNotification, raw []byte) (*PaymentNotification, error)
+	// ParseStatus parses a status API response; transaction_status is required
+	ParseStatus(notification *MidtransNotification, raw []byte) (*PaymentNotification, error)
+}
+
+type midtransNotificationParser struct{}
+
+// Midtrans timestamps come in a few layouts depending on the payment type
+var midtransTimeFormats = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+}
+
+func NewPaymentNotificationParser() PaymentNotificationParser {
+	return &midtransNotificationParser{}
+}
+
+func (p *midtransNotificationParser) ParseCallback(notification *MidtransNotification, raw []byte) (*PaymentNotification, error) {
+	if notification.OrderID == "" {
+		return nil, errors.New("invalid notification: missing order_id")
+	}
+	if notification.TransactionID == "" {
+		return nil, errors.New("invalid notification: missing transaction_id")
+	}
+	return p.parse(notification, raw), nil
+}
+
+func (p *midtransNotificationParser) ParseStatus(notification *MidtransNotification, raw []byte) (*PaymentNotification, error) {
+	if notification.TransactionStatus == "" {
+		return nil, errors.New("no transaction_status in response")
+	}
+	return p.parse(notification, raw), nil
+}
+
+func (p *midtransNotificationParser) parse(notification *MidtransNotification, raw []byte) *PaymentNotification {
+	parsed := &PaymentNotification{
+		OrderNumber:       notification.OrderID,
+		TransactionID:     notification.TransactionID,
+		TransactionStatus: notification.TransactionStatus,
+		QRCodeURL:         notification.QRCodeURL,
+		ExpiryTime:        parseMidtransTime(notification.ExpiryTime),
+		Raw:               string(raw),
+	}
+	if len(notification.VANumbers) > 0 {
+		parsed.VANumber = notification.VANumbers[0].VANumber
+		parsed.BankType = notification.VANumbers[0].Bank
+	}
+	if parsed.QRCodeURL == "" {
+		parsed.QRCodeURL = qrCodeURLFromActions(notification.Actions)
+	}
+	return parsed
+}
+
+// applyMidtransResponseFields copies the fields reporting needs out of a raw Midtrans response
+// onto the payment, so nobody has to re-parse midtrans_response. Fields missing from the
+// response keep their current value.
+func applyMidtransResponseFields(payment *model.Payment, raw string) {
+	notification, err := DecodeMidtransNotification([]byte(raw))
+	if err != nil {
+		return
+	}
+	if notification.FraudStatus != "" {
+		payment.FraudStatus = &notification.FraudStatus
+	}
+	if statusCode := notification.StatusCode.String(); statusCode != "" {
+		payment.MidtransStatusCode = &statusCode
+	}
+	if settlementTime := parseMidtransTime(notification.SettlementTime); settlementTime != nil {
+		payment.SettlementTime = settlementTime
+	}
+	if notification.ApprovalCode != "" {
+		payment.ApprovalCode = &notification.ApprovalCode
+	}
+	if notification.Currency != "" {
+		payment.Currency = &notification.Currency
+	}
+}
+
+// qrCodeURLFromActions finds the QR code image URL among the actions of a GoPay/QRIS transaction
+func qrCodeURLFromActions(actions []MidtransAction) string {
+	for _, action := range actions {
+		if (action.Name == "generate-qr-code" || action.Name == "generate-qr-code-v2" || action.Name == "qr-code") && action.URL != "" {
+			return action.URL
+		}
+	}
+	// If not found by name, try by method GET
+	for _, action := range actions {
+		if action.Method == "GET" && action.URL != "" && strings.Contains(strings.ToLower(action.URL), "qr") {
+			return action.URL
+		}
+	}
+	return ""
+}
+
+// parseMidtransTime parses a Midtrans timestamp, returning nil when it is empty or unrecognised
+func parseMidtransTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	for _, format := range midtransTimeFormats {
+		if parsed, err := time.Parse(format, value); err == nil {
+			return &parsed
+		}
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// Background payment status polling
+const (
+	paymentPollInterval    = 15 * time.Second
+	paymentPollStartDelay  = 5 * time.Second // Lets the server start properly before the first check
+	paymentPollConcurrency = 5
+	paymentPollSpawnDelay  = 500 * time.Millisecond
+)
+
+// PaymentPoller asks Midtrans for the status of pending payments, catching up on callbacks
+// that never arrived
+type PaymentPoller interface {
+	// Run checks pending payments periodically until ctx is cancelled
+	Run(ctx context.Context)
+	// CheckPendingPayments expires overdue payments and syncs the rest with Midtrans
+	CheckPendingPayments(ctx context.Context)
+	// SyncPayment fetches the Midtrans status of one payment by order number and saves it
+	SyncPayment(ctx context.Context, orderNumber string) error
+	// Wait blocks until status checks started by CheckPendingPayments have finished
+	Wait()
+	// BudgetStats reports how much of the background Midtrans API budget is being used
+	BudgetStats() MidtransBudgetStats
+}
+
+type paymentPoller struct {
+	paymentRepo repository.PaymentRepository
+	gateway     MidtransGateway
+	parser      PaymentNotificationParser
+	updater     PaymentStatusUpdater
+	budget      MidtransBudget
+	breaker     MidtransBreaker
+	inFlight    sync.WaitGroup // Background checks currently running
+}
+
+func NewPaymentPoller(paymentRepo repository.PaymentRepository, gateway MidtransGateway, parser PaymentNotificationParser, updater PaymentStatusUpdater, budget MidtransBudget, breaker MidtransBreaker) PaymentPoller {
+	return &paymentPoller{
+		paymentRepo: paymentRepo,
+		gateway:     gateway,
+		parser:      parser,
+		updater:     updater,
+		budget:      budget,
+		breaker:     breaker,
+	}
+}
+
+func (p *paymentPoller) Run(ctx context.Context) {
+	ticker := time.NewTicker(paymentPollInterval)
+	defer ticker.Stop()
+
+	select {
+	case <-time.After(paymentPollStartDelay):
+	case <-ctx.Done():
+		return
+	}
+	p.CheckPendingPayments(ctx)
+
+	log.Println("🔄 Background payment checker initialized (checking every 15 seconds)")
+
+	for {
+		select {
+		case <-ticker.C:
+			p.CheckPendingPayments(ctx)
+		case <-ctx.Done():
+			log.Println("🛑 Background payment checker stopped")
+			return
+		}
+	}
+}
+
+func (p *paymentPoller) CheckPendingPayments(ctx context.Context) {
+	pendingPayments, err := p.paymentRepo.FindPendingPayments(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to fetch pending payments: %v", err)
+		return
+	}
+
+	if len(pendingPayments) == 0 {
+		return // No pending payments to check
+	}
+
+	log.Printf("🔍 Background check: Checking status for %d pending payment(s)...", len(pendingPayments))
+
+	// Use semaphore to limit concurrent checks
+	semaphore := make(chan struct{}, paymentPollConcurrency)
+
+	for _, payment := range pendingPayments {
+		if ctx.Err() != nil {
+			return // Shutting down
+		}
+
+		// Skip if no transaction ID
+		if payment.MidtransTransactionID == nil || *payment.MidtransTransactionID == "" {
+			continue
+		}
+
+		// Check if payment is expired (based on expiry_time)
+		if payment.ExpiryTime != nil && payment.ExpiryTime.Before(time.Now()) {
+			log.Printf("⏰ Payment %s (Order: %s) has expired, marking as expired", payment.ID, payment.OrderID)
+			payment.Status = model.PaymentStatusExpired
+			if err := p.paymentRepo.Update(ctx, payment); err == nil {
+				p.updater.PublishStatusChange(ctx, payment)
+			}
+			continue
+		}
+
+		// Pause while Midtrans is down; the breaker's trial call decides when checks resume
+		if wait := p.breaker.RetryAfter(); wait > 0 {
+			log.Printf("⏸️  Midtrans circuit breaker open, pausing status checks for %s", wait.Round(time.Second))
+			break
+		}
+
+		// Stay within the Midtrans API budget; the rest are checked on a later cycle
+		if !p.budget.Allow() {
+			log.Printf("⏸️  Midtrans API budget used up or backing off, deferring remaining status checks")
+			break
+		}
+
+		// Acquire semaphore
+		semaphore <- struct{}{}
+
+		// Check status asynchronously (non-blocking) with semaphore to limit concurrency
+		p.inFlight.Add(1)
+		go func(payment *model.Payment) {
+			defer p.inFlight.Done()
+			defer func() { <-semaphore }() // Release semaphore when done
+
+			log.Printf("🔄 Background checking payment %s (Order: %s, Transaction: %s)",
+				payment.ID, payment.OrderID, *payment.MidtransTransactionID)
+
+			if err := p.SyncPayment(ctx, payment.OrderID); err != nil {
+				// Log error but don't fail - will retry on next cycle
+				log.Printf("⚠️  Background check failed for payment %s (Order: %s): %v", payment.ID, payment.OrderID, err)
+			} else {
+				log.Printf("✅ Background check completed for payment %s (Order: %s)", payment.ID, payment.OrderID)
+			}
+		}(payment)
+
+		// Small delay between spawning goroutines to avoid overwhelming the system
+		time.Sleep(paymentPollSpawnDelay)
+	}
+}
+
+func (p *paymentPoller) SyncPayment(ctx context.Context, orderNumber string) error {
+	// Get payment from database first by order number
+	payment, err := p.paymentRepo.FindByOrderNumber(ctx, orderNumber)
+	if err != nil {
+		log.Printf("❌ Payment not found for order number %s: %v", orderNumber, err)
+		return fmt.Errorf("payment not found for order number %s: %v", orderNumber, err)
+	}
+
+	// If already successful, skip check
+	if payment.Status == model.PaymentStatusSuccess {
+		log.Printf("✅ Payment for order %s already successful, skipping check", orderNumber)
+		return nil
+	}
+
+	// If no transaction ID, cannot check
+	if payment.MidtransTransactionID == nil || *payment.MidtransTransactionID == "" {
+		log.Printf("⚠️  No transaction ID for payment with order number %s", orderNumber)
+		return fmt.Errorf("no transaction ID for payment")
+	}
+
+	log.Printf("🔍 Checking Midtrans status for transaction ID: %s (Order: %s)", *payment.MidtransTransactionID, orderNumber)
+
+	statusResp, body, err := p.gateway.GetStatus(ctx, *payment.MidtransTransactionID)
+	p.budget.Report(err)
+	if err != nil {
+		return err
+	}
+
+	notification, err := p.parser.ParseStatus(statusResp, body)
+	if err != nil {
+		log.Printf("⚠️  %v for order %s", err, orderNumber)
+		return err
+	}
+
+	log.Printf("📊 Midtrans response - Status: %s, Transaction ID: %s, Order ID: %s",
+		notification.TransactionStatus, notification.TransactionID, notification.OrderNumber)
+
+	// Use order number from parameter (not from Midtrans response, as it might differ)
+	// The orderNumber parameter is the order_number we sent to Midtrans
+	notification.OrderNumber = orderNumber
+
+	log.Printf("🔄 Updating payment status for order number: %s with status: %s", orderNumber, notification.TransactionStatus)
+
+	return p.updater.Apply(ctx, notification)
+}
+
+func (p *paymentPoller) Wait() {
+	p.inFlight.Wait()
+}
+
+func (p *paymentPoller) BudgetStats() MidtransBudgetStats {
+	return p.budget.Stats()
+}
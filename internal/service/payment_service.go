@@ -1,251 +1,174 @@
 package service
 
 import (
-	"bytes"
-	"encoding/base64"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"strings"
+	"sync"
 	"time"
 	"yourapp/internal/config"
 	"yourapp/internal/model"
 	"yourapp/internal/repository"
-)
-
-type PaymentService interface {
-	CreatePayment(orderID string, paymentMethod model.PaymentMethod, bankType *string) (*model.Payment, error)
-	GetPaymentByID(paymentID string) (*model.Payment, error)
-	GetPaymentByOrderID(orderID string) (*model.Payment, error)
-	HandleMidtransCallback(notification map[string]interface{}) error
-	CheckPaymentStatus(paymentID string) (*model.Payment, error)
-	CheckPaymentStatusFromMidtrans(orderID string) error
-	UpdatePaymentStatus(orderID string, status string, transactionID string, vaNumber string, bankType string, qrCodeURL string, expiryTime *time.Time, midtransResponse string) error
-}
-
-type paymentService struct {
-	paymentRepo    repository.PaymentRepository
-	orderRepo      repository.OrderRepository
-	cfg            *config.Config
-	stopBackground chan bool // Channel to stop background job
-}
-
-// Midtrans API request/response structures
-type MidtransChargeRequest struct {
-	PaymentType        string                     `json:"payment_type"`
-	TransactionDetails MidtransTransactionDetails `json:"transaction_details"`
-	CustomerDetails    MidtransCustomerDetails    `json:"customer_details"`
-	ItemDetails        []MidtransItemDetail       `json:"item_details"`
-	BankTransfer       *MidtransBankTransfer      `json:"bank_transfer,omitempty"`
-	Gopay              *MidtransGopay             `json:"gopay,omitempty"`
-	CreditCard         *MidtransCreditCard        `json:"credit_card,omitempty"`
-}
+	"yourapp/internal/util"
 
-type MidtransTransactionDetails struct {
-	OrderID     string `json:"order_id"`
-	GrossAmount int    `json:"gross_amount"`
-}
+	"github.com/redis/go-redis/v9"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+)
 
-type MidtransCustomerDetails struct {
-	FirstName string `json:"first_name"`
-	Email     string `json:"email"`
-	Phone     string `json:"phone,omitempty"`
-}
+// paymentStatusFallbackPollInterval is how often a status wait re-reads the database when Redis is unavailable
+const paymentStatusFallbackPollInterval = 2 * time.Second
 
-type MidtransItemDetail struct {
-	ID       string `json:"id"`
-	Price    int    `json:"price"`
-	Quantity int    `json:"quantity"`
-	Name     string `json:"name"`
-	Category string `json:"category,omitempty"`
-}
+// paymentInstructionsResendCooldown is the minimum time between two resend requests for the same payment
+const paymentInstructionsResendCooldown = 2 * time.Minute
 
-type MidtransBankTransfer struct {
-	Bank string `json:"bank"`
-}
+// QR code PNG rendering and caching
+const (
+	qrCodeImageSize      = 512
+	qrCodeCacheKeyPrefix = "payment_qr:"
+	qrCodeDefaultTTL     = 24 * time.Hour // Used when the payment has no expiry time
+)
 
-type MidtransGopay struct {
-	EnableCallback bool   `json:"enable_callback"`
-	CallbackURL    string `json:"callback_url"`
-}
+// ErrQRCodeUnavailable is returned when a payment has no QR payload to render
+var ErrQRCodeUnavailable = errors.New("QR code is not available for this payment")
 
-type MidtransCreditCard struct {
-	Secure         bool `json:"secure"`
-	Authentication bool `json:"authentication"`
-}
+// ErrResendTooSoon is returned when payment instructions were resent too recently
+var ErrResendTooSoon = errors.New("payment instructions were sent recently, please try again later")
 
-type MidtransChargeResponse struct {
-	TransactionID     string             `json:"transaction_id"`
-	OrderID           string             `json:"order_id"`
-	GrossAmount       string             `json:"gross_amount"`
-	PaymentType       string             `json:"payment_type"`
-	TransactionTime   string             `json:"transaction_time"`
-	TransactionStatus string             `json:"transaction_status"`
-	FraudStatus       string             `json:"fraud_status"`
-	StatusMessage     string             `json:"status_message"`
-	VANumbers         []MidtransVANumber `json:"va_numbers,omitempty"`
-	Actions           []MidtransAction   `json:"actions,omitempty"`
-	ExpiryTime        string             `json:"expiry_time,omitempty"`
-	QRCodeURL         string             `json:"qr_code_url,omitempty"`
+type PaymentService interface {
+	CreatePayment(ctx context.Context, orderID string, paymentMethod model.PaymentMethod, bankType *string, card *CardChargeOptions) (*model.Payment, error)
+	GetPaymentByID(ctx context.Context, paymentID string) (*model.Payment, error)
+	GetPaymentByOrderID(ctx context.Context, orderID string) (*model.Payment, error)
+	HandleMidtransCallback(ctx context.Context, notification *MidtransNotification, rawBody []byte) error
+	CheckPaymentStatus(ctx context.Context, paymentID string) (*model.Payment, error)
+	WaitForPaymentStatusChange(ctx context.Context, paymentID string, knownStatus model.PaymentStatus, wait time.Duration) (*model.Payment, error)
+	CheckPaymentStatusFromMidtrans(ctx context.Context, orderID string) error
+	UpdatePaymentStatus(ctx context.Context, orderID string, status string, transactionID string, vaNumber string, bankType string, qrCodeURL string, expiryTime *time.Time, midtransResponse string) error
+	CheckTransferProofUpload(ctx context.Context, paymentID string, userID string) error
+	SubmitTransferProof(ctx context.Context, paymentID string, userID string, proofImageURL string) (*model.Payment, error)
+	VerifyManualTransfer(ctx context.Context, paymentID string, adminID string, approved bool, note *string) (*model.Payment, error)
+	ResendPaymentInstructions(ctx context.Context, paymentID string, userID string) error
+	GetPaymentQRCode(ctx context.Context, paymentID string, userID string) ([]byte, *time.Time, error)
+	CancelPaymentForOrder(ctx context.Context, orderUUID string) error
+	RefundPayment(ctx context.Context, orderUUID string, refundKey string, amount int, reason string) (string, error)
+	GetSavedCards(ctx context.Context, userID string) ([]model.SavedCard, error)
+	DeleteSavedCard(ctx context.Context, userID string, cardID string) error
+	GetMidtransBudgetStats() MidtransBudgetStats
+	GetMidtransBreakerStats() MidtransBreakerStats
+	Shutdown(ctx context.Context) error
 }
 
-type MidtransVANumber struct {
-	Bank     string `json:"bank"`
-	VANumber string `json:"va_number"`
+type paymentService struct {
+	paymentRepo   repository.PaymentRepository
+	orderRepo     repository.OrderRepository
+	savedCardRepo repository.SavedCardRepository
+	gateway       MidtransGateway
+	breaker       MidtransBreaker
+	parser        PaymentNotificationParser
+	updater       PaymentStatusUpdater
+	poller        PaymentPoller
+	hooks         *HookRegistry
+	rabbitMQ      *util.RabbitMQClient
+	redis         *util.RedisClient // Optional; used for status long-polling and the QR code cache
+	cfg           *config.Config
+	bgCtx         context.Context // Cancelled on shutdown to stop background jobs
+	bgCancel      context.CancelFunc
+	inFlight      sync.WaitGroup // Charges currently running
+
+	resendMu       sync.Mutex
+	lastResendTime map[string]time.Time // paymentID -> last resend, when Redis is not available
 }
 
-type MidtransAction struct {
-	Name   string `json:"name"`
-	Method string `json:"method"`
-	URL    string `json:"url"`
+// CardChargeOptions carries credit card details for CreatePayment (credit_card only)
+type CardChargeOptions struct {
+	TokenID     string `json:"token_id,omitempty"`      // One-time card token obtained client-side from Midtrans
+	SaveCard    bool   `json:"save_card,omitempty"`     // User consents to saving this card for one-click checkout
+	SavedCardID string `json:"saved_card_id,omitempty"` // Charge a previously saved card instead of a new token
 }
 
 func NewPaymentService(
 	paymentRepo repository.PaymentRepository,
 	orderRepo repository.OrderRepository,
+	savedCardRepo repository.SavedCardRepository,
+	gateway MidtransGateway,
+	breaker MidtransBreaker,
+	parser PaymentNotificationParser,
+	updater PaymentStatusUpdater,
+	poller PaymentPoller,
+	hooks *HookRegistry,
+	rabbitMQ *util.RabbitMQClient,
+	redisClient *util.RedisClient,
 	cfg *config.Config,
 ) PaymentService {
+	bgCtx, bgCancel := context.WithCancel(context.Background())
 	service := &paymentService{
 		paymentRepo:    paymentRepo,
 		orderRepo:      orderRepo,
+		savedCardRepo:  savedCardRepo,
+		gateway:        gateway,
+		breaker:        breaker,
+		parser:         parser,
+		updater:        updater,
+		poller:         poller,
+		hooks:          hooks,
+		rabbitMQ:       rabbitMQ,
+		redis:          redisClient,
 		cfg:            cfg,
-		stopBackground: make(chan bool),
+		bgCtx:          bgCtx,
+		bgCancel:       bgCancel,
+		lastResendTime: make(map[string]time.Time),
 	}
 
 	// Start background job to periodically check pending payments
 	if cfg.MidtransServerKey != "" {
-		go service.startBackgroundPaymentChecker()
-		log.Println("✅ Background payment status checker started (checking every 30 seconds)")
+		go poller.Run(bgCtx)
+		log.Println("✅ Background payment status checker started (checking every 15 seconds)")
 	}
 
-	return service
-}
-
-// startBackgroundPaymentChecker runs in background to periodically check pending payment status
-func (s *paymentService) startBackgroundPaymentChecker() {
-	ticker := time.NewTicker(15 * time.Second) // Check every 15 seconds for faster detection
-	defer ticker.Stop()
-
-	// Do initial check after 5 seconds (to let server start properly)
-	time.Sleep(5 * time.Second)
-	s.checkAllPendingPayments()
+	// Start background job to replay payment statuses whose database write failed
+	go updater.RunRetryWorker(bgCtx)
 
-	log.Println("🔄 Background payment checker initialized (checking every 15 seconds)")
-
-	for {
-		select {
-		case <-ticker.C:
-			s.checkAllPendingPayments()
-		case <-s.stopBackground:
-			log.Println("🛑 Background payment checker stopped")
-			return
-		}
-	}
+	return service
 }
 
-// checkAllPendingPayments checks status of all pending payments
-func (s *paymentService) checkAllPendingPayments() {
-	pendingPayments, err := s.paymentRepo.FindPendingPayments()
+func (s *paymentService) CreatePayment(ctx context.Context, orderID string, paymentMethod model.PaymentMethod, bankType *string, card *CardChargeOptions) (*model.Payment, error) {
+	// Get order with preloaded data
+	order, err := s.orderRepo.FindByID(ctx, orderID)
 	if err != nil {
-		log.Printf("⚠️  Failed to fetch pending payments: %v", err)
-		return
-	}
-
-	if len(pendingPayments) == 0 {
-		return // No pending payments to check
+		return nil, errors.New("order not found")
 	}
 
-	log.Printf("🔍 Background check: Checking status for %d pending payment(s)...", len(pendingPayments))
-
-	// Use semaphore to limit concurrent checks (max 5 at a time)
-	semaphore := make(chan struct{}, 5)
-
-	for _, payment := range pendingPayments {
-		// Skip if no transaction ID
-		if payment.MidtransTransactionID == nil || *payment.MidtransTransactionID == "" {
-			continue
-		}
-
-		// Check if payment is expired (based on expiry_time)
-		if payment.ExpiryTime != nil && payment.ExpiryTime.Before(time.Now()) {
-			log.Printf("⏰ Payment %s (Order: %s) has expired, marking as expired", payment.ID, payment.OrderID)
-			payment.Status = model.PaymentStatusExpired
-			s.paymentRepo.Update(payment)
-			continue
-		}
-
-		// Acquire semaphore
-		semaphore <- struct{}{}
-
-		// Check status asynchronously (non-blocking) with semaphore to limit concurrency
-		go func(p *model.Payment) {
-			defer func() { <-semaphore }() // Release semaphore when done
-
-			log.Printf("🔄 Background checking payment %s (Order: %s, Transaction: %s)",
-				p.ID, p.OrderID, *p.MidtransTransactionID)
-
-			if err := s.CheckPaymentStatusFromMidtrans(p.OrderID); err != nil {
-				// Log error but don't fail - will retry on next cycle
-				log.Printf("⚠️  Background check failed for payment %s (Order: %s): %v", p.ID, p.OrderID, err)
-			} else {
-				log.Printf("✅ Background check completed for payment %s (Order: %s)", p.ID, p.OrderID)
-			}
-		}(payment)
-
-		// Small delay between spawning goroutines to avoid overwhelming the system
-		time.Sleep(500 * time.Millisecond)
+	// Check if payment already exists
+	existingPayment, _ := s.paymentRepo.FindByOrderID(ctx, orderID)
+	if existingPayment != nil {
+		return existingPayment, nil
 	}
-}
 
-// mapMidtransStatusToPaymentStatus maps Midtrans status to PaymentStatus
-func mapMidtransStatusToPaymentStatus(status string) model.PaymentStatus {
-	switch status {
-	case "pending":
-		return model.PaymentStatusPending
-	case "settlement", "capture":
-		return model.PaymentStatusSuccess
-	case "deny":
-		return model.PaymentStatusFailed
-	case "cancel":
-		return model.PaymentStatusCancelled
-	case "expire":
-		return model.PaymentStatusExpired
-	default:
-		return model.PaymentStatusPending
+	if err := s.hooks.RunBeforePaymentCreate(ctx, order); err != nil {
+		return nil, err
 	}
-}
 
-// getMidtransBaseURL returns Midtrans API base URL based on environment
-func (s *paymentService) getMidtransBaseURL() string {
-	if s.cfg.MidtransServerKey != "" {
-		// Check if it's production key (starts with Mid-server) or sandbox (starts with SB-Mid-server)
-		if strings.HasPrefix(s.cfg.MidtransServerKey, "Mid-server") {
-			return "https://api.midtrans.com/v2"
+	// While Midtrans is down, fail fast without a payment record so the buyer can retry the
+	// saved order later instead of getting a payment that has no transaction
+	if paymentMethod != model.PaymentMethodManualTransfer && s.cfg.MidtransServerKey != "" {
+		if wait := s.breaker.RetryAfter(); wait > 0 {
+			log.Printf("⏸️  Midtrans circuit breaker open, not charging order %s", order.OrderNumber)
+			return nil, &MidtransUnavailableError{RetryAfter: wait}
 		}
 	}
-	return "https://api.sandbox.midtrans.com/v2"
-}
-
-// getAuthHeader returns base64 encoded authorization header
-func (s *paymentService) getAuthHeader() string {
-	auth := base64.StdEncoding.EncodeToString([]byte(s.cfg.MidtransServerKey + ":"))
-	return "Basic " + auth
-}
-
-func (s *paymentService) CreatePayment(orderID string, paymentMethod model.PaymentMethod, bankType *string) (*model.Payment, error) {
-	// Get order with preloaded data
-	order, err := s.orderRepo.FindByID(orderID)
-	if err != nil {
-		return nil, errors.New("order not found")
-	}
 
-	// Check if payment already exists
-	existingPayment, _ := s.paymentRepo.FindByOrderID(orderID)
-	if existingPayment != nil {
-		return existingPayment, nil
+	// Resolve saved card up front so an invalid card doesn't leave a dangling payment record
+	var savedCard *model.SavedCard
+	if paymentMethod == model.PaymentMethodCreditCard && card != nil && card.SavedCardID != "" {
+		savedCard, err = s.savedCardRepo.FindByID(ctx, card.SavedCardID)
+		if err != nil || savedCard.UserID != order.UserID {
+			return nil, errors.New("saved card not found")
+		}
+		if savedCard.ExpiresAt != nil && savedCard.ExpiresAt.Before(time.Now()) {
+			return nil, errors.New("saved card has expired, please re-enter card details")
+		}
 	}
 
 	// Create payment record first
@@ -259,11 +182,29 @@ func (s *paymentService) CreatePayment(orderID string, paymentMethod model.Payme
 		PaymentType:   "midtrans",
 	}
 
-	if err := s.paymentRepo.Create(payment); err != nil {
+	// Manual transfer skips Midtrans entirely: buyer transfers to our bank account
+	// and uploads a receipt, which is then verified by an admin
+	if paymentMethod == model.PaymentMethodManualTransfer {
+		payment.PaymentType = "manual"
+		if s.cfg.ManualTransferBank != "" {
+			bank := s.cfg.ManualTransferBank
+			payment.BankType = &bank
+		}
+		if s.cfg.ManualTransferAccountNumber != "" {
+			accountNumber := s.cfg.ManualTransferAccountNumber // Destination account shown to buyer
+			payment.VANumber = &accountNumber
+		}
+	}
+
+	if err := s.paymentRepo.Create(ctx, payment); err != nil {
 		log.Printf("❌ Failed to create payment: %v", err)
 		return nil, fmt.Errorf("failed to create payment: %v", err)
 	}
 
+	if paymentMethod == model.PaymentMethodManualTransfer {
+		return payment, nil
+	}
+
 	// If Midtrans is not configured, return payment without transaction
 	if s.cfg.MidtransServerKey == "" {
 		log.Printf("⚠️  Midtrans not configured, returning payment without transaction")
@@ -325,6 +266,16 @@ func (s *paymentService) CreatePayment(orderID string, paymentMethod model.Payme
 		})
 	}
 
+	if order.GiftWrapFee > 0 {
+		itemDetails = append(itemDetails, MidtransItemDetail{
+			ID:       "gift_wrap",
+			Price:    order.GiftWrapFee,
+			Quantity: 1,
+			Name:     "Gift Wrap",
+			Category: "fee",
+		})
+	}
+
 	if order.ServiceFee > 0 {
 		itemDetails = append(itemDetails, MidtransItemDetail{
 			ID:       "service_fee",
@@ -335,6 +286,16 @@ func (s *paymentService) CreatePayment(orderID string, paymentMethod model.Payme
 		})
 	}
 
+	if order.DonationAmount > 0 {
+		itemDetails = append(itemDetails, MidtransItemDetail{
+			ID:       "donation",
+			Price:    order.DonationAmount,
+			Quantity: 1,
+			Name:     "Donation",
+			Category: "donation",
+		})
+	}
+
 	// Add discount as negative item (Midtrans requires item_details sum to equal gross_amount)
 	if order.TotalDiscount > 0 {
 		itemDetails = append(itemDetails, MidtransItemDetail{
@@ -418,10 +379,28 @@ func (s *paymentService) CreatePayment(orderID string, paymentMethod model.Payme
 		}
 
 	case model.PaymentMethodCreditCard:
+		enforce3DS, decision := s.decide3DS(ctx, order)
 		chargeData.CreditCard = &MidtransCreditCard{
-			Secure:         true,
-			Authentication: true,
+			Secure:         enforce3DS,
+			Authentication: enforce3DS,
+		}
+		if savedCard != nil {
+			// One-click: Midtrans accepts the saved_token_id in place of a fresh token
+			chargeData.CreditCard.TokenID = savedCard.SavedTokenID
+		} else if card != nil {
+			chargeData.CreditCard.TokenID = card.TokenID
+			chargeData.CreditCard.SaveTokenID = card.SaveCard
+		}
+		payment.ThreeDSEnforced = &enforce3DS
+		payment.ThreeDSDecision = &decision
+		if err := s.paymentRepo.Update(ctx, payment); err != nil {
+			// Nothing was charged yet; no card charge goes out without its 3DS audit trail
+			if err := s.paymentRepo.Delete(ctx, payment.ID); err != nil {
+				log.Printf("⚠️  Failed to delete uncharged payment %s: %v", payment.ID, err)
+			}
+			return nil, fmt.Errorf("failed to record 3DS decision: %w", err)
 		}
+		log.Printf("🔐 3DS decision for order %s: enforce=%v (%s)", order.OrderNumber, enforce3DS, decision)
 
 	case model.PaymentMethodAlfamart:
 		// Alfamart uses cstore payment type
@@ -429,52 +408,35 @@ func (s *paymentService) CreatePayment(orderID string, paymentMethod model.Payme
 		// Note: Alfamart callback should be configured in Midtrans Dashboard
 	}
 
-	// Charge to Midtrans
-	chargeJSON, err := json.Marshal(chargeData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal charge data: %v", err)
-	}
+	// A charge must not be abandoned halfway when the client disconnects (Midtrans would
+	// hold a transaction we never recorded), so detach from request cancellation and rely
+	// on the gateway's deadline. Shutdown waits for in-flight charges via inFlight.
+	s.inFlight.Add(1)
+	defer s.inFlight.Done()
 
-	baseURL := s.getMidtransBaseURL()
-	authHeader := s.getAuthHeader()
-
-	// Make HTTP request to Midtrans
-	reqHTTP, err := http.NewRequest("POST", baseURL+"/charge", bytes.NewBuffer(chargeJSON))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
+	midtransResp, body, err := s.gateway.Charge(context.WithoutCancel(ctx), &chargeData)
+	var unavailable *MidtransUnavailableError
+	if errors.As(err, &unavailable) {
+		// The breaker opened after the check above; Midtrans never saw the charge
+		if err := s.paymentRepo.Delete(ctx, payment.ID); err != nil {
+			log.Printf("⚠️  Failed to delete uncharged payment %s: %v", payment.ID, err)
+		}
+		return nil, err
 	}
-
-	reqHTTP.Header.Set("Authorization", authHeader)
-	reqHTTP.Header.Set("Content-Type", "application/json")
-	reqHTTP.Header.Set("Accept", "application/json")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(reqHTTP)
 	if err != nil {
 		log.Printf("⚠️  Failed to charge Midtrans: %v", err)
+		var apiErr *MidtransAPIError
+		if errors.As(err, &apiErr) {
+			// Store error response but don't fail
+			payment.MidtransResponse = &apiErr.Body
+			s.paymentRepo.Update(ctx, payment)
+		}
 		return payment, nil // Return payment even if Midtrans fails
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("⚠️  Failed to read Midtrans response: %v", err)
-		return payment, nil
-	}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		log.Printf("⚠️  Midtrans API returned status %d: %s", resp.StatusCode, string(body))
-		// Store error response but don't fail
-		errorResp := string(body)
-		payment.MidtransResponse = &errorResp
-		s.paymentRepo.Update(payment)
-		return payment, nil
-	}
-
-	var midtransResp MidtransChargeResponse
-	if err := json.Unmarshal(body, &midtransResp); err != nil {
-		log.Printf("⚠️  Failed to parse Midtrans response: %v", err)
-		return payment, nil
+	// Store the card for one-click checkout if the user consented and Midtrans returned a saved token
+	if card != nil && card.SaveCard && midtransResp.SavedTokenID != "" {
+		s.saveCard(ctx, order.UserID, midtransResp)
 	}
 
 	// Extract payment details from response
@@ -484,44 +446,13 @@ func (s *paymentService) CreatePayment(orderID string, paymentMethod model.Payme
 		bankTypeStr = midtransResp.VANumbers[0].Bank
 	}
 
-	// Extract QR code URL from actions (for Gopay/QRIS)
-	for _, action := range midtransResp.Actions {
-		if action.Name == "generate-qr-code" || action.Name == "generate-qr-code-v2" || action.Name == "qr-code" {
-			qrCodeURL = action.URL
-			break
-		}
-	}
-	// If not found by name, try by method GET
-	if qrCodeURL == "" {
-		for _, action := range midtransResp.Actions {
-			if action.Method == "GET" && action.URL != "" && strings.Contains(strings.ToLower(action.URL), "qr") {
-				qrCodeURL = action.URL
-				break
-			}
-		}
-	}
-
-	// Use QRCodeURL directly from response if available
+	// Extract QR code URL from actions (for Gopay/QRIS), falling back to the response field
+	qrCodeURL = qrCodeURLFromActions(midtransResp.Actions)
 	if qrCodeURL == "" && midtransResp.QRCodeURL != "" {
 		qrCodeURL = midtransResp.QRCodeURL
 	}
 
-	// Parse expiry time
-	var expiryTime *time.Time
-	if midtransResp.ExpiryTime != "" {
-		formats := []string{
-			time.RFC3339,
-			"2006-01-02 15:04:05",
-			"2006-01-02T15:04:05",
-		}
-		for _, format := range formats {
-			exp, err := time.Parse(format, midtransResp.ExpiryTime)
-			if err == nil {
-				expiryTime = &exp
-				break
-			}
-		}
-	}
+	expiryTime := parseMidtransTime(midtransResp.ExpiryTime)
 
 	// Update payment with Midtrans response
 	updateData := map[string]interface{}{
@@ -532,17 +463,18 @@ func (s *paymentService) CreatePayment(orderID string, paymentMethod model.Payme
 		"va_number":               vaNumber,
 		"bank_type":               bankTypeStr,
 		"qr_code_url":             qrCodeURL,
+		"qr_string":               midtransResp.QRString,
 		"expiry_time":             expiryTime,
 		"updated_at":              time.Now(),
 	}
 
 	// Update payment using repository
-	if err := s.updatePaymentFields(payment.ID, updateData); err != nil {
+	if err := s.updatePaymentFields(ctx, payment.ID, updateData); err != nil {
 		log.Printf("⚠️  Failed to update payment: %v", err)
 	}
 
 	// Reload payment with updated data
-	updatedPayment, err := s.paymentRepo.FindByID(payment.ID)
+	updatedPayment, err := s.paymentRepo.FindByID(ctx, payment.ID)
 	if err != nil {
 		return payment, nil
 	}
@@ -550,9 +482,52 @@ func (s *paymentService) CreatePayment(orderID string, paymentMethod model.Payme
 	return updatedPayment, nil
 }
 
+// decide3DS decides whether a credit card charge must go through 3DS.
+// Order-level overrides win; otherwise the configured policy applies. In risk_based
+// mode 3DS is only skipped for low-risk repeat customers below the amount threshold.
+func (s *paymentService) decide3DS(ctx context.Context, order *model.Order) (bool, string) {
+	if order.Require3DS != nil {
+		if *order.Require3DS {
+			return true, "order override: 3DS required"
+		}
+		return false, "order override: 3DS waived"
+	}
+
+	switch s.cfg.CreditCard3DSMode {
+	case "never":
+		return false, "policy: never"
+	case "risk_based":
+		// Evaluated below
+	default:
+		return true, "policy: always"
+	}
+
+	if order.TotalAmount > s.cfg.CreditCard3DSMaxAmount {
+		return true, fmt.Sprintf("risk_based: amount %d above threshold %d", order.TotalAmount, s.cfg.CreditCard3DSMaxAmount)
+	}
+
+	flagged, err := s.paymentRepo.CountFraudFlaggedByUserID(ctx, order.UserID)
+	if err != nil {
+		return true, "risk_based: fraud history unavailable"
+	}
+	if flagged > 0 {
+		return true, fmt.Sprintf("risk_based: %d fraud-flagged payment(s) on record", flagged)
+	}
+
+	paidOrders, err := s.paymentRepo.CountByUserID(ctx, order.UserID, model.PaymentStatusSuccess)
+	if err != nil {
+		return true, "risk_based: payment history unavailable"
+	}
+	if int(paidOrders) < s.cfg.CreditCard3DSMinPaidOrders {
+		return true, fmt.Sprintf("risk_based: %d paid order(s), need %d", paidOrders, s.cfg.CreditCard3DSMinPaidOrders)
+	}
+
+	return false, fmt.Sprintf("risk_based: low risk repeat customer (%d paid orders, amount %d)", paidOrders, order.TotalAmount)
+}
+
 // updatePaymentFields updates payment fields using repository
-func (s *paymentService) updatePaymentFields(paymentID string, updateData map[string]interface{}) error {
-	payment, err := s.paymentRepo.FindByID(paymentID)
+func (s *paymentService) updatePaymentFields(ctx context.Context, paymentID string, updateData map[string]interface{}) error {
+	payment, err := s.paymentRepo.FindByID(ctx, paymentID)
 	if err != nil {
 		return err
 	}
@@ -569,6 +544,7 @@ func (s *paymentService) updatePaymentFields(paymentID string, updateData map[st
 	}
 	if midtransResponse, ok := updateData["midtrans_response"].(string); ok {
 		payment.MidtransResponse = &midtransResponse
+		applyMidtransResponseFields(payment, midtransResponse)
 	}
 	if vaNumber, ok := updateData["va_number"].(string); ok && vaNumber != "" {
 		payment.VANumber = &vaNumber
@@ -579,110 +555,47 @@ func (s *paymentService) updatePaymentFields(paymentID string, updateData map[st
 	if qrCodeURL, ok := updateData["qr_code_url"].(string); ok && qrCodeURL != "" {
 		payment.QRCodeURL = &qrCodeURL
 	}
+	if qrString, ok := updateData["qr_string"].(string); ok && qrString != "" {
+		payment.QRString = &qrString
+	}
 	if expiryTime, ok := updateData["expiry_time"].(*time.Time); ok && expiryTime != nil {
 		payment.ExpiryTime = expiryTime
 	}
 
-	return s.paymentRepo.Update(payment)
+	return s.paymentRepo.Update(ctx, payment)
 }
 
-func (s *paymentService) GetPaymentByID(paymentID string) (*model.Payment, error) {
-	return s.paymentRepo.FindByID(paymentID)
+func (s *paymentService) GetPaymentByID(ctx context.Context, paymentID string) (*model.Payment, error) {
+	return s.paymentRepo.FindByID(ctx, paymentID)
 }
 
-func (s *paymentService) GetPaymentByOrderID(orderID string) (*model.Payment, error) {
-	return s.paymentRepo.FindByOrderID(orderID)
+func (s *paymentService) GetPaymentByOrderID(ctx context.Context, orderID string) (*model.Payment, error) {
+	return s.paymentRepo.FindByOrderID(ctx, orderID)
 }
 
-func (s *paymentService) HandleMidtransCallback(notification map[string]interface{}) error {
-	orderID, ok := notification["order_id"].(string)
-	if !ok {
-		log.Printf("❌ Invalid Midtrans callback: missing order_id")
-		return errors.New("invalid notification: missing order_id")
-	}
-
-	transactionID, ok := notification["transaction_id"].(string)
-	if !ok {
-		log.Printf("❌ Invalid Midtrans callback for order %s: missing transaction_id", orderID)
-		return errors.New("invalid notification: missing transaction_id")
+func (s *paymentService) HandleMidtransCallback(ctx context.Context, payload *MidtransNotification, rawBody []byte) error {
+	notification, err := s.parser.ParseCallback(payload, rawBody)
+	if err != nil {
+		log.Printf("❌ Invalid Midtrans callback: %v", err)
+		return err
 	}
 
-	transactionStatus, _ := notification["transaction_status"].(string)
 	log.Printf("📞 Midtrans callback received - Order Number: %s, Transaction ID: %s, Status: %s",
-		orderID, transactionID, transactionStatus)
-
-	var vaNumber, bankType, qrCodeURL string
-
-	// Extract VA numbers
-	if vaNumbers, ok := notification["va_numbers"].([]interface{}); ok && len(vaNumbers) > 0 {
-		if vaNum, ok := vaNumbers[0].(map[string]interface{}); ok {
-			vaNumber, _ = vaNum["va_number"].(string)
-			bankType, _ = vaNum["bank"].(string)
-		}
-	}
-
-	// Extract QR code URL
-	if qrCode, ok := notification["qr_code_url"].(string); ok {
-		qrCodeURL = qrCode
-	} else if actions, ok := notification["actions"].([]interface{}); ok && len(actions) > 0 {
-		for _, action := range actions {
-			if act, ok := action.(map[string]interface{}); ok {
-				name, _ := act["name"].(string)
-				url, _ := act["url"].(string)
-				if (name == "generate-qr-code" || name == "generate-qr-code-v2" || name == "qr-code") && url != "" {
-					qrCodeURL = url
-					break
-				}
-			}
-		}
-		// If not found by name, try by method GET
-		if qrCodeURL == "" {
-			for _, action := range actions {
-				if act, ok := action.(map[string]interface{}); ok {
-					method, _ := act["method"].(string)
-					url, _ := act["url"].(string)
-					if method == "GET" && url != "" && strings.Contains(strings.ToLower(url), "qr") {
-						qrCodeURL = url
-						break
-					}
-				}
-			}
-		}
-	}
-
-	var expiryTime *time.Time
-	if expiry, ok := notification["expiry_time"].(string); ok && expiry != "" {
-		formats := []string{
-			time.RFC3339,
-			"2006-01-02 15:04:05",
-			"2006-01-02T15:04:05",
-		}
-		for _, format := range formats {
-			exp, err := time.Parse(format, expiry)
-			if err == nil {
-				expiryTime = &exp
-				break
-			}
-		}
-	}
-
-	webhookJSON, _ := json.Marshal(notification)
-
-	log.Printf("🔄 Processing Midtrans callback - Order Number: %s, Status: %s", orderID, transactionStatus)
+		notification.OrderNumber, notification.TransactionID, notification.TransactionStatus)
 
 	// Update payment status with fraud status included in midtransResponse
-	// orderID here is the order_number we sent to Midtrans
-	if err := s.UpdatePaymentStatus(orderID, transactionStatus, transactionID, vaNumber, bankType, qrCodeURL, expiryTime, string(webhookJSON)); err != nil {
+	// OrderNumber here is the order_number we sent to Midtrans
+	if err := s.updater.Apply(ctx, notification); err != nil {
 		log.Printf("❌ Failed to update payment status from callback: %v", err)
 		return err
 	}
 
-	log.Printf("✅ Midtrans callback processed successfully - Order Number: %s, Status: %s", orderID, transactionStatus)
+	log.Printf("✅ Midtrans callback processed successfully - Order Number: %s, Status: %s", notification.OrderNumber, notification.TransactionStatus)
 	return nil
 }
 
-func (s *paymentService) CheckPaymentStatus(paymentID string) (*model.Payment, error) {
-	payment, err := s.paymentRepo.FindByID(paymentID)
+func (s *paymentService) CheckPaymentStatus(ctx context.Context, paymentID string) (*model.Payment, error) {
+	payment, err := s.paymentRepo.FindByID(ctx, paymentID)
 	if err != nil {
 		return nil, err
 	}
@@ -692,240 +605,490 @@ func (s *paymentService) CheckPaymentStatus(paymentID string) (*model.Payment, e
 		payment.Status == model.PaymentStatusPending && s.cfg.MidtransServerKey != "" {
 		log.Printf("🔍 Checking payment status from Midtrans for payment ID: %s, Order Number: %s, Transaction ID: %s",
 			paymentID, payment.OrderID, *payment.MidtransTransactionID)
-		if err := s.CheckPaymentStatusFromMidtrans(payment.OrderID); err != nil {
+		if err := s.CheckPaymentStatusFromMidtrans(ctx, payment.OrderID); err != nil {
 			log.Printf("⚠️  Failed to check payment status from Midtrans: %v", err)
 			// Don't return error, return current payment status instead
 		} else {
 			log.Printf("✅ Payment status check completed for payment ID: %s", paymentID)
 		}
 		// Reload payment after status check to get updated status
-		payment, _ = s.paymentRepo.FindByID(paymentID)
+		payment, _ = s.paymentRepo.FindByID(ctx, paymentID)
 	}
 
 	return payment, nil
 }
 
-// CheckPaymentStatusFromMidtrans checks payment status from Midtrans API
-func (s *paymentService) CheckPaymentStatusFromMidtrans(orderNumber string) error {
-	// Get payment from database first by order number
-	payment, err := s.paymentRepo.FindByOrderNumber(orderNumber)
+// WaitForPaymentStatusChange blocks until the payment status differs from knownStatus or wait
+// elapses, then returns the latest payment. An empty knownStatus means the status at call time.
+func (s *paymentService) WaitForPaymentStatusChange(ctx context.Context, paymentID string, knownStatus model.PaymentStatus, wait time.Duration) (*model.Payment, error) {
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	// Subscribe before reading the current status so a change in between is not missed
+	var notifications <-chan *redis.Message
+	if s.redis != nil {
+		pubsub, err := s.redis.Subscribe(ctx, util.PaymentStatusChannel(paymentID))
+		if err != nil {
+			log.Printf("⚠️  Failed to subscribe to payment status channel, falling back to polling: %v", err)
+		} else {
+			defer pubsub.Close()
+			notifications = pubsub.Channel()
+		}
+	}
+
+	payment, err := s.CheckPaymentStatus(ctx, paymentID)
 	if err != nil {
-		log.Printf("❌ Payment not found for order number %s: %v", orderNumber, err)
-		return fmt.Errorf("payment not found for order number %s: %v", orderNumber, err)
+		return nil, err
+	}
+	if knownStatus == "" {
+		knownStatus = payment.Status
+	}
+	if payment.Status != knownStatus {
+		return payment, nil
 	}
 
-	// If already successful, skip check
-	if payment.Status == model.PaymentStatusSuccess {
-		log.Printf("✅ Payment for order %s already successful, skipping check", orderNumber)
-		return nil
+	// Without Redis, re-read the database periodically instead
+	var poll <-chan time.Time
+	if notifications == nil {
+		ticker := time.NewTicker(paymentStatusFallbackPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
 	}
 
-	// If no transaction ID, cannot check
-	if payment.MidtransTransactionID == nil || *payment.MidtransTransactionID == "" {
-		log.Printf("⚠️  No transaction ID for payment with order number %s", orderNumber)
-		return fmt.Errorf("no transaction ID for payment")
+	for {
+		select {
+		case <-ctx.Done():
+			// Timed out or client went away: report whatever we have now
+			return s.paymentRepo.FindByID(context.WithoutCancel(ctx), paymentID)
+		case msg, ok := <-notifications:
+			if !ok {
+				return s.paymentRepo.FindByID(context.WithoutCancel(ctx), paymentID)
+			}
+			if model.PaymentStatus(msg.Payload) == knownStatus {
+				continue
+			}
+		case <-poll:
+		}
+
+		payment, err = s.paymentRepo.FindByID(ctx, paymentID)
+		if err != nil {
+			return nil, err
+		}
+		if payment.Status != knownStatus {
+			return payment, nil
+		}
 	}
+}
 
-	log.Printf("🔍 Checking Midtrans status for transaction ID: %s (Order: %s)", *payment.MidtransTransactionID, orderNumber)
+// CheckPaymentStatusFromMidtrans checks payment status from Midtrans API
+func (s *paymentService) CheckPaymentStatusFromMidtrans(ctx context.Context, orderNumber string) error {
+	return s.poller.SyncPayment(ctx, orderNumber)
+}
 
-	// Call Midtrans status API
-	baseURL := s.getMidtransBaseURL()
-	authHeader := s.getAuthHeader()
-	url := fmt.Sprintf("%s/%s/status", baseURL, *payment.MidtransTransactionID)
+// UpdatePaymentStatus updates payment status from Midtrans webhook or status check
+// orderID parameter here is actually the order_number (not UUID)
+func (s *paymentService) UpdatePaymentStatus(ctx context.Context, orderNumber string, status string, transactionID string, vaNumber string, bankType string, qrCodeURL string, expiryTime *time.Time, midtransResponse string) error {
+	return s.updater.Apply(ctx, &PaymentNotification{
+		OrderNumber:       orderNumber,
+		TransactionID:     transactionID,
+		TransactionStatus: status,
+		VANumber:          vaNumber,
+		BankType:          bankType,
+		QRCodeURL:         qrCodeURL,
+		ExpiryTime:        expiryTime,
+		Raw:               midtransResponse,
+	})
+}
 
-	log.Printf("📍 Midtrans status API URL: %s", url)
+// CheckTransferProofUpload reports whether the user may upload a transfer proof for the payment,
+// so the image is only stored once the request is known to be valid
+func (s *paymentService) CheckTransferProofUpload(ctx context.Context, paymentID string, userID string) error {
+	_, err := s.findTransferProofPayment(ctx, paymentID, userID)
+	return err
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// SubmitTransferProof attaches the buyer's transfer receipt to a manual transfer payment
+func (s *paymentService) SubmitTransferProof(ctx context.Context, paymentID string, userID string, proofImageURL string) (*model.Payment, error) {
+	payment, err := s.findTransferProofPayment(ctx, paymentID, userID)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
+		return nil, err
 	}
 
-	req.Header.Set("Authorization", authHeader)
-	req.Header.Set("Accept", "application/json")
+	now := time.Now()
+	payment.ProofImageURL = &proofImageURL
+	payment.ProofUploadedAt = &now
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	if err := s.paymentRepo.Update(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to save transfer proof: %v", err)
+	}
+
+	log.Printf("🧾 Transfer proof uploaded for payment %s (Order: %s)", payment.ID, payment.OrderID)
+	return payment, nil
+}
+
+// findTransferProofPayment loads a pending manual transfer payment owned by the user
+func (s *paymentService) findTransferProofPayment(ctx context.Context, paymentID string, userID string) (*model.Payment, error) {
+	payment, err := s.paymentRepo.FindByID(ctx, paymentID)
 	if err != nil {
-		return fmt.Errorf("failed to call Midtrans API: %v", err)
+		return nil, errors.New("payment not found")
 	}
-	defer resp.Body.Close()
+	if payment.Order.UserID != userID {
+		return nil, errors.New("payment does not belong to user")
+	}
+	if payment.PaymentMethod != model.PaymentMethodManualTransfer {
+		return nil, errors.New("transfer proof is only accepted for manual transfer payments")
+	}
+	if payment.Status != model.PaymentStatusPending {
+		return nil, errors.New("payment is no longer pending")
+	}
+	return payment, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+// VerifyManualTransfer approves or rejects an uploaded transfer proof.
+// Approval marks the payment successful and moves the order to processing.
+func (s *paymentService) VerifyManualTransfer(ctx context.Context, paymentID string, adminID string, approved bool, note *string) (*model.Payment, error) {
+	payment, err := s.paymentRepo.FindByID(ctx, paymentID)
 	if err != nil {
-		return fmt.Errorf("failed to read response: %v", err)
+		return nil, errors.New("payment not found")
+	}
+	if payment.PaymentMethod != model.PaymentMethodManualTransfer {
+		return nil, errors.New("payment is not a manual transfer")
+	}
+	if payment.Status != model.PaymentStatusPending {
+		return nil, errors.New("payment has already been reviewed")
+	}
+	if payment.ProofImageURL == nil || *payment.ProofImageURL == "" {
+		return nil, errors.New("buyer has not uploaded a transfer proof yet")
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("⚠️  Midtrans API returned status %d: %s", resp.StatusCode, string(body))
-		return fmt.Errorf("Midtrans API error (status %d): %s", resp.StatusCode, string(body))
+	now := time.Now()
+	payment.VerifiedBy = &adminID
+	payment.VerifiedAt = &now
+	payment.VerificationNote = note
+	if approved {
+		payment.Status = model.PaymentStatusSuccess
+	} else {
+		payment.Status = model.PaymentStatusFailed
 	}
 
-	var midtransResp map[string]interface{}
-	if err := json.Unmarshal(body, &midtransResp); err != nil {
-		log.Printf("❌ Failed to parse Midtrans response: %v", err)
-		return fmt.Errorf("failed to parse response: %v", err)
+	if err := s.paymentRepo.Update(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to update payment: %v", err)
 	}
 
-	// Extract status information
-	transactionStatus, ok := midtransResp["transaction_status"].(string)
-	if !ok || transactionStatus == "" {
-		log.Printf("⚠️  No transaction_status in Midtrans response: %s", string(body))
-		return fmt.Errorf("no transaction_status in response")
+	log.Printf("✅ Manual transfer reviewed for payment %s (Order: %s) by admin %s - status: %s",
+		payment.ID, payment.OrderID, adminID, payment.Status)
+	s.updater.PublishStatusChange(ctx, payment)
+
+	if approved {
+		s.updater.MarkOrderPaid(ctx, payment.OrderUUID)
 	}
 
-	transactionID, _ := midtransResp["transaction_id"].(string)
-	orderIDFromMidtrans, _ := midtransResp["order_id"].(string)
+	return s.paymentRepo.FindByID(ctx, payment.ID)
+}
 
-	log.Printf("📊 Midtrans response - Status: %s, Transaction ID: %s, Order ID: %s",
-		transactionStatus, transactionID, orderIDFromMidtrans)
+// ResendPaymentInstructions re-delivers the VA / QR / payment code details of a pending
+// payment to the buyer's email. Requests are limited to one per payment per cooldown window.
+func (s *paymentService) ResendPaymentInstructions(ctx context.Context, paymentID string, userID string) error {
+	payment, err := s.paymentRepo.FindByID(ctx, paymentID)
+	if err != nil {
+		return errors.New("payment not found")
+	}
+	if payment.Order.UserID != userID {
+		return errors.New("payment does not belong to user")
+	}
+	if payment.Status != model.PaymentStatusPending {
+		return errors.New("payment is no longer pending")
+	}
 
-	var vaNumber, bankType, qrCodeURL string
-	if vaNumbers, ok := midtransResp["va_numbers"].([]interface{}); ok && len(vaNumbers) > 0 {
-		if va, ok := vaNumbers[0].(map[string]interface{}); ok {
-			vaNumber, _ = va["va_number"].(string)
-			bankType, _ = va["bank"].(string)
-		}
+	order, err := s.orderRepo.FindByID(ctx, payment.OrderUUID)
+	if err != nil {
+		return errors.New("order not found")
 	}
 
-	// Extract QR code URL from actions
-	if actions, ok := midtransResp["actions"].([]interface{}); ok && len(actions) > 0 {
-		for _, action := range actions {
-			if act, ok := action.(map[string]interface{}); ok {
-				name, _ := act["name"].(string)
-				url, _ := act["url"].(string)
-				if (name == "generate-qr-code" || name == "generate-qr-code-v2" || name == "qr-code") && url != "" {
-					qrCodeURL = url
-					log.Printf("✅ Found QR code URL from action '%s': %s", name, qrCodeURL)
-					break
-				}
-			}
+	if s.rabbitMQ == nil {
+		return errors.New("email service is unavailable, please try again later")
+	}
+	if !s.claimResend(ctx, paymentID) {
+		return ErrResendTooSoon
+	}
+
+	data := map[string]string{
+		"order_number":   payment.OrderID,
+		"amount":         fmt.Sprintf("Rp %d", payment.TotalAmount),
+		"payment_method": string(payment.PaymentMethod),
+	}
+	if payment.BankType != nil {
+		data["bank"] = strings.ToUpper(*payment.BankType)
+	}
+	if payment.VANumber != nil {
+		data["va_number"] = *payment.VANumber
+	}
+	if payment.QRCodeURL != nil {
+		data["qr_code_url"] = *payment.QRCodeURL
+	}
+	if payment.ExpiryTime != nil {
+		data["expiry_time"] = payment.ExpiryTime.Format("02 Jan 2006 15:04")
+	}
+
+	emailMsg := util.EmailMessage{
+		To:      order.User.Email,
+		Subject: "Instruksi Pembayaran",
+		Type:    "payment_instructions",
+		Data:    data,
+	}
+	if err := s.rabbitMQ.PublishEmail(emailMsg); err != nil {
+		s.releaseResend(ctx, paymentID)
+		log.Printf("❌ Failed to queue payment instructions for payment %s: %v", paymentID, err)
+		return errors.New("failed to send payment instructions")
+	}
+
+	log.Printf("📧 Payment instructions re-sent for payment %s (Order: %s) to %s", payment.ID, payment.OrderID, order.User.Email)
+	return nil
+}
+
+// claimResend starts the resend cooldown of a payment and reports whether the previous one had run
+// out. The cooldown is kept in Redis when available, so it holds across instances and expires on
+// its own; otherwise in memory, where expired cooldowns are swept on each claim.
+func (s *paymentService) claimResend(ctx context.Context, paymentID string) bool {
+	if s.redis != nil {
+		claimed, err := s.redis.Claim(ctx, util.PaymentResendKey(paymentID), paymentInstructionsResendCooldown)
+		if err == nil {
+			return claimed
 		}
-		// If not found by name, try by method GET
-		if qrCodeURL == "" {
-			for _, action := range actions {
-				if act, ok := action.(map[string]interface{}); ok {
-					method, _ := act["method"].(string)
-					url, _ := act["url"].(string)
-					if method == "GET" && url != "" && strings.Contains(strings.ToLower(url), "qr") {
-						qrCodeURL = url
-						log.Printf("✅ Found QR code URL from GET method: %s", qrCodeURL)
-						break
-					}
-				}
-			}
+		log.Printf("⚠️  Redis resend cooldown unavailable for payment %s, falling back to memory: %v", paymentID, err)
+	}
+
+	s.resendMu.Lock()
+	defer s.resendMu.Unlock()
+	now := time.Now()
+	for id, last := range s.lastResendTime {
+		if now.Sub(last) >= paymentInstructionsResendCooldown {
+			delete(s.lastResendTime, id)
 		}
 	}
+	if _, ok := s.lastResendTime[paymentID]; ok {
+		return false
+	}
+	s.lastResendTime[paymentID] = now
+	return true
+}
 
-	// If QR code URL not found in response but payment already has one, preserve it
-	if qrCodeURL == "" && payment.QRCodeURL != nil && *payment.QRCodeURL != "" {
-		log.Printf("⚠️  QR code URL not in response, preserving existing: %s", *payment.QRCodeURL)
-		qrCodeURL = *payment.QRCodeURL
+// releaseResend ends the resend cooldown of a payment early, after the resend failed
+func (s *paymentService) releaseResend(ctx context.Context, paymentID string) {
+	if s.redis != nil {
+		if err := s.redis.Delete(ctx, util.PaymentResendKey(paymentID)); err != nil {
+			log.Printf("⚠️  Failed to clear resend cooldown for payment %s: %v", paymentID, err)
+		}
 	}
+	s.resendMu.Lock()
+	delete(s.lastResendTime, paymentID)
+	s.resendMu.Unlock()
+}
 
-	// Extract expiry time
-	var expiryTime *time.Time
-	if expiry, ok := midtransResp["expiry_time"].(string); ok && expiry != "" {
-		formats := []string{
-			time.RFC3339,
-			"2006-01-02 15:04:05",
-			"2006-01-02T15:04:05",
+// saveCard stores (or refreshes) a Midtrans saved_token_id for the user
+func (s *paymentService) saveCard(ctx context.Context, userID string, resp *MidtransChargeResponse) {
+	now := time.Now()
+	var expiresAt *time.Time
+	if resp.SavedTokenIDExpiredAt != "" {
+		if exp, err := time.Parse("2006-01-02 15:04:05", resp.SavedTokenIDExpiredAt); err == nil {
+			expiresAt = &exp
 		}
-		for _, format := range formats {
-			exp, err := time.Parse(format, expiry)
-			if err == nil {
-				expiryTime = &exp
-				break
-			}
+	}
+
+	card, err := s.savedCardRepo.FindByUserAndMaskedCard(ctx, userID, resp.MaskedCard)
+	if err != nil {
+		card = &model.SavedCard{
+			UserID:     userID,
+			MaskedCard: resp.MaskedCard,
 		}
 	}
+	card.SavedTokenID = resp.SavedTokenID
+	card.ExpiresAt = expiresAt
+	card.ConsentGiven = true
+	card.ConsentAt = &now
+	if resp.Bank != "" {
+		card.Bank = &resp.Bank
+	}
+	if resp.CardType != "" {
+		card.CardType = &resp.CardType
+	}
 
-	webhookJSON, _ := json.Marshal(midtransResp)
+	if card.ID == "" {
+		err = s.savedCardRepo.Create(ctx, card)
+	} else {
+		err = s.savedCardRepo.Update(ctx, card)
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to save card for user %s: %v", userID, err)
+		return
+	}
+	log.Printf("💳 Card %s saved for user %s", card.MaskedCard, userID)
+}
 
-	// Use order number from parameter (not from Midtrans response, as it might differ)
-	// The orderNumber parameter is the order_number we sent to Midtrans
-	log.Printf("🔄 Updating payment status for order number: %s with status: %s", orderNumber, transactionStatus)
+func (s *paymentService) GetSavedCards(ctx context.Context, userID string) ([]model.SavedCard, error) {
+	return s.savedCardRepo.FindByUserID(ctx, userID)
+}
 
-	return s.UpdatePaymentStatus(orderNumber, transactionStatus, transactionID, vaNumber, bankType, qrCodeURL, expiryTime, string(webhookJSON))
+func (s *paymentService) DeleteSavedCard(ctx context.Context, userID string, cardID string) error {
+	card, err := s.savedCardRepo.FindByID(ctx, cardID)
+	if err != nil || card.UserID != userID {
+		return errors.New("saved card not found")
+	}
+	return s.savedCardRepo.Delete(ctx, cardID)
 }
 
-// UpdatePaymentStatus updates payment status from Midtrans webhook or status check
-// orderID parameter here is actually the order_number (not UUID)
-func (s *paymentService) UpdatePaymentStatus(orderNumber string, status string, transactionID string, vaNumber string, bankType string, qrCodeURL string, expiryTime *time.Time, midtransResponse string) error {
-	paymentStatus := mapMidtransStatusToPaymentStatus(status)
+// GetMidtransBudgetStats reports the background poller's Midtrans API budget consumption
+func (s *paymentService) GetMidtransBudgetStats() MidtransBudgetStats {
+	return s.poller.BudgetStats()
+}
 
-	log.Printf("🔄 Updating payment status - Order Number: %s, Status: %s -> %s", orderNumber, status, paymentStatus)
+// GetMidtransBreakerStats reports whether calls to Midtrans are currently being skipped
+func (s *paymentService) GetMidtransBreakerStats() MidtransBreakerStats {
+	return s.breaker.Stats()
+}
 
-	// Get payment by order number (order_number, not UUID)
-	payment, err := s.paymentRepo.FindByOrderNumber(orderNumber)
-	if err != nil {
-		log.Printf("❌ Payment not found for order number %s: %v", orderNumber, err)
-		return fmt.Errorf("payment not found for order number: %s", orderNumber)
+// Shutdown stops the background jobs and waits for in-flight charges and
+// status checks to finish, or for ctx to expire
+func (s *paymentService) Shutdown(ctx context.Context) error {
+	s.bgCancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		s.poller.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Println("✅ Payment service stopped, no charges in flight")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("payment service shutdown: %w", ctx.Err())
 	}
+}
 
-	log.Printf("📝 Current payment status: %s, updating to: %s", payment.Status, paymentStatus)
-
-	// Preserve existing values if new ones are empty
-	if qrCodeURL == "" && payment.QRCodeURL != nil && *payment.QRCodeURL != "" {
-		qrCodeURL = *payment.QRCodeURL
+// GetPaymentQRCode renders the QRIS/GoPay QR payload of a pending payment as a PNG so clients
+// do not depend on the Midtrans image URL. Rendered images are cached in Redis until the
+// payment expires. The payment's expiry time is returned for HTTP caching.
+func (s *paymentService) GetPaymentQRCode(ctx context.Context, paymentID string, userID string) ([]byte, *time.Time, error) {
+	payment, err := s.paymentRepo.FindByID(ctx, paymentID)
+	if err != nil {
+		return nil, nil, errors.New("payment not found")
 	}
-	if vaNumber == "" && payment.VANumber != nil && *payment.VANumber != "" {
-		vaNumber = *payment.VANumber
+	if payment.Order.UserID != userID {
+		return nil, nil, errors.New("payment does not belong to user")
 	}
-	if bankType == "" && payment.BankType != nil && *payment.BankType != "" {
-		bankType = *payment.BankType
+	if payment.PaymentMethod != model.PaymentMethodQRIS && payment.PaymentMethod != model.PaymentMethodGopay {
+		return nil, nil, ErrQRCodeUnavailable
 	}
-
-	// Update payment fields
-	payment.Status = paymentStatus
-	if transactionID != "" {
-		payment.MidtransTransactionID = &transactionID
+	if payment.Status != model.PaymentStatusPending {
+		return nil, nil, errors.New("payment is no longer pending")
 	}
-	if vaNumber != "" {
-		payment.VANumber = &vaNumber
+	if payment.QRString == nil || *payment.QRString == "" {
+		return nil, nil, ErrQRCodeUnavailable
 	}
-	if bankType != "" {
-		payment.BankType = &bankType
-	}
-	if qrCodeURL != "" {
-		payment.QRCodeURL = &qrCodeURL
+
+	cacheKey := qrCodeCacheKeyPrefix + payment.ID
+	if s.redis != nil {
+		if cached, err := s.redis.GetBytes(ctx, cacheKey); err != nil {
+			log.Printf("⚠️  Failed to read cached QR code for payment %s: %v", payment.ID, err)
+		} else if cached != nil {
+			return cached, payment.ExpiryTime, nil
+		}
 	}
-	if expiryTime != nil {
-		payment.ExpiryTime = expiryTime
+
+	png, err := qrcode.Encode(*payment.QRString, qrcode.Medium, qrCodeImageSize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate QR code: %v", err)
 	}
-	if midtransResponse != "" {
-		payment.MidtransResponse = &midtransResponse
-		// Extract fraud_status from midtransResponse if available
-		var responseMap map[string]interface{}
-		if err := json.Unmarshal([]byte(midtransResponse), &responseMap); err == nil {
-			if fraudStatus, ok := responseMap["fraud_status"].(string); ok && fraudStatus != "" {
-				payment.FraudStatus = &fraudStatus
+
+	if s.redis != nil {
+		ttl := qrCodeDefaultTTL
+		if payment.ExpiryTime != nil {
+			ttl = time.Until(*payment.ExpiryTime)
+		}
+		if ttl > 0 {
+			if err := s.redis.SetBytes(ctx, cacheKey, png, ttl); err != nil {
+				log.Printf("⚠️  Failed to cache QR code for payment %s: %v", payment.ID, err)
 			}
 		}
 	}
 
-	if err := s.paymentRepo.Update(payment); err != nil {
-		log.Printf("❌ Failed to update payment: %v", err)
-		return err
+	return png, payment.ExpiryTime, nil
+}
+
+// CancelPaymentForOrder cancels the order's pending payment, including the Midtrans transaction
+// when one exists. Orders without a payment, or whose payment already ended unpaid, are a no-op.
+func (s *paymentService) CancelPaymentForOrder(ctx context.Context, orderUUID string) error {
+	payment, err := s.paymentRepo.FindByOrderID(ctx, orderUUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // No payment created yet
+	}
+	if err != nil {
+		// The payment may still be live at Midtrans; fail so the cancel is retried
+		return fmt.Errorf("failed to find payment: %w", err)
 	}
 
-	log.Printf("✅ Payment updated successfully - Order Number: %s, New Status: %s", orderNumber, paymentStatus)
+	switch payment.Status {
+	case model.PaymentStatusSuccess:
+		return errors.New("order has already been paid")
+	case model.PaymentStatusPending:
+	default:
+		return nil // Already failed, expired or cancelled
+	}
 
-	// Update order status if payment is successful
-	if paymentStatus == model.PaymentStatusSuccess {
-		order, err := s.orderRepo.FindByID(payment.OrderUUID)
-		if err == nil {
-			if order.Status == "pending" {
-				order.Status = "processing"
-				if err := s.orderRepo.Update(order); err != nil {
-					log.Printf("⚠️  Failed to update order status: %v", err)
-				} else {
-					log.Printf("✅ Order status updated to 'processing' for order UUID: %s", payment.OrderUUID)
-				}
-			}
-		} else {
-			log.Printf("⚠️  Order not found for UUID %s: %v", payment.OrderUUID, err)
+	if payment.MidtransTransactionID != nil && *payment.MidtransTransactionID != "" {
+		if err := s.gateway.Cancel(ctx, *payment.MidtransTransactionID); err != nil {
+			return err
 		}
 	}
 
+	payment.Status = model.PaymentStatusCancelled
+	if err := s.paymentRepo.Update(ctx, payment); err != nil {
+		return fmt.Errorf("failed to update payment: %v", err)
+	}
+
+	log.Printf("🚫 Payment %s cancelled (Order: %s)", payment.ID, payment.OrderID)
+	s.updater.PublishStatusChange(ctx, payment)
 	return nil
 }
+
+// RefundPayment gives part or all of a successful payment back to the buyer and returns the refund
+// method used. Midtrans payments are refunded through the API; refundKey makes retries of the same
+// refund safe. Manual transfers cannot be refunded automatically and are only recorded.
+func (s *paymentService) RefundPayment(ctx context.Context, orderUUID string, refundKey string, amount int, reason string) (string, error) {
+	payment, err := s.paymentRepo.FindByOrderID(ctx, orderUUID)
+	if err != nil {
+		return "", errors.New("payment not found")
+	}
+	if payment.Status != model.PaymentStatusSuccess {
+		return "", errors.New("only successful payments can be refunded")
+	}
+	if amount <= 0 || amount > payment.TotalAmount-payment.RefundedAmount {
+		return "", fmt.Errorf("refund amount must be between 1 and %d", payment.TotalAmount-payment.RefundedAmount)
+	}
+
+	method := model.RefundMethodMidtrans
+	if payment.PaymentMethod == model.PaymentMethodManualTransfer {
+		method = model.RefundMethodManual
+		log.Printf("⚠️  Manual transfer payment %s needs a manual refund of %d (key: %s)", payment.ID, amount, refundKey)
+	} else {
+		// Midtrans accepts either the transaction ID or our order number
+		transactionID := payment.OrderID
+		if payment.MidtransTransactionID != nil && *payment.MidtransTransactionID != "" {
+			transactionID = *payment.MidtransTransactionID
+		}
+		if err := s.gateway.Refund(ctx, transactionID, refundKey, amount, reason); err != nil {
+			return "", err
+		}
+	}
+
+	payment.RefundedAmount += amount
+	if err := s.paymentRepo.Update(ctx, payment); err != nil {
+		return "", fmt.Errorf("failed to update payment: %v", err)
+	}
+
+	log.Printf("💸 Payment %s refunded %d via %s (Order: %s)", payment.ID, amount, method, payment.OrderID)
+	return method, nil
+}
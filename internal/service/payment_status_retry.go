@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"yourapp/internal/model"
+)
+
+// errPaymentNotSaved marks a payment status update that failed on the database side and is worth retrying
+var errPaymentNotSaved = errors.New("payment status could not be saved")
+
+// Payment status retry limits
+const (
+	paymentRetryBatchSize   = 50
+	paymentRetryMaxBackoff  = time.Hour
+	paymentRetryBacklogSize = 1000 // In-memory retries kept while the retry table is unreachable
+)
+
+// enqueuePaymentStatusRetry stores a failed status update for the retry worker. If the retry table
+// cannot be written either, the update is kept in memory until the worker can store it.
+func (u *paymentStatusUpdater) enqueuePaymentStatusRetry(update *model.PaymentStatusRetry, cause error) {
+	message := cause.Error()
+	update.LastError = &message
+	update.NextAttemptAt = time.Now().Add(u.paymentRetryInterval())
+
+	// The retry must be stored even if the webhook request was cancelled
+	if err := u.retryRepo.Create(context.Background(), update); err != nil {
+		u.retryMu.Lock()
+		defer u.retryMu.Unlock()
+		if len(u.retryBacklog) >= paymentRetryBacklogSize {
+			log.Printf("❌ Payment status retry backlog full, dropping %s for order %s: %v", update.MidtransStatus, update.OrderNumber, err)
+			return
+		}
+		u.retryBacklog = append(u.retryBacklog, *update)
+		log.Printf("⚠️  Payment status retry for order %s kept in memory: %v", update.OrderNumber, err)
+		return
+	}
+	log.Printf("🔁 Payment status %s for order %s queued for retry", update.MidtransStatus, update.OrderNumber)
+}
+
+// RunRetryWorker periodically replays payment status updates that failed to save
+func (u *paymentStatusUpdater) RunRetryWorker(ctx context.Context) {
+	ticker := time.NewTicker(u.paymentRetryInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			u.flushRetryBacklog(ctx)
+			u.retryPaymentStatuses(ctx)
+		case <-ctx.Done():
+			log.Println("🛑 Payment status retry worker stopped")
+			return
+		}
+	}
+}
+
+// flushRetryBacklog moves in-memory retries into the retry table
+func (u *paymentStatusUpdater) flushRetryBacklog(ctx context.Context) {
+	u.retryMu.Lock()
+	backlog := u.retryBacklog
+	u.retryBacklog = nil
+	u.retryMu.Unlock()
+
+	for i := range backlog {
+		if err := u.retryRepo.Create(ctx, &backlog[i]); err != nil {
+			u.retryMu.Lock()
+			u.retryBacklog = append(backlog[i:], u.retryBacklog...)
+			u.retryMu.Unlock()
+			return
+		}
+	}
+}
+
+// retryPaymentStatuses replays due retries, backing off exponentially. Retries that keep failing
+// are parked with dead_at set and need manual reconciliation.
+func (u *paymentStatusUpdater) retryPaymentStatuses(ctx context.Context) {
+	retries, err := u.retryRepo.FindDue(ctx, time.Now(), paymentRetryBatchSize)
+	if err != nil {
+		log.Printf("⚠️  Failed to fetch payment status retries: %v", err)
+		return
+	}
+
+	for i := range retries {
+		if ctx.Err() != nil {
+			return // Shutting down
+		}
+		retry := &retries[i]
+
+		err := u.applyPaymentStatus(ctx, retry, true)
+		if err == nil {
+			if err := u.retryRepo.Delete(ctx, retry.ID); err != nil {
+				log.Printf("⚠️  Failed to delete payment status retry %s: %v", retry.ID, err)
+			}
+			log.Printf("✅ Payment status %s for order %s saved after %d retries", retry.MidtransStatus, retry.OrderNumber, retry.Attempts+1)
+			continue
+		}
+
+		now := time.Now()
+		message := err.Error()
+		retry.Attempts++
+		retry.LastError = &message
+		if retry.Attempts >= u.cfg.PaymentRetryMaxAttempts {
+			retry.DeadAt = &now
+			log.Printf("❌ Giving up on payment status %s for order %s after %d attempts, reconcile manually: %v", retry.MidtransStatus, retry.OrderNumber, retry.Attempts, err)
+		} else {
+			backoff := u.paymentRetryInterval() << retry.Attempts
+			if backoff <= 0 || backoff > paymentRetryMaxBackoff {
+				backoff = paymentRetryMaxBackoff
+			}
+			retry.NextAttemptAt = now.Add(backoff)
+		}
+		if err := u.retryRepo.Update(ctx, retry); err != nil {
+			log.Printf("⚠️  Failed to update payment status retry %s: %v", retry.ID, err)
+		}
+	}
+}
+
+func (u *paymentStatusUpdater) paymentRetryInterval() time.Duration {
+	if u.cfg.PaymentRetryIntervalSeconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(u.cfg.PaymentRetryIntervalSeconds) * time.Second
+}
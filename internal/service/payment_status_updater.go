@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+
+	"gorm.io/gorm"
+)
+
+// PaymentStatusUpdater saves gateway-reported payment statuses and runs their side effects:
+// notifying long-polling clients, moving paid orders to processing and running the
+// AfterPaymentSuccess hooks
+type PaymentStatusUpdater interface {
+	// Apply saves a reported status. Updates that fail on the database side are queued for retry.
+	Apply(ctx context.Context, notification *PaymentNotification) error
+	// PublishStatusChange runs the payment status hooks and notifies long-polling clients that a
+	// payment's status changed
+	PublishStatusChange(ctx context.Context, payment *model.Payment)
+	// MarkOrderPaid moves a pending order to processing once its payment succeeds
+	MarkOrderPaid(ctx context.Context, orderUUID string)
+	// RunRetryWorker replays queued status updates until ctx is cancelled
+	RunRetryWorker(ctx context.Context)
+}
+
+type paymentStatusUpdater struct {
+	paymentRepo repository.PaymentRepository
+	retryRepo   repository.PaymentStatusRetryRepository
+	orderRepo   repository.OrderRepository
+	hooks       *HookRegistry
+	redis       *util.RedisClient // Optional; publishes status changes for long-polling clients
+	cfg         *config.Config
+
+	retryMu      sync.Mutex
+	retryBacklog []model.PaymentStatusRetry // Retries that could not be stored yet (database down)
+}
+
+func NewPaymentStatusUpdater(
+	paymentRepo repository.PaymentRepository,
+	retryRepo repository.PaymentStatusRetryRepository,
+	orderRepo repository.OrderRepository,
+	hooks *HookRegistry,
+	redisClient *util.RedisClient,
+	cfg *config.Config,
+) PaymentStatusUpdater {
+	return &paymentStatusUpdater{
+		paymentRepo: paymentRepo,
+		retryRepo:   retryRepo,
+		orderRepo:   orderRepo,
+		hooks:       hooks,
+		redis:       redisClient,
+		cfg:         cfg,
+	}
+}
+
+// mapMidtransStatusToPaymentStatus maps Midtrans status to PaymentStatus
+func mapMidtransStatusToPaymentStatus(status string) model.PaymentStatus {
+	switch status {
+	case "pending":
+		return model.PaymentStatusPending
+	case "settlement", "capture":
+		return model.PaymentStatusSuccess
+	case "deny":
+		return model.PaymentStatusFailed
+	case "cancel":
+		return model.PaymentStatusCancelled
+	case "expire":
+		return model.PaymentStatusExpired
+	default:
+		return model.PaymentStatusPending
+	}
+}
+
+func (u *paymentStatusUpdater) Apply(ctx context.Context, notification *PaymentNotification) error {
+	update := &model.PaymentStatusRetry{
+		OrderNumber:      notification.OrderNumber,
+		MidtransStatus:   notification.TransactionStatus,
+		TransactionID:    notification.TransactionID,
+		VANumber:         notification.VANumber,
+		BankType:         notification.BankType,
+		QRCodeURL:        notification.QRCodeURL,
+		ExpiryTime:       notification.ExpiryTime,
+		MidtransResponse: notification.Raw,
+		ReportedAt:       time.Now(),
+	}
+	err := u.applyPaymentStatus(ctx, update, false)
+	if errors.Is(err, errPaymentNotSaved) {
+		u.enqueuePaymentStatusRetry(update, err)
+	}
+	return err
+}
+
+// applyPaymentStatus saves a gateway-reported status on the payment. Database failures are
+// wrapped in errPaymentNotSaved so the caller can retry them. When replaying, a status the
+// payment has already moved past since it was reported is skipped.
+func (u *paymentStatusUpdater) applyPaymentStatus(ctx context.Context, update *model.PaymentStatusRetry, replay bool) error {
+	orderNumber := update.OrderNumber
+	status := update.MidtransStatus
+	transactionID := update.TransactionID
+	vaNumber := update.VANumber
+	bankType := update.BankType
+	qrCodeURL := update.QRCodeURL
+	expiryTime := update.ExpiryTime
+	midtransResponse := update.MidtransResponse
+
+	paymentStatus := mapMidtransStatusToPaymentStatus(status)
+
+	log.Printf("🔄 Updating payment status - Order Number: %s, Status: %s -> %s", orderNumber, status, paymentStatus)
+
+	// Get payment by order number (order_number, not UUID)
+	payment, err := u.paymentRepo.FindByOrderNumber(ctx, orderNumber)
+	if err != nil {
+		log.Printf("❌ Payment not found for order number %s: %v", orderNumber, err)
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %v", errPaymentNotSaved, err)
+		}
+		return fmt.Errorf("payment not found for order number: %s", orderNumber)
+	}
+
+	if replay && payment.UpdatedAt.After(update.ReportedAt) &&
+		!(paymentStatus == model.PaymentStatusSuccess && payment.Status != model.PaymentStatusSuccess) {
+		log.Printf("⏭️  Skipping stale payment status %s for order %s (payment updated since)", paymentStatus, orderNumber)
+		return nil
+	}
+
+	log.Printf("📝 Current payment status: %s, updating to: %s", payment.Status, paymentStatus)
+	previousStatus := payment.Status
+
+	// Preserve existing values if new ones are empty
+	if qrCodeURL == "" && payment.QRCodeURL != nil && *payment.QRCodeURL != "" {
+		qrCodeURL = *payment.QRCodeURL
+	}
+	if vaNumber == "" && payment.VANumber != nil && *payment.VANumber != "" {
+		vaNumber = *payment.VANumber
+	}
+	if bankType == "" && payment.BankType != nil && *payment.BankType != "" {
+		bankType = *payment.BankType
+	}
+
+	// Update payment fields
+	payment.Status = paymentStatus
+	if transactionID != "" {
+		payment.MidtransTransactionID = &transactionID
+	}
+	if vaNumber != "" {
+		payment.VANumber = &vaNumber
+	}
+	if bankType != "" {
+		payment.BankType = &bankType
+	}
+	if qrCodeURL != "" {
+		payment.QRCodeURL = &qrCodeURL
+	}
+	if expiryTime != nil {
+		payment.ExpiryTime = expiryTime
+	}
+	if midtransResponse != "" {
+		payment.MidtransResponse = &midtransResponse
+		applyMidtransResponseFields(payment, midtransResponse)
+	}
+
+	if err := u.paymentRepo.Update(ctx, payment); err != nil {
+		log.Printf("❌ Failed to update payment: %v", err)
+		return fmt.Errorf("%w: %v", errPaymentNotSaved, err)
+	}
+
+	log.Printf("✅ Payment updated successfully - Order Number: %s, New Status: %s", orderNumber, paymentStatus)
+
+	if paymentStatus != previousStatus {
+		u.PublishStatusChange(ctx, payment)
+	}
+
+	// Update order status if payment is successful
+	if paymentStatus == model.PaymentStatusSuccess {
+		u.MarkOrderPaid(ctx, payment.OrderUUID)
+	}
+
+	return nil
+}
+
+func (u *paymentStatusUpdater) PublishStatusChange(ctx context.Context, payment *model.Payment) {
+	u.hooks.RunAfterPaymentStatusChange(ctx, payment)
+	if u.redis == nil {
+		return
+	}
+	if err := u.redis.Publish(ctx, util.PaymentStatusChannel(payment.ID), string(payment.Status)); err != nil {
+		log.Printf("⚠️  Failed to publish status change for payment %s: %v", payment.ID, err)
+	}
+}
+
+func (u *paymentStatusUpdater) MarkOrderPaid(ctx context.Context, orderUUID string) {
+	order, err := u.orderRepo.FindByID(ctx, orderUUID)
+	if err != nil {
+		log.Printf("⚠️  Order not found for UUID %s: %v", orderUUID, err)
+		return
+	}
+	if order.Status != "pending" {
+		return
+	}
+	change := model.StatusChange{ActorType: model.StatusActorSystem, Note: "Payment received"}
+	// The status is re-checked under the row lock: the unpaid-order job may have cancelled the
+	// order (and released its stock) between the read above and this update
+	err = u.orderRepo.UpdateStatus(ctx, order.ID, "pending", "processing", change)
+	if errors.Is(err, repository.ErrOrderNotPending) {
+		log.Printf("⚠️  Payment received for order %s after it left pending, refund it manually", orderUUID)
+		return
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to update order status: %v", err)
+		return
+	}
+	log.Printf("✅ Order status updated to 'processing' for order UUID: %s", orderUUID)
+	order.Status = "processing"
+	u.hooks.RunAfterPaymentSuccess(ctx, order)
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+)
+
+// PricingService computes order amounts server-side: item prices come from the product, fees
+// from config and shipping from a weight-based rate table, charged per seller shipment.
+type PricingService interface {
+	QuoteOrder(lines []QuoteLine, opts QuoteOptions) *OrderQuote
+	ShippingCost(weightGrams int) int
+	// MinOrderAmount is the marketplace-wide minimum goods subtotal per order (0 means none)
+	MinOrderAmount() int
+}
+
+type pricingService struct {
+	cfg *config.Config
+}
+
+// QuoteLine is a product and the quantity being ordered
+type QuoteLine struct {
+	Product  *model.Product
+	Quantity int
+}
+
+type QuoteOptions struct {
+	WithInsurance bool
+	WithWarranty  bool
+	WithGiftWrap  bool
+	Coupon        *model.Coupon // Voucher to discount; must already be validated for the buyer
+
+	// Donation added on top of the total: rounding it up, or a fixed amount
+	RoundUpDonation bool
+	Donation        int
+}
+
+type OrderQuote struct {
+	Subtotal       int `json:"subtotal"`
+	TotalWeight    int `json:"total_weight"` // Grams
+	ShippingCost   int `json:"shipping_cost"`
+	InsuranceCost  int `json:"insurance_cost"`
+	WarrantyCost   int `json:"warranty_cost"`
+	GiftWrapFee    int `json:"gift_wrap_fee"`
+	ServiceFee     int `json:"service_fee"`
+	ApplicationFee int `json:"application_fee"`
+	TotalDiscount  int `json:"total_discount"`
+	Bonus          int `json:"bonus"`
+	Donation       int `json:"donation"`
+	TotalAmount    int `json:"total_amount"`
+
+	TaxAmount    int  `json:"tax_amount"`    // PPN on the goods of PKP sellers
+	TaxInclusive bool `json:"tax_inclusive"` // The tax is contained in the subtotal rather than added to the total
+
+	Sellers []SellerQuote `json:"sellers"` // One shipment per seller, in first-seen order
+}
+
+// SellerQuote is the part of an order shipped by one seller
+type SellerQuote struct {
+	SellerID     string `json:"seller_id"`
+	Subtotal     int    `json:"subtotal"`
+	TotalWeight  int    `json:"total_weight"` // Grams
+	ShippingCost int    `json:"shipping_cost"`
+
+	TaxRateBasisPoints int     `json:"tax_rate_basis_points"` // 0 when the seller is not PKP
+	TaxableAmount      int     `json:"taxable_amount"`        // DPP of the seller's goods
+	TaxAmount          int     `json:"tax_amount"`
+	SellerNPWP         *string `json:"-"`
+}
+
+// PriceMismatch is one client-sent amount that differs from the server's calculation
+type PriceMismatch struct {
+	Field       string `json:"field"`
+	ClientValue int    `json:"client_value"`
+	ServerValue int    `json:"server_value"`
+}
+
+// PriceMismatchError is returned when client-sent amounts do not match the server's calculation
+type PriceMismatchError struct {
+	Mismatches []PriceMismatch
+}
+
+func (e *PriceMismatchError) Error() string {
+	parts := make([]string, 0, len(e.Mismatches))
+	for _, m := range e.Mismatches {
+		parts = append(parts, fmt.Sprintf("%s (client %d, server %d)", m.Field, m.ClientValue, m.ServerValue))
+	}
+	return "order amounts do not match: " + strings.Join(parts, ", ")
+}
+
+func NewPricingService(cfg *config.Config) PricingService {
+	return &pricingService{cfg: cfg}
+}
+
+func (s *pricingService) QuoteOrder(lines []QuoteLine, opts QuoteOptions) *OrderQuote {
+	quote := &OrderQuote{
+		ServiceFee:     s.cfg.ServiceFee,
+		ApplicationFee: s.cfg.ApplicationFee,
+	}
+
+	sellerIndex := make(map[string]int)
+	for _, line := range lines {
+		weight := s.cfg.DefaultProductWeight
+		if line.Product.Weight != nil && *line.Product.Weight > 0 {
+			weight = *line.Product.Weight
+		}
+
+		i, ok := sellerIndex[line.Product.SellerID]
+		if !ok {
+			i = len(quote.Sellers)
+			sellerIndex[line.Product.SellerID] = i
+			quote.Sellers = append(quote.Sellers, SellerQuote{SellerID: line.Product.SellerID})
+			if line.Product.Seller.IsPKP {
+				quote.Sellers[i].TaxRateBasisPoints = s.cfg.TaxRateBasisPoints
+				quote.Sellers[i].SellerNPWP = line.Product.Seller.NPWP
+			}
+		}
+		quote.Sellers[i].Subtotal += line.Product.Price * line.Quantity
+		quote.Sellers[i].TotalWeight += weight * line.Quantity
+	}
+
+	// Each seller ships separately, so shipping is rated per seller shipment
+	for i := range quote.Sellers {
+		quote.Sellers[i].ShippingCost = s.ShippingCost(quote.Sellers[i].TotalWeight)
+		quote.Subtotal += quote.Sellers[i].Subtotal
+		quote.TotalWeight += quote.Sellers[i].TotalWeight
+		quote.ShippingCost += quote.Sellers[i].ShippingCost
+
+		s.applyTax(&quote.Sellers[i])
+		quote.TaxAmount += quote.Sellers[i].TaxAmount
+	}
+	quote.TaxInclusive = s.cfg.TaxPricesInclusive
+
+	if opts.WithInsurance {
+		quote.InsuranceCost = basisPoints(quote.Subtotal, s.cfg.InsuranceRateBasisPoints)
+	}
+	if opts.WithWarranty {
+		quote.WarrantyCost = basisPoints(quote.Subtotal, s.cfg.WarrantyRateBasisPoints)
+	}
+	if opts.WithGiftWrap {
+		quote.GiftWrapFee = s.cfg.GiftWrapFee
+	}
+
+	if opts.Coupon != nil {
+		quote.TotalDiscount = couponDiscount(opts.Coupon, quote).Discount
+	}
+
+	// There is no loyalty program yet, so bonuses are always zero
+	quote.TotalAmount = quote.Subtotal + quote.ShippingCost + quote.InsuranceCost + quote.WarrantyCost +
+		quote.GiftWrapFee + quote.ServiceFee + quote.ApplicationFee - quote.TotalDiscount - quote.Bonus
+	if !quote.TaxInclusive {
+		quote.TotalAmount += quote.TaxAmount
+	}
+	if quote.TotalAmount < 0 {
+		quote.TotalAmount = 0
+	}
+
+	quote.Donation = opts.Donation
+	if opts.RoundUpDonation && s.cfg.DonationRoundUpTo > 0 {
+		quote.Donation = (s.cfg.DonationRoundUpTo - quote.TotalAmount%s.cfg.DonationRoundUpTo) % s.cfg.DonationRoundUpTo
+	}
+	quote.TotalAmount += quote.Donation
+	return quote
+}
+
+// applyTax computes PPN on the seller's goods. With inclusive prices the tax is extracted from the
+// subtotal (DPP = subtotal - tax); otherwise the subtotal is the DPP and the tax comes on top.
+// Amounts are rounded half up to whole rupiah.
+func (s *pricingService) applyTax(seller *SellerQuote) {
+	rate := seller.TaxRateBasisPoints
+	if rate <= 0 || seller.Subtotal <= 0 {
+		seller.TaxRateBasisPoints = 0
+		return
+	}
+	if s.cfg.TaxPricesInclusive {
+		seller.TaxAmount = (seller.Subtotal*rate + (10000+rate)/2) / (10000 + rate)
+		seller.TaxableAmount = seller.Subtotal - seller.TaxAmount
+		return
+	}
+	seller.TaxableAmount = seller.Subtotal
+	seller.TaxAmount = (seller.Subtotal*rate + 5000) / 10000
+}
+
+func (s *pricingService) MinOrderAmount() int {
+	return s.cfg.MinOrderAmount
+}
+
+// ShippingCost charges the base cost for the first kilogram and the per-kg cost for every
+// additional started kilogram
+func (s *pricingService) ShippingCost(weightGrams int) int {
+	if weightGrams <= 0 {
+		return 0
+	}
+	kilograms := (weightGrams + 999) / 1000
+	return s.cfg.ShippingBaseCost + (kilograms-1)*s.cfg.ShippingCostPerKg
+}
+
+// basisPoints returns amount * bps / 10000, rounded up
+func basisPoints(amount, bps int) int {
+	if amount <= 0 || bps <= 0 {
+		return 0
+	}
+	return (amount*bps + 9999) / 10000
+}
+
+// compareAmount records a mismatch when the client sent a value that differs from the server's
+func compareAmount(mismatches []PriceMismatch, field string, client *int, server int) []PriceMismatch {
+	if client != nil && *client != server {
+		mismatches = append(mismatches, PriceMismatch{Field: field, ClientValue: *client, ServerValue: server})
+	}
+	return mismatches
+}
+
+// CouponBreakdown is how a coupon discounts a priced order
+type CouponBreakdown struct {
+	EligibleSubtotal int `json:"eligible_subtotal"` // Goods the coupon applies to (one shop's for shop vouchers)
+	EligibleShipping int `json:"eligible_shipping"` // Shipping of those shops
+	Discount         int `json:"discount"`
+}
+
+// couponDiscount works out a coupon's discount on a quote, never more than what it applies to.
+// Minimum subtotals and usage limits are not checked here.
+func couponDiscount(coupon *model.Coupon, quote *OrderQuote) CouponBreakdown {
+	var breakdown CouponBreakdown
+	for _, seller := range quote.Sellers {
+		if coupon.SellerID != nil && *coupon.SellerID != seller.SellerID {
+			continue
+		}
+		breakdown.EligibleSubtotal += seller.Subtotal
+		breakdown.EligibleShipping += seller.ShippingCost
+	}
+
+	switch coupon.DiscountType {
+	case model.CouponTypePercentage:
+		breakdown.Discount = breakdown.EligibleSubtotal * coupon.DiscountValue / 100
+	case model.CouponTypeFixed:
+		breakdown.Discount = coupon.DiscountValue
+	case model.CouponTypeFreeShipping:
+		breakdown.Discount = breakdown.EligibleShipping
+	}
+	if coupon.MaxDiscount > 0 && breakdown.Discount > coupon.MaxDiscount {
+		breakdown.Discount = coupon.MaxDiscount
+	}
+
+	limit := breakdown.EligibleSubtotal
+	if coupon.DiscountType == model.CouponTypeFreeShipping {
+		limit = breakdown.EligibleShipping
+	}
+	if breakdown.Discount > limit {
+		breakdown.Discount = limit
+	}
+	if breakdown.Discount < 0 {
+		breakdown.Discount = 0
+	}
+	return breakdown
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"yourapp/internal/util"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// productEventBuffer is how many product IDs a subscriber may fall behind before events are dropped
+const productEventBuffer = 64
+
+// ProductEventService fans out "product changed" events (stock or price) to in-process
+// subscribers such as the live cart stock streams. With Redis the events travel over pub/sub so
+// a change made on one instance reaches streams held by every other instance.
+type ProductEventService interface {
+	// Publish announces that the stock or price of the products changed
+	Publish(ctx context.Context, productIDs ...string)
+	// Subscribe returns a channel receiving the IDs of changed products until ctx is done. A slow
+	// subscriber misses events rather than blocking publishers.
+	Subscribe(ctx context.Context) <-chan string
+}
+
+type productEventService struct {
+	redis *util.RedisClient // Optional; nil keeps events within this instance
+
+	mu          sync.Mutex
+	subscribers map[chan string]struct{}
+}
+
+func NewProductEventService(redisClient *util.RedisClient) ProductEventService {
+	service := &productEventService{
+		redis:       redisClient,
+		subscribers: make(map[chan string]struct{}),
+	}
+
+	if redisClient != nil {
+		pubsub, err := redisClient.Subscribe(context.Background(), util.ProductUpdatesChannel)
+		if err != nil {
+			log.Printf("⚠️  Failed to subscribe to product updates, live stock only covers changes made on this instance: %v", err)
+			service.redis = nil
+		} else {
+			go service.relay(pubsub)
+			log.Println("✅ Product update relay started")
+		}
+	}
+
+	return service
+}
+
+// relay forwards events published by any instance to this instance's subscribers
+func (s *productEventService) relay(pubsub *redis.PubSub) {
+	defer pubsub.Close()
+
+	for msg := range pubsub.Channel() {
+		s.dispatch(strings.Split(msg.Payload, ","))
+	}
+}
+
+func (s *productEventService) Publish(ctx context.Context, productIDs ...string) {
+	if len(productIDs) == 0 {
+		return
+	}
+	if s.redis != nil {
+		err := s.redis.Publish(ctx, util.ProductUpdatesChannel, strings.Join(productIDs, ","))
+		if err == nil {
+			return
+		}
+		log.Printf("⚠️  Failed to publish product update, notifying this instance only: %v", err)
+	}
+	s.dispatch(productIDs)
+}
+
+func (s *productEventService) Subscribe(ctx context.Context) <-chan string {
+	ch := make(chan string, productEventBuffer)
+
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		s.mu.Unlock()
+		close(ch)
+	}()
+
+	return ch
+}
+
+func (s *productEventService) dispatch(productIDs []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.subscribers {
+		for _, productID := range productIDs {
+			select {
+			case ch <- productID:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+)
+
+// priceScheduleBatchSize is how many due price schedules one pass applies at most
+const priceScheduleBatchSize = 200
+
+// ProductPriceService schedules future price changes, keeps each product's price history and
+// issues the quote tokens that let a checkout keep its previewed prices through a scheduled change
+type ProductPriceService interface {
+	SchedulePrice(ctx context.Context, userID string, productID string, req SchedulePriceRequest) (*model.ProductPriceSchedule, error)
+	GetSchedules(ctx context.Context, userID string, productID string, status string) ([]model.ProductPriceSchedule, error)
+	CancelSchedule(ctx context.Context, userID string, productID string, scheduleID string) (*model.ProductPriceSchedule, error)
+	GetPriceHistory(ctx context.Context, productID string, limit int) ([]model.ProductPriceHistory, error)
+	// ApplyDueSchedules applies schedules whose time has come and returns how many were applied
+	ApplyDueSchedules(ctx context.Context) (int, error)
+	// RecordManualChange adds a price edited directly on the product to its history
+	RecordManualChange(ctx context.Context, productID string, oldPrice, newPrice int)
+
+	// IssueQuote signs the prices a buyer was shown, keyed by product ID
+	IssueQuote(userID string, prices map[string]int) (string, time.Time, error)
+	// ValidQuote returns the claims of a quote token issued to userID, or nil
+	ValidQuote(userID string, token string) *util.QuoteClaims
+	// QuotedPrice returns the lower price the buyer was quoted for product when a scheduled change
+	// raised it after the quote was issued
+	QuotedPrice(ctx context.Context, quote *util.QuoteClaims, product *model.Product) (int, bool)
+}
+
+type productPriceService struct {
+	priceRepo   repository.ProductPriceRepository
+	productRepo repository.ProductRepository
+	sellerRepo  repository.SellerRepository
+	events      ProductEventService
+	jwtSecret   string
+	quoteTTL    time.Duration
+}
+
+type SchedulePriceRequest struct {
+	Price       int       `json:"price" binding:"required,min=0"`
+	EffectiveAt time.Time `json:"effective_at" binding:"required"`
+}
+
+func NewProductPriceService(
+	priceRepo repository.ProductPriceRepository,
+	productRepo repository.ProductRepository,
+	sellerRepo repository.SellerRepository,
+	events ProductEventService,
+	cfg *config.Config,
+	jobs *util.BackgroundJobs,
+) ProductPriceService {
+	service := &productPriceService{
+		priceRepo:   priceRepo,
+		productRepo: productRepo,
+		sellerRepo:  sellerRepo,
+		events:      events,
+		jwtSecret:   cfg.JWTSecret,
+		quoteTTL:    time.Duration(cfg.PriceQuoteTTLMinutes) * time.Minute,
+	}
+
+	// Start background job to apply scheduled prices
+	if cfg.PriceScheduleCheckIntervalSeconds > 0 {
+		interval := time.Duration(cfg.PriceScheduleCheckIntervalSeconds) * time.Second
+		jobs.Every(interval, service.runPriceScheduler)
+		log.Printf("✅ Price scheduler started (checking every %s)", interval)
+	}
+
+	return service
+}
+
+// runPriceScheduler applies price schedules whose effective time has passed
+func (s *productPriceService) runPriceScheduler(ctx context.Context) {
+	if _, err := s.ApplyDueSchedules(ctx); err != nil {
+		log.Printf("⚠️  Failed to apply scheduled prices: %v", err)
+	}
+}
+
+func (s *productPriceService) SchedulePrice(ctx context.Context, userID string, productID string, req SchedulePriceRequest) (*model.ProductPriceSchedule, error) {
+	product, err := s.findOwned(userID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !req.EffectiveAt.After(time.Now()) {
+		return nil, errors.New("effective_at must be in the future")
+	}
+
+	schedule := &model.ProductPriceSchedule{
+		ProductID:   product.ID,
+		SellerID:    product.SellerID,
+		Price:       req.Price,
+		EffectiveAt: req.EffectiveAt,
+		Status:      model.PriceScheduleScheduled,
+		CreatedBy:   userID,
+	}
+	if err := s.priceRepo.CreateSchedule(ctx, schedule); err != nil {
+		return nil, fmt.Errorf("failed to schedule price: %w", err)
+	}
+
+	log.Printf("🏷️  Price of product %s scheduled to change to %d at %s", product.ID, req.Price, req.EffectiveAt.Format(time.RFC3339))
+	return schedule, nil
+}
+
+func (s *productPriceService) GetSchedules(ctx context.Context, userID string, productID string, status string) ([]model.ProductPriceSchedule, error) {
+	product, err := s.findOwned(userID, productID)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := s.priceRepo.FindSchedulesByProductID(ctx, product.ID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+func (s *productPriceService) CancelSchedule(ctx context.Context, userID string, productID string, scheduleID string) (*model.ProductPriceSchedule, error) {
+	product, err := s.findOwned(userID, productID)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := s.priceRepo.FindScheduleByID(ctx, scheduleID)
+	if err != nil || schedule.ProductID != product.ID {
+		return nil, errors.New("price schedule not found")
+	}
+	if err := s.priceRepo.CancelSchedule(ctx, schedule.ID); err != nil {
+		return nil, err
+	}
+	return s.priceRepo.FindScheduleByID(ctx, schedule.ID)
+}
+
+func (s *productPriceService) GetPriceHistory(ctx context.Context, productID string, limit int) ([]model.ProductPriceHistory, error) {
+	if _, err := s.productRepo.FindByID(productID); err != nil {
+		return nil, errors.New("product not found")
+	}
+	if limit < 1 || limit > 100 {
+		limit = 30
+	}
+	history, err := s.priceRepo.FindHistory(ctx, productID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+	return history, nil
+}
+
+func (s *productPriceService) ApplyDueSchedules(ctx context.Context) (int, error) {
+	now := time.Now()
+	schedules, err := s.priceRepo.FindDueSchedules(ctx, now, priceScheduleBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, schedule := range schedules {
+		history, err := s.priceRepo.ApplySchedule(ctx, schedule.ID, now)
+		if err != nil {
+			if !errors.Is(err, repository.ErrPriceScheduleNotPending) {
+				log.Printf("⚠️  Failed to apply price schedule %s: %v", schedule.ID, err)
+			}
+			continue
+		}
+		applied++
+		if history != nil {
+			s.events.Publish(ctx, schedule.ProductID)
+			log.Printf("🏷️  Product %s price changed from %d to %d (schedule %s)", schedule.ProductID, history.OldPrice, history.NewPrice, schedule.ID)
+		}
+	}
+	return applied, nil
+}
+
+func (s *productPriceService) RecordManualChange(ctx context.Context, productID string, oldPrice, newPrice int) {
+	if oldPrice == newPrice {
+		return
+	}
+	history := &model.ProductPriceHistory{
+		ProductID: productID,
+		OldPrice:  oldPrice,
+		NewPrice:  newPrice,
+		Source:    model.PriceChangeManual,
+		ChangedAt: time.Now(),
+	}
+	if err := s.priceRepo.RecordHistory(ctx, history); err != nil {
+		log.Printf("⚠️  Failed to record price history for product %s: %v", productID, err)
+	}
+}
+
+func (s *productPriceService) IssueQuote(userID string, prices map[string]int) (string, time.Time, error) {
+	token, expiresAt, err := util.GenerateQuoteToken(userID, prices, s.jwtSecret, s.quoteTTL)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create quote token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+func (s *productPriceService) ValidQuote(userID string, token string) *util.QuoteClaims {
+	if token == "" {
+		return nil
+	}
+	claims, err := util.ValidateQuoteToken(token, s.jwtSecret)
+	if err != nil || claims.UserID != userID {
+		return nil
+	}
+	return claims
+}
+
+// QuotedPrice only ever honors a lower quoted price, and only across a scheduled change: manual
+// edits (e.g. fixing a mistyped price) take effect immediately
+func (s *productPriceService) QuotedPrice(ctx context.Context, quote *util.QuoteClaims, product *model.Product) (int, bool) {
+	if quote == nil {
+		return 0, false
+	}
+	quoted, ok := quote.Prices[product.ID]
+	if !ok || quoted >= product.Price {
+		return 0, false
+	}
+	change, err := s.priceRepo.FindScheduledChangeSince(ctx, product.ID, quoted, quote.IssuedAt.Time)
+	if err != nil || change.NewPrice != product.Price {
+		return 0, false
+	}
+	return quoted, true
+}
+
+func (s *productPriceService) findOwned(userID string, productID string) (*model.Product, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found. Please create a shop first")
+	}
+	product, err := s.productRepo.FindByID(productID)
+	if err != nil || product.SellerID != seller.ID {
+		return nil, errors.New("product not found")
+	}
+	return product, nil
+}
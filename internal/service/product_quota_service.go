@@ -0,0 +1,151 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// ErrProductLimitReached is returned when listing another product would take a seller over its limit
+var ErrProductLimitReached = errors.New("product listing limit reached")
+
+// ProductQuotaService caps how many products a seller can have listed (active or scheduled to
+// publish) at once. The limit comes from the seller's tier unless an admin set an override. It is a
+// soft quota: it is checked when products are created, published or scheduled, and shops already
+// over the limit keep their existing listings.
+type ProductQuotaService interface {
+	// CheckCapacity returns ErrProductLimitReached if listing productIDs would exceed the seller's
+	// limit. Products that are already listed count once; use an empty ID for a new product.
+	CheckCapacity(seller *model.Seller, productIDs []string) error
+	GetQuota(userID string) (*ProductQuota, error)
+	// SetLimitOverride sets a seller's limit (0 for unlimited), or restores the tier default when nil
+	SetLimitOverride(sellerID string, limit *int) (*ProductQuota, error)
+}
+
+type productQuotaService struct {
+	productRepo repository.ProductRepository
+	sellerRepo  repository.SellerRepository
+	tierLimits  map[string]int
+}
+
+// ProductQuota is a seller's listing usage
+type ProductQuota struct {
+	SellerID   string `json:"seller_id"`
+	Tier       string `json:"tier"`
+	Limit      int    `json:"limit"` // 0 means unlimited
+	Overridden bool   `json:"overridden"`
+	Listed     int64  `json:"listed"`
+	Remaining  *int64 `json:"remaining,omitempty"` // Omitted when unlimited
+}
+
+func NewProductQuotaService(productRepo repository.ProductRepository, sellerRepo repository.SellerRepository, cfg *config.Config) ProductQuotaService {
+	return &productQuotaService{
+		productRepo: productRepo,
+		sellerRepo:  sellerRepo,
+		tierLimits: map[string]int{
+			model.SellerTierUnverified: cfg.SellerProductLimitUnverified,
+			model.SellerTierVerified:   cfg.SellerProductLimitVerified,
+		},
+	}
+}
+
+func (s *productQuotaService) CheckCapacity(seller *model.Seller, productIDs []string) error {
+	limit := s.limitFor(seller)
+	if limit == 0 || len(productIDs) == 0 {
+		return nil
+	}
+
+	existing := make([]string, 0, len(productIDs))
+	for _, id := range productIDs {
+		if id != "" {
+			existing = append(existing, id)
+		}
+	}
+	listed, err := s.productRepo.CountListedBySellerID(seller.ID, existing)
+	if err != nil {
+		return fmt.Errorf("failed to check product limit: %w", err)
+	}
+	if listed+int64(len(productIDs)) <= int64(limit) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrProductLimitReached, s.upgradeHint(seller, limit, listed))
+}
+
+func (s *productQuotaService) GetQuota(userID string) (*ProductQuota, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	return s.quotaFor(seller)
+}
+
+func (s *productQuotaService) SetLimitOverride(sellerID string, limit *int) (*ProductQuota, error) {
+	if limit != nil && *limit < 0 {
+		return nil, errors.New("limit cannot be negative")
+	}
+	seller, err := s.sellerRepo.FindByID(sellerID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+
+	seller.ProductLimit = limit
+	if err := s.sellerRepo.Update(seller); err != nil {
+		return nil, errors.New("failed to update product limit: " + err.Error())
+	}
+
+	if limit != nil {
+		log.Printf("📦 Product limit of seller %s set to %d", seller.ID, *limit)
+	} else {
+		log.Printf("📦 Product limit of seller %s reset to the %s tier default", seller.ID, seller.Tier())
+	}
+	return s.quotaFor(seller)
+}
+
+func (s *productQuotaService) quotaFor(seller *model.Seller) (*ProductQuota, error) {
+	listed, err := s.productRepo.CountListedBySellerID(seller.ID, nil)
+	if err != nil {
+		return nil, errors.New("failed to count products: " + err.Error())
+	}
+
+	quota := &ProductQuota{
+		SellerID:   seller.ID,
+		Tier:       seller.Tier(),
+		Limit:      s.limitFor(seller),
+		Overridden: seller.ProductLimit != nil,
+		Listed:     listed,
+	}
+	if quota.Limit > 0 {
+		remaining := int64(quota.Limit) - listed
+		if remaining < 0 {
+			remaining = 0
+		}
+		quota.Remaining = &remaining
+	}
+	return quota, nil
+}
+
+func (s *productQuotaService) limitFor(seller *model.Seller) int {
+	if seller.ProductLimit != nil {
+		return *seller.ProductLimit
+	}
+	return s.tierLimits[seller.Tier()]
+}
+
+// upgradeHint tells the seller what the limit is and how to get a higher one
+func (s *productQuotaService) upgradeHint(seller *model.Seller, limit int, listed int64) string {
+	hint := fmt.Sprintf("your shop can list %d products and has %d listed; unpublish a product first", limit, listed)
+	if seller.ProductLimit == nil && seller.Tier() == model.SellerTierUnverified {
+		verified := s.tierLimits[model.SellerTierVerified]
+		if verified == 0 {
+			return hint + ", or verify your shop to list unlimited products"
+		}
+		if verified > limit {
+			return hint + fmt.Sprintf(", or verify your shop to list up to %d products", verified)
+		}
+	}
+	return hint + ", or contact support to raise your limit"
+}
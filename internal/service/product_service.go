@@ -1,28 +1,51 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
 
 	"yourapp/internal/model"
 	"yourapp/internal/repository"
+	"yourapp/internal/util"
 )
 
 type ProductService interface {
 	CreateProduct(userID string, req CreateProductRequest) (*model.Product, error)
 	GetProductByID(id string) (*model.Product, error)
-	GetProducts(page, limit int, categoryID, featured, activeOnly *string) (*ProductListResponse, error)
-	SearchProducts(page, limit int, keyword string, activeOnly bool) (*ProductListResponse, error)
+	ViewProduct(ctx context.Context, id string) (*model.Product, error)
+	GetProducts(page, limit int, query ProductListQuery) (*ProductListResponse, error)
+	// SearchProducts ranks products matching keyword, optionally filtered and sorted like GetProducts
+	SearchProducts(page, limit int, keyword string, query ProductListQuery) (*ProductListResponse, error)
+	GetSellerProducts(sellerID string, page, limit int, keyword string, categoryID *string) (*ProductListResponse, error)
 	UpdateProduct(id string, req UpdateProductRequest) (*model.Product, error)
 	DeleteProduct(id string) error
 	AddProductImage(productID string, req AddProductImageRequest) (*model.ProductImage, error)
 	DeleteProductImage(imageID string) error
+	ScheduleProductVisibility(userID string, req ScheduleProductVisibilityRequest) ([]model.Product, error)
+	// RecordSale counts a paid order's items towards the products' sold counts
+	RecordSale(ctx context.Context, order *model.Order) error
+	// OnOrderStatusChange takes a paid order's items off the sold counts again when it is cancelled
+	OnOrderStatusChange(ctx context.Context, order *model.Order, from string) error
 }
 
+// productSchedulerInterval is how often scheduled publish/unpublish times are applied
+const productSchedulerInterval = time.Minute
+
 type productService struct {
 	productRepo  repository.ProductRepository
 	categoryRepo repository.CategoryRepository
 	sellerRepo   repository.SellerRepository
+	analytics    AnalyticsService
+	stock        StockCacheService
+	quota        ProductQuotaService
+	prices       ProductPriceService
+	events       ProductEventService
+	hooks        *HookRegistry
 }
 
 type CreateProductRequest struct {
@@ -36,6 +59,17 @@ type CreateProductRequest struct {
 	Thumbnail   *string `json:"thumbnail,omitempty"`
 	IsActive    *bool   `json:"is_active,omitempty"`
 	IsFeatured  *bool   `json:"is_featured,omitempty"`
+
+	MaxOrderQuantity int `json:"max_order_quantity" binding:"min=0"` // 0 means no limit
+
+	// Warranty
+	WarrantyMonths int     `json:"warranty_months" binding:"min=0,max=120"` // 0 uses the platform default
+	WarrantyTerms  *string `json:"warranty_terms,omitempty"`
+
+	// Digital goods
+	IsDigital          bool    `json:"is_digital"`
+	DigitalFileURL     *string `json:"digital_file_url,omitempty"`
+	LicenseKeyRequired bool    `json:"license_key_required"`
 }
 
 type UpdateProductRequest struct {
@@ -49,6 +83,17 @@ type UpdateProductRequest struct {
 	Thumbnail   *string `json:"thumbnail,omitempty"`
 	IsActive    *bool   `json:"is_active,omitempty"`
 	IsFeatured  *bool   `json:"is_featured,omitempty"`
+
+	MaxOrderQuantity *int `json:"max_order_quantity,omitempty" binding:"omitempty,min=0"` // 0 removes the limit
+
+	// Warranty
+	WarrantyMonths *int    `json:"warranty_months,omitempty" binding:"omitempty,min=0,max=120"` // 0 uses the platform default
+	WarrantyTerms  *string `json:"warranty_terms,omitempty"`                                    // Empty string removes the terms
+
+	// Digital goods
+	IsDigital          *bool   `json:"is_digital,omitempty"`
+	DigitalFileURL     *string `json:"digital_file_url,omitempty"`
+	LicenseKeyRequired *bool   `json:"license_key_required,omitempty"`
 }
 
 type AddProductImageRequest struct {
@@ -56,6 +101,24 @@ type AddProductImageRequest struct {
 	SortOrder *int   `json:"sort_order,omitempty"`
 }
 
+// ScheduleProductVisibilityRequest schedules publish and/or unpublish times for several products at once.
+// Set Clear to remove any existing schedule instead.
+type ScheduleProductVisibilityRequest struct {
+	ProductIDs  []string   `json:"product_ids" binding:"required,min=1,max=100"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	UnpublishAt *time.Time `json:"unpublish_at,omitempty"`
+	Clear       bool       `json:"clear,omitempty"`
+}
+
+// ScheduledVisibilityChange is an upcoming publish or unpublish of a product
+type ScheduledVisibilityChange struct {
+	ProductID   string    `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	SKU         string    `json:"sku"`
+	Action      string    `json:"action"` // "publish" or "unpublish"
+	ScheduledAt time.Time `json:"scheduled_at"`
+}
+
 type ProductListResponse struct {
 	Products []model.Product `json:"products"`
 	Total    int64           `json:"total"`
@@ -63,11 +126,68 @@ type ProductListResponse struct {
 	Limit    int             `json:"limit"`
 }
 
-func NewProductService(productRepo repository.ProductRepository, categoryRepo repository.CategoryRepository, sellerRepo repository.SellerRepository) ProductService {
-	return &productService{
+// ProductListQuery holds the raw listing query parameters; empty fields are not applied
+type ProductListQuery struct {
+	CategoryID string
+	Featured   string // "true" or "false"
+	ActiveOnly string // "true" to hide inactive products
+	MinPrice   string
+	MaxPrice   string
+	Sort       string // price_asc, price_desc, newest, best_selling, rating
+}
+
+func NewProductService(productRepo repository.ProductRepository, categoryRepo repository.CategoryRepository, sellerRepo repository.SellerRepository, analytics AnalyticsService, stock StockCacheService, quota ProductQuotaService, prices ProductPriceService, events ProductEventService, hooks *HookRegistry, jobs *util.BackgroundJobs) ProductService {
+	service := &productService{
 		productRepo:  productRepo,
 		categoryRepo: categoryRepo,
 		sellerRepo:   sellerRepo,
+		analytics:    analytics,
+		stock:        stock,
+		quota:        quota,
+		prices:       prices,
+		events:       events,
+		hooks:        hooks,
+	}
+
+	// Start background job to apply scheduled product visibility changes
+	jobs.Go(service.startVisibilityScheduler)
+	log.Println("✅ Product visibility scheduler started (checking every minute)")
+
+	return service
+}
+
+// startVisibilityScheduler periodically publishes/unpublishes products whose scheduled time has passed
+func (s *productService) startVisibilityScheduler(ctx context.Context) {
+	ticker := time.NewTicker(productSchedulerInterval)
+	defer ticker.Stop()
+
+	s.applyScheduledVisibility()
+	for {
+		select {
+		case <-ticker.C:
+			s.applyScheduledVisibility()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *productService) applyScheduledVisibility() {
+	now := time.Now()
+
+	published, err := s.productRepo.ApplyScheduledPublish(now)
+	if err != nil {
+		log.Printf("⚠️  Failed to apply scheduled product publishes: %v", err)
+	} else if published > 0 {
+		log.Printf("📢 Published %d scheduled product(s)", published)
+	}
+
+	// Unpublish runs after publish so a product whose window has already closed ends up hidden
+	unpublished, err := s.productRepo.ApplyScheduledUnpublish(now)
+	if err != nil {
+		log.Printf("⚠️  Failed to apply scheduled product unpublishes: %v", err)
+	} else if unpublished > 0 {
+		log.Printf("🔕 Unpublished %d scheduled product(s)", unpublished)
 	}
 }
 
@@ -112,6 +232,26 @@ func (s *productService) CreateProduct(userID string, req CreateProductRequest)
 		Thumbnail:   req.Thumbnail,
 		IsActive:    isActive,
 		IsFeatured:  isFeatured,
+
+		MaxOrderQuantity: req.MaxOrderQuantity,
+
+		WarrantyMonths: req.WarrantyMonths,
+		WarrantyTerms:  req.WarrantyTerms,
+
+		IsDigital:          req.IsDigital,
+		DigitalFileURL:     req.DigitalFileURL,
+		LicenseKeyRequired: req.LicenseKeyRequired,
+	}
+	if err := validateDigitalProduct(product); err != nil {
+		return nil, err
+	}
+	if product.IsActive {
+		if err := s.quota.CheckCapacity(seller, []string{""}); err != nil {
+			return nil, err
+		}
+		if err := s.hooks.RunBeforeProductPublish(context.Background(), product); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := s.productRepo.Create(product); err != nil {
@@ -129,7 +269,17 @@ func (s *productService) GetProductByID(id string) (*model.Product, error) {
 	return product, nil
 }
 
-func (s *productService) GetProducts(page, limit int, categoryID, featured, activeOnly *string) (*ProductListResponse, error) {
+// ViewProduct returns a product for its detail page and counts the view in the seller's funnel
+func (s *productService) ViewProduct(ctx context.Context, id string) (*model.Product, error) {
+	product, err := s.GetProductByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.analytics.RecordProductView(ctx, product)
+	return product, nil
+}
+
+func (s *productService) GetProducts(page, limit int, query ProductListQuery) (*ProductListResponse, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -137,23 +287,12 @@ func (s *productService) GetProducts(page, limit int, categoryID, featured, acti
 		limit = 10
 	}
 
-	var categoryIDPtr *string
-	if categoryID != nil && *categoryID != "" {
-		categoryIDPtr = categoryID
-	}
-
-	var featuredPtr *bool
-	if featured != nil && *featured != "" {
-		feat := *featured == "true"
-		featuredPtr = &feat
-	}
-
-	activeOnlyBool := false
-	if activeOnly != nil && *activeOnly == "true" {
-		activeOnlyBool = true
+	filter, err := parseProductListQuery(query)
+	if err != nil {
+		return nil, err
 	}
 
-	products, total, err := s.productRepo.FindAll(page, limit, categoryIDPtr, featuredPtr, activeOnlyBool)
+	products, total, err := s.productRepo.FindAll(page, limit, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get products: %w", err)
 	}
@@ -166,7 +305,7 @@ func (s *productService) GetProducts(page, limit int, categoryID, featured, acti
 	}, nil
 }
 
-func (s *productService) SearchProducts(page, limit int, keyword string, activeOnly bool) (*ProductListResponse, error) {
+func (s *productService) SearchProducts(page, limit int, keyword string, query ProductListQuery) (*ProductListResponse, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -183,7 +322,12 @@ func (s *productService) SearchProducts(page, limit int, keyword string, activeO
 		}, nil
 	}
 
-	products, total, err := s.productRepo.Search(page, limit, keyword, activeOnly)
+	filter, err := parseProductListQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	products, total, err := s.productRepo.Search(page, limit, keyword, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search products: %w", err)
 	}
@@ -196,6 +340,76 @@ func (s *productService) SearchProducts(page, limit int, keyword string, activeO
 	}, nil
 }
 
+// parseProductListQuery validates the listing query parameters and turns them into a repository filter
+func parseProductListQuery(query ProductListQuery) (repository.ProductListFilter, error) {
+	filter := repository.ProductListFilter{ActiveOnly: query.ActiveOnly == "true"}
+	if query.CategoryID != "" {
+		filter.CategoryID = &query.CategoryID
+	}
+	if query.Featured != "" {
+		featured := query.Featured == "true"
+		filter.Featured = &featured
+	}
+
+	var err error
+	if filter.MinPrice, err = parsePriceBound("min_price", query.MinPrice); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice, err = parsePriceBound("max_price", query.MaxPrice); err != nil {
+		return filter, err
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, errors.New("min_price cannot be greater than max_price")
+	}
+
+	if query.Sort != "" {
+		if !repository.IsProductSort(query.Sort) {
+			return filter, fmt.Errorf("invalid sort %q: use price_asc, price_desc, newest, best_selling or rating", query.Sort)
+		}
+		filter.Sort = query.Sort
+	}
+	return filter, nil
+}
+
+func parsePriceBound(name, value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	price, err := strconv.Atoi(value)
+	if err != nil || price < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative whole number", name)
+	}
+	return &price, nil
+}
+
+// GetSellerProducts lists the active products of an open shop, optionally searched by keyword
+// (same matching as SearchProducts) and filtered by category
+func (s *productService) GetSellerProducts(sellerID string, page, limit int, keyword string, categoryID *string) (*ProductListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	seller, err := s.sellerRepo.FindByID(sellerID)
+	if err != nil || !seller.IsActive {
+		return nil, errors.New("seller not found")
+	}
+
+	products, total, err := s.productRepo.SearchBySellerID(seller.ID, page, limit, keyword, categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seller products: %w", err)
+	}
+
+	return &ProductListResponse{
+		Products: products,
+		Total:    total,
+		Page:     page,
+		Limit:    limit,
+	}, nil
+}
+
 func (s *productService) UpdateProduct(id string, req UpdateProductRequest) (*model.Product, error) {
 	product, err := s.productRepo.FindByID(id)
 	if err != nil {
@@ -226,10 +440,16 @@ func (s *productService) UpdateProduct(id string, req UpdateProductRequest) (*mo
 	if req.Description != nil {
 		product.Description = req.Description
 	}
+	oldPrice := product.Price
 	if req.Price != nil {
 		product.Price = *req.Price
 	}
+	stockDelta := 0
+	if req.Stock != nil && len(product.Variants) > 0 {
+		return nil, repository.ErrProductHasVariants
+	}
 	if req.Stock != nil {
+		stockDelta = *req.Stock - product.Stock
 		product.Stock = *req.Stock
 	}
 	if req.Weight != nil {
@@ -238,16 +458,59 @@ func (s *productService) UpdateProduct(id string, req UpdateProductRequest) (*mo
 	if req.Thumbnail != nil {
 		product.Thumbnail = req.Thumbnail
 	}
+	wasActive := product.IsActive
 	if req.IsActive != nil {
 		product.IsActive = *req.IsActive
 	}
 	if req.IsFeatured != nil {
 		product.IsFeatured = *req.IsFeatured
 	}
+	if req.MaxOrderQuantity != nil {
+		product.MaxOrderQuantity = *req.MaxOrderQuantity
+	}
+	if req.WarrantyMonths != nil {
+		product.WarrantyMonths = *req.WarrantyMonths
+	}
+	if req.WarrantyTerms != nil {
+		product.WarrantyTerms = req.WarrantyTerms
+		if *req.WarrantyTerms == "" {
+			product.WarrantyTerms = nil
+		}
+	}
+	if req.IsDigital != nil {
+		product.IsDigital = *req.IsDigital
+	}
+	if req.DigitalFileURL != nil {
+		product.DigitalFileURL = req.DigitalFileURL
+		if *req.DigitalFileURL == "" {
+			product.DigitalFileURL = nil // Empty string removes the file
+		}
+	}
+	if req.LicenseKeyRequired != nil {
+		product.LicenseKeyRequired = *req.LicenseKeyRequired
+	}
+	if err := validateDigitalProduct(product); err != nil {
+		return nil, err
+	}
+	if product.IsActive && !wasActive {
+		seller, err := s.sellerRepo.FindByID(product.SellerID)
+		if err != nil {
+			return nil, errors.New("seller not found")
+		}
+		if err := s.quota.CheckCapacity(seller, []string{product.ID}); err != nil {
+			return nil, err
+		}
+		if err := s.hooks.RunBeforeProductPublish(context.Background(), product); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := s.productRepo.Update(product); err != nil {
 		return nil, fmt.Errorf("failed to update product: %w", err)
 	}
+	s.stock.Adjust(context.Background(), product.ID, stockDelta)
+	s.prices.RecordManualChange(context.Background(), product.ID, oldPrice, product.Price)
+	s.events.Publish(context.Background(), product.ID)
 
 	return s.productRepo.FindByID(product.ID)
 }
@@ -258,7 +521,11 @@ func (s *productService) DeleteProduct(id string) error {
 		return errors.New("product not found")
 	}
 
-	return s.productRepo.Delete(id)
+	if err := s.productRepo.Delete(id); err != nil {
+		return err
+	}
+	s.events.Publish(context.Background(), id)
+	return nil
 }
 
 func (s *productService) AddProductImage(productID string, req AddProductImageRequest) (*model.ProductImage, error) {
@@ -289,3 +556,151 @@ func (s *productService) AddProductImage(productID string, req AddProductImageRe
 func (s *productService) DeleteProductImage(imageID string) error {
 	return s.productRepo.DeleteImage(imageID)
 }
+
+// ScheduleProductVisibility sets (or clears) publish/unpublish times on the seller's products.
+// A future publish time hides the product until that time.
+func (s *productService) ScheduleProductVisibility(userID string, req ScheduleProductVisibilityRequest) ([]model.Product, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found. Please create a shop first")
+	}
+
+	products, err := s.productRepo.FindByIDsAndSellerID(req.ProductIDs, seller.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+	if len(products) != len(uniqueStrings(req.ProductIDs)) {
+		return nil, errors.New("one or more products not found in your shop")
+	}
+
+	updates := map[string]interface{}{}
+	if req.Clear {
+		updates["scheduled_publish_at"] = nil
+		updates["scheduled_unpublish_at"] = nil
+	} else {
+		if req.PublishAt == nil && req.UnpublishAt == nil {
+			return nil, errors.New("publish_at or unpublish_at is required")
+		}
+
+		now := time.Now()
+		if req.PublishAt != nil {
+			if !req.PublishAt.After(now) {
+				return nil, errors.New("publish_at must be in the future")
+			}
+			updates["scheduled_publish_at"] = *req.PublishAt
+			updates["is_active"] = false
+		}
+		if req.UnpublishAt != nil {
+			if !req.UnpublishAt.After(now) {
+				return nil, errors.New("unpublish_at must be in the future")
+			}
+			if req.PublishAt != nil && !req.UnpublishAt.After(*req.PublishAt) {
+				return nil, errors.New("unpublish_at must be after publish_at")
+			}
+			updates["scheduled_unpublish_at"] = *req.UnpublishAt
+		}
+	}
+
+	ids := make([]string, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	if req.PublishAt != nil && !req.Clear {
+		// Scheduled products count toward the listing limit until they publish
+		if err := s.quota.CheckCapacity(seller, ids); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.productRepo.UpdateByIDs(ids, updates); err != nil {
+		return nil, fmt.Errorf("failed to schedule products: %w", err)
+	}
+
+	return s.productRepo.FindByIDsAndSellerID(ids, seller.ID)
+}
+
+// upcomingVisibilityChanges flattens product schedules into a list ordered by time
+func upcomingVisibilityChanges(products []model.Product) []ScheduledVisibilityChange {
+	changes := []ScheduledVisibilityChange{}
+	for _, product := range products {
+		if product.ScheduledPublishAt != nil {
+			changes = append(changes, ScheduledVisibilityChange{
+				ProductID:   product.ID,
+				ProductName: product.Name,
+				SKU:         product.SKU,
+				Action:      "publish",
+				ScheduledAt: *product.ScheduledPublishAt,
+			})
+		}
+		if product.ScheduledUnpublishAt != nil {
+			changes = append(changes, ScheduledVisibilityChange{
+				ProductID:   product.ID,
+				ProductName: product.Name,
+				SKU:         product.SKU,
+				Action:      "unpublish",
+				ScheduledAt: *product.ScheduledUnpublishAt,
+			})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].ScheduledAt.Before(changes[j].ScheduledAt)
+	})
+	return changes
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// validateDigitalProduct checks that a digital product has something to deliver
+func validateDigitalProduct(product *model.Product) error {
+	if !product.IsDigital {
+		if product.LicenseKeyRequired {
+			return errors.New("license keys are only available for digital products")
+		}
+		return nil
+	}
+	if (product.DigitalFileURL == nil || *product.DigitalFileURL == "") && !product.LicenseKeyRequired {
+		return errors.New("digital products need a digital_file_url or license keys")
+	}
+	return nil
+}
+
+func (s *productService) RecordSale(ctx context.Context, order *model.Order) error {
+	return s.addSoldCounts(order, 1)
+}
+
+func (s *productService) OnOrderStatusChange(ctx context.Context, order *model.Order, from string) error {
+	if order.Status != "cancelled" {
+		return nil
+	}
+	switch from {
+	case "processing", "shipped", "delivered":
+		return s.addSoldCounts(order, -1)
+	}
+	return nil
+}
+
+// addSoldCounts adds (sign 1) or takes off (sign -1) the order's item quantities from the sold counts
+func (s *productService) addSoldCounts(order *model.Order, sign int) error {
+	if len(order.OrderItems) == 0 {
+		return nil
+	}
+	units := make(map[string]int)
+	for _, item := range order.OrderItems {
+		units[item.ProductID] += sign * item.Quantity
+	}
+	if err := s.productRepo.AddSoldCounts(units); err != nil {
+		return fmt.Errorf("failed to update sold counts for order %s: %w", order.OrderNumber, err)
+	}
+	return nil
+}
@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// ProductVariantService manages the variants a seller sells a product in (e.g. sizes and colors),
+// each with its own SKU, stock and optionally price
+type ProductVariantService interface {
+	// GetVariants lists the product's active variants for buyers
+	GetVariants(ctx context.Context, productID string) ([]model.ProductVariant, error)
+	CreateVariant(ctx context.Context, userID string, productID string, req CreateVariantRequest) (*model.ProductVariant, error)
+	UpdateVariant(ctx context.Context, userID string, productID string, variantID string, req UpdateVariantRequest) (*model.ProductVariant, error)
+	DeleteVariant(ctx context.Context, userID string, productID string, variantID string) error
+}
+
+type productVariantService struct {
+	variantRepo repository.ProductVariantRepository
+	productRepo repository.ProductRepository
+	sellerRepo  repository.SellerRepository
+	stock       StockCacheService
+	events      ProductEventService
+}
+
+type CreateVariantRequest struct {
+	SKU       string  `json:"sku" binding:"required,max=100"`
+	Name      string  `json:"name" binding:"max=255"` // Optional: built from the color and size
+	Size      *string `json:"size" binding:"omitempty,max=50"`
+	Color     *string `json:"color" binding:"omitempty,max=50"`
+	Price     *int    `json:"price" binding:"omitempty,min=0"` // Optional: defaults to the product's price
+	Stock     int     `json:"stock" binding:"min=0"`
+	SortOrder int     `json:"sort_order"`
+}
+
+type UpdateVariantRequest struct {
+	SKU        *string `json:"sku" binding:"omitempty,max=100"`
+	Name       *string `json:"name" binding:"omitempty,max=255"`
+	Size       *string `json:"size" binding:"omitempty,max=50"` // Empty string removes it
+	Color      *string `json:"color" binding:"omitempty,max=50"`
+	Price      *int    `json:"price" binding:"omitempty,min=0"`
+	ClearPrice bool    `json:"clear_price"` // Sell at the product's price again
+	Stock      *int    `json:"stock" binding:"omitempty,min=0"`
+	IsActive   *bool   `json:"is_active"`
+	SortOrder  *int    `json:"sort_order"`
+}
+
+func NewProductVariantService(
+	variantRepo repository.ProductVariantRepository,
+	productRepo repository.ProductRepository,
+	sellerRepo repository.SellerRepository,
+	stock StockCacheService,
+	events ProductEventService,
+) ProductVariantService {
+	return &productVariantService{
+		variantRepo: variantRepo,
+		productRepo: productRepo,
+		sellerRepo:  sellerRepo,
+		stock:       stock,
+		events:      events,
+	}
+}
+
+func (s *productVariantService) GetVariants(ctx context.Context, productID string) ([]model.ProductVariant, error) {
+	if _, err := s.productRepo.FindByID(productID); err != nil {
+		return nil, errors.New("product not found")
+	}
+	variants, err := s.variantRepo.FindByProductID(ctx, productID, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variants: %w", err)
+	}
+	return variants, nil
+}
+
+// CreateVariant adds a variant to a product in the seller's shop. From the first variant on the
+// product is only sold per variant and its stock is the sum of the variants' stock.
+func (s *productVariantService) CreateVariant(ctx context.Context, userID string, productID string, req CreateVariantRequest) (*model.ProductVariant, error) {
+	product, err := s.findOwned(userID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	variant := &model.ProductVariant{
+		ProductID: product.ID,
+		SKU:       strings.TrimSpace(req.SKU),
+		Name:      strings.TrimSpace(req.Name),
+		Size:      trimAttribute(req.Size),
+		Color:     trimAttribute(req.Color),
+		Price:     req.Price,
+		Stock:     req.Stock,
+		IsActive:  true,
+		SortOrder: req.SortOrder,
+	}
+	if err := s.validate(ctx, product, variant); err != nil {
+		return nil, err
+	}
+
+	delta, err := s.variantRepo.Create(ctx, variant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create variant: %w", err)
+	}
+	s.stockChanged(ctx, product.ID, delta)
+
+	log.Printf("🎨 Variant %s (%s) added to product %s", variant.SKU, variant.Name, product.ID)
+	return variant, nil
+}
+
+func (s *productVariantService) UpdateVariant(ctx context.Context, userID string, productID string, variantID string, req UpdateVariantRequest) (*model.ProductVariant, error) {
+	product, err := s.findOwned(userID, productID)
+	if err != nil {
+		return nil, err
+	}
+	variant, err := s.variantRepo.FindByID(ctx, variantID)
+	if err != nil || variant.ProductID != product.ID {
+		return nil, errors.New("variant not found")
+	}
+
+	if req.SKU != nil {
+		variant.SKU = strings.TrimSpace(*req.SKU)
+	}
+	if req.Size != nil {
+		variant.Size = trimAttribute(req.Size)
+	}
+	if req.Color != nil {
+		variant.Color = trimAttribute(req.Color)
+	}
+	if req.Name != nil {
+		variant.Name = strings.TrimSpace(*req.Name)
+	} else if req.Size != nil || req.Color != nil {
+		variant.Name = "" // Rebuilt from the new attributes
+	}
+	if req.ClearPrice {
+		variant.Price = nil
+	} else if req.Price != nil {
+		variant.Price = req.Price
+	}
+	if req.Stock != nil {
+		variant.Stock = *req.Stock
+	}
+	if req.IsActive != nil {
+		variant.IsActive = *req.IsActive
+	}
+	if req.SortOrder != nil {
+		variant.SortOrder = *req.SortOrder
+	}
+	if err := s.validate(ctx, product, variant); err != nil {
+		return nil, err
+	}
+
+	delta, err := s.variantRepo.Update(ctx, variant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update variant: %w", err)
+	}
+	s.stockChanged(ctx, product.ID, delta)
+	return variant, nil
+}
+
+// DeleteVariant removes a variant; orders keep its name. Cart lines holding it can no longer be
+// checked out.
+func (s *productVariantService) DeleteVariant(ctx context.Context, userID string, productID string, variantID string) error {
+	product, err := s.findOwned(userID, productID)
+	if err != nil {
+		return err
+	}
+	variant, err := s.variantRepo.FindByID(ctx, variantID)
+	if err != nil || variant.ProductID != product.ID {
+		return errors.New("variant not found")
+	}
+
+	delta, err := s.variantRepo.Delete(ctx, variant.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete variant: %w", err)
+	}
+	s.stockChanged(ctx, product.ID, delta)
+
+	log.Printf("🎨 Variant %s removed from product %s", variant.SKU, product.ID)
+	return nil
+}
+
+// validate checks the variant's SKU and attributes, naming it from its attributes when it has no
+// name. Two variants of a product cannot have the same attributes.
+func (s *productVariantService) validate(ctx context.Context, product *model.Product, variant *model.ProductVariant) error {
+	if variant.SKU == "" {
+		return errors.New("SKU is required")
+	}
+	if existing, err := s.variantRepo.FindBySKU(ctx, variant.SKU); err == nil && existing.ID != variant.ID {
+		return errors.New("SKU already exists")
+	}
+	if existing, err := s.productRepo.FindBySKU(variant.SKU); err == nil && existing != nil {
+		return errors.New("SKU already exists")
+	}
+
+	if variant.Name == "" {
+		var parts []string
+		for _, attribute := range []*string{variant.Color, variant.Size} {
+			if attribute != nil {
+				parts = append(parts, *attribute)
+			}
+		}
+		variant.Name = strings.Join(parts, " / ")
+	}
+	if variant.Name == "" {
+		return errors.New("a variant needs a name, size or color")
+	}
+
+	for _, other := range product.Variants {
+		if other.ID != variant.ID && sameAttribute(other.Size, variant.Size) && sameAttribute(other.Color, variant.Color) &&
+			strings.EqualFold(other.Name, variant.Name) {
+			return fmt.Errorf("the product already has a variant %q", other.Name)
+		}
+	}
+	return nil
+}
+
+// stockChanged writes a change of the product's stock through to the stock counter and tells
+// carts and stock streams holding the product
+func (s *productVariantService) stockChanged(ctx context.Context, productID string, delta int) {
+	s.stock.Adjust(ctx, productID, delta)
+	s.events.Publish(ctx, productID)
+}
+
+func (s *productVariantService) findOwned(userID string, productID string) (*model.Product, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found. Please create a shop first")
+	}
+	product, err := s.productRepo.FindByID(productID)
+	if err != nil || product.SellerID != seller.ID {
+		return nil, errors.New("product not found")
+	}
+	return product, nil
+}
+
+// trimAttribute trims a variant attribute; an empty one is removed
+func trimAttribute(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+func sameAttribute(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return strings.EqualFold(*a, *b)
+}
+
+// resolveVariant returns the variant of the product to buy. A product with variants can only be
+// bought as one of its active variants; nil is returned for a product without variants.
+func resolveVariant(product *model.Product, variantID *string) (*model.ProductVariant, error) {
+	if variantID == nil || *variantID == "" {
+		if len(product.Variants) > 0 {
+			return nil, errors.New("please choose a variant of " + product.Name)
+		}
+		return nil, nil
+	}
+	for i := range product.Variants {
+		variant := &product.Variants[i]
+		if variant.ID != *variantID {
+			continue
+		}
+		if !variant.IsActive {
+			return nil, errors.New("variant is not available")
+		}
+		return variant, nil
+	}
+	return nil, errors.New("variant not found")
+}
+
+// applyVariant returns the product priced as the variant is sold. Its stock is left as the
+// product's, which the stock counters are seeded from; see availableUnits.
+func applyVariant(product model.Product, variant *model.ProductVariant) model.Product {
+	if variant != nil && variant.Price != nil {
+		product.Price = *variant.Price
+	}
+	return product
+}
+
+// cartItemProduct returns the cart item's product as it would be bought, with its variant applied.
+// A variant that was deactivated or removed makes the product inactive.
+func cartItemProduct(item model.CartItem) model.Product {
+	if item.VariantID == nil {
+		return item.Product
+	}
+	product := applyVariant(item.Product, item.Variant)
+	if item.Variant == nil || !item.Variant.IsActive {
+		product.IsActive = false
+	}
+	return product
+}
+
+// availableUnits returns how many units of the product can be bought. The stock counters are kept
+// per product, so a variant is also capped at its own stock.
+func availableUnits(ctx context.Context, stock StockCacheService, product *model.Product, variant *model.ProductVariant) int {
+	available := stock.Available(ctx, product)
+	if variant != nil && variant.Stock < available {
+		available = variant.Stock
+	}
+	return available
+}
+
+// variantName returns the name of the variant for an order item, nil without one
+func variantName(variant *model.ProductVariant) *string {
+	if variant == nil {
+		return nil
+	}
+	name := variant.Name
+	return &name
+}
+
+// cartItemAvailable returns how many units of the cart item's product or variant can be bought
+func cartItemAvailable(ctx context.Context, stock StockCacheService, item model.CartItem) int {
+	return availableUnits(ctx, stock, &item.Product, item.Variant)
+}
+
+// hasStock reports whether the product, and the variant when there is one, has quantity units in stock
+func hasStock(product *model.Product, variant *model.ProductVariant, quantity int) bool {
+	if variant != nil && variant.Stock < quantity {
+		return false
+	}
+	return product.Stock >= quantity
+}
+
+// overridesPrice reports whether the variant is sold at its own price
+func overridesPrice(variant *model.ProductVariant) bool {
+	return variant != nil && variant.Price != nil
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// Push events, sent in the data payload so the app knows which screen to open
+const (
+	PushEventPaymentSuccess = "payment.success"
+	PushEventPaymentExpired = "payment.expired"
+	PushEventOrderShipped   = "order.shipped"
+	PushEventOrderDelivered = "order.delivered"
+	PushEventCartReminder   = "cart.reminder"
+	PushEventOrderChat      = "order.chat_message"
+)
+
+// PushService keeps users' device tokens and sends FCM pushes on order and payment events, so the
+// mobile apps do not have to poll status endpoints. Pushes are sent in the background; a failed
+// push is logged and never affects the change that triggered it.
+type PushService interface {
+	RegisterDevice(ctx context.Context, userID string, req RegisterDeviceRequest) (*model.DeviceToken, error)
+	UnregisterDevice(ctx context.Context, userID string, token string) error
+	// NotifyUser pushes a message to every registered device of the user
+	NotifyUser(userID string, message PushMessage)
+
+	// Hook handlers, registered on the HookRegistry
+	OnPaymentSuccess(ctx context.Context, order *model.Order) error
+	OnPaymentStatusChange(ctx context.Context, payment *model.Payment) error
+	OnOrderStatusChange(ctx context.Context, order *model.Order, from string) error
+
+	// Shutdown waits for pushes still being sent
+	Shutdown(ctx context.Context) error
+}
+
+type pushService struct {
+	deviceRepo repository.DeviceTokenRepository
+	orderRepo  repository.OrderRepository
+	gateway    FCMGateway // nil when FCM is not configured
+	inFlight   sync.WaitGroup
+}
+
+type RegisterDeviceRequest struct {
+	Token    string `json:"token" binding:"required,max=512"`
+	Platform string `json:"platform" binding:"omitempty,oneof=android ios web"`
+}
+
+func NewPushService(deviceRepo repository.DeviceTokenRepository, orderRepo repository.OrderRepository, gateway FCMGateway) PushService {
+	return &pushService{
+		deviceRepo: deviceRepo,
+		orderRepo:  orderRepo,
+		gateway:    gateway,
+	}
+}
+
+func (s *pushService) RegisterDevice(ctx context.Context, userID string, req RegisterDeviceRequest) (*model.DeviceToken, error) {
+	token := strings.TrimSpace(req.Token)
+	if token == "" {
+		return nil, errors.New("token is required")
+	}
+	platform := req.Platform
+	if platform == "" {
+		platform = model.DevicePlatformAndroid
+	}
+
+	device := &model.DeviceToken{
+		UserID:     userID,
+		Token:      token,
+		Platform:   platform,
+		LastSeenAt: time.Now(),
+	}
+	if err := s.deviceRepo.Upsert(ctx, device); err != nil {
+		return nil, errors.New("failed to register device: " + err.Error())
+	}
+	return device, nil
+}
+
+func (s *pushService) UnregisterDevice(ctx context.Context, userID string, token string) error {
+	return s.deviceRepo.Delete(ctx, userID, token)
+}
+
+func (s *pushService) NotifyUser(userID string, message PushMessage) {
+	if s.gateway == nil {
+		return
+	}
+	s.inFlight.Add(1)
+	go func() {
+		defer s.inFlight.Done()
+		s.send(context.Background(), userID, message)
+	}()
+}
+
+// send pushes to each of the user's devices and forgets tokens FCM no longer accepts
+func (s *pushService) send(ctx context.Context, userID string, message PushMessage) {
+	devices, err := s.deviceRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("⚠️  Failed to load devices of user %s for push: %v", userID, err)
+		return
+	}
+
+	var invalid []string
+	for _, device := range devices {
+		err := s.gateway.Send(ctx, device.Token, message)
+		switch {
+		case errors.Is(err, ErrPushTokenInvalid):
+			invalid = append(invalid, device.Token)
+		case err != nil:
+			log.Printf("⚠️  Push %s to user %s failed: %v", message.Data["event"], userID, err)
+		}
+	}
+	if len(invalid) > 0 {
+		if err := s.deviceRepo.DeleteTokens(ctx, invalid); err != nil {
+			log.Printf("⚠️  Failed to remove %d invalid push tokens: %v", len(invalid), err)
+		}
+	}
+	if len(devices) > len(invalid) {
+		log.Printf("📲 Push %s sent to user %s", message.Data["event"], userID)
+	}
+}
+
+func (s *pushService) OnPaymentSuccess(ctx context.Context, order *model.Order) error {
+	s.NotifyUser(order.UserID, orderPush(PushEventPaymentSuccess, order,
+		"Pembayaran berhasil", "Pembayaran pesanan %s sudah kami terima dan pesanan sedang diproses."))
+	return nil
+}
+
+func (s *pushService) OnPaymentStatusChange(ctx context.Context, payment *model.Payment) error {
+	if payment.Status != model.PaymentStatusExpired {
+		return nil // Success is pushed once the order is marked paid
+	}
+	order, err := s.orderRepo.FindByID(ctx, payment.OrderUUID)
+	if err != nil {
+		return fmt.Errorf("order %s not found: %w", payment.OrderUUID, err)
+	}
+	s.NotifyUser(order.UserID, orderPush(PushEventPaymentExpired, order,
+		"Pembayaran kedaluwarsa", "Batas waktu pembayaran pesanan %s sudah lewat."))
+	return nil
+}
+
+func (s *pushService) OnOrderStatusChange(ctx context.Context, order *model.Order, from string) error {
+	switch order.Status {
+	case "shipped":
+		s.NotifyUser(order.UserID, orderPush(PushEventOrderShipped, order,
+			"Pesanan dikirim", "Pesanan %s sedang dalam perjalanan."))
+	case "delivered":
+		s.NotifyUser(order.UserID, orderPush(PushEventOrderDelivered, order,
+			"Pesanan sampai", "Pesanan %s sudah sampai. Terima kasih telah berbelanja!"))
+	}
+	return nil
+}
+
+func (s *pushService) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("push service shutdown: %w", ctx.Err())
+	}
+}
+
+// orderPush builds a push about an order; body must contain one %s for the order number
+func orderPush(event string, order *model.Order, title string, body string) PushMessage {
+	return PushMessage{
+		Title: title,
+		Body:  fmt.Sprintf(body, order.OrderNumber),
+		Data: map[string]string{
+			"event":        event,
+			"order_id":     order.ID,
+			"order_number": order.OrderNumber,
+			"status":       order.Status,
+		},
+	}
+}
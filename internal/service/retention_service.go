@@ -0,0 +1,329 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+)
+
+// retentionBatchSize is how many rows one purge transaction handles
+const retentionBatchSize = 500
+
+// retentionMaxBatches bounds one run per policy so a large backlog is worked off over several runs
+const retentionMaxBatches = 200
+
+// Retention actions
+const (
+	RetentionActionDelete = "delete" // Expired rows are deleted
+	RetentionActionClear  = "clear"  // Only the raw payload column of expired rows is cleared
+)
+
+var ErrRetentionRunning = errors.New("a retention run is already in progress")
+
+// RetentionService prunes data the marketplace only needs for a while (raw gateway payloads, the
+// fraud check log, analytics counters, reminder history, stale cart items) to keep the database
+// and its PII bounded. Purged rows can be archived to gzipped JSON lines first, and purged volumes are kept
+// as metrics.
+type RetentionService interface {
+	// Run purges expired data for every enabled policy and returns the updated report
+	Run(ctx context.Context) (*RetentionReport, error)
+	Report() *RetentionReport
+}
+
+// RetentionPolicyStat is what one policy purged
+type RetentionPolicyStat struct {
+	Name          string     `json:"name"`
+	Action        string     `json:"action"`
+	RetentionDays int        `json:"retention_days"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+	LastPurged    int64      `json:"last_purged"`
+	TotalPurged   int64      `json:"total_purged"`
+	TotalArchived int64      `json:"total_archived"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// RetentionReport lists the policies and their purged volumes since the process started
+type RetentionReport struct {
+	Since       time.Time             `json:"since"`
+	LastRunAt   *time.Time            `json:"last_run_at,omitempty"`
+	TotalPurged int64                 `json:"total_purged"`
+	ArchiveDir  string                `json:"archive_dir,omitempty"`
+	Policies    []RetentionPolicyStat `json:"policies"`
+}
+
+type retentionPolicy struct {
+	name   string
+	days   int
+	target repository.RetentionTarget
+}
+
+type retentionService struct {
+	repo       repository.RetentionRepository
+	policies   []retentionPolicy
+	archiveDir string
+
+	runMu sync.Mutex // Held for the duration of a run
+
+	mu        sync.Mutex // Guards the stats below
+	started   time.Time
+	lastRunAt *time.Time
+	stats     map[string]*RetentionPolicyStat
+}
+
+func NewRetentionService(repo repository.RetentionRepository, cfg *config.Config, jobs *util.BackgroundJobs) RetentionService {
+	service := &retentionService{
+		repo:       repo,
+		archiveDir: cfg.RetentionArchiveDir,
+		started:    time.Now(),
+		stats:      make(map[string]*RetentionPolicyStat),
+		policies: []retentionPolicy{
+			{
+				name: "payments.midtrans_response",
+				days: cfg.RetentionGatewayPayloadDays,
+				target: repository.RetentionTarget{
+					Table:      "payments",
+					TimeColumn: "updated_at",
+					Filter: fmt.Sprintf("midtrans_response IS NOT NULL AND status IN ('%s', '%s', '%s', '%s')",
+						model.PaymentStatusSuccess, model.PaymentStatusFailed, model.PaymentStatusCancelled, model.PaymentStatusExpired),
+					ClearColumn: "midtrans_response",
+					ClearValue:  nil,
+				},
+			},
+			{
+				// Dead retries stay for manual reconciliation; only the raw payload goes
+				name: "payment_status_retries.midtrans_response",
+				days: cfg.RetentionGatewayPayloadDays,
+				target: repository.RetentionTarget{
+					Table:       "payment_status_retries",
+					TimeColumn:  "dead_at",
+					Filter:      "dead_at IS NOT NULL AND midtrans_response <> ''",
+					ClearColumn: "midtrans_response",
+					ClearValue:  "",
+				},
+			},
+			{
+				name:   "fraud_checks",
+				days:   cfg.RetentionFraudCheckDays,
+				target: repository.RetentionTarget{Table: "fraud_checks", TimeColumn: "created_at"},
+			},
+			{
+				name:   "product_funnel_stats",
+				days:   cfg.RetentionAnalyticsDays,
+				target: repository.RetentionTarget{Table: "product_funnel_stats", TimeColumn: "date"},
+			},
+			{
+				name:   "cart_reminders",
+				days:   cfg.RetentionCartReminderDays,
+				target: repository.RetentionTarget{Table: "cart_reminders", TimeColumn: "sent_at"},
+			},
+			{
+				// Lines that can no longer be checked out and that the buyer left alone
+				name: "cart_items.unavailable",
+				days: cfg.RetentionCartUnavailableDays,
+				target: repository.RetentionTarget{
+					Table:      "cart_items",
+					TimeColumn: "updated_at",
+					Filter: "(unavailable_reason IS NOT NULL OR NOT EXISTS (SELECT 1 FROM products WHERE products.id = cart_items.product_id " +
+						"AND products.deleted_at IS NULL AND products.is_active))",
+				},
+			},
+			{
+				// Lines added so long ago at a price so different that they no longer reflect what the buyer wanted
+				name: "cart_items.stale_price",
+				days: cfg.RetentionCartStalePriceDays,
+				target: repository.RetentionTarget{
+					Table:      "cart_items",
+					TimeColumn: "updated_at",
+					Filter: fmt.Sprintf("EXISTS (SELECT 1 FROM products WHERE products.id = cart_items.product_id "+
+						"AND ABS(products.price - cart_items.price) * 100 > cart_items.price * %d)", cfg.RetentionCartPriceChangePct),
+				},
+			},
+			{
+				// Seller webhook deliveries that were delivered or given up on
+				name: "seller_webhook_deliveries",
+				days: cfg.RetentionWebhookDeliveryDays,
+				target: repository.RetentionTarget{
+					Table:      "seller_webhook_deliveries",
+					TimeColumn: "created_at",
+					Filter:     "status <> 'pending'",
+				},
+			},
+		},
+	}
+	for _, policy := range service.policies {
+		action := RetentionActionDelete
+		if policy.target.ClearColumn != "" {
+			action = RetentionActionClear
+		}
+		service.stats[policy.name] = &RetentionPolicyStat{Name: policy.name, Action: action, RetentionDays: policy.days}
+	}
+
+	// Start background job to purge expired data
+	if cfg.RetentionCheckIntervalMinutes > 0 {
+		interval := time.Duration(cfg.RetentionCheckIntervalMinutes) * time.Minute
+		jobs.Every(interval, service.runRetention)
+		log.Printf("✅ Data retention started (checking every %s)", interval)
+	}
+
+	return service
+}
+
+// runRetention purges expired data
+func (s *retentionService) runRetention(ctx context.Context) {
+	if _, err := s.Run(ctx); err != nil && !errors.Is(err, ErrRetentionRunning) {
+		log.Printf("⚠️  Data retention run failed: %v", err)
+	}
+}
+
+func (s *retentionService) Run(ctx context.Context) (*RetentionReport, error) {
+	if !s.runMu.TryLock() {
+		return nil, ErrRetentionRunning
+	}
+	defer s.runMu.Unlock()
+
+	now := time.Now()
+	var failed error
+	for _, policy := range s.policies {
+		if policy.days <= 0 {
+			continue
+		}
+		purged, archived, err := s.purge(ctx, policy, now)
+
+		s.mu.Lock()
+		stat := s.stats[policy.name]
+		runAt := now
+		stat.LastRunAt = &runAt
+		stat.LastPurged = purged
+		stat.TotalPurged += purged
+		stat.TotalArchived += archived
+		stat.LastError = ""
+		if err != nil {
+			stat.LastError = err.Error()
+		}
+		s.mu.Unlock()
+
+		if purged > 0 {
+			log.Printf("🧹 Retention purged %d row(s) of %s older than %d days", purged, policy.name, policy.days)
+		}
+		if err != nil {
+			log.Printf("⚠️  Retention of %s failed: %v", policy.name, err)
+			if failed == nil {
+				failed = fmt.Errorf("%s: %w", policy.name, err)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.lastRunAt = &now
+	s.mu.Unlock()
+	return s.Report(), failed
+}
+
+// purge works off expired rows of one policy in batches and returns how many were purged and
+// archived
+func (s *retentionService) purge(ctx context.Context, policy retentionPolicy, now time.Time) (int64, int64, error) {
+	cutoff := now.AddDate(0, 0, -policy.days)
+
+	var archive *retentionArchive
+	var archiveFn func(rows []map[string]interface{}) error
+	if s.archiveDir != "" {
+		archive = &retentionArchive{path: filepath.Join(s.archiveDir, fmt.Sprintf("%s-%s.jsonl.gz", policy.name, now.UTC().Format("20060102T150405Z")))}
+		archiveFn = archive.write
+	}
+
+	var purged int64
+	var err error
+	for i := 0; i < retentionMaxBatches && ctx.Err() == nil; i++ {
+		var n int64
+		n, err = s.repo.PurgeBatch(ctx, policy.target, cutoff, retentionBatchSize, archiveFn)
+		purged += n
+		if err != nil || n < retentionBatchSize {
+			break
+		}
+	}
+
+	var archived int64
+	if archive != nil {
+		archived = archive.rows
+		if closeErr := archive.close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return purged, archived, err
+}
+
+func (s *retentionService) Report() *RetentionReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := &RetentionReport{
+		Since:      s.started,
+		LastRunAt:  s.lastRunAt,
+		ArchiveDir: s.archiveDir,
+		Policies:   make([]RetentionPolicyStat, 0, len(s.policies)),
+	}
+	for _, policy := range s.policies {
+		stat := *s.stats[policy.name]
+		report.TotalPurged += stat.TotalPurged
+		report.Policies = append(report.Policies, stat)
+	}
+	return report
+}
+
+// retentionArchive writes purged rows of one policy run to a gzipped JSON lines file, created on
+// the first batch so runs that purge nothing leave no files behind
+type retentionArchive struct {
+	path string
+	file *os.File
+	gz   *gzip.Writer
+	enc  *json.Encoder
+	rows int64
+}
+
+// write appends rows and flushes them to the file, so they are on disk before the batch commits
+func (a *retentionArchive) write(rows []map[string]interface{}) error {
+	if a.file == nil {
+		if err := os.MkdirAll(filepath.Dir(a.path), 0o750); err != nil {
+			return fmt.Errorf("failed to create archive directory: %w", err)
+		}
+		file, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+		if err != nil {
+			return fmt.Errorf("failed to open archive: %w", err)
+		}
+		a.file = file
+		a.gz = gzip.NewWriter(file)
+		a.enc = json.NewEncoder(a.gz)
+	}
+	for _, row := range rows {
+		if err := a.enc.Encode(row); err != nil {
+			return fmt.Errorf("failed to archive row: %w", err)
+		}
+	}
+	if err := a.gz.Flush(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	a.rows += int64(len(rows))
+	return nil
+}
+
+func (a *retentionArchive) close() error {
+	if a.file == nil {
+		return nil
+	}
+	if err := a.gz.Close(); err != nil {
+		a.file.Close()
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return a.file.Close()
+}
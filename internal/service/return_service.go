@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// maxReturnPhotos caps how many evidence photos a buyer can attach to a return
+const maxReturnPhotos = 5
+
+// ErrReturnWindowClosed is returned when a buyer opens a return too long after delivery
+var ErrReturnWindowClosed = errors.New("the return window for this order has closed")
+
+// ErrRefundFailed is returned when the item was received but the refund could not be made yet.
+// The return stays item_received and receiving it again retries the refund.
+var ErrRefundFailed = errors.New("refund failed")
+
+// ReturnService handles buyers sending back delivered sub-orders and sellers reviewing them
+type ReturnService interface {
+	OpenReturn(ctx context.Context, userID string, orderID string, req OpenReturnRequest, photoURLs []string) (*model.ReturnRequest, error)
+	GetMyReturns(ctx context.Context, userID string, status string, page, limit int) ([]model.ReturnRequest, int64, error)
+	GetMyReturn(ctx context.Context, userID string, returnID string) (*model.ReturnRequest, error)
+	GetSellerReturns(ctx context.Context, userID string, status string, page, limit int) ([]model.ReturnRequest, int64, error)
+	ApproveReturn(ctx context.Context, userID string, returnID string, note *string) (*model.ReturnRequest, error)
+	RejectReturn(ctx context.Context, userID string, returnID string, reason string) (*model.ReturnRequest, error)
+	ReceiveReturn(ctx context.Context, userID string, returnID string) (*model.ReturnRequest, error)
+}
+
+type returnService struct {
+	returnRepo      repository.ReturnRequestRepository
+	orderRepo       repository.OrderRepository
+	sellerOrderRepo repository.SellerOrderRepository
+	sellerRepo      repository.SellerRepository
+	paymentService  PaymentService
+	windowDays      int
+}
+
+// OpenReturnRequest is the buyer's return form; photos are uploaded alongside as multipart files
+type OpenReturnRequest struct {
+	SellerOrderID string `form:"seller_order_id" binding:"required"`
+	Reason        string `form:"reason" binding:"required,max=1000"`
+}
+
+func NewReturnService(returnRepo repository.ReturnRequestRepository, orderRepo repository.OrderRepository, sellerOrderRepo repository.SellerOrderRepository, sellerRepo repository.SellerRepository, paymentService PaymentService, cfg *config.Config) ReturnService {
+	return &returnService{
+		returnRepo:      returnRepo,
+		orderRepo:       orderRepo,
+		sellerOrderRepo: sellerOrderRepo,
+		sellerRepo:      sellerRepo,
+		paymentService:  paymentService,
+		windowDays:      cfg.ReturnWindowDays,
+	}
+}
+
+// OpenReturn asks to return one delivered sub-order of the buyer's order
+func (s *returnService) OpenReturn(ctx context.Context, userID string, orderID string, req OpenReturnRequest, photoURLs []string) (*model.ReturnRequest, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	if len(photoURLs) > maxReturnPhotos {
+		return nil, fmt.Errorf("at most %d photos can be attached", maxReturnPhotos)
+	}
+
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil || order.UserID != userID {
+		return nil, errors.New("order not found")
+	}
+	sellerOrder, err := s.sellerOrderRepo.FindByID(ctx, req.SellerOrderID)
+	if err != nil || sellerOrder.OrderID != order.ID {
+		return nil, errors.New("order not found")
+	}
+	if sellerOrder.Status != "delivered" || order.DeliveredAt == nil {
+		return nil, errors.New("only delivered orders can be returned")
+	}
+	if time.Since(*order.DeliveredAt) > time.Duration(s.windowDays)*24*time.Hour {
+		return nil, fmt.Errorf("%w (%d days after delivery)", ErrReturnWindowClosed, s.windowDays)
+	}
+	if _, err := s.returnRepo.FindBySellerOrderID(ctx, sellerOrder.ID); err == nil {
+		return nil, errors.New("a return has already been requested for this order")
+	}
+
+	returnRequest := &model.ReturnRequest{
+		OrderID:       order.ID,
+		SellerOrderID: sellerOrder.ID,
+		UserID:        userID,
+		SellerID:      sellerOrder.SellerID,
+		Reason:        reason,
+		Status:        model.ReturnStatusRequested,
+		RefundAmount:  sellerOrder.Subtotal,
+	}
+	for i, url := range photoURLs {
+		returnRequest.Photos = append(returnRequest.Photos, model.ReturnRequestPhoto{ImageURL: url, SortOrder: i})
+	}
+	if err := s.returnRepo.Create(ctx, returnRequest); err != nil {
+		return nil, errors.New("failed to create return request: " + err.Error())
+	}
+
+	log.Printf("↩️  Return %s requested for sub-order %s by user %s", returnRequest.ID, sellerOrder.SubOrderNumber, userID)
+	return s.returnRepo.FindByID(ctx, returnRequest.ID)
+}
+
+func (s *returnService) GetMyReturns(ctx context.Context, userID string, status string, page, limit int) ([]model.ReturnRequest, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	returns, total, err := s.returnRepo.FindByUserID(ctx, userID, status, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get returns: " + err.Error())
+	}
+	return returns, total, nil
+}
+
+func (s *returnService) GetMyReturn(ctx context.Context, userID string, returnID string) (*model.ReturnRequest, error) {
+	returnRequest, err := s.returnRepo.FindByID(ctx, returnID)
+	if err != nil || returnRequest.UserID != userID {
+		return nil, errors.New("return not found")
+	}
+	return returnRequest, nil
+}
+
+func (s *returnService) GetSellerReturns(ctx context.Context, userID string, status string, page, limit int) ([]model.ReturnRequest, int64, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, 0, errors.New("seller not found")
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	returns, total, err := s.returnRepo.FindBySellerID(ctx, seller.ID, status, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get returns: " + err.Error())
+	}
+	return returns, total, nil
+}
+
+// ApproveReturn accepts a requested return; the note can tell the buyer where to send the item
+func (s *returnService) ApproveReturn(ctx context.Context, userID string, returnID string, note *string) (*model.ReturnRequest, error) {
+	returnRequest, err := s.findOwnedBySeller(ctx, userID, returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{"approved_at": time.Now()}
+	if note != nil && strings.TrimSpace(*note) != "" {
+		updates["seller_note"] = strings.TrimSpace(*note)
+	}
+	if err := s.returnRepo.Transition(ctx, returnRequest.ID, model.ReturnStatusRequested, model.ReturnStatusApproved, updates); err != nil {
+		return nil, s.transitionError(err, returnRequest, model.ReturnStatusApproved)
+	}
+
+	log.Printf("↩️  Return %s approved by seller %s", returnRequest.ID, returnRequest.SellerID)
+	return s.returnRepo.FindByID(ctx, returnRequest.ID)
+}
+
+// RejectReturn turns down a requested return with a reason for the buyer
+func (s *returnService) RejectReturn(ctx context.Context, userID string, returnID string, reason string) (*model.ReturnRequest, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	returnRequest, err := s.findOwnedBySeller(ctx, userID, returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{"rejected_at": time.Now(), "seller_note": reason}
+	if err := s.returnRepo.Transition(ctx, returnRequest.ID, model.ReturnStatusRequested, model.ReturnStatusRejected, updates); err != nil {
+		return nil, s.transitionError(err, returnRequest, model.ReturnStatusRejected)
+	}
+
+	log.Printf("↩️  Return %s rejected by seller %s", returnRequest.ID, returnRequest.SellerID)
+	return s.returnRepo.FindByID(ctx, returnRequest.ID)
+}
+
+// ReceiveReturn records that the seller got the item back and refunds the buyer. If the refund
+// fails the return stays item_received and calling this again retries it with the same refund key.
+func (s *returnService) ReceiveReturn(ctx context.Context, userID string, returnID string) (*model.ReturnRequest, error) {
+	returnRequest, err := s.findOwnedBySeller(ctx, userID, returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	refundKey := "return-" + returnRequest.ID
+	if returnRequest.Status != model.ReturnStatusItemReceived {
+		updates := map[string]interface{}{"received_at": time.Now(), "refund_key": refundKey}
+		if err := s.returnRepo.Transition(ctx, returnRequest.ID, model.ReturnStatusApproved, model.ReturnStatusItemReceived, updates); err != nil {
+			return nil, s.transitionError(err, returnRequest, model.ReturnStatusItemReceived)
+		}
+		log.Printf("↩️  Return %s received by seller %s", returnRequest.ID, returnRequest.SellerID)
+	}
+
+	reason := "Return of " + returnRequest.SellerOrder.SubOrderNumber
+	method, err := s.paymentService.RefundPayment(ctx, returnRequest.OrderID, refundKey, returnRequest.RefundAmount, reason)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRefundFailed, err)
+	}
+	if err := s.returnRepo.MarkRefunded(ctx, returnRequest.ID, method, time.Now()); err != nil {
+		return nil, errors.New("refund was made but the return could not be updated: " + err.Error())
+	}
+
+	log.Printf("💸 Return %s refunded %d via %s", returnRequest.ID, returnRequest.RefundAmount, method)
+	return s.returnRepo.FindByID(ctx, returnRequest.ID)
+}
+
+// findOwnedBySeller loads a return request, making sure it belongs to the user's shop
+func (s *returnService) findOwnedBySeller(ctx context.Context, userID string, returnID string) (*model.ReturnRequest, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	returnRequest, err := s.returnRepo.FindByID(ctx, returnID)
+	if err != nil || returnRequest.SellerID != seller.ID {
+		return nil, errors.New("return not found")
+	}
+	return returnRequest, nil
+}
+
+func (s *returnService) transitionError(err error, returnRequest *model.ReturnRequest, to string) error {
+	if errors.Is(err, repository.ErrInvalidReturnTransition) {
+		return fmt.Errorf("%w: %s to %s", err, returnRequest.Status, to)
+	}
+	return errors.New("failed to update return: " + err.Error())
+}
@@ -0,0 +1,396 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Sandbox limits
+const (
+	sandboxPaymentExpiry        = 24 * time.Hour
+	sandboxNotificationMaxLimit = 100
+)
+
+// ErrSandboxOnly is returned when a live API key calls a sandbox-only endpoint
+var ErrSandboxOnly = errors.New("this endpoint requires a sandbox API key")
+
+// sandboxCatalog seeds every new sandbox key. The low and zero stock entries let partners test
+// their stock warnings and out-of-stock handling.
+var sandboxCatalog = []model.SandboxProduct{
+	{SKU: "SBX-TSHIRT-BLK-M", Name: "Kaos Polos Hitam (M)", Price: 79000, Stock: 120},
+	{SKU: "SBX-SNEAKER-WHT-42", Name: "Sepatu Sneakers Putih (42)", Price: 349000, Stock: 25},
+	{SKU: "SBX-COFFEE-GAYO-250", Name: "Kopi Arabika Gayo 250g", Price: 95000, Stock: 60},
+	{SKU: "SBX-CASE-SILICONE", Name: "Casing HP Silikon", Price: 35000, Stock: 3},
+	{SKU: "SBX-BACKPACK-15", Name: "Tas Ransel Laptop 15 inci", Price: 275000, Stock: 0},
+}
+
+// SandboxService serves the partner API for sandbox keys from the sandbox tables, and simulates
+// orders, payments and notifications there. Every method rejects live keys with ErrSandboxOnly.
+type SandboxService interface {
+	// Seed fills a new sandbox key's catalog
+	Seed(ctx context.Context, apiKey *model.PartnerAPIKey) error
+	// Reset deletes the key's sandbox data and seeds a fresh catalog
+	Reset(ctx context.Context, apiKey *model.PartnerAPIKey) error
+	GetInventoryChanges(ctx context.Context, apiKey *model.PartnerAPIKey, cursor string, limit int) (*InventoryChangesResponse, error)
+	AdjustStock(ctx context.Context, apiKey *model.PartnerAPIKey, req BulkStockAdjustmentRequest) (*BulkStockAdjustmentResponse, error)
+	CreateOrder(ctx context.Context, apiKey *model.PartnerAPIKey, req SandboxOrderRequest) (*model.SandboxOrder, error)
+	GetOrders(ctx context.Context, apiKey *model.PartnerAPIKey, page, limit int) ([]model.SandboxOrder, int64, error)
+	GetOrder(ctx context.Context, apiKey *model.PartnerAPIKey, orderID string) (*model.SandboxOrder, error)
+	// SimulatePayment settles a pending sandbox order with the given outcome (success, failed, expired)
+	SimulatePayment(ctx context.Context, apiKey *model.PartnerAPIKey, orderID string, outcome string) (*model.SandboxOrder, error)
+	GetNotifications(ctx context.Context, apiKey *model.PartnerAPIKey, limit int) ([]model.SandboxNotification, error)
+}
+
+type sandboxService struct {
+	sandboxRepo repository.SandboxRepository
+}
+
+type SandboxOrderRequest struct {
+	Items         []SandboxOrderItemRequest `json:"items" binding:"required,min=1,max=50,dive"`
+	PaymentMethod string                    `json:"payment_method" binding:"required"`
+}
+
+type SandboxOrderItemRequest struct {
+	SKU      string `json:"sku" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,min=1"`
+}
+
+func NewSandboxService(sandboxRepo repository.SandboxRepository) SandboxService {
+	return &sandboxService{
+		sandboxRepo: sandboxRepo,
+	}
+}
+
+func (s *sandboxService) Seed(ctx context.Context, apiKey *model.PartnerAPIKey) error {
+	if !apiKey.Sandbox {
+		return ErrSandboxOnly
+	}
+
+	products := make([]model.SandboxProduct, len(sandboxCatalog))
+	for i, product := range sandboxCatalog {
+		product.APIKeyID = apiKey.ID
+		product.IsActive = true
+		products[i] = product
+	}
+	if err := s.sandboxRepo.CreateProducts(ctx, products); err != nil {
+		return fmt.Errorf("failed to seed sandbox catalog: %w", err)
+	}
+	return nil
+}
+
+func (s *sandboxService) Reset(ctx context.Context, apiKey *model.PartnerAPIKey) error {
+	if !apiKey.Sandbox {
+		return ErrSandboxOnly
+	}
+	if err := s.sandboxRepo.Reset(ctx, apiKey.ID); err != nil {
+		return fmt.Errorf("failed to reset sandbox: %w", err)
+	}
+
+	log.Printf("🧪 Sandbox data of API key %s reset", apiKey.KeyPrefix)
+	return s.Seed(ctx, apiKey)
+}
+
+// GetInventoryChanges pages through the sandbox catalog exactly like the live inventory feed
+func (s *sandboxService) GetInventoryChanges(ctx context.Context, apiKey *model.PartnerAPIKey, cursor string, limit int) (*InventoryChangesResponse, error) {
+	if !apiKey.Sandbox {
+		return nil, ErrSandboxOnly
+	}
+	if limit < 1 {
+		limit = partnerInventoryDefaultLimit
+	}
+	if limit > partnerInventoryMaxLimit {
+		limit = partnerInventoryMaxLimit
+	}
+
+	since, afterID, err := decodeInventoryCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := s.sandboxRepo.FindProductChangesSince(ctx, apiKey.ID, since, afterID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory changes: %w", err)
+	}
+
+	hasMore := len(products) > limit
+	if hasMore {
+		products = products[:limit]
+	}
+
+	changes := make([]InventoryChange, 0, len(products))
+	for _, product := range products {
+		changes = append(changes, InventoryChange{
+			ProductID: product.ID,
+			SKU:       product.SKU,
+			Name:      product.Name,
+			Price:     product.Price,
+			Stock:     product.Stock,
+			IsActive:  product.IsActive,
+			ChangedAt: product.UpdatedAt,
+		})
+	}
+
+	nextCursor := cursor
+	if len(changes) > 0 {
+		last := changes[len(changes)-1]
+		nextCursor = encodeInventoryCursor(last.ChangedAt, last.ProductID)
+	}
+
+	return &InventoryChangesResponse{
+		Changes:    changes,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}, nil
+}
+
+// AdjustStock applies adjustments to sandbox products with the live endpoint's semantics
+func (s *sandboxService) AdjustStock(ctx context.Context, apiKey *model.PartnerAPIKey, req BulkStockAdjustmentRequest) (*BulkStockAdjustmentResponse, error) {
+	if !apiKey.Sandbox {
+		return nil, ErrSandboxOnly
+	}
+
+	resp := &BulkStockAdjustmentResponse{Results: make([]StockAdjustmentResult, 0, len(req.Adjustments))}
+	for _, item := range req.Adjustments {
+		result := s.applyAdjustment(ctx, apiKey, item)
+		switch result.Status {
+		case "applied":
+			resp.Applied++
+		case "duplicate":
+			resp.Duplicate++
+		default:
+			resp.Failed++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+func (s *sandboxService) applyAdjustment(ctx context.Context, apiKey *model.PartnerAPIKey, item StockAdjustmentItem) StockAdjustmentResult {
+	result := StockAdjustmentResult{Reference: item.Reference}
+
+	if existing, err := s.sandboxRepo.FindAdjustmentByReference(ctx, apiKey.ID, item.Reference); err == nil {
+		result.Status = "duplicate"
+		result.Adjustment = sandboxAdjustmentResponse(apiKey, existing)
+		return result
+	}
+
+	if (item.Delta == nil) == (item.SetStock == nil) {
+		result.Status = "failed"
+		result.Error = "exactly one of delta or set_stock is required"
+		return result
+	}
+
+	product, err := s.sandboxRepo.FindProductBySKU(ctx, apiKey.ID, item.SKU)
+	if err != nil {
+		result.Status = "failed"
+		result.Error = "product not found: " + item.SKU
+		return result
+	}
+
+	adjustment := &model.SandboxStockAdjustment{
+		APIKeyID:  apiKey.ID,
+		Reference: item.Reference,
+		ProductID: product.ID,
+		SKU:       product.SKU,
+		Reason:    item.Reason,
+	}
+	if item.Delta != nil {
+		adjustment.Delta = *item.Delta
+	}
+
+	if err := s.sandboxRepo.ApplyStockAdjustment(ctx, adjustment, item.SetStock); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate") {
+			if existing, findErr := s.sandboxRepo.FindAdjustmentByReference(ctx, apiKey.ID, item.Reference); findErr == nil {
+				result.Status = "duplicate"
+				result.Adjustment = sandboxAdjustmentResponse(apiKey, existing)
+				return result
+			}
+		}
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = "applied"
+	result.Adjustment = sandboxAdjustmentResponse(apiKey, adjustment)
+	return result
+}
+
+// sandboxAdjustmentResponse shapes a sandbox adjustment like a live one, so partner code parses
+// both the same way
+func sandboxAdjustmentResponse(apiKey *model.PartnerAPIKey, adjustment *model.SandboxStockAdjustment) *model.StockAdjustment {
+	return &model.StockAdjustment{
+		ID:            adjustment.ID,
+		SellerID:      apiKey.SellerID,
+		Reference:     adjustment.Reference,
+		ProductID:     adjustment.ProductID,
+		SKU:           adjustment.SKU,
+		Delta:         adjustment.Delta,
+		PreviousStock: adjustment.PreviousStock,
+		NewStock:      adjustment.NewStock,
+		Source:        "sandbox",
+		Reason:        adjustment.Reason,
+		APIKeyID:      &apiKey.ID,
+		CreatedAt:     adjustment.CreatedAt,
+	}
+}
+
+func (s *sandboxService) CreateOrder(ctx context.Context, apiKey *model.PartnerAPIKey, req SandboxOrderRequest) (*model.SandboxOrder, error) {
+	if !apiKey.Sandbox {
+		return nil, ErrSandboxOnly
+	}
+
+	method := model.PaymentMethod(req.PaymentMethod)
+	switch method {
+	case model.PaymentMethodBankTransfer, model.PaymentMethodGopay, model.PaymentMethodQRIS,
+		model.PaymentMethodCreditCard, model.PaymentMethodAlfamart:
+	default:
+		return nil, errors.New("invalid payment method")
+	}
+
+	order := &model.SandboxOrder{
+		APIKeyID:      apiKey.ID,
+		Status:        model.SandboxOrderPendingPayment,
+		PaymentMethod: req.PaymentMethod,
+		PaymentStatus: model.SandboxPaymentPending,
+		ExpiryTime:    time.Now().Add(sandboxPaymentExpiry),
+	}
+	for _, item := range req.Items {
+		product, err := s.sandboxRepo.FindProductBySKU(ctx, apiKey.ID, item.SKU)
+		if err != nil {
+			return nil, errors.New("product not found: " + item.SKU)
+		}
+		if !product.IsActive {
+			return nil, errors.New("product is not available: " + item.SKU)
+		}
+		order.Items = append(order.Items, model.SandboxOrderItem{
+			ProductID: product.ID,
+			SKU:       product.SKU,
+			Name:      product.Name,
+			Quantity:  item.Quantity,
+			Price:     product.Price,
+		})
+		order.TotalAmount += product.Price * item.Quantity
+	}
+	if method == model.PaymentMethodBankTransfer {
+		va := sandboxVANumber()
+		order.VANumber = &va
+	}
+
+	if err := s.sandboxRepo.CreateOrder(ctx, order); err != nil {
+		if errors.Is(err, repository.ErrSandboxInsufficientStock) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create sandbox order: %w", err)
+	}
+
+	s.notify(ctx, apiKey, order, "order.created", fmt.Sprintf("Order %s created, waiting for payment of %s", order.OrderNumber, formatRupiah(order.TotalAmount)))
+	return order, nil
+}
+
+func (s *sandboxService) GetOrders(ctx context.Context, apiKey *model.PartnerAPIKey, page, limit int) ([]model.SandboxOrder, int64, error) {
+	if !apiKey.Sandbox {
+		return nil, 0, ErrSandboxOnly
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return s.sandboxRepo.FindOrders(ctx, apiKey.ID, page, limit)
+}
+
+func (s *sandboxService) GetOrder(ctx context.Context, apiKey *model.PartnerAPIKey, orderID string) (*model.SandboxOrder, error) {
+	if !apiKey.Sandbox {
+		return nil, ErrSandboxOnly
+	}
+	order, err := s.sandboxRepo.FindOrderByID(ctx, apiKey.ID, orderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+	return order, nil
+}
+
+func (s *sandboxService) SimulatePayment(ctx context.Context, apiKey *model.PartnerAPIKey, orderID string, outcome string) (*model.SandboxOrder, error) {
+	if !apiKey.Sandbox {
+		return nil, ErrSandboxOnly
+	}
+
+	order, err := s.sandboxRepo.FindOrderByID(ctx, apiKey.ID, orderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+	if order.Status != model.SandboxOrderPendingPayment {
+		return nil, errors.New("order is not waiting for payment")
+	}
+
+	restock := false
+	var message string
+	switch outcome {
+	case model.SandboxPaymentSuccess:
+		now := time.Now()
+		order.Status = model.SandboxOrderPaid
+		order.PaidAt = &now
+		message = fmt.Sprintf("Payment for order %s received", order.OrderNumber)
+	case model.SandboxPaymentFailed, model.SandboxPaymentExpired:
+		order.Status = model.SandboxOrderCancelled
+		restock = true
+		message = fmt.Sprintf("Payment for order %s %s, the order was cancelled", order.OrderNumber, outcome)
+	default:
+		return nil, errors.New("invalid outcome, use success, failed or expired")
+	}
+	order.PaymentStatus = outcome
+
+	if err := s.sandboxRepo.UpdateOrder(ctx, order, restock); err != nil {
+		return nil, fmt.Errorf("failed to update sandbox order: %w", err)
+	}
+
+	s.notify(ctx, apiKey, order, "payment."+outcome, message)
+	return order, nil
+}
+
+func (s *sandboxService) GetNotifications(ctx context.Context, apiKey *model.PartnerAPIKey, limit int) ([]model.SandboxNotification, error) {
+	if !apiKey.Sandbox {
+		return nil, ErrSandboxOnly
+	}
+	if limit < 1 || limit > sandboxNotificationMaxLimit {
+		limit = sandboxNotificationMaxLimit
+	}
+	return s.sandboxRepo.FindNotifications(ctx, apiKey.ID, limit)
+}
+
+// notify records the buyer email and push notification a live order event would send
+func (s *sandboxService) notify(ctx context.Context, apiKey *model.PartnerAPIKey, order *model.SandboxOrder, event string, message string) {
+	for _, channel := range []string{"email", "push"} {
+		notification := &model.SandboxNotification{
+			APIKeyID: apiKey.ID,
+			Event:    event,
+			OrderID:  &order.ID,
+			Channel:  channel,
+			Message:  message,
+		}
+		if err := s.sandboxRepo.CreateNotification(ctx, notification); err != nil {
+			log.Printf("⚠️  Failed to record sandbox notification %s for order %s: %v", event, order.OrderNumber, err)
+		}
+	}
+}
+
+// sandboxVANumber returns a fake virtual account number in the shape of a BCA one
+func sandboxVANumber() string {
+	n, err := rand.Int(rand.Reader, big.NewInt(1e11))
+	if err != nil {
+		return "80000000000"
+	}
+	return fmt.Sprintf("8%011d", n.Int64())
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"yourapp/internal/model"
+)
+
+// SaveForLater moves a cart item to the user's saved list, so the cart only holds what the buyer
+// means to purchase now
+func (s *cartService) SaveForLater(ctx context.Context, userID string, cartItemID string) (*model.SavedForLaterItem, error) {
+	cart, err := s.cartRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, errors.New("cart not found")
+	}
+
+	cartItem, err := s.cartRepo.GetCartItemByID(cartItemID)
+	if err != nil {
+		return nil, errors.New("cart item not found")
+	}
+	if cartItem.CartID != cart.ID {
+		return nil, errors.New("unauthorized")
+	}
+
+	saved, err := s.savedRepo.MoveFromCart(ctx, userID, cartItem)
+	if err != nil {
+		return nil, errors.New("failed to save item for later: " + err.Error())
+	}
+	s.cartRepo.Invalidate(userID)
+
+	// Reload with product details
+	return s.savedRepo.FindByID(ctx, saved.ID)
+}
+
+// GetSavedForLater lists the user's saved items, most recently saved first
+func (s *cartService) GetSavedForLater(ctx context.Context, userID string) ([]model.SavedForLaterItem, error) {
+	return s.savedRepo.FindByUserID(ctx, userID)
+}
+
+// MoveToCart puts a saved item back in the cart with the same checks as adding it. The item stays
+// saved when it cannot be added (e.g. out of stock).
+func (s *cartService) MoveToCart(ctx context.Context, userID string, savedItemID string) (*model.CartItem, error) {
+	saved, err := s.savedRepo.FindByID(ctx, savedItemID)
+	if err != nil || saved.UserID != userID {
+		return nil, errors.New("saved item not found")
+	}
+
+	cartItem, err := s.AddItemToCart(userID, &AddCartItemRequest{ProductID: saved.ProductID, Quantity: saved.Quantity})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.savedRepo.Delete(ctx, saved.ID); err != nil {
+		log.Printf("⚠️  Failed to remove saved item %s after moving it to the cart: %v", saved.ID, err)
+	}
+	return cartItem, nil
+}
+
+// RemoveSavedForLater deletes an item from the user's saved list
+func (s *cartService) RemoveSavedForLater(ctx context.Context, userID string, savedItemID string) error {
+	saved, err := s.savedRepo.FindByID(ctx, savedItemID)
+	if err != nil || saved.UserID != userID {
+		return errors.New("saved item not found")
+	}
+	return s.savedRepo.Delete(ctx, saved.ID)
+}
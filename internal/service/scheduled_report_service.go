@@ -0,0 +1,625 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+
+	"github.com/google/uuid"
+)
+
+// Built-in scheduled reports
+const (
+	ReportDailySales         = "daily_sales"
+	ReportPendingSettlements = "pending_settlements"
+	ReportFailedWebhooks     = "failed_webhooks"
+)
+
+// Scheduled report limits
+const (
+	scheduledReportBatchSize     = 10 // Reports one check runs at most
+	scheduledReportMaxRecipients = 20
+	scheduledReportLease         = 10 * time.Minute // Time a claimed report has to finish before it can be claimed again
+)
+
+// ReportDefinition is a report that can be scheduled. The built-in reports are registered when the
+// service is created; others can be added with RegisterReport.
+type ReportDefinition struct {
+	Key         string   `json:"key"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Columns     []string `json:"columns"`
+	// Period returns the time range a run at now covers; nil means everything since the last
+	// successful run (or the last day on the first run)
+	Period func(now time.Time, lastSuccess *time.Time) ReportPeriod `json:"-"`
+	// Query returns the report's rows, each in column order
+	Query func(ctx context.Context, period ReportPeriod) ([][]string, error) `json:"-"`
+}
+
+// ReportPeriod is the time range a report run covers
+type ReportPeriod struct {
+	From time.Time
+	To   time.Time
+}
+
+// ScheduledReportService runs reports on cron schedules and delivers the output (CSV or JSON) by
+// email, as an attachment, or to a webhook.
+//
+// A webhook delivery is a POST with the output as its body and the headers X-Report, X-Report-Run,
+// X-Report-Period-From, X-Report-Period-To, X-Webhook-Timestamp and
+// X-Webhook-Signature: sha256=HMAC-SHA256(secret, timestamp + "." + body) in hex, signed like
+// seller webhooks.
+type ScheduledReportService interface {
+	// RegisterReport makes a report available for scheduling, replacing one with the same key
+	RegisterReport(definition ReportDefinition)
+	GetDefinitions() []ReportDefinition
+
+	CreateReport(ctx context.Context, adminID string, req ScheduledReportRequest) (*ScheduledReportResponse, error)
+	GetReports(ctx context.Context, page, limit int) ([]model.ScheduledReport, int64, error)
+	GetReport(ctx context.Context, id string) (*model.ScheduledReport, error)
+	UpdateReport(ctx context.Context, id string, req ScheduledReportRequest) (*ScheduledReportResponse, error)
+	DeleteReport(ctx context.Context, id string) error
+	GetRuns(ctx context.Context, id string, page, limit int) ([]model.ScheduledReportRun, int64, error)
+	// RunNow runs the report immediately, outside its schedule
+	RunNow(ctx context.Context, id string) (*model.ScheduledReportRun, error)
+
+	// RunDue runs the reports whose scheduled time has come and returns how many succeeded
+	RunDue(ctx context.Context) (int, error)
+}
+
+type ScheduledReportRequest struct {
+	Name       string   `json:"name" binding:"required,max=100"`
+	Report     string   `json:"report" binding:"required"` // See GET /admin/scheduled-reports/definitions
+	Format     string   `json:"format" binding:"required,oneof=csv json"`
+	Schedule   string   `json:"schedule" binding:"required,max=100"` // Cron expression, e.g. "0 7 * * *"
+	Channel    string   `json:"channel" binding:"required,oneof=email webhook"`
+	Recipients []string `json:"recipients"`  // Email channel
+	WebhookURL *string  `json:"webhook_url"` // Webhook channel
+	IsActive   *bool    `json:"is_active"`   // Default: true
+}
+
+// ScheduledReportResponse contains the webhook signing secret when one was just created; it is
+// only returned once
+type ScheduledReportResponse struct {
+	Report        *model.ScheduledReport `json:"report"`
+	WebhookSecret string                 `json:"webhook_secret,omitempty"`
+}
+
+type scheduledReportService struct {
+	scheduledReportRepo repository.ScheduledReportRepository
+	reportRepo          repository.ReportRepository
+	calendar            BusinessCalendarService
+	rabbitMQ            *util.RabbitMQClient
+	client              *http.Client
+
+	mu          sync.RWMutex
+	definitions map[string]ReportDefinition
+}
+
+func NewScheduledReportService(
+	scheduledReportRepo repository.ScheduledReportRepository,
+	reportRepo repository.ReportRepository,
+	calendar BusinessCalendarService,
+	rabbitMQ *util.RabbitMQClient,
+	cfg *config.Config,
+	jobs *util.BackgroundJobs,
+) ScheduledReportService {
+	service := &scheduledReportService{
+		scheduledReportRepo: scheduledReportRepo,
+		reportRepo:          reportRepo,
+		calendar:            calendar,
+		rabbitMQ:            rabbitMQ,
+		client:              &http.Client{Timeout: time.Duration(cfg.ScheduledReportTimeoutSeconds) * time.Second},
+		definitions:         make(map[string]ReportDefinition),
+	}
+	service.registerBuiltInReports()
+
+	// Run due reports in the background
+	if cfg.ScheduledReportCheckIntervalSeconds > 0 {
+		interval := time.Duration(cfg.ScheduledReportCheckIntervalSeconds) * time.Second
+		jobs.Every(interval, service.runScheduler)
+		log.Printf("✅ Scheduled report runner started (checking every %s)", interval)
+	}
+
+	return service
+}
+
+// runScheduler runs due reports
+func (s *scheduledReportService) runScheduler(ctx context.Context) {
+	if _, err := s.RunDue(ctx); err != nil {
+		log.Printf("⚠️  Failed to run scheduled reports: %v", err)
+	}
+}
+
+func (s *scheduledReportService) registerBuiltInReports() {
+	location := s.calendar.Location()
+
+	s.RegisterReport(ReportDefinition{
+		Key:         ReportDailySales,
+		Title:       "Penjualan Harian",
+		Description: "Paid item sales per shop on the previous day (business time zone), returned items left out",
+		Columns:     []string{"date", "seller_id", "shop_name", "orders", "items_sold", "revenue"},
+		Period: func(now time.Time, _ *time.Time) ReportPeriod {
+			local := now.In(location)
+			today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+			return ReportPeriod{From: today.AddDate(0, 0, -1), To: today}
+		},
+		Query: func(ctx context.Context, period ReportPeriod) ([][]string, error) {
+			sales, err := s.reportRepo.DailySales(ctx, period.From, period.To)
+			if err != nil {
+				return nil, err
+			}
+			date := period.From.In(location).Format("2006-01-02")
+			rows := make([][]string, len(sales))
+			for i, sale := range sales {
+				rows[i] = []string{
+					date,
+					sale.SellerID,
+					sale.ShopName,
+					strconv.FormatInt(sale.Orders, 10),
+					strconv.FormatInt(sale.ItemsSold, 10),
+					strconv.FormatInt(sale.Revenue, 10),
+				}
+			}
+			return rows, nil
+		},
+	})
+
+	s.RegisterReport(ReportDefinition{
+		Key:         ReportPendingSettlements,
+		Title:       "Settlement Tertunda",
+		Description: "Seller settlements not paid out yet at the time of the run, oldest first",
+		Columns:     []string{"settlement_id", "seller_id", "shop_name", "sub_order_number", "amount", "on_hold", "created_at", "age_days"},
+		Query: func(ctx context.Context, period ReportPeriod) ([][]string, error) {
+			settlements, err := s.reportRepo.PendingSettlements(ctx)
+			if err != nil {
+				return nil, err
+			}
+			rows := make([][]string, len(settlements))
+			for i, settlement := range settlements {
+				rows[i] = []string{
+					settlement.SettlementID,
+					settlement.SellerID,
+					settlement.ShopName,
+					settlement.SubOrderNumber,
+					strconv.Itoa(settlement.Amount),
+					strconv.FormatBool(settlement.OnHold),
+					settlement.CreatedAt.In(location).Format("2006-01-02 15:04:05"),
+					strconv.Itoa(int(period.To.Sub(settlement.CreatedAt).Hours() / 24)),
+				}
+			}
+			return rows, nil
+		},
+	})
+
+	s.RegisterReport(ReportDefinition{
+		Key:         ReportFailedWebhooks,
+		Title:       "Webhook Gagal",
+		Description: "Seller webhook deliveries given up on since the last successful run",
+		Columns:     []string{"delivery_id", "seller_id", "shop_name", "url", "event", "attempts", "response_status", "last_error", "created_at", "failed_at"},
+		Query: func(ctx context.Context, period ReportPeriod) ([][]string, error) {
+			deliveries, err := s.reportRepo.FailedWebhookDeliveries(ctx, period.From, period.To)
+			if err != nil {
+				return nil, err
+			}
+			rows := make([][]string, len(deliveries))
+			for i, delivery := range deliveries {
+				responseStatus := ""
+				if delivery.ResponseStatus != nil {
+					responseStatus = strconv.Itoa(*delivery.ResponseStatus)
+				}
+				rows[i] = []string{
+					delivery.DeliveryID,
+					delivery.SellerID,
+					delivery.ShopName,
+					delivery.URL,
+					delivery.Event,
+					strconv.Itoa(delivery.Attempts),
+					responseStatus,
+					stringOrEmpty(delivery.LastError),
+					delivery.CreatedAt.In(location).Format("2006-01-02 15:04:05"),
+					delivery.FailedAt.In(location).Format("2006-01-02 15:04:05"),
+				}
+			}
+			return rows, nil
+		},
+	})
+}
+
+func (s *scheduledReportService) RegisterReport(definition ReportDefinition) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.definitions[definition.Key] = definition
+}
+
+func (s *scheduledReportService) GetDefinitions() []ReportDefinition {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	definitions := make([]ReportDefinition, 0, len(s.definitions))
+	for _, definition := range s.definitions {
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Key < definitions[j].Key })
+	return definitions
+}
+
+func (s *scheduledReportService) definition(key string) (ReportDefinition, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	definition, ok := s.definitions[key]
+	return definition, ok
+}
+
+func (s *scheduledReportService) CreateReport(ctx context.Context, adminID string, req ScheduledReportRequest) (*ScheduledReportResponse, error) {
+	report := &model.ScheduledReport{CreatedBy: adminID, IsActive: true}
+	secret, err := s.applyScheduledReportRequest(report, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.scheduledReportRepo.Create(ctx, report); err != nil {
+		return nil, internalError("failed to create scheduled report", err)
+	}
+	log.Printf("📊 Scheduled report %s (%s, %s) created", report.ID, report.Report, report.Schedule)
+	return &ScheduledReportResponse{Report: report, WebhookSecret: secret}, nil
+}
+
+func (s *scheduledReportService) GetReports(ctx context.Context, page, limit int) ([]model.ScheduledReport, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	reports, total, err := s.scheduledReportRepo.List(ctx, page, limit)
+	if err != nil {
+		return nil, 0, internalError("failed to get scheduled reports", err)
+	}
+	return reports, total, nil
+}
+
+func (s *scheduledReportService) GetReport(ctx context.Context, id string) (*model.ScheduledReport, error) {
+	report, err := s.scheduledReportRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("scheduled report not found")
+	}
+	return report, nil
+}
+
+func (s *scheduledReportService) UpdateReport(ctx context.Context, id string, req ScheduledReportRequest) (*ScheduledReportResponse, error) {
+	report, err := s.GetReport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := s.applyScheduledReportRequest(report, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.scheduledReportRepo.Update(ctx, report); err != nil {
+		return nil, internalError("failed to update scheduled report", err)
+	}
+	return &ScheduledReportResponse{Report: report, WebhookSecret: secret}, nil
+}
+
+func (s *scheduledReportService) DeleteReport(ctx context.Context, id string) error {
+	if _, err := s.GetReport(ctx, id); err != nil {
+		return err
+	}
+	if err := s.scheduledReportRepo.Delete(ctx, id); err != nil {
+		return internalError("failed to delete scheduled report", err)
+	}
+	return nil
+}
+
+func (s *scheduledReportService) GetRuns(ctx context.Context, id string, page, limit int) ([]model.ScheduledReportRun, int64, error) {
+	if _, err := s.GetReport(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	runs, total, err := s.scheduledReportRepo.FindRuns(ctx, id, page, limit)
+	if err != nil {
+		return nil, 0, internalError("failed to get report runs", err)
+	}
+	return runs, total, nil
+}
+
+// applyScheduledReportRequest validates the request and copies it onto the report. It returns the
+// webhook secret when one was generated, i.e. when the report starts delivering to a webhook.
+func (s *scheduledReportService) applyScheduledReportRequest(report *model.ScheduledReport, req ScheduledReportRequest) (string, error) {
+	if _, ok := s.definition(req.Report); !ok {
+		keys := make([]string, 0)
+		for _, definition := range s.GetDefinitions() {
+			keys = append(keys, definition.Key)
+		}
+		return "", fmt.Errorf("unknown report %q; available: %s", req.Report, strings.Join(keys, ", "))
+	}
+	schedule, err := util.ParseCron(req.Schedule)
+	if err != nil {
+		return "", err
+	}
+	nextRun := schedule.Next(time.Now().In(s.calendar.Location()))
+	if nextRun.IsZero() {
+		return "", errors.New("schedule never runs")
+	}
+
+	report.Recipients = ""
+	report.WebhookURL = nil
+	switch req.Channel {
+	case model.ReportChannelEmail:
+		if len(req.Recipients) == 0 {
+			return "", errors.New("recipients are required for email reports")
+		}
+		if len(req.Recipients) > scheduledReportMaxRecipients {
+			return "", fmt.Errorf("a report can have at most %d recipients", scheduledReportMaxRecipients)
+		}
+		recipients := make([]string, 0, len(req.Recipients))
+		for _, recipient := range req.Recipients {
+			address, err := mail.ParseAddress(strings.TrimSpace(recipient))
+			if err != nil {
+				return "", fmt.Errorf("invalid recipient %q", recipient)
+			}
+			recipients = append(recipients, address.Address)
+		}
+		report.Recipients = strings.Join(recipients, ",")
+	case model.ReportChannelWebhook:
+		if req.WebhookURL == nil {
+			return "", errors.New("webhook_url is required for webhook reports")
+		}
+		endpoint := strings.TrimSpace(*req.WebhookURL)
+		if err := validateWebhookURL(endpoint); err != nil {
+			return "", err
+		}
+		report.WebhookURL = &endpoint
+	}
+
+	secret := ""
+	if req.Channel == model.ReportChannelWebhook && report.WebhookSecret == "" {
+		if secret, err = newWebhookSecret(); err != nil {
+			return "", internalError("failed to generate webhook secret", err)
+		}
+		report.WebhookSecret = secret
+	}
+
+	report.Name = strings.TrimSpace(req.Name)
+	report.Report = req.Report
+	report.Format = req.Format
+	report.Schedule = strings.Join(strings.Fields(req.Schedule), " ")
+	report.Channel = req.Channel
+	report.NextRunAt = nextRun
+	if req.IsActive != nil {
+		report.IsActive = *req.IsActive
+	}
+	return secret, nil
+}
+
+func (s *scheduledReportService) RunNow(ctx context.Context, id string) (*model.ScheduledReportRun, error) {
+	report, err := s.GetReport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.run(ctx, report, true), nil
+}
+
+func (s *scheduledReportService) RunDue(ctx context.Context) (int, error) {
+	reports, err := s.scheduledReportRepo.ClaimDue(ctx, time.Now(), scheduledReportLease, scheduledReportBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	succeeded := 0
+	for i := range reports {
+		if run := s.run(ctx, &reports[i], false); run.Status == model.ReportRunSucceeded {
+			succeeded++
+		}
+	}
+	return succeeded, nil
+}
+
+// run generates and delivers the report, records the run and, for scheduled runs, moves the report
+// to its next scheduled time
+func (s *scheduledReportService) run(ctx context.Context, report *model.ScheduledReport, manual bool) *model.ScheduledReportRun {
+	now := time.Now()
+	run := &model.ScheduledReportRun{
+		ID:        uuid.New().String(),
+		ReportID:  report.ID,
+		Manual:    manual,
+		StartedAt: now,
+	}
+
+	rows, err := s.generateAndDeliver(ctx, report, run)
+	run.CompletedAt = time.Now()
+	run.Rows = rows
+	run.Status = model.ReportRunSucceeded
+	if err != nil {
+		message := err.Error()
+		run.Status = model.ReportRunFailed
+		run.Error = &message
+		log.Printf("⚠️  Scheduled report %s (%s) failed: %s", report.ID, report.Report, message)
+	}
+	if err := s.scheduledReportRepo.CreateRun(ctx, run); err != nil {
+		log.Printf("⚠️  Failed to record run of scheduled report %s: %v", report.ID, err)
+	}
+
+	report.LastRunAt = &now
+	report.LastStatus = &run.Status
+	if run.Status == model.ReportRunSucceeded {
+		periodTo := run.PeriodTo
+		report.LastSuccessAt = &periodTo
+	}
+	if !manual {
+		if schedule, err := util.ParseCron(report.Schedule); err == nil {
+			report.NextRunAt = schedule.Next(now.In(s.calendar.Location()))
+		}
+		if report.NextRunAt.IsZero() {
+			report.IsActive = false
+		}
+	}
+	if err := s.scheduledReportRepo.Update(ctx, report); err != nil {
+		log.Printf("⚠️  Failed to update scheduled report %s after a run: %v", report.ID, err)
+	}
+	return run
+}
+
+// generateAndDeliver runs the report's query for its period and sends the output; it returns the
+// number of rows
+func (s *scheduledReportService) generateAndDeliver(ctx context.Context, report *model.ScheduledReport, run *model.ScheduledReportRun) (int, error) {
+	definition, ok := s.definition(report.Report)
+	if !ok {
+		return 0, fmt.Errorf("report %q is no longer available", report.Report)
+	}
+
+	period := ReportPeriod{From: run.StartedAt.Add(-24 * time.Hour), To: run.StartedAt}
+	if report.LastSuccessAt != nil {
+		period.From = *report.LastSuccessAt
+	}
+	if definition.Period != nil {
+		period = definition.Period(run.StartedAt, report.LastSuccessAt)
+	}
+	run.PeriodFrom, run.PeriodTo = period.From, period.To
+
+	rows, err := definition.Query(ctx, period)
+	if err != nil {
+		return 0, errors.New("query failed: " + err.Error())
+	}
+	output, contentType, err := renderReport(definition.Columns, rows, report.Format)
+	if err != nil {
+		return len(rows), internalError("failed to render report", err)
+	}
+
+	switch report.Channel {
+	case model.ReportChannelEmail:
+		err = s.sendEmail(report, definition, run, len(rows), output, contentType)
+	case model.ReportChannelWebhook:
+		err = s.sendWebhook(ctx, report, run, output, contentType)
+	default:
+		err = fmt.Errorf("unknown channel %q", report.Channel)
+	}
+	return len(rows), err
+}
+
+// renderReport writes the rows as CSV with a header line (cells made safe for spreadsheet apps), or
+// as a JSON array of objects keyed by column
+func renderReport(columns []string, rows [][]string, format string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	switch format {
+	case model.ReportFormatJSON:
+		records := make([]map[string]string, len(rows))
+		for i, row := range rows {
+			record := make(map[string]string, len(columns))
+			for j, column := range columns {
+				if j < len(row) {
+					record[column] = row[j]
+				}
+			}
+			records[i] = record
+		}
+		if err := json.NewEncoder(&buf).Encode(records); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "application/json", nil
+	default:
+		writer := csv.NewWriter(&buf)
+		if err := writer.Write(columns); err != nil {
+			return nil, "", err
+		}
+		for _, row := range rows {
+			record := make([]string, len(row))
+			for i, value := range row {
+				record[i] = csvSafe(value)
+			}
+			if err := writer.Write(record); err != nil {
+				return nil, "", err
+			}
+		}
+		writer.Flush()
+		return buf.Bytes(), "text/csv", writer.Error()
+	}
+}
+
+func (s *scheduledReportService) sendEmail(report *model.ScheduledReport, definition ReportDefinition, run *model.ScheduledReportRun, rows int, output []byte, contentType string) error {
+	if s.rabbitMQ == nil {
+		return errors.New("email is not available: RabbitMQ is not connected")
+	}
+
+	location := s.calendar.Location()
+	period := run.PeriodFrom.In(location).Format("2006-01-02 15:04") + " - " + run.PeriodTo.In(location).Format("2006-01-02 15:04")
+	filename := fmt.Sprintf("%s-%s.%s", report.Report, run.PeriodTo.In(location).Format("20060102-1504"), report.Format)
+	failed := 0
+	var lastErr error
+	for _, recipient := range strings.Split(report.Recipients, ",") {
+		emailMsg := util.EmailMessage{
+			To:      recipient,
+			Subject: fmt.Sprintf("Laporan %s - %s", report.Name, period),
+			Type:    "scheduled_report",
+			Data: map[string]string{
+				"name":         report.Name,
+				"title":        definition.Title,
+				"period":       period,
+				"rows":         strconv.Itoa(rows),
+				"filename":     filename,
+				"content_type": contentType,
+				"content":      string(output),
+			},
+		}
+		if err := s.rabbitMQ.PublishEmail(emailMsg); err != nil {
+			failed++
+			lastErr = err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to queue email for %d recipient(s): %v", failed, lastErr)
+	}
+	return nil
+}
+
+func (s *scheduledReportService) sendWebhook(ctx context.Context, report *model.ScheduledReport, run *model.ScheduledReportRun, output []byte, contentType string) error {
+	if report.WebhookURL == nil {
+		return errors.New("webhook_url is not set")
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *report.WebhookURL, bytes.NewReader(output))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "Marketplace-Reports/1.0")
+	req.Header.Set("X-Report", report.Report)
+	req.Header.Set("X-Report-Run", run.ID)
+	req.Header.Set("X-Report-Period-From", run.PeriodFrom.UTC().Format(time.RFC3339))
+	req.Header.Set("X-Report-Period-To", run.PeriodTo.UTC().Format(time.RFC3339))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+signWebhookPayload(report.WebhookSecret, timestamp, string(output)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseExcerpt))
+		return fmt.Errorf("endpoint responded %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
@@ -0,0 +1,458 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// SellerOrderService lets sellers see and fulfill their own sub-orders
+type SellerOrderService interface {
+	GetOrders(ctx context.Context, userID string, query SellerOrderQuery, page, limit int) ([]model.SellerOrder, int64, error)
+	ExportOrders(ctx context.Context, userID string, query SellerOrderQuery, w io.Writer) error
+	GetOrder(ctx context.Context, userID string, sellerOrderID string) (*model.SellerOrder, error)
+	UpdateOrderStatus(ctx context.Context, userID string, sellerOrderID string, status string) (*model.SellerOrder, error)
+	ShipOrder(ctx context.Context, userID string, sellerOrderID string, req ShipSellerOrderRequest) (*model.SellerOrder, error)
+	UpdateItemStatus(ctx context.Context, userID string, sellerOrderID string, itemID string, req UpdateOrderItemStatusRequest) (*model.SellerOrder, error)
+	GetSettlements(ctx context.Context, userID string, status string, page, limit int) ([]model.SellerSettlement, int64, error)
+	GetPackingSlip(ctx context.Context, userID string, sellerOrderID string) (*PackingSlip, error)
+	GetSales(ctx context.Context, userID string, from, to string) (*repository.SellerSales, error)
+	GetTaxReport(ctx context.Context, userID string, month string) (*SellerTaxReport, error)
+	ExportTaxReport(ctx context.Context, userID string, month string, w io.Writer) error
+}
+
+type sellerOrderService struct {
+	sellerOrderRepo repository.SellerOrderRepository
+	sellerRepo      repository.SellerRepository
+	orderRepo       repository.OrderRepository
+	orderItemRepo   repository.OrderItemRepository
+	calendar        BusinessCalendarService
+	hooks           *HookRegistry
+}
+
+// sellerOrderExportBatchSize is how many sub-orders are loaded per query while exporting
+const sellerOrderExportBatchSize = 200
+
+// sellerOrderExportHeader is the CSV header; there is one row per line item
+var sellerOrderExportHeader = []string{
+	"sub_order_number", "ordered_at", "status", "product_name", "quantity", "price", "item_subtotal",
+	"order_subtotal", "shipping_cost", "order_total", "courier", "tracking_number", "recipient_name", "city",
+}
+
+// SellerOrderQuery is the seller's order list filter as sent by the client; dates are YYYY-MM-DD
+// in the business timezone and both ends are inclusive
+type SellerOrderQuery struct {
+	Status string
+	From   string
+	To     string
+	Search string
+}
+
+// ShipSellerOrderRequest hands a sub-order to the courier
+type ShipSellerOrderRequest struct {
+	Courier        string `json:"courier" binding:"required,max=50"`
+	TrackingNumber string `json:"tracking_number" binding:"required,max=100"` // AWB
+}
+
+// UpdateOrderItemStatusRequest moves one item through fulfillment; courier and tracking number are
+// required when shipping
+type UpdateOrderItemStatusRequest struct {
+	Status         string `json:"status" binding:"required,oneof=packed shipped delivered returned"`
+	Courier        string `json:"courier" binding:"max=50"`
+	TrackingNumber string `json:"tracking_number" binding:"max=100"` // AWB
+}
+
+// PackingSlip is what the seller puts in the parcel. For gift orders the recipient's contact is
+// used and all prices are left out.
+type PackingSlip struct {
+	SubOrderNumber string               `json:"sub_order_number"`
+	ShopName       string               `json:"shop_name"`
+	IsGift         bool                 `json:"is_gift"`
+	GiftWrap       bool                 `json:"gift_wrap"`
+	GiftMessage    *string              `json:"gift_message,omitempty"`
+	Recipient      PackingSlipRecipient `json:"recipient"`
+	Items          []PackingSlipItem    `json:"items"`
+	Subtotal       *int                 `json:"subtotal,omitempty"` // Omitted for gifts
+	OrderedAt      time.Time            `json:"ordered_at"`
+}
+
+type PackingSlipRecipient struct {
+	Name         string  `json:"name"`
+	Phone        string  `json:"phone"`
+	AddressLine1 string  `json:"address_line1"`
+	AddressLine2 *string `json:"address_line2,omitempty"`
+	City         string  `json:"city"`
+	Province     string  `json:"province"`
+	PostalCode   string  `json:"postal_code"`
+}
+
+type PackingSlipItem struct {
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+	Price       *int   `json:"price,omitempty"`    // Omitted for gifts
+	Subtotal    *int   `json:"subtotal,omitempty"` // Omitted for gifts
+}
+
+func NewSellerOrderService(sellerOrderRepo repository.SellerOrderRepository, sellerRepo repository.SellerRepository, orderRepo repository.OrderRepository, orderItemRepo repository.OrderItemRepository, calendar BusinessCalendarService, hooks *HookRegistry) SellerOrderService {
+	return &sellerOrderService{
+		sellerOrderRepo: sellerOrderRepo,
+		sellerRepo:      sellerRepo,
+		orderRepo:       orderRepo,
+		orderItemRepo:   orderItemRepo,
+		calendar:        calendar,
+		hooks:           hooks,
+	}
+}
+
+func (s *sellerOrderService) GetOrders(ctx context.Context, userID string, query SellerOrderQuery, page, limit int) ([]model.SellerOrder, int64, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, 0, errors.New("seller not found")
+	}
+
+	from, to, err := parseDateRange(query.From, query.To, s.calendar.Location())
+	if err != nil {
+		return nil, 0, err
+	}
+	filter := repository.SellerOrderFilter{
+		Status: query.Status,
+		From:   from,
+		To:     to,
+		Search: strings.TrimSpace(query.Search),
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	orders, total, err := s.sellerOrderRepo.FindBySellerID(ctx, seller.ID, filter, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get orders: " + err.Error())
+	}
+	return orders, total, nil
+}
+
+// ExportOrders writes the seller's filtered sub-orders to w as CSV, one row per line item. Rows are
+// flushed batch by batch, so a failure after the first batch leaves a truncated file; errors before
+// anything is written (unknown seller, bad dates) can still be reported normally.
+func (s *sellerOrderService) ExportOrders(ctx context.Context, userID string, query SellerOrderQuery, w io.Writer) error {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return errors.New("seller not found")
+	}
+	from, to, err := parseDateRange(query.From, query.To, s.calendar.Location())
+	if err != nil {
+		return err
+	}
+	filter := repository.SellerOrderFilter{
+		Status: query.Status,
+		From:   from,
+		To:     to,
+		Search: strings.TrimSpace(query.Search),
+	}
+
+	location := s.calendar.Location()
+	writer := csv.NewWriter(w)
+	if err := writer.Write(sellerOrderExportHeader); err != nil {
+		return err
+	}
+
+	rows := 0
+	err = s.sellerOrderRepo.EachBatchBySellerID(ctx, seller.ID, filter, sellerOrderExportBatchSize, func(batch []model.SellerOrder) error {
+		for _, sellerOrder := range batch {
+			for _, item := range sellerOrder.OrderItems {
+				record := []string{
+					sellerOrder.SubOrderNumber,
+					sellerOrder.CreatedAt.In(location).Format("2006-01-02 15:04:05"),
+					sellerOrder.Status,
+					csvSafe(item.ProductName),
+					strconv.Itoa(item.Quantity),
+					strconv.Itoa(item.Price),
+					strconv.Itoa(item.Subtotal),
+					strconv.Itoa(sellerOrder.Subtotal),
+					strconv.Itoa(sellerOrder.ShippingCost),
+					strconv.Itoa(sellerOrder.TotalAmount),
+					stringOrEmpty(sellerOrder.Courier),
+					csvSafe(stringOrEmpty(sellerOrder.TrackingNumber)),
+					csvSafe(sellerOrder.ShippingAddress.RecipientName),
+					csvSafe(sellerOrder.ShippingAddress.City),
+				}
+				if err := writer.Write(record); err != nil {
+					return err
+				}
+				rows++
+			}
+		}
+		writer.Flush()
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		return writer.Error()
+	})
+	if err != nil {
+		log.Printf("⚠️  Order export for seller %s stopped after %d rows: %v", seller.ID, rows, err)
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvSafe keeps spreadsheet apps from running buyer-entered text as a formula
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func stringOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func (s *sellerOrderService) GetOrder(ctx context.Context, userID string, sellerOrderID string) (*model.SellerOrder, error) {
+	_, sellerOrder, err := s.findOwned(ctx, userID, sellerOrderID)
+	return sellerOrder, err
+}
+
+// UpdateOrderStatus moves the seller's paid sub-order to processing. Shipping goes through
+// ShipOrder, which records the tracking number.
+func (s *sellerOrderService) UpdateOrderStatus(ctx context.Context, userID string, sellerOrderID string, status string) (*model.SellerOrder, error) {
+	if status != "processing" {
+		return nil, errors.New("status must be processing; use the ship endpoint to ship an order")
+	}
+	sellerOrder, err := s.GetOrder(ctx, userID, sellerOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	parentStatus := s.parentStatus(ctx, sellerOrder)
+	change := model.StatusChange{ActorType: model.StatusActorSeller, ActorID: userID}
+	if err := s.sellerOrderRepo.UpdateStatus(ctx, sellerOrder.ID, status, change); err != nil {
+		if errors.Is(err, repository.ErrInvalidSellerOrderTransition) {
+			return nil, fmt.Errorf("%w: %s to %s", err, sellerOrder.Status, status)
+		}
+		return nil, errors.New("failed to update order status: " + err.Error())
+	}
+
+	log.Printf("📦 Sub-order %s moved from %s to %s by seller %s", sellerOrder.SubOrderNumber, sellerOrder.Status, status, sellerOrder.SellerID)
+	s.notifyParentStatusChange(ctx, sellerOrder, parentStatus)
+	return s.reloadAndNotify(ctx, sellerOrder)
+}
+
+// ShipOrder marks the seller's paid or processing sub-order as shipped with its tracking number
+func (s *sellerOrderService) ShipOrder(ctx context.Context, userID string, sellerOrderID string, req ShipSellerOrderRequest) (*model.SellerOrder, error) {
+	courier := strings.ToLower(strings.TrimSpace(req.Courier))
+	trackingNumber := strings.ToUpper(strings.TrimSpace(req.TrackingNumber))
+	if courier == "" || trackingNumber == "" {
+		return nil, errors.New("courier and tracking number are required")
+	}
+	sellerOrder, err := s.GetOrder(ctx, userID, sellerOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	parentStatus := s.parentStatus(ctx, sellerOrder)
+	change := model.StatusChange{ActorType: model.StatusActorSeller, ActorID: userID, Note: courier + " " + trackingNumber}
+	if err := s.sellerOrderRepo.Ship(ctx, sellerOrder.ID, courier, trackingNumber, change); err != nil {
+		if errors.Is(err, repository.ErrInvalidSellerOrderTransition) {
+			return nil, fmt.Errorf("%w: %s to shipped", err, sellerOrder.Status)
+		}
+		return nil, errors.New("failed to ship order: " + err.Error())
+	}
+
+	log.Printf("📦 Sub-order %s shipped by seller %s via %s (%s)", sellerOrder.SubOrderNumber, sellerOrder.SellerID, courier, trackingNumber)
+	s.notifyParentStatusChange(ctx, sellerOrder, parentStatus)
+	return s.reloadAndNotify(ctx, sellerOrder)
+}
+
+// UpdateItemStatus updates the fulfillment status of one item in the seller's sub-order, so a
+// back-ordered item does not hold up the rest of the parcel
+func (s *sellerOrderService) UpdateItemStatus(ctx context.Context, userID string, sellerOrderID string, itemID string, req UpdateOrderItemStatusRequest) (*model.SellerOrder, error) {
+	update := repository.OrderItemStatusUpdate{
+		Status:         req.Status,
+		Courier:        strings.ToLower(strings.TrimSpace(req.Courier)),
+		TrackingNumber: strings.ToUpper(strings.TrimSpace(req.TrackingNumber)),
+	}
+	if update.Status == model.OrderItemStatusShipped && (update.Courier == "" || update.TrackingNumber == "") {
+		return nil, errors.New("courier and tracking number are required")
+	}
+	sellerOrder, err := s.GetOrder(ctx, userID, sellerOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	parentStatus := s.parentStatus(ctx, sellerOrder)
+	change := model.StatusChange{ActorType: model.StatusActorSeller, ActorID: userID, Note: "Item " + itemID + " " + update.Status}
+	if err := s.sellerOrderRepo.UpdateItemStatus(ctx, sellerOrder.ID, itemID, update, change); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("order item not found")
+		}
+		if errors.Is(err, repository.ErrInvalidOrderItemTransition) {
+			return nil, err
+		}
+		return nil, errors.New("failed to update item status: " + err.Error())
+	}
+
+	log.Printf("📦 Item %s of sub-order %s marked %s by seller %s", itemID, sellerOrder.SubOrderNumber, update.Status, sellerOrder.SellerID)
+	s.notifyParentStatusChange(ctx, sellerOrder, parentStatus)
+	return s.sellerOrderRepo.FindByID(ctx, sellerOrder.ID)
+}
+
+// GetSettlements lists what the marketplace owes the seller for delivered sub-orders
+func (s *sellerOrderService) GetSettlements(ctx context.Context, userID string, status string, page, limit int) ([]model.SellerSettlement, int64, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, 0, errors.New("seller not found")
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	settlements, total, err := s.sellerOrderRepo.FindSettlementsBySellerID(ctx, seller.ID, status, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get settlements: " + err.Error())
+	}
+	return settlements, total, nil
+}
+
+// GetPackingSlip builds the packing slip for the seller's sub-order
+func (s *sellerOrderService) GetPackingSlip(ctx context.Context, userID string, sellerOrderID string) (*PackingSlip, error) {
+	seller, sellerOrder, err := s.findOwned(ctx, userID, sellerOrderID)
+	if err != nil {
+		return nil, err
+	}
+	order, err := s.orderRepo.FindByID(ctx, sellerOrder.OrderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+
+	slip := &PackingSlip{
+		SubOrderNumber: sellerOrder.SubOrderNumber,
+		ShopName:       seller.ShopName,
+		IsGift:         order.IsGift,
+		GiftWrap:       order.GiftWrap,
+		Recipient:      packingRecipient(sellerOrder, order),
+		Items:          make([]PackingSlipItem, 0, len(sellerOrder.OrderItems)),
+		OrderedAt:      sellerOrder.CreatedAt,
+	}
+	slip.GiftMessage = order.GiftMessage
+	if !order.IsGift {
+		slip.Subtotal = &sellerOrder.Subtotal
+	}
+	for _, item := range sellerOrder.OrderItems {
+		slipItem := PackingSlipItem{ProductName: item.ProductName, Quantity: item.Quantity}
+		if !order.IsGift {
+			price, subtotal := item.Price, item.Subtotal
+			slipItem.Price = &price
+			slipItem.Subtotal = &subtotal
+		}
+		slip.Items = append(slip.Items, slipItem)
+	}
+	return slip, nil
+}
+
+// packingRecipient is who the sub-order ships to: the shipping address, with the gift recipient's
+// name and phone for gift orders
+func packingRecipient(sellerOrder *model.SellerOrder, order *model.Order) PackingSlipRecipient {
+	address := sellerOrder.ShippingAddress
+	recipient := PackingSlipRecipient{
+		Name:         address.RecipientName,
+		Phone:        address.Phone,
+		AddressLine1: address.AddressLine1,
+		AddressLine2: address.AddressLine2,
+		City:         address.City,
+		Province:     address.Province,
+		PostalCode:   address.PostalCode,
+	}
+	if order.IsGift {
+		if order.RecipientName != nil {
+			recipient.Name = *order.RecipientName
+		}
+		if order.RecipientPhone != nil {
+			recipient.Phone = *order.RecipientPhone
+		}
+	}
+	return recipient
+}
+
+// GetSales sums the seller's paid item sales between two dates (YYYY-MM-DD, inclusive, optional)
+func (s *sellerOrderService) GetSales(ctx context.Context, userID string, from, to string) (*repository.SellerSales, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	fromTime, toTime, err := parseDateRange(from, to, s.calendar.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	sales, err := s.orderItemRepo.GetSalesBySellerID(ctx, seller.ID, fromTime, toTime)
+	if err != nil {
+		return nil, errors.New("failed to get sales: " + err.Error())
+	}
+	return sales, nil
+}
+
+// parentStatus returns the status of the buyer's order a sub-order belongs to, "" if unknown
+func (s *sellerOrderService) parentStatus(ctx context.Context, sellerOrder *model.SellerOrder) string {
+	order, err := s.orderRepo.FindByID(ctx, sellerOrder.OrderID)
+	if err != nil {
+		return ""
+	}
+	return order.Status
+}
+
+// notifyParentStatusChange runs the order status hooks when a sub-order change rolled the
+// buyer's order up to a new status (e.g. the last sub-order shipped)
+func (s *sellerOrderService) notifyParentStatusChange(ctx context.Context, sellerOrder *model.SellerOrder, from string) {
+	if from == "" {
+		return
+	}
+	order, err := s.orderRepo.FindByID(ctx, sellerOrder.OrderID)
+	if err != nil || order.Status == from {
+		return
+	}
+	s.hooks.RunAfterOrderStatusChange(ctx, order, from)
+}
+
+// reloadAndNotify returns the sub-order as saved after a status change and runs the sub-order
+// status hooks
+func (s *sellerOrderService) reloadAndNotify(ctx context.Context, before *model.SellerOrder) (*model.SellerOrder, error) {
+	updated, err := s.sellerOrderRepo.FindByID(ctx, before.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.hooks.RunAfterSellerOrderStatusChange(ctx, updated, before.Status)
+	return updated, nil
+}
+
+// findOwned loads a sub-order, making sure it belongs to the user's shop
+func (s *sellerOrderService) findOwned(ctx context.Context, userID string, sellerOrderID string) (*model.Seller, *model.SellerOrder, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, nil, errors.New("seller not found")
+	}
+	sellerOrder, err := s.sellerOrderRepo.FindByID(ctx, sellerOrderID)
+	if err != nil || sellerOrder.SellerID != seller.ID {
+		return nil, nil, errors.New("order not found")
+	}
+	return seller, sellerOrder, nil
+}
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
@@ -16,11 +18,20 @@ type SellerService interface {
 	GetSellerByUserID(userID string) (*model.Seller, error)
 	UpdateSeller(userID string, req UpdateSellerRequest) (*model.Seller, error)
 	DeleteSeller(userID string) error
+	GetDashboard(userID string) (*SellerDashboardResponse, error)
+	GetScorecard(ctx context.Context, userID string) (*SellerScorecard, error)
+	GetDeletedSellers(page, limit int) ([]model.Seller, int64, error)
+	// RestoreSeller undeletes a shop. A shop name or slug taken in the meantime gets a numbered
+	// suffix; a shop whose owner has opened a new one cannot be restored.
+	RestoreSeller(sellerID string) (*model.Seller, error)
 }
 
 type sellerService struct {
-	sellerRepo repository.SellerRepository
-	userRepo   repository.UserRepository
+	sellerRepo  repository.SellerRepository
+	userRepo    repository.UserRepository
+	productRepo repository.ProductRepository
+	orderRepo   repository.OrderRepository
+	cartService CartService
 }
 
 type CreateSellerRequest struct {
@@ -45,12 +56,36 @@ type UpdateSellerRequest struct {
 	ShopProvince   *string `json:"shop_province,omitempty"`
 	ShopPhone      *string `json:"shop_phone,omitempty"`
 	ShopEmail      *string `json:"shop_email,omitempty"`
+	HandlingDays   *int    `json:"handling_days,omitempty" binding:"omitempty,min=0,max=14"`
+	MinOrderAmount *int    `json:"min_order_amount,omitempty" binding:"omitempty,min=0"` // 0 removes the minimum
+	IsActive       *bool   `json:"is_active,omitempty"` // false closes the shop; its products are flagged in buyers' carts
+
+	IsPKP *bool   `json:"is_pkp,omitempty"` // Registered for PPN; requires an NPWP
+	NPWP  *string `json:"npwp,omitempty"`   // Dots and dashes are ignored; empty string removes it
+}
+
+// SellerScorecard tracks how well the shop keeps its delivery promises
+type SellerScorecard struct {
+	HandlingDays     int     `json:"handling_days"`
+	DeliveredOrders  int64   `json:"delivered_orders"`
+	OnTimeDeliveries int64   `json:"on_time_deliveries"`
+	LateDeliveries   int64   `json:"late_deliveries"`
+	OnTimeRate       float64 `json:"on_time_rate"` // Percentage of delivered orders that arrived by the promised date
+}
+
+// SellerDashboardResponse is the seller's shop overview
+type SellerDashboardResponse struct {
+	Shop                      *model.Seller               `json:"shop"`
+	UpcomingVisibilityChanges []ScheduledVisibilityChange `json:"upcoming_visibility_changes"`
 }
 
-func NewSellerService(sellerRepo repository.SellerRepository, userRepo repository.UserRepository) SellerService {
+func NewSellerService(sellerRepo repository.SellerRepository, userRepo repository.UserRepository, productRepo repository.ProductRepository, orderRepo repository.OrderRepository, cartService CartService) SellerService {
 	return &sellerService{
-		sellerRepo: sellerRepo,
-		userRepo:   userRepo,
+		sellerRepo:  sellerRepo,
+		userRepo:    userRepo,
+		productRepo: productRepo,
+		orderRepo:   orderRepo,
+		cartService: cartService,
 	}
 }
 
@@ -168,6 +203,29 @@ func (s *sellerService) UpdateSeller(userID string, req UpdateSellerRequest) (*m
 	if req.ShopEmail != nil {
 		seller.ShopEmail = req.ShopEmail
 	}
+	if req.HandlingDays != nil {
+		seller.HandlingDays = *req.HandlingDays
+	}
+	if req.MinOrderAmount != nil {
+		seller.MinOrderAmount = *req.MinOrderAmount
+	}
+	if req.NPWP != nil {
+		npwp, err := normalizeNPWP(*req.NPWP)
+		if err != nil {
+			return nil, err
+		}
+		seller.NPWP = npwp
+	}
+	if req.IsPKP != nil {
+		seller.IsPKP = *req.IsPKP
+	}
+	if seller.IsPKP && seller.NPWP == nil {
+		return nil, errors.New("NPWP is required for PKP shops")
+	}
+	wasActive := seller.IsActive
+	if req.IsActive != nil {
+		seller.IsActive = *req.IsActive
+	}
 
 	if err := s.sellerRepo.Update(seller); err != nil {
 		// Check if error is due to duplicate shop_name
@@ -177,6 +235,13 @@ func (s *sellerService) UpdateSeller(userID string, req UpdateSellerRequest) (*m
 		return nil, fmt.Errorf("failed to update seller: %w", err)
 	}
 
+	// Buyers' carts follow the shop being closed or reopened
+	if wasActive && !seller.IsActive {
+		s.cartService.FlagShopItems(seller.ID, seller.ShopName)
+	} else if !wasActive && seller.IsActive {
+		s.cartService.UnflagShopItems(seller.ID)
+	}
+
 	return s.sellerRepo.FindByID(seller.ID)
 }
 
@@ -188,7 +253,91 @@ func (s *sellerService) DeleteSeller(userID string) error {
 	}
 
 	// Soft delete
-	return s.sellerRepo.Delete(seller.ID)
+	if err := s.sellerRepo.Delete(seller.ID); err != nil {
+		return err
+	}
+	s.cartService.FlagShopItems(seller.ID, seller.ShopName)
+	return nil
+}
+
+// GetDashboard returns the seller's shop with its upcoming scheduled visibility changes
+func (s *sellerService) GetDashboard(userID string) (*SellerDashboardResponse, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+
+	scheduled, err := s.productRepo.FindScheduledBySellerID(seller.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled products: %w", err)
+	}
+
+	return &SellerDashboardResponse{
+		Shop:                      seller,
+		UpcomingVisibilityChanges: upcomingVisibilityChanges(scheduled),
+	}, nil
+}
+
+// GetScorecard reports actual vs promised delivery for the seller's orders
+func (s *sellerService) GetScorecard(ctx context.Context, userID string) (*SellerScorecard, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+
+	stats, err := s.orderRepo.GetDeliveryStatsBySellerID(ctx, seller.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery stats: %w", err)
+	}
+
+	scorecard := &SellerScorecard{
+		HandlingDays:     seller.HandlingDays,
+		DeliveredOrders:  stats.Delivered,
+		OnTimeDeliveries: stats.OnTime,
+		LateDeliveries:   stats.Late,
+	}
+	if stats.Delivered > 0 {
+		scorecard.OnTimeRate = float64(stats.OnTime) * 100 / float64(stats.Delivered)
+	}
+	return scorecard, nil
+}
+
+func (s *sellerService) GetDeletedSellers(page, limit int) ([]model.Seller, int64, error) {
+	sellers, total, err := s.sellerRepo.FindDeleted(page, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get deleted sellers: %w", err)
+	}
+	return sellers, total, nil
+}
+
+func (s *sellerService) RestoreSeller(sellerID string) (*model.Seller, error) {
+	seller, err := s.sellerRepo.FindDeletedByID(sellerID)
+	if err != nil {
+		return nil, err
+	}
+	if existing, _ := s.sellerRepo.FindByUserID(seller.UserID); existing != nil {
+		return nil, errors.New("the owner has opened another shop since. One user can only have one shop")
+	}
+
+	shopName := seller.ShopName
+	for i := 2; ; i++ {
+		if existing, _ := s.sellerRepo.FindByShopName(shopName); existing == nil {
+			break
+		}
+		shopName = fmt.Sprintf("%s %d", seller.ShopName, i)
+	}
+	shopSlug := availableSlug(seller.ShopSlug, func(candidate string) bool {
+		existing, _ := s.sellerRepo.FindBySlug(candidate)
+		return existing != nil
+	})
+
+	if err := s.sellerRepo.Restore(seller.ID, shopName, shopSlug); err != nil {
+		return nil, fmt.Errorf("failed to restore seller: %w", err)
+	}
+	s.cartService.UnflagShopItems(seller.ID)
+
+	log.Printf("♻️  Shop %s restored as %q (%s)", seller.ID, shopName, shopSlug)
+	return s.sellerRepo.FindByID(seller.ID)
 }
 
 // generateSellerSlug generates a URL-friendly slug from a string
@@ -210,3 +359,25 @@ func generateSellerSlug(text string) string {
 func generateUniqueSuffix() string {
 	return fmt.Sprintf("%d", time.Now().Unix()%10000)
 }
+
+// normalizeNPWP strips the usual 99.999.999.9-999.999 punctuation and checks the digit count
+// (15 digits, or 16 for the NIK-based format). An empty value clears the NPWP.
+func normalizeNPWP(value string) (*string, error) {
+	digits := strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9':
+			return r
+		case r == '.' || r == '-' || r == ' ':
+			return -1
+		default:
+			return 'x'
+		}
+	}, value)
+	if digits == "" {
+		return nil, nil
+	}
+	if strings.ContainsRune(digits, 'x') || (len(digits) != 15 && len(digits) != 16) {
+		return nil, errors.New("NPWP must have 15 or 16 digits")
+	}
+	return &digits, nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+	"yourapp/internal/repository"
+)
+
+// sellerTaxReportHeader is the CSV header of the monthly tax report; there is one row per sub-order
+var sellerTaxReportHeader = []string{
+	"sub_order_number", "ordered_at", "status", "seller_npwp", "tax_rate_percent", "prices_include_tax",
+	"taxable_amount", "tax_amount",
+}
+
+// SellerTaxReport is the PPN a shop charged in one month, for its SPT Masa PPN filing
+type SellerTaxReport struct {
+	Month         string                     `json:"month"` // YYYY-MM
+	ShopName      string                     `json:"shop_name"`
+	IsPKP         bool                       `json:"is_pkp"`
+	NPWP          *string                    `json:"npwp,omitempty"`
+	OrderCount    int                        `json:"order_count"`
+	TaxableAmount int                        `json:"taxable_amount"` // Total DPP
+	TaxAmount     int                        `json:"tax_amount"`
+	Lines         []repository.SellerTaxLine `json:"lines"`
+}
+
+// GetTaxReport sums the PPN on the shop's paid sub-orders placed in month (YYYY-MM in the
+// business timezone; defaults to the current month)
+func (s *sellerOrderService) GetTaxReport(ctx context.Context, userID string, month string) (*SellerTaxReport, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	from, to, err := parseMonth(month, s.calendar.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := s.sellerOrderRepo.FindTaxLines(ctx, seller.ID, from, to)
+	if err != nil {
+		return nil, errors.New("failed to get tax report: " + err.Error())
+	}
+
+	report := &SellerTaxReport{
+		Month:      from.Format("2006-01"),
+		ShopName:   seller.ShopName,
+		IsPKP:      seller.IsPKP,
+		NPWP:       seller.NPWP,
+		OrderCount: len(lines),
+		Lines:      lines,
+	}
+	for _, line := range lines {
+		report.TaxableAmount += line.TaxableAmount
+		report.TaxAmount += line.TaxAmount
+	}
+	return report, nil
+}
+
+// ExportTaxReport writes the month's tax report to w as CSV, one row per sub-order
+func (s *sellerOrderService) ExportTaxReport(ctx context.Context, userID string, month string, w io.Writer) error {
+	report, err := s.GetTaxReport(ctx, userID, month)
+	if err != nil {
+		return err
+	}
+
+	location := s.calendar.Location()
+	writer := csv.NewWriter(w)
+	if err := writer.Write(sellerTaxReportHeader); err != nil {
+		return err
+	}
+	for _, line := range report.Lines {
+		record := []string{
+			line.SubOrderNumber,
+			line.OrderedAt.In(location).Format("2006-01-02 15:04:05"),
+			line.Status,
+			stringOrEmpty(line.SellerNPWP),
+			strconv.FormatFloat(float64(line.RateBasisPoints)/100, 'f', -1, 64),
+			strconv.FormatBool(line.Inclusive),
+			strconv.Itoa(line.TaxableAmount),
+			strconv.Itoa(line.TaxAmount),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// parseMonth returns the start of month (YYYY-MM) and of the month after it in location
+func parseMonth(month string, location *time.Location) (time.Time, time.Time, error) {
+	if month == "" {
+		now := time.Now().In(location)
+		month = now.Format("2006-01")
+	}
+	from, err := time.ParseInLocation("2006-01", month, location)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("month must be in YYYY-MM format")
+	}
+	return from, from.AddDate(0, 1, 0), nil
+}
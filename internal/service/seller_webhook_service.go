@@ -0,0 +1,497 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+
+	"github.com/google/uuid"
+)
+
+// Seller webhook limits
+const (
+	maxSellerWebhooks        = 5   // Endpoints per shop
+	webhookDeliveryBatchSize = 50  // Deliveries one dispatch pass sends at most
+	webhookResponseExcerpt   = 500 // Bytes of an error response kept on the delivery
+	webhookSecretPrefix      = "whsec_"
+)
+
+// webhookRetryBackoff is the wait before each retry; the last entry repeats
+var webhookRetryBackoff = []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour, 6 * time.Hour}
+
+// SellerWebhookService lets sellers register HTTPS endpoints of their own systems and delivers
+// product events to them. Stock events are derived from the product change feed: when a product's
+// available stock crosses the low stock threshold or runs out, one event is queued per
+// subscribed endpoint. Deliveries are signed and retried with backoff.
+//
+// Each delivery is a POST with a JSON body {id, event, created_at, data} and the headers
+// X-Webhook-Event, X-Webhook-Delivery, X-Webhook-Timestamp and
+// X-Webhook-Signature: sha256=HMAC-SHA256(secret, timestamp + "." + body) in hex.
+type SellerWebhookService interface {
+	CreateWebhook(ctx context.Context, userID string, req SellerWebhookRequest) (*CreateSellerWebhookResponse, error)
+	GetWebhooks(ctx context.Context, userID string) ([]model.SellerWebhook, error)
+	UpdateWebhook(ctx context.Context, userID string, webhookID string, req SellerWebhookRequest) (*model.SellerWebhook, error)
+	DeleteWebhook(ctx context.Context, userID string, webhookID string) error
+	GetDeliveries(ctx context.Context, userID string, webhookID string, status string, page, limit int) ([]model.SellerWebhookDelivery, int64, error)
+	// SendTest queues a webhook.test event to the endpoint
+	SendTest(ctx context.Context, userID string, webhookID string) (*model.SellerWebhookDelivery, error)
+
+	// DispatchDue sends deliveries whose attempt is due and returns how many were delivered
+	DispatchDue(ctx context.Context) (int, error)
+}
+
+type SellerWebhookRequest struct {
+	URL      string   `json:"url" binding:"required,max=2000"`
+	Events   []string `json:"events" binding:"required,min=1"`
+	IsActive *bool    `json:"is_active"` // Default: true
+}
+
+// CreateSellerWebhookResponse contains the signing secret, which is only returned once
+type CreateSellerWebhookResponse struct {
+	Webhook *model.SellerWebhook `json:"webhook"`
+	Secret  string               `json:"secret"`
+}
+
+// WebhookPayload is the JSON body of a delivery
+type WebhookPayload struct {
+	ID        string      `json:"id"` // Delivery ID; the same on every retry
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// ProductStockEventData is the data of product.low_stock and product.out_of_stock events
+type ProductStockEventData struct {
+	ProductID         string `json:"product_id"`
+	SKU               string `json:"sku"`
+	Name              string `json:"name"`
+	Stock             int    `json:"stock"` // Available units
+	LowStockThreshold int    `json:"low_stock_threshold"`
+}
+
+type sellerWebhookService struct {
+	webhookRepo       repository.SellerWebhookRepository
+	sellerRepo        repository.SellerRepository
+	productRepo       repository.ProductRepository
+	stock             StockCacheService
+	client            *http.Client
+	lowStockThreshold int
+	maxAttempts       int
+	lease             time.Duration
+}
+
+func NewSellerWebhookService(
+	webhookRepo repository.SellerWebhookRepository,
+	sellerRepo repository.SellerRepository,
+	productRepo repository.ProductRepository,
+	stock StockCacheService,
+	events ProductEventService,
+	cfg *config.Config,
+	jobs *util.BackgroundJobs,
+) SellerWebhookService {
+	timeout := time.Duration(cfg.SellerWebhookTimeoutSeconds) * time.Second
+	service := &sellerWebhookService{
+		webhookRepo:       webhookRepo,
+		sellerRepo:        sellerRepo,
+		productRepo:       productRepo,
+		stock:             stock,
+		client:            &http.Client{Timeout: timeout},
+		lowStockThreshold: cfg.SellerWebhookLowStockThreshold,
+		maxAttempts:       cfg.SellerWebhookMaxAttempts,
+		lease:             webhookDeliveryBatchSize * timeout, // Long enough to send a whole batch
+	}
+
+	// Watch product changes for stock events and send deliveries in the background
+	if cfg.SellerWebhookDispatchIntervalSeconds > 0 {
+		interval := time.Duration(cfg.SellerWebhookDispatchIntervalSeconds) * time.Second
+		jobs.Go(func(ctx context.Context) {
+			service.watchStock(ctx, events.Subscribe(ctx))
+		})
+		jobs.Every(interval, service.runDispatcher)
+		log.Printf("✅ Seller webhook dispatcher started (low stock at %d, checking every %s)", service.lowStockThreshold, interval)
+	}
+
+	return service
+}
+
+// runDispatcher sends due deliveries
+func (s *sellerWebhookService) runDispatcher(ctx context.Context) {
+	if _, err := s.DispatchDue(ctx); err != nil {
+		log.Printf("⚠️  Failed to dispatch seller webhooks: %v", err)
+	}
+}
+
+// watchStock raises stock events for changed products
+func (s *sellerWebhookService) watchStock(ctx context.Context, productIDs <-chan string) {
+	for productID := range productIDs {
+		s.checkStock(ctx, productID)
+	}
+}
+
+// checkStock records the product's stock level and queues an event when it dropped to low or out
+// of stock. Every instance sees the change; the stock state update lets only one of them queue it.
+func (s *sellerWebhookService) checkStock(ctx context.Context, productID string) {
+	product, err := s.productRepo.FindByID(productID)
+	if err != nil || !product.IsActive {
+		return
+	}
+
+	available := s.stock.Available(ctx, product)
+	state := model.ProductStockInStock
+	switch {
+	case available <= 0:
+		state = model.ProductStockOutOfStock
+	case available <= s.lowStockThreshold:
+		state = model.ProductStockLow
+	}
+
+	changed, err := s.webhookRepo.SetStockState(ctx, product.ID, state)
+	if err != nil {
+		log.Printf("⚠️  Failed to record stock state of product %s: %v", product.ID, err)
+		return
+	}
+	if !changed || state == model.ProductStockInStock {
+		return
+	}
+
+	event := model.WebhookEventProductLowStock
+	if state == model.ProductStockOutOfStock {
+		event = model.WebhookEventProductOutOfStock
+	}
+	s.queue(ctx, product.SellerID, event, ProductStockEventData{
+		ProductID:         product.ID,
+		SKU:               product.SKU,
+		Name:              product.Name,
+		Stock:             max(available, 0),
+		LowStockThreshold: s.lowStockThreshold,
+	})
+}
+
+// queue creates a delivery of the event for each of the seller's endpoints subscribed to it
+func (s *sellerWebhookService) queue(ctx context.Context, sellerID string, event string, data interface{}) {
+	webhooks, err := s.webhookRepo.FindActiveForEvent(ctx, sellerID, event)
+	if err != nil {
+		log.Printf("⚠️  Failed to find webhooks of seller %s for %s: %v", sellerID, event, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	deliveries := make([]model.SellerWebhookDelivery, 0, len(webhooks))
+	for i := range webhooks {
+		delivery, err := newWebhookDelivery(&webhooks[i], event, data)
+		if err != nil {
+			log.Printf("⚠️  Failed to build %s delivery: %v", event, err)
+			return
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	if err := s.webhookRepo.CreateDeliveries(ctx, deliveries); err != nil {
+		log.Printf("⚠️  Failed to queue %s for seller %s: %v", event, sellerID, err)
+		return
+	}
+	log.Printf("🪝 Queued %s for %d webhook(s) of seller %s", event, len(deliveries), sellerID)
+}
+
+func newWebhookDelivery(webhook *model.SellerWebhook, event string, data interface{}) (*model.SellerWebhookDelivery, error) {
+	now := time.Now()
+	payload := WebhookPayload{ID: uuid.New().String(), Event: event, CreatedAt: now, Data: data}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &model.SellerWebhookDelivery{
+		ID:            payload.ID,
+		WebhookID:     webhook.ID,
+		SellerID:      webhook.SellerID,
+		Event:         event,
+		Payload:       string(body),
+		Status:        model.WebhookDeliveryPending,
+		NextAttemptAt: now,
+	}, nil
+}
+
+func (s *sellerWebhookService) DispatchDue(ctx context.Context) (int, error) {
+	deliveries, err := s.webhookRepo.ClaimDueDeliveries(ctx, time.Now(), s.lease, webhookDeliveryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		s.attempt(ctx, delivery)
+		if err := s.webhookRepo.RecordAttempt(ctx, delivery); err != nil {
+			log.Printf("⚠️  Failed to record webhook delivery %s: %v", delivery.ID, err)
+			continue
+		}
+		if delivery.Status == model.WebhookDeliveryDelivered {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// attempt sends the delivery once and updates it with the outcome
+func (s *sellerWebhookService) attempt(ctx context.Context, delivery *model.SellerWebhookDelivery) {
+	delivery.Attempts++
+	delivery.ResponseStatus = nil
+
+	status, err := s.send(ctx, delivery)
+	if status != 0 {
+		delivery.ResponseStatus = &status
+	}
+	if err == nil {
+		now := time.Now()
+		delivery.Status = model.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = nil
+		return
+	}
+
+	message := err.Error()
+	delivery.LastError = &message
+	if delivery.Webhook == nil || !delivery.Webhook.IsActive || delivery.Attempts >= s.maxAttempts {
+		delivery.Status = model.WebhookDeliveryFailed
+		log.Printf("⚠️  Gave up on webhook delivery %s (%s) after %d attempt(s): %s", delivery.ID, delivery.Event, delivery.Attempts, message)
+		return
+	}
+	backoff := webhookRetryBackoff[min(delivery.Attempts, len(webhookRetryBackoff))-1]
+	delivery.NextAttemptAt = time.Now().Add(backoff)
+}
+
+// send POSTs the signed payload and returns the response status
+func (s *sellerWebhookService) send(ctx context.Context, delivery *model.SellerWebhookDelivery) (int, error) {
+	webhook := delivery.Webhook
+	if webhook == nil {
+		return 0, errors.New("webhook no longer exists")
+	}
+	if !webhook.IsActive {
+		return 0, errors.New("webhook is disabled")
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Marketplace-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+signWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseExcerpt))
+		return resp.StatusCode, fmt.Errorf("endpoint responded %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return resp.StatusCode, nil
+}
+
+func signWebhookPayload(secret, timestamp, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newWebhookSecret returns a random signing secret
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + hex.EncodeToString(secret), nil
+}
+
+func (s *sellerWebhookService) CreateWebhook(ctx context.Context, userID string, req SellerWebhookRequest) (*CreateSellerWebhookResponse, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	existing, err := s.webhookRepo.FindBySellerID(ctx, seller.ID)
+	if err != nil {
+		return nil, errors.New("failed to get webhooks: " + err.Error())
+	}
+	if len(existing) >= maxSellerWebhooks {
+		return nil, fmt.Errorf("a shop can have at most %d webhooks", maxSellerWebhooks)
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, errors.New("failed to generate webhook secret: " + err.Error())
+	}
+	webhook := &model.SellerWebhook{SellerID: seller.ID, Secret: secret, IsActive: true}
+	if err := applySellerWebhookRequest(webhook, req); err != nil {
+		return nil, err
+	}
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return nil, errors.New("failed to create webhook: " + err.Error())
+	}
+
+	log.Printf("🪝 Seller %s added webhook %s for %s", seller.ID, webhook.ID, webhook.Events)
+	return &CreateSellerWebhookResponse{Webhook: webhook, Secret: webhook.Secret}, nil
+}
+
+func (s *sellerWebhookService) GetWebhooks(ctx context.Context, userID string) ([]model.SellerWebhook, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	webhooks, err := s.webhookRepo.FindBySellerID(ctx, seller.ID)
+	if err != nil {
+		return nil, errors.New("failed to get webhooks: " + err.Error())
+	}
+	return webhooks, nil
+}
+
+func (s *sellerWebhookService) UpdateWebhook(ctx context.Context, userID string, webhookID string, req SellerWebhookRequest) (*model.SellerWebhook, error) {
+	webhook, err := s.findOwned(ctx, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applySellerWebhookRequest(webhook, req); err != nil {
+		return nil, err
+	}
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		return nil, errors.New("failed to update webhook: " + err.Error())
+	}
+	return webhook, nil
+}
+
+func (s *sellerWebhookService) DeleteWebhook(ctx context.Context, userID string, webhookID string) error {
+	webhook, err := s.findOwned(ctx, userID, webhookID)
+	if err != nil {
+		return err
+	}
+	if err := s.webhookRepo.Delete(ctx, webhook.ID); err != nil {
+		return errors.New("failed to delete webhook: " + err.Error())
+	}
+	return nil
+}
+
+func (s *sellerWebhookService) GetDeliveries(ctx context.Context, userID string, webhookID string, status string, page, limit int) ([]model.SellerWebhookDelivery, int64, error) {
+	webhook, err := s.findOwned(ctx, userID, webhookID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	deliveries, total, err := s.webhookRepo.FindDeliveries(ctx, webhook.ID, status, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get deliveries: " + err.Error())
+	}
+	return deliveries, total, nil
+}
+
+func (s *sellerWebhookService) SendTest(ctx context.Context, userID string, webhookID string) (*model.SellerWebhookDelivery, error) {
+	webhook, err := s.findOwned(ctx, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := newWebhookDelivery(webhook, model.WebhookEventTest, map[string]string{"webhook_id": webhook.ID})
+	if err != nil {
+		return nil, errors.New("failed to build test delivery: " + err.Error())
+	}
+	if err := s.webhookRepo.CreateDeliveries(ctx, []model.SellerWebhookDelivery{*delivery}); err != nil {
+		return nil, errors.New("failed to queue test delivery: " + err.Error())
+	}
+	return delivery, nil
+}
+
+func (s *sellerWebhookService) findOwned(ctx context.Context, userID string, webhookID string) (*model.SellerWebhook, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	webhook, err := s.webhookRepo.FindByID(ctx, webhookID)
+	if err != nil || webhook.SellerID != seller.ID {
+		return nil, errors.New("webhook not found")
+	}
+	return webhook, nil
+}
+
+func applySellerWebhookRequest(webhook *model.SellerWebhook, req SellerWebhookRequest) error {
+	endpoint := strings.TrimSpace(req.URL)
+	if err := validateWebhookURL(endpoint); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(req.Events))
+	events := make([]string, 0, len(req.Events))
+	for _, event := range req.Events {
+		event = strings.TrimSpace(event)
+		if !isSellerWebhookEvent(event) {
+			return fmt.Errorf("unknown event %q; supported: %s", event, strings.Join(model.SellerWebhookEvents, ", "))
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+
+	webhook.URL = endpoint
+	webhook.Events = strings.Join(events, ",")
+	if req.IsActive != nil {
+		webhook.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// validateWebhookURL accepts public HTTPS URLs only, so sellers cannot point deliveries at the
+// marketplace's own network
+func validateWebhookURL(endpoint string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		return errors.New("url must be an absolute URL")
+	}
+	if parsed.Scheme != "https" {
+		return errors.New("url must use https")
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") {
+		return errors.New("url must be publicly reachable")
+	}
+	if ip := net.ParseIP(host); ip != nil && (ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified()) {
+		return errors.New("url must be publicly reachable")
+	}
+	return nil
+}
+
+func isSellerWebhookEvent(event string) bool {
+	for _, e := range model.SellerWebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
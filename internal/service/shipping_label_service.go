@@ -0,0 +1,332 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+)
+
+// ErrShippingLabelsUnavailable is returned when no courier integration is configured
+var ErrShippingLabelsUnavailable = errors.New("shipping labels are not available")
+
+// staleLabelBookingAfter is how long a pending label may stay pending before it is considered
+// abandoned (the server stopped mid-booking) and may be booked again
+const staleLabelBookingAfter = 5 * time.Minute
+
+// ShippingLabelService books courier pickups for sub-orders and renders the labels sellers stick
+// on their parcels
+type ShippingLabelService interface {
+	CreateLabel(ctx context.Context, userID string, sellerOrderID string, req CreateShippingLabelRequest) (*model.ShippingLabel, error)
+	GetLabel(ctx context.Context, userID string, sellerOrderID string) (*model.ShippingLabel, error)
+	// GetLabelPDF renders the booked label on a 100 x 150 mm page
+	GetLabelPDF(ctx context.Context, userID string, sellerOrderID string) (*model.ShippingLabel, []byte, error)
+}
+
+type shippingLabelService struct {
+	labelRepo       repository.ShippingLabelRepository
+	sellerOrderRepo repository.SellerOrderRepository
+	sellerRepo      repository.SellerRepository
+	orderRepo       repository.OrderRepository
+	gateway         CourierGateway // nil when no courier integration is configured
+	couriers        map[string]bool
+	config          *config.Config
+}
+
+// CreateShippingLabelRequest picks the courier; the service level defaults to the one the buyer
+// chose at checkout
+type CreateShippingLabelRequest struct {
+	Courier string `json:"courier" binding:"required,max=50"`
+	Service string `json:"service" binding:"max=30"`
+}
+
+func NewShippingLabelService(labelRepo repository.ShippingLabelRepository, sellerOrderRepo repository.SellerOrderRepository, sellerRepo repository.SellerRepository, orderRepo repository.OrderRepository, gateway CourierGateway, cfg *config.Config) ShippingLabelService {
+	couriers := make(map[string]bool)
+	for _, courier := range strings.Split(cfg.SupportedCouriers, ",") {
+		if courier = strings.ToLower(strings.TrimSpace(courier)); courier != "" {
+			couriers[courier] = true
+		}
+	}
+	return &shippingLabelService{
+		labelRepo:       labelRepo,
+		sellerOrderRepo: sellerOrderRepo,
+		sellerRepo:      sellerRepo,
+		orderRepo:       orderRepo,
+		gateway:         gateway,
+		couriers:        couriers,
+		config:          cfg,
+	}
+}
+
+// CreateLabel books a pickup with the courier. The label row is reserved first so concurrent
+// requests cannot book the same sub-order twice; it is removed again when the booking fails.
+func (s *shippingLabelService) CreateLabel(ctx context.Context, userID string, sellerOrderID string, req CreateShippingLabelRequest) (*model.ShippingLabel, error) {
+	if s.gateway == nil {
+		return nil, ErrShippingLabelsUnavailable
+	}
+	courier := strings.ToLower(strings.TrimSpace(req.Courier))
+	if !s.couriers[courier] {
+		return nil, errors.New("unsupported courier")
+	}
+
+	seller, sellerOrder, err := s.findOwned(ctx, userID, sellerOrderID)
+	if err != nil {
+		return nil, err
+	}
+	if sellerOrder.Status != "paid" && sellerOrder.Status != "processing" {
+		return nil, errors.New("only paid or processing orders can get a shipping label")
+	}
+	if seller.ShopAddress == nil || *seller.ShopAddress == "" || seller.ShopPhone == nil || *seller.ShopPhone == "" {
+		return nil, errors.New("set the shop address and phone before booking shipping labels")
+	}
+	order, err := s.orderRepo.FindByID(ctx, sellerOrder.OrderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+
+	service := strings.ToLower(strings.TrimSpace(req.Service))
+	if service == "" {
+		service = order.CourierService
+	}
+	if service == "" {
+		service = DefaultCourierService
+	}
+
+	booking := ShipmentBooking{
+		Reference:   sellerOrder.SubOrderNumber,
+		Courier:     courier,
+		Service:     service,
+		Origin:      shopContact(seller),
+		Destination: recipientContact(order, sellerOrder),
+		Items:       make([]ShipmentItem, 0, len(sellerOrder.OrderItems)),
+	}
+	for _, item := range sellerOrder.OrderItems {
+		weight := s.config.DefaultProductWeight
+		if item.Product.Weight != nil && *item.Product.Weight > 0 {
+			weight = *item.Product.Weight
+		}
+		booking.Items = append(booking.Items, ShipmentItem{
+			Name:        item.ProductName,
+			Quantity:    item.Quantity,
+			Value:       item.Price,
+			WeightGrams: weight,
+		})
+		booking.WeightGrams += weight * item.Quantity
+	}
+
+	label := &model.ShippingLabel{
+		SellerOrderID: sellerOrder.ID,
+		SellerID:      seller.ID,
+		Courier:       courier,
+		Service:       service,
+		Status:        model.ShippingLabelStatusPending,
+		WeightGrams:   booking.WeightGrams,
+	}
+	if err := s.reserve(ctx, label); err != nil {
+		return nil, err
+	}
+
+	result, err := s.gateway.BookShipment(ctx, booking)
+	if err != nil {
+		if delErr := s.labelRepo.Delete(context.Background(), label.ID); delErr != nil {
+			log.Printf("⚠️  Failed to release shipping label of sub-order %s: %v", sellerOrder.SubOrderNumber, delErr)
+		}
+		return nil, errors.New("failed to book shipment: " + err.Error())
+	}
+
+	label.Status = model.ShippingLabelStatusBooked
+	label.Waybill = &result.Waybill
+	label.BookingID = &result.BookingID
+	label.ShippingFee = result.Fee
+	if err := s.labelRepo.Update(context.Background(), label); err != nil {
+		// The courier already has the booking; keep the waybill in the log so it can be recovered
+		log.Printf("❌ Booked %s waybill %s for sub-order %s but failed to save it: %v", courier, result.Waybill, sellerOrder.SubOrderNumber, err)
+		return nil, errors.New("failed to save shipping label: " + err.Error())
+	}
+
+	log.Printf("🏷️  Booked %s %s waybill %s for sub-order %s", courier, service, result.Waybill, sellerOrder.SubOrderNumber)
+	return label, nil
+}
+
+// reserve creates the pending label, taking over a pending one that was abandoned mid-booking
+func (s *shippingLabelService) reserve(ctx context.Context, label *model.ShippingLabel) error {
+	err := s.labelRepo.Create(ctx, label)
+	if !errors.Is(err, repository.ErrShippingLabelExists) {
+		return err
+	}
+	existing, findErr := s.labelRepo.FindBySellerOrderID(ctx, label.SellerOrderID)
+	if findErr != nil || existing.Status != model.ShippingLabelStatusPending || time.Since(existing.UpdatedAt) < staleLabelBookingAfter {
+		return err
+	}
+	if err := s.labelRepo.Delete(ctx, existing.ID); err != nil {
+		return err
+	}
+	return s.labelRepo.Create(ctx, label)
+}
+
+func (s *shippingLabelService) GetLabel(ctx context.Context, userID string, sellerOrderID string) (*model.ShippingLabel, error) {
+	_, sellerOrder, err := s.findOwned(ctx, userID, sellerOrderID)
+	if err != nil {
+		return nil, err
+	}
+	label, err := s.labelRepo.FindBySellerOrderID(ctx, sellerOrder.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("shipping label not found")
+		}
+		return nil, errors.New("failed to get shipping label: " + err.Error())
+	}
+	return label, nil
+}
+
+func (s *shippingLabelService) GetLabelPDF(ctx context.Context, userID string, sellerOrderID string) (*model.ShippingLabel, []byte, error) {
+	seller, sellerOrder, err := s.findOwned(ctx, userID, sellerOrderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	label, err := s.labelRepo.FindBySellerOrderID(ctx, sellerOrder.ID)
+	if err != nil || label.Status != model.ShippingLabelStatusBooked || label.Waybill == nil {
+		return nil, nil, errors.New("shipping label not found")
+	}
+	order, err := s.orderRepo.FindByID(ctx, sellerOrder.OrderID)
+	if err != nil {
+		return nil, nil, errors.New("order not found")
+	}
+
+	pdf, err := renderShippingLabelPDF(label, sellerOrder, shopContact(seller), recipientContact(order, sellerOrder))
+	if err != nil {
+		return nil, nil, errors.New("failed to render shipping label: " + err.Error())
+	}
+	return label, pdf, nil
+}
+
+// findOwned loads a sub-order, making sure it belongs to the user's shop
+func (s *shippingLabelService) findOwned(ctx context.Context, userID string, sellerOrderID string) (*model.Seller, *model.SellerOrder, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, nil, errors.New("seller not found")
+	}
+	sellerOrder, err := s.sellerOrderRepo.FindByID(ctx, sellerOrderID)
+	if err != nil || sellerOrder.SellerID != seller.ID {
+		return nil, nil, errors.New("order not found")
+	}
+	return seller, sellerOrder, nil
+}
+
+func shopContact(seller *model.Seller) ShipmentContact {
+	contact := ShipmentContact{Name: seller.ShopName}
+	if seller.ShopPhone != nil {
+		contact.Phone = *seller.ShopPhone
+	}
+	if seller.ShopAddress != nil {
+		contact.Address = *seller.ShopAddress
+	}
+	if seller.ShopCity != nil {
+		contact.City = *seller.ShopCity
+	}
+	if seller.ShopProvince != nil {
+		contact.Province = *seller.ShopProvince
+	}
+	return contact
+}
+
+// recipientContact is the sub-order's shipping address; gift orders go to the gift recipient
+func recipientContact(order *model.Order, sellerOrder *model.SellerOrder) ShipmentContact {
+	address := sellerOrder.ShippingAddress
+	contact := ShipmentContact{
+		Name:       address.RecipientName,
+		Phone:      address.Phone,
+		Address:    address.AddressLine1,
+		City:       address.City,
+		Province:   address.Province,
+		PostalCode: address.PostalCode,
+	}
+	if address.AddressLine2 != nil && *address.AddressLine2 != "" {
+		contact.Address += ", " + *address.AddressLine2
+	}
+	if order.IsGift {
+		if order.RecipientName != nil {
+			contact.Name = *order.RecipientName
+		}
+		if order.RecipientPhone != nil {
+			contact.Phone = *order.RecipientPhone
+		}
+	}
+	return contact
+}
+
+// renderShippingLabelPDF draws the courier label: waybill with a QR code for the courier's
+// scanner, recipient, sender and parcel details
+func renderShippingLabelPDF(label *model.ShippingLabel, sellerOrder *model.SellerOrder, from, to ShipmentContact) ([]byte, error) {
+	const margin = 14.0
+	doc := util.NewPDFDocument(util.PDFLabelWidth, util.PDFLabelHeight)
+	right := doc.Width() - margin
+	width := right - margin
+
+	doc.Rect(margin, margin, width, util.PDFLabelHeight-2*margin, 1)
+	doc.Text(margin+8, 36, 16, true, strings.ToUpper(label.Courier))
+	doc.Text(right-90, 36, 10, true, strings.ToUpper(label.Service))
+	doc.Line(margin, 46, right, 46, 1)
+
+	qr, err := qrcode.New(*label.Waybill, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	doc.Bitmap(qr.Bitmap(), margin+8, 52, 86, 86)
+	doc.Text(margin+104, 76, 8, false, "WAYBILL / AWB")
+	y := doc.WrapText(margin+104, 94, 13, true, right-margin-112, *label.Waybill)
+	doc.Text(margin+104, y+4, 8, false, sellerOrder.SubOrderNumber)
+	doc.Line(margin, 146, right, 146, 1)
+
+	y = 162.0
+	doc.Text(margin+8, y, 8, true, "TO")
+	y += 14
+	doc.Text(margin+8, y, 11, true, to.Name)
+	y += 13
+	doc.Text(margin+8, y, 10, false, to.Phone)
+	y += 13
+	y = doc.WrapText(margin+8, y, 9, false, width-16, to.Address)
+	y = doc.WrapText(margin+8, y, 9, false, width-16, to.City+", "+to.Province+" "+to.PostalCode)
+	y += 4
+	doc.Line(margin, y, right, y, 0.5)
+
+	y += 16
+	doc.Text(margin+8, y, 8, true, "FROM")
+	y += 13
+	doc.Text(margin+8, y, 10, true, from.Name)
+	y += 12
+	doc.Text(margin+8, y, 9, false, from.Phone)
+	y += 12
+	y = doc.WrapText(margin+8, y, 8, false, width-16, joinShipmentAddress(from))
+	y += 4
+	doc.Line(margin, y, right, y, 0.5)
+
+	quantity := 0
+	for _, item := range sellerOrder.OrderItems {
+		quantity += item.Quantity
+	}
+	y += 16
+	doc.Text(margin+8, y, 9, false, "Weight: "+formatWeight(label.WeightGrams))
+	doc.Text(margin+width/2, y, 9, false, "Items: "+strconv.Itoa(quantity))
+	y += 13
+	doc.Text(margin+8, y, 9, false, "Booked: "+label.CreatedAt.Format("02 Jan 2006"))
+
+	return doc.Bytes()
+}
+
+func formatWeight(grams int) string {
+	if grams < 1000 {
+		return fmt.Sprintf("%d g", grams)
+	}
+	return fmt.Sprintf("%.2f kg", float64(grams)/1000)
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+)
+
+// StockCacheService keeps available-stock counters in Redis so cart validation and checkout
+// admission do not hit Postgres under flash-sale load. Postgres stays authoritative: admission
+// only turns away orders early, the order transaction still re-checks stock under a row lock.
+// Without Redis every method falls back to the database values.
+type StockCacheService interface {
+	// Available returns the product's available stock, loading the counter from product if needed
+	Available(ctx context.Context, product *model.Product) int
+	// Admit reserves the quantities (product ID → units) for an order about to be placed, all or
+	// nothing. Returns repository.ErrInsufficientStock when a product cannot cover its quantity.
+	Admit(ctx context.Context, quantities map[string]int) error
+	// Release gives admitted or ordered quantities back, e.g. when the order fails or is cancelled
+	Release(ctx context.Context, quantities map[string]int)
+	// Adjust writes a stock change made outside of orders through to the counter
+	Adjust(ctx context.Context, productID string, delta int)
+}
+
+type stockCacheService struct {
+	productRepo repository.ProductRepository
+	redis       *util.RedisClient // Optional; nil disables the cache
+	events      ProductEventService
+	ttl         time.Duration
+
+	// lastDrift is the counter-vs-database difference seen by the previous reconciliation pass.
+	// Only the reconciler goroutine touches it.
+	lastDrift map[string]int
+}
+
+func NewStockCacheService(productRepo repository.ProductRepository, redisClient *util.RedisClient, events ProductEventService, cfg *config.Config, jobs *util.BackgroundJobs) StockCacheService {
+	service := &stockCacheService{
+		productRepo: productRepo,
+		redis:       redisClient,
+		events:      events,
+		ttl:         time.Duration(cfg.StockCacheTTLSeconds) * time.Second,
+		lastDrift:   make(map[string]int),
+	}
+
+	// Start background job to repair drift between the counters and Postgres
+	if redisClient != nil && cfg.StockReconcileIntervalSeconds > 0 {
+		interval := time.Duration(cfg.StockReconcileIntervalSeconds) * time.Second
+		jobs.Every(interval, service.runReconciler)
+		log.Printf("✅ Stock counter reconciler started (checking every %s)", interval)
+	}
+
+	return service
+}
+
+func (s *stockCacheService) Available(ctx context.Context, product *model.Product) int {
+	if s.redis == nil {
+		return product.Stock
+	}
+	key := util.StockKey(product.ID)
+	stock, ok, err := s.redis.GetInt(ctx, key)
+	if err != nil {
+		log.Printf("⚠️  Failed to read stock counter for product %s: %v", product.ID, err)
+		return product.Stock
+	}
+	if !ok {
+		if err := s.redis.SetIntIfAbsent(ctx, key, product.Stock, s.ttl); err != nil {
+			log.Printf("⚠️  Failed to load stock counter for product %s: %v", product.ID, err)
+		}
+		return product.Stock
+	}
+	return stock
+}
+
+func (s *stockCacheService) Admit(ctx context.Context, quantities map[string]int) error {
+	if s.redis == nil || len(quantities) == 0 {
+		return nil
+	}
+	keys, amounts, productIDs := counterArgs(quantities)
+
+	err := s.redis.ReserveCounters(ctx, keys, amounts)
+	if errors.Is(err, util.ErrCounterMissing) {
+		// Cold counters: load them from Postgres and try once more
+		if loadErr := s.load(ctx, productIDs); loadErr != nil {
+			log.Printf("⚠️  Failed to load stock counters, admitting order to the database check: %v", loadErr)
+			return nil
+		}
+		err = s.redis.ReserveCounters(ctx, keys, amounts)
+	}
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, util.ErrCounterInsufficient):
+		return repository.ErrInsufficientStock
+	case errors.Is(err, util.ErrCounterMissing):
+		// Expired again in between; leave it to the database check
+		return nil
+	default:
+		// Redis trouble must not stop sales, Postgres still guards against overselling
+		log.Printf("⚠️  Stock admission unavailable, admitting order to the database check: %v", err)
+		return nil
+	}
+}
+
+func (s *stockCacheService) Release(ctx context.Context, quantities map[string]int) {
+	if len(quantities) == 0 {
+		return
+	}
+	keys, amounts, productIDs := counterArgs(quantities)
+	if s.redis != nil {
+		if err := s.redis.IncrCounters(ctx, keys, amounts); err != nil {
+			log.Printf("⚠️  Failed to release stock counters (reconciliation will repair them): %v", err)
+		}
+	}
+	s.events.Publish(ctx, productIDs...)
+}
+
+func (s *stockCacheService) Adjust(ctx context.Context, productID string, delta int) {
+	if delta == 0 {
+		return
+	}
+	if s.redis != nil {
+		if err := s.redis.IncrCounters(ctx, []string{util.StockKey(productID)}, []int{delta}); err != nil {
+			log.Printf("⚠️  Failed to adjust stock counter for product %s (reconciliation will repair it): %v", productID, err)
+		}
+	}
+	s.events.Publish(ctx, productID)
+}
+
+// load seeds missing counters from Postgres; counters that appeared meanwhile are kept
+func (s *stockCacheService) load(ctx context.Context, productIDs []string) error {
+	stocks, err := s.productRepo.FindStocks(productIDs)
+	if err != nil {
+		return err
+	}
+	for _, productID := range productIDs {
+		stock, ok := stocks[productID]
+		if !ok {
+			continue // Unknown product; the order itself will reject it
+		}
+		if err := s.redis.SetIntIfAbsent(ctx, util.StockKey(productID), stock, s.ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runReconciler compares every loaded counter with Postgres
+func (s *stockCacheService) runReconciler(ctx context.Context) {
+	repaired, err := s.reconcile(ctx)
+	if err != nil {
+		log.Printf("⚠️  Stock counter reconciliation failed: %v", err)
+	} else if repaired > 0 {
+		log.Printf("🔧 Repaired %d drifted stock counter(s)", repaired)
+	}
+}
+
+// reconcile repairs counters that disagree with Postgres. Orders between admission and commit
+// make a counter legitimately lower than the database for a moment, so a counter is only
+// repaired when the same drift is seen on two passes in a row, and only if it has not moved
+// since it was read.
+func (s *stockCacheService) reconcile(ctx context.Context) (int, error) {
+	repaired := 0
+	drift := make(map[string]int)
+
+	err := s.redis.ScanKeys(ctx, util.StockKeyPrefix+"*", func(keys []string) error {
+		productIDs := make([]string, 0, len(keys))
+		for _, key := range keys {
+			productIDs = append(productIDs, strings.TrimPrefix(key, util.StockKeyPrefix))
+		}
+
+		// An order in flight between these reads shows up as drift on this pass only
+		counters := make(map[string]int, len(productIDs))
+		for _, productID := range productIDs {
+			counter, ok, err := s.redis.GetInt(ctx, util.StockKey(productID))
+			if err != nil {
+				return err
+			}
+			if ok {
+				counters[productID] = counter
+			}
+		}
+		stocks, err := s.productRepo.FindStocks(productIDs)
+		if err != nil {
+			return fmt.Errorf("failed to load stocks: %w", err)
+		}
+
+		for productID, counter := range counters {
+			stock, ok := stocks[productID]
+			if !ok || counter == stock {
+				continue
+			}
+			drift[productID] = counter - stock
+			if s.lastDrift[productID] != counter-stock {
+				continue
+			}
+			swapped, err := s.redis.CompareAndSetInt(ctx, util.StockKey(productID), counter, stock)
+			if err != nil {
+				return err
+			}
+			if swapped {
+				repaired++
+				delete(drift, productID)
+			}
+		}
+		return nil
+	})
+
+	s.lastDrift = drift
+	return repaired, err
+}
+
+// counterArgs turns quantities into counter keys and amounts, sorted by product ID
+func counterArgs(quantities map[string]int) (keys []string, amounts []int, productIDs []string) {
+	productIDs = make([]string, 0, len(quantities))
+	for productID := range quantities {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Strings(productIDs)
+
+	keys = make([]string, len(productIDs))
+	amounts = make([]int, len(productIDs))
+	for i, productID := range productIDs {
+		keys[i] = util.StockKey(productID)
+		amounts[i] = quantities[productID]
+	}
+	return keys, amounts, productIDs
+}
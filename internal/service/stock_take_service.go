@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// StockTakeService runs stock opname (physical inventory counts) for sellers
+type StockTakeService interface {
+	StartStockTake(ctx context.Context, userID string, req StartStockTakeRequest) (*model.StockTake, error)
+	GetStockTakes(ctx context.Context, userID string, page, limit int) ([]model.StockTake, int64, error)
+	GetStockTake(ctx context.Context, userID string, takeID string) (*model.StockTake, error)
+	SubmitCounts(ctx context.Context, userID string, takeID string, req SubmitStockCountsRequest) (*model.StockTake, error)
+	GetVarianceReport(ctx context.Context, userID string, takeID string) (*StockVarianceReport, error)
+	ApplyStockTake(ctx context.Context, userID string, takeID string) (*ApplyStockTakeResponse, error)
+	CancelStockTake(ctx context.Context, userID string, takeID string) (*model.StockTake, error)
+}
+
+type stockTakeService struct {
+	stockTakeRepo repository.StockTakeRepository
+	productRepo   repository.ProductRepository
+	sellerRepo    repository.SellerRepository
+	stock         StockCacheService
+}
+
+type StartStockTakeRequest struct {
+	Note *string `json:"note,omitempty"`
+}
+
+// SubmitStockCountsRequest records counted quantities; each product is identified by ID or SKU
+type SubmitStockCountsRequest struct {
+	Counts []StockCountItem `json:"counts" binding:"required,min=1,max=500,dive"`
+}
+
+type StockCountItem struct {
+	ProductID       string `json:"product_id,omitempty"`
+	SKU             string `json:"sku,omitempty"`
+	CountedQuantity *int   `json:"counted_quantity" binding:"required,min=0"`
+}
+
+// StockVarianceReport compares counted quantities with system stock at count time
+type StockVarianceReport struct {
+	StockTakeID       string              `json:"stock_take_id"`
+	Status            string              `json:"status"`
+	Lines             []StockVarianceLine `json:"lines"`
+	CountedProducts   int                 `json:"counted_products"`
+	UncountedProducts int64               `json:"uncounted_products"` // Products in the shop with no count yet
+	TotalVariance     int                 `json:"total_variance"`     // Sum of unit variances
+	TotalValue        int                 `json:"total_value"`        // Sum of variance x current price (IDR)
+}
+
+type StockVarianceLine struct {
+	ProductID       string `json:"product_id"`
+	SKU             string `json:"sku"`
+	ProductName     string `json:"product_name"`
+	SystemStock     int    `json:"system_stock"`
+	CountedQuantity int    `json:"counted_quantity"`
+	Variance        int    `json:"variance"` // Counted - system; negative means missing stock
+	Price           int    `json:"price"`
+	VarianceValue   int    `json:"variance_value"`
+}
+
+type ApplyStockTakeResponse struct {
+	StockTake   *model.StockTake        `json:"stock_take"`
+	Adjustments []model.StockAdjustment `json:"adjustments"`
+}
+
+func NewStockTakeService(stockTakeRepo repository.StockTakeRepository, productRepo repository.ProductRepository, sellerRepo repository.SellerRepository, stock StockCacheService) StockTakeService {
+	return &stockTakeService{
+		stockTakeRepo: stockTakeRepo,
+		productRepo:   productRepo,
+		sellerRepo:    sellerRepo,
+		stock:         stock,
+	}
+}
+
+// StartStockTake opens a new stock take; a shop can only have one open at a time
+func (s *stockTakeService) StartStockTake(ctx context.Context, userID string, req StartStockTakeRequest) (*model.StockTake, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	if open, err := s.stockTakeRepo.FindOpenBySellerID(ctx, seller.ID); err == nil {
+		return nil, fmt.Errorf("stock take %s is still open; apply or cancel it first", open.ID)
+	}
+
+	take := &model.StockTake{
+		SellerID:  seller.ID,
+		StartedBy: userID,
+		Status:    model.StockTakeStatusOpen,
+		Note:      req.Note,
+	}
+	if err := s.stockTakeRepo.Create(ctx, take); err != nil {
+		return nil, errors.New("failed to start stock take: " + err.Error())
+	}
+
+	log.Printf("📋 Stock take %s started for seller %s", take.ID, seller.ID)
+	return take, nil
+}
+
+func (s *stockTakeService) GetStockTakes(ctx context.Context, userID string, page, limit int) ([]model.StockTake, int64, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, 0, errors.New("seller not found")
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	takes, total, err := s.stockTakeRepo.FindBySellerID(ctx, seller.ID, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get stock takes: " + err.Error())
+	}
+	return takes, total, nil
+}
+
+func (s *stockTakeService) GetStockTake(ctx context.Context, userID string, takeID string) (*model.StockTake, error) {
+	_, take, err := s.findOwned(ctx, userID, takeID)
+	return take, err
+}
+
+// SubmitCounts records counted quantities for products in the shop, snapshotting each product's
+// current stock as the system quantity
+func (s *stockTakeService) SubmitCounts(ctx context.Context, userID string, takeID string, req SubmitStockCountsRequest) (*model.StockTake, error) {
+	seller, take, err := s.findOwned(ctx, userID, takeID)
+	if err != nil {
+		return nil, err
+	}
+	if take.Status != model.StockTakeStatusOpen {
+		return nil, repository.ErrStockTakeNotOpen
+	}
+
+	now := time.Now()
+	counts := make([]model.StockTakeCount, 0, len(req.Counts))
+	seen := make(map[string]bool, len(req.Counts))
+	for _, item := range req.Counts {
+		var product *model.Product
+		switch {
+		case item.ProductID != "" && item.SKU == "":
+			product, err = s.productRepo.FindByID(item.ProductID)
+		case item.SKU != "" && item.ProductID == "":
+			product, err = s.productRepo.FindBySKU(strings.TrimSpace(item.SKU))
+		default:
+			return nil, errors.New("each count needs exactly one of product_id or sku")
+		}
+		if err != nil || product.SellerID != seller.ID {
+			return nil, fmt.Errorf("product not found: %s%s", item.ProductID, item.SKU)
+		}
+		if seen[product.ID] {
+			return nil, fmt.Errorf("product %s is counted twice", product.SKU)
+		}
+		seen[product.ID] = true
+
+		counts = append(counts, model.StockTakeCount{
+			StockTakeID:     take.ID,
+			ProductID:       product.ID,
+			SKU:             product.SKU,
+			ProductName:     product.Name,
+			CountedQuantity: *item.CountedQuantity,
+			SystemStock:     product.Stock,
+			CountedAt:       now,
+		})
+	}
+
+	if err := s.stockTakeRepo.UpsertCounts(ctx, counts); err != nil {
+		return nil, errors.New("failed to save counts: " + err.Error())
+	}
+	return s.stockTakeRepo.FindByID(ctx, take.ID)
+}
+
+// GetVarianceReport lists the difference between counted and system stock for every counted product
+func (s *stockTakeService) GetVarianceReport(ctx context.Context, userID string, takeID string) (*StockVarianceReport, error) {
+	seller, take, err := s.findOwned(ctx, userID, takeID)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]string, 0, len(take.Counts))
+	for _, count := range take.Counts {
+		productIDs = append(productIDs, count.ProductID)
+	}
+	products, err := s.productRepo.FindByIDsAndSellerID(productIDs, seller.ID)
+	if err != nil {
+		return nil, errors.New("failed to get products: " + err.Error())
+	}
+	prices := make(map[string]int, len(products))
+	for _, product := range products {
+		prices[product.ID] = product.Price
+	}
+
+	report := &StockVarianceReport{
+		StockTakeID:     take.ID,
+		Status:          take.Status,
+		Lines:           make([]StockVarianceLine, 0, len(take.Counts)),
+		CountedProducts: len(take.Counts),
+	}
+	for _, count := range take.Counts {
+		variance := count.CountedQuantity - count.SystemStock
+		line := StockVarianceLine{
+			ProductID:       count.ProductID,
+			SKU:             count.SKU,
+			ProductName:     count.ProductName,
+			SystemStock:     count.SystemStock,
+			CountedQuantity: count.CountedQuantity,
+			Variance:        variance,
+			Price:           prices[count.ProductID],
+			VarianceValue:   variance * prices[count.ProductID],
+		}
+		report.Lines = append(report.Lines, line)
+		report.TotalVariance += line.Variance
+		report.TotalValue += line.VarianceValue
+	}
+
+	if take.Status == model.StockTakeStatusOpen {
+		uncounted, err := s.stockTakeRepo.CountUncountedProducts(ctx, take.ID, seller.ID)
+		if err != nil {
+			return nil, errors.New("failed to count uncounted products: " + err.Error())
+		}
+		report.UncountedProducts = uncounted
+	}
+	return report, nil
+}
+
+// ApplyStockTake corrects product stock by the counted variances and closes the stock take
+func (s *stockTakeService) ApplyStockTake(ctx context.Context, userID string, takeID string) (*ApplyStockTakeResponse, error) {
+	_, take, err := s.findOwned(ctx, userID, takeID)
+	if err != nil {
+		return nil, err
+	}
+	if len(take.Counts) == 0 {
+		return nil, errors.New("no products have been counted")
+	}
+
+	adjustments, err := s.stockTakeRepo.Apply(ctx, take.ID, time.Now())
+	if err != nil {
+		if errors.Is(err, repository.ErrStockTakeNotOpen) {
+			return nil, err
+		}
+		return nil, errors.New("failed to apply stock take: " + err.Error())
+	}
+	for _, adjustment := range adjustments {
+		s.stock.Adjust(ctx, adjustment.ProductID, adjustment.Delta)
+	}
+
+	log.Printf("📋 Stock take %s applied for seller %s (%d adjustments)", take.ID, take.SellerID, len(adjustments))
+	take, err = s.stockTakeRepo.FindByID(ctx, take.ID)
+	if err != nil {
+		return nil, errors.New("failed to get stock take: " + err.Error())
+	}
+	if adjustments == nil {
+		adjustments = []model.StockAdjustment{}
+	}
+	return &ApplyStockTakeResponse{StockTake: take, Adjustments: adjustments}, nil
+}
+
+func (s *stockTakeService) CancelStockTake(ctx context.Context, userID string, takeID string) (*model.StockTake, error) {
+	_, take, err := s.findOwned(ctx, userID, takeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.stockTakeRepo.Cancel(ctx, take.ID); err != nil {
+		if errors.Is(err, repository.ErrStockTakeNotOpen) {
+			return nil, err
+		}
+		return nil, errors.New("failed to cancel stock take: " + err.Error())
+	}
+	return s.stockTakeRepo.FindByID(ctx, take.ID)
+}
+
+// findOwned loads a stock take, making sure it belongs to the user's shop
+func (s *stockTakeService) findOwned(ctx context.Context, userID string, takeID string) (*model.Seller, *model.StockTake, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, nil, errors.New("seller not found")
+	}
+	take, err := s.stockTakeRepo.FindByID(ctx, takeID)
+	if err != nil || take.SellerID != seller.ID {
+		return nil, nil, errors.New("stock take not found")
+	}
+	return seller, take, nil
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+)
+
+// Storefront visibility of a product in a preview
+const (
+	PreviewVisibilityLive      = "live"      // Buyers see it now
+	PreviewVisibilityScheduled = "scheduled" // Draft with a scheduled publish time
+	PreviewVisibilityDraft     = "draft"     // Hidden from buyers
+)
+
+// StorefrontPreviewService issues signed preview links that show a shop's storefront, drafts and
+// scheduled changes included, exactly as buyers will see it. Sellers can preview their own shop,
+// admins any shop; the link itself needs no login so it can be opened in a fresh browser.
+type StorefrontPreviewService interface {
+	CreateSellerPreviewToken(userID string) (*PreviewToken, error)
+	CreateAdminPreviewToken(adminID string, sellerID string) (*PreviewToken, error)
+	GetStorefront(token string, page, limit int) (*StorefrontPreview, error)
+	GetProduct(token string, productID string) (*PreviewProduct, error)
+}
+
+type storefrontPreviewService struct {
+	sellerRepo  repository.SellerRepository
+	productRepo repository.ProductRepository
+	jwtSecret   string
+	ttl         time.Duration
+}
+
+type PreviewToken struct {
+	Token     string    `json:"token"`
+	SellerID  string    `json:"seller_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// StorefrontPreview is a shop's storefront with every product, whatever its visibility
+type StorefrontPreview struct {
+	Shop            *model.Seller               `json:"shop"`
+	Products        []PreviewProduct            `json:"products"`
+	Total           int64                       `json:"total"`
+	Page            int                         `json:"page"`
+	Limit           int                         `json:"limit"`
+	UpcomingChanges []ScheduledVisibilityChange `json:"upcoming_changes"`
+}
+
+// PreviewProduct is a product as buyers will see it, with its current visibility
+type PreviewProduct struct {
+	model.Product
+	Visibility string `json:"visibility"` // live, scheduled, draft
+}
+
+func NewStorefrontPreviewService(sellerRepo repository.SellerRepository, productRepo repository.ProductRepository, cfg *config.Config) StorefrontPreviewService {
+	return &storefrontPreviewService{
+		sellerRepo:  sellerRepo,
+		productRepo: productRepo,
+		jwtSecret:   cfg.JWTSecret,
+		ttl:         time.Duration(cfg.PreviewTokenTTLMinutes) * time.Minute,
+	}
+}
+
+func (s *storefrontPreviewService) CreateSellerPreviewToken(userID string) (*PreviewToken, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	return s.issue(seller.ID, userID)
+}
+
+func (s *storefrontPreviewService) CreateAdminPreviewToken(adminID string, sellerID string) (*PreviewToken, error) {
+	seller, err := s.sellerRepo.FindByID(sellerID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	return s.issue(seller.ID, adminID)
+}
+
+func (s *storefrontPreviewService) GetStorefront(token string, page, limit int) (*StorefrontPreview, error) {
+	seller, err := s.authorize(token)
+	if err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	products, total, err := s.productRepo.FindBySellerID(seller.ID, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+	scheduled, err := s.productRepo.FindScheduledBySellerID(seller.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled products: %w", err)
+	}
+
+	preview := &StorefrontPreview{
+		Shop:            seller,
+		Products:        make([]PreviewProduct, 0, len(products)),
+		Total:           total,
+		Page:            page,
+		Limit:           limit,
+		UpcomingChanges: upcomingVisibilityChanges(scheduled),
+	}
+	for _, product := range products {
+		preview.Products = append(preview.Products, PreviewProduct{Product: product, Visibility: previewVisibility(&product)})
+	}
+	return preview, nil
+}
+
+func (s *storefrontPreviewService) GetProduct(token string, productID string) (*PreviewProduct, error) {
+	seller, err := s.authorize(token)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.productRepo.FindByID(productID)
+	if err != nil || product.SellerID != seller.ID {
+		return nil, errors.New("product not found")
+	}
+	return &PreviewProduct{Product: *product, Visibility: previewVisibility(product)}, nil
+}
+
+func (s *storefrontPreviewService) issue(sellerID, issuedBy string) (*PreviewToken, error) {
+	token, expiresAt, err := util.GeneratePreviewToken(sellerID, issuedBy, s.jwtSecret, s.ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create preview token: %w", err)
+	}
+	return &PreviewToken{Token: token, SellerID: sellerID, ExpiresAt: expiresAt}, nil
+}
+
+// authorize checks the preview token and loads the shop it was issued for
+func (s *storefrontPreviewService) authorize(token string) (*model.Seller, error) {
+	if token == "" {
+		return nil, errors.New("preview token is required")
+	}
+	claims, err := util.ValidatePreviewToken(token, s.jwtSecret)
+	if err != nil {
+		return nil, errors.New("invalid or expired preview token")
+	}
+	seller, err := s.sellerRepo.FindByID(claims.SellerID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	return seller, nil
+}
+
+// previewVisibility tells whether buyers can see the product now, later or not at all
+func previewVisibility(product *model.Product) string {
+	switch {
+	case product.IsActive:
+		return PreviewVisibilityLive
+	case product.ScheduledPublishAt != nil:
+		return PreviewVisibilityScheduled
+	default:
+		return PreviewVisibilityDraft
+	}
+}
@@ -0,0 +1,445 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+
+	"github.com/google/uuid"
+)
+
+// maxSupportAttachments caps how many images can be attached to one ticket message
+const maxSupportAttachments = 5
+
+// supportSLABatchSize is how many overdue tickets one SLA check flags at most
+const supportSLABatchSize = 200
+
+// supportEmailExcerpt is how much of a reply is quoted in the notification email
+const supportEmailExcerpt = 500
+
+// SupportTicketService handles buyer support tickets about orders: the buyer opens a ticket from
+// an order, admins assign and answer it, and both sides are emailed about the other's replies.
+// Tickets carry first response and resolution deadlines; a background check flags the ones that
+// are overdue.
+type SupportTicketService interface {
+	OpenTicket(ctx context.Context, userID string, orderID string, req OpenSupportTicketRequest, attachments []SupportAttachment) (*model.SupportTicket, error)
+	GetMyTickets(ctx context.Context, userID string, status string, page, limit int) ([]model.SupportTicket, int64, error)
+	GetTicket(ctx context.Context, userID string, ticketID string) (*model.SupportTicket, error)
+	// Reply adds the buyer's message; it reopens a ticket that was waiting for them or resolved
+	Reply(ctx context.Context, userID string, ticketID string, message string, attachments []SupportAttachment) (*model.SupportTicket, error)
+	Close(ctx context.Context, userID string, ticketID string) (*model.SupportTicket, error)
+
+	ListTickets(ctx context.Context, filter repository.SupportTicketFilter, page, limit int) ([]model.SupportTicket, int64, error)
+	GetTicketForAdmin(ctx context.Context, ticketID string) (*model.SupportTicket, error)
+	// Assign hands the ticket to an admin; an empty assigneeID unassigns it
+	Assign(ctx context.Context, ticketID string, assigneeID string) (*model.SupportTicket, error)
+	// AgentReply adds an admin's answer and moves the ticket to awaiting_customer
+	AgentReply(ctx context.Context, adminID string, ticketID string, message string, attachments []SupportAttachment) (*model.SupportTicket, error)
+	// SetStatus resolves, closes or reopens a ticket
+	SetStatus(ctx context.Context, ticketID string, status string) (*model.SupportTicket, error)
+
+	// CheckSLAs flags tickets that missed a deadline and returns how many breaches were recorded
+	CheckSLAs(ctx context.Context) (int, error)
+}
+
+// OpenSupportTicketRequest is the buyer's ticket form; images are uploaded alongside as multipart files
+type OpenSupportTicketRequest struct {
+	Subject  string `form:"subject" binding:"required,max=200"`
+	Category string `form:"category" binding:"required"`
+	Message  string `form:"message" binding:"required,max=5000"`
+}
+
+// SupportAttachment is an uploaded image to attach to a ticket message
+type SupportAttachment struct {
+	FileName string
+	URL      string
+}
+
+type supportTicketService struct {
+	ticketRepo       repository.SupportTicketRepository
+	orderRepo        repository.OrderRepository
+	userRepo         repository.UserRepository
+	rabbitMQ         *util.RabbitMQClient // Optional; used to email replies
+	firstResponseSLA time.Duration
+	resolutionSLA    time.Duration
+	ticketURL        string
+}
+
+func NewSupportTicketService(
+	ticketRepo repository.SupportTicketRepository,
+	orderRepo repository.OrderRepository,
+	userRepo repository.UserRepository,
+	rabbitMQ *util.RabbitMQClient,
+	cfg *config.Config,
+	jobs *util.BackgroundJobs,
+) SupportTicketService {
+	ticketURL := cfg.SupportTicketURL
+	if ticketURL == "" {
+		ticketURL = strings.TrimRight(cfg.ClientURL, "/") + "/support/tickets/"
+	}
+	service := &supportTicketService{
+		ticketRepo:       ticketRepo,
+		orderRepo:        orderRepo,
+		userRepo:         userRepo,
+		rabbitMQ:         rabbitMQ,
+		firstResponseSLA: time.Duration(cfg.SupportFirstResponseHours) * time.Hour,
+		resolutionSLA:    time.Duration(cfg.SupportResolutionHours) * time.Hour,
+		ticketURL:        ticketURL,
+	}
+
+	// Start background job to flag tickets that missed their SLA
+	if cfg.SupportSLACheckIntervalSeconds > 0 {
+		interval := time.Duration(cfg.SupportSLACheckIntervalSeconds) * time.Second
+		jobs.Every(interval, service.runSLAChecker)
+		log.Printf("✅ Support ticket SLA checker started (first response %s, resolution %s, checking every %s)",
+			service.firstResponseSLA, service.resolutionSLA, interval)
+	}
+
+	return service
+}
+
+// runSLAChecker flags overdue tickets
+func (s *supportTicketService) runSLAChecker(ctx context.Context) {
+	if _, err := s.CheckSLAs(ctx); err != nil {
+		log.Printf("⚠️  Failed to check support ticket SLAs: %v", err)
+	}
+}
+
+// OpenTicket opens a ticket about one of the buyer's orders
+func (s *supportTicketService) OpenTicket(ctx context.Context, userID string, orderID string, req OpenSupportTicketRequest, attachments []SupportAttachment) (*model.SupportTicket, error) {
+	subject := strings.TrimSpace(req.Subject)
+	if subject == "" {
+		return nil, errors.New("subject is required")
+	}
+	category := strings.ToLower(strings.TrimSpace(req.Category))
+	if !isSupportTicketCategory(category) {
+		return nil, fmt.Errorf("category must be one of: %s", strings.Join(model.SupportTicketCategories, ", "))
+	}
+	message, err := validateSupportMessage(req.Message, attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil || order.UserID != userID {
+		return nil, errors.New("order not found")
+	}
+
+	now := time.Now()
+	ticket := &model.SupportTicket{
+		ID:                 uuid.New().String(), // The first message's attachments refer to it
+		UserID:             userID,
+		OrderID:            order.ID,
+		Subject:            subject,
+		Category:           category,
+		Status:             model.SupportTicketStatusOpen,
+		FirstResponseDueAt: now.Add(s.firstResponseSLA),
+		ResolutionDueAt:    now.Add(s.resolutionSLA),
+		LastMessageAt:      now,
+	}
+	ticket.Messages = []model.SupportTicketMessage{*newSupportMessage(ticket.ID, userID, model.SupportRoleBuyer, message, attachments)}
+	if err := s.ticketRepo.Create(ctx, ticket); err != nil {
+		return nil, errors.New("failed to open ticket: " + err.Error())
+	}
+
+	log.Printf("🎫 Support ticket %s opened for order %s by user %s", ticket.TicketNumber, order.OrderNumber, userID)
+	return s.ticketRepo.FindByID(ctx, ticket.ID)
+}
+
+func (s *supportTicketService) GetMyTickets(ctx context.Context, userID string, status string, page, limit int) ([]model.SupportTicket, int64, error) {
+	page, limit = normalizeSupportPage(page, limit)
+	tickets, total, err := s.ticketRepo.FindByUserID(ctx, userID, status, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get tickets: " + err.Error())
+	}
+	return tickets, total, nil
+}
+
+func (s *supportTicketService) GetTicket(ctx context.Context, userID string, ticketID string) (*model.SupportTicket, error) {
+	ticket, err := s.ticketRepo.FindByID(ctx, ticketID)
+	if err != nil || ticket.UserID != userID {
+		return nil, errors.New("ticket not found")
+	}
+	return ticket, nil
+}
+
+func (s *supportTicketService) Reply(ctx context.Context, userID string, ticketID string, message string, attachments []SupportAttachment) (*model.SupportTicket, error) {
+	ticket, err := s.GetTicket(ctx, userID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	message, err = validateSupportMessage(message, attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"status":          model.SupportTicketStatusOpen,
+		"resolved_at":     nil,
+		"last_message_at": time.Now(),
+	}
+	entry := newSupportMessage(ticket.ID, userID, model.SupportRoleBuyer, message, attachments)
+	if err := s.ticketRepo.AddMessage(ctx, entry, updates); err != nil {
+		return nil, s.messageError(err)
+	}
+
+	if ticket.Assignee != nil {
+		s.notify(ticket, ticket.Assignee, ticket.User.FullName, message)
+	}
+	return s.ticketRepo.FindByID(ctx, ticket.ID)
+}
+
+// Close lets the buyer close a ticket they no longer need help with
+func (s *supportTicketService) Close(ctx context.Context, userID string, ticketID string) (*model.SupportTicket, error) {
+	ticket, err := s.GetTicket(ctx, userID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	return s.setStatus(ctx, ticket, model.SupportTicketStatusClosed)
+}
+
+func (s *supportTicketService) ListTickets(ctx context.Context, filter repository.SupportTicketFilter, page, limit int) ([]model.SupportTicket, int64, error) {
+	page, limit = normalizeSupportPage(page, limit)
+	tickets, total, err := s.ticketRepo.FindAll(ctx, filter, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get tickets: " + err.Error())
+	}
+	return tickets, total, nil
+}
+
+func (s *supportTicketService) GetTicketForAdmin(ctx context.Context, ticketID string) (*model.SupportTicket, error) {
+	ticket, err := s.ticketRepo.FindByID(ctx, ticketID)
+	if err != nil {
+		return nil, errors.New("ticket not found")
+	}
+	return ticket, nil
+}
+
+func (s *supportTicketService) Assign(ctx context.Context, ticketID string, assigneeID string) (*model.SupportTicket, error) {
+	ticket, err := s.GetTicketForAdmin(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{"assignee_id": nil, "assigned_at": nil}
+	if assigneeID != "" {
+		assignee, err := s.userRepo.FindByID(assigneeID)
+		if err != nil || assignee.UserType != "admin" {
+			return nil, errors.New("assignee must be an admin")
+		}
+		updates["assignee_id"] = assignee.ID
+		updates["assigned_at"] = time.Now()
+	}
+	if err := s.ticketRepo.Update(ctx, ticket.ID, updates); err != nil {
+		return nil, errors.New("failed to assign ticket: " + err.Error())
+	}
+
+	log.Printf("🎫 Support ticket %s assigned to %q", ticket.TicketNumber, assigneeID)
+	return s.ticketRepo.FindByID(ctx, ticket.ID)
+}
+
+func (s *supportTicketService) AgentReply(ctx context.Context, adminID string, ticketID string, message string, attachments []SupportAttachment) (*model.SupportTicket, error) {
+	ticket, err := s.GetTicketForAdmin(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	message, err = validateSupportMessage(message, attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"last_message_at": now}
+	if ticket.Status != model.SupportTicketStatusResolved {
+		updates["status"] = model.SupportTicketStatusAwaitingCustomer
+	}
+	if ticket.FirstRespondedAt == nil {
+		updates["first_responded_at"] = now
+	}
+	if ticket.AssigneeID == nil {
+		// Whoever answers an unassigned ticket takes it
+		updates["assignee_id"] = adminID
+		updates["assigned_at"] = now
+	}
+	entry := newSupportMessage(ticket.ID, adminID, model.SupportRoleAgent, message, attachments)
+	if err := s.ticketRepo.AddMessage(ctx, entry, updates); err != nil {
+		return nil, s.messageError(err)
+	}
+
+	if ticket.User != nil {
+		s.notify(ticket, ticket.User, "Tim Support", message)
+	}
+	return s.ticketRepo.FindByID(ctx, ticket.ID)
+}
+
+func (s *supportTicketService) SetStatus(ctx context.Context, ticketID string, status string) (*model.SupportTicket, error) {
+	ticket, err := s.GetTicketForAdmin(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	return s.setStatus(ctx, ticket, status)
+}
+
+func (s *supportTicketService) setStatus(ctx context.Context, ticket *model.SupportTicket, status string) (*model.SupportTicket, error) {
+	if ticket.Status == model.SupportTicketStatusClosed {
+		return nil, repository.ErrSupportTicketClosed
+	}
+
+	updates := map[string]interface{}{"status": status}
+	switch status {
+	case model.SupportTicketStatusOpen:
+		updates["resolved_at"] = nil
+	case model.SupportTicketStatusResolved:
+		updates["resolved_at"] = time.Now()
+	case model.SupportTicketStatusClosed:
+		updates["closed_at"] = time.Now()
+		if ticket.ResolvedAt == nil {
+			updates["resolved_at"] = time.Now()
+		}
+	default:
+		return nil, errors.New("status must be one of: open, resolved, closed")
+	}
+	if err := s.ticketRepo.Update(ctx, ticket.ID, updates); err != nil {
+		return nil, errors.New("failed to update ticket: " + err.Error())
+	}
+
+	log.Printf("🎫 Support ticket %s moved from %s to %s", ticket.TicketNumber, ticket.Status, status)
+	return s.ticketRepo.FindByID(ctx, ticket.ID)
+}
+
+// CheckSLAs flags tickets without an agent's answer past the first response deadline, and open
+// tickets past the resolution deadline. Time spent waiting for the buyer is not held against the
+// resolution deadline until the buyer replies.
+func (s *supportTicketService) CheckSLAs(ctx context.Context) (int, error) {
+	now := time.Now()
+	tickets, err := s.ticketRepo.FindSLABreaches(ctx, now, supportSLABatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	breaches := 0
+	for i := range tickets {
+		ticket := &tickets[i]
+		if !ticket.FirstResponseBreached && ticket.FirstRespondedAt == nil && ticket.FirstResponseDueAt.Before(now) {
+			breaches += s.markBreached(ctx, ticket, "first_response", ticket.FirstResponseDueAt)
+		}
+		if !ticket.ResolutionBreached && ticket.ResolutionDueAt.Before(now) {
+			breaches += s.markBreached(ctx, ticket, "resolution", ticket.ResolutionDueAt)
+		}
+	}
+
+	if breaches > 0 {
+		log.Printf("⏰ Flagged %d support ticket SLA breach(es)", breaches)
+	}
+	return breaches, nil
+}
+
+func (s *supportTicketService) markBreached(ctx context.Context, ticket *model.SupportTicket, deadline string, dueAt time.Time) int {
+	marked, err := s.ticketRepo.MarkBreached(ctx, ticket.ID, deadline)
+	if err != nil {
+		log.Printf("⚠️  Failed to flag %s SLA breach of ticket %s: %v", deadline, ticket.TicketNumber, err)
+		return 0
+	}
+	if !marked {
+		return 0
+	}
+	assignee := "unassigned"
+	if ticket.Assignee != nil {
+		assignee = ticket.Assignee.Email
+	}
+	log.Printf("⏰ Support ticket %s missed its %s deadline of %s (%s)", ticket.TicketNumber, deadline, dueAt.Format(time.RFC3339), assignee)
+	return 1
+}
+
+// notify emails the recipient about a new reply on the ticket
+func (s *supportTicketService) notify(ticket *model.SupportTicket, recipient *model.User, sender string, message string) {
+	if s.rabbitMQ == nil {
+		log.Printf("Warning: RabbitMQ not available, reply on ticket %s not emailed", ticket.TicketNumber)
+		return
+	}
+	if recipient.Email == "" {
+		return
+	}
+	if excerpt := []rune(message); len(excerpt) > supportEmailExcerpt {
+		message = string(excerpt[:supportEmailExcerpt]) + "..."
+	}
+
+	emailMsg := util.EmailMessage{
+		To:      recipient.Email,
+		Subject: fmt.Sprintf("Balasan Tiket %s - %s", ticket.TicketNumber, ticket.Subject),
+		Type:    "support_ticket_reply",
+		Data: map[string]string{
+			"name":          recipient.FullName,
+			"ticket_number": ticket.TicketNumber,
+			"subject":       ticket.Subject,
+			"sender":        sender,
+			"message":       message,
+			"ticket_url":    s.ticketURL + ticket.ID,
+		},
+	}
+	if err := s.rabbitMQ.PublishEmail(emailMsg); err != nil {
+		log.Printf("Failed to publish reply email for ticket %s: %v", ticket.TicketNumber, err)
+	}
+}
+
+func (s *supportTicketService) messageError(err error) error {
+	if errors.Is(err, repository.ErrSupportTicketClosed) {
+		return err
+	}
+	return errors.New("failed to add message: " + err.Error())
+}
+
+func normalizeSupportPage(page, limit int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	return page, limit
+}
+
+func isSupportTicketCategory(category string) bool {
+	for _, c := range model.SupportTicketCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+func validateSupportMessage(message string, attachments []SupportAttachment) (string, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return "", errors.New("message is required")
+	}
+	if len(attachments) > maxSupportAttachments {
+		return "", fmt.Errorf("at most %d attachments can be added to a message", maxSupportAttachments)
+	}
+	return message, nil
+}
+
+func newSupportMessage(ticketID, senderID, role, message string, attachments []SupportAttachment) *model.SupportTicketMessage {
+	entry := &model.SupportTicketMessage{
+		TicketID:   ticketID,
+		SenderID:   senderID,
+		SenderRole: role,
+		Message:    message,
+	}
+	for i, attachment := range attachments {
+		entry.Attachments = append(entry.Attachments, model.SupportTicketAttachment{
+			TicketID:  ticketID,
+			FileName:  attachment.FileName,
+			URL:       attachment.URL,
+			SortOrder: i,
+		})
+	}
+	return entry
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// Product tag limits
+const (
+	maxTagsPerProduct = 10
+	minTagLength      = 2
+	maxTagLength      = 30
+)
+
+// TagService manages product tags and tag-based browsing
+type TagService interface {
+	GetProductTags(ctx context.Context, productID string) ([]model.Tag, error)
+	SetProductTags(ctx context.Context, userID string, productID string, names []string) ([]model.Tag, error)
+	AddProductTag(ctx context.Context, userID string, productID string, name string) ([]model.Tag, error)
+	RemoveProductTag(ctx context.Context, userID string, productID string, name string) ([]model.Tag, error)
+	GetProductsByTag(ctx context.Context, name string, page, limit int) (*ProductListResponse, error)
+	SuggestTags(ctx context.Context, prefix string, limit int) ([]repository.TagSuggestion, error)
+}
+
+type tagService struct {
+	tagRepo     repository.TagRepository
+	productRepo repository.ProductRepository
+	sellerRepo  repository.SellerRepository
+}
+
+type SetProductTagsRequest struct {
+	Tags []string `json:"tags"` // Replaces all tags; empty clears them
+}
+
+type AddProductTagRequest struct {
+	Tag string `json:"tag" binding:"required"`
+}
+
+func NewTagService(tagRepo repository.TagRepository, productRepo repository.ProductRepository, sellerRepo repository.SellerRepository) TagService {
+	return &tagService{
+		tagRepo:     tagRepo,
+		productRepo: productRepo,
+		sellerRepo:  sellerRepo,
+	}
+}
+
+func (s *tagService) GetProductTags(ctx context.Context, productID string) ([]model.Tag, error) {
+	if _, err := s.productRepo.FindByID(productID); err != nil {
+		return nil, errors.New("product not found")
+	}
+	return s.tagRepo.FindByProductID(ctx, productID)
+}
+
+// SetProductTags replaces the tags of a product in the user's shop
+func (s *tagService) SetProductTags(ctx context.Context, userID string, productID string, names []string) ([]model.Tag, error) {
+	if err := s.checkOwner(userID, productID); err != nil {
+		return nil, err
+	}
+
+	normalized := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, raw := range names {
+		name, err := normalizeTag(raw)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+	if len(normalized) > maxTagsPerProduct {
+		return nil, fmt.Errorf("a product can have at most %d tags", maxTagsPerProduct)
+	}
+
+	tags, err := s.tagRepo.FindOrCreate(ctx, normalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save tags: %w", err)
+	}
+	if err := s.tagRepo.ReplaceProductTags(ctx, productID, tags); err != nil {
+		return nil, fmt.Errorf("failed to save tags: %w", err)
+	}
+	return s.tagRepo.FindByProductID(ctx, productID)
+}
+
+// AddProductTag adds one tag to a product in the user's shop; adding a tag it already has is a no-op
+func (s *tagService) AddProductTag(ctx context.Context, userID string, productID string, name string) ([]model.Tag, error) {
+	if err := s.checkOwner(userID, productID); err != nil {
+		return nil, err
+	}
+	name, err := normalizeTag(name)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.tagRepo.FindByProductID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	names := make([]string, 0, len(current)+1)
+	for _, tag := range current {
+		if tag.Name == name {
+			return current, nil
+		}
+		names = append(names, tag.Name)
+	}
+	if len(current) >= maxTagsPerProduct {
+		return nil, fmt.Errorf("a product can have at most %d tags", maxTagsPerProduct)
+	}
+
+	tags, err := s.tagRepo.FindOrCreate(ctx, append(names, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save tags: %w", err)
+	}
+	if err := s.tagRepo.ReplaceProductTags(ctx, productID, tags); err != nil {
+		return nil, fmt.Errorf("failed to save tags: %w", err)
+	}
+	return s.tagRepo.FindByProductID(ctx, productID)
+}
+
+// RemoveProductTag removes one tag from a product in the user's shop
+func (s *tagService) RemoveProductTag(ctx context.Context, userID string, productID string, name string) ([]model.Tag, error) {
+	if err := s.checkOwner(userID, productID); err != nil {
+		return nil, err
+	}
+	name, err := normalizeTag(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := s.tagRepo.FindByName(ctx, name)
+	if err != nil {
+		return nil, errors.New("tag not found")
+	}
+	if err := s.tagRepo.RemoveProductTag(ctx, productID, tag); err != nil {
+		return nil, fmt.Errorf("failed to remove tag: %w", err)
+	}
+	return s.tagRepo.FindByProductID(ctx, productID)
+}
+
+// GetProductsByTag lists active products carrying the tag
+func (s *tagService) GetProductsByTag(ctx context.Context, name string, page, limit int) (*ProductListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	empty := &ProductListResponse{Products: []model.Product{}, Total: 0, Page: page, Limit: limit}
+	name, err := normalizeTag(name)
+	if err != nil {
+		return empty, nil
+	}
+	tag, err := s.tagRepo.FindByName(ctx, name)
+	if err != nil {
+		return empty, nil
+	}
+
+	products, total, err := s.tagRepo.FindProductsByTagID(ctx, tag.ID, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+	return &ProductListResponse{
+		Products: products,
+		Total:    total,
+		Page:     page,
+		Limit:    limit,
+	}, nil
+}
+
+// SuggestTags returns tags starting with prefix, ranked by how many live products use them.
+// An empty prefix returns the most popular tags overall.
+func (s *tagService) SuggestTags(ctx context.Context, prefix string, limit int) ([]repository.TagSuggestion, error) {
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+	prefix = strings.Trim(tagSlug(prefix), "-")
+
+	suggestions, err := s.tagRepo.Suggest(ctx, prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag suggestions: %w", err)
+	}
+	if suggestions == nil {
+		suggestions = []repository.TagSuggestion{}
+	}
+	return suggestions, nil
+}
+
+// checkOwner makes sure the product belongs to the user's shop
+func (s *tagService) checkOwner(userID string, productID string) error {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return errors.New("seller not found. Please create a shop first")
+	}
+	product, err := s.productRepo.FindByID(productID)
+	if err != nil || product.SellerID != seller.ID {
+		return errors.New("product not found")
+	}
+	return nil
+}
+
+// normalizeTag turns user input like "#Summer Sale" into "summer-sale"
+func normalizeTag(raw string) (string, error) {
+	name := strings.Trim(tagSlug(raw), "-")
+	if len(name) < minTagLength || len(name) > maxTagLength {
+		return "", fmt.Errorf("tag %q must be %d to %d letters or digits", strings.TrimSpace(raw), minTagLength, maxTagLength)
+	}
+	return name, nil
+}
+
+// tagSlug lowercases s, joins words with single hyphens and drops anything but letters and digits
+func tagSlug(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			b.WriteRune(r)
+			hyphen = false
+		case r == ' ' || r == '-' || r == '_':
+			if !hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+				hyphen = true
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+	"yourapp/internal/util"
+)
+
+// unpaidOrderBatchSize is how many unpaid orders one pass cancels at most
+const unpaidOrderBatchSize = 100
+
+// unpaidOrderCancelReason is stored on orders cancelled because they were never paid
+const unpaidOrderCancelReason = "Payment not received in time"
+
+// UnpaidOrderService cancels orders that stay pending without a payment, so their reserved
+// stock goes back on sale
+type UnpaidOrderService interface {
+	// CancelUnpaidOrders cancels overdue unpaid orders and returns how many were cancelled
+	CancelUnpaidOrders(ctx context.Context) (int, error)
+}
+
+type unpaidOrderService struct {
+	orderRepo      repository.OrderRepository
+	paymentService PaymentService
+	stock          StockCacheService
+	rabbitMQ       *util.RabbitMQClient // Optional; used to notify buyers
+	timeout        time.Duration
+}
+
+func NewUnpaidOrderService(
+	orderRepo repository.OrderRepository,
+	paymentService PaymentService,
+	stock StockCacheService,
+	rabbitMQ *util.RabbitMQClient,
+	cfg *config.Config,
+	jobs *util.BackgroundJobs,
+) UnpaidOrderService {
+	service := &unpaidOrderService{
+		orderRepo:      orderRepo,
+		paymentService: paymentService,
+		stock:          stock,
+		rabbitMQ:       rabbitMQ,
+		timeout:        time.Duration(cfg.UnpaidOrderTimeoutMinutes) * time.Minute,
+	}
+
+	// Start background job to cancel unpaid orders
+	if service.timeout > 0 && cfg.UnpaidOrderCheckIntervalSeconds > 0 {
+		interval := time.Duration(cfg.UnpaidOrderCheckIntervalSeconds) * time.Second
+		jobs.Every(interval, service.runUnpaidOrderCanceller)
+		log.Printf("✅ Unpaid order canceller started (orders unpaid after %s, checking every %s)", service.timeout, interval)
+	}
+
+	return service
+}
+
+// runUnpaidOrderCanceller cancels unpaid orders
+func (s *unpaidOrderService) runUnpaidOrderCanceller(ctx context.Context) {
+	if _, err := s.CancelUnpaidOrders(ctx); err != nil {
+		log.Printf("⚠️  Failed to cancel unpaid orders: %v", err)
+	}
+}
+
+func (s *unpaidOrderService) CancelUnpaidOrders(ctx context.Context) (int, error) {
+	now := time.Now()
+	orders, err := s.orderRepo.FindUnpaidPendingBefore(ctx, now.Add(-s.timeout), now, unpaidOrderBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	for i := range orders {
+		order := &orders[i]
+
+		// Void the gateway transaction first so a late payment cannot land on a cancelled order
+		if err := s.paymentService.CancelPaymentForOrder(ctx, order.ID); err != nil {
+			log.Printf("⚠️  Skipping auto-cancel of order %s: %v", order.OrderNumber, err)
+			continue
+		}
+
+		change := model.StatusChange{ActorType: model.StatusActorSystem, Note: unpaidOrderCancelReason}
+		if err := s.orderRepo.CancelAndRestoreStock(ctx, order.ID, unpaidOrderCancelReason, time.Now(), change); err != nil {
+			if !errors.Is(err, repository.ErrOrderNotPending) {
+				log.Printf("⚠️  Failed to auto-cancel order %s: %v", order.OrderNumber, err)
+			}
+			continue
+		}
+		s.stock.Release(ctx, orderQuantities(order))
+		cancelled++
+
+		log.Printf("⏰ Order %s cancelled: unpaid since %s", order.OrderNumber, order.CreatedAt.Format(time.RFC3339))
+		s.notifyBuyer(order)
+	}
+
+	if cancelled > 0 {
+		log.Printf("🧹 Auto-cancelled %d unpaid order(s)", cancelled)
+	}
+	return cancelled, nil
+}
+
+// notifyBuyer emails the buyer that their order was cancelled for non-payment
+func (s *unpaidOrderService) notifyBuyer(order *model.Order) {
+	if s.rabbitMQ == nil {
+		log.Printf("Warning: RabbitMQ not available, buyer of order %s not notified about cancellation", order.OrderNumber)
+		return
+	}
+	if order.User.Email == "" {
+		return
+	}
+
+	emailMsg := util.EmailMessage{
+		To:      order.User.Email,
+		Subject: "Pesanan Dibatalkan - " + order.OrderNumber,
+		Type:    "order_auto_cancelled",
+		Data: map[string]string{
+			"name":         order.User.FullName,
+			"order_number": order.OrderNumber,
+			"total_amount": strconv.Itoa(order.TotalAmount),
+			"created_at":   order.CreatedAt.Format("02 Jan 2006 15:04"),
+		},
+	}
+	if err := s.rabbitMQ.PublishEmail(emailMsg); err != nil {
+		log.Printf("Failed to publish order cancelled email for order %s: %v", order.OrderNumber, err)
+	}
+}
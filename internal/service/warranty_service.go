@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"yourapp/internal/config"
+	"yourapp/internal/model"
+	"yourapp/internal/repository"
+)
+
+// warrantyClaimTransitions are the statuses a seller can move a warranty claim to from each status
+var warrantyClaimTransitions = map[string][]string{
+	model.WarrantyClaimSubmitted: {model.WarrantyClaimInReview, model.WarrantyClaimApproved, model.WarrantyClaimRejected},
+	model.WarrantyClaimInReview:  {model.WarrantyClaimApproved, model.WarrantyClaimRejected},
+	model.WarrantyClaimApproved:  {model.WarrantyClaimCompleted},
+}
+
+// WarrantyService activates the warranties buyers paid warranty protection for once their order
+// is delivered, and tracks the claims filed under them. Sellers handle the claims for their items.
+type WarrantyService interface {
+	// OnOrderStatusChange activates the warranties of an order that was just delivered
+	OnOrderStatusChange(ctx context.Context, order *model.Order, from string) error
+
+	GetMyWarranties(ctx context.Context, userID string, activeOnly bool, page, limit int) ([]model.Warranty, int64, error)
+	GetWarranty(ctx context.Context, userID string, warrantyID string) (*model.Warranty, error)
+	FileClaim(ctx context.Context, userID string, warrantyID string, req FileWarrantyClaimRequest) (*model.WarrantyClaim, error)
+	GetMyClaims(ctx context.Context, userID string, status string, page, limit int) ([]model.WarrantyClaim, int64, error)
+	// GetClaim returns a claim with its status history to its buyer or seller
+	GetClaim(ctx context.Context, userID string, claimID string) (*model.WarrantyClaim, error)
+
+	GetSellerClaims(ctx context.Context, userID string, status string, page, limit int) ([]model.WarrantyClaim, int64, error)
+	UpdateClaimStatus(ctx context.Context, userID string, claimID string, req UpdateWarrantyClaimRequest) (*model.WarrantyClaim, error)
+}
+
+type warrantyService struct {
+	warrantyRepo  repository.WarrantyRepository
+	sellerRepo    repository.SellerRepository
+	defaultMonths int
+}
+
+type FileWarrantyClaimRequest struct {
+	Description string `json:"description" binding:"required,max=2000"`
+}
+
+type UpdateWarrantyClaimRequest struct {
+	Status string `json:"status" binding:"required,oneof=in_review approved rejected completed"`
+	Note   string `json:"note" binding:"max=2000"` // Required to reject
+}
+
+func NewWarrantyService(warrantyRepo repository.WarrantyRepository, sellerRepo repository.SellerRepository, cfg *config.Config) WarrantyService {
+	return &warrantyService{
+		warrantyRepo:  warrantyRepo,
+		sellerRepo:    sellerRepo,
+		defaultMonths: cfg.WarrantyDefaultMonths,
+	}
+}
+
+// OnOrderStatusChange gives every physical item of a delivered order with warranty protection a
+// warranty running from the delivery for its product's warranty months
+func (s *warrantyService) OnOrderStatusChange(ctx context.Context, order *model.Order, from string) error {
+	if order.Status != "delivered" || from == "delivered" || order.WarrantyCost <= 0 {
+		return nil
+	}
+
+	startsAt := time.Now()
+	if order.DeliveredAt != nil {
+		startsAt = *order.DeliveredAt
+	}
+	var warranties []model.Warranty
+	for _, item := range order.OrderItems {
+		if item.Product.IsDigital || item.Status == model.OrderItemStatusReturned {
+			continue
+		}
+		months := item.Product.WarrantyMonths
+		if months <= 0 {
+			months = s.defaultMonths
+		}
+		if months <= 0 {
+			continue
+		}
+		warranties = append(warranties, model.Warranty{
+			OrderID:     order.ID,
+			OrderItemID: item.ID,
+			UserID:      order.UserID,
+			SellerID:    item.SellerID,
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			VariantName: item.VariantName,
+			Quantity:    item.Quantity,
+			Months:      months,
+			Terms:       item.Product.WarrantyTerms,
+			StartsAt:    startsAt,
+			ExpiresAt:   startsAt.AddDate(0, months, 0),
+		})
+	}
+
+	created, err := s.warrantyRepo.CreateForOrder(ctx, warranties)
+	if err != nil {
+		return fmt.Errorf("failed to activate warranties: %w", err)
+	}
+	if created > 0 {
+		log.Printf("🛡️  Activated %d warranty(ies) for delivered order %s", created, order.OrderNumber)
+	}
+	return nil
+}
+
+func (s *warrantyService) GetMyWarranties(ctx context.Context, userID string, activeOnly bool, page, limit int) ([]model.Warranty, int64, error) {
+	warranties, total, err := s.warrantyRepo.FindByUserID(ctx, userID, activeOnly, time.Now(), page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get warranties: " + err.Error())
+	}
+	return warranties, total, nil
+}
+
+func (s *warrantyService) GetWarranty(ctx context.Context, userID string, warrantyID string) (*model.Warranty, error) {
+	warranty, err := s.warrantyRepo.FindByID(ctx, warrantyID)
+	if err != nil || warranty.UserID != userID {
+		return nil, errors.New("warranty not found")
+	}
+	return warranty, nil
+}
+
+// FileClaim files a claim under an active warranty; a warranty has one claim handled at a time
+func (s *warrantyService) FileClaim(ctx context.Context, userID string, warrantyID string, req FileWarrantyClaimRequest) (*model.WarrantyClaim, error) {
+	description := strings.TrimSpace(req.Description)
+	if description == "" {
+		return nil, errors.New("description is required")
+	}
+	warranty, err := s.GetWarranty(ctx, userID, warrantyID)
+	if err != nil {
+		return nil, err
+	}
+	if !warranty.IsActive(time.Now()) {
+		return nil, fmt.Errorf("the warranty expired on %s", warranty.ExpiresAt.Format("2006-01-02"))
+	}
+
+	claim := &model.WarrantyClaim{
+		WarrantyID:  warranty.ID,
+		UserID:      userID,
+		SellerID:    warranty.SellerID,
+		Description: description,
+		Status:      model.WarrantyClaimSubmitted,
+	}
+	event := &model.WarrantyClaimEvent{
+		Status:    model.WarrantyClaimSubmitted,
+		ActorType: model.StatusActorBuyer,
+		ActorID:   userID,
+	}
+	if err := s.warrantyRepo.CreateClaim(ctx, claim, event); err != nil {
+		if errors.Is(err, repository.ErrWarrantyClaimOpen) {
+			return nil, err
+		}
+		return nil, errors.New("failed to file warranty claim: " + err.Error())
+	}
+
+	log.Printf("🛡️  Warranty claim %s filed for %s under warranty %s", claim.ID, warranty.ProductName, warranty.ID)
+	return s.warrantyRepo.FindClaimByID(ctx, claim.ID)
+}
+
+func (s *warrantyService) GetMyClaims(ctx context.Context, userID string, status string, page, limit int) ([]model.WarrantyClaim, int64, error) {
+	claims, total, err := s.warrantyRepo.FindClaimsByUserID(ctx, userID, status, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get warranty claims: " + err.Error())
+	}
+	return claims, total, nil
+}
+
+func (s *warrantyService) GetClaim(ctx context.Context, userID string, claimID string) (*model.WarrantyClaim, error) {
+	claim, err := s.warrantyRepo.FindClaimByID(ctx, claimID)
+	if err != nil {
+		return nil, errors.New("warranty claim not found")
+	}
+	if claim.UserID == userID {
+		return claim, nil
+	}
+	if seller, err := s.sellerRepo.FindByUserID(userID); err == nil && seller.ID == claim.SellerID {
+		return claim, nil
+	}
+	return nil, errors.New("warranty claim not found")
+}
+
+func (s *warrantyService) GetSellerClaims(ctx context.Context, userID string, status string, page, limit int) ([]model.WarrantyClaim, int64, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, 0, errors.New("seller not found")
+	}
+	claims, total, err := s.warrantyRepo.FindClaimsBySellerID(ctx, seller.ID, status, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to get warranty claims: " + err.Error())
+	}
+	return claims, total, nil
+}
+
+// UpdateClaimStatus moves a claim on for the seller of its item: into review, approved or
+// rejected, and an approved claim to completed once the item was repaired or replaced
+func (s *warrantyService) UpdateClaimStatus(ctx context.Context, userID string, claimID string, req UpdateWarrantyClaimRequest) (*model.WarrantyClaim, error) {
+	seller, err := s.sellerRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("seller not found")
+	}
+	claim, err := s.warrantyRepo.FindClaimByID(ctx, claimID)
+	if err != nil || claim.SellerID != seller.ID {
+		return nil, errors.New("warranty claim not found")
+	}
+
+	allowed := false
+	for _, to := range warrantyClaimTransitions[claim.Status] {
+		allowed = allowed || to == req.Status
+	}
+	if !allowed {
+		return nil, fmt.Errorf("a %s claim cannot be moved to %s", claim.Status, req.Status)
+	}
+	note := strings.TrimSpace(req.Note)
+	if req.Status == model.WarrantyClaimRejected && note == "" {
+		return nil, errors.New("a note is required to reject a claim")
+	}
+
+	updates := map[string]interface{}{}
+	event := &model.WarrantyClaimEvent{
+		Status:    req.Status,
+		ActorType: model.StatusActorSeller,
+		ActorID:   userID,
+	}
+	if note != "" {
+		updates["resolution"] = note
+		event.Note = &note
+	}
+	if req.Status == model.WarrantyClaimRejected || req.Status == model.WarrantyClaimCompleted {
+		updates["resolved_at"] = time.Now()
+	}
+	if err := s.warrantyRepo.UpdateClaimStatus(ctx, claim.ID, claim.Status, updates, event); err != nil {
+		if errors.Is(err, repository.ErrWarrantyClaimChanged) {
+			return nil, err
+		}
+		return nil, errors.New("failed to update warranty claim: " + err.Error())
+	}
+
+	log.Printf("🛡️  Warranty claim %s moved from %s to %s by seller %s", claim.ID, claim.Status, req.Status, seller.ID)
+	return s.warrantyRepo.FindClaimByID(ctx, claim.ID)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"yourapp/internal/model"
+)
+
+// MoveToWishlist takes a cart item out of the cart and puts its product on the user's wishlist,
+// and returns the updated cart
+func (s *cartService) MoveToWishlist(ctx context.Context, userID string, cartItemID string) (*CartView, error) {
+	cart, err := s.cartRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, errors.New("cart not found")
+	}
+
+	cartItem, err := s.cartRepo.GetCartItemByID(cartItemID)
+	if err != nil {
+		return nil, errors.New("cart item not found")
+	}
+	if cartItem.CartID != cart.ID {
+		return nil, errors.New("unauthorized")
+	}
+
+	if err := s.wishlistRepo.MoveFromCart(ctx, userID, cartItem); err != nil {
+		return nil, errors.New("failed to move item to wishlist: " + err.Error())
+	}
+	s.cartRepo.Invalidate(userID)
+
+	log.Printf("💝 Cart item %s moved to the wishlist of user %s", cartItem.ID, userID)
+	return s.GetCart(ctx, userID)
+}
+
+// GetWishlist lists the user's wishlist, most recently added first
+func (s *cartService) GetWishlist(ctx context.Context, userID string) ([]model.WishlistItem, error) {
+	return s.wishlistRepo.FindByUserID(ctx, userID)
+}
+
+// AddToWishlist puts a product on the user's wishlist; adding it again is a no-op
+func (s *cartService) AddToWishlist(ctx context.Context, userID string, productID string) error {
+	product, err := s.productRepo.FindByID(productID)
+	if err != nil {
+		return errors.New("product not found")
+	}
+	if !product.IsActive {
+		return errors.New("product is not available")
+	}
+
+	item := &model.WishlistItem{UserID: userID, ProductID: product.ID, Price: product.Price}
+	if err := s.wishlistRepo.Add(ctx, item); err != nil {
+		return errors.New("failed to add to wishlist: " + err.Error())
+	}
+	return nil
+}
+
+// RemoveFromWishlist deletes an item from the user's wishlist
+func (s *cartService) RemoveFromWishlist(ctx context.Context, userID string, wishlistItemID string) error {
+	item, err := s.wishlistRepo.FindByID(ctx, wishlistItemID)
+	if err != nil || item.UserID != userID {
+		return errors.New("wishlist item not found")
+	}
+	return s.wishlistRepo.Delete(ctx, item.ID)
+}
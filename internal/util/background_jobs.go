@@ -0,0 +1,65 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BackgroundJobs runs the periodic jobs that services start from their constructors, so they can
+// all be stopped together when the server shuts down
+type BackgroundJobs struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewBackgroundJobs() *BackgroundJobs {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &BackgroundJobs{ctx: ctx, cancel: cancel}
+}
+
+// Go runs fn in its own goroutine. fn must return once ctx is cancelled.
+func (j *BackgroundJobs) Go(fn func(ctx context.Context)) {
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		fn(j.ctx)
+	}()
+}
+
+// Every runs job every interval until shutdown. The first run is one interval from now.
+func (j *BackgroundJobs) Every(interval time.Duration, job func(ctx context.Context)) {
+	j.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				job(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// Shutdown stops the jobs and waits for runs in progress to finish
+func (j *BackgroundJobs) Shutdown(ctx context.Context) error {
+	j.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		j.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background jobs shutdown: %w", ctx.Err())
+	}
+}
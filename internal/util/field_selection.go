@@ -0,0 +1,168 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FieldSet is the whitelist of fields a resource's ?fields= may select. A top-level key keeps the
+// whole value; a nested path such as "seller.shop_name" keeps only that part of the object (for
+// arrays, of each element). The resource's id is always kept.
+type FieldSet struct {
+	Resource string   // Named in error messages
+	ListKey  string   // Key of the resource array in list responses, e.g. "products"
+	Fields   []string // Selectable key paths
+}
+
+// ProductFieldSet is selectable on product endpoints
+var ProductFieldSet = FieldSet{
+	Resource: "product",
+	ListKey:  "products",
+	Fields: []string{
+		"id", "seller_id", "category_id", "name", "description", "sku", "price", "stock", "weight",
+		"thumbnail", "is_active", "is_featured", "max_order_quantity", "is_digital", "created_at", "updated_at",
+		"warranty_months", "warranty_terms",
+		"seller", "seller.id", "seller.shop_name", "seller.shop_slug", "seller.shop_logo", "seller.shop_city",
+		"seller.is_verified", "seller.rating_average",
+		"category", "category.id", "category.name", "category.slug",
+		"images", "images.image_url", "tags",
+		"variants", "variants.id", "variants.sku", "variants.name", "variants.size", "variants.color",
+		"variants.price", "variants.stock", "variants.is_active",
+	},
+}
+
+// OrderFieldSet is selectable on the buyer's order endpoints
+var OrderFieldSet = FieldSet{
+	Resource: "order",
+	ListKey:  "orders",
+	Fields: []string{
+		"id", "order_number", "status", "subtotal", "shipping_cost", "total_discount", "tax_amount",
+		"total_amount", "notes", "is_gift", "courier", "tracking_number", "estimated_ship_date",
+		"shipped_at", "delivered_at", "cancelled_at", "archived_at", "created_at", "updated_at",
+		"shipping_address",
+		"order_items", "order_items.id", "order_items.product_id", "order_items.seller_id",
+		"order_items.product_name", "order_items.quantity", "order_items.price", "order_items.subtotal",
+		"order_items.status", "order_items.product.thumbnail", "order_items.variant_id", "order_items.variant_name",
+		"seller_orders", "payment", "payment.status", "payment.payment_type", "payment.expiry_time",
+	},
+}
+
+// SellerFieldSet is selectable on shop endpoints
+var SellerFieldSet = FieldSet{
+	Resource: "seller",
+	ListKey:  "sellers",
+	Fields: []string{
+		"id", "shop_name", "shop_slug", "shop_description", "shop_logo", "shop_banner", "shop_city",
+		"shop_province", "is_verified", "is_active", "total_products", "total_sales", "rating_average",
+		"total_reviews", "handling_days", "min_order_amount", "created_at",
+	},
+}
+
+// fieldSelectionKey holds the parsed selection in the gin context
+const fieldSelectionKey = "fieldSelection"
+
+type fieldSelection struct {
+	listKey string
+	paths   [][]string
+}
+
+// ParseFields checks a comma-separated ?fields= value against the whitelist
+func (fs FieldSet) ParseFields(value string) ([][]string, error) {
+	var paths [][]string
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] {
+			continue
+		}
+		if !fs.allows(field) {
+			return nil, fmt.Errorf("unknown %s field %q; selectable: %s", fs.Resource, field, strings.Join(fs.Fields, ","))
+		}
+		seen[field] = true
+		paths = append(paths, strings.Split(field, "."))
+	}
+	if !seen["id"] {
+		paths = append(paths, []string{"id"})
+	}
+	return paths, nil
+}
+
+func (fs FieldSet) allows(field string) bool {
+	for _, f := range fs.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// SetFieldSelection makes SuccessResponse keep only the given paths of the resource(s)
+func SetFieldSelection(c *gin.Context, fs FieldSet, paths [][]string) {
+	c.Set(fieldSelectionKey, fieldSelection{listKey: fs.ListKey, paths: paths})
+}
+
+// SelectResponseFields applies the request's field selection, if any. In list responses only the
+// items under the resource's list key are trimmed, so totals and paging stay.
+func SelectResponseFields(c *gin.Context, data interface{}) interface{} {
+	value, ok := c.Get(fieldSelectionKey)
+	if !ok || data == nil {
+		return data
+	}
+	selection := value.(fieldSelection)
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return data // Let the response writer report the encoding error
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return data
+	}
+
+	if envelope, ok := generic.(map[string]interface{}); ok {
+		if items, ok := envelope[selection.listKey].([]interface{}); ok {
+			keepFields(items, selection.paths)
+			return envelope
+		}
+	}
+	keepFields(generic, selection.paths)
+	return generic
+}
+
+// keepFields deletes the object keys not on one of the paths, descending into arrays
+func keepFields(value interface{}, paths [][]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			var nested [][]string
+			whole := false
+			for _, path := range paths {
+				if path[0] != key {
+					continue
+				}
+				if len(path) == 1 {
+					whole = true
+					break
+				}
+				nested = append(nested, path[1:])
+			}
+			switch {
+			case whole:
+			case len(nested) > 0:
+				keepFields(child, nested)
+			default:
+				delete(v, key)
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			keepFields(child, paths)
+		}
+	}
+}
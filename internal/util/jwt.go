@@ -67,3 +67,160 @@ func ValidateToken(tokenString, secret string) (*JWTClaims, error) {
 
 	return nil, errors.New("invalid token")
 }
+
+// PreviewClaims authorize viewing one shop's storefront as buyers will see it, drafts included
+type PreviewClaims struct {
+	SellerID string `json:"sellerId"`
+	IssuedBy string `json:"issuedBy"` // User (seller or admin) who created the preview link
+	jwt.RegisteredClaims
+}
+
+// previewSecret derives the preview signing key so preview and login tokens can never stand in
+// for each other
+func previewSecret(secret string) []byte {
+	return []byte(secret + ":storefront-preview")
+}
+
+// GeneratePreviewToken generates a storefront preview token for a shop
+func GeneratePreviewToken(sellerID, issuedBy, secret string, expiresIn time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(expiresIn)
+	claims := PreviewClaims{
+		SellerID: sellerID,
+		IssuedBy: issuedBy,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "yourapp",
+			Subject:   sellerID,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(previewSecret(secret))
+	return signed, expiresAt, err
+}
+
+// ValidatePreviewToken validates a storefront preview token
+func ValidatePreviewToken(tokenString, secret string) (*PreviewClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &PreviewClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return previewSecret(secret), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*PreviewClaims); ok && token.Valid && claims.SellerID != "" {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid token")
+}
+
+// DownloadClaims authorize downloading the file of one digital delivery
+type DownloadClaims struct {
+	DeliveryID string `json:"deliveryId"`
+	UserID     string `json:"userId"`
+	jwt.RegisteredClaims
+}
+
+// downloadSecret derives the download signing key, kept apart from login and preview tokens
+func downloadSecret(secret string) []byte {
+	return []byte(secret + ":digital-download")
+}
+
+// GenerateDownloadToken generates a signed, expiring download token for a digital delivery
+func GenerateDownloadToken(deliveryID, userID, secret string, expiresIn time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(expiresIn)
+	claims := DownloadClaims{
+		DeliveryID: deliveryID,
+		UserID:     userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "yourapp",
+			Subject:   deliveryID,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(downloadSecret(secret))
+	return signed, expiresAt, err
+}
+
+// ValidateDownloadToken validates a digital download token
+func ValidateDownloadToken(tokenString, secret string) (*DownloadClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &DownloadClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return downloadSecret(secret), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*DownloadClaims); ok && token.Valid && claims.DeliveryID != "" {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid token")
+}
+
+// QuoteClaims lock the prices a buyer was quoted at checkout preview, keyed by product ID
+type QuoteClaims struct {
+	UserID string         `json:"userId"`
+	Prices map[string]int `json:"prices"`
+	jwt.RegisteredClaims
+}
+
+// quoteSecret derives the price quote signing key, kept apart from the other token kinds
+func quoteSecret(secret string) []byte {
+	return []byte(secret + ":price-quote")
+}
+
+// GenerateQuoteToken generates a signed, expiring price quote token
+func GenerateQuoteToken(userID string, prices map[string]int, secret string, expiresIn time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(expiresIn)
+	claims := QuoteClaims{
+		UserID: userID,
+		Prices: prices,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "yourapp",
+			Subject:   userID,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(quoteSecret(secret))
+	return signed, expiresAt, err
+}
+
+// ValidateQuoteToken validates a price quote token
+func ValidateQuoteToken(tokenString, secret string) (*QuoteClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &QuoteClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return quoteSecret(secret), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*QuoteClaims); ok && token.Valid && claims.UserID != "" && claims.IssuedAt != nil {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid token")
+}
@@ -0,0 +1,249 @@
+package util
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Page sizes in PDF points (1/72 inch)
+const (
+	PDFPageA4Width  = 595.28
+	PDFPageA4Height = 841.89
+
+	// 100 x 150 mm thermal label, the common size for courier labels
+	PDFLabelWidth  = 283.46
+	PDFLabelHeight = 425.2
+)
+
+// PDFDocument builds simple documents (packing slips, shipping labels) with text, lines and
+// grayscale images, using the built-in Helvetica fonts so no font files are embedded.
+// Coordinates are in points from the top-left corner of the page.
+type PDFDocument struct {
+	width  float64
+	height float64
+	pages  []*bytes.Buffer
+	images []pdfImage
+}
+
+type pdfImage struct {
+	width  int
+	height int
+	pixels []byte // One byte per pixel, 0 = black
+}
+
+func NewPDFDocument(width, height float64) *PDFDocument {
+	return &PDFDocument{width: width, height: height}
+}
+
+// Width returns the page width in points
+func (d *PDFDocument) Width() float64 {
+	return d.width
+}
+
+// AddPage starts a new page; drawing always goes to the last page
+func (d *PDFDocument) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *PDFDocument) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Text draws one line of text with its baseline at y. Characters outside Latin-1 are replaced.
+func (d *PDFDocument) Text(x, y, size float64, bold bool, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page(), "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, d.height-y, pdfEscape(text))
+}
+
+// WrapText draws text wrapped to maxWidth and returns the y below the last line
+func (d *PDFDocument) WrapText(x, y, size float64, bold bool, maxWidth float64, text string) float64 {
+	for _, line := range WrapPDFText(text, size, maxWidth) {
+		d.Text(x, y, size, bold, line)
+		y += size * 1.3
+	}
+	return y
+}
+
+// Line draws a straight line
+func (d *PDFDocument) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.page(), "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, d.height-y1, x2, d.height-y2)
+}
+
+// Rect draws the outline of a rectangle whose top-left corner is at x, y
+func (d *PDFDocument) Rect(x, y, w, h, lineWidth float64) {
+	fmt.Fprintf(d.page(), "%.2f w %.2f %.2f %.2f %.2f re S\n", lineWidth, x, d.height-y-h, w, h)
+}
+
+// Bitmap draws a black-and-white bitmap (true = black) scaled into the w x h box at x, y
+func (d *PDFDocument) Bitmap(bitmap [][]bool, x, y, w, h float64) {
+	if len(bitmap) == 0 || len(bitmap[0]) == 0 {
+		return
+	}
+	img := pdfImage{width: len(bitmap[0]), height: len(bitmap)}
+	img.pixels = make([]byte, 0, img.width*img.height)
+	for _, row := range bitmap {
+		for col := 0; col < img.width; col++ {
+			if col < len(row) && row[col] {
+				img.pixels = append(img.pixels, 0)
+			} else {
+				img.pixels = append(img.pixels, 255)
+			}
+		}
+	}
+	d.images = append(d.images, img)
+	fmt.Fprintf(d.page(), "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", w, h, x, d.height-y-h, len(d.images))
+}
+
+// Bytes renders the document
+func (d *PDFDocument) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write renders the document to w
+func (d *PDFDocument) Write(w io.Writer) error {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	// Object numbers: 1 catalog, 2 page tree, 3-4 fonts, then images, then a page and its
+	// content stream for every page
+	firstImage := 5
+	firstPage := firstImage + len(d.images)
+	objects := make([][]byte, 0, firstPage-1+2*len(d.pages))
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	objects = append(objects,
+		[]byte("<< /Type /Catalog /Pages 2 0 R >>"),
+		[]byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages))),
+		[]byte("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"),
+		[]byte("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>"),
+	)
+
+	xObjects := make([]string, len(d.images))
+	for i, img := range d.images {
+		data, err := deflate(img.pixels)
+		if err != nil {
+			return err
+		}
+		header := fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n",
+			img.width, img.height, len(data))
+		objects = append(objects, append(append([]byte(header), data...), []byte("\nendstream")...))
+		xObjects[i] = fmt.Sprintf("/Im%d %d 0 R", i+1, firstImage+i)
+	}
+
+	resources := "<< /Font << /F1 3 0 R /F2 4 0 R >>"
+	if len(xObjects) > 0 {
+		resources += " /XObject << " + strings.Join(xObjects, " ") + " >>"
+	}
+	resources += " >>"
+
+	for i, content := range d.pages {
+		objects = append(objects,
+			[]byte(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources %s /Contents %d 0 R >>",
+				d.width, d.height, resources, firstPage+2*i+1)),
+			[]byte(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String())),
+		)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(object)
+		out.WriteString("\nendobj\n")
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := zlib.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pdfEscape encodes text as a WinAnsi literal string body
+func pdfEscape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n' || r == '\r' || r == '\t':
+			b.WriteByte(' ')
+		case r < 0x20 || (r >= 0x7f && r < 0xa0) || r > 0xff:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
+
+// WrapPDFText splits text into lines that fit maxWidth, estimating Helvetica's average glyph
+// width as half the font size
+func WrapPDFText(text string, size, maxWidth float64) []string {
+	maxChars := int(maxWidth / (size * 0.5))
+	if maxChars < 1 {
+		maxChars = 1
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			for len([]rune(word)) > maxChars {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				runes := []rune(word)
+				lines = append(lines, string(runes[:maxChars]))
+				word = string(runes[maxChars:])
+			}
+			switch {
+			case line == "":
+				line = word
+			case len([]rune(line))+1+len([]rune(word)) <= maxChars:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -17,10 +17,11 @@ type RabbitMQClient struct {
 }
 
 type EmailMessage struct {
-	To      string `json:"to"`
-	Subject string `json:"subject"`
-	Body    string `json:"body"`
-	Type    string `json:"type"` // "otp", "reset_password", "verification"
+	To      string            `json:"to"`
+	Subject string            `json:"subject"`
+	Body    string            `json:"body"`
+	Type    string            `json:"type"`           // "otp", "reset_password", "verification", "payment_instructions", "cart_items_unavailable", "order_auto_cancelled", "abandoned_cart", "partner_usage_alert", "support_ticket_reply", "scheduled_report"
+	Data    map[string]string `json:"data,omitempty"` // Structured fields for templated emails
 }
 
 const (
@@ -0,0 +1,345 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"yourapp/internal/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisClient struct {
+	client *redis.Client
+}
+
+// PaymentStatusChannelPrefix is the pub/sub channel prefix for payment status changes
+const PaymentStatusChannelPrefix = "payment_status:"
+
+func NewRedisClient(cfg *config.Config) (*RedisClient, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
+		Password: cfg.RedisPassword,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	return &RedisClient{client: client}, nil
+}
+
+// StockKeyPrefix is the key prefix of the available-stock counters
+const StockKeyPrefix = "stock:"
+
+// ErrCounterMissing is returned by ReserveCounters when one of the counters is not loaded
+var ErrCounterMissing = errors.New("counter not loaded")
+
+// ErrCounterInsufficient is returned by ReserveCounters when a counter is below the amount requested
+var ErrCounterInsufficient = errors.New("counter insufficient")
+
+// reserveCountersScript decrements every KEYS[i] by ARGV[i] only if all of them exist and are large
+// enough, so a multi-product reservation is all or nothing
+var reserveCountersScript = redis.NewScript(`
+for i, key in ipairs(KEYS) do
+	local value = redis.call('GET', key)
+	if not value then
+		return -1
+	end
+	if tonumber(value) < tonumber(ARGV[i]) then
+		return 0
+	end
+end
+for i, key in ipairs(KEYS) do
+	redis.call('DECRBY', key, ARGV[i])
+end
+return 1
+`)
+
+// incrExistingScript increments KEYS[1] by ARGV[1] only if it exists
+var incrExistingScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return redis.call('INCRBY', KEYS[1], ARGV[1])
+end
+return 0
+`)
+
+// compareAndSetScript sets KEYS[1] to ARGV[2] if it currently holds ARGV[1]
+var compareAndSetScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[2], 'KEEPTTL')
+	return 1
+end
+return 0
+`)
+
+// seedHashScript sets the field/value pairs in ARGV on KEYS[1] only if the key does not exist, and
+// expires it after the last ARGV seconds
+var seedHashScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+for i = 1, #ARGV - 1, 2 do
+	redis.call('HSET', KEYS[1], ARGV[i], ARGV[i + 1])
+end
+redis.call('EXPIRE', KEYS[1], ARGV[#ARGV])
+return 1
+`)
+
+// takeHashScript returns all fields of KEYS[1] and deletes it, so counters handed to a rollup are
+// not counted twice
+var takeHashScript = redis.NewScript(`
+local fields = redis.call('HGETALL', KEYS[1])
+redis.call('DEL', KEYS[1])
+return fields
+`)
+
+// StockKey returns the available-stock counter key of a product
+func StockKey(productID string) string {
+	return StockKeyPrefix + productID
+}
+
+// Cart cache keys: the cart with its items by owner, the owner of each cart ID, and per product the
+// owners whose cached cart holds it
+const (
+	CartCacheKeyPrefix        = "cart:user:"
+	CartOwnerKeyPrefix        = "cart:owner:"
+	CartProductIndexKeyPrefix = "cart:product:"
+)
+
+// ProductUpdatesChannel is the pub/sub channel carrying the IDs of products whose stock or price changed
+const ProductUpdatesChannel = "product_updates"
+
+// PaymentStatusChannel returns the pub/sub channel for a single payment
+func PaymentStatusChannel(paymentID string) string {
+	return PaymentStatusChannelPrefix + paymentID
+}
+
+// PaymentResendKeyPrefix is the key prefix of the payment instruction resend cooldowns
+const PaymentResendKeyPrefix = "payment_resend:"
+
+// PaymentResendKey returns the resend cooldown key of a payment
+func PaymentResendKey(paymentID string) string {
+	return PaymentResendKeyPrefix + paymentID
+}
+
+// Publish sends a message to a pub/sub channel
+func (r *RedisClient) Publish(ctx context.Context, channel string, message string) error {
+	return r.client.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe opens a subscription to a pub/sub channel. The caller must close it.
+func (r *RedisClient) Subscribe(ctx context.Context, channel string) (*redis.PubSub, error) {
+	pubsub := r.client.Subscribe(ctx, channel)
+	// Wait for the subscription to be confirmed so no message published afterwards is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+	return pubsub, nil
+}
+
+// GetBytes returns the value stored at key, or nil if the key does not exist
+func (r *RedisClient) GetBytes(ctx context.Context, key string) ([]byte, error) {
+	value, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return value, err
+}
+
+// SetBytes stores value at key with the given expiration (0 means no expiration)
+func (r *RedisClient) SetBytes(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, key, value, ttl).Err()
+}
+
+// SetBytesIfAbsent stores value at key only if the key does not exist yet
+func (r *RedisClient) SetBytesIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.SetNX(ctx, key, value, ttl).Err()
+}
+
+// Claim sets key with the given expiration if it does not exist yet and reports whether it did
+func (r *RedisClient) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, 1, ttl).Result()
+}
+
+// Delete removes the keys; missing keys are ignored
+func (r *RedisClient) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.client.Del(ctx, keys...).Err()
+}
+
+// AddToSets adds member to the set at each key and (re)sets their expiration
+func (r *RedisClient) AddToSets(ctx context.Context, keys []string, member string, ttl time.Duration) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	pipe := r.client.Pipeline()
+	for _, key := range keys {
+		pipe.SAdd(ctx, key, member)
+		pipe.Expire(ctx, key, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// TakeSetMembers atomically reads and deletes the set at key; a missing key returns no members
+func (r *RedisClient) TakeSetMembers(ctx context.Context, key string) ([]string, error) {
+	pipe := r.client.TxPipeline()
+	members := pipe.SMembers(ctx, key)
+	pipe.Del(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	return members.Val(), nil
+}
+
+// GetInt returns the integer stored at key; ok is false if the key does not exist
+func (r *RedisClient) GetInt(ctx context.Context, key string) (value int, ok bool, err error) {
+	value, err = r.client.Get(ctx, key).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return value, true, nil
+}
+
+// SetInt stores an integer at key with the given expiration (0 means no expiration)
+func (r *RedisClient) SetInt(ctx context.Context, key string, value int, ttl time.Duration) error {
+	return r.client.Set(ctx, key, value, ttl).Err()
+}
+
+// SetIntIfAbsent stores an integer at key only if the key does not exist yet
+func (r *RedisClient) SetIntIfAbsent(ctx context.Context, key string, value int, ttl time.Duration) error {
+	return r.client.SetNX(ctx, key, value, ttl).Err()
+}
+
+// ReserveCounters atomically decrements each key by its amount, or none of them. It returns
+// ErrCounterMissing or ErrCounterInsufficient when the reservation cannot be made.
+func (r *RedisClient) ReserveCounters(ctx context.Context, keys []string, amounts []int) error {
+	args := make([]interface{}, len(amounts))
+	for i, amount := range amounts {
+		args[i] = amount
+	}
+	result, err := reserveCountersScript.Run(ctx, r.client, keys, args...).Int()
+	if err != nil {
+		return err
+	}
+	switch result {
+	case -1:
+		return ErrCounterMissing
+	case 0:
+		return ErrCounterInsufficient
+	}
+	return nil
+}
+
+// IncrCounters increments each existing key by its amount. Keys that have expired are left
+// unset so they are reloaded from the source of truth on next use.
+func (r *RedisClient) IncrCounters(ctx context.Context, keys []string, amounts []int) error {
+	for i, key := range keys {
+		if err := incrExistingScript.Run(ctx, r.client, []string{key}, amounts[i]).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScanKeys calls fn with batches of keys matching pattern until the keyspace has been walked
+func (r *RedisClient) ScanKeys(ctx context.Context, pattern string, fn func(keys []string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, pattern, 500).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// CompareAndSetInt replaces the integer at key with value only if it still holds expected, so a
+// repair does not overwrite a reservation made in between. The key keeps its expiration.
+func (r *RedisClient) CompareAndSetInt(ctx context.Context, key string, expected, value int) (bool, error) {
+	result, err := compareAndSetScript.Run(ctx, r.client, []string{key}, expected, value).Int()
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
+// IncrHash increments integer fields of the hash at key and (re)sets its expiration
+func (r *RedisClient) IncrHash(ctx context.Context, key string, fields map[string]int64, ttl time.Duration) error {
+	pipe := r.client.TxPipeline()
+	for field, amount := range fields {
+		pipe.HIncrBy(ctx, key, field, amount)
+	}
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetHashInts returns the integer fields of the hash at key; ok is false if the key does not exist
+func (r *RedisClient) GetHashInts(ctx context.Context, key string) (fields map[string]int64, ok bool, err error) {
+	values, err := r.client.HGetAll(ctx, key).Result()
+	if err != nil || len(values) == 0 {
+		return nil, false, err
+	}
+	return parseHashInts(values), true, nil
+}
+
+// SeedHashIfAbsent stores integer fields as the hash at key only if the key does not exist yet
+func (r *RedisClient) SeedHashIfAbsent(ctx context.Context, key string, fields map[string]int64, ttl time.Duration) error {
+	args := make([]interface{}, 0, 2*len(fields)+1)
+	for field, value := range fields {
+		args = append(args, field, value)
+	}
+	args = append(args, int64(ttl/time.Second))
+	return seedHashScript.Run(ctx, r.client, []string{key}, args...).Err()
+}
+
+// TakeHashInts atomically reads and deletes the hash at key; a missing key returns no fields
+func (r *RedisClient) TakeHashInts(ctx context.Context, key string) (map[string]int64, error) {
+	result, err := takeHashScript.Run(ctx, r.client, []string{key}).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(result)/2)
+	for i := 0; i+1 < len(result); i += 2 {
+		values[result[i]] = result[i+1]
+	}
+	return parseHashInts(values), nil
+}
+
+func parseHashInts(values map[string]string) map[string]int64 {
+	fields := make(map[string]int64, len(values))
+	for field, value := range values {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err == nil {
+			fields[field] = n
+		}
+	}
+	return fields
+}
+
+func (r *RedisClient) Close() error {
+	return r.client.Close()
+}
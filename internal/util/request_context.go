@@ -0,0 +1,17 @@
+package util
+
+import "context"
+
+type clientIPKey struct{}
+
+// WithClientIP stores the caller's IP address in the request context so services can use it
+// without depending on gin
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIPFromContext returns the caller's IP address, "" outside of an HTTP request
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
@@ -13,12 +13,13 @@ type Response struct {
 	Error   interface{} `json:"error,omitempty"`
 }
 
-// SuccessResponse sends a success response
+// SuccessResponse sends a success response, without the fields the response policy hides from
+// the viewer and, when the request selected fields, only those
 func SuccessResponse(c *gin.Context, statusCode int, message string, data interface{}) {
 	c.JSON(statusCode, Response{
 		Success: true,
 		Message: message,
-		Data:    data,
+		Data:    SelectResponseFields(c, ApplyResponsePolicy(c, data)),
 	})
 }
 
@@ -0,0 +1,155 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Response viewers, from least to most privileged
+const (
+	ViewerPublic = "public"
+	ViewerBuyer  = "buyer"
+	ViewerSeller = "seller"
+	ViewerAdmin  = "admin"
+)
+
+// fieldRule hides a JSON field from some viewers. Path is a dot-separated suffix of the field's key
+// path (array indexes are skipped), so "seller.user.email" matches data.products[].seller.user.email
+// and a single key like "fraud_status" matches the field anywhere in the response.
+type fieldRule struct {
+	Path       string
+	HiddenFrom []string
+}
+
+// responsePolicy is the one place that decides which fields a viewer may see; handlers return
+// full models and SuccessResponse strips the rest. Admins see everything.
+var responsePolicy = []fieldRule{
+	// Payment gateway internals and risk decisions
+	{Path: "midtrans_response", HiddenFrom: []string{ViewerPublic, ViewerBuyer, ViewerSeller}},
+	{Path: "fraud_status", HiddenFrom: []string{ViewerPublic, ViewerBuyer, ViewerSeller}},
+	{Path: "three_ds_decision", HiddenFrom: []string{ViewerPublic, ViewerBuyer, ViewerSeller}},
+	{Path: "verified_by", HiddenFrom: []string{ViewerPublic, ViewerBuyer, ViewerSeller}},
+
+	// A shop owner's personal account and tax details are not part of the storefront
+	{Path: "seller.user.email", HiddenFrom: []string{ViewerPublic, ViewerBuyer}},
+	{Path: "seller.user.phone", HiddenFrom: []string{ViewerPublic, ViewerBuyer}},
+	{Path: "seller.user.date_of_birth", HiddenFrom: []string{ViewerPublic, ViewerBuyer}},
+	{Path: "seller.user.gender", HiddenFrom: []string{ViewerPublic, ViewerBuyer}},
+	{Path: "seller.user.last_login", HiddenFrom: []string{ViewerPublic, ViewerBuyer}},
+	{Path: "seller.npwp", HiddenFrom: []string{ViewerPublic, ViewerBuyer}},
+
+	// Sellers fulfil orders with the shipping address, not the buyer's account contact details
+	{Path: "order.user.email", HiddenFrom: []string{ViewerSeller}},
+	{Path: "order.user.phone", HiddenFrom: []string{ViewerSeller}},
+	{Path: "orders.user.email", HiddenFrom: []string{ViewerSeller}},
+	{Path: "orders.user.phone", HiddenFrom: []string{ViewerSeller}},
+}
+
+// ResponseViewer classifies who a response is for: admins by role, sellers on their own shop's
+// routes and the partner API, other signed-in users as buyers
+func ResponseViewer(c *gin.Context) string {
+	if userType, _ := c.Get("userType"); userType == "admin" {
+		return ViewerAdmin
+	}
+	if _, ok := c.Get("partnerAPIKey"); ok {
+		return ViewerSeller
+	}
+	if _, ok := c.Get("userID"); !ok {
+		return ViewerPublic
+	}
+	if strings.HasPrefix(c.FullPath(), "/api/v1/sellers/me") {
+		return ViewerSeller
+	}
+	return ViewerBuyer
+}
+
+// ApplyResponsePolicy returns data without the fields the request's viewer may not see. Data that
+// holds none of them is returned as already-encoded JSON so it is not marshalled twice.
+func ApplyResponsePolicy(c *gin.Context, data interface{}) interface{} {
+	if data == nil {
+		return nil
+	}
+	viewer := ResponseViewer(c)
+	if viewer == ViewerAdmin {
+		return data
+	}
+
+	var hidden [][]string
+	for _, rule := range responsePolicy {
+		for _, v := range rule.HiddenFrom {
+			if v == viewer {
+				hidden = append(hidden, strings.Split(rule.Path, "."))
+				break
+			}
+		}
+	}
+	if len(hidden) == 0 {
+		return data
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return data // Let the response writer report the encoding error
+	}
+	candidate := false
+	for _, path := range hidden {
+		if bytes.Contains(encoded, []byte(`"`+path[len(path)-1]+`"`)) {
+			candidate = true
+			break
+		}
+	}
+	if !candidate {
+		return json.RawMessage(encoded)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return data
+	}
+	stripFields(generic, nil, hidden)
+	return generic
+}
+
+// stripFields deletes every object key whose key path ends with one of the hidden paths
+func stripFields(value interface{}, path []string, hidden [][]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := append(path[:len(path):len(path)], key)
+			if matchesHiddenPath(childPath, hidden) {
+				delete(v, key)
+				continue
+			}
+			stripFields(child, childPath, hidden)
+		}
+	case []interface{}:
+		for _, child := range v {
+			stripFields(child, path, hidden)
+		}
+	}
+}
+
+func matchesHiddenPath(path []string, hidden [][]string) bool {
+	for _, suffix := range hidden {
+		if len(suffix) > len(path) {
+			continue
+		}
+		offset := len(path) - len(suffix)
+		matched := true
+		for i, key := range suffix {
+			if path[offset+i] != key {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package util
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// TimeoutMetrics counts timeouts per operation (an API route, an outbound Midtrans call) since
+// the process started, so slow dependencies are visible before they are tuned
+type TimeoutMetrics struct {
+	mu      sync.Mutex
+	started time.Time
+	stats   map[string]*TimeoutStat
+}
+
+// TimeoutStat is how often one operation timed out
+type TimeoutStat struct {
+	Name   string    `json:"name"` // e.g. "POST /api/v1/checkout" or "midtrans.charge"
+	Count  int64     `json:"count"`
+	LastAt time.Time `json:"last_at"`
+}
+
+// TimeoutMetricsSnapshot lists the operations that timed out, most frequent first
+type TimeoutMetricsSnapshot struct {
+	Since      time.Time     `json:"since"`
+	Total      int64         `json:"total"`
+	Operations []TimeoutStat `json:"operations"`
+}
+
+func NewTimeoutMetrics() *TimeoutMetrics {
+	return &TimeoutMetrics{
+		started: time.Now(),
+		stats:   make(map[string]*TimeoutStat),
+	}
+}
+
+// Record counts one timeout of the named operation; a nil TimeoutMetrics ignores it
+func (m *TimeoutMetrics) Record(name string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stat, ok := m.stats[name]
+	if !ok {
+		stat = &TimeoutStat{Name: name}
+		m.stats[name] = stat
+	}
+	stat.Count++
+	stat.LastAt = time.Now()
+}
+
+// Snapshot returns a copy of the counters
+func (m *TimeoutMetrics) Snapshot() TimeoutMetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := TimeoutMetricsSnapshot{
+		Since:      m.started,
+		Operations: make([]TimeoutStat, 0, len(m.stats)),
+	}
+	for _, stat := range m.stats {
+		snapshot.Total += stat.Count
+		snapshot.Operations = append(snapshot.Operations, *stat)
+	}
+	sort.Slice(snapshot.Operations, func(i, j int) bool {
+		if snapshot.Operations[i].Count != snapshot.Operations[j].Count {
+			return snapshot.Operations[i].Count > snapshot.Operations[j].Count
+		}
+		return snapshot.Operations[i].Name < snapshot.Operations[j].Name
+	})
+	return snapshot
+}